@@ -0,0 +1,123 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/EdlanioJ/kbu/payments/application/kafka"
+	"github.com/spf13/cobra"
+)
+
+var (
+	dlqTopic string
+	dlqLimit int
+	dlqAll   bool
+)
+
+var dlqCmd = &cobra.Command{
+	Use:   "dlq",
+	Short: "manage the dead letter queue of failed messages",
+}
+
+var dlqListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "list dead letter messages",
+	Run: func(cmd *cobra.Command, args []string) {
+		queue, broker := newDeadLetterQueue()
+		defer broker.Close()
+
+		deadLetters, err := queue.List(dlqLimit)
+		cobra.CheckErr(err)
+
+		for _, deadLetter := range deadLetters {
+			fmt.Printf("%d/%d\t%s\t%d\t%s\t%s\n",
+				deadLetter.Partition,
+				deadLetter.Offset,
+				deadLetter.OriginalTopic,
+				deadLetter.Attempts,
+				deadLetter.ErrorType,
+				deadLetter.Error,
+			)
+		}
+	},
+}
+
+var dlqInspectCmd = &cobra.Command{
+	Use:   "inspect <partition> <offset>",
+	Short: "show a dead letter message with its error metadata",
+	Args:  cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		partition, offset := parseDeadLetterPosition(args)
+
+		queue, broker := newDeadLetterQueue()
+		defer broker.Close()
+
+		deadLetter, err := queue.Inspect(partition, offset)
+		cobra.CheckErr(err)
+
+		result, err := deadLetter.ToJson()
+		cobra.CheckErr(err)
+
+		fmt.Println(string(result))
+	},
+}
+
+var dlqRedriveCmd = &cobra.Command{
+	Use:   "redrive [<partition> <offset>]",
+	Short: "publish dead letter messages back to their original topic",
+	Args: func(cmd *cobra.Command, args []string) error {
+		if dlqAll {
+			return cobra.NoArgs(cmd, args)
+		}
+
+		return cobra.ExactArgs(2)(cmd, args)
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		queue, broker := newDeadLetterQueue()
+		defer broker.Close()
+
+		if !dlqAll {
+			partition, offset := parseDeadLetterPosition(args)
+
+			cobra.CheckErr(queue.Redrive(partition, offset))
+			fmt.Printf("redriven %d/%d\n", partition, offset)
+			return
+		}
+
+		deadLetters, err := queue.List(0)
+		cobra.CheckErr(err)
+
+		for _, deadLetter := range deadLetters {
+			cobra.CheckErr(queue.Redrive(deadLetter.Partition, deadLetter.Offset))
+		}
+
+		fmt.Printf("redriven %d messages\n", len(deadLetters))
+	},
+}
+
+func newDeadLetterQueue() (*kafka.DeadLetterQueue, kafka.Broker) {
+	broker, err := kafka.NewBroker()
+	cobra.CheckErr(err)
+
+	return kafka.NewDeadLetterQueue(dlqTopic, broker), broker
+}
+
+func parseDeadLetterPosition(args []string) (int32, int64) {
+	partition, err := strconv.ParseInt(args[0], 10, 32)
+	cobra.CheckErr(err)
+
+	offset, err := strconv.ParseInt(args[1], 10, 64)
+	cobra.CheckErr(err)
+
+	return int32(partition), offset
+}
+
+func init() {
+	rootCmd.AddCommand(dlqCmd)
+	dlqCmd.AddCommand(dlqListCmd, dlqInspectCmd, dlqRedriveCmd)
+
+	dlqCmd.PersistentFlags().StringVar(&dlqTopic, "topic", os.Getenv("KAFKA_TRANSACTION_CONFIRMATION_TOPIC"), "topic whose dead letter queue is managed")
+	dlqListCmd.Flags().IntVarP(&dlqLimit, "limit", "l", 50, "maximum number of messages to list")
+	dlqRedriveCmd.Flags().BoolVar(&dlqAll, "all", false, "redrive every message in the dead letter queue")
+}
@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/EdlanioJ/kbu/payments/application/config/gorm"
+	"github.com/EdlanioJ/kbu/payments/application/factory"
+	"github.com/EdlanioJ/kbu/payments/domain/entity"
+	"github.com/spf13/cobra"
+)
+
+var (
+	eventsAggregate string
+	eventsTruncate  bool
+)
+
+var eventsCmd = &cobra.Command{
+	Use:   "events",
+	Short: "inspect the event store and rebuild projections",
+}
+
+var eventsHistoryCmd = &cobra.Command{
+	Use:   "history <id>",
+	Short: "show every event recorded for a transaction or account",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		database := gorm.ConnectDB(os.Getenv("env"))
+		defer database.Close()
+
+		events, err := factory.EventServiceFactory(database).History(cmd.Context(), eventsAggregate, args[0])
+		cobra.CheckErr(err)
+
+		for _, event := range events {
+			fmt.Printf("%d\t%s\t%s\t%s\n",
+				event.Version,
+				event.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+				event.Type,
+				event.Payload,
+			)
+		}
+	},
+}
+
+var eventsRebuildCmd = &cobra.Command{
+	Use:   "rebuild",
+	Short: "rebuild the accounts and transactions tables from the event store",
+	Run: func(cmd *cobra.Command, args []string) {
+		database := gorm.ConnectDB(os.Getenv("env"))
+		defer database.Close()
+
+		rebuilt, err := factory.EventServiceFactory(database).Rebuild(cmd.Context(), eventsTruncate)
+		cobra.CheckErr(err)
+
+		fmt.Printf("rebuilt %d accounts and %d transactions\n", rebuilt[entity.AggregateAccount], rebuilt[entity.AggregateTransaction])
+	},
+}
+
+func init() {
+	eventsHistoryCmd.Flags().StringVarP(&eventsAggregate, "aggregate", "a", entity.AggregateTransaction, "aggregate type (transaction or account)")
+	eventsRebuildCmd.Flags().BoolVar(&eventsTruncate, "truncate", false, "delete every projection row before rebuilding, including rows without events")
+
+	eventsCmd.AddCommand(eventsHistoryCmd)
+	eventsCmd.AddCommand(eventsRebuildCmd)
+	rootCmd.AddCommand(eventsCmd)
+}
@@ -1,14 +1,38 @@
 package cmd
 
 import (
+	"context"
 	"os"
+	"time"
 
 	"github.com/EdlanioJ/kbu/payments/application/config/gorm"
+	"github.com/EdlanioJ/kbu/payments/application/factory"
 	"github.com/EdlanioJ/kbu/payments/application/grpc"
+	"github.com/EdlanioJ/kbu/payments/application/kafka"
+	"github.com/EdlanioJ/kbu/payments/application/ledger"
+	"github.com/EdlanioJ/kbu/payments/application/lifecycle"
+	"github.com/EdlanioJ/kbu/payments/application/metrics"
+	"github.com/EdlanioJ/kbu/payments/application/ratelimit"
+	"github.com/EdlanioJ/kbu/payments/application/rest"
+	"github.com/EdlanioJ/kbu/payments/application/saga"
+	"github.com/EdlanioJ/kbu/payments/application/tracing"
+	"github.com/EdlanioJ/kbu/payments/application/webhook"
+	"github.com/EdlanioJ/kbu/payments/infra/db/gorm/repository"
+	gorm_db "github.com/jinzhu/gorm"
+	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 )
 
-var portNumber int
+var (
+	metricsPort  int
+	httpPort     int
+	serverConfig = &grpc.ServerConfig{
+		TLS:       &grpc.TLSOptions{},
+		Auth:      &grpc.AuthOptions{},
+		RateLimit: &ratelimit.Config{},
+	}
+)
 
 var grpcCmd = &cobra.Command{
 	Use:   "grpc",
@@ -16,12 +40,180 @@ var grpcCmd = &cobra.Command{
 	Run: func(cmd *cobra.Command, args []string) {
 		database := gorm.ConnectDB(os.Getenv("env"))
 
-		grpc.StartGrpcServer(database, portNumber)
+		broker, err := kafka.NewBroker()
+
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		manager := newManager()
+
+		manager.Add(databaseComponent(database))
+		manager.Add(brokerComponent(broker))
+
+		if os.Getenv("BROKER") == kafka.BrokerMemory {
+			manager.Add(&lifecycle.Component{
+				Name:  "consumer",
+				Start: kafka.NewKafkaProcessor(database, broker).Consume,
+			})
+		} else {
+			// Confirmations are consumed by the kafka command, so status
+			// changes reach the watch feed through the broker.
+			factory.ShareTransactionUpdates(kafka.NewTransactionNotifier(broker))
+
+			manager.Add(&lifecycle.Component{
+				Name:  "watch feed relay",
+				Start: kafka.NewFeedRelay(broker, repository.NewTransactionRepository(database), factory.TransactionFeed()).Run,
+			})
+		}
+
+		manager.Add(lifecycle.Worker("webhook dispatcher", webhook.NewDispatcher(database).Run))
+
+		if interval, err := time.ParseDuration(os.Getenv("LEDGER_CHECKPOINT_INTERVAL")); err == nil && interval > 0 {
+			signingKey, err := ledger.LoadSigningKey(os.Getenv("LEDGER_SIGNING_KEY_FILE"))
+
+			if err != nil {
+				log.Fatal(err)
+			}
+
+			checkpointer := ledger.NewCheckpointer(factory.LedgerServiceFactory(database, signingKey), os.Getenv("LEDGER_CHECKPOINT_DIR"), interval)
+			manager.Add(lifecycle.Worker("ledger checkpointer", checkpointer.Run))
+		}
+
+		if sagaController := factory.SagaControllerFactory(database); sagaController != nil {
+			manager.Add(lifecycle.Worker("saga recoverer", saga.NewRecoverer(sagaController).Run))
+		}
+
+		if metricsPort > 0 {
+			factory.EnableMetrics(database)
+			metricsServer := metrics.NewMetricsServer(metricsPort)
+
+			manager.Add(&lifecycle.Component{
+				Name:  "metrics server",
+				Start: metricsServer.Serve,
+				Ready: metricsServer.Ready,
+				Stop:  metricsServer.Stop,
+			})
+		}
+
+		err = viper.UnmarshalKey("rate_limit", serverConfig.RateLimit)
+
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		grpcServer, err := grpc.NewGrpcServer(database, broker, serverConfig)
+
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		manager.Add(&lifecycle.Component{
+			Name:  "grpc server",
+			Start: grpcServer.Serve,
+			Ready: grpcServer.Ready,
+			Stop:  grpcServer.Stop,
+		})
+
+		if httpPort > 0 {
+			restServer, err := rest.NewRestServer(database, broker, &rest.ServerConfig{
+				Port:      httpPort,
+				TLS:       serverConfig.TLS,
+				Auth:      serverConfig.Auth,
+				RateLimit: grpcServer.RateLimit,
+			})
+
+			if err != nil {
+				log.Fatal(err)
+			}
+
+			manager.Add(&lifecycle.Component{
+				Name:  "http server",
+				Start: restServer.Serve,
+				Ready: restServer.Ready,
+				Stop:  restServer.Stop,
+			})
+		}
+
+		err = manager.Run(context.Background())
+
+		if err != nil {
+			log.Fatal(err)
+		}
 	},
 }
 
+func newManager() *lifecycle.Manager {
+	manager := lifecycle.NewManager()
+
+	if timeout, err := time.ParseDuration(os.Getenv("SHUTDOWN_TIMEOUT")); err == nil && timeout > 0 {
+		manager.ShutdownTimeout = timeout
+	}
+
+	provider, err := tracing.NewProvider(context.Background(), tracing.ConfigFromEnv())
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	manager.Add(&lifecycle.Component{
+		Name: "tracing",
+		Stop: provider.Stop,
+	})
+
+	return manager
+}
+
+func databaseComponent(database *gorm_db.DB) *lifecycle.Component {
+	return &lifecycle.Component{
+		Name:  "database",
+		Ready: database.DB().PingContext,
+		Stop:  func(context.Context) error { return database.Close() },
+	}
+}
+
+// brokerComponent closes the broker last among the components that publish,
+// which flushes the messages its producer still holds. There is no outbox to
+// flush: events are published to the broker as they happen, so a crash before
+// the flush can still lose them. Adding a transactional outbox needs its own
+// table and relay and is left out of the shutdown work on purpose.
+func brokerComponent(broker kafka.Broker) *lifecycle.Component {
+	component := &lifecycle.Component{
+		Name: "broker",
+		Stop: func(context.Context) error { return broker.Close() },
+	}
+
+	if pinger, ok := broker.(kafka.Pinger); ok {
+		component.Ready = func(ctx context.Context) error {
+			timeout := 5 * time.Second
+
+			if deadline, ok := ctx.Deadline(); ok {
+				timeout = time.Until(deadline)
+			}
+
+			return pinger.Ping(timeout)
+		}
+	}
+
+	return component
+}
+
 func init() {
 	rootCmd.AddCommand(grpcCmd)
 
-	grpcCmd.Flags().IntVarP(&portNumber, "port", "p", 50051, "grpc server port")
+	grpcCmd.Flags().IntVarP(&serverConfig.Port, "port", "p", 50051, "grpc server port")
+	grpcCmd.Flags().IntVar(&httpPort, "http-port", 8080, "rest gateway port, 0 disables it")
+	grpcCmd.Flags().IntVar(&metricsPort, "metrics-port", 9090, "prometheus metrics port, 0 disables it")
+	grpcCmd.Flags().StringVar(&serverConfig.TLS.CertFile, "tls-cert", os.Getenv("GRPC_TLS_CERT"), "server certificate file, enables tls")
+	grpcCmd.Flags().StringVar(&serverConfig.TLS.KeyFile, "tls-key", os.Getenv("GRPC_TLS_KEY"), "server private key file")
+	grpcCmd.Flags().StringVar(&serverConfig.TLS.ClientCAFile, "tls-client-ca", os.Getenv("GRPC_TLS_CLIENT_CA"), "ca bundle used to verify client certificates")
+	grpcCmd.Flags().StringVar(&serverConfig.TLS.MinVersion, "tls-min-version", "1.2", "minimum tls version (1.0, 1.1, 1.2, 1.3)")
+	grpcCmd.Flags().BoolVar(&serverConfig.TLS.RequireClientCert, "mtls", false, "require and verify client certificates")
+	grpcCmd.Flags().DurationVar(&serverConfig.TLS.ReloadInterval, "tls-reload-interval", 10*time.Second, "how often certificate files are checked for changes")
+	grpcCmd.Flags().StringVar(&serverConfig.Auth.HMACKeyFile, "auth-hmac-key", os.Getenv("AUTH_HMAC_KEY_FILE"), "file with the shared secret used to verify HS256/384/512 tokens")
+	grpcCmd.Flags().StringVar(&serverConfig.Auth.RSAPublicKeyFile, "auth-rsa-public-key", os.Getenv("AUTH_RSA_PUBLIC_KEY_FILE"), "pem public key used to verify RS256/384/512 tokens")
+	grpcCmd.Flags().StringVar(&serverConfig.Auth.APIKeysFile, "auth-api-keys", os.Getenv("AUTH_API_KEYS_FILE"), "json file with sha256 hashed api keys")
+	grpcCmd.Flags().StringVar(&serverConfig.Auth.Issuer, "auth-issuer", os.Getenv("AUTH_ISSUER"), "expected token issuer")
+	grpcCmd.Flags().StringVar(&serverConfig.Auth.Audience, "auth-audience", os.Getenv("AUTH_AUDIENCE"), "expected token audience")
+	grpcCmd.Flags().BoolVar(&serverConfig.MultiTenant, "multi-tenant", os.Getenv("MULTI_TENANT") == "true", "serve more than one tenant, requires authentication")
 }
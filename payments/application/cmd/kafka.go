@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	"context"
+	"os"
+
+	"github.com/EdlanioJ/kbu/payments/application/config/gorm"
+	"github.com/EdlanioJ/kbu/payments/application/factory"
+	"github.com/EdlanioJ/kbu/payments/application/kafka"
+	"github.com/EdlanioJ/kbu/payments/application/lifecycle"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var kafkaCmd = &cobra.Command{
+	Use:   "kafka",
+	Short: "start consuming transactions from the configured broker",
+	Run: func(cmd *cobra.Command, args []string) {
+		database := gorm.ConnectDB(os.Getenv("env"))
+
+		broker, err := kafka.NewBroker()
+
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		if os.Getenv("BROKER") != kafka.BrokerMemory {
+			factory.ShareTransactionUpdates(kafka.NewTransactionNotifier(broker))
+		}
+
+		manager := newManager()
+
+		manager.Add(databaseComponent(database))
+		manager.Add(brokerComponent(broker))
+		manager.Add(&lifecycle.Component{
+			Name:  "consumer",
+			Start: kafka.NewKafkaProcessor(database, broker).Consume,
+		})
+
+		err = manager.Run(context.Background())
+
+		if err != nil {
+			log.Fatal(err)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(kafkaCmd)
+}
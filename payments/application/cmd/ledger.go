@@ -0,0 +1,109 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/EdlanioJ/kbu/payments/application/config/gorm"
+	"github.com/EdlanioJ/kbu/payments/application/factory"
+	"github.com/EdlanioJ/kbu/payments/application/ledger"
+	"github.com/EdlanioJ/kbu/payments/domain/entity"
+	"github.com/spf13/cobra"
+)
+
+var (
+	ledgerSigningKey string
+	ledgerTrustedKey string
+	ledgerTenant     string
+	ledgerCheckpoint string
+	ledgerOutput     string
+)
+
+var ledgerCmd = &cobra.Command{
+	Use:   "ledger",
+	Short: "verify the event hash chains and export signed checkpoints",
+}
+
+var ledgerVerifyCmd = &cobra.Command{
+	Use:   "verify",
+	Short: "walk the hash chain of every tenant and report the first broken link",
+	Run: func(cmd *cobra.Command, args []string) {
+		trustedKey, err := ledger.LoadTrustedKey(ledgerTrustedKey)
+		cobra.CheckErr(err)
+
+		var checkpoint *entity.LedgerCheckpoint
+
+		if ledgerCheckpoint != "" {
+			if trustedKey == nil {
+				cobra.CheckErr(entity.ErrCheckpointNoTrustedKey)
+			}
+
+			checkpoint, err = ledger.ReadCheckpoint(ledgerCheckpoint)
+			cobra.CheckErr(err)
+		}
+
+		database := gorm.ConnectDB(os.Getenv("env"))
+		defer database.Close()
+
+		ledgerService := factory.LedgerServiceFactory(database, nil)
+		ledgerService.TrustedKey = trustedKey
+
+		broken, err := ledgerService.Verify(ledgerContext(cmd.Context()), checkpoint)
+		cobra.CheckErr(err)
+
+		if broken != nil {
+			fmt.Printf("broken link\t%s\tsequence %d\t%s\t%s\tversion %d\t%s\n", broken.Tenant, broken.Sequence, broken.AggregateType, broken.AggregateID, broken.Version, broken.Reason)
+			database.Close()
+			os.Exit(1)
+		}
+
+		fmt.Println("every hash chain is intact")
+	},
+}
+
+var ledgerCheckpointCmd = &cobra.Command{
+	Use:   "checkpoint",
+	Short: "sign the head of every hash chain and write it to a file",
+	Run: func(cmd *cobra.Command, args []string) {
+		signingKey, err := ledger.LoadSigningKey(ledgerSigningKey)
+		cobra.CheckErr(err)
+
+		database := gorm.ConnectDB(os.Getenv("env"))
+		defer database.Close()
+
+		checkpoint, err := factory.LedgerServiceFactory(database, signingKey).Checkpoint(ledgerContext(cmd.Context()))
+		cobra.CheckErr(err)
+
+		path := ledgerOutput
+
+		if path == "" {
+			path = ledger.CheckpointFile(".", checkpoint)
+		}
+
+		cobra.CheckErr(ledger.WriteCheckpoint(path, checkpoint))
+
+		fmt.Printf("checkpoint of %d chains with root %s written to %s\n", len(checkpoint.Heads), checkpoint.Root, path)
+	},
+}
+
+// ledgerContext scopes the ledger to the chains of --tenant when it is set.
+func ledgerContext(ctx context.Context) context.Context {
+	if ledgerTenant == "" {
+		return ctx
+	}
+
+	return entity.WithTenant(ctx, ledgerTenant)
+}
+
+func init() {
+	ledgerCmd.PersistentFlags().StringVar(&ledgerTenant, "tenant", "", "only verify or checkpoint the chains of this tenant")
+	ledgerCheckpointCmd.Flags().StringVar(&ledgerSigningKey, "signing-key", os.Getenv("LEDGER_SIGNING_KEY_FILE"), "pem ed25519 private key that signs checkpoints")
+	ledgerVerifyCmd.Flags().StringVar(&ledgerTrustedKey, "trusted-key", os.Getenv("LEDGER_TRUSTED_KEY_FILE"), "pem ed25519 public key that checkpoints must be signed by")
+	ledgerVerifyCmd.Flags().StringVar(&ledgerCheckpoint, "checkpoint", "", "checkpoint file whose heads must still be in the chains")
+	ledgerCheckpointCmd.Flags().StringVarP(&ledgerOutput, "output", "o", "", "checkpoint file, defaults to checkpoint-<time>.json in the working directory")
+
+	ledgerCmd.AddCommand(ledgerVerifyCmd)
+	ledgerCmd.AddCommand(ledgerCheckpointCmd)
+	rootCmd.AddCommand(ledgerCmd)
+}
@@ -0,0 +1,135 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/EdlanioJ/kbu/payments/application/config/gorm"
+	"github.com/EdlanioJ/kbu/payments/infra/db/gorm/migration"
+	"github.com/spf13/cobra"
+)
+
+var (
+	migrateUpSteps   int
+	migrateDownSteps int
+	migrateAll       bool
+	migrateDir       string
+)
+
+var migrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "apply, revert and create the versioned sql migrations of the database",
+}
+
+var migrateUpCmd = &cobra.Command{
+	Use:   "up",
+	Short: "apply every pending migration, or the next --steps of them",
+	Run: func(cmd *cobra.Command, args []string) {
+		database := gorm.Open(os.Getenv("env"))
+		defer database.Close()
+
+		migrator, err := migration.NewMigrator(database)
+		cobra.CheckErr(err)
+
+		applied, err := migrator.Up(migrateUpSteps)
+
+		for _, m := range applied {
+			fmt.Printf("applied\t%04d_%s\n", m.Version, m.Name)
+		}
+
+		cobra.CheckErr(err)
+
+		if len(applied) == 0 {
+			fmt.Println("the database is up to date")
+		}
+	},
+}
+
+var migrateDownCmd = &cobra.Command{
+	Use:   "down",
+	Short: "revert the latest migration, or the latest --steps of them",
+	Run: func(cmd *cobra.Command, args []string) {
+		database := gorm.Open(os.Getenv("env"))
+		defer database.Close()
+
+		migrator, err := migration.NewMigrator(database)
+		cobra.CheckErr(err)
+
+		steps := migrateDownSteps
+
+		if migrateAll {
+			steps = 0
+		} else if steps <= 0 {
+			steps = 1
+		}
+
+		reverted, err := migrator.Down(steps)
+
+		for _, m := range reverted {
+			fmt.Printf("reverted\t%04d_%s\n", m.Version, m.Name)
+		}
+
+		cobra.CheckErr(err)
+
+		if len(reverted) == 0 {
+			fmt.Println("no migration is applied")
+		}
+	},
+}
+
+var migrateStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "list the migrations and when each one was applied",
+	Run: func(cmd *cobra.Command, args []string) {
+		database := gorm.Open(os.Getenv("env"))
+		defer database.Close()
+
+		migrator, err := migration.NewMigrator(database)
+		cobra.CheckErr(err)
+
+		statuses, err := migrator.Status()
+		cobra.CheckErr(err)
+
+		for _, status := range statuses {
+			state := "pending"
+
+			if status.Applied {
+				state = "applied " + status.AppliedAt.Format("2006-01-02T15:04:05Z07:00")
+			}
+
+			if status.Unknown {
+				state += ", unknown to this build"
+			}
+
+			fmt.Printf("%04d_%s\t%s\n", status.Version, status.Name, state)
+		}
+	},
+}
+
+var migrateCreateCmd = &cobra.Command{
+	Use:   "create <name>",
+	Short: "write empty up and down files of a new migration for every dialect",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		files, err := migration.Create(migrateDir, args[0])
+
+		for _, file := range files {
+			fmt.Println(file)
+		}
+
+		cobra.CheckErr(err)
+	},
+}
+
+func init() {
+	migrateUpCmd.Flags().IntVarP(&migrateUpSteps, "steps", "n", 0, "number of migrations to apply, every pending one when 0")
+	migrateDownCmd.Flags().IntVarP(&migrateDownSteps, "steps", "n", 1, "number of migrations to revert")
+	migrateDownCmd.Flags().BoolVar(&migrateAll, "all", false, "revert every applied migration")
+	migrateCreateCmd.Flags().StringVar(&migrateDir, "dir", "infra/db/gorm/migration/migrations", "directory holding the migrations of each dialect")
+
+	migrateCmd.AddCommand(migrateUpCmd)
+	migrateCmd.AddCommand(migrateDownCmd)
+	migrateCmd.AddCommand(migrateStatusCmd)
+	migrateCmd.AddCommand(migrateCreateCmd)
+	rootCmd.AddCommand(migrateCmd)
+}
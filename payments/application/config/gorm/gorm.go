@@ -6,7 +6,8 @@ import (
 	"path/filepath"
 	"runtime"
 
-	"github.com/EdlanioJ/kbu/payments/domain/entity"
+	"github.com/EdlanioJ/kbu/payments/infra/db/gorm/migration"
+	"github.com/EdlanioJ/kbu/payments/infra/db/gorm/repository"
 	"github.com/jinzhu/gorm"
 	"github.com/joho/godotenv"
 	_ "github.com/lib/pq"
@@ -25,7 +26,8 @@ func init() {
 	}
 }
 
-func ConnectDB(env string) *gorm.DB {
+// Open connects to the database of env without touching its schema.
+func Open(env string) *gorm.DB {
 	var dns string
 	var db *gorm.DB
 	var err error
@@ -46,8 +48,27 @@ func ConnectDB(env string) *gorm.DB {
 		db.LogMode(true)
 	}
 
+	repository.RegisterTracing(db)
+	repository.RegisterTenancy(db)
+
+	return db
+}
+
+func ConnectDB(env string) *gorm.DB {
+	db := Open(env)
+
 	if os.Getenv("AUTO_MIGRATE_DB") == "true" {
-		db.AutoMigrate(&entity.Account{}, &entity.Transaction{})
+		migrator, err := migration.NewMigrator(db)
+
+		if err != nil {
+			log.Fatalf("Error loading migrations: %v", err)
+		}
+
+		_, err = migrator.Up(0)
+
+		if err != nil {
+			log.Fatalf("Error migrating database: %v", err)
+		}
 	}
 
 	return db
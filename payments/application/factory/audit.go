@@ -0,0 +1,15 @@
+package factory
+
+import (
+	"github.com/EdlanioJ/kbu/payments/data/service"
+	"github.com/EdlanioJ/kbu/payments/infra/db/gorm/repository"
+	"github.com/EdlanioJ/kbu/payments/presentation/controller"
+	"github.com/jinzhu/gorm"
+)
+
+func AuditControllerFactory(database *gorm.DB) *controller.Audit {
+	auditRepo := repository.NewAuditRepository(database)
+	auditService := service.NewAudit(auditRepo)
+
+	return controller.NewAudit(auditService)
+}
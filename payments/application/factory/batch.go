@@ -0,0 +1,22 @@
+package factory
+
+import (
+	"github.com/EdlanioJ/kbu/payments/data/service"
+	"github.com/EdlanioJ/kbu/payments/infra/db/gorm/repository"
+	"github.com/EdlanioJ/kbu/payments/presentation/controller"
+	"github.com/jinzhu/gorm"
+)
+
+func BatchControllerFactory(database *gorm.DB) *controller.Batch {
+	batchRepo := repository.NewBatchRepository(database)
+	transactionRepo := repository.NewTransactionRepository(database)
+	accountRepo := repository.NewAccountRepository(database)
+	unitOfWork := repository.NewUnitOfWork(database)
+
+	batchService := service.NewBatch(batchRepo, transactionRepo, accountRepo, unitOfWork)
+	batchService.Notifier = transactionNotifier
+	batchService.Metrics = transactionMetrics
+	batchService.Saga = sagaServiceFactory(database)
+
+	return controller.NewBatch(batchService)
+}
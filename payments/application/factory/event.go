@@ -0,0 +1,13 @@
+package factory
+
+import (
+	"github.com/EdlanioJ/kbu/payments/data/service"
+	"github.com/EdlanioJ/kbu/payments/infra/db/gorm/repository"
+	"github.com/jinzhu/gorm"
+)
+
+func EventServiceFactory(database *gorm.DB) *service.Event {
+	eventRepo := repository.NewEventRepository(database)
+
+	return service.NewEvent(eventRepo)
+}
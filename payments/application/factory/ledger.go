@@ -0,0 +1,18 @@
+package factory
+
+import (
+	"crypto/ed25519"
+
+	"github.com/EdlanioJ/kbu/payments/data/service"
+	"github.com/EdlanioJ/kbu/payments/infra/db/gorm/repository"
+	"github.com/jinzhu/gorm"
+)
+
+func LedgerServiceFactory(database *gorm.DB, signingKey ed25519.PrivateKey) *service.Ledger {
+	eventRepo := repository.NewEventRepository(database)
+
+	ledgerService := service.NewLedger(eventRepo)
+	ledgerService.SigningKey = signingKey
+
+	return ledgerService
+}
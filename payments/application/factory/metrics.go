@@ -0,0 +1,18 @@
+package factory
+
+import (
+	"github.com/EdlanioJ/kbu/payments/data/protocol"
+	"github.com/EdlanioJ/kbu/payments/infra/db/gorm/repository"
+	"github.com/EdlanioJ/kbu/payments/infra/metrics"
+	"github.com/jinzhu/gorm"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var transactionMetrics protocol.TransactionMetrics
+
+func EnableMetrics(database *gorm.DB) {
+	metrics.InstrumentDB(database, prometheus.DefaultRegisterer)
+	prometheus.MustRegister(metrics.NewTransactionCollector(repository.NewTransactionRepository(database)))
+
+	transactionMetrics = metrics.NewPrometheus(prometheus.DefaultRegisterer)
+}
@@ -0,0 +1,50 @@
+package factory
+
+import (
+	"os"
+	"time"
+
+	"github.com/EdlanioJ/kbu/payments/data/service"
+	"github.com/EdlanioJ/kbu/payments/infra/db/gorm/repository"
+	"github.com/EdlanioJ/kbu/payments/infra/http"
+	"github.com/EdlanioJ/kbu/payments/presentation/controller"
+	"github.com/jinzhu/gorm"
+)
+
+func SagaControllerFactory(database *gorm.DB) *controller.Saga {
+	sagaService := sagaServiceFactory(database)
+
+	if sagaService == nil {
+		return nil
+	}
+
+	return controller.NewSaga(sagaService)
+}
+
+func sagaServiceFactory(database *gorm.DB) *service.Saga {
+	providerURL := os.Getenv("SERVICE_PROVIDER_URL")
+
+	if providerURL == "" {
+		return nil
+	}
+
+	timeout := 30 * time.Second
+
+	if value, err := time.ParseDuration(os.Getenv("SAGA_TIMEOUT")); err == nil && value > 0 {
+		timeout = value
+	}
+
+	sagaRepo := repository.NewSagaRepository(database)
+	transactionRepo := repository.NewTransactionRepository(database)
+	accountRepo := repository.NewAccountRepository(database)
+	unitOfWork := repository.NewUnitOfWork(database)
+	provider := http.NewServiceProvider(providerURL, timeout)
+
+	sagaService := service.NewSaga(sagaRepo, transactionRepo, accountRepo, unitOfWork, provider)
+	sagaService.Notifier = transactionNotifier
+	sagaService.Timeout = timeout
+	sagaService.StaleAfter = 2 * timeout
+	sagaService.LeaseFor = 4 * timeout
+
+	return sagaService
+}
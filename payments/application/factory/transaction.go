@@ -1,16 +1,42 @@
 package factory
 
 import (
+	"github.com/EdlanioJ/kbu/payments/data/protocol"
 	"github.com/EdlanioJ/kbu/payments/data/service"
 	"github.com/EdlanioJ/kbu/payments/infra/db/gorm/repository"
 	"github.com/EdlanioJ/kbu/payments/presentation/controller"
 	"github.com/jinzhu/gorm"
 )
 
+var transactionFeed = service.NewTransactionFeed()
+
+// transactionNotifier receives every status change made by this process. It
+// is the in-process watch feed unless ShareTransactionUpdates is called.
+var transactionNotifier protocol.TransactionNotifier = transactionFeed
+
+// ShareTransactionUpdates sends the status changes to notifier instead of the
+// in-process feed, for deployments where payments change in a process other
+// than the one serving Watch. The feed is then filled from the broker.
+func ShareTransactionUpdates(notifier protocol.TransactionNotifier) {
+	transactionNotifier = notifier
+}
+
+func TransactionFeed() protocol.TransactionNotifier {
+	return transactionFeed
+}
+
 func TransactionControllerFactory(database *gorm.DB) *controller.Transaction {
 	transactionRepo := repository.NewTransactionRepository(database)
 	accountRepo := repository.NewAccountRepository(database)
 	transactionService := service.NewTransaction(transactionRepo, accountRepo)
+	transactionService.Notifier = transactionNotifier
+	transactionService.Metrics = transactionMetrics
+	transactionService.Confirmations = repository.NewConfirmationUnitOfWork(database)
+	transactionService.Webhooks = webhookServiceFactory(database)
 
 	return controller.NewTransaction(transactionService)
 }
+
+func WatchControllerFactory() *controller.Watch {
+	return controller.NewWatch(transactionFeed)
+}
@@ -0,0 +1,34 @@
+package factory
+
+import (
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/EdlanioJ/kbu/payments/data/service"
+	"github.com/EdlanioJ/kbu/payments/infra/db/gorm/repository"
+	"github.com/EdlanioJ/kbu/payments/infra/http"
+	"github.com/EdlanioJ/kbu/payments/presentation/controller"
+	"github.com/jinzhu/gorm"
+)
+
+func WebhookControllerFactory(database *gorm.DB) *controller.Webhook {
+	return controller.NewWebhook(webhookServiceFactory(database))
+}
+
+func webhookServiceFactory(database *gorm.DB) *service.Webhook {
+	webhookRepo := repository.NewWebhookRepository(database)
+	deliveryRepo := repository.NewWebhookDeliveryRepository(database)
+	sender := http.NewWebhookSender(10 * time.Second)
+	webhookService := service.NewWebhook(webhookRepo, deliveryRepo, sender)
+
+	if value, err := strconv.Atoi(os.Getenv("WEBHOOK_MAX_ATTEMPTS")); err == nil && value > 0 {
+		webhookService.MaxAttempts = value
+	}
+
+	if value, err := time.ParseDuration(os.Getenv("WEBHOOK_RETRY_BACKOFF")); err == nil && value > 0 {
+		webhookService.InitialBackoff = value
+	}
+
+	return webhookService
+}
@@ -0,0 +1,79 @@
+package grpc
+
+import (
+	"context"
+	"time"
+
+	"github.com/EdlanioJ/kbu/payments/application/grpc/pb"
+	"github.com/EdlanioJ/kbu/payments/domain/entity"
+	"github.com/EdlanioJ/kbu/payments/presentation/controller"
+	"google.golang.org/grpc/codes"
+)
+
+type AuditGrpcHandler struct {
+	AuditController *controller.Audit
+
+	pb.UnimplementedAuditServiceServer
+}
+
+func NewAuditGrpcHandler(
+	audit *controller.Audit,
+) *AuditGrpcHandler {
+
+	return &AuditGrpcHandler{
+		AuditController: audit,
+	}
+}
+
+func (a *AuditGrpcHandler) ListAuditEntries(ctx context.Context, in *pb.ListAuditEntriesRequest) (*pb.ListAuditEntriesResponse, error) {
+	filter := &entity.AuditFilter{
+		Actor:      in.Actor,
+		Action:     in.Action,
+		EntityType: in.EntityType,
+		EntityID:   in.EntityID,
+	}
+
+	var err error
+
+	if in.From != "" {
+		filter.From, err = time.Parse(time.RFC3339, in.From)
+
+		if err != nil {
+			return nil, toStatus(err, codes.InvalidArgument)
+		}
+	}
+
+	if in.To != "" {
+		filter.To, err = time.Parse(time.RFC3339, in.To)
+
+		if err != nil {
+			return nil, toStatus(err, codes.InvalidArgument)
+		}
+	}
+
+	entries, total, err := a.AuditController.List(ctx, filter, int(in.Page), int(in.Limit))
+
+	if err != nil {
+		return nil, toStatus(err, codes.Internal)
+	}
+
+	response := &pb.ListAuditEntriesResponse{
+		Total: int32(total),
+	}
+
+	for _, entry := range entries {
+		response.Entries = append(response.Entries, &pb.AuditEntry{
+			ID:         entry.ID,
+			Actor:      entry.Actor,
+			Action:     entry.Action,
+			EntityType: entry.EntityType,
+			EntityID:   entry.EntityID,
+			Before:     entry.Before,
+			After:      entry.After,
+			RequestID:  entry.RequestID,
+			CreatedAt:  entry.CreatedAt.Format(time.RFC3339Nano),
+		})
+	}
+
+	return response, nil
+}
@@ -0,0 +1,264 @@
+package grpc
+
+import (
+	"context"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/EdlanioJ/kbu/payments/domain/entity"
+	"github.com/EdlanioJ/kbu/payments/presentation/controller"
+	"github.com/golang-jwt/jwt"
+	grpc_middleware "github.com/grpc-ecosystem/go-grpc-middleware"
+	grpc_ctxtags "github.com/grpc-ecosystem/go-grpc-middleware/tags"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+)
+
+const (
+	authorizationHeader = "authorization"
+	apiKeyHeader        = "x-api-key"
+)
+
+var (
+	errMissingCredentials = entity.Unauthenticated("missing credentials")
+	errInvalidToken       = entity.Unauthenticated("invalid token")
+	errInvalidAPIKey      = entity.Unauthenticated("invalid api key")
+	errNoVerificationKey  = errors.New("authentication requires an hmac key, an rsa public key or api keys")
+	errTenancyWithoutAuth = errors.New("multi-tenancy requires authentication, every caller would share the default tenant")
+)
+
+var publicMethods = []string{
+	"/grpc.health.v1.Health/",
+	"/grpc.reflection.v1alpha.ServerReflection/",
+}
+
+type AuthOptions struct {
+	HMACKeyFile      string
+	RSAPublicKeyFile string
+	APIKeysFile      string
+	Issuer           string
+	Audience         string
+}
+
+func (o *AuthOptions) Enabled() bool {
+	return o != nil && (o.HMACKeyFile != "" || o.RSAPublicKeyFile != "" || o.APIKeysFile != "")
+}
+
+type apiKey struct {
+	KeySHA256 string   `json:"key_sha256"`
+	Subject   string   `json:"subject"`
+	Roles     []string `json:"roles"`
+	Accounts  []string `json:"accounts"`
+	Tenant    string   `json:"tenant"`
+}
+
+type tokenClaims struct {
+	Roles    []string `json:"roles"`
+	Accounts []string `json:"accounts"`
+	Tenant   string   `json:"tenant"`
+	jwt.StandardClaims
+}
+
+type Authenticator struct {
+	Issuer   string
+	Audience string
+	hmacKey  []byte
+	rsaKey   *rsa.PublicKey
+	apiKeys  []*apiKey
+}
+
+func NewAuthenticator(options *AuthOptions) (*Authenticator, error) {
+	if !options.Enabled() {
+		return nil, errNoVerificationKey
+	}
+
+	authenticator := &Authenticator{
+		Issuer:   options.Issuer,
+		Audience: options.Audience,
+	}
+
+	if options.HMACKeyFile != "" {
+		key, err := ioutil.ReadFile(options.HMACKeyFile)
+
+		if err != nil {
+			return nil, err
+		}
+
+		authenticator.hmacKey = []byte(strings.TrimSpace(string(key)))
+	}
+
+	if options.RSAPublicKeyFile != "" {
+		pem, err := ioutil.ReadFile(options.RSAPublicKeyFile)
+
+		if err != nil {
+			return nil, err
+		}
+
+		authenticator.rsaKey, err = jwt.ParseRSAPublicKeyFromPEM(pem)
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if options.APIKeysFile != "" {
+		content, err := ioutil.ReadFile(options.APIKeysFile)
+
+		if err != nil {
+			return nil, err
+		}
+
+		err = json.Unmarshal(content, &authenticator.apiKeys)
+
+		if err != nil {
+			return nil, fmt.Errorf("invalid api keys file: %w", err)
+		}
+	}
+
+	return authenticator, nil
+}
+
+func (a *Authenticator) Authenticate(ctx context.Context) (*entity.Principal, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	if values := md.Get(apiKeyHeader); len(values) > 0 {
+		return a.authenticateAPIKey(values[0])
+	}
+
+	if values := md.Get(authorizationHeader); len(values) > 0 {
+		token := strings.TrimSpace(values[0])
+
+		if len(token) < 7 || !strings.EqualFold(token[:7], "bearer ") {
+			return nil, errInvalidToken
+		}
+
+		return a.authenticateToken(strings.TrimSpace(token[7:]))
+	}
+
+	return nil, errMissingCredentials
+}
+
+func (a *Authenticator) authenticateAPIKey(key string) (*entity.Principal, error) {
+	sum := sha256.Sum256([]byte(key))
+	hash := hex.EncodeToString(sum[:])
+
+	for _, candidate := range a.apiKeys {
+		if subtle.ConstantTimeCompare([]byte(strings.ToLower(candidate.KeySHA256)), []byte(hash)) == 1 {
+			return &entity.Principal{
+				Subject:  candidate.Subject,
+				Roles:    candidate.Roles,
+				Accounts: candidate.Accounts,
+				Tenant:   candidate.Tenant,
+			}, nil
+		}
+	}
+
+	return nil, errInvalidAPIKey
+}
+
+func (a *Authenticator) authenticateToken(raw string) (*entity.Principal, error) {
+	claims := &tokenClaims{}
+
+	token, err := jwt.ParseWithClaims(raw, claims, a.verificationKey)
+
+	if err != nil || !token.Valid {
+		return nil, errInvalidToken
+	}
+
+	if a.Issuer != "" && !claims.VerifyIssuer(a.Issuer, true) {
+		return nil, errInvalidToken
+	}
+
+	if a.Audience != "" && !claims.VerifyAudience(a.Audience, true) {
+		return nil, errInvalidToken
+	}
+
+	if claims.Subject == "" {
+		return nil, errInvalidToken
+	}
+
+	return &entity.Principal{
+		Subject:  claims.Subject,
+		Roles:    claims.Roles,
+		Accounts: claims.Accounts,
+		Tenant:   claims.Tenant,
+	}, nil
+}
+
+func (a *Authenticator) verificationKey(token *jwt.Token) (interface{}, error) {
+	switch token.Method.(type) {
+	case *jwt.SigningMethodHMAC:
+		if a.hmacKey != nil {
+			return a.hmacKey, nil
+		}
+	case *jwt.SigningMethodRSA:
+		if a.rsaKey != nil {
+			return a.rsaKey, nil
+		}
+	}
+
+	return nil, fmt.Errorf("unexpected signing method %v", token.Header["alg"])
+}
+
+func (a *Authenticator) authorize(ctx context.Context, method string) (context.Context, error) {
+	for _, prefix := range publicMethods {
+		if strings.HasPrefix(method, prefix) {
+			return ctx, nil
+		}
+	}
+
+	principal, err := a.Authenticate(ctx)
+
+	if err != nil {
+		return nil, toStatus(err, codes.Unauthenticated)
+	}
+
+	grpc_ctxtags.Extract(ctx).Set("auth.subject", principal.Subject)
+
+	return controller.WithPrincipal(ctx, principal), nil
+}
+
+func (a *Authenticator) UnaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	ctx, err := a.authorize(ctx, info.FullMethod)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return handler(ctx, req)
+}
+
+func (a *Authenticator) StreamInterceptor(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, err := a.authorize(stream.Context(), info.FullMethod)
+
+	if err != nil {
+		return err
+	}
+
+	wrapped := grpc_middleware.WrapServerStream(stream)
+	wrapped.WrappedContext = ctx
+
+	return handler(srv, wrapped)
+}
+
+// anonymousUnaryInterceptor stands in for the authenticator while
+// authentication is off, every call is made by the anonymous principal of the
+// default tenant.
+func anonymousUnaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	return handler(controller.WithPrincipal(ctx, entity.AnonymousPrincipal()), req)
+}
+
+func anonymousStreamInterceptor(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	wrapped := grpc_middleware.WrapServerStream(stream)
+	wrapped.WrappedContext = controller.WithPrincipal(stream.Context(), entity.AnonymousPrincipal())
+
+	return handler(srv, wrapped)
+}
@@ -0,0 +1,213 @@
+package grpc_test
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	grpc_handler "github.com/EdlanioJ/kbu/payments/application/grpc"
+	"github.com/EdlanioJ/kbu/payments/domain/entity"
+	"github.com/EdlanioJ/kbu/payments/presentation/controller"
+	"github.com/golang-jwt/jwt"
+	grpc_middleware "github.com/grpc-ecosystem/go-grpc-middleware"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+type authFixture struct {
+	hmacKey []byte
+	rsaKey  *rsa.PrivateKey
+	apiKey  string
+	options *grpc_handler.AuthOptions
+}
+
+func newAuthFixture(t *testing.T) *authFixture {
+	dir, err := ioutil.TempDir("", "kbu-auth")
+	require.Nil(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.Nil(t, err)
+
+	publicKey, err := x509.MarshalPKIXPublicKey(&rsaKey.PublicKey)
+	require.Nil(t, err)
+
+	fixture := &authFixture{
+		hmacKey: []byte("a-shared-secret-for-tests"),
+		rsaKey:  rsaKey,
+		apiKey:  "kbu_test_api_key",
+		options: &grpc_handler.AuthOptions{
+			HMACKeyFile:      filepath.Join(dir, "hmac.key"),
+			RSAPublicKeyFile: filepath.Join(dir, "rsa.pub"),
+			APIKeysFile:      filepath.Join(dir, "api-keys.json"),
+			Issuer:           "kbu-auth",
+		},
+	}
+
+	sum := sha256.Sum256([]byte(fixture.apiKey))
+	apiKeys := `[{"key_sha256": "` + hex.EncodeToString(sum[:]) + `", "subject": "billing", "roles": ["service"]}]`
+
+	require.Nil(t, ioutil.WriteFile(fixture.options.HMACKeyFile, append(fixture.hmacKey, '\n'), 0600))
+	require.Nil(t, ioutil.WriteFile(fixture.options.RSAPublicKeyFile, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicKey}), 0600))
+	require.Nil(t, ioutil.WriteFile(fixture.options.APIKeysFile, []byte(apiKeys), 0600))
+
+	return fixture
+}
+
+func (f *authFixture) token(t *testing.T, method jwt.SigningMethod, key interface{}, claims jwt.MapClaims) string {
+	if _, ok := claims["iss"]; !ok {
+		claims["iss"] = "kbu-auth"
+	}
+
+	if _, ok := claims["exp"]; !ok {
+		claims["exp"] = time.Now().Add(time.Minute).Unix()
+	}
+
+	signed, err := jwt.NewWithClaims(method, claims).SignedString(key)
+	require.Nil(t, err)
+
+	return signed
+}
+
+func authenticate(t *testing.T, authenticator *grpc_handler.Authenticator, method string, md metadata.MD) (*entity.Principal, error) {
+	chain := grpc_middleware.ChainUnaryServer(authenticator.UnaryInterceptor)
+	ctx := metadata.NewIncomingContext(context.Background(), md)
+
+	var principal *entity.Principal
+
+	_, err := chain(ctx, nil, &grpc.UnaryServerInfo{FullMethod: method}, func(ctx context.Context, req interface{}) (interface{}, error) {
+		principal, _ = controller.PrincipalFromContext(ctx)
+		return nil, nil
+	})
+
+	return principal, err
+}
+
+func TestAuthenticator(t *testing.T) {
+	t.Parallel()
+
+	const method = "/github.com.edlanioj.kbu.payments.PaymentService/Register"
+
+	fixture := newAuthFixture(t)
+	authenticator, err := grpc_handler.NewAuthenticator(fixture.options)
+	require.Nil(t, err)
+
+	t.Run("should accept hmac tokens and carry claims", func(t *testing.T) {
+		is := require.New(t)
+
+		token := fixture.token(t, jwt.SigningMethodHS256, fixture.hmacKey, jwt.MapClaims{
+			"sub":      "customer-1",
+			"roles":    []string{entity.RoleCustomer},
+			"accounts": []string{"account-1"},
+			"tenant":   "acme",
+		})
+
+		principal, err := authenticate(t, authenticator, method, metadata.Pairs("authorization", "Bearer "+token))
+
+		is.Nil(err)
+		is.Equal("customer-1", principal.Subject)
+		is.Equal([]string{"account-1"}, principal.Accounts)
+		is.Equal("acme", principal.Tenant)
+		is.False(principal.Privileged())
+	})
+
+	t.Run("should accept rsa tokens", func(t *testing.T) {
+		is := require.New(t)
+
+		token := fixture.token(t, jwt.SigningMethodRS256, fixture.rsaKey, jwt.MapClaims{
+			"sub":   "ops",
+			"roles": []string{entity.RoleAdmin},
+		})
+
+		principal, err := authenticate(t, authenticator, method, metadata.Pairs("authorization", "Bearer "+token))
+
+		is.Nil(err)
+		is.True(principal.Privileged())
+	})
+
+	t.Run("should accept api keys", func(t *testing.T) {
+		is := require.New(t)
+
+		principal, err := authenticate(t, authenticator, method, metadata.Pairs("x-api-key", fixture.apiKey))
+
+		is.Nil(err)
+		is.Equal("billing", principal.Subject)
+		is.True(principal.HasRole(entity.RoleService))
+	})
+
+	t.Run("should scope calls to the tenant of the caller", func(t *testing.T) {
+		is := require.New(t)
+
+		token := fixture.token(t, jwt.SigningMethodHS256, fixture.hmacKey, jwt.MapClaims{
+			"sub":    "customer-1",
+			"tenant": "acme",
+		})
+
+		for tenant, md := range map[string]metadata.MD{
+			"acme":               metadata.Pairs("authorization", "Bearer "+token),
+			entity.DefaultTenant: metadata.Pairs("x-api-key", fixture.apiKey),
+		} {
+			var scoped string
+
+			chain := grpc_middleware.ChainUnaryServer(authenticator.UnaryInterceptor)
+			_, err := chain(metadata.NewIncomingContext(context.Background(), md), nil, &grpc.UnaryServerInfo{FullMethod: method}, func(ctx context.Context, req interface{}) (interface{}, error) {
+				scoped, _ = entity.Tenant(ctx)
+				return nil, nil
+			})
+
+			is.Nil(err)
+			is.Equal(tenant, scoped)
+		}
+	})
+
+	t.Run("should reject invalid credentials", func(t *testing.T) {
+		is := require.New(t)
+
+		expired := fixture.token(t, jwt.SigningMethodHS256, fixture.hmacKey, jwt.MapClaims{
+			"sub": "customer-1",
+			"exp": time.Now().Add(-time.Minute).Unix(),
+		})
+		wrongIssuer := fixture.token(t, jwt.SigningMethodHS256, fixture.hmacKey, jwt.MapClaims{
+			"sub": "customer-1",
+			"iss": "someone-else",
+		})
+		wrongKey := fixture.token(t, jwt.SigningMethodHS256, []byte("another-secret"), jwt.MapClaims{
+			"sub": "customer-1",
+		})
+
+		for _, md := range []metadata.MD{
+			metadata.Pairs(),
+			metadata.Pairs("authorization", "Bearer "+expired),
+			metadata.Pairs("authorization", "Bearer "+wrongIssuer),
+			metadata.Pairs("authorization", "Bearer "+wrongKey),
+			metadata.Pairs("authorization", "Basic dXNlcjpwYXNz"),
+			metadata.Pairs("x-api-key", "unknown"),
+		} {
+			principal, err := authenticate(t, authenticator, method, md)
+
+			is.Nil(principal)
+			is.Equal(codes.Unauthenticated, status.Code(err))
+		}
+	})
+
+	t.Run("should not authenticate health checks", func(t *testing.T) {
+		is := require.New(t)
+
+		principal, err := authenticate(t, authenticator, "/grpc.health.v1.Health/Check", metadata.Pairs())
+
+		is.Nil(err)
+		is.Nil(principal)
+	})
+}
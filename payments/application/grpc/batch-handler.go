@@ -0,0 +1,122 @@
+package grpc
+
+import (
+	"context"
+	"io"
+
+	"github.com/EdlanioJ/kbu/payments/application/grpc/pb"
+	"github.com/EdlanioJ/kbu/payments/domain/entity"
+	"github.com/EdlanioJ/kbu/payments/presentation/validator"
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (t *TransactionGrpcHandler) RegisterBatch(ctx context.Context, in *pb.RegisterBatchRequest) (*pb.BatchResponse, error) {
+	items := make([]*entity.BatchItem, 0, len(in.Items))
+
+	for position, item := range in.Items {
+		items = append(items, toBatchItem(position, item))
+	}
+
+	return t.registerBatch(ctx, in.Mode.String(), items)
+}
+
+func (t *TransactionGrpcHandler) RegisterBatchStream(stream pb.PaymentService_RegisterBatchStreamServer) error {
+	var mode string
+	var items []*entity.BatchItem
+
+	for {
+		in, err := stream.Recv()
+
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if len(items) == 0 {
+			mode = in.Mode.String()
+		}
+
+		if len(items) == validator.MaxBatchItems {
+			return status.Errorf(codes.InvalidArgument, "a batch accepts at most %d items", validator.MaxBatchItems)
+		}
+
+		items = append(items, toBatchItem(len(items), in.Item))
+	}
+
+	response, err := t.registerBatch(stream.Context(), mode, items)
+
+	if err != nil {
+		return err
+	}
+
+	return stream.SendAndClose(response)
+}
+
+func (t *TransactionGrpcHandler) GetBatch(ctx context.Context, in *pb.Request) (*pb.BatchResponse, error) {
+	batch, items, err := t.BatchController.Get(ctx, in.ID)
+
+	if err != nil {
+		return nil, toStatus(err, codes.NotFound)
+	}
+
+	return toPbBatch(batch, items), nil
+}
+
+func (t *TransactionGrpcHandler) registerBatch(ctx context.Context, mode string, items []*entity.BatchItem) (*pb.BatchResponse, error) {
+	ctx = withIdempotencyKey(ctx)
+	batch, items, err := t.BatchController.Register(ctx, mode, items)
+
+	if err != nil {
+		return nil, toStatus(err, codes.Internal)
+	}
+
+	for _, item := range items {
+		if item.Transaction == nil {
+			continue
+		}
+
+		err = t.TransactionPublisher.Publish(ctx, item.Transaction)
+
+		if err != nil {
+			log.WithField("transaction_id", item.TransactionID).WithError(err).Error("error on publish transaction")
+		}
+	}
+
+	return toPbBatch(batch, items), nil
+}
+
+func toBatchItem(position int, in *pb.RegisterRequest) *entity.BatchItem {
+	if in == nil {
+		in = &pb.RegisterRequest{}
+	}
+
+	return entity.NewBatchItem(position, in.AccountFrom, in.AccountTo, in.ExternalID, in.Type.String(), in.Currency, float64(in.Amount))
+}
+
+func toPbBatch(batch *entity.Batch, items []*entity.BatchItem) *pb.BatchResponse {
+	response := &pb.BatchResponse{
+		ID:        batch.ID,
+		Mode:      batch.Mode,
+		Status:    batch.Status,
+		Total:     int32(batch.Total),
+		Succeeded: int32(batch.Succeeded),
+		Failed:    int32(batch.Failed),
+		CreatedAt: batch.CreatedAt.String(),
+	}
+
+	for _, item := range items {
+		response.Items = append(response.Items, &pb.BatchItemResult{
+			Position:      int32(item.Position),
+			Status:        item.Status,
+			TransactionID: item.TransactionID,
+			Error:         item.Error,
+		})
+	}
+
+	return response
+}
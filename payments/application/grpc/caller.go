@@ -0,0 +1,64 @@
+package grpc
+
+import (
+	"context"
+
+	grpc_middleware "github.com/grpc-ecosystem/go-grpc-middleware"
+	grpc_ctxtags "github.com/grpc-ecosystem/go-grpc-middleware/tags"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+)
+
+type callerKey struct{}
+
+type Caller struct {
+	Subject      string
+	CommonName   string
+	Organization []string
+	SerialNumber string
+}
+
+func CallerFromContext(ctx context.Context) (*Caller, bool) {
+	caller, ok := ctx.Value(callerKey{}).(*Caller)
+
+	return caller, ok
+}
+
+func callerUnaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	return handler(withCaller(ctx), req)
+}
+
+func callerStreamInterceptor(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	wrapped := grpc_middleware.WrapServerStream(stream)
+	wrapped.WrappedContext = withCaller(stream.Context())
+
+	return handler(srv, wrapped)
+}
+
+func withCaller(ctx context.Context) context.Context {
+	p, ok := peer.FromContext(ctx)
+
+	if !ok {
+		return ctx
+	}
+
+	tlsInfo, ok := p.AuthInfo.(credentials.TLSInfo)
+
+	if !ok || len(tlsInfo.State.VerifiedChains) == 0 || len(tlsInfo.State.VerifiedChains[0]) == 0 {
+		return ctx
+	}
+
+	certificate := tlsInfo.State.VerifiedChains[0][0]
+
+	caller := &Caller{
+		Subject:      certificate.Subject.String(),
+		CommonName:   certificate.Subject.CommonName,
+		Organization: certificate.Subject.Organization,
+		SerialNumber: certificate.SerialNumber.String(),
+	}
+
+	grpc_ctxtags.Extract(ctx).Set("caller.subject", caller.Subject)
+
+	return context.WithValue(ctx, callerKey{}, caller)
+}
@@ -0,0 +1,76 @@
+package grpc
+
+import (
+	"sort"
+
+	"github.com/EdlanioJ/kbu/payments/domain/entity"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const errorDomain = "payments.kbu"
+
+var errorCodes = map[string]codes.Code{
+	entity.ErrorNotFound:          codes.NotFound,
+	entity.ErrorInvalidArgument:   codes.InvalidArgument,
+	entity.ErrorInsufficientFunds: codes.FailedPrecondition,
+	entity.ErrorAccountFrozen:     codes.FailedPrecondition,
+	entity.ErrorConflict:          codes.Aborted,
+	entity.ErrorUnauthenticated:   codes.Unauthenticated,
+	entity.ErrorPermissionDenied:  codes.PermissionDenied,
+}
+
+func toStatus(err error, fallback codes.Code) error {
+	domainError, ok := entity.AsDomainError(err)
+
+	if !ok {
+		return status.Error(fallback, err.Error())
+	}
+
+	code, ok := errorCodes[domainError.Kind]
+
+	if !ok {
+		code = fallback
+	}
+
+	st := status.New(code, domainError.Message)
+
+	info := &errdetails.ErrorInfo{
+		Reason:   domainError.Kind,
+		Domain:   errorDomain,
+		Metadata: domainError.Metadata,
+	}
+
+	withDetails, err := st.WithDetails(info)
+
+	if len(domainError.Fields) > 0 {
+		withDetails, err = st.WithDetails(info, badRequest(domainError.Fields))
+	}
+
+	if err != nil {
+		return st.Err()
+	}
+
+	return withDetails.Err()
+}
+
+func badRequest(fields map[string]string) *errdetails.BadRequest {
+	names := make([]string, 0, len(fields))
+
+	for field := range fields {
+		names = append(names, field)
+	}
+	sort.Strings(names)
+
+	violations := make([]*errdetails.BadRequest_FieldViolation, 0, len(names))
+
+	for _, field := range names {
+		violations = append(violations, &errdetails.BadRequest_FieldViolation{
+			Field:       field,
+			Description: fields[field],
+		})
+	}
+
+	return &errdetails.BadRequest{FieldViolations: violations}
+}
@@ -2,33 +2,75 @@ package grpc
 
 import (
 	"context"
+	"time"
 
 	"github.com/EdlanioJ/kbu/payments/application/grpc/pb"
+	"github.com/EdlanioJ/kbu/payments/application/kafka"
+	"github.com/EdlanioJ/kbu/payments/domain/entity"
+	"github.com/EdlanioJ/kbu/payments/domain/usecase"
 	"github.com/EdlanioJ/kbu/payments/presentation/controller"
+	log "github.com/sirupsen/logrus"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
 )
 
+const IdempotencyKeyHeader = "idempotency-key"
+
 type TransactionGrpcHandler struct {
 	TransactionController *controller.Transaction
+	TransactionPublisher  *kafka.TransactionPublisher
+	SagaController        *controller.Saga
+	WatchController       *controller.Watch
+	BatchController       *controller.Batch
 
 	pb.UnimplementedPaymentServiceServer
 }
 
 func NewTransactionGrpcHandler(
 	transaction *controller.Transaction,
+	publisher *kafka.TransactionPublisher,
+	saga *controller.Saga,
+	watch *controller.Watch,
+	batch *controller.Batch,
 ) *TransactionGrpcHandler {
 
 	return &TransactionGrpcHandler{
 		TransactionController: transaction,
+		TransactionPublisher:  publisher,
+		SagaController:        saga,
+		WatchController:       watch,
+		BatchController:       batch,
 	}
 }
 
+// withIdempotencyKey passes the idempotency key sent as metadata, if any, on
+// to the controllers.
+func withIdempotencyKey(ctx context.Context) context.Context {
+	if md, ok := metadata.FromIncomingContext(ctx); ok && len(md.Get(IdempotencyKeyHeader)) > 0 {
+		return controller.WithIdempotencyKey(ctx, md.Get(IdempotencyKeyHeader)[0])
+	}
+
+	return ctx
+}
+
 func (t *TransactionGrpcHandler) Register(ctx context.Context, in *pb.RegisterRequest) (*pb.Response, error) {
-	response, err := t.TransactionController.Register(ctx, in.AccountFrom, in.AccountFrom, in.ExternalID, in.Type.String(), in.Currency, float64(in.Amount))
+	ctx = withIdempotencyKey(ctx)
+
+	if in.Type == pb.TransactionType_to_service && t.SagaController != nil {
+		return t.registerServicePayment(ctx, in)
+	}
+
+	response, err := t.TransactionController.Register(ctx, in.AccountFrom, in.AccountTo, in.ExternalID, in.Type.String(), in.Currency, float64(in.Amount))
 
 	if err != nil {
-		return nil, status.Error(codes.Internal, err.Error())
+		return nil, toStatus(err, codes.Internal)
+	}
+
+	err = t.TransactionPublisher.Publish(ctx, response)
+
+	if err != nil {
+		log.WithField("transaction_id", response.ID).WithError(err).Error("error on publish transaction")
 	}
 
 	return &pb.Response{
@@ -46,11 +88,26 @@ func (t *TransactionGrpcHandler) Register(ctx context.Context, in *pb.RegisterRe
 		},
 	}, nil
 }
+
+func (t *TransactionGrpcHandler) registerServicePayment(ctx context.Context, in *pb.RegisterRequest) (*pb.Response, error) {
+	saga, err := t.SagaController.Start(ctx, in.AccountFrom, in.AccountTo, in.ExternalID, in.Currency, float64(in.Amount))
+
+	if err != nil {
+		return nil, toStatus(err, codes.Internal)
+	}
+
+	if saga.Status == entity.SagaCompensated {
+		return nil, status.Error(codes.Aborted, saga.Error)
+	}
+
+	return t.Get(ctx, &pb.Request{ID: saga.TransactionID})
+}
+
 func (t *TransactionGrpcHandler) Get(ctx context.Context, in *pb.Request) (*pb.Response, error) {
 	response, err := t.TransactionController.Get(ctx, in.ID)
 
 	if err != nil {
-		return nil, status.Error(codes.NotFound, err.Error())
+		return nil, toStatus(err, codes.NotFound)
 	}
 
 	return &pb.Response{
@@ -74,10 +131,10 @@ func (t *TransactionGrpcHandler) List(ctx context.Context, in *pb.PaginationRequ
 	var transactions []*pb.Transaction
 
 	if err != nil {
-		return nil, status.Error(codes.Internal, err.Error())
+		return nil, toStatus(err, codes.Internal)
 	}
 
-	if transactions == nil {
+	if response == nil {
 		return nil, status.Error(codes.NotFound, "no payment was found")
 	}
 
@@ -106,7 +163,7 @@ func (t *TransactionGrpcHandler) GetByType(ctx context.Context, in *pb.GetByType
 	response, err := t.TransactionController.GetByType(ctx, in.TransactionID, in.Type.String())
 
 	if err != nil {
-		return nil, status.Error(codes.NotFound, err.Error())
+		return nil, toStatus(err, codes.NotFound)
 	}
 
 	return &pb.Response{
@@ -130,10 +187,10 @@ func (t *TransactionGrpcHandler) ListByType(ctx context.Context, in *pb.ListByTy
 	var transactions []*pb.Transaction
 
 	if err != nil {
-		return nil, status.Error(codes.Internal, err.Error())
+		return nil, toStatus(err, codes.Internal)
 	}
 
-	if transactions == nil {
+	if response == nil {
 		return nil, status.Error(codes.NotFound, "no payment was found")
 	}
 
@@ -162,7 +219,7 @@ func (t *TransactionGrpcHandler) GetByReference(ctx context.Context, in *pb.GetR
 	response, err := t.TransactionController.GetByExternalID(ctx, in.TransactionID, in.Id)
 
 	if err != nil {
-		return nil, status.Error(codes.NotFound, err.Error())
+		return nil, toStatus(err, codes.NotFound)
 	}
 
 	return &pb.Response{
@@ -186,10 +243,10 @@ func (t *TransactionGrpcHandler) ListByReference(ctx context.Context, in *pb.Lis
 	var transactions []*pb.Transaction
 
 	if err != nil {
-		return nil, status.Error(codes.Internal, err.Error())
+		return nil, toStatus(err, codes.Internal)
 	}
 
-	if transactions == nil {
+	if response == nil {
 		return nil, status.Error(codes.NotFound, "no payment was found")
 	}
 
@@ -218,7 +275,7 @@ func (t *TransactionGrpcHandler) GetByAccountFrom(ctx context.Context, in *pb.Ge
 	response, err := t.TransactionController.GetByAccountFrom(ctx, in.TransactionID, in.Id)
 
 	if err != nil {
-		return nil, status.Error(codes.NotFound, err.Error())
+		return nil, toStatus(err, codes.NotFound)
 	}
 
 	return &pb.Response{
@@ -242,10 +299,10 @@ func (t *TransactionGrpcHandler) ListByAccountFrom(ctx context.Context, in *pb.L
 	var transactions []*pb.Transaction
 
 	if err != nil {
-		return nil, status.Error(codes.Internal, err.Error())
+		return nil, toStatus(err, codes.Internal)
 	}
 
-	if transactions == nil {
+	if response == nil {
 		return nil, status.Error(codes.NotFound, "no payment was found")
 	}
 
@@ -274,7 +331,7 @@ func (t *TransactionGrpcHandler) GetByAccountTo(ctx context.Context, in *pb.GetR
 	response, err := t.TransactionController.GetByAccoutTo(ctx, in.TransactionID, in.Id)
 
 	if err != nil {
-		return nil, status.Error(codes.NotFound, err.Error())
+		return nil, toStatus(err, codes.NotFound)
 	}
 
 	return &pb.Response{
@@ -298,10 +355,10 @@ func (t *TransactionGrpcHandler) ListByAccountTo(ctx context.Context, in *pb.Lis
 	var transactions []*pb.Transaction
 
 	if err != nil {
-		return nil, status.Error(codes.Internal, err.Error())
+		return nil, toStatus(err, codes.Internal)
 	}
 
-	if transactions == nil {
+	if response == nil {
 		return nil, status.Error(codes.NotFound, "no payment was found")
 	}
 
@@ -325,3 +382,130 @@ func (t *TransactionGrpcHandler) ListByAccountTo(ctx context.Context, in *pb.Lis
 		Total:        int32(total),
 	}, nil
 }
+
+func (t *TransactionGrpcHandler) WatchTransaction(in *pb.WatchRequest, stream pb.PaymentService_WatchTransactionServer) error {
+	ctx := stream.Context()
+
+	subscription, err := t.WatchController.WatchTransaction(ctx, in.ID, in.FromVersion)
+
+	if err != nil {
+		return toStatus(err, codes.InvalidArgument)
+	}
+	defer subscription.Close()
+
+	response, err := t.TransactionController.Get(ctx, in.ID)
+
+	if err != nil {
+		return toStatus(err, codes.NotFound)
+	}
+
+	if in.FromVersion == 0 {
+		err = stream.Send(&pb.TransactionUpdate{Version: subscription.Version(), Transaction: toPbTransaction(response)})
+
+		if err != nil {
+			return err
+		}
+
+		if response.Status != entity.TransactionPending {
+			return nil
+		}
+	}
+
+	return streamUpdates(ctx, subscription, true, stream.Send)
+}
+
+func (t *TransactionGrpcHandler) WatchAccount(in *pb.WatchRequest, stream pb.PaymentService_WatchAccountServer) error {
+	ctx := stream.Context()
+
+	subscription, err := t.WatchController.WatchAccount(ctx, in.ID, in.FromVersion)
+
+	if err != nil {
+		return toStatus(err, codes.InvalidArgument)
+	}
+	defer subscription.Close()
+
+	return streamUpdates(ctx, subscription, false, stream.Send)
+}
+
+func (t *TransactionGrpcHandler) ExportTransactions(in *pb.ExportRequest, stream pb.PaymentService_ExportTransactionsServer) error {
+	filter := &entity.TransactionFilter{
+		Type:          in.Type,
+		Status:        in.Status,
+		AccountFromID: in.AccountFrom,
+		AccountToID:   in.AccountTo,
+		ExternalID:    in.ExternalID,
+	}
+
+	var err error
+
+	if in.CreatedFrom != "" {
+		filter.CreatedFrom, err = time.Parse(time.RFC3339, in.CreatedFrom)
+
+		if err != nil {
+			return toStatus(err, codes.InvalidArgument)
+		}
+	}
+
+	if in.CreatedTo != "" {
+		filter.CreatedTo, err = time.Parse(time.RFC3339, in.CreatedTo)
+
+		if err != nil {
+			return toStatus(err, codes.InvalidArgument)
+		}
+	}
+
+	err = t.TransactionController.Export(stream.Context(), filter, in.ResumeToken, func(transaction *entity.Transaction, resumeToken string) error {
+		return stream.Send(&pb.ExportItem{
+			Transaction: toPbTransaction(transaction),
+			ResumeToken: resumeToken,
+		})
+	})
+
+	if err != nil {
+		return toStatus(err, codes.Internal)
+	}
+
+	return nil
+}
+
+func streamUpdates(ctx context.Context, subscription usecase.TransactionSubscription, untilSettled bool, send func(*pb.TransactionUpdate) error) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case update, ok := <-subscription.Updates():
+			if !ok {
+				if err := subscription.Err(); err != nil {
+					return toStatus(err, codes.Aborted)
+				}
+
+				return nil
+			}
+
+			err := send(&pb.TransactionUpdate{Version: update.Version, Transaction: toPbTransaction(update.Transaction)})
+
+			if err != nil {
+				return err
+			}
+
+			if untilSettled && update.Transaction.Status != entity.TransactionPending {
+				return nil
+			}
+		}
+	}
+}
+
+func toPbTransaction(transaction *entity.Transaction) *pb.Transaction {
+	return &pb.Transaction{
+		ID:          transaction.ID,
+		Amount:      float32(transaction.Amount),
+		Status:      transaction.Status,
+		Currency:    transaction.Currency,
+		AccountFrom: transaction.AccountFromID,
+		AccountTo:   transaction.AccountToID,
+		Type:        transaction.Type,
+		ExternalID:  transaction.ExternalID,
+		CreatedAt:   transaction.CreatedAt.String(),
+		UpdatedAt:   transaction.UpdatedAt.String(),
+	}
+}
@@ -0,0 +1,76 @@
+package grpc_test
+
+import (
+	"context"
+	"testing"
+
+	grpc_handler "github.com/EdlanioJ/kbu/payments/application/grpc"
+	"github.com/EdlanioJ/kbu/payments/application/grpc/pb"
+	"github.com/EdlanioJ/kbu/payments/application/kafka"
+	"github.com/EdlanioJ/kbu/payments/domain/entity"
+	"github.com/EdlanioJ/kbu/payments/presentation/controller"
+	"github.com/EdlanioJ/kbu/payments/presentation/controller/mock"
+	uuid "github.com/satori/go.uuid"
+	"github.com/stretchr/testify/require"
+)
+
+// anonymousContext is the context the interceptors give every call while
+// authentication is off.
+func anonymousContext() context.Context {
+	return controller.WithPrincipal(context.Background(), entity.AnonymousPrincipal())
+}
+
+func TestTransactionGrpcHandler(t *testing.T) {
+	t.Parallel()
+
+	t.Run("should register the payment to the requested destination account", func(t *testing.T) {
+		is := require.New(t)
+		transactionUseCase := mock.NewMockTransactionUseCase()
+
+		accountFrom, _ := entity.NewAccount(3000)
+		accountTo, _ := entity.NewAccount(200)
+		externalID := uuid.NewV4().String()
+		transaction, _ := entity.NewTransaction(accountFrom, accountTo, externalID, entity.TransactionToUser, "AOA", 30)
+
+		transactionUseCase.On("Register", accountFrom.ID, accountTo.ID, externalID, entity.TransactionToUser, "AOA", 30.0).Return(transaction, nil)
+
+		handler := &grpc_handler.TransactionGrpcHandler{
+			TransactionController: controller.NewTransaction(transactionUseCase),
+			TransactionPublisher:  kafka.NewTransactionPublisher(kafka.NewMemoryBroker(1)),
+		}
+
+		response, err := handler.Register(anonymousContext(), &pb.RegisterRequest{
+			AccountFrom: accountFrom.ID,
+			AccountTo:   accountTo.ID,
+			ExternalID:  externalID,
+			Type:        pb.TransactionType_to_user,
+			Currency:    "AOA",
+			Amount:      30,
+		})
+
+		is.Nil(err)
+		is.Equal(accountTo.ID, response.Transaction.AccountTo)
+		transactionUseCase.AssertExpectations(t)
+	})
+	t.Run("should list the payments of a page", func(t *testing.T) {
+		is := require.New(t)
+		transactionUseCase := mock.NewMockTransactionUseCase()
+
+		accountFrom, _ := entity.NewAccount(3000)
+		accountTo, _ := entity.NewAccount(200)
+		transaction, _ := entity.NewTransaction(accountFrom, accountTo, uuid.NewV4().String(), entity.TransactionToUser, "AOA", 30)
+
+		transactionUseCase.On("FindAll", 1, 10, "created_at").Return([]*entity.Transaction{transaction}, 1, nil)
+
+		handler := &grpc_handler.TransactionGrpcHandler{
+			TransactionController: controller.NewTransaction(transactionUseCase),
+		}
+
+		response, err := handler.List(anonymousContext(), &pb.PaginationRequest{Page: 1, Limit: 10, Sort: "created_at"})
+
+		is.Nil(err)
+		is.Len(response.Transactions, 1)
+		is.Equal(transaction.ID, response.Transactions[0].ID)
+		is.Equal(int32(1), response.Total)
+	})
+}
@@ -0,0 +1,182 @@
+package grpc
+
+import (
+	"context"
+	"os"
+	"runtime/debug"
+
+	"github.com/EdlanioJ/kbu/payments/application/ratelimit"
+	"github.com/EdlanioJ/kbu/payments/domain/entity"
+	grpc_middleware "github.com/grpc-ecosystem/go-grpc-middleware"
+	grpc_logrus "github.com/grpc-ecosystem/go-grpc-middleware/logging/logrus"
+	"github.com/grpc-ecosystem/go-grpc-middleware/logging/logrus/ctxlogrus"
+	grpc_recovery "github.com/grpc-ecosystem/go-grpc-middleware/recovery"
+	grpc_ctxtags "github.com/grpc-ecosystem/go-grpc-middleware/tags"
+	grpc_prometheus "github.com/grpc-ecosystem/go-grpc-prometheus"
+	uuid "github.com/satori/go.uuid"
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	RequestIDHeader = "x-request-id"
+	requestIDTag    = "request_id"
+	maxRequestID    = 128
+)
+
+type Interceptors struct {
+	Logger    *log.Entry
+	RequestID bool
+	AccessLog bool
+	Recovery  bool
+	Tracing   bool
+	Auth      *Authenticator
+	RateLimit *ratelimit.Limiter
+}
+
+func NewInterceptors() *Interceptors {
+	logger := log.New()
+	logger.SetFormatter(&log.JSONFormatter{})
+
+	return &Interceptors{
+		Logger:    log.NewEntry(logger),
+		RequestID: os.Getenv("GRPC_REQUEST_ID") != "false",
+		AccessLog: os.Getenv("GRPC_ACCESS_LOG") != "false",
+		Recovery:  os.Getenv("GRPC_RECOVERY") != "false",
+		Tracing:   os.Getenv("GRPC_TRACING") != "false",
+	}
+}
+
+func (i *Interceptors) ServerOptions() []grpc.ServerOption {
+	return []grpc.ServerOption{
+		grpc_middleware.WithUnaryServerChain(i.Unary()...),
+		grpc_middleware.WithStreamServerChain(i.Stream()...),
+	}
+}
+
+func (i *Interceptors) Unary() []grpc.UnaryServerInterceptor {
+	interceptors := []grpc.UnaryServerInterceptor{
+		grpc_ctxtags.UnaryServerInterceptor(),
+	}
+
+	if i.Tracing {
+		interceptors = append(interceptors, tracingUnaryInterceptor)
+	}
+
+	interceptors = append(interceptors, grpc_prometheus.UnaryServerInterceptor, callerUnaryInterceptor)
+
+	if i.RequestID {
+		interceptors = append(interceptors, requestIDUnaryInterceptor)
+	}
+
+	interceptors = append(interceptors, grpc_logrus.UnaryServerInterceptor(i.Logger, i.decider()))
+
+	if i.Recovery {
+		interceptors = append(interceptors, grpc_recovery.UnaryServerInterceptor(grpc_recovery.WithRecoveryHandlerContext(recoverPanic)))
+	}
+
+	if i.Auth != nil {
+		interceptors = append(interceptors, i.Auth.UnaryInterceptor)
+	} else {
+		interceptors = append(interceptors, anonymousUnaryInterceptor)
+	}
+
+	if i.RateLimit != nil {
+		interceptors = append(interceptors, rateLimitUnaryInterceptor(i.RateLimit))
+	}
+
+	return interceptors
+}
+
+func (i *Interceptors) Stream() []grpc.StreamServerInterceptor {
+	interceptors := []grpc.StreamServerInterceptor{
+		grpc_ctxtags.StreamServerInterceptor(),
+	}
+
+	if i.Tracing {
+		interceptors = append(interceptors, tracingStreamInterceptor)
+	}
+
+	interceptors = append(interceptors, grpc_prometheus.StreamServerInterceptor, callerStreamInterceptor)
+
+	if i.RequestID {
+		interceptors = append(interceptors, requestIDStreamInterceptor)
+	}
+
+	interceptors = append(interceptors, grpc_logrus.StreamServerInterceptor(i.Logger, i.decider()))
+
+	if i.Recovery {
+		interceptors = append(interceptors, grpc_recovery.StreamServerInterceptor(grpc_recovery.WithRecoveryHandlerContext(recoverPanic)))
+	}
+
+	if i.Auth != nil {
+		interceptors = append(interceptors, i.Auth.StreamInterceptor)
+	} else {
+		interceptors = append(interceptors, anonymousStreamInterceptor)
+	}
+
+	if i.RateLimit != nil {
+		interceptors = append(interceptors, rateLimitStreamInterceptor(i.RateLimit))
+	}
+
+	return interceptors
+}
+
+func (i *Interceptors) decider() grpc_logrus.Option {
+	return grpc_logrus.WithDecider(func(string, error) bool {
+		return i.AccessLog
+	})
+}
+
+func RequestID(ctx context.Context) string {
+	if id, ok := grpc_ctxtags.Extract(ctx).Values()[requestIDTag].(string); ok {
+		return id
+	}
+
+	return ""
+}
+
+func requestIDUnaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	return handler(tagRequestID(ctx), req)
+}
+
+func requestIDStreamInterceptor(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	wrapped := grpc_middleware.WrapServerStream(stream)
+	wrapped.WrappedContext = tagRequestID(stream.Context())
+
+	return handler(srv, wrapped)
+}
+
+func tagRequestID(ctx context.Context) context.Context {
+	var id string
+
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(RequestIDHeader); len(values) > 0 && len(values[0]) <= maxRequestID {
+			id = values[0]
+		}
+	}
+
+	if id == "" {
+		id = uuid.NewV4().String()
+	}
+
+	grpc_ctxtags.Extract(ctx).Set(requestIDTag, id)
+
+	if err := grpc.SetHeader(ctx, metadata.Pairs(RequestIDHeader, id)); err != nil {
+		ctxlogrus.Extract(ctx).WithError(err).Warn("could not set request id header")
+	}
+
+	return entity.WithRequestID(ctx, id)
+}
+
+func recoverPanic(ctx context.Context, p interface{}) error {
+	ctxlogrus.Extract(ctx).
+		WithField("panic", p).
+		WithField("stack", string(debug.Stack())).
+		Error("recovered from panic")
+
+	return status.Error(codes.Internal, "internal server error")
+}
@@ -0,0 +1,130 @@
+package grpc_test
+
+import (
+	"context"
+	"testing"
+
+	grpc_handler "github.com/EdlanioJ/kbu/payments/application/grpc"
+	"github.com/EdlanioJ/kbu/payments/domain/entity"
+	"github.com/EdlanioJ/kbu/payments/presentation/controller"
+	grpc_middleware "github.com/grpc-ecosystem/go-grpc-middleware"
+	"github.com/grpc-ecosystem/go-grpc-middleware/logging/logrus/ctxlogrus"
+	log "github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func newTestInterceptors() (*grpc_handler.Interceptors, *test.Hook) {
+	logger, hook := test.NewNullLogger()
+
+	return &grpc_handler.Interceptors{
+		Logger:    log.NewEntry(logger),
+		RequestID: true,
+		AccessLog: true,
+		Recovery:  true,
+	}, hook
+}
+
+func TestInterceptors(t *testing.T) {
+	t.Parallel()
+
+	info := &grpc.UnaryServerInfo{FullMethod: "/github.com.EdlanioJ.kbu.payments.PaymentService/Find"}
+
+	t.Run("should propagate incoming request id", func(t *testing.T) {
+		is := require.New(t)
+		interceptors, hook := newTestInterceptors()
+		chain := grpc_middleware.ChainUnaryServer(interceptors.Unary()...)
+
+		ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(grpc_handler.RequestIDHeader, "req-1"))
+
+		_, err := chain(ctx, nil, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+			is.Equal("req-1", grpc_handler.RequestID(ctx))
+			is.Equal("req-1", ctxlogrus.Extract(ctx).Data["request_id"])
+			is.Equal("req-1", entity.RequestID(ctx))
+			return nil, nil
+		})
+
+		is.Nil(err)
+
+		entry := hook.LastEntry()
+		is.NotNil(entry)
+		is.Equal("req-1", entry.Data["request_id"])
+		is.Equal("OK", entry.Data["grpc.code"])
+		is.Equal("Find", entry.Data["grpc.method"])
+	})
+
+	t.Run("should generate request id when missing", func(t *testing.T) {
+		is := require.New(t)
+		interceptors, _ := newTestInterceptors()
+		chain := grpc_middleware.ChainUnaryServer(interceptors.Unary()...)
+
+		_, err := chain(context.Background(), nil, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+			is.NotEmpty(grpc_handler.RequestID(ctx))
+			return nil, nil
+		})
+
+		is.Nil(err)
+	})
+
+	t.Run("should recover from panic", func(t *testing.T) {
+		is := require.New(t)
+		interceptors, hook := newTestInterceptors()
+		chain := grpc_middleware.ChainUnaryServer(interceptors.Unary()...)
+
+		_, err := chain(context.Background(), nil, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+			panic("boom")
+		})
+
+		is.Equal(codes.Internal, status.Code(err))
+		is.Equal("Internal", hook.LastEntry().Data["grpc.code"])
+	})
+
+	t.Run("should call as the anonymous principal of the default tenant without authentication", func(t *testing.T) {
+		is := require.New(t)
+		interceptors, _ := newTestInterceptors()
+		chain := grpc_middleware.ChainUnaryServer(interceptors.Unary()...)
+
+		_, err := chain(context.Background(), nil, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+			principal, ok := controller.PrincipalFromContext(ctx)
+			is.True(ok)
+			is.Equal(entity.AnonymousPrincipal(), principal)
+
+			tenant, ok := entity.Tenant(ctx)
+			is.True(ok)
+			is.Equal(entity.DefaultTenant, tenant)
+			return nil, nil
+		})
+
+		is.Nil(err)
+	})
+
+	t.Run("should refuse to serve many tenants without authentication", func(t *testing.T) {
+		is := require.New(t)
+
+		server, err := grpc_handler.NewGrpcServer(nil, nil, &grpc_handler.ServerConfig{
+			Auth:        &grpc_handler.AuthOptions{},
+			MultiTenant: true,
+		})
+
+		is.Nil(server)
+		is.NotNil(err)
+	})
+
+	t.Run("should skip access log when disabled", func(t *testing.T) {
+		is := require.New(t)
+		interceptors, hook := newTestInterceptors()
+		interceptors.AccessLog = false
+		chain := grpc_middleware.ChainUnaryServer(interceptors.Unary()...)
+
+		_, err := chain(context.Background(), nil, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+			return nil, nil
+		})
+
+		is.Nil(err)
+		is.Empty(hook.AllEntries())
+	})
+}
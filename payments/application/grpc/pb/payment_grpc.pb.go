@@ -29,6 +29,12 @@ type PaymentServiceClient interface {
 	ListByAccountFrom(ctx context.Context, in *ListRequest, opts ...grpc.CallOption) (*ListResponse, error)
 	GetByAccountTo(ctx context.Context, in *GetRequest, opts ...grpc.CallOption) (*Response, error)
 	ListByAccountTo(ctx context.Context, in *ListRequest, opts ...grpc.CallOption) (*ListResponse, error)
+	WatchTransaction(ctx context.Context, in *WatchRequest, opts ...grpc.CallOption) (PaymentService_WatchTransactionClient, error)
+	WatchAccount(ctx context.Context, in *WatchRequest, opts ...grpc.CallOption) (PaymentService_WatchAccountClient, error)
+	ExportTransactions(ctx context.Context, in *ExportRequest, opts ...grpc.CallOption) (PaymentService_ExportTransactionsClient, error)
+	RegisterBatch(ctx context.Context, in *RegisterBatchRequest, opts ...grpc.CallOption) (*BatchResponse, error)
+	RegisterBatchStream(ctx context.Context, opts ...grpc.CallOption) (PaymentService_RegisterBatchStreamClient, error)
+	GetBatch(ctx context.Context, in *Request, opts ...grpc.CallOption) (*BatchResponse, error)
 }
 
 type paymentServiceClient struct {
@@ -138,6 +144,154 @@ func (c *paymentServiceClient) ListByAccountTo(ctx context.Context, in *ListRequ
 	return out, nil
 }
 
+func (c *paymentServiceClient) WatchTransaction(ctx context.Context, in *WatchRequest, opts ...grpc.CallOption) (PaymentService_WatchTransactionClient, error) {
+	stream, err := c.cc.NewStream(ctx, &PaymentService_ServiceDesc.Streams[0], "/github.com.edlanioj.kbu.payments.PaymentService/WatchTransaction", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &paymentServiceWatchTransactionClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type PaymentService_WatchTransactionClient interface {
+	Recv() (*TransactionUpdate, error)
+	grpc.ClientStream
+}
+
+type paymentServiceWatchTransactionClient struct {
+	grpc.ClientStream
+}
+
+func (x *paymentServiceWatchTransactionClient) Recv() (*TransactionUpdate, error) {
+	m := new(TransactionUpdate)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *paymentServiceClient) WatchAccount(ctx context.Context, in *WatchRequest, opts ...grpc.CallOption) (PaymentService_WatchAccountClient, error) {
+	stream, err := c.cc.NewStream(ctx, &PaymentService_ServiceDesc.Streams[1], "/github.com.edlanioj.kbu.payments.PaymentService/WatchAccount", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &paymentServiceWatchAccountClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type PaymentService_WatchAccountClient interface {
+	Recv() (*TransactionUpdate, error)
+	grpc.ClientStream
+}
+
+type paymentServiceWatchAccountClient struct {
+	grpc.ClientStream
+}
+
+func (x *paymentServiceWatchAccountClient) Recv() (*TransactionUpdate, error) {
+	m := new(TransactionUpdate)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *paymentServiceClient) ExportTransactions(ctx context.Context, in *ExportRequest, opts ...grpc.CallOption) (PaymentService_ExportTransactionsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &PaymentService_ServiceDesc.Streams[2], "/github.com.edlanioj.kbu.payments.PaymentService/ExportTransactions", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &paymentServiceExportTransactionsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type PaymentService_ExportTransactionsClient interface {
+	Recv() (*ExportItem, error)
+	grpc.ClientStream
+}
+
+type paymentServiceExportTransactionsClient struct {
+	grpc.ClientStream
+}
+
+func (x *paymentServiceExportTransactionsClient) Recv() (*ExportItem, error) {
+	m := new(ExportItem)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *paymentServiceClient) RegisterBatch(ctx context.Context, in *RegisterBatchRequest, opts ...grpc.CallOption) (*BatchResponse, error) {
+	out := new(BatchResponse)
+	err := c.cc.Invoke(ctx, "/github.com.edlanioj.kbu.payments.PaymentService/RegisterBatch", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *paymentServiceClient) RegisterBatchStream(ctx context.Context, opts ...grpc.CallOption) (PaymentService_RegisterBatchStreamClient, error) {
+	stream, err := c.cc.NewStream(ctx, &PaymentService_ServiceDesc.Streams[3], "/github.com.edlanioj.kbu.payments.PaymentService/RegisterBatchStream", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &paymentServiceRegisterBatchStreamClient{stream}
+	return x, nil
+}
+
+type PaymentService_RegisterBatchStreamClient interface {
+	Send(*RegisterBatchStreamRequest) error
+	CloseAndRecv() (*BatchResponse, error)
+	grpc.ClientStream
+}
+
+type paymentServiceRegisterBatchStreamClient struct {
+	grpc.ClientStream
+}
+
+func (x *paymentServiceRegisterBatchStreamClient) Send(m *RegisterBatchStreamRequest) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *paymentServiceRegisterBatchStreamClient) CloseAndRecv() (*BatchResponse, error) {
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	m := new(BatchResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *paymentServiceClient) GetBatch(ctx context.Context, in *Request, opts ...grpc.CallOption) (*BatchResponse, error) {
+	out := new(BatchResponse)
+	err := c.cc.Invoke(ctx, "/github.com.edlanioj.kbu.payments.PaymentService/GetBatch", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // PaymentServiceServer is the server API for PaymentService service.
 // All implementations must embed UnimplementedPaymentServiceServer
 // for forward compatibility
@@ -153,6 +307,12 @@ type PaymentServiceServer interface {
 	ListByAccountFrom(context.Context, *ListRequest) (*ListResponse, error)
 	GetByAccountTo(context.Context, *GetRequest) (*Response, error)
 	ListByAccountTo(context.Context, *ListRequest) (*ListResponse, error)
+	WatchTransaction(*WatchRequest, PaymentService_WatchTransactionServer) error
+	WatchAccount(*WatchRequest, PaymentService_WatchAccountServer) error
+	ExportTransactions(*ExportRequest, PaymentService_ExportTransactionsServer) error
+	RegisterBatch(context.Context, *RegisterBatchRequest) (*BatchResponse, error)
+	RegisterBatchStream(PaymentService_RegisterBatchStreamServer) error
+	GetBatch(context.Context, *Request) (*BatchResponse, error)
 	mustEmbedUnimplementedPaymentServiceServer()
 }
 
@@ -193,6 +353,24 @@ func (UnimplementedPaymentServiceServer) GetByAccountTo(context.Context, *GetReq
 func (UnimplementedPaymentServiceServer) ListByAccountTo(context.Context, *ListRequest) (*ListResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method ListByAccountTo not implemented")
 }
+func (UnimplementedPaymentServiceServer) WatchTransaction(*WatchRequest, PaymentService_WatchTransactionServer) error {
+	return status.Errorf(codes.Unimplemented, "method WatchTransaction not implemented")
+}
+func (UnimplementedPaymentServiceServer) WatchAccount(*WatchRequest, PaymentService_WatchAccountServer) error {
+	return status.Errorf(codes.Unimplemented, "method WatchAccount not implemented")
+}
+func (UnimplementedPaymentServiceServer) ExportTransactions(*ExportRequest, PaymentService_ExportTransactionsServer) error {
+	return status.Errorf(codes.Unimplemented, "method ExportTransactions not implemented")
+}
+func (UnimplementedPaymentServiceServer) RegisterBatch(context.Context, *RegisterBatchRequest) (*BatchResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RegisterBatch not implemented")
+}
+func (UnimplementedPaymentServiceServer) RegisterBatchStream(PaymentService_RegisterBatchStreamServer) error {
+	return status.Errorf(codes.Unimplemented, "method RegisterBatchStream not implemented")
+}
+func (UnimplementedPaymentServiceServer) GetBatch(context.Context, *Request) (*BatchResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetBatch not implemented")
+}
 func (UnimplementedPaymentServiceServer) mustEmbedUnimplementedPaymentServiceServer() {}
 
 // UnsafePaymentServiceServer may be embedded to opt out of forward compatibility for this service.
@@ -404,6 +582,131 @@ func _PaymentService_ListByAccountTo_Handler(srv interface{}, ctx context.Contex
 	return interceptor(ctx, in, info, handler)
 }
 
+func _PaymentService_WatchTransaction_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(PaymentServiceServer).WatchTransaction(m, &paymentServiceWatchTransactionServer{stream})
+}
+
+type PaymentService_WatchTransactionServer interface {
+	Send(*TransactionUpdate) error
+	grpc.ServerStream
+}
+
+type paymentServiceWatchTransactionServer struct {
+	grpc.ServerStream
+}
+
+func (x *paymentServiceWatchTransactionServer) Send(m *TransactionUpdate) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _PaymentService_WatchAccount_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(PaymentServiceServer).WatchAccount(m, &paymentServiceWatchAccountServer{stream})
+}
+
+type PaymentService_WatchAccountServer interface {
+	Send(*TransactionUpdate) error
+	grpc.ServerStream
+}
+
+type paymentServiceWatchAccountServer struct {
+	grpc.ServerStream
+}
+
+func (x *paymentServiceWatchAccountServer) Send(m *TransactionUpdate) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _PaymentService_ExportTransactions_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ExportRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(PaymentServiceServer).ExportTransactions(m, &paymentServiceExportTransactionsServer{stream})
+}
+
+type PaymentService_ExportTransactionsServer interface {
+	Send(*ExportItem) error
+	grpc.ServerStream
+}
+
+type paymentServiceExportTransactionsServer struct {
+	grpc.ServerStream
+}
+
+func (x *paymentServiceExportTransactionsServer) Send(m *ExportItem) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _PaymentService_RegisterBatch_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RegisterBatchRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PaymentServiceServer).RegisterBatch(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/github.com.edlanioj.kbu.payments.PaymentService/RegisterBatch",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PaymentServiceServer).RegisterBatch(ctx, req.(*RegisterBatchRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PaymentService_RegisterBatchStream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(PaymentServiceServer).RegisterBatchStream(&paymentServiceRegisterBatchStreamServer{stream})
+}
+
+type PaymentService_RegisterBatchStreamServer interface {
+	SendAndClose(*BatchResponse) error
+	Recv() (*RegisterBatchStreamRequest, error)
+	grpc.ServerStream
+}
+
+type paymentServiceRegisterBatchStreamServer struct {
+	grpc.ServerStream
+}
+
+func (x *paymentServiceRegisterBatchStreamServer) SendAndClose(m *BatchResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *paymentServiceRegisterBatchStreamServer) Recv() (*RegisterBatchStreamRequest, error) {
+	m := new(RegisterBatchStreamRequest)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func _PaymentService_GetBatch_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Request)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PaymentServiceServer).GetBatch(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/github.com.edlanioj.kbu.payments.PaymentService/GetBatch",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PaymentServiceServer).GetBatch(ctx, req.(*Request))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // PaymentService_ServiceDesc is the grpc.ServiceDesc for PaymentService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -455,7 +758,36 @@ var PaymentService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "ListByAccountTo",
 			Handler:    _PaymentService_ListByAccountTo_Handler,
 		},
+		{
+			MethodName: "RegisterBatch",
+			Handler:    _PaymentService_RegisterBatch_Handler,
+		},
+		{
+			MethodName: "GetBatch",
+			Handler:    _PaymentService_GetBatch_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchTransaction",
+			Handler:       _PaymentService_WatchTransaction_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "WatchAccount",
+			Handler:       _PaymentService_WatchAccount_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "ExportTransactions",
+			Handler:       _PaymentService_ExportTransactions_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "RegisterBatchStream",
+			Handler:       _PaymentService_RegisterBatchStream_Handler,
+			ClientStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "payment.proto",
 }
@@ -0,0 +1,162 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net"
+	"strconv"
+
+	"github.com/EdlanioJ/kbu/payments/application/grpc/pb"
+	"github.com/EdlanioJ/kbu/payments/application/ratelimit"
+	"github.com/EdlanioJ/kbu/payments/presentation/controller"
+	grpc_middleware "github.com/grpc-ecosystem/go-grpc-middleware"
+	"github.com/grpc-ecosystem/go-grpc-middleware/logging/logrus/ctxlogrus"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+const RetryAfterHeader = "retry-after"
+
+type rateLimitedStream struct {
+	*grpc_middleware.WrappedServerStream
+	limiter *ratelimit.Limiter
+}
+
+func (s *rateLimitedStream) RecvMsg(m interface{}) error {
+	err := s.WrappedServerStream.RecvMsg(m)
+
+	if err != nil {
+		return err
+	}
+
+	err = s.limiter.AllowAccounts(s.Context(), requestAccounts(m)...)
+
+	return rateLimitError(s.Context(), err, s.SetTrailer)
+}
+
+func rateLimitUnaryInterceptor(limiter *ratelimit.Limiter) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		err := limiter.Allow(ctx, callerIdentity(ctx), info.FullMethod)
+
+		if err == nil {
+			err = limiter.AllowAccounts(ctx, requestAccounts(req)...)
+		}
+
+		err = rateLimitError(ctx, err, func(md metadata.MD) {
+			if err := grpc.SetHeader(ctx, md); err != nil {
+				ctxlogrus.Extract(ctx).WithError(err).Warn("could not set retry-after header")
+			}
+		})
+
+		if err != nil {
+			return nil, err
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+func rateLimitStreamInterceptor(limiter *ratelimit.Limiter) grpc.StreamServerInterceptor {
+	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx := stream.Context()
+		err := limiter.Allow(ctx, callerIdentity(ctx), info.FullMethod)
+
+		err = rateLimitError(ctx, err, func(md metadata.MD) {
+			if err := stream.SetHeader(md); err != nil {
+				ctxlogrus.Extract(ctx).WithError(err).Warn("could not set retry-after header")
+			}
+		})
+
+		if err != nil {
+			return err
+		}
+
+		return handler(srv, &rateLimitedStream{
+			WrappedServerStream: grpc_middleware.WrapServerStream(stream),
+			limiter:             limiter,
+		})
+	}
+}
+
+// rateLimitError turns an exceeded limit into ResourceExhausted with the
+// retry delay both as metadata and as a RetryInfo detail. Store failures
+// are logged and let through so a broken shared store does not take the
+// service down.
+func rateLimitError(ctx context.Context, err error, setMetadata func(metadata.MD)) error {
+	if err == nil {
+		return nil
+	}
+
+	var exceeded *ratelimit.ExceededError
+
+	if !errors.As(err, &exceeded) {
+		ctxlogrus.Extract(ctx).WithError(err).Warn("rate limit store is unavailable")
+		return nil
+	}
+
+	ctxlogrus.Extract(ctx).
+		WithField("rate_limit.scope", exceeded.Scope).
+		WithField("rate_limit.key", exceeded.Key).
+		Warn("rate limit exceeded")
+
+	retryAfter := int64(math.Ceil(exceeded.RetryAfter.Seconds()))
+
+	if retryAfter < 1 {
+		retryAfter = 1
+	}
+
+	setMetadata(metadata.Pairs(RetryAfterHeader, strconv.FormatInt(retryAfter, 10)))
+
+	st := status.New(codes.ResourceExhausted, exceeded.Error())
+
+	if detailed, detailsErr := st.WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(exceeded.RetryAfter)}); detailsErr == nil {
+		st = detailed
+	}
+
+	return st.Err()
+}
+
+func callerIdentity(ctx context.Context) string {
+	if principal, ok := controller.PrincipalFromContext(ctx); ok && principal.Subject != "" {
+		return principal.Subject
+	}
+
+	if caller, ok := CallerFromContext(ctx); ok {
+		return caller.Subject
+	}
+
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		if host, _, err := net.SplitHostPort(p.Addr.String()); err == nil {
+			return host
+		}
+
+		return p.Addr.String()
+	}
+
+	return "anonymous"
+}
+
+func requestAccounts(req interface{}) []string {
+	switch req := req.(type) {
+	case *pb.RegisterRequest:
+		return []string{req.GetAccountFrom()}
+	case *pb.RegisterBatchRequest:
+		accounts := make([]string, 0, len(req.GetItems()))
+
+		for _, item := range req.GetItems() {
+			accounts = append(accounts, item.GetAccountFrom())
+		}
+
+		return accounts
+	case *pb.RegisterBatchStreamRequest:
+		return []string{req.GetItem().GetAccountFrom()}
+	}
+
+	return nil
+}
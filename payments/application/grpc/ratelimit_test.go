@@ -0,0 +1,87 @@
+package grpc_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/EdlanioJ/kbu/payments/application/grpc/pb"
+	"github.com/EdlanioJ/kbu/payments/application/ratelimit"
+	grpc_middleware "github.com/grpc-ecosystem/go-grpc-middleware"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestRateLimitInterceptor(t *testing.T) {
+	t.Parallel()
+
+	info := &grpc.UnaryServerInfo{FullMethod: "/github.com.EdlanioJ.kbu.payments.PaymentService/Register"}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return "ok", nil
+	}
+
+	t.Run("should reject with resource exhausted and retry info", func(t *testing.T) {
+		is := require.New(t)
+		interceptors, _ := newTestInterceptors()
+		interceptors.RateLimit = ratelimit.NewLimiter(&ratelimit.Config{
+			Methods: map[string]*ratelimit.Limit{"register": {Rate: 0.5, Burst: 1}},
+		}, ratelimit.NewMemoryStore())
+		chain := grpc_middleware.ChainUnaryServer(interceptors.Unary()...)
+
+		res, err := chain(context.Background(), &pb.RegisterRequest{}, info, handler)
+
+		is.Nil(err)
+		is.Equal("ok", res)
+
+		_, err = chain(context.Background(), &pb.RegisterRequest{}, info, handler)
+
+		st := status.Convert(err)
+		is.Equal(codes.ResourceExhausted, st.Code())
+		is.Len(st.Details(), 1)
+
+		retryInfo, ok := st.Details()[0].(*errdetails.RetryInfo)
+		is.True(ok)
+		is.InDelta(2, retryInfo.GetRetryDelay().AsDuration().Seconds(), 0.1)
+	})
+
+	t.Run("should limit the source account of a registration", func(t *testing.T) {
+		is := require.New(t)
+		interceptors, _ := newTestInterceptors()
+		interceptors.RateLimit = ratelimit.NewLimiter(&ratelimit.Config{
+			Account: &ratelimit.Limit{Rate: 1, Burst: 1},
+		}, ratelimit.NewMemoryStore())
+		chain := grpc_middleware.ChainUnaryServer(interceptors.Unary()...)
+
+		_, err := chain(context.Background(), &pb.RegisterRequest{AccountFrom: "account-1"}, info, handler)
+		is.Nil(err)
+
+		_, err = chain(context.Background(), &pb.RegisterRequest{AccountFrom: "account-2"}, info, handler)
+		is.Nil(err)
+
+		_, err = chain(context.Background(), &pb.RegisterRequest{AccountFrom: "account-1"}, info, handler)
+		is.Equal(codes.ResourceExhausted, status.Code(err))
+	})
+	t.Run("should limit each source account of a batch once", func(t *testing.T) {
+		is := require.New(t)
+		interceptors, _ := newTestInterceptors()
+		interceptors.RateLimit = ratelimit.NewLimiter(&ratelimit.Config{
+			Account: &ratelimit.Limit{Rate: 1, Burst: 1},
+		}, ratelimit.NewMemoryStore())
+		chain := grpc_middleware.ChainUnaryServer(interceptors.Unary()...)
+		batchInfo := &grpc.UnaryServerInfo{FullMethod: "/github.com.EdlanioJ.kbu.payments.PaymentService/RegisterBatch"}
+
+		batch := &pb.RegisterBatchRequest{Items: []*pb.RegisterRequest{
+			{AccountFrom: "account-1"},
+			{AccountFrom: "account-1"},
+			{AccountFrom: "account-1"},
+		}}
+
+		_, err := chain(context.Background(), batch, batchInfo, handler)
+		is.Nil(err)
+
+		_, err = chain(context.Background(), batch, batchInfo, handler)
+		is.Equal(codes.ResourceExhausted, status.Code(err))
+	})
+}
@@ -1,20 +1,83 @@
 package grpc
 
 import (
+	"context"
 	"fmt"
 	"net"
+	"os"
+	"time"
 
 	"github.com/EdlanioJ/kbu/payments/application/factory"
 	"github.com/EdlanioJ/kbu/payments/application/grpc/pb"
+	"github.com/EdlanioJ/kbu/payments/application/health"
+	"github.com/EdlanioJ/kbu/payments/application/kafka"
+	"github.com/EdlanioJ/kbu/payments/application/ratelimit"
+	grpc_prometheus "github.com/grpc-ecosystem/go-grpc-prometheus"
 	"github.com/jinzhu/gorm"
 	log "github.com/sirupsen/logrus"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 	"google.golang.org/grpc/reflection"
 )
 
-func StartGrpcServer(database *gorm.DB, port int) {
-	grpcServer := grpc.NewServer()
+type Server struct {
+	GRPC            *grpc.Server
+	Checker         *health.Checker
+	Certificates    *CertificateReloader
+	RateLimit       *ratelimit.Limiter
+	Port            int
+	GracefulTimeout time.Duration
+	listening       chan struct{}
+}
+
+type ServerConfig struct {
+	Port        int
+	TLS         *TLSOptions
+	Auth        *AuthOptions
+	RateLimit   *ratelimit.Config
+	MultiTenant bool
+}
+
+func NewGrpcServer(database *gorm.DB, broker kafka.Broker, config *ServerConfig) (*Server, error) {
+	interceptors := NewInterceptors()
+
+	if config.Auth.Enabled() {
+		authenticator, err := NewAuthenticator(config.Auth)
+
+		if err != nil {
+			return nil, err
+		}
+
+		interceptors.Auth = authenticator
+	} else if config.MultiTenant {
+		return nil, errTenancyWithoutAuth
+	} else {
+		log.Warn("gRPC authentication is disabled, every caller is an admin of the default tenant")
+	}
+
+	if config.RateLimit.Enabled() {
+		interceptors.RateLimit = ratelimit.NewLimiter(config.RateLimit, ratelimit.NewMemoryStore())
+	}
+
+	options := interceptors.ServerOptions()
+
+	var reloader *CertificateReloader
+
+	if config.TLS.Enabled() {
+		var err error
+
+		reloader, err = NewCertificateReloader(config.TLS)
+
+		if err != nil {
+			return nil, err
+		}
+
+		options = append(options, grpc.Creds(credentials.NewTLS(reloader.TLSConfig())))
+	}
+
+	grpcServer := grpc.NewServer(options...)
 
 	reflection.Register(grpcServer)
 
@@ -22,23 +85,111 @@ func StartGrpcServer(database *gorm.DB, port int) {
 
 	grpcHandler := NewTransactionGrpcHandler(
 		transactionController,
+		kafka.NewTransactionPublisher(broker),
+		factory.SagaControllerFactory(database),
+		factory.WatchControllerFactory(),
+		factory.BatchControllerFactory(database),
 	)
 
 	pb.RegisterPaymentServiceServer(grpcServer, grpcHandler)
+	pb.RegisterWebhookServiceServer(grpcServer, NewWebhookGrpcHandler(factory.WebhookControllerFactory(database)))
+	pb.RegisterAuditServiceServer(grpcServer, NewAuditGrpcHandler(factory.AuditControllerFactory(database)))
+
+	checker := newReadinessChecker(database, broker)
+	healthpb.RegisterHealthServer(grpcServer, checker.Server)
 
-	address := fmt.Sprintf("0.0.0.0:%d", port)
+	grpc_prometheus.EnableHandlingTimeHistogram()
+	grpc_prometheus.Register(grpcServer)
 
-	listener, err := net.Listen("tcp", address)
+	return &Server{
+		GRPC:            grpcServer,
+		Checker:         checker,
+		Certificates:    reloader,
+		RateLimit:       interceptors.RateLimit,
+		Port:            config.Port,
+		GracefulTimeout: 15 * time.Second,
+		listening:       make(chan struct{}),
+	}, nil
+}
+
+func (s *Server) Serve(ctx context.Context) error {
+	listener, err := net.Listen("tcp", fmt.Sprintf("0.0.0.0:%d", s.Port))
 
 	if err != nil {
-		log.Error(err)
+		return err
+	}
+
+	go s.Checker.Run(ctx)
+
+	if s.Certificates != nil {
+		go s.Certificates.Watch(ctx)
 	}
 
-	log.Infof("gRPC server has been started on port %d", port)
+	log.WithField("tls", s.Certificates != nil).Infof("gRPC server has been started on port %d", s.Port)
+	close(s.listening)
 
-	err = grpcServer.Serve(listener)
+	return s.GRPC.Serve(listener)
+}
 
-	if err != nil {
-		log.Fatal(err)
+// Ready blocks until the server is listening.
+func (s *Server) Ready(ctx context.Context) error {
+	select {
+	case <-s.listening:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (s *Server) Stop(ctx context.Context) error {
+	s.Checker.Server.Shutdown()
+
+	ctx, cancel := context.WithTimeout(ctx, s.GracefulTimeout)
+	defer cancel()
+
+	stopped := make(chan struct{})
+
+	go func() {
+		s.GRPC.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+		return nil
+	case <-ctx.Done():
+		s.GRPC.Stop()
+		return ctx.Err()
 	}
 }
+
+func newReadinessChecker(database *gorm.DB, broker kafka.Broker) *health.Checker {
+	checks := []health.Check{
+		{Name: "database", Ping: database.DB().PingContext},
+	}
+
+	if pinger, ok := broker.(kafka.Pinger); ok {
+		checks = append(checks, health.Check{
+			Name: "broker",
+			Ping: func(ctx context.Context) error {
+				timeout := time.Second
+
+				if deadline, ok := ctx.Deadline(); ok {
+					timeout = time.Until(deadline)
+				}
+
+				return pinger.Ping(timeout)
+			},
+		})
+	}
+
+	checker := health.NewChecker(checks...)
+	checker.Require(pb.PaymentService_ServiceDesc.ServiceName, "database", "broker")
+	checker.Require(pb.WebhookService_ServiceDesc.ServiceName, "database")
+
+	if interval, err := time.ParseDuration(os.Getenv("HEALTH_CHECK_INTERVAL")); err == nil && interval > 0 {
+		checker.Interval = interval
+	}
+
+	return checker
+}
@@ -0,0 +1,444 @@
+package grpc_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	grpc_handler "github.com/EdlanioJ/kbu/payments/application/grpc"
+	"github.com/EdlanioJ/kbu/payments/application/grpc/pb"
+	"github.com/EdlanioJ/kbu/payments/application/kafka"
+	"github.com/EdlanioJ/kbu/payments/data/service"
+	"github.com/EdlanioJ/kbu/payments/domain/entity"
+	"github.com/EdlanioJ/kbu/payments/infra/db/gorm/migration"
+	"github.com/EdlanioJ/kbu/payments/infra/db/gorm/repository"
+	"github.com/EdlanioJ/kbu/payments/presentation/controller"
+	"github.com/jinzhu/gorm"
+	_ "github.com/mattn/go-sqlite3"
+	uuid "github.com/satori/go.uuid"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+type chargeCounter struct {
+	mu      sync.Mutex
+	charges int
+}
+
+func (p *chargeCounter) Charge(transaction *entity.Transaction) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.charges++
+
+	return "ref-" + transaction.ID, nil
+}
+
+func (p *chargeCounter) Refund(transaction *entity.Transaction) error {
+	return nil
+}
+
+func (p *chargeCounter) count() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.charges
+}
+
+type noopSender struct{}
+
+func (noopSender) Send(url string, headers map[string]string, payload []byte) (int, error) {
+	return 200, nil
+}
+
+// tenancyFixture serves every RPC from the real handlers over SQLite, with
+// records of the acme tenant and an admin api key for acme and for globex.
+type tenancyFixture struct {
+	db       *gorm.DB
+	feed     *service.TransactionFeed
+	provider *chargeCounter
+	webhooks *service.Webhook
+
+	payments pb.PaymentServiceClient
+	hooks    pb.WebhookServiceClient
+	audit    pb.AuditServiceClient
+
+	acme   context.Context
+	globex context.Context
+
+	accountFrom *entity.Account
+	accountTo   *entity.Account
+	transaction *entity.Transaction
+	batchID     string
+	deliveryID  string
+}
+
+func newTenancyFixture(t *testing.T) *tenancyFixture {
+	is := require.New(t)
+
+	db, err := gorm.Open("sqlite3", ":memory:")
+	is.Nil(err)
+
+	db.LogMode(false)
+	db.DB().SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+
+	repository.RegisterTenancy(db)
+
+	migrator, err := migration.NewMigrator(db)
+	is.Nil(err)
+
+	_, err = migrator.Up(0)
+	is.Nil(err)
+
+	dir, err := ioutil.TempDir("", "tenancy")
+	is.Nil(err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	apiKeys := "["
+
+	for i, tenant := range []string{"acme", "globex"} {
+		sum := sha256.Sum256([]byte(tenant + "-key"))
+
+		if i > 0 {
+			apiKeys += ","
+		}
+
+		apiKeys += fmt.Sprintf(`{"key_sha256": "%s", "subject": "%s-admin", "roles": ["admin"], "tenant": "%s"}`, hex.EncodeToString(sum[:]), tenant, tenant)
+	}
+
+	options := &grpc_handler.AuthOptions{APIKeysFile: filepath.Join(dir, "api-keys.json")}
+	is.Nil(ioutil.WriteFile(options.APIKeysFile, []byte(apiKeys+"]"), 0600))
+
+	authenticator, err := grpc_handler.NewAuthenticator(options)
+	is.Nil(err)
+
+	f := &tenancyFixture{
+		db:       db,
+		feed:     service.NewTransactionFeed(),
+		provider: &chargeCounter{},
+		acme:     metadata.AppendToOutgoingContext(context.Background(), "x-api-key", "acme-key"),
+		globex:   metadata.AppendToOutgoingContext(context.Background(), "x-api-key", "globex-key"),
+	}
+
+	transactionRepo := repository.NewTransactionRepository(db)
+	accountRepo := repository.NewAccountRepository(db)
+	unitOfWork := repository.NewUnitOfWork(db)
+
+	sagaService := service.NewSaga(repository.NewSagaRepository(db), transactionRepo, accountRepo, unitOfWork, f.provider)
+	batchService := service.NewBatch(repository.NewBatchRepository(db), transactionRepo, accountRepo, unitOfWork)
+	f.webhooks = service.NewWebhook(repository.NewWebhookRepository(db), repository.NewWebhookDeliveryRepository(db), noopSender{})
+
+	grpcServer := grpc.NewServer(
+		grpc.UnaryInterceptor(authenticator.UnaryInterceptor),
+		grpc.StreamInterceptor(authenticator.StreamInterceptor),
+	)
+	pb.RegisterPaymentServiceServer(grpcServer, grpc_handler.NewTransactionGrpcHandler(
+		controller.NewTransaction(service.NewTransaction(transactionRepo, accountRepo)),
+		kafka.NewTransactionPublisher(kafka.NewMemoryBroker(16)),
+		controller.NewSaga(sagaService),
+		controller.NewWatch(f.feed),
+		controller.NewBatch(batchService),
+	))
+	pb.RegisterWebhookServiceServer(grpcServer, grpc_handler.NewWebhookGrpcHandler(controller.NewWebhook(f.webhooks)))
+	pb.RegisterAuditServiceServer(grpcServer, grpc_handler.NewAuditGrpcHandler(controller.NewAudit(service.NewAudit(repository.NewAuditRepository(db)))))
+
+	listener := bufconn.Listen(1024 * 1024)
+
+	go grpcServer.Serve(listener)
+	t.Cleanup(grpcServer.Stop)
+
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return listener.Dial() }),
+		grpc.WithInsecure(),
+	)
+	is.Nil(err)
+	t.Cleanup(func() { conn.Close() })
+
+	f.payments = pb.NewPaymentServiceClient(conn)
+	f.hooks = pb.NewWebhookServiceClient(conn)
+	f.audit = pb.NewAuditServiceClient(conn)
+
+	ctx := entity.WithTenant(context.Background(), "acme")
+
+	f.accountFrom, _ = entity.NewAccount(1000)
+	f.accountFrom.TenantID = "acme"
+	is.Nil(accountRepo.Save(ctx, f.accountFrom))
+
+	f.accountTo, _ = entity.NewAccount(0)
+	f.accountTo.TenantID = "acme"
+	is.Nil(accountRepo.Save(ctx, f.accountTo))
+
+	registered, err := f.payments.Register(f.acme, f.paymentRequest(pb.TransactionType_to_user))
+	is.Nil(err)
+
+	f.transaction, err = transactionRepo.Find(ctx, registered.Transaction.ID)
+	is.Nil(err)
+
+	batch, err := f.payments.RegisterBatch(f.acme, &pb.RegisterBatchRequest{
+		Mode:  pb.BatchMode_best_effort,
+		Items: []*pb.RegisterRequest{f.paymentRequest(pb.TransactionType_to_user)},
+	})
+	is.Nil(err)
+	is.Equal(int32(1), batch.Succeeded)
+	f.batchID = batch.ID
+
+	_, err = f.hooks.RegisterWebhook(f.acme, &pb.RegisterWebhookRequest{AccountID: f.accountTo.ID, Url: "https://acme.kbu.test/hooks"})
+	is.Nil(err)
+
+	deliveries, err := f.webhooks.Notify(context.Background(), entity.WebhookPaymentCompleted, f.transaction)
+	is.Nil(err)
+	is.Len(deliveries, 1)
+	f.deliveryID = deliveries[0].ID
+
+	return f
+}
+
+func (f *tenancyFixture) paymentRequest(transactionType pb.TransactionType) *pb.RegisterRequest {
+	return &pb.RegisterRequest{
+		AccountFrom: f.accountFrom.ID,
+		AccountTo:   f.accountTo.ID,
+		ExternalID:  uuid.NewV4().String(),
+		Type:        transactionType,
+		Currency:    "AOA",
+		Amount:      10,
+	}
+}
+
+func (f *tenancyFixture) balance(t *testing.T) float64 {
+	account, err := repository.NewAccountRepository(f.db).Find(context.Background(), f.accountFrom.ID)
+	require.Nil(t, err)
+
+	return account.Balance
+}
+
+func TestTenancy(t *testing.T) {
+	t.Parallel()
+
+	f := newTenancyFixture(t)
+	page := &pb.PaginationRequest{Page: 1, Limit: 10, Sort: "created_at"}
+
+	t.Run("Register should not pay from the account of another tenant", func(t *testing.T) {
+		is := require.New(t)
+		before := f.balance(t)
+
+		_, err := f.payments.Register(f.globex, f.paymentRequest(pb.TransactionType_to_user))
+
+		is.Equal(codes.NotFound, status.Code(err))
+		is.Equal(before, f.balance(t))
+	})
+
+	t.Run("Register should not charge a service payment from another tenant", func(t *testing.T) {
+		is := require.New(t)
+		before := f.balance(t)
+
+		_, err := f.payments.Register(f.globex, f.paymentRequest(pb.TransactionType_to_service))
+
+		is.NotNil(err)
+		is.Equal(0, f.provider.count())
+		is.Equal(before, f.balance(t))
+	})
+
+	t.Run("Get should not find a payment of another tenant", func(t *testing.T) {
+		_, err := f.payments.Get(f.globex, &pb.Request{ID: f.transaction.ID})
+
+		require.Equal(t, codes.NotFound, status.Code(err))
+	})
+
+	t.Run("List should not list the payments of another tenant", func(t *testing.T) {
+		_, err := f.payments.List(f.globex, page)
+
+		require.Equal(t, codes.NotFound, status.Code(err))
+	})
+
+	t.Run("GetByType should not find a payment of another tenant", func(t *testing.T) {
+		_, err := f.payments.GetByType(f.globex, &pb.GetByTypeRequest{Type: pb.TransactionType_to_user, TransactionID: f.transaction.ID})
+
+		require.Equal(t, codes.NotFound, status.Code(err))
+	})
+
+	t.Run("ListByType should not list the payments of another tenant", func(t *testing.T) {
+		_, err := f.payments.ListByType(f.globex, &pb.ListByTypeRequest{Type: pb.TransactionType_to_user, Pagination: page})
+
+		require.Equal(t, codes.NotFound, status.Code(err))
+	})
+
+	t.Run("GetByReference should not find a payment of another tenant", func(t *testing.T) {
+		_, err := f.payments.GetByReference(f.globex, &pb.GetRequest{Id: f.transaction.ExternalID, TransactionID: f.transaction.ID})
+
+		require.Equal(t, codes.NotFound, status.Code(err))
+	})
+
+	t.Run("ListByReference should not list the payments of another tenant", func(t *testing.T) {
+		_, err := f.payments.ListByReference(f.globex, &pb.ListRequest{ID: f.transaction.ExternalID, Pagination: page})
+
+		require.Equal(t, codes.NotFound, status.Code(err))
+	})
+
+	t.Run("GetByAccountFrom should not find a payment of another tenant", func(t *testing.T) {
+		_, err := f.payments.GetByAccountFrom(f.globex, &pb.GetRequest{Id: f.accountFrom.ID, TransactionID: f.transaction.ID})
+
+		require.Equal(t, codes.NotFound, status.Code(err))
+	})
+
+	t.Run("ListByAccountFrom should not list the payments of another tenant", func(t *testing.T) {
+		_, err := f.payments.ListByAccountFrom(f.globex, &pb.ListRequest{ID: f.accountFrom.ID, Pagination: page})
+
+		require.Equal(t, codes.NotFound, status.Code(err))
+	})
+
+	t.Run("GetByAccountTo should not find a payment of another tenant", func(t *testing.T) {
+		_, err := f.payments.GetByAccountTo(f.globex, &pb.GetRequest{Id: f.accountTo.ID, TransactionID: f.transaction.ID})
+
+		require.Equal(t, codes.NotFound, status.Code(err))
+	})
+
+	t.Run("ListByAccountTo should not list the payments of another tenant", func(t *testing.T) {
+		_, err := f.payments.ListByAccountTo(f.globex, &pb.ListRequest{ID: f.accountTo.ID, Pagination: page})
+
+		require.Equal(t, codes.NotFound, status.Code(err))
+	})
+
+	t.Run("WatchTransaction should not watch a payment of another tenant", func(t *testing.T) {
+		stream, err := f.payments.WatchTransaction(f.globex, &pb.WatchRequest{ID: f.transaction.ID})
+		require.Nil(t, err)
+
+		_, err = stream.Recv()
+
+		require.Equal(t, codes.NotFound, status.Code(err))
+	})
+
+	t.Run("WatchAccount should not stream the payments of another tenant", func(t *testing.T) {
+		is := require.New(t)
+		f.feed.Publish(f.transaction)
+		f.feed.Publish(f.transaction)
+		version := uint64(1)
+
+		acme, cancel := context.WithTimeout(f.acme, time.Second)
+		defer cancel()
+
+		stream, err := f.payments.WatchAccount(acme, &pb.WatchRequest{ID: f.accountFrom.ID, FromVersion: version})
+		is.Nil(err)
+
+		update, err := stream.Recv()
+		is.Nil(err)
+		is.Equal(f.transaction.ID, update.Transaction.ID)
+
+		globex, cancel := context.WithTimeout(f.globex, 200*time.Millisecond)
+		defer cancel()
+
+		stream, err = f.payments.WatchAccount(globex, &pb.WatchRequest{ID: f.accountFrom.ID, FromVersion: version})
+		is.Nil(err)
+
+		_, err = stream.Recv()
+		is.Equal(codes.DeadlineExceeded, status.Code(err))
+	})
+
+	t.Run("ExportTransactions should not export the payments of another tenant", func(t *testing.T) {
+		is := require.New(t)
+
+		stream, err := f.payments.ExportTransactions(f.globex, &pb.ExportRequest{AccountFrom: f.accountFrom.ID})
+		is.Nil(err)
+
+		_, err = stream.Recv()
+		is.Equal(io.EOF, err)
+	})
+
+	t.Run("RegisterBatch should not pay from the accounts of another tenant", func(t *testing.T) {
+		is := require.New(t)
+		before := f.balance(t)
+
+		batch, err := f.payments.RegisterBatch(f.globex, &pb.RegisterBatchRequest{
+			Mode:  pb.BatchMode_best_effort,
+			Items: []*pb.RegisterRequest{f.paymentRequest(pb.TransactionType_to_user)},
+		})
+
+		is.Nil(err)
+		is.Equal(int32(0), batch.Succeeded)
+		is.Equal(int32(1), batch.Failed)
+		is.Equal(before, f.balance(t))
+	})
+
+	t.Run("RegisterBatchStream should not pay from the accounts of another tenant", func(t *testing.T) {
+		is := require.New(t)
+		before := f.balance(t)
+
+		stream, err := f.payments.RegisterBatchStream(f.globex)
+		is.Nil(err)
+		is.Nil(stream.Send(&pb.RegisterBatchStreamRequest{Mode: pb.BatchMode_best_effort, Item: f.paymentRequest(pb.TransactionType_to_user)}))
+
+		batch, err := stream.CloseAndRecv()
+
+		is.Nil(err)
+		is.Equal(int32(0), batch.Succeeded)
+		is.Equal(int32(1), batch.Failed)
+		is.Equal(before, f.balance(t))
+	})
+
+	t.Run("GetBatch should not find a batch of another tenant", func(t *testing.T) {
+		is := require.New(t)
+
+		_, err := f.payments.GetBatch(f.acme, &pb.Request{ID: f.batchID})
+		is.Nil(err)
+
+		_, err = f.payments.GetBatch(f.globex, &pb.Request{ID: f.batchID})
+		is.Equal(codes.NotFound, status.Code(err))
+	})
+
+	t.Run("RegisterWebhook should not deliver the payments of another tenant", func(t *testing.T) {
+		is := require.New(t)
+
+		_, err := f.hooks.RegisterWebhook(f.globex, &pb.RegisterWebhookRequest{AccountID: f.accountTo.ID, Url: "https://globex.kbu.test/hooks"})
+		is.Nil(err)
+
+		deliveries, err := f.webhooks.Notify(context.Background(), entity.WebhookPaymentCompleted, f.transaction)
+
+		is.Nil(err)
+		is.Len(deliveries, 1)
+		is.Equal("acme", deliveries[0].TenantID)
+	})
+
+	t.Run("GetDelivery should not find a delivery of another tenant", func(t *testing.T) {
+		is := require.New(t)
+
+		_, err := f.hooks.GetDelivery(f.acme, &pb.DeliveryRequest{DeliveryID: f.deliveryID})
+		is.Nil(err)
+
+		_, err = f.hooks.GetDelivery(f.globex, &pb.DeliveryRequest{DeliveryID: f.deliveryID})
+		is.Equal(codes.NotFound, status.Code(err))
+	})
+
+	t.Run("Redeliver should not send a delivery of another tenant", func(t *testing.T) {
+		_, err := f.hooks.Redeliver(f.globex, &pb.DeliveryRequest{DeliveryID: f.deliveryID})
+
+		require.Equal(t, codes.NotFound, status.Code(err))
+	})
+
+	t.Run("ListAuditEntries should not list the audit log of another tenant", func(t *testing.T) {
+		is := require.New(t)
+
+		request := &pb.ListAuditEntriesRequest{EntityID: f.accountFrom.ID, Page: 1, Limit: 10}
+
+		entries, err := f.audit.ListAuditEntries(f.acme, request)
+		is.Nil(err)
+		is.NotZero(entries.Total)
+
+		entries, err = f.audit.ListAuditEntries(f.globex, request)
+		is.Nil(err)
+		is.Zero(entries.Total)
+		is.Empty(entries.Entries)
+	})
+}
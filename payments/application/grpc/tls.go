@@ -0,0 +1,206 @@
+package grpc
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	errMissingKeyPair  = errors.New("tls requires both a certificate and a key")
+	errMissingClientCA = errors.New("mutual tls requires a client ca")
+	errInvalidClientCA = errors.New("client ca does not contain any certificate")
+)
+
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+type TLSOptions struct {
+	CertFile          string
+	KeyFile           string
+	ClientCAFile      string
+	MinVersion        string
+	RequireClientCert bool
+	ReloadInterval    time.Duration
+}
+
+func (o *TLSOptions) Enabled() bool {
+	return o != nil && (o.CertFile != "" || o.KeyFile != "")
+}
+
+type CertificateReloader struct {
+	Options     *TLSOptions
+	mu          sync.RWMutex
+	certificate *tls.Certificate
+	clientCAs   *x509.CertPool
+	minVersion  uint16
+	modified    map[string]time.Time
+}
+
+func NewCertificateReloader(options *TLSOptions) (*CertificateReloader, error) {
+	if options.CertFile == "" || options.KeyFile == "" {
+		return nil, errMissingKeyPair
+	}
+
+	if options.RequireClientCert && options.ClientCAFile == "" {
+		return nil, errMissingClientCA
+	}
+
+	minVersion := uint16(tls.VersionTLS12)
+
+	if options.MinVersion != "" {
+		version, ok := tlsVersions[options.MinVersion]
+
+		if !ok {
+			return nil, fmt.Errorf("unsupported tls version %q", options.MinVersion)
+		}
+
+		minVersion = version
+	}
+
+	reloader := &CertificateReloader{
+		Options:    options,
+		minVersion: minVersion,
+		modified:   make(map[string]time.Time),
+	}
+
+	_, err := reloader.Reload()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return reloader, nil
+}
+
+func (r *CertificateReloader) Reload() (bool, error) {
+	files := []string{r.Options.CertFile, r.Options.KeyFile}
+
+	if r.Options.ClientCAFile != "" {
+		files = append(files, r.Options.ClientCAFile)
+	}
+
+	modified := make(map[string]time.Time, len(files))
+	changed := false
+
+	for _, file := range files {
+		info, err := os.Stat(file)
+
+		if err != nil {
+			return false, err
+		}
+
+		modified[file] = info.ModTime()
+
+		if !info.ModTime().Equal(r.modified[file]) {
+			changed = true
+		}
+	}
+
+	if !changed {
+		return false, nil
+	}
+
+	certificate, err := tls.LoadX509KeyPair(r.Options.CertFile, r.Options.KeyFile)
+
+	if err != nil {
+		return false, err
+	}
+
+	var clientCAs *x509.CertPool
+
+	if r.Options.ClientCAFile != "" {
+		pem, err := ioutil.ReadFile(r.Options.ClientCAFile)
+
+		if err != nil {
+			return false, err
+		}
+
+		clientCAs = x509.NewCertPool()
+
+		if !clientCAs.AppendCertsFromPEM(pem) {
+			return false, errInvalidClientCA
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.certificate = &certificate
+	r.clientCAs = clientCAs
+	r.modified = modified
+
+	return true, nil
+}
+
+func (r *CertificateReloader) Watch(ctx context.Context) {
+	interval := r.Options.ReloadInterval
+
+	if interval <= 0 {
+		interval = 10 * time.Second
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		reloaded, err := r.Reload()
+
+		if err != nil {
+			log.WithError(err).Error("could not reload tls certificates, keeping the current ones")
+			continue
+		}
+
+		if reloaded {
+			log.Info("tls certificates have been reloaded")
+		}
+	}
+}
+
+func (r *CertificateReloader) TLSConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion:         r.minVersion,
+		GetConfigForClient: r.configForClient,
+	}
+}
+
+func (r *CertificateReloader) configForClient(*tls.ClientHelloInfo) (*tls.Config, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	clientAuth := tls.NoClientCert
+
+	if r.clientCAs != nil {
+		clientAuth = tls.VerifyClientCertIfGiven
+	}
+
+	if r.Options.RequireClientCert {
+		clientAuth = tls.RequireAndVerifyClientCert
+	}
+
+	return &tls.Config{
+		MinVersion:   r.minVersion,
+		Certificates: []tls.Certificate{*r.certificate},
+		ClientCAs:    r.clientCAs,
+		ClientAuth:   clientAuth,
+		NextProtos:   []string{"h2"},
+	}, nil
+}
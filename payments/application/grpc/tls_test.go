@@ -0,0 +1,256 @@
+package grpc_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	grpc_handler "github.com/EdlanioJ/kbu/payments/application/grpc"
+	grpc_middleware "github.com/grpc-ecosystem/go-grpc-middleware"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+type testCA struct {
+	certificate *x509.Certificate
+	key         *ecdsa.PrivateKey
+	serial      int64
+}
+
+func newTestCA(t *testing.T) *testCA {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.Nil(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "kbu test ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.Nil(t, err)
+
+	certificate, err := x509.ParseCertificate(der)
+	require.Nil(t, err)
+
+	return &testCA{certificate: certificate, key: key, serial: 1}
+}
+
+func (ca *testCA) pem() []byte {
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ca.certificate.Raw})
+}
+
+func (ca *testCA) issue(t *testing.T, commonName string, usage x509.ExtKeyUsage) ([]byte, []byte) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.Nil(t, err)
+
+	ca.serial++
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(ca.serial),
+		Subject:      pkix.Name{CommonName: commonName, Organization: []string{"kbu"}},
+		DNSNames:     []string{"localhost"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.certificate, &key.PublicKey, ca.key)
+	require.Nil(t, err)
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.Nil(t, err)
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+func writeFile(t *testing.T, path string, content []byte, modified time.Time) {
+	require.Nil(t, ioutil.WriteFile(path, content, 0600))
+	require.Nil(t, os.Chtimes(path, modified, modified))
+}
+
+type tlsFixture struct {
+	ca       *testCA
+	options  *grpc_handler.TLSOptions
+	reloader *grpc_handler.CertificateReloader
+	listener *bufconn.Listener
+	callers  chan *grpc_handler.Caller
+}
+
+func newTLSFixture(t *testing.T, requireClientCert bool) *tlsFixture {
+	dir, err := ioutil.TempDir("", "kbu-tls")
+	require.Nil(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	ca := newTestCA(t)
+	cert, key := ca.issue(t, "payments", x509.ExtKeyUsageServerAuth)
+	modified := time.Now().Add(-time.Minute)
+
+	options := &grpc_handler.TLSOptions{
+		CertFile:          filepath.Join(dir, "server.crt"),
+		KeyFile:           filepath.Join(dir, "server.key"),
+		ClientCAFile:      filepath.Join(dir, "ca.crt"),
+		RequireClientCert: requireClientCert,
+	}
+
+	writeFile(t, options.CertFile, cert, modified)
+	writeFile(t, options.KeyFile, key, modified)
+	writeFile(t, options.ClientCAFile, ca.pem(), modified)
+
+	reloader, err := grpc_handler.NewCertificateReloader(options)
+	require.Nil(t, err)
+
+	fixture := &tlsFixture{
+		ca:       ca,
+		options:  options,
+		reloader: reloader,
+		listener: bufconn.Listen(1024 * 1024),
+		callers:  make(chan *grpc_handler.Caller, 1),
+	}
+
+	capture := func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		caller, _ := grpc_handler.CallerFromContext(ctx)
+		fixture.callers <- caller
+		return handler(ctx, req)
+	}
+
+	interceptors, _ := newTestInterceptors()
+	server := grpc.NewServer(
+		grpc.Creds(credentials.NewTLS(reloader.TLSConfig())),
+		grpc_middleware.WithUnaryServerChain(append(interceptors.Unary(), capture)...),
+	)
+	healthpb.RegisterHealthServer(server, health.NewServer())
+
+	go server.Serve(fixture.listener)
+	t.Cleanup(server.Stop)
+
+	return fixture
+}
+
+func (f *tlsFixture) check(t *testing.T, config *tls.Config) error {
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) {
+			return f.listener.Dial()
+		}),
+		grpc.WithTransportCredentials(credentials.NewTLS(config)),
+	)
+	require.Nil(t, err)
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	_, err = healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{})
+
+	return err
+}
+
+func (f *tlsFixture) clientConfig(t *testing.T, commonName string) *tls.Config {
+	roots := x509.NewCertPool()
+	roots.AddCert(f.ca.certificate)
+
+	config := &tls.Config{RootCAs: roots, ServerName: "localhost"}
+
+	if commonName != "" {
+		cert, key := f.ca.issue(t, commonName, x509.ExtKeyUsageClientAuth)
+		pair, err := tls.X509KeyPair(cert, key)
+		require.Nil(t, err)
+
+		config.Certificates = []tls.Certificate{pair}
+	}
+
+	return config
+}
+
+func TestTLS(t *testing.T) {
+	t.Parallel()
+
+	t.Run("should expose the client certificate as caller", func(t *testing.T) {
+		is := require.New(t)
+		fixture := newTLSFixture(t, true)
+
+		is.Nil(fixture.check(t, fixture.clientConfig(t, "billing")))
+
+		caller := <-fixture.callers
+		is.NotNil(caller)
+		is.Equal("billing", caller.CommonName)
+		is.Equal([]string{"kbu"}, caller.Organization)
+		is.Contains(caller.Subject, "CN=billing")
+	})
+
+	t.Run("should reject clients without certificate when mtls is required", func(t *testing.T) {
+		is := require.New(t)
+		fixture := newTLSFixture(t, true)
+
+		is.Error(fixture.check(t, fixture.clientConfig(t, "")))
+	})
+
+	t.Run("should accept anonymous clients without mtls", func(t *testing.T) {
+		is := require.New(t)
+		fixture := newTLSFixture(t, false)
+
+		is.Nil(fixture.check(t, fixture.clientConfig(t, "")))
+		is.Nil(<-fixture.callers)
+	})
+
+	t.Run("should reload certificates when files change", func(t *testing.T) {
+		is := require.New(t)
+		fixture := newTLSFixture(t, false)
+
+		reloaded, err := fixture.reloader.Reload()
+		is.Nil(err)
+		is.False(reloaded)
+
+		cert, key := fixture.ca.issue(t, "payments-rotated", x509.ExtKeyUsageServerAuth)
+		writeFile(t, fixture.options.CertFile, cert, time.Now())
+		writeFile(t, fixture.options.KeyFile, key, time.Now())
+
+		reloaded, err = fixture.reloader.Reload()
+		is.Nil(err)
+		is.True(reloaded)
+
+		var served string
+		config := fixture.clientConfig(t, "")
+		config.VerifyConnection = func(state tls.ConnectionState) error {
+			served = state.PeerCertificates[0].Subject.CommonName
+			return nil
+		}
+
+		is.Nil(fixture.check(t, config))
+		is.Equal("payments-rotated", served)
+	})
+
+	t.Run("should refuse mtls without a client ca", func(t *testing.T) {
+		is := require.New(t)
+
+		_, err := grpc_handler.NewCertificateReloader(&grpc_handler.TLSOptions{
+			CertFile:          "server.crt",
+			KeyFile:           "server.key",
+			RequireClientCert: true,
+		})
+
+		is.Error(err)
+	})
+}
@@ -0,0 +1,109 @@
+package grpc
+
+import (
+	"context"
+	"strings"
+
+	grpc_middleware "github.com/grpc-ecosystem/go-grpc-middleware"
+	grpc_ctxtags "github.com/grpc-ecosystem/go-grpc-middleware/tags"
+	"go.opentelemetry.io/otel"
+	otelcodes "go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	tracerName = "github.com/EdlanioJ/kbu/payments/application/grpc"
+	traceIDTag = "trace_id"
+)
+
+var tracer = otel.Tracer(tracerName)
+
+type metadataCarrier metadata.MD
+
+func (c metadataCarrier) Get(key string) string {
+	values := metadata.MD(c).Get(key)
+
+	if len(values) == 0 {
+		return ""
+	}
+
+	return values[0]
+}
+
+func (c metadataCarrier) Set(key, value string) {
+	metadata.MD(c).Set(key, value)
+}
+
+func (c metadataCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+
+	for key := range c {
+		keys = append(keys, key)
+	}
+
+	return keys
+}
+
+func tracingUnaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	ctx, span := startServerSpan(ctx, info.FullMethod)
+	defer span.End()
+
+	resp, err := handler(ctx, req)
+	endServerSpan(span, err)
+
+	return resp, err
+}
+
+func tracingStreamInterceptor(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, span := startServerSpan(stream.Context(), info.FullMethod)
+	defer span.End()
+
+	wrapped := grpc_middleware.WrapServerStream(stream)
+	wrapped.WrappedContext = ctx
+
+	err := handler(srv, wrapped)
+	endServerSpan(span, err)
+
+	return err
+}
+
+func startServerSpan(ctx context.Context, fullMethod string) (context.Context, trace.Span) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	ctx = otel.GetTextMapPropagator().Extract(ctx, metadataCarrier(md.Copy()))
+
+	name := strings.TrimPrefix(fullMethod, "/")
+	service, method := name, ""
+
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		service, method = name[:i], name[i+1:]
+	}
+
+	ctx, span := tracer.Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(
+			semconv.RPCSystemKey.String("grpc"),
+			semconv.RPCServiceKey.String(service),
+			semconv.RPCMethodKey.String(method),
+		),
+	)
+
+	if spanContext := span.SpanContext(); spanContext.IsValid() {
+		grpc_ctxtags.Extract(ctx).Set(traceIDTag, spanContext.TraceID().String())
+	}
+
+	return ctx, span
+}
+
+func endServerSpan(span trace.Span, err error) {
+	st := status.Convert(err)
+
+	span.SetAttributes(semconv.RPCGRPCStatusCodeKey.Int(int(st.Code())))
+
+	if err != nil {
+		span.SetStatus(otelcodes.Error, st.Message())
+	}
+}
@@ -0,0 +1,157 @@
+package grpc_test
+
+import (
+	"context"
+	"net/http"
+	"regexp"
+	"sync"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	grpc_handler "github.com/EdlanioJ/kbu/payments/application/grpc"
+	"github.com/EdlanioJ/kbu/payments/application/grpc/pb"
+	"github.com/EdlanioJ/kbu/payments/data/service"
+	"github.com/EdlanioJ/kbu/payments/infra/db/gorm/repository"
+	"github.com/EdlanioJ/kbu/payments/presentation/controller"
+	grpc_middleware "github.com/grpc-ecosystem/go-grpc-middleware"
+	grpc_ctxtags "github.com/grpc-ecosystem/go-grpc-middleware/tags"
+	"github.com/jinzhu/gorm"
+	uuid "github.com/satori/go.uuid"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	otelcodes "go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+var (
+	spanExporter     *tracetest.InMemoryExporter
+	spanExporterOnce sync.Once
+)
+
+func newTestSpanExporter() *tracetest.InMemoryExporter {
+	spanExporterOnce.Do(func() {
+		spanExporter = tracetest.NewInMemoryExporter()
+		otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(spanExporter)))
+		otel.SetTextMapPropagator(propagation.TraceContext{})
+	})
+
+	return spanExporter
+}
+
+func newTracedHandler() (*grpc_handler.TransactionGrpcHandler, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New()
+
+	if err != nil {
+		panic(err)
+	}
+
+	gdb, err := gorm.Open("postgres", db)
+
+	if err != nil {
+		panic(err)
+	}
+
+	gdb.LogMode(false)
+	repository.RegisterTracing(gdb)
+
+	transactionService := service.NewTransaction(repository.NewTransactionRepository(gdb), repository.NewAccountRepository(gdb))
+	handler := grpc_handler.NewTransactionGrpcHandler(controller.NewTransaction(transactionService), nil, nil, nil, nil)
+
+	return handler, mock
+}
+
+func TestTracing(t *testing.T) {
+	t.Parallel()
+
+	info := &grpc.UnaryServerInfo{FullMethod: "/github.com.edlanioj.kbu.payments.PaymentService/Get"}
+
+	t.Run("should trace a call from the interceptor down to its statements", func(t *testing.T) {
+		is := require.New(t)
+		exporter := newTestSpanExporter()
+		interceptors, _ := newTestInterceptors()
+		interceptors.Tracing = true
+		chain := grpc_middleware.ChainUnaryServer(interceptors.Unary()...)
+		handler, mock := newTracedHandler()
+
+		id := uuid.NewV4().String()
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "transactions"`)).
+			WithArgs(id).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "status"}).AddRow(id, "pending"))
+
+		remote, client := otel.Tracer("test").Start(context.Background(), "client")
+		header := http.Header{}
+		otel.GetTextMapPropagator().Inject(remote, propagation.HeaderCarrier(header))
+		md := metadata.Pairs("traceparent", header.Get("traceparent"))
+		client.End()
+
+		var traceIDTag interface{}
+		_, err := chain(metadata.NewIncomingContext(context.Background(), md), &pb.Request{ID: id}, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+			traceIDTag = grpc_ctxtags.Extract(ctx).Values()["trace_id"]
+			return handler.Get(ctx, req.(*pb.Request))
+		})
+
+		is.Nil(err)
+		is.Nil(mock.ExpectationsWereMet())
+
+		traceID := client.SpanContext().TraceID()
+		is.Equal(traceID.String(), traceIDTag)
+
+		spans := make(map[string]tracetest.SpanStub)
+		for _, span := range exporter.GetSpans() {
+			if span.SpanContext.TraceID() == traceID {
+				spans[span.Name] = span
+			}
+		}
+
+		tree := []struct{ parent, child string }{
+			{"client", "github.com.edlanioj.kbu.payments.PaymentService/Get"},
+			{"github.com.edlanioj.kbu.payments.PaymentService/Get", "controller.Transaction.Get"},
+			{"controller.Transaction.Get", "service.Transaction.Find"},
+			{"service.Transaction.Find", "repository.Transaction.Find"},
+			{"repository.Transaction.Find", "SELECT transactions"},
+		}
+
+		for _, edge := range tree {
+			parent, ok := spans[edge.parent]
+			is.True(ok, edge.parent)
+			child, ok := spans[edge.child]
+			is.True(ok, edge.child)
+			is.Equal(parent.SpanContext.SpanID(), child.Parent.SpanID(), edge.child)
+		}
+
+		is.Equal(trace.SpanKindServer, spans["github.com.edlanioj.kbu.payments.PaymentService/Get"].SpanKind)
+	})
+
+	t.Run("should mark the server span as failed when the call fails", func(t *testing.T) {
+		is := require.New(t)
+		exporter := newTestSpanExporter()
+		interceptors, _ := newTestInterceptors()
+		interceptors.Tracing = true
+		chain := grpc_middleware.ChainUnaryServer(interceptors.Unary()...)
+		handler, _ := newTracedHandler()
+
+		var traceID trace.TraceID
+		_, err := chain(context.Background(), &pb.Request{ID: "invalid"}, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+			traceID = trace.SpanContextFromContext(ctx).TraceID()
+			return handler.Get(ctx, req.(*pb.Request))
+		})
+
+		is.NotNil(err)
+
+		var statuses = make(map[string]otelcodes.Code)
+		for _, span := range exporter.GetSpans() {
+			if span.SpanContext.TraceID() == traceID {
+				statuses[span.Name] = span.Status.Code
+			}
+		}
+
+		is.Equal(otelcodes.Error, statuses["github.com.edlanioj.kbu.payments.PaymentService/Get"])
+		is.Equal(otelcodes.Error, statuses["controller.Transaction.Get"])
+		is.NotContains(statuses, "service.Transaction.Find")
+	})
+}
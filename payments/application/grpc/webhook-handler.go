@@ -0,0 +1,91 @@
+package grpc
+
+import (
+	"context"
+
+	"github.com/EdlanioJ/kbu/payments/application/grpc/pb"
+	"github.com/EdlanioJ/kbu/payments/domain/entity"
+	"github.com/EdlanioJ/kbu/payments/presentation/controller"
+	"google.golang.org/grpc/codes"
+)
+
+type WebhookGrpcHandler struct {
+	WebhookController *controller.Webhook
+
+	pb.UnimplementedWebhookServiceServer
+}
+
+func NewWebhookGrpcHandler(
+	webhook *controller.Webhook,
+) *WebhookGrpcHandler {
+
+	return &WebhookGrpcHandler{
+		WebhookController: webhook,
+	}
+}
+
+func (w *WebhookGrpcHandler) RegisterWebhook(ctx context.Context, in *pb.RegisterWebhookRequest) (*pb.WebhookResponse, error) {
+	response, err := w.WebhookController.Register(ctx, in.AccountID, in.Url)
+
+	if err != nil {
+		return nil, toStatus(err, codes.Internal)
+	}
+
+	return &pb.WebhookResponse{
+		Webhook: &pb.Webhook{
+			ID:        response.ID,
+			AccountID: response.AccountID,
+			Url:       response.URL,
+			Secret:    response.Secret,
+			Active:    response.Active,
+			CreatedAt: response.CreatedAt.String(),
+		},
+	}, nil
+}
+
+func (w *WebhookGrpcHandler) GetDelivery(ctx context.Context, in *pb.DeliveryRequest) (*pb.DeliveryResponse, error) {
+	delivery, attempts, err := w.WebhookController.GetDelivery(ctx, in.DeliveryID)
+
+	if err != nil {
+		return nil, toStatus(err, codes.NotFound)
+	}
+
+	return deliveryResponse(delivery, attempts), nil
+}
+
+func (w *WebhookGrpcHandler) Redeliver(ctx context.Context, in *pb.DeliveryRequest) (*pb.DeliveryResponse, error) {
+	delivery, attempts, err := w.WebhookController.Redeliver(ctx, in.DeliveryID)
+
+	if err != nil {
+		return nil, toStatus(err, codes.Internal)
+	}
+
+	return deliveryResponse(delivery, attempts), nil
+}
+
+func deliveryResponse(delivery *entity.WebhookDelivery, attempts []*entity.WebhookAttempt) *pb.DeliveryResponse {
+	response := &pb.DeliveryResponse{
+		Delivery: &pb.WebhookDelivery{
+			ID:            delivery.ID,
+			WebhookID:     delivery.WebhookID,
+			TransactionID: delivery.TransactionID,
+			Event:         delivery.Event,
+			Status:        delivery.Status,
+			Attempts:      int32(delivery.Attempts),
+			NextAttemptAt: delivery.NextAttemptAt.String(),
+			CreatedAt:     delivery.CreatedAt.String(),
+		},
+	}
+
+	for _, attempt := range attempts {
+		response.Attempts = append(response.Attempts, &pb.WebhookAttempt{
+			ID:         attempt.ID,
+			StatusCode: int32(attempt.StatusCode),
+			Error:      attempt.Error,
+			DurationMs: attempt.Duration,
+			CreatedAt:  attempt.CreatedAt.String(),
+		})
+	}
+
+	return response
+}
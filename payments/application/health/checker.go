@@ -0,0 +1,108 @@
+package health
+
+import (
+	"context"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+type Check struct {
+	Name string
+	Ping func(ctx context.Context) error
+}
+
+type Checker struct {
+	Server       *health.Server
+	Checks       []Check
+	Dependencies map[string][]string
+	Interval     time.Duration
+	Timeout      time.Duration
+	failing      map[string]bool
+}
+
+func NewChecker(checks ...Check) *Checker {
+	server := health.NewServer()
+	server.SetServingStatus("", healthpb.HealthCheckResponse_NOT_SERVING)
+
+	return &Checker{
+		Server:       server,
+		Checks:       checks,
+		Dependencies: make(map[string][]string),
+		Interval:     10 * time.Second,
+		Timeout:      2 * time.Second,
+		failing:      make(map[string]bool),
+	}
+}
+
+func (c *Checker) Require(service string, checks ...string) {
+	c.Dependencies[service] = checks
+	c.Server.SetServingStatus(service, healthpb.HealthCheckResponse_NOT_SERVING)
+}
+
+func (c *Checker) Run(ctx context.Context) {
+	log.Info("readiness checker has been started")
+
+	ticker := time.NewTicker(c.Interval)
+	defer ticker.Stop()
+
+	for {
+		c.Check(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (c *Checker) Check(ctx context.Context) {
+	failing := make(map[string]bool, len(c.Checks))
+
+	for _, check := range c.Checks {
+		checkCtx, cancel := context.WithTimeout(ctx, c.Timeout)
+		err := check.Ping(checkCtx)
+		cancel()
+
+		if err != nil {
+			failing[check.Name] = true
+
+			if !c.failing[check.Name] {
+				log.WithField("dependency", check.Name).WithError(err).Error("dependency is down")
+			}
+			continue
+		}
+
+		if c.failing[check.Name] {
+			log.WithField("dependency", check.Name).Info("dependency has recovered")
+		}
+	}
+
+	c.failing = failing
+
+	c.Server.SetServingStatus("", c.status(len(failing) == 0))
+
+	for service, dependencies := range c.Dependencies {
+		ready := true
+
+		for _, dependency := range dependencies {
+			if failing[dependency] {
+				ready = false
+				break
+			}
+		}
+
+		c.Server.SetServingStatus(service, c.status(ready))
+	}
+}
+
+func (c *Checker) status(ready bool) healthpb.HealthCheckResponse_ServingStatus {
+	if ready {
+		return healthpb.HealthCheckResponse_SERVING
+	}
+
+	return healthpb.HealthCheckResponse_NOT_SERVING
+}
@@ -0,0 +1,62 @@
+package health_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/EdlanioJ/kbu/payments/application/health"
+	"github.com/stretchr/testify/require"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func status(t *testing.T, checker *health.Checker, service string) healthpb.HealthCheckResponse_ServingStatus {
+	response, err := checker.Server.Check(context.Background(), &healthpb.HealthCheckRequest{Service: service})
+	require.Nil(t, err)
+
+	return response.Status
+}
+
+func TestChecker(t *testing.T) {
+	t.Parallel()
+
+	t.Run("should not serve before the first check", func(t *testing.T) {
+		is := require.New(t)
+
+		checker := health.NewChecker()
+		checker.Require("payments", "database")
+
+		is.Equal(healthpb.HealthCheckResponse_NOT_SERVING, status(t, checker, ""))
+		is.Equal(healthpb.HealthCheckResponse_NOT_SERVING, status(t, checker, "payments"))
+	})
+
+	t.Run("should flip status with dependencies", func(t *testing.T) {
+		is := require.New(t)
+
+		var brokerErr error
+
+		checker := health.NewChecker(
+			health.Check{Name: "database", Ping: func(context.Context) error { return nil }},
+			health.Check{Name: "broker", Ping: func(context.Context) error { return brokerErr }},
+		)
+		checker.Require("payments", "database", "broker")
+		checker.Require("webhooks", "database")
+
+		checker.Check(context.Background())
+
+		is.Equal(healthpb.HealthCheckResponse_SERVING, status(t, checker, ""))
+		is.Equal(healthpb.HealthCheckResponse_SERVING, status(t, checker, "payments"))
+
+		brokerErr = errors.New("broker is down")
+		checker.Check(context.Background())
+
+		is.Equal(healthpb.HealthCheckResponse_NOT_SERVING, status(t, checker, ""))
+		is.Equal(healthpb.HealthCheckResponse_NOT_SERVING, status(t, checker, "payments"))
+		is.Equal(healthpb.HealthCheckResponse_SERVING, status(t, checker, "webhooks"))
+
+		brokerErr = nil
+		checker.Check(context.Background())
+
+		is.Equal(healthpb.HealthCheckResponse_SERVING, status(t, checker, "payments"))
+	})
+}
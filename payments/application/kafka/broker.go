@@ -0,0 +1,96 @@
+package kafka
+
+import (
+	"errors"
+	"os"
+	"strconv"
+	"time"
+)
+
+const (
+	BrokerKafka  = "kafka"
+	BrokerMemory = "memory"
+)
+
+var (
+	errUnknownBroker    = errors.New("unknown broker")
+	errBrokerClosed     = errors.New("broker is closed")
+	errMessageNotLeased = errors.New("message was not delivered by a subscription")
+	errNotBrowsable     = errors.New("broker does not support browsing topics")
+)
+
+type Message struct {
+	Topic     string
+	Partition int32
+	Offset    int64
+	Key       []byte
+	Value     []byte
+	Headers   map[string]string
+	Timestamp time.Time
+
+	ack  func() error
+	nack func() error
+}
+
+func (m *Message) Header(key string) string {
+	if m.Headers == nil {
+		return ""
+	}
+
+	return m.Headers[key]
+}
+
+type Subscription interface {
+	Messages() <-chan *Message
+	Close() error
+}
+
+type Broker interface {
+	Publish(msg *Message) error
+	Subscribe(group string, topics []string) (Subscription, error)
+	Ack(msg *Message) error
+	Nack(msg *Message) error
+	Close() error
+}
+
+type Pinger interface {
+	Ping(timeout time.Duration) error
+}
+
+type Browser interface {
+	Browse(topic string, limit int) ([]*Message, error)
+	Read(topic string, partition int32, offset int64) (*Message, error)
+}
+
+func NewBroker() (Broker, error) {
+	switch os.Getenv("BROKER") {
+	case BrokerMemory:
+		partitions, err := strconv.Atoi(os.Getenv("BROKER_PARTITIONS"))
+
+		if err != nil {
+			partitions = 3
+		}
+
+		return NewMemoryBroker(partitions), nil
+	case "", BrokerKafka:
+		return NewKafkaBroker()
+	default:
+		return nil, errUnknownBroker
+	}
+}
+
+func ack(msg *Message) error {
+	if msg.ack == nil {
+		return errMessageNotLeased
+	}
+
+	return msg.ack()
+}
+
+func nack(msg *Message) error {
+	if msg.nack == nil {
+		return errMessageNotLeased
+	}
+
+	return msg.nack()
+}
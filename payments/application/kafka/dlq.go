@@ -0,0 +1,123 @@
+package kafka
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/EdlanioJ/kbu/payments/application/kafka/model"
+)
+
+var errMessageNotFound = errors.New("no message was found")
+
+type DeadLetterQueue struct {
+	Topic  string
+	Broker Broker
+}
+
+func NewDeadLetterQueue(topic string, broker Broker) *DeadLetterQueue {
+	return &DeadLetterQueue{
+		Topic:  DeadLetterTopic(topic),
+		Broker: broker,
+	}
+}
+
+func (d *DeadLetterQueue) browser() (Browser, error) {
+	browser, ok := d.Broker.(Browser)
+
+	if !ok {
+		return nil, errNotBrowsable
+	}
+
+	return browser, nil
+}
+
+func (d *DeadLetterQueue) List(limit int) ([]*model.DeadLetter, error) {
+	browser, err := d.browser()
+
+	if err != nil {
+		return nil, err
+	}
+
+	messages, err := browser.Browse(d.Topic, limit)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var deadLetters []*model.DeadLetter
+
+	for _, msg := range messages {
+		deadLetters = append(deadLetters, parseDeadLetter(msg))
+	}
+
+	return deadLetters, nil
+}
+
+func (d *DeadLetterQueue) Inspect(partition int32, offset int64) (*model.DeadLetter, error) {
+	browser, err := d.browser()
+
+	if err != nil {
+		return nil, err
+	}
+
+	msg, err := browser.Read(d.Topic, partition, offset)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return parseDeadLetter(msg), nil
+}
+
+func (d *DeadLetterQueue) Redrive(partition int32, offset int64) error {
+	browser, err := d.browser()
+
+	if err != nil {
+		return err
+	}
+
+	msg, err := browser.Read(d.Topic, partition, offset)
+
+	if err != nil {
+		return err
+	}
+
+	headers := carriedHeaders(msg)
+	headers[headerRedrivenFrom] = fmt.Sprintf("%s/%d/%d", d.Topic, partition, offset)
+
+	return d.Broker.Publish(&Message{
+		Topic:   originalTopic(msg),
+		Key:     msg.Key,
+		Value:   msg.Value,
+		Headers: headers,
+	})
+}
+
+func parseDeadLetter(msg *Message) *model.DeadLetter {
+	deadLetter := model.NewDeadLetter()
+
+	deadLetter.Partition = msg.Partition
+	deadLetter.Offset = msg.Offset
+	deadLetter.OriginalTopic = originalTopic(msg)
+	deadLetter.Attempts = retryAttempt(msg)
+	deadLetter.Error = msg.Header(headerError)
+	deadLetter.ErrorType = msg.Header(headerErrorType)
+	deadLetter.Key = string(msg.Key)
+	deadLetter.Payload = string(msg.Value)
+
+	if partition, err := strconv.Atoi(msg.Header(headerOriginalPartition)); err == nil {
+		deadLetter.OriginalPartition = int32(partition)
+	}
+
+	if offset, err := strconv.ParseInt(msg.Header(headerOriginalOffset), 10, 64); err == nil {
+		deadLetter.OriginalOffset = offset
+	}
+
+	if failedAt, err := time.Parse(time.RFC3339Nano, msg.Header(headerFailedAt)); err == nil {
+		deadLetter.FailedAt = failedAt
+	}
+
+	return deadLetter
+}
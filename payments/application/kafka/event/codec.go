@@ -0,0 +1,99 @@
+package event
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+
+	"github.com/EdlanioJ/kbu/payments/application/kafka/model"
+	"github.com/EdlanioJ/kbu/payments/application/kafka/pb"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	ContentTypeJSON     = "application/json"
+	ContentTypeProtobuf = "application/protobuf"
+)
+
+var errUnsupportedContentType = errors.New("unsupported data content type")
+
+type Codec interface {
+	ContentType() string
+	Marshal(transaction *model.Transaction) ([]byte, error)
+	Unmarshal(data []byte, transaction *model.Transaction) error
+}
+
+type JSONCodec struct{}
+
+func (JSONCodec) ContentType() string {
+	return ContentTypeJSON
+}
+
+func (JSONCodec) Marshal(transaction *model.Transaction) ([]byte, error) {
+	return transaction.ToJson()
+}
+
+func (JSONCodec) Unmarshal(data []byte, transaction *model.Transaction) error {
+	return transaction.ParseJson(data)
+}
+
+type ProtobufCodec struct{}
+
+func (ProtobufCodec) ContentType() string {
+	return ContentTypeProtobuf
+}
+
+func (ProtobufCodec) Marshal(transaction *model.Transaction) ([]byte, error) {
+	_, err := transaction.ToJson()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return proto.Marshal(&pb.TransactionV1{
+		ID:          transaction.ID,
+		AccountFrom: transaction.AccountFrom,
+		Amount:      transaction.Amount,
+		Status:      transaction.Status,
+		AccountTo:   transaction.AccountTo,
+		Store:       transaction.Store,
+		Service:     transaction.Service,
+	})
+}
+
+func (ProtobufCodec) Unmarshal(data []byte, transaction *model.Transaction) error {
+	message := &pb.TransactionV1{}
+
+	err := proto.Unmarshal(data, message)
+
+	if err != nil {
+		return err
+	}
+
+	result, err := json.Marshal(&model.Transaction{
+		ID:          message.ID,
+		AccountFrom: message.AccountFrom,
+		Amount:      message.Amount,
+		Status:      message.Status,
+		AccountTo:   message.AccountTo,
+		Store:       message.Store,
+		Service:     message.Service,
+	})
+
+	if err != nil {
+		return err
+	}
+
+	return transaction.ParseJson(result)
+}
+
+func CodecFor(contentType string) (Codec, error) {
+	switch {
+	case isJSON(contentType):
+		return JSONCodec{}, nil
+	case strings.HasPrefix(contentType, ContentTypeProtobuf):
+		return ProtobufCodec{}, nil
+	default:
+		return nil, errUnsupportedContentType
+	}
+}
@@ -0,0 +1,175 @@
+package event
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+const (
+	SpecVersion = "1.0"
+
+	ModeBinary     = "binary"
+	ModeStructured = "structured"
+
+	ContentTypeCloudEvents = "application/cloudevents+json"
+
+	headerContentType = "content-type"
+	headerPrefix      = "ce_"
+)
+
+var (
+	errMissingAttribute   = errors.New("missing required cloudevents attribute")
+	errUnknownSpecVersion = errors.New("unsupported cloudevents spec version")
+	errUnknownMode        = errors.New("unknown cloudevents content mode")
+)
+
+type Envelope struct {
+	SpecVersion     string    `json:"specversion"`
+	ID              string    `json:"id"`
+	Type            string    `json:"type"`
+	Source          string    `json:"source"`
+	Subject         string    `json:"subject,omitempty"`
+	Time            time.Time `json:"time"`
+	DataContentType string    `json:"datacontenttype,omitempty"`
+	DataSchema      string    `json:"dataschema,omitempty"`
+	SchemaVersion   string    `json:"schemaversion"`
+	TraceParent     string    `json:"traceparent,omitempty"`
+	TraceState      string    `json:"tracestate,omitempty"`
+	Data            []byte    `json:"-"`
+}
+
+type structuredEnvelope struct {
+	Envelope
+	Data       json.RawMessage `json:"data,omitempty"`
+	DataBase64 []byte          `json:"data_base64,omitempty"`
+}
+
+func (e *Envelope) isValid() error {
+	if e.SpecVersion != SpecVersion {
+		return errUnknownSpecVersion
+	}
+
+	if e.ID == "" || e.Type == "" || e.Source == "" || e.SchemaVersion == "" {
+		return errMissingAttribute
+	}
+
+	return nil
+}
+
+func New(eventType, schemaVersion, source, subject string) *Envelope {
+	return &Envelope{
+		SpecVersion:   SpecVersion,
+		ID:            uuid.NewV4().String(),
+		Type:          eventType,
+		Source:        source,
+		Subject:       subject,
+		Time:          time.Now().UTC(),
+		SchemaVersion: schemaVersion,
+	}
+}
+
+func Encode(e *Envelope, mode string) (map[string]string, []byte, error) {
+	err := e.isValid()
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	switch mode {
+	case ModeBinary, "":
+		headers := map[string]string{
+			headerPrefix + "specversion":   e.SpecVersion,
+			headerPrefix + "id":            e.ID,
+			headerPrefix + "type":          e.Type,
+			headerPrefix + "source":        e.Source,
+			headerPrefix + "time":          e.Time.Format(time.RFC3339Nano),
+			headerPrefix + "schemaversion": e.SchemaVersion,
+			headerContentType:              e.DataContentType,
+		}
+
+		if e.Subject != "" {
+			headers[headerPrefix+"subject"] = e.Subject
+		}
+
+		if e.DataSchema != "" {
+			headers[headerPrefix+"dataschema"] = e.DataSchema
+		}
+
+		if e.TraceParent != "" {
+			headers[headerPrefix+traceParent] = e.TraceParent
+		}
+
+		if e.TraceState != "" {
+			headers[headerPrefix+traceState] = e.TraceState
+		}
+
+		return headers, e.Data, nil
+	case ModeStructured:
+		structured := structuredEnvelope{Envelope: *e}
+
+		if isJSON(e.DataContentType) {
+			structured.Data = e.Data
+		} else {
+			structured.DataBase64 = e.Data
+		}
+
+		value, err := json.Marshal(structured)
+
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return map[string]string{headerContentType: ContentTypeCloudEvents}, value, nil
+	default:
+		return nil, nil, errUnknownMode
+	}
+}
+
+func Decode(headers map[string]string, value []byte) (*Envelope, error) {
+	if strings.HasPrefix(headers[headerContentType], ContentTypeCloudEvents) {
+		structured := structuredEnvelope{}
+
+		err := json.Unmarshal(value, &structured)
+
+		if err != nil {
+			return nil, err
+		}
+
+		e := structured.Envelope
+		e.Data = structured.DataBase64
+
+		if structured.Data != nil {
+			e.Data = structured.Data
+		}
+
+		return &e, e.isValid()
+	}
+
+	e := &Envelope{
+		SpecVersion:     headers[headerPrefix+"specversion"],
+		ID:              headers[headerPrefix+"id"],
+		Type:            headers[headerPrefix+"type"],
+		Source:          headers[headerPrefix+"source"],
+		Subject:         headers[headerPrefix+"subject"],
+		DataContentType: headers[headerContentType],
+		DataSchema:      headers[headerPrefix+"dataschema"],
+		SchemaVersion:   headers[headerPrefix+"schemaversion"],
+		TraceParent:     headers[headerPrefix+traceParent],
+		TraceState:      headers[headerPrefix+traceState],
+		Data:            value,
+	}
+
+	if eventTime, err := time.Parse(time.RFC3339Nano, headers[headerPrefix+"time"]); err == nil {
+		e.Time = eventTime
+	}
+
+	return e, e.isValid()
+}
+
+func isJSON(contentType string) bool {
+	return contentType == "" || strings.HasPrefix(contentType, ContentTypeJSON)
+}
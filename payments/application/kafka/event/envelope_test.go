@@ -0,0 +1,139 @@
+package event_test
+
+import (
+	"testing"
+
+	"github.com/EdlanioJ/kbu/payments/application/kafka/event"
+	"github.com/EdlanioJ/kbu/payments/application/kafka/model"
+	uuid "github.com/satori/go.uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func newTransaction() *model.Transaction {
+	return &model.Transaction{
+		ID:          uuid.NewV4().String(),
+		AccountFrom: uuid.NewV4().String(),
+		AccountTo:   uuid.NewV4().String(),
+		Amount:      30,
+		Status:      model.TransactionCompleted,
+	}
+}
+
+func TestEnvelope(t *testing.T) {
+	t.Parallel()
+
+	for _, mode := range []string{event.ModeBinary, event.ModeStructured} {
+		for _, codec := range []event.Codec{event.JSONCodec{}, event.ProtobufCodec{}} {
+			mode, codec := mode, codec
+
+			t.Run("should round trip "+codec.ContentType()+" in "+mode+" mode", func(t *testing.T) {
+				is := require.New(t)
+				transaction := newTransaction()
+				registry := event.NewDefaultRegistry()
+
+				data, err := codec.Marshal(transaction)
+				is.Nil(err)
+
+				envelope := event.New(event.TransactionConfirmed, "1", event.Source, transaction.ID)
+				envelope.DataContentType = codec.ContentType()
+				envelope.Data = data
+
+				headers, value, err := event.Encode(envelope, mode)
+				is.Nil(err)
+
+				decoded, err := registry.Decode(headers, value)
+				is.Nil(err)
+				is.Equal(envelope.ID, decoded.ID)
+				is.Equal(envelope.Type, decoded.Type)
+				is.Equal(envelope.Source, decoded.Source)
+				is.Equal(envelope.SchemaVersion, decoded.SchemaVersion)
+				is.True(envelope.Time.Equal(decoded.Time))
+
+				negotiated, err := event.CodecFor(decoded.DataContentType)
+				is.Nil(err)
+
+				result := model.NewTransaction()
+				is.Nil(negotiated.Unmarshal(decoded.Data, result))
+				is.Equal(transaction, result)
+			})
+		}
+	}
+
+	for _, mode := range []string{event.ModeBinary, event.ModeStructured} {
+		mode := mode
+
+		t.Run("should carry the trace context in "+mode+" mode", func(t *testing.T) {
+			is := require.New(t)
+			transaction := newTransaction()
+
+			envelope := event.New(event.TransactionConfirmed, "1", event.Source, transaction.ID)
+			envelope.DataContentType = event.JSONCodec{}.ContentType()
+			envelope.Data, _ = event.JSONCodec{}.Marshal(transaction)
+			envelope.TraceParent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+			envelope.TraceState = "kbu=1"
+
+			headers, value, err := event.Encode(envelope, mode)
+			is.Nil(err)
+
+			decoded, err := event.Decode(headers, value)
+			is.Nil(err)
+			is.Equal(envelope.TraceParent, decoded.TraceParent)
+			is.Equal(envelope.TraceState, decoded.TraceState)
+		})
+	}
+
+	t.Run("should fail on missing attributes", func(t *testing.T) {
+		is := require.New(t)
+
+		_, err := event.Decode(map[string]string{}, []byte(`{"id": "1"}`))
+		is.NotNil(err)
+
+		_, _, err = event.Encode(&event.Envelope{SpecVersion: event.SpecVersion}, event.ModeBinary)
+		is.NotNil(err)
+	})
+
+	t.Run("should fail on unsupported content type", func(t *testing.T) {
+		is := require.New(t)
+
+		_, err := event.CodecFor("application/avro")
+		is.NotNil(err)
+	})
+}
+
+func TestRegistry(t *testing.T) {
+	t.Parallel()
+
+	t.Run("should reject unknown types and versions", func(t *testing.T) {
+		is := require.New(t)
+		registry := event.NewDefaultRegistry()
+
+		_, err := registry.Lookup(event.TransactionConfirmed, "1")
+		is.Nil(err)
+
+		_, err = registry.Lookup(event.TransactionConfirmed, "2")
+		is.EqualError(err, "unknown event schema version")
+
+		_, err = registry.Lookup("kbu.payments.unknown", "1")
+		is.EqualError(err, "unknown event type")
+
+		envelope := event.New(event.TransactionConfirmed, "2", event.Source, "")
+		envelope.DataContentType = event.ContentTypeJSON
+		envelope.Data = []byte(`{}`)
+
+		headers, value, err := event.Encode(envelope, event.ModeBinary)
+		is.Nil(err)
+
+		_, err = registry.Decode(headers, value)
+		is.EqualError(err, "unknown event schema version")
+	})
+
+	t.Run("should resolve schema by content type", func(t *testing.T) {
+		is := require.New(t)
+		registry := event.NewDefaultRegistry()
+
+		definition, err := registry.Lookup(event.TransactionRegistered, "1")
+		is.Nil(err)
+		is.Contains(definition.DataSchema(event.ContentTypeJSON), "transaction.v1.json")
+		is.Contains(definition.DataSchema(event.ContentTypeProtobuf), "TransactionV1")
+	})
+}
@@ -0,0 +1,111 @@
+package event
+
+import (
+	"errors"
+	"sync"
+)
+
+const (
+	Source = "/kbu/payments"
+
+	TransactionRegistered = "kbu.payments.transaction.registered"
+	TransactionConfirmed  = "kbu.payments.transaction.confirmed"
+	TransactionUpdated    = "kbu.payments.transaction.updated"
+
+	schemaBaseURL = "https://github.com/EdlanioJ/kbu/payments/application/kafka/"
+)
+
+var (
+	errUnknownEventType = errors.New("unknown event type")
+	errUnknownVersion   = errors.New("unknown event schema version")
+)
+
+type Definition struct {
+	Type        string
+	Version     string
+	ProtoSchema string
+	JSONSchema  string
+}
+
+func (d *Definition) DataSchema(contentType string) string {
+	if isJSON(contentType) {
+		return d.JSONSchema
+	}
+
+	return d.ProtoSchema
+}
+
+type Registry struct {
+	mu          sync.RWMutex
+	definitions map[string]map[string]*Definition
+}
+
+func NewRegistry() *Registry {
+	return &Registry{
+		definitions: make(map[string]map[string]*Definition),
+	}
+}
+
+func (r *Registry) Register(definition *Definition) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	versions, ok := r.definitions[definition.Type]
+
+	if !ok {
+		versions = make(map[string]*Definition)
+		r.definitions[definition.Type] = versions
+	}
+
+	versions[definition.Version] = definition
+}
+
+func (r *Registry) Lookup(eventType, version string) (*Definition, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	versions, ok := r.definitions[eventType]
+
+	if !ok {
+		return nil, errUnknownEventType
+	}
+
+	definition, ok := versions[version]
+
+	if !ok {
+		return nil, errUnknownVersion
+	}
+
+	return definition, nil
+}
+
+func (r *Registry) Decode(headers map[string]string, value []byte) (*Envelope, error) {
+	e, err := Decode(headers, value)
+
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = r.Lookup(e.Type, e.SchemaVersion)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return e, nil
+}
+
+func NewDefaultRegistry() *Registry {
+	registry := NewRegistry()
+
+	for _, eventType := range []string{TransactionRegistered, TransactionConfirmed, TransactionUpdated} {
+		registry.Register(&Definition{
+			Type:        eventType,
+			Version:     "1",
+			ProtoSchema: schemaBaseURL + "protofiles/transaction.proto#TransactionV1",
+			JSONSchema:  schemaBaseURL + "schemas/transaction.v1.json",
+		})
+	}
+
+	return registry
+}
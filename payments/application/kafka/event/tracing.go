@@ -0,0 +1,50 @@
+package event
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+)
+
+const (
+	traceParent = "traceparent"
+	traceState  = "tracestate"
+)
+
+// traceCarrier maps the W3C trace context onto the cloudevents distributed
+// tracing extension attributes of an envelope.
+type traceCarrier struct {
+	envelope *Envelope
+}
+
+func (c traceCarrier) Get(key string) string {
+	switch key {
+	case traceParent:
+		return c.envelope.TraceParent
+	case traceState:
+		return c.envelope.TraceState
+	}
+
+	return ""
+}
+
+func (c traceCarrier) Set(key, value string) {
+	switch key {
+	case traceParent:
+		c.envelope.TraceParent = value
+	case traceState:
+		c.envelope.TraceState = value
+	}
+}
+
+func (c traceCarrier) Keys() []string {
+	return []string{traceParent, traceState}
+}
+
+func (e *Envelope) InjectTrace(ctx context.Context) {
+	otel.GetTextMapPropagator().Inject(ctx, traceCarrier{envelope: e})
+}
+
+func (e *Envelope) ExtractTrace(ctx context.Context) context.Context {
+	return otel.GetTextMapPropagator().Extract(ctx, traceCarrier{envelope: e})
+}
@@ -0,0 +1,115 @@
+package kafka
+
+import (
+	"context"
+	"os"
+	"time"
+
+	"github.com/EdlanioJ/kbu/payments/application/kafka/event"
+	"github.com/EdlanioJ/kbu/payments/data/protocol"
+	"github.com/EdlanioJ/kbu/payments/data/repository"
+	"github.com/EdlanioJ/kbu/payments/domain/entity"
+	uuid "github.com/satori/go.uuid"
+	log "github.com/sirupsen/logrus"
+)
+
+// TransactionNotifier shares the status changes of this process through the
+// broker, so the FeedRelay of every process serving Watch sees them.
+type TransactionNotifier struct {
+	Publisher *TransactionPublisher
+}
+
+func NewTransactionNotifier(broker Broker) *TransactionNotifier {
+	publisher := NewTransactionPublisher(broker)
+	publisher.Topic = os.Getenv("KAFKA_TRANSACTION_UPDATE_TOPIC")
+	publisher.EventType = event.TransactionUpdated
+
+	return &TransactionNotifier{
+		Publisher: publisher,
+	}
+}
+
+func (n *TransactionNotifier) Publish(transaction *entity.Transaction) {
+	err := n.Publisher.Publish(context.Background(), transaction)
+
+	if err != nil {
+		log.
+			WithField("transaction_id", transaction.ID).
+			WithError(err).
+			Error("error on publish transaction update")
+	}
+}
+
+// FeedRelay fills the in-process watch feed with the transaction updates
+// published by every process. Each relay consumes in a group of its own, so
+// every instance receives every update, and skips the updates published
+// before it was created.
+type FeedRelay struct {
+	Broker                Broker
+	Topic                 string
+	Group                 string
+	Since                 time.Time
+	Registry              *event.Registry
+	TransactionRepository repository.TransactionRepository
+	Feed                  protocol.TransactionNotifier
+}
+
+func NewFeedRelay(broker Broker, transactionRepository repository.TransactionRepository, feed protocol.TransactionNotifier) *FeedRelay {
+	return &FeedRelay{
+		Broker:                broker,
+		Topic:                 os.Getenv("KAFKA_TRANSACTION_UPDATE_TOPIC"),
+		Group:                 os.Getenv("KAFKA_CONSUMER_GROUP_ID") + ".watch." + uuid.NewV4().String(),
+		Since:                 time.Now(),
+		Registry:              event.NewDefaultRegistry(),
+		TransactionRepository: transactionRepository,
+		Feed:                  feed,
+	}
+}
+
+func (r *FeedRelay) Run(ctx context.Context) error {
+	subscription, err := r.Broker.Subscribe(r.Group, []string{r.Topic})
+
+	if err != nil {
+		return err
+	}
+	defer subscription.Close()
+
+	log.WithField("topic", r.Topic).Info("watch feed relay has been started")
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case msg, ok := <-subscription.Messages():
+			if !ok {
+				return nil
+			}
+
+			r.relay(ctx, msg)
+			r.Broker.Ack(msg)
+		}
+	}
+}
+
+func (r *FeedRelay) relay(ctx context.Context, msg *Message) {
+	envelope, err := r.Registry.Decode(msg.Headers, msg.Value)
+
+	if err != nil {
+		log.WithField("topic", msg.Topic).WithError(err).Warn("skipping invalid transaction update")
+		return
+	}
+
+	if envelope.Type != event.TransactionUpdated || envelope.Time.Before(r.Since) {
+		return
+	}
+
+	// The update only carries the new status, the feed gets the whole row.
+	transaction, err := r.TransactionRepository.Find(ctx, envelope.Subject)
+
+	if err != nil {
+		log.WithField("transaction_id", envelope.Subject).WithError(err).Warn("skipping transaction update")
+		return
+	}
+
+	r.Feed.Publish(transaction)
+}
@@ -0,0 +1,93 @@
+package kafka_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/EdlanioJ/kbu/payments/application/kafka"
+	"github.com/EdlanioJ/kbu/payments/data/service"
+	"github.com/EdlanioJ/kbu/payments/domain/entity"
+	"github.com/EdlanioJ/kbu/payments/infra/db/gorm/repository"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFeedRelay(t *testing.T) {
+	t.Run("should relay the status changes of another process to the watch feed", func(t *testing.T) {
+		is := require.New(t)
+		db := newProcessorDB(t)
+		broker := kafka.NewMemoryBroker(1)
+		defer broker.Close()
+
+		transaction := newPendingTransaction(t, db)
+
+		feed := service.NewTransactionFeed()
+		relay := kafka.NewFeedRelay(broker, repository.NewTransactionRepository(db), feed)
+		relay.Topic = "transaction_updates"
+
+		subscription, err := feed.WatchTransaction(transaction.ID, 0)
+		is.Nil(err)
+		defer subscription.Close()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan error)
+		go func() {
+			done <- relay.Run(ctx)
+		}()
+
+		notifier := kafka.NewTransactionNotifier(broker)
+		notifier.Publisher.Topic = "transaction_updates"
+
+		transaction.Status = entity.TransactionCompleted
+		is.Nil(repository.NewTransactionRepository(db).Save(context.Background(), transaction))
+		notifier.Publish(transaction)
+
+		select {
+		case update := <-subscription.Updates():
+			is.Equal(transaction.ID, update.Transaction.ID)
+			is.Equal(entity.TransactionCompleted, update.Transaction.Status)
+			is.Equal(transaction.ExternalID, update.Transaction.ExternalID)
+		case <-time.After(2 * time.Second):
+			t.Fatal("update was not relayed")
+		}
+
+		cancel()
+		is.Nil(<-done)
+	})
+
+	t.Run("should skip updates published before the relay was created", func(t *testing.T) {
+		is := require.New(t)
+		db := newProcessorDB(t)
+		broker := kafka.NewMemoryBroker(1)
+		defer broker.Close()
+
+		transaction := newPendingTransaction(t, db)
+
+		notifier := kafka.NewTransactionNotifier(broker)
+		notifier.Publisher.Topic = "transaction_updates"
+		notifier.Publish(transaction)
+
+		feed := service.NewTransactionFeed()
+		relay := kafka.NewFeedRelay(broker, repository.NewTransactionRepository(db), feed)
+		relay.Topic = "transaction_updates"
+
+		subscription, err := feed.WatchTransaction(transaction.ID, 0)
+		is.Nil(err)
+		defer subscription.Close()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan error)
+		go func() {
+			done <- relay.Run(ctx)
+		}()
+
+		select {
+		case <-subscription.Updates():
+			t.Fatal("an old update was relayed")
+		case <-time.After(100 * time.Millisecond):
+		}
+
+		cancel()
+		is.Nil(<-done)
+	})
+}
@@ -0,0 +1,298 @@
+package kafka
+
+import (
+	"os"
+	"sync"
+	"time"
+
+	ckafka "github.com/confluentinc/confluent-kafka-go/kafka"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	kafkaPollTimeout  = 100 * time.Millisecond
+	kafkaReadTimeout  = 10 * time.Second
+	kafkaFlushTimeout = 15 * 1000
+)
+
+type KafkaBroker struct {
+	Producer     *ckafka.Producer
+	DeliveryChan chan ckafka.Event
+}
+
+func NewKafkaBroker() (*KafkaBroker, error) {
+	producer, err := NewKafkaProducer()
+
+	if err != nil {
+		return nil, err
+	}
+
+	deliveryChan := make(chan ckafka.Event)
+	go DeliveryReport(deliveryChan)
+
+	return &KafkaBroker{
+		Producer:     producer,
+		DeliveryChan: deliveryChan,
+	}, nil
+}
+
+func (k *KafkaBroker) Publish(msg *Message) error {
+	var headers []ckafka.Header
+
+	for key, value := range msg.Headers {
+		headers = append(headers, ckafka.Header{Key: key, Value: []byte(value)})
+	}
+
+	return Publish(msg.Value, msg.Key, msg.Topic, headers, k.Producer, k.DeliveryChan)
+}
+
+func (k *KafkaBroker) Subscribe(group string, topics []string) (Subscription, error) {
+	consumer, err := newKafkaConsumer(group, true)
+
+	if err != nil {
+		return nil, err
+	}
+
+	err = consumer.SubscribeTopics(topics, nil)
+
+	if err != nil {
+		consumer.Close()
+		return nil, err
+	}
+
+	subscription := &kafkaSubscription{
+		consumer: consumer,
+		messages: make(chan *Message),
+		settled:  make(chan struct{}, 1),
+		done:     make(chan struct{}),
+		stopped:  make(chan struct{}),
+	}
+
+	go subscription.run()
+
+	return subscription, nil
+}
+
+func (k *KafkaBroker) Ack(msg *Message) error {
+	return ack(msg)
+}
+
+func (k *KafkaBroker) Nack(msg *Message) error {
+	return nack(msg)
+}
+
+func (k *KafkaBroker) Browse(topic string, limit int) ([]*Message, error) {
+	c, err := newKafkaConsumer(os.Getenv("KAFKA_CONSUMER_GROUP_ID")+".browser", false)
+
+	if err != nil {
+		return nil, err
+	}
+	defer c.Close()
+
+	metadata, err := c.GetMetadata(&topic, false, int(kafkaReadTimeout/time.Millisecond))
+
+	if err != nil {
+		return nil, err
+	}
+
+	var partitions []ckafka.TopicPartition
+	remaining := make(map[int32]int64)
+
+	for _, partition := range metadata.Topics[topic].Partitions {
+		low, high, err := c.QueryWatermarkOffsets(topic, partition.ID, int(kafkaReadTimeout/time.Millisecond))
+
+		if err != nil {
+			return nil, err
+		}
+
+		if high <= low {
+			continue
+		}
+
+		remaining[partition.ID] = high
+		partitions = append(partitions, ckafka.TopicPartition{Topic: &topic, Partition: partition.ID, Offset: ckafka.Offset(low)})
+	}
+
+	if len(partitions) == 0 {
+		return nil, nil
+	}
+
+	err = c.Assign(partitions)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var messages []*Message
+
+	for len(remaining) > 0 && (limit <= 0 || len(messages) < limit) {
+		msg, err := c.ReadMessage(kafkaReadTimeout)
+
+		if err != nil {
+			return messages, err
+		}
+
+		messages = append(messages, fromKafkaMessage(msg))
+
+		if int64(msg.TopicPartition.Offset)+1 >= remaining[msg.TopicPartition.Partition] {
+			delete(remaining, msg.TopicPartition.Partition)
+		}
+	}
+
+	return messages, nil
+}
+
+func (k *KafkaBroker) Read(topic string, partition int32, offset int64) (*Message, error) {
+	c, err := newKafkaConsumer(os.Getenv("KAFKA_CONSUMER_GROUP_ID")+".browser", false)
+
+	if err != nil {
+		return nil, err
+	}
+	defer c.Close()
+
+	err = c.Assign([]ckafka.TopicPartition{{Topic: &topic, Partition: partition, Offset: ckafka.Offset(offset)}})
+
+	if err != nil {
+		return nil, err
+	}
+
+	msg, err := c.ReadMessage(kafkaReadTimeout)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if int64(msg.TopicPartition.Offset) != offset {
+		return nil, errMessageNotFound
+	}
+
+	return fromKafkaMessage(msg), nil
+}
+
+func (k *KafkaBroker) Ping(timeout time.Duration) error {
+	_, err := k.Producer.GetMetadata(nil, false, int(timeout/time.Millisecond))
+
+	return err
+}
+
+func (k *KafkaBroker) Close() error {
+	k.Producer.Flush(kafkaFlushTimeout)
+	k.Producer.Close()
+
+	return nil
+}
+
+func newKafkaConsumer(group string, commit bool) (*ckafka.Consumer, error) {
+	configMap := &ckafka.ConfigMap{
+		"bootstrap.servers":        os.Getenv("KAFKA_BOOTSTRAP_SERVERS"),
+		"group.id":                 group,
+		"auto.offset.reset":        "earliest",
+		"enable.auto.commit":       commit,
+		"enable.auto.offset.store": false,
+	}
+
+	return ckafka.NewConsumer(configMap)
+}
+
+func fromKafkaMessage(msg *ckafka.Message) *Message {
+	headers := make(map[string]string, len(msg.Headers))
+
+	for _, header := range msg.Headers {
+		headers[header.Key] = string(header.Value)
+	}
+
+	return &Message{
+		Topic:     *msg.TopicPartition.Topic,
+		Partition: msg.TopicPartition.Partition,
+		Offset:    int64(msg.TopicPartition.Offset),
+		Key:       msg.Key,
+		Value:     msg.Value,
+		Headers:   headers,
+		Timestamp: msg.Timestamp,
+	}
+}
+
+type kafkaSubscription struct {
+	consumer *ckafka.Consumer
+	messages chan *Message
+	settled  chan struct{}
+	done     chan struct{}
+	stopped  chan struct{}
+	once     sync.Once
+}
+
+func (s *kafkaSubscription) Messages() <-chan *Message {
+	return s.messages
+}
+
+func (s *kafkaSubscription) run() {
+	defer close(s.stopped)
+	defer close(s.messages)
+
+	for {
+		select {
+		case <-s.done:
+			return
+		default:
+		}
+
+		msg, err := s.consumer.ReadMessage(kafkaPollTimeout)
+
+		if err != nil {
+			if kafkaErr, ok := err.(ckafka.Error); !ok || kafkaErr.Code() != ckafka.ErrTimedOut {
+				log.WithError(err).Error("error on read message")
+			}
+			continue
+		}
+
+		message := fromKafkaMessage(msg)
+		partition := msg.TopicPartition
+
+		message.ack = func() error {
+			defer s.settle()
+
+			next := partition
+			next.Offset++
+			_, err := s.consumer.StoreOffsets([]ckafka.TopicPartition{next})
+
+			return err
+		}
+		message.nack = func() error {
+			defer s.settle()
+
+			return s.consumer.Seek(partition, 0)
+		}
+
+		select {
+		case s.messages <- message:
+		case <-s.done:
+			return
+		}
+
+		select {
+		case <-s.settled:
+		case <-s.done:
+			return
+		}
+	}
+}
+
+func (s *kafkaSubscription) settle() {
+	select {
+	case s.settled <- struct{}{}:
+	default:
+	}
+}
+
+func (s *kafkaSubscription) Close() error {
+	var err error
+
+	s.once.Do(func() {
+		close(s.done)
+		<-s.stopped
+
+		err = s.consumer.Close()
+	})
+
+	return err
+}
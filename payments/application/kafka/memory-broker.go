@@ -0,0 +1,364 @@
+package kafka
+
+import (
+	"hash/fnv"
+	"sort"
+	"sync"
+	"time"
+)
+
+type topicPartition struct {
+	topic     string
+	partition int32
+}
+
+type MemoryBroker struct {
+	mu         sync.Mutex
+	cond       *sync.Cond
+	partitions int
+	topics     map[string][][]*Message
+	groups     map[string]*memoryGroup
+	counter    int
+	closed     bool
+}
+
+type memoryGroup struct {
+	members   []*memorySubscription
+	committed map[topicPartition]int64
+	owners    map[topicPartition]*memorySubscription
+	inflight  map[topicPartition]*memorySubscription
+}
+
+type memorySubscription struct {
+	broker   *MemoryBroker
+	group    *memoryGroup
+	topics   []string
+	messages chan *Message
+	done     chan struct{}
+	closed   bool
+	once     sync.Once
+}
+
+func NewMemoryBroker(partitions int) *MemoryBroker {
+	if partitions < 1 {
+		partitions = 1
+	}
+
+	broker := &MemoryBroker{
+		partitions: partitions,
+		topics:     make(map[string][][]*Message),
+		groups:     make(map[string]*memoryGroup),
+	}
+	broker.cond = sync.NewCond(&broker.mu)
+
+	return broker
+}
+
+func (b *MemoryBroker) Publish(msg *Message) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return errBrokerClosed
+	}
+
+	partitions := b.topic(msg.Topic)
+	partition := b.partitionFor(msg.Key, len(partitions))
+
+	headers := make(map[string]string, len(msg.Headers))
+
+	for key, value := range msg.Headers {
+		headers[key] = value
+	}
+
+	stored := &Message{
+		Topic:     msg.Topic,
+		Partition: int32(partition),
+		Offset:    int64(len(partitions[partition])),
+		Key:       append([]byte(nil), msg.Key...),
+		Value:     append([]byte(nil), msg.Value...),
+		Headers:   headers,
+		Timestamp: time.Now(),
+	}
+
+	partitions[partition] = append(partitions[partition], stored)
+	b.cond.Broadcast()
+
+	return nil
+}
+
+func (b *MemoryBroker) Subscribe(group string, topics []string) (Subscription, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return nil, errBrokerClosed
+	}
+
+	for _, topic := range topics {
+		b.topic(topic)
+	}
+
+	memGroup, ok := b.groups[group]
+
+	if !ok {
+		memGroup = &memoryGroup{
+			committed: make(map[topicPartition]int64),
+			owners:    make(map[topicPartition]*memorySubscription),
+			inflight:  make(map[topicPartition]*memorySubscription),
+		}
+		b.groups[group] = memGroup
+	}
+
+	subscription := &memorySubscription{
+		broker:   b,
+		group:    memGroup,
+		topics:   append([]string(nil), topics...),
+		messages: make(chan *Message),
+		done:     make(chan struct{}),
+	}
+
+	memGroup.members = append(memGroup.members, subscription)
+	b.rebalance(memGroup)
+
+	go subscription.run()
+
+	return subscription, nil
+}
+
+func (b *MemoryBroker) Ack(msg *Message) error {
+	return ack(msg)
+}
+
+func (b *MemoryBroker) Nack(msg *Message) error {
+	return nack(msg)
+}
+
+func (b *MemoryBroker) Browse(topic string, limit int) ([]*Message, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var messages []*Message
+
+	for _, partition := range b.topics[topic] {
+		for _, msg := range partition {
+			if limit > 0 && len(messages) >= limit {
+				return messages, nil
+			}
+
+			copied := *msg
+			messages = append(messages, &copied)
+		}
+	}
+
+	return messages, nil
+}
+
+func (b *MemoryBroker) Read(topic string, partition int32, offset int64) (*Message, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	partitions := b.topics[topic]
+
+	if int(partition) >= len(partitions) || offset < 0 || offset >= int64(len(partitions[partition])) {
+		return nil, errMessageNotFound
+	}
+
+	copied := *partitions[partition][offset]
+
+	return &copied, nil
+}
+
+func (b *MemoryBroker) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.closed = true
+	b.cond.Broadcast()
+
+	return nil
+}
+
+func (b *MemoryBroker) Ping(timeout time.Duration) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return errBrokerClosed
+	}
+
+	return nil
+}
+
+func (b *MemoryBroker) topic(name string) [][]*Message {
+	partitions, ok := b.topics[name]
+
+	if !ok {
+		partitions = make([][]*Message, b.partitions)
+		b.topics[name] = partitions
+	}
+
+	return partitions
+}
+
+func (b *MemoryBroker) partitionFor(key []byte, partitions int) int {
+	if len(key) == 0 {
+		b.counter++
+		return b.counter % partitions
+	}
+
+	hash := fnv.New32a()
+	hash.Write(key)
+
+	return int(hash.Sum32() % uint32(partitions))
+}
+
+func (b *MemoryBroker) rebalance(group *memoryGroup) {
+	subscribers := make(map[string][]*memorySubscription)
+
+	for _, member := range group.members {
+		for _, topic := range member.topics {
+			subscribers[topic] = append(subscribers[topic], member)
+		}
+	}
+
+	topics := make([]string, 0, len(subscribers))
+
+	for topic := range subscribers {
+		topics = append(topics, topic)
+	}
+	sort.Strings(topics)
+
+	owners := make(map[topicPartition]*memorySubscription)
+
+	for _, topic := range topics {
+		members := subscribers[topic]
+
+		for partition := range b.topics[topic] {
+			tp := topicPartition{topic: topic, partition: int32(partition)}
+			owners[tp] = members[partition%len(members)]
+		}
+	}
+
+	for tp, member := range group.inflight {
+		if owners[tp] != member {
+			delete(group.inflight, tp)
+		}
+	}
+
+	group.owners = owners
+	b.cond.Broadcast()
+}
+
+func (b *MemoryBroker) fetch(s *memorySubscription) *Message {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for {
+		if s.closed || b.closed {
+			return nil
+		}
+
+		for _, topic := range s.topics {
+			for partition, messages := range b.topics[topic] {
+				tp := topicPartition{topic: topic, partition: int32(partition)}
+
+				if s.group.owners[tp] != s || s.group.inflight[tp] != nil {
+					continue
+				}
+
+				offset := s.group.committed[tp]
+
+				if offset >= int64(len(messages)) {
+					continue
+				}
+
+				s.group.inflight[tp] = s
+
+				msg := *messages[offset]
+				msg.ack = func() error {
+					return b.settle(s, tp, msg.Offset, true)
+				}
+				msg.nack = func() error {
+					return b.settle(s, tp, msg.Offset, false)
+				}
+
+				return &msg
+			}
+		}
+
+		b.cond.Wait()
+	}
+}
+
+func (b *MemoryBroker) settle(s *memorySubscription, tp topicPartition, offset int64, ack bool) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if s.group.inflight[tp] != s || s.group.committed[tp] != offset {
+		return nil
+	}
+
+	delete(s.group.inflight, tp)
+
+	if ack {
+		s.group.committed[tp] = offset + 1
+	}
+
+	b.cond.Broadcast()
+
+	return nil
+}
+
+func (s *memorySubscription) Messages() <-chan *Message {
+	return s.messages
+}
+
+func (s *memorySubscription) run() {
+	defer close(s.messages)
+
+	for {
+		msg := s.broker.fetch(s)
+
+		if msg == nil {
+			return
+		}
+
+		select {
+		case s.messages <- msg:
+		case <-s.done:
+			return
+		}
+	}
+}
+
+func (s *memorySubscription) Close() error {
+	s.once.Do(func() {
+		b := s.broker
+
+		b.mu.Lock()
+		defer b.mu.Unlock()
+
+		s.closed = true
+
+		members := s.group.members[:0]
+
+		for _, member := range s.group.members {
+			if member != s {
+				members = append(members, member)
+			}
+		}
+		s.group.members = members
+
+		for tp, member := range s.group.inflight {
+			if member == s {
+				delete(s.group.inflight, tp)
+			}
+		}
+
+		b.rebalance(s.group)
+		close(s.done)
+	})
+
+	return nil
+}
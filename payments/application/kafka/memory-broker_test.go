@@ -0,0 +1,144 @@
+package kafka_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/EdlanioJ/kbu/payments/application/kafka"
+	"github.com/stretchr/testify/require"
+)
+
+func receive(t *testing.T, subscription kafka.Subscription) *kafka.Message {
+	select {
+	case msg := <-subscription.Messages():
+		return msg
+	case <-time.After(time.Second):
+		t.Fatal("no message was received")
+		return nil
+	}
+}
+
+func TestMemoryBroker(t *testing.T) {
+	t.Parallel()
+
+	t.Run("should keep key order within a partition", func(t *testing.T) {
+		is := require.New(t)
+		broker := kafka.NewMemoryBroker(4)
+		defer broker.Close()
+
+		for i := 0; i < 5; i++ {
+			err := broker.Publish(&kafka.Message{Topic: "transactions", Key: []byte("account"), Value: []byte(fmt.Sprint(i))})
+			is.Nil(err)
+		}
+
+		subscription, err := broker.Subscribe("payments", []string{"transactions"})
+		is.Nil(err)
+		defer subscription.Close()
+
+		for i := 0; i < 5; i++ {
+			msg := receive(t, subscription)
+
+			is.Equal(fmt.Sprint(i), string(msg.Value))
+			is.Equal(int64(i), msg.Offset)
+			is.Nil(broker.Ack(msg))
+		}
+	})
+
+	t.Run("should redeliver nacked messages", func(t *testing.T) {
+		is := require.New(t)
+		broker := kafka.NewMemoryBroker(1)
+		defer broker.Close()
+
+		subscription, err := broker.Subscribe("payments", []string{"transactions"})
+		is.Nil(err)
+		defer subscription.Close()
+
+		is.Nil(broker.Publish(&kafka.Message{Topic: "transactions", Value: []byte("first")}))
+		is.Nil(broker.Publish(&kafka.Message{Topic: "transactions", Value: []byte("second")}))
+
+		msg := receive(t, subscription)
+		is.Equal("first", string(msg.Value))
+		is.Nil(broker.Nack(msg))
+
+		msg = receive(t, subscription)
+		is.Equal("first", string(msg.Value))
+		is.Nil(broker.Ack(msg))
+
+		msg = receive(t, subscription)
+		is.Equal("second", string(msg.Value))
+		is.Nil(broker.Ack(msg))
+	})
+
+	t.Run("should share partitions within a group and fan out across groups", func(t *testing.T) {
+		is := require.New(t)
+		broker := kafka.NewMemoryBroker(2)
+		defer broker.Close()
+
+		first, err := broker.Subscribe("payments", []string{"transactions"})
+		is.Nil(err)
+		defer first.Close()
+
+		second, err := broker.Subscribe("payments", []string{"transactions"})
+		is.Nil(err)
+		defer second.Close()
+
+		audit, err := broker.Subscribe("audit", []string{"transactions"})
+		is.Nil(err)
+		defer audit.Close()
+
+		is.Nil(broker.Publish(&kafka.Message{Topic: "transactions", Value: []byte("a")}))
+		is.Nil(broker.Publish(&kafka.Message{Topic: "transactions", Value: []byte("b")}))
+
+		got := map[string]bool{}
+
+		for _, subscription := range []kafka.Subscription{first, second} {
+			msg := receive(t, subscription)
+			got[string(msg.Value)] = true
+			is.Nil(broker.Ack(msg))
+		}
+
+		is.Equal(map[string]bool{"a": true, "b": true}, got)
+
+		for i := 0; i < 2; i++ {
+			is.Nil(broker.Ack(receive(t, audit)))
+		}
+	})
+
+	t.Run("should hand partitions over when a member leaves", func(t *testing.T) {
+		is := require.New(t)
+		broker := kafka.NewMemoryBroker(2)
+		defer broker.Close()
+
+		first, err := broker.Subscribe("payments", []string{"transactions"})
+		is.Nil(err)
+
+		second, err := broker.Subscribe("payments", []string{"transactions"})
+		is.Nil(err)
+		defer second.Close()
+
+		is.Nil(first.Close())
+
+		is.Nil(broker.Publish(&kafka.Message{Topic: "transactions", Value: []byte("a")}))
+		is.Nil(broker.Publish(&kafka.Message{Topic: "transactions", Value: []byte("b")}))
+
+		is.Nil(broker.Ack(receive(t, second)))
+		is.Nil(broker.Ack(receive(t, second)))
+	})
+
+	t.Run("should refuse acks of messages not delivered by a subscription", func(t *testing.T) {
+		is := require.New(t)
+		broker := kafka.NewMemoryBroker(1)
+		defer broker.Close()
+
+		is.NotNil(broker.Ack(&kafka.Message{Topic: "transactions"}))
+	})
+	t.Run("should fail ping once closed", func(t *testing.T) {
+		is := require.New(t)
+		broker := kafka.NewMemoryBroker(1)
+
+		is.Nil(broker.Ping(time.Second))
+		is.Nil(broker.Close())
+		is.Error(broker.Ping(time.Second))
+	})
+}
@@ -0,0 +1,34 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type DeadLetter struct {
+	Partition         int32     `json:"partition"`
+	Offset            int64     `json:"offset"`
+	OriginalTopic     string    `json:"original_topic"`
+	OriginalPartition int32     `json:"original_partition"`
+	OriginalOffset    int64     `json:"original_offset"`
+	Attempts          int       `json:"attempts"`
+	Error             string    `json:"error"`
+	ErrorType         string    `json:"error_type"`
+	FailedAt          time.Time `json:"failed_at"`
+	Key               string    `json:"key,omitempty"`
+	Payload           string    `json:"payload"`
+}
+
+func (d *DeadLetter) ToJson() ([]byte, error) {
+	result, err := json.Marshal(d)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func NewDeadLetter() *DeadLetter {
+	return &DeadLetter{}
+}
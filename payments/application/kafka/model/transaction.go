@@ -7,6 +7,12 @@ import (
 	"github.com/go-ozzo/ozzo-validation/v4/is"
 )
 
+const (
+	TransactionPending   string = "pending"
+	TransactionCompleted string = "completed"
+	TransactionError     string = "error"
+)
+
 type Transaction struct {
 	ID          string  `json:"id"`
 	AccountFrom string  `json:"account_from"`
@@ -19,13 +25,13 @@ type Transaction struct {
 
 func (t *Transaction) isValid() error {
 	err := validation.ValidateStruct(t,
-		validation.Field(t.ID, validation.Required, is.UUIDv4),
-		validation.Field(t.AccountFrom, validation.Required, is.UUIDv4),
-		validation.Field(t.AccountTo, is.UUIDv4),
-		validation.Field(t.Service, is.UUIDv4),
-		validation.Field(t.Store, is.UUIDv4),
-		validation.Field(t.Status, validation.Required),
-		validation.Field(t.Amount, validation.Required, validation.Min(float64(0))),
+		validation.Field(&t.ID, validation.Required, is.UUIDv4),
+		validation.Field(&t.AccountFrom, validation.Required, is.UUIDv4),
+		validation.Field(&t.AccountTo, is.UUIDv4),
+		validation.Field(&t.Service, is.UUIDv4),
+		validation.Field(&t.Store, is.UUIDv4),
+		validation.Field(&t.Status, validation.Required, validation.In(TransactionPending, TransactionCompleted, TransactionError)),
+		validation.Field(&t.Amount, validation.Required, validation.Min(float64(0))),
 	)
 
 	return err
@@ -0,0 +1,270 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/EdlanioJ/kbu/payments/application/factory"
+	"github.com/EdlanioJ/kbu/payments/application/kafka/event"
+	"github.com/EdlanioJ/kbu/payments/application/kafka/model"
+	"github.com/EdlanioJ/kbu/payments/domain/entity"
+	"github.com/EdlanioJ/kbu/payments/presentation/controller"
+	"github.com/jinzhu/gorm"
+	log "github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var (
+	errUnknownTopic   = errors.New("no handler for topic")
+	errInvalidStatus  = errors.New("invalid confirmation status")
+	errUnexpectedType = errors.New("unexpected event type")
+)
+
+type KafkaProcessor struct {
+	Database    *gorm.DB
+	Broker      Broker
+	RetryPolicy *RetryPolicy
+	Registry    *event.Registry
+}
+
+func NewKafkaProcessor(database *gorm.DB, broker Broker) *KafkaProcessor {
+	return &KafkaProcessor{
+		Database:    database,
+		Broker:      broker,
+		RetryPolicy: NewRetryPolicy(),
+		Registry:    event.NewDefaultRegistry(),
+	}
+}
+
+func (k *KafkaProcessor) Consume(ctx context.Context) error {
+	group := os.Getenv("KAFKA_CONSUMER_GROUP_ID")
+	topics := []string{os.Getenv("KAFKA_TRANSACTION_CONFIRMATION_TOPIC")}
+
+	subscriptions := make([]Subscription, 0, k.RetryPolicy.MaxAttempts+1)
+
+	for attempt := 0; attempt <= k.RetryPolicy.MaxAttempts; attempt++ {
+		subscribed := topics
+
+		if attempt > 0 {
+			subscribed = nil
+
+			for _, topic := range topics {
+				subscribed = append(subscribed, RetryTopic(topic, attempt))
+			}
+		}
+
+		subscription, err := k.Broker.Subscribe(group, subscribed)
+
+		if err != nil {
+			for _, s := range subscriptions {
+				s.Close()
+			}
+			return err
+		}
+
+		log.WithField("topics", subscribed).Info("consumer has been started")
+		subscriptions = append(subscriptions, subscription)
+	}
+
+	var wg sync.WaitGroup
+
+	for _, subscription := range subscriptions {
+		wg.Add(1)
+
+		go func(subscription Subscription) {
+			defer wg.Done()
+			k.consume(ctx, subscription)
+		}(subscription)
+	}
+
+	wg.Wait()
+
+	for _, subscription := range subscriptions {
+		subscription.Close()
+	}
+
+	log.Info("consumers have been drained")
+
+	return nil
+}
+
+func (k *KafkaProcessor) consume(ctx context.Context, subscription Subscription) {
+	for {
+		var msg *Message
+		var ok bool
+
+		select {
+		case <-ctx.Done():
+			return
+		case msg, ok = <-subscription.Messages():
+			if !ok {
+				return
+			}
+		}
+
+		if due := notBefore(msg); time.Now().Before(due) {
+			timer := time.NewTimer(time.Until(due))
+
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return
+			case <-timer.C:
+			}
+		}
+
+		k.handleMessage(msg)
+	}
+}
+
+func (k *KafkaProcessor) handleMessage(msg *Message) {
+	err := k.processMessage(msg)
+
+	if err == nil {
+		k.Broker.Ack(msg)
+		return
+	}
+
+	attempt := retryAttempt(msg)
+	topic := originalTopic(msg)
+
+	logger := log.
+		WithField("topic", topic).
+		WithField("attempt", attempt).
+		WithError(err)
+
+	if k.RetryPolicy.ShouldRetry(attempt, err) {
+		next := attempt + 1
+		delay := k.RetryPolicy.Backoff(next)
+
+		err = k.Broker.Publish(&Message{
+			Topic:   RetryTopic(topic, next),
+			Key:     msg.Key,
+			Value:   msg.Value,
+			Headers: retryHeaders(msg, next, delay, err),
+		})
+
+		if err != nil {
+			logger.WithError(err).Error("error on publish message to retry topic")
+			k.Broker.Nack(msg)
+			return
+		}
+
+		logger.WithField("delay", delay).Warn("message scheduled for retry")
+		k.Broker.Ack(msg)
+		return
+	}
+
+	err = k.Broker.Publish(&Message{
+		Topic:   DeadLetterTopic(topic),
+		Key:     msg.Key,
+		Value:   msg.Value,
+		Headers: deadLetterHeaders(msg, attempt, err),
+	})
+
+	if err != nil {
+		logger.WithError(err).Error("error on publish message to dead letter queue")
+		k.Broker.Nack(msg)
+		return
+	}
+
+	logger.Error("message sent to dead letter queue")
+	k.Broker.Ack(msg)
+}
+
+func (k *KafkaProcessor) processMessage(msg *Message) error {
+	switch originalTopic(msg) {
+	case os.Getenv("KAFKA_TRANSACTION_CONFIRMATION_TOPIC"):
+		return k.processTransactionConfirmation(msg)
+	default:
+		return Permanent(errUnknownTopic)
+	}
+}
+
+func (k *KafkaProcessor) processTransactionConfirmation(msg *Message) error {
+	envelope, err := k.Registry.Decode(msg.Headers, msg.Value)
+
+	if err != nil {
+		return Permanent(err)
+	}
+
+	ctx, span := tracer.Start(envelope.ExtractTrace(context.Background()), originalTopic(msg)+" process",
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(
+			semconv.MessagingSystemKey.String("kafka"),
+			semconv.MessagingDestinationKey.String(originalTopic(msg)),
+			semconv.MessagingDestinationKindTopic,
+			semconv.MessagingOperationProcess,
+			semconv.MessagingMessageIDKey.String(envelope.ID),
+		),
+	)
+	defer span.End()
+
+	err = k.confirmTransaction(controller.WithSystemPrincipal(entity.WithRequestID(ctx, envelope.ID)), envelope)
+
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	return err
+}
+
+func (k *KafkaProcessor) confirmTransaction(ctx context.Context, envelope *event.Envelope) error {
+	if envelope.Type != event.TransactionConfirmed {
+		return Permanent(errUnexpectedType)
+	}
+
+	codec, err := event.CodecFor(envelope.DataContentType)
+
+	if err != nil {
+		return Permanent(err)
+	}
+
+	transaction := model.NewTransaction()
+
+	err = codec.Unmarshal(envelope.Data, transaction)
+
+	if err != nil {
+		return Permanent(err)
+	}
+
+	// The webhooks of the payment are queued in the same transaction as its
+	// status change, a confirmation that fails to queue them is retried.
+	transactionController := factory.TransactionControllerFactory(k.Database)
+
+	switch transaction.Status {
+	case model.TransactionCompleted:
+		_, err = transactionController.Complete(ctx, transaction.ID)
+	case model.TransactionError:
+		_, err = transactionController.Error(ctx, transaction.ID)
+	default:
+		return Permanent(errInvalidStatus)
+	}
+
+	if err != nil {
+		return confirmationError(err)
+	}
+
+	return nil
+}
+
+// confirmationError marks the errors a retry would only repeat, an invalid
+// confirmation or one for an unknown payment, as permanent. A confirmation of
+// a payment that is no longer pending is a duplicate and is acknowledged.
+func confirmationError(err error) error {
+	if entity.IsErrorKind(err, entity.ErrorConflict) {
+		return nil
+	}
+
+	if entity.IsErrorKind(err, entity.ErrorInvalidArgument) || entity.IsErrorKind(err, entity.ErrorNotFound) {
+		return Permanent(err)
+	}
+
+	return err
+}
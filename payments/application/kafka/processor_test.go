@@ -0,0 +1,300 @@
+package kafka_test
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/EdlanioJ/kbu/payments/application/kafka"
+	"github.com/EdlanioJ/kbu/payments/application/kafka/event"
+	"github.com/EdlanioJ/kbu/payments/application/kafka/model"
+	"github.com/EdlanioJ/kbu/payments/domain/entity"
+	"github.com/EdlanioJ/kbu/payments/infra/db/gorm/migration"
+	"github.com/EdlanioJ/kbu/payments/infra/db/gorm/repository"
+	"github.com/jinzhu/gorm"
+	_ "github.com/mattn/go-sqlite3"
+	uuid "github.com/satori/go.uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func waitFor(t *testing.T, condition func() bool) {
+	deadline := time.Now().Add(2 * time.Second)
+
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition was not met in time")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func newProcessorDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open("sqlite3", ":memory:")
+	require.Nil(t, err)
+
+	db.LogMode(false)
+	db.DB().SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+
+	repository.RegisterTracing(db)
+	repository.RegisterTenancy(db)
+
+	migrator, err := migration.NewMigrator(db)
+	require.Nil(t, err)
+
+	_, err = migrator.Up(0)
+	require.Nil(t, err)
+
+	return db
+}
+
+func newPendingTransaction(t *testing.T, db *gorm.DB) *entity.Transaction {
+	ctx := context.Background()
+	accountFrom, _ := entity.NewAccount(3000)
+	accountTo, _ := entity.NewAccount(200)
+
+	require.Nil(t, repository.NewAccountRepository(db).Save(ctx, accountFrom))
+	require.Nil(t, repository.NewAccountRepository(db).Save(ctx, accountTo))
+
+	transaction, _ := entity.NewTransaction(accountFrom, accountTo, uuid.NewV4().String(), entity.TransactionToUser, "AOA", 30)
+	require.Nil(t, repository.NewTransactionRepository(db).Register(ctx, transaction))
+
+	return transaction
+}
+
+func publishConfirmation(t *testing.T, broker kafka.Broker, transaction *entity.Transaction, status string) {
+	message := model.NewTransaction()
+	message.ID = transaction.ID
+	message.AccountFrom = transaction.AccountFromID
+	message.AccountTo = transaction.AccountToID
+	message.Amount = transaction.Amount
+	message.Status = status
+
+	data, err := event.JSONCodec{}.Marshal(message)
+	require.Nil(t, err)
+
+	envelope := event.New(event.TransactionConfirmed, "1", event.Source, transaction.ID)
+	envelope.DataContentType = event.ContentTypeJSON
+	envelope.Data = data
+
+	headers, value, err := event.Encode(envelope, event.ModeBinary)
+	require.Nil(t, err)
+
+	require.Nil(t, broker.Publish(&kafka.Message{
+		Topic:   "transaction_confirmation",
+		Key:     []byte(transaction.ID),
+		Value:   value,
+		Headers: headers,
+	}))
+}
+
+// processed publishes an invalid message behind the ones already published
+// and waits for it to be dead lettered, so every earlier message is settled.
+func processed(t *testing.T, broker *kafka.MemoryBroker, deadLetters int) {
+	require.Nil(t, broker.Publish(&kafka.Message{Topic: "transaction_confirmation", Value: []byte(`{"id": "invalid"}`)}))
+
+	queue := kafka.NewDeadLetterQueue("transaction_confirmation", broker)
+
+	waitFor(t, func() bool {
+		list, _ := queue.List(0)
+		return len(list) == deadLetters+1
+	})
+}
+
+func TestKafkaProcessor(t *testing.T) {
+	os.Setenv("KAFKA_CONSUMER_GROUP_ID", "payments")
+	os.Setenv("KAFKA_TRANSACTION_CONFIRMATION_TOPIC", "transaction_confirmation")
+
+	t.Run("should dead letter invalid messages and redrive them", func(t *testing.T) {
+		is := require.New(t)
+		broker := kafka.NewMemoryBroker(1)
+
+		processor := kafka.NewKafkaProcessor(nil, broker)
+		processor.RetryPolicy.MaxAttempts = 1
+
+		done := make(chan error)
+		go func() {
+			done <- processor.Consume(context.Background())
+		}()
+
+		err := broker.Publish(&kafka.Message{Topic: "transaction_confirmation", Value: []byte(`{"id": "invalid"}`)})
+		is.Nil(err)
+
+		queue := kafka.NewDeadLetterQueue("transaction_confirmation", broker)
+
+		waitFor(t, func() bool {
+			deadLetters, _ := queue.List(0)
+			return len(deadLetters) == 1
+		})
+
+		deadLetters, err := queue.List(0)
+		is.Nil(err)
+		is.Equal("transaction_confirmation", deadLetters[0].OriginalTopic)
+		is.Equal("permanent", deadLetters[0].ErrorType)
+		is.Equal(`{"id": "invalid"}`, deadLetters[0].Payload)
+
+		deadLetter, err := queue.Inspect(deadLetters[0].Partition, deadLetters[0].Offset)
+		is.Nil(err)
+		is.Equal(deadLetters[0], deadLetter)
+
+		is.Nil(queue.Redrive(deadLetter.Partition, deadLetter.Offset))
+
+		waitFor(t, func() bool {
+			deadLetters, _ := queue.List(0)
+			return len(deadLetters) == 2
+		})
+
+		is.Nil(broker.Close())
+		is.Nil(<-done)
+	})
+	t.Run("should drain consumers when the context is canceled", func(t *testing.T) {
+		is := require.New(t)
+		broker := kafka.NewMemoryBroker(1)
+		defer broker.Close()
+
+		processor := kafka.NewKafkaProcessor(nil, broker)
+		processor.RetryPolicy.MaxAttempts = 1
+
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan error)
+		go func() {
+			done <- processor.Consume(ctx)
+		}()
+
+		cancel()
+
+		select {
+		case err := <-done:
+			is.Nil(err)
+		case <-time.After(2 * time.Second):
+			t.Fatal("consumer did not stop")
+		}
+	})
+	t.Run("should retry a confirmation whose webhooks cannot be queued", func(t *testing.T) {
+		is := require.New(t)
+		db := newProcessorDB(t)
+		broker := kafka.NewMemoryBroker(1)
+
+		transaction := newPendingTransaction(t, db)
+		is.Nil(db.Exec("DROP TABLE webhooks").Error)
+
+		processor := kafka.NewKafkaProcessor(db, broker)
+		processor.RetryPolicy.MaxAttempts = 1
+
+		done := make(chan error)
+		go func() {
+			done <- processor.Consume(context.Background())
+		}()
+
+		publishConfirmation(t, broker, transaction, model.TransactionCompleted)
+		processed(t, broker, 0)
+
+		found, err := repository.NewTransactionRepository(db).Find(context.Background(), transaction.ID)
+		is.Nil(err)
+		is.Equal(entity.TransactionPending, found.Status)
+
+		retries, err := broker.Browse(kafka.RetryTopic("transaction_confirmation", 1), 0)
+		is.Nil(err)
+		is.Len(retries, 1)
+
+		is.Nil(broker.Close())
+		is.Nil(<-done)
+	})
+	t.Run("should queue the webhooks of a confirmation with its status change", func(t *testing.T) {
+		is := require.New(t)
+		db := newProcessorDB(t)
+		broker := kafka.NewMemoryBroker(1)
+
+		transaction := newPendingTransaction(t, db)
+
+		webhook, _ := entity.NewWebhook(transaction.AccountToID, "https://merchant.kbu.test/hooks")
+		is.Nil(repository.NewWebhookRepository(db).Register(context.Background(), webhook))
+
+		processor := kafka.NewKafkaProcessor(db, broker)
+		processor.RetryPolicy.MaxAttempts = 1
+
+		done := make(chan error)
+		go func() {
+			done <- processor.Consume(context.Background())
+		}()
+
+		publishConfirmation(t, broker, transaction, model.TransactionCompleted)
+		publishConfirmation(t, broker, transaction, model.TransactionCompleted)
+		processed(t, broker, 0)
+
+		var deliveries []*entity.WebhookDelivery
+		is.Nil(db.Where("transaction_id = ?", transaction.ID).Find(&deliveries).Error)
+		is.Len(deliveries, 1)
+		is.Equal(entity.WebhookPaymentCompleted, deliveries[0].Event)
+
+		is.Nil(broker.Close())
+		is.Nil(<-done)
+	})
+	t.Run("should acknowledge confirmations of a payment that is no longer pending", func(t *testing.T) {
+		is := require.New(t)
+		db := newProcessorDB(t)
+		broker := kafka.NewMemoryBroker(1)
+
+		transaction := newPendingTransaction(t, db)
+
+		processor := kafka.NewKafkaProcessor(db, broker)
+		processor.RetryPolicy.MaxAttempts = 1
+
+		done := make(chan error)
+		go func() {
+			done <- processor.Consume(context.Background())
+		}()
+
+		publishConfirmation(t, broker, transaction, model.TransactionCompleted)
+		publishConfirmation(t, broker, transaction, model.TransactionCompleted)
+		publishConfirmation(t, broker, transaction, model.TransactionError)
+		processed(t, broker, 0)
+
+		found, err := repository.NewTransactionRepository(db).Find(context.Background(), transaction.ID)
+		is.Nil(err)
+		is.Equal(entity.TransactionCompleted, found.Status)
+
+		events, err := repository.NewEventRepository(db).FindAllByAggregate(context.Background(), entity.AggregateTransaction, transaction.ID)
+		is.Nil(err)
+		is.Len(events, 2)
+
+		retries, err := broker.Browse(kafka.RetryTopic("transaction_confirmation", 1), 0)
+		is.Nil(err)
+		is.Empty(retries)
+
+		is.Nil(broker.Close())
+		is.Nil(<-done)
+	})
+	t.Run("should dead letter confirmations of unknown payments without retrying", func(t *testing.T) {
+		is := require.New(t)
+		db := newProcessorDB(t)
+		broker := kafka.NewMemoryBroker(1)
+
+		transaction := newPendingTransaction(t, db)
+		transaction.ID = uuid.NewV4().String()
+
+		processor := kafka.NewKafkaProcessor(db, broker)
+		processor.RetryPolicy.MaxAttempts = 1
+
+		done := make(chan error)
+		go func() {
+			done <- processor.Consume(context.Background())
+		}()
+
+		publishConfirmation(t, broker, transaction, model.TransactionCompleted)
+		processed(t, broker, 1)
+
+		deadLetters, err := kafka.NewDeadLetterQueue("transaction_confirmation", broker).List(0)
+		is.Nil(err)
+		is.Equal("permanent", deadLetters[0].ErrorType)
+		is.Equal("no payment was found", deadLetters[0].Error)
+
+		retries, err := broker.Browse(kafka.RetryTopic("transaction_confirmation", 1), 0)
+		is.Nil(err)
+		is.Empty(retries)
+
+		is.Nil(broker.Close())
+		is.Nil(<-done)
+	})
+}
@@ -0,0 +1,59 @@
+package kafka
+
+import (
+	"os"
+
+	ckafka "github.com/confluentinc/confluent-kafka-go/kafka"
+	log "github.com/sirupsen/logrus"
+)
+
+func NewKafkaProducer() (*ckafka.Producer, error) {
+	configMap := &ckafka.ConfigMap{
+		"bootstrap.servers": os.Getenv("KAFKA_BOOTSTRAP_SERVERS"),
+	}
+
+	producer, err := ckafka.NewProducer(configMap)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return producer, nil
+}
+
+func Publish(msg []byte, key []byte, topic string, headers []ckafka.Header, producer *ckafka.Producer, deliveryChan chan ckafka.Event) error {
+	message := &ckafka.Message{
+		TopicPartition: ckafka.TopicPartition{Topic: &topic, Partition: ckafka.PartitionAny},
+		Key:            key,
+		Value:          msg,
+		Headers:        headers,
+	}
+
+	err := producer.Produce(message, deliveryChan)
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func DeliveryReport(deliveryChan chan ckafka.Event) {
+	for e := range deliveryChan {
+		switch ev := e.(type) {
+		case *ckafka.Message:
+			if ev.TopicPartition.Error != nil {
+				log.
+					WithField("topic", *ev.TopicPartition.Topic).
+					WithError(ev.TopicPartition.Error).
+					Error("delivery failed")
+			} else {
+				log.
+					WithField("topic", *ev.TopicPartition.Topic).
+					WithField("partition", ev.TopicPartition.Partition).
+					WithField("offset", ev.TopicPartition.Offset).
+					Debug("delivered message")
+			}
+		}
+	}
+}
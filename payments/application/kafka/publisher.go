@@ -0,0 +1,126 @@
+package kafka
+
+import (
+	"context"
+	"os"
+
+	"github.com/EdlanioJ/kbu/payments/application/kafka/event"
+	"github.com/EdlanioJ/kbu/payments/application/kafka/model"
+	"github.com/EdlanioJ/kbu/payments/domain/entity"
+	log "github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const transactionSchemaVersion = "1"
+
+type TransactionPublisher struct {
+	Broker    Broker
+	Topic     string
+	EventType string
+	Mode      string
+	Codec     event.Codec
+	Registry  *event.Registry
+}
+
+func NewTransactionPublisher(broker Broker) *TransactionPublisher {
+	codec, err := event.CodecFor(os.Getenv("EVENT_CONTENT_TYPE"))
+
+	if err != nil {
+		log.WithField("content_type", os.Getenv("EVENT_CONTENT_TYPE")).WithError(err).Warn("falling back to json events")
+		codec = event.JSONCodec{}
+	}
+
+	return &TransactionPublisher{
+		Broker:    broker,
+		Topic:     os.Getenv("KAFKA_TRANSACTION_TOPIC"),
+		EventType: event.TransactionRegistered,
+		Mode:      os.Getenv("EVENT_MODE"),
+		Codec:     codec,
+		Registry:  event.NewDefaultRegistry(),
+	}
+}
+
+func (p *TransactionPublisher) Publish(ctx context.Context, transaction *entity.Transaction) error {
+	ctx, span := tracer.Start(ctx, p.Topic+" send",
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(
+			semconv.MessagingSystemKey.String("kafka"),
+			semconv.MessagingDestinationKey.String(p.Topic),
+			semconv.MessagingDestinationKindTopic,
+		),
+	)
+	defer span.End()
+
+	err := p.publish(ctx, transaction)
+
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	return err
+}
+
+func (p *TransactionPublisher) publish(ctx context.Context, transaction *entity.Transaction) error {
+	message := model.NewTransaction()
+
+	message.ID = transaction.ID
+	message.AccountFrom = transaction.AccountFromID
+	message.Amount = transaction.Amount
+	message.Status = messageStatus(transaction.Status)
+
+	switch transaction.Type {
+	case entity.TransactionToStore:
+		message.Store = transaction.AccountToID
+	case entity.TransactionToService:
+		message.Service = transaction.AccountToID
+	default:
+		message.AccountTo = transaction.AccountToID
+	}
+
+	definition, err := p.Registry.Lookup(p.EventType, transactionSchemaVersion)
+
+	if err != nil {
+		return err
+	}
+
+	data, err := p.Codec.Marshal(message)
+
+	if err != nil {
+		return err
+	}
+
+	envelope := event.New(definition.Type, definition.Version, event.Source, transaction.ID)
+	envelope.DataContentType = p.Codec.ContentType()
+	envelope.DataSchema = definition.DataSchema(envelope.DataContentType)
+	envelope.Data = data
+	envelope.InjectTrace(ctx)
+
+	trace.SpanFromContext(ctx).SetAttributes(semconv.MessagingMessageIDKey.String(envelope.ID))
+
+	headers, value, err := event.Encode(envelope, p.Mode)
+
+	if err != nil {
+		return err
+	}
+
+	return p.Broker.Publish(&Message{
+		Topic:   p.Topic,
+		Key:     []byte(transaction.ID),
+		Value:   value,
+		Headers: headers,
+	})
+}
+
+func messageStatus(status string) string {
+	switch status {
+	case entity.TransactionCompleted:
+		return model.TransactionCompleted
+	case entity.TransactionCanceled:
+		return model.TransactionError
+	default:
+		return model.TransactionPending
+	}
+}
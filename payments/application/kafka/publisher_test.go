@@ -0,0 +1,67 @@
+package kafka_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/EdlanioJ/kbu/payments/application/kafka"
+	"github.com/EdlanioJ/kbu/payments/application/kafka/event"
+	"github.com/EdlanioJ/kbu/payments/domain/entity"
+	uuid "github.com/satori/go.uuid"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestTransactionPublisher(t *testing.T) {
+	t.Parallel()
+
+	t.Run("should publish within the trace of the caller", func(t *testing.T) {
+		is := require.New(t)
+		exporter := tracetest.NewInMemoryExporter()
+		otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)))
+		otel.SetTextMapPropagator(propagation.TraceContext{})
+
+		broker := kafka.NewMemoryBroker(1)
+		defer broker.Close()
+
+		publisher := kafka.NewTransactionPublisher(broker)
+		publisher.Topic = "transactions"
+		publisher.Mode = event.ModeBinary
+
+		accountFrom, _ := entity.NewAccount(100)
+		accountTo, _ := entity.NewAccount(100)
+		transaction, err := entity.NewTransaction(accountFrom, accountTo, uuid.NewV4().String(), entity.TransactionToUser, "AOA", 10)
+		is.Nil(err)
+
+		ctx, parent := otel.Tracer("test").Start(context.Background(), "register")
+		err = publisher.Publish(ctx, transaction)
+		parent.End()
+
+		is.Nil(err)
+
+		messages, err := broker.Browse("transactions", 1)
+		is.Nil(err)
+		is.Len(messages, 1)
+
+		envelope, err := event.Decode(messages[0].Headers, messages[0].Value)
+		is.Nil(err)
+
+		var send tracetest.SpanStub
+		for _, span := range exporter.GetSpans() {
+			if span.Parent.SpanID() == parent.SpanContext().SpanID() {
+				send = span
+			}
+		}
+
+		is.Equal("transactions send", send.Name)
+		is.Equal(trace.SpanKindProducer, send.SpanKind)
+
+		published := trace.SpanContextFromContext(envelope.ExtractTrace(context.Background()))
+		is.Equal(parent.SpanContext().TraceID(), published.TraceID())
+		is.Equal(send.SpanContext.SpanID(), published.SpanID())
+	})
+}
@@ -0,0 +1,194 @@
+package kafka
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	internalHeaderPrefix    = "x-"
+	headerAttempt           = "x-retry-attempt"
+	headerOriginalTopic     = "x-original-topic"
+	headerOriginalPartition = "x-original-partition"
+	headerOriginalOffset    = "x-original-offset"
+	headerNotBefore         = "x-not-before"
+	headerError             = "x-error"
+	headerErrorType         = "x-error-type"
+	headerFailedAt          = "x-failed-at"
+	headerRedrivenFrom      = "x-redriven-from"
+
+	errorTypePermanent = "permanent"
+	errorTypeExhausted = "retries_exhausted"
+)
+
+type permanentError struct {
+	err error
+}
+
+func (p *permanentError) Error() string {
+	return p.err.Error()
+}
+
+func (p *permanentError) Unwrap() error {
+	return p.err
+}
+
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	return &permanentError{err: err}
+}
+
+func IsPermanent(err error) bool {
+	var p *permanentError
+
+	return errors.As(err, &p)
+}
+
+type RetryPolicy struct {
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	Multiplier     float64
+}
+
+func NewRetryPolicy() *RetryPolicy {
+	policy := &RetryPolicy{
+		MaxAttempts:    3,
+		InitialBackoff: time.Second,
+		MaxBackoff:     time.Minute,
+		Multiplier:     2,
+	}
+
+	if value, err := strconv.Atoi(os.Getenv("KAFKA_MAX_RETRIES")); err == nil && value >= 0 {
+		policy.MaxAttempts = value
+	}
+
+	if value, err := time.ParseDuration(os.Getenv("KAFKA_RETRY_BACKOFF")); err == nil && value > 0 {
+		policy.InitialBackoff = value
+	}
+
+	if value, err := time.ParseDuration(os.Getenv("KAFKA_RETRY_MAX_BACKOFF")); err == nil && value > 0 {
+		policy.MaxBackoff = value
+	}
+
+	return policy
+}
+
+func (r *RetryPolicy) Backoff(attempt int) time.Duration {
+	if attempt < 1 {
+		return 0
+	}
+
+	backoff := float64(r.InitialBackoff) * math.Pow(r.Multiplier, float64(attempt-1))
+
+	if backoff > float64(r.MaxBackoff) {
+		return r.MaxBackoff
+	}
+
+	return time.Duration(backoff)
+}
+
+func (r *RetryPolicy) ShouldRetry(attempt int, err error) bool {
+	return !IsPermanent(err) && attempt < r.MaxAttempts
+}
+
+func RetryTopic(topic string, attempt int) string {
+	return fmt.Sprintf("%s.retry.%d", topic, attempt)
+}
+
+func DeadLetterTopic(topic string) string {
+	return topic + ".dlq"
+}
+
+func retryAttempt(msg *Message) int {
+	attempt, err := strconv.Atoi(msg.Header(headerAttempt))
+
+	if err != nil {
+		return 0
+	}
+
+	return attempt
+}
+
+func originalTopic(msg *Message) string {
+	if topic := msg.Header(headerOriginalTopic); topic != "" {
+		return topic
+	}
+
+	return msg.Topic
+}
+
+func notBefore(msg *Message) time.Time {
+	value, err := strconv.ParseInt(msg.Header(headerNotBefore), 10, 64)
+
+	if err != nil {
+		return time.Time{}
+	}
+
+	return time.Unix(0, value*int64(time.Millisecond))
+}
+
+func carriedHeaders(msg *Message) map[string]string {
+	headers := make(map[string]string, len(msg.Headers))
+
+	for key, value := range msg.Headers {
+		if !strings.HasPrefix(key, internalHeaderPrefix) {
+			headers[key] = value
+		}
+	}
+
+	return headers
+}
+
+func originHeaders(msg *Message) map[string]string {
+	headers := carriedHeaders(msg)
+
+	if msg.Header(headerOriginalTopic) != "" {
+		headers[headerOriginalTopic] = msg.Header(headerOriginalTopic)
+		headers[headerOriginalPartition] = msg.Header(headerOriginalPartition)
+		headers[headerOriginalOffset] = msg.Header(headerOriginalOffset)
+
+		return headers
+	}
+
+	headers[headerOriginalTopic] = msg.Topic
+	headers[headerOriginalPartition] = strconv.Itoa(int(msg.Partition))
+	headers[headerOriginalOffset] = strconv.FormatInt(msg.Offset, 10)
+
+	return headers
+}
+
+func retryHeaders(msg *Message, attempt int, delay time.Duration, cause error) map[string]string {
+	headers := originHeaders(msg)
+	due := time.Now().Add(delay).UnixNano() / int64(time.Millisecond)
+
+	headers[headerAttempt] = strconv.Itoa(attempt)
+	headers[headerNotBefore] = strconv.FormatInt(due, 10)
+	headers[headerError] = cause.Error()
+
+	return headers
+}
+
+func deadLetterHeaders(msg *Message, attempt int, cause error) map[string]string {
+	headers := originHeaders(msg)
+	errorType := errorTypeExhausted
+
+	if IsPermanent(cause) {
+		errorType = errorTypePermanent
+	}
+
+	headers[headerAttempt] = strconv.Itoa(attempt)
+	headers[headerError] = cause.Error()
+	headers[headerErrorType] = errorType
+	headers[headerFailedAt] = time.Now().UTC().Format(time.RFC3339Nano)
+
+	return headers
+}
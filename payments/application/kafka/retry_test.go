@@ -0,0 +1,106 @@
+package kafka
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/EdlanioJ/kbu/payments/domain/entity"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRetryPolicy(t *testing.T) {
+	t.Parallel()
+
+	t.Run("should grow backoff until max", func(t *testing.T) {
+		is := require.New(t)
+
+		policy := &RetryPolicy{
+			MaxAttempts:    5,
+			InitialBackoff: time.Second,
+			MaxBackoff:     5 * time.Second,
+			Multiplier:     2,
+		}
+
+		is.Equal(time.Duration(0), policy.Backoff(0))
+		is.Equal(time.Second, policy.Backoff(1))
+		is.Equal(2*time.Second, policy.Backoff(2))
+		is.Equal(4*time.Second, policy.Backoff(3))
+		is.Equal(5*time.Second, policy.Backoff(4))
+	})
+
+	t.Run("should not retry permanent errors", func(t *testing.T) {
+		is := require.New(t)
+
+		policy := &RetryPolicy{MaxAttempts: 3}
+
+		is.True(policy.ShouldRetry(0, errors.New("db error")))
+		is.True(policy.ShouldRetry(2, errors.New("db error")))
+		is.False(policy.ShouldRetry(3, errors.New("db error")))
+		is.False(policy.ShouldRetry(0, Permanent(errors.New("invalid payload"))))
+	})
+
+	t.Run("should name retry and dead letter topics", func(t *testing.T) {
+		is := require.New(t)
+
+		is.Equal("transactions.retry.2", RetryTopic("transactions", 2))
+		is.Equal("transactions.dlq", DeadLetterTopic("transactions"))
+	})
+}
+
+func TestRetryHeaders(t *testing.T) {
+	t.Parallel()
+
+	t.Run("should keep the original position across retries", func(t *testing.T) {
+		is := require.New(t)
+
+		topic := "transactions"
+		msg := &Message{Topic: topic, Partition: 2, Offset: 40, Headers: map[string]string{"ce_id": "1"}}
+
+		retried := &Message{
+			Topic:     RetryTopic(topic, 1),
+			Partition: 0,
+			Offset:    3,
+			Headers:   retryHeaders(msg, 1, time.Minute, errors.New("db error")),
+		}
+
+		is.Equal(topic, originalTopic(retried))
+		is.Equal("1", retried.Header("ce_id"))
+		is.Equal(1, retryAttempt(retried))
+		is.True(notBefore(retried).After(time.Now()))
+
+		deadLetter := parseDeadLetter(&Message{
+			Topic:     DeadLetterTopic(topic),
+			Partition: 0,
+			Offset:    7,
+			Headers:   deadLetterHeaders(retried, 1, Permanent(errors.New("invalid payload"))),
+			Value:     []byte("{}"),
+		})
+
+		is.Equal(topic, deadLetter.OriginalTopic)
+		is.Equal(int32(2), deadLetter.OriginalPartition)
+		is.Equal(int64(40), deadLetter.OriginalOffset)
+		is.Equal(1, deadLetter.Attempts)
+		is.Equal("invalid payload", deadLetter.Error)
+		is.Equal(errorTypePermanent, deadLetter.ErrorType)
+		is.Equal("{}", deadLetter.Payload)
+		is.False(deadLetter.FailedAt.IsZero())
+	})
+}
+
+func TestConfirmationError(t *testing.T) {
+	t.Parallel()
+
+	t.Run("should not retry invalid or unknown confirmations", func(t *testing.T) {
+		is := require.New(t)
+
+		invalid := entity.InvalidArgument("invalid confirmation", map[string]string{"transaction": "must be a valid UUID v4"})
+		unknown := entity.NotFound("payment", "c0a80121-7ac0-4e1c-9b6f-2d0f6a1f7e55")
+
+		is.True(IsPermanent(confirmationError(invalid)))
+		is.True(IsPermanent(confirmationError(unknown)))
+		is.ErrorIs(confirmationError(unknown), unknown)
+		is.False(IsPermanent(confirmationError(errors.New("db error"))))
+		is.False(IsPermanent(confirmationError(entity.Conflict("payment", "1", "the payment was changed or already exists"))))
+	})
+}
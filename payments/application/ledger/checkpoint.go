@@ -0,0 +1,139 @@
+package ledger
+
+import (
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/EdlanioJ/kbu/payments/domain/entity"
+)
+
+var (
+	errInvalidSigningKey = errors.New("signing key file must hold a pem encoded ed25519 private key")
+	errInvalidTrustedKey = errors.New("trusted key file must hold a pem encoded ed25519 public key")
+)
+
+// LoadSigningKey reads a PKCS #8 ed25519 key, as written by
+// `openssl genpkey -algorithm ed25519`. An empty file name means no key.
+func LoadSigningKey(file string) (ed25519.PrivateKey, error) {
+	if file == "" {
+		return nil, nil
+	}
+
+	data, err := ioutil.ReadFile(file)
+
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(data)
+
+	if block == nil {
+		return nil, errInvalidSigningKey
+	}
+
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+
+	if err != nil {
+		return nil, err
+	}
+
+	signingKey, ok := key.(ed25519.PrivateKey)
+
+	if !ok {
+		return nil, errInvalidSigningKey
+	}
+
+	return signingKey, nil
+}
+
+// LoadTrustedKey reads a PKIX ed25519 public key, as written by
+// `openssl pkey -pubout`. An empty file name means no key.
+func LoadTrustedKey(file string) (ed25519.PublicKey, error) {
+	if file == "" {
+		return nil, nil
+	}
+
+	data, err := ioutil.ReadFile(file)
+
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(data)
+
+	if block == nil {
+		return nil, errInvalidTrustedKey
+	}
+
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+
+	if err != nil {
+		return nil, err
+	}
+
+	trustedKey, ok := key.(ed25519.PublicKey)
+
+	if !ok {
+		return nil, errInvalidTrustedKey
+	}
+
+	return trustedKey, nil
+}
+
+func ReadCheckpoint(path string) (*entity.LedgerCheckpoint, error) {
+	data, err := ioutil.ReadFile(path)
+
+	if err != nil {
+		return nil, err
+	}
+
+	checkpoint := &entity.LedgerCheckpoint{}
+
+	err = json.Unmarshal(data, checkpoint)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return checkpoint, nil
+}
+
+// WriteCheckpoint writes the checkpoint to path, through a temporary file so a
+// reader never sees half of it.
+func WriteCheckpoint(path string, checkpoint *entity.LedgerCheckpoint) error {
+	data, err := json.MarshalIndent(checkpoint, "", "  ")
+
+	if err != nil {
+		return err
+	}
+
+	temporary, err := ioutil.TempFile(filepath.Dir(path), ".checkpoint-*")
+
+	if err != nil {
+		return err
+	}
+	defer os.Remove(temporary.Name())
+
+	_, err = temporary.Write(data)
+
+	if closeErr := temporary.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(temporary.Name(), path)
+}
+
+func CheckpointFile(dir string, checkpoint *entity.LedgerCheckpoint) string {
+	return filepath.Join(dir, fmt.Sprintf("checkpoint-%s.json", checkpoint.CreatedAt.Format("20060102T150405Z")))
+}
@@ -0,0 +1,106 @@
+package ledger_test
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/EdlanioJ/kbu/payments/application/ledger"
+	"github.com/EdlanioJ/kbu/payments/domain/entity"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckpointFiles(t *testing.T) {
+	t.Parallel()
+
+	t.Run("should load a pem signing key", func(t *testing.T) {
+		is := require.New(t)
+		dir, err := ioutil.TempDir("", "ledger")
+		is.Nil(err)
+		defer os.RemoveAll(dir)
+
+		_, key, err := ed25519.GenerateKey(rand.Reader)
+		is.Nil(err)
+
+		der, err := x509.MarshalPKCS8PrivateKey(key)
+		is.Nil(err)
+
+		file := filepath.Join(dir, "signing.pem")
+		is.Nil(ioutil.WriteFile(file, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0600))
+
+		loaded, err := ledger.LoadSigningKey(file)
+		is.Nil(err)
+		is.True(key.Equal(loaded))
+
+		is.Nil(ioutil.WriteFile(file, []byte("not a key"), 0600))
+		_, err = ledger.LoadSigningKey(file)
+		is.NotNil(err)
+
+		loaded, err = ledger.LoadSigningKey("")
+		is.Nil(err)
+		is.Nil(loaded)
+	})
+
+	t.Run("should load a pem trusted key", func(t *testing.T) {
+		is := require.New(t)
+		dir, err := ioutil.TempDir("", "ledger")
+		is.Nil(err)
+		defer os.RemoveAll(dir)
+
+		public, key, err := ed25519.GenerateKey(rand.Reader)
+		is.Nil(err)
+
+		der, err := x509.MarshalPKIXPublicKey(public)
+		is.Nil(err)
+
+		file := filepath.Join(dir, "trusted.pem")
+		is.Nil(ioutil.WriteFile(file, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), 0600))
+
+		loaded, err := ledger.LoadTrustedKey(file)
+		is.Nil(err)
+		is.True(public.Equal(loaded))
+
+		der, err = x509.MarshalPKCS8PrivateKey(key)
+		is.Nil(err)
+
+		is.Nil(ioutil.WriteFile(file, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0600))
+		_, err = ledger.LoadTrustedKey(file)
+		is.NotNil(err)
+
+		loaded, err = ledger.LoadTrustedKey("")
+		is.Nil(err)
+		is.Nil(loaded)
+	})
+
+	t.Run("should round trip a signed checkpoint", func(t *testing.T) {
+		is := require.New(t)
+		dir, err := ioutil.TempDir("", "ledger")
+		is.Nil(err)
+		defer os.RemoveAll(dir)
+
+		_, key, err := ed25519.GenerateKey(rand.Reader)
+		is.Nil(err)
+
+		checkpoint := entity.NewLedgerCheckpoint([]*entity.LedgerHead{
+			{Tenant: entity.DefaultTenant, Sequence: 3, Hash: "ab"},
+		})
+		checkpoint.Sign(key)
+
+		path := ledger.CheckpointFile(dir, checkpoint)
+		is.Nil(ledger.WriteCheckpoint(path, checkpoint))
+
+		read, err := ledger.ReadCheckpoint(path)
+		is.Nil(err)
+		is.Equal(checkpoint.Root, read.Root)
+		is.Nil(read.VerifySignature(key.Public().(ed25519.PublicKey)))
+
+		files, err := ioutil.ReadDir(dir)
+		is.Nil(err)
+		is.Len(files, 1)
+	})
+}
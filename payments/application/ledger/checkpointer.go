@@ -0,0 +1,59 @@
+package ledger
+
+import (
+	"context"
+	"time"
+
+	"github.com/EdlanioJ/kbu/payments/domain/usecase"
+	log "github.com/sirupsen/logrus"
+)
+
+type Checkpointer struct {
+	Ledger   usecase.Ledger
+	Dir      string
+	Interval time.Duration
+}
+
+func NewCheckpointer(ledger usecase.Ledger, dir string, interval time.Duration) *Checkpointer {
+	return &Checkpointer{
+		Ledger:   ledger,
+		Dir:      dir,
+		Interval: interval,
+	}
+}
+
+func (c *Checkpointer) Run(ctx context.Context) {
+	log.WithField("dir", c.Dir).Info("ledger checkpointer has been started")
+
+	ticker := time.NewTicker(c.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		path, err := c.Export(ctx)
+
+		if err != nil {
+			log.WithError(err).Error("could not export a ledger checkpoint")
+			continue
+		}
+
+		log.WithField("file", path).Info("ledger checkpoint has been exported")
+	}
+}
+
+func (c *Checkpointer) Export(ctx context.Context) (string, error) {
+	checkpoint, err := c.Ledger.Checkpoint(ctx)
+
+	if err != nil {
+		return "", err
+	}
+
+	path := CheckpointFile(c.Dir, checkpoint)
+
+	return path, WriteCheckpoint(path, checkpoint)
+}
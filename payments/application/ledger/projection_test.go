@@ -0,0 +1,141 @@
+package ledger_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/EdlanioJ/kbu/payments/data/service"
+	"github.com/EdlanioJ/kbu/payments/domain/entity"
+	"github.com/EdlanioJ/kbu/payments/infra/db/gorm/migration"
+	"github.com/EdlanioJ/kbu/payments/infra/db/gorm/repository"
+	"github.com/jinzhu/gorm"
+	uuid "github.com/satori/go.uuid"
+	"github.com/stretchr/testify/require"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func newLedgerTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open("sqlite3", ":memory:")
+	require.Nil(t, err)
+
+	db.LogMode(false)
+	db.DB().SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+
+	repository.RegisterTenancy(db)
+
+	migrator, err := migration.NewMigrator(db)
+	require.Nil(t, err)
+
+	_, err = migrator.Up(0)
+	require.Nil(t, err)
+
+	return db
+}
+
+func TestVerifyProjection(t *testing.T) {
+	t.Parallel()
+
+	t.Run("should catch a payment edited behind the event store", func(t *testing.T) {
+		is := require.New(t)
+		db := newLedgerTestDB(t)
+
+		ctx := context.Background()
+		accountFrom, _ := entity.NewAccount(1000)
+		accountTo, _ := entity.NewAccount(0)
+		is.Nil(repository.NewAccountRepository(db).Save(ctx, accountFrom))
+		is.Nil(repository.NewAccountRepository(db).Save(ctx, accountTo))
+
+		transaction, err := entity.NewTransaction(accountFrom, accountTo, uuid.NewV4().String(), entity.TransactionToUser, "AOA", 300)
+		is.Nil(err)
+		is.Nil(repository.NewTransactionRepository(db).Register(ctx, transaction))
+
+		ledger := service.NewLedger(repository.NewEventRepository(db))
+
+		broken, err := ledger.Verify(ctx, nil)
+		is.Nil(err)
+		is.Nil(broken)
+
+		is.Nil(db.Exec("UPDATE transactions SET amount = ? WHERE id = ?", 3000, transaction.ID).Error)
+
+		broken, err = ledger.Verify(ctx, nil)
+		is.Nil(err)
+		is.Equal(entity.AggregateTransaction, broken.AggregateType)
+		is.Equal(transaction.ID, broken.AggregateID)
+		is.Equal("projection does not match the last event", broken.Reason)
+	})
+}
+
+func TestVerifyRemovedPayment(t *testing.T) {
+	t.Parallel()
+
+	t.Run("should catch a payment removed with all of its events", func(t *testing.T) {
+		is := require.New(t)
+		db := newLedgerTestDB(t)
+
+		ctx := context.Background()
+		accounts := repository.NewAccountRepository(db)
+		accountFrom, _ := entity.NewAccount(1000)
+		accountTo, _ := entity.NewAccount(0)
+		is.Nil(accounts.Save(ctx, accountFrom))
+		is.Nil(accounts.Save(ctx, accountTo))
+
+		transaction, err := entity.NewTransaction(accountFrom, accountTo, uuid.NewV4().String(), entity.TransactionToUser, "AOA", 300)
+		is.Nil(err)
+		is.Nil(repository.NewTransactionRepository(db).Register(ctx, transaction))
+
+		is.Nil(accountFrom.Withdow(300))
+		is.Nil(accounts.Save(ctx, accountFrom))
+
+		ledger := service.NewLedger(repository.NewEventRepository(db))
+
+		broken, err := ledger.Verify(ctx, nil)
+		is.Nil(err)
+		is.Nil(broken)
+
+		is.Nil(db.Exec("DELETE FROM transactions WHERE id = ?", transaction.ID).Error)
+		is.Nil(db.Exec("DELETE FROM events WHERE aggregate_id = ?", transaction.ID).Error)
+
+		broken, err = ledger.Verify(ctx, nil)
+		is.Nil(err)
+		is.Equal(accountFrom.ID, broken.AggregateID)
+		is.Equal("expected sequence 3, found 4", broken.Reason)
+	})
+}
+
+func TestVerifyTenant(t *testing.T) {
+	t.Parallel()
+
+	t.Run("should only walk the chains and rows of the tenant", func(t *testing.T) {
+		is := require.New(t)
+		db := newLedgerTestDB(t)
+
+		acme := entity.WithTenant(context.Background(), "acme")
+		globex := entity.WithTenant(context.Background(), "globex")
+
+		account, _ := entity.NewAccount(100)
+		account.TenantID = "acme"
+		is.Nil(repository.NewAccountRepository(db).Save(acme, account))
+
+		foreign, _ := entity.NewAccount(100)
+		foreign.TenantID = "globex"
+		is.Nil(repository.NewAccountRepository(db).Save(globex, foreign))
+
+		is.Nil(db.Exec("UPDATE accounts SET balance = ? WHERE id = ?", 1000000, foreign.ID).Error)
+
+		ledger := service.NewLedger(repository.NewEventRepository(db))
+
+		broken, err := ledger.Verify(acme, nil)
+		is.Nil(err)
+		is.Nil(broken)
+
+		broken, err = ledger.Verify(globex, nil)
+		is.Nil(err)
+		is.Equal(foreign.ID, broken.AggregateID)
+
+		broken, err = ledger.Verify(context.Background(), nil)
+		is.Nil(err)
+		is.Equal(foreign.ID, broken.AggregateID)
+	})
+}
@@ -0,0 +1,182 @@
+package lifecycle
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Component is a part of the process the manager starts and stops. Start
+// runs until its ctx is cancelled. Ready, when set, blocks until the component
+// can do its work, and the components added after it are only started once it
+// returns.
+type Component struct {
+	Name  string
+	Start func(ctx context.Context) error
+	Ready func(ctx context.Context) error
+	Stop  func(ctx context.Context) error
+}
+
+type Manager struct {
+	StartTimeout    time.Duration
+	ShutdownTimeout time.Duration
+	Signals         []os.Signal
+	components      []*Component
+}
+
+type running struct {
+	component *Component
+	cancel    context.CancelFunc
+	done      chan struct{}
+}
+
+func NewManager() *Manager {
+	return &Manager{
+		StartTimeout:    30 * time.Second,
+		ShutdownTimeout: 30 * time.Second,
+		Signals:         []os.Signal{syscall.SIGINT, syscall.SIGTERM},
+	}
+}
+
+func (m *Manager) Add(component *Component) {
+	m.components = append(m.components, component)
+}
+
+func (m *Manager) Run(ctx context.Context) error {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, m.Signals...)
+	defer signal.Stop(signals)
+
+	failed := make(chan error, len(m.components))
+	started := make([]*running, 0, len(m.components))
+
+	var cause error
+	interrupted := false
+
+	for _, component := range m.components {
+		r := m.start(component, failed)
+		started = append(started, r)
+
+		if component.Ready == nil {
+			continue
+		}
+
+		interrupted, cause = m.await(ctx, r, signals, failed)
+
+		if interrupted {
+			break
+		}
+
+		log.WithField("component", component.Name).Info("component is ready")
+	}
+
+	if !interrupted {
+		select {
+		case received := <-signals:
+			log.WithField("signal", received.String()).Info("shutdown signal has been received")
+		case <-ctx.Done():
+		case cause = <-failed:
+		}
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), m.ShutdownTimeout)
+	defer cancel()
+
+	for i := len(started) - 1; i >= 0; i-- {
+		m.stop(shutdownCtx, started[i])
+	}
+
+	log.Info("shutdown has been completed")
+
+	return cause
+}
+
+func (m *Manager) start(component *Component, failed chan<- error) *running {
+	ctx, cancel := context.WithCancel(context.Background())
+	r := &running{component: component, cancel: cancel, done: make(chan struct{})}
+
+	if component.Start == nil {
+		close(r.done)
+		return r
+	}
+
+	go func() {
+		defer close(r.done)
+
+		err := component.Start(ctx)
+
+		if err != nil {
+			log.WithField("component", component.Name).WithError(err).Error("component has failed")
+			failed <- err
+		}
+	}()
+
+	return r
+}
+
+// await waits until r is ready. It reports whether the start up was
+// interrupted and the error that caused it, if any.
+func (m *Manager) await(ctx context.Context, r *running, signals <-chan os.Signal, failed <-chan error) (bool, error) {
+	readyCtx, cancel := context.WithTimeout(ctx, m.StartTimeout)
+	defer cancel()
+
+	ready := make(chan error, 1)
+
+	go func() {
+		ready <- r.component.Ready(readyCtx)
+	}()
+
+	select {
+	case err := <-ready:
+		if err == nil {
+			return false, nil
+		}
+
+		if ctx.Err() != nil {
+			return true, nil
+		}
+
+		log.WithField("component", r.component.Name).WithError(err).Error("component did not become ready")
+
+		return true, fmt.Errorf("%s did not become ready: %w", r.component.Name, err)
+	case received := <-signals:
+		log.WithField("signal", received.String()).Info("shutdown signal has been received")
+		return true, nil
+	case err := <-failed:
+		return true, err
+	}
+}
+
+func (m *Manager) stop(ctx context.Context, r *running) {
+	logger := log.WithField("component", r.component.Name)
+
+	if r.component.Stop != nil {
+		if err := r.component.Stop(ctx); err != nil {
+			logger.WithError(err).Error("component did not stop cleanly")
+		}
+	}
+
+	r.cancel()
+
+	select {
+	case <-r.done:
+		logger.Info("component has been stopped")
+	case <-ctx.Done():
+		logger.Warn("component did not stop before the shutdown deadline")
+	}
+}
+
+func Worker(name string, run func(ctx context.Context)) *Component {
+	return &Component{
+		Name: name,
+		Start: func(ctx context.Context) error {
+			run(ctx)
+			return nil
+		},
+	}
+}
@@ -0,0 +1,203 @@
+package lifecycle_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/EdlanioJ/kbu/payments/application/lifecycle"
+	"github.com/stretchr/testify/require"
+)
+
+type recorder struct {
+	mu     sync.Mutex
+	events []string
+}
+
+func (r *recorder) record(event string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.events = append(r.events, event)
+}
+
+func (r *recorder) component(name string) *lifecycle.Component {
+	return &lifecycle.Component{
+		Name: name,
+		Start: func(ctx context.Context) error {
+			<-ctx.Done()
+			r.record("exited " + name)
+			return nil
+		},
+		Stop: func(context.Context) error {
+			r.record("stopped " + name)
+			return nil
+		},
+	}
+}
+
+func TestManager(t *testing.T) {
+	t.Parallel()
+
+	t.Run("should stop components in reverse order", func(t *testing.T) {
+		is := require.New(t)
+		r := &recorder{}
+
+		manager := lifecycle.NewManager()
+		manager.Add(&lifecycle.Component{
+			Name: "database",
+			Stop: func(context.Context) error {
+				r.record("stopped database")
+				return nil
+			},
+		})
+		manager.Add(r.component("worker"))
+		manager.Add(r.component("server"))
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		is.Nil(manager.Run(ctx))
+		is.Equal([]string{
+			"stopped server",
+			"exited server",
+			"stopped worker",
+			"exited worker",
+			"stopped database",
+		}, r.events)
+	})
+
+	t.Run("should shut down when a component fails", func(t *testing.T) {
+		is := require.New(t)
+		r := &recorder{}
+
+		manager := lifecycle.NewManager()
+		manager.Add(r.component("worker"))
+		manager.Add(&lifecycle.Component{
+			Name: "server",
+			Start: func(context.Context) error {
+				return errors.New("address already in use")
+			},
+		})
+
+		err := manager.Run(context.Background())
+
+		is.EqualError(err, "address already in use")
+		is.Equal([]string{"stopped worker", "exited worker"}, r.events)
+	})
+
+	t.Run("should start a component once the ones before it are ready", func(t *testing.T) {
+		is := require.New(t)
+		r := &recorder{}
+		listening := make(chan struct{})
+
+		server := r.component("server")
+		start := server.Start
+		server.Start = func(ctx context.Context) error {
+			r.record("started server")
+			time.Sleep(20 * time.Millisecond)
+			close(listening)
+			return start(ctx)
+		}
+		server.Ready = func(ctx context.Context) error {
+			<-listening
+			r.record("server is ready")
+			return nil
+		}
+
+		subscribed := make(chan struct{})
+
+		worker := r.component("worker")
+		worker.Start = func(ctx context.Context) error {
+			r.record("started worker")
+			close(subscribed)
+			<-ctx.Done()
+			return nil
+		}
+		worker.Ready = func(context.Context) error {
+			<-subscribed
+			return nil
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+
+		manager := lifecycle.NewManager()
+		manager.Add(server)
+		manager.Add(worker)
+		manager.Add(lifecycle.Worker("cancel", func(context.Context) { cancel() }))
+
+		is.Nil(manager.Run(ctx))
+		is.Equal([]string{
+			"started server",
+			"server is ready",
+			"started worker",
+			"stopped worker",
+			"stopped server",
+			"exited server",
+		}, r.events)
+	})
+
+	t.Run("should not start the rest when a component is not ready", func(t *testing.T) {
+		is := require.New(t)
+		r := &recorder{}
+
+		database := r.component("database")
+		database.Ready = func(context.Context) error {
+			return errors.New("connection refused")
+		}
+
+		manager := lifecycle.NewManager()
+		manager.Add(database)
+		manager.Add(&lifecycle.Component{
+			Name: "server",
+			Start: func(context.Context) error {
+				r.record("started server")
+				return nil
+			},
+		})
+
+		err := manager.Run(context.Background())
+
+		is.EqualError(err, "database did not become ready: connection refused")
+		is.Equal([]string{"stopped database", "exited database"}, r.events)
+	})
+
+	t.Run("should give up on a component that is never ready", func(t *testing.T) {
+		is := require.New(t)
+		r := &recorder{}
+
+		server := r.component("server")
+		server.Ready = func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		}
+
+		manager := lifecycle.NewManager()
+		manager.StartTimeout = 20 * time.Millisecond
+		manager.Add(server)
+
+		err := manager.Run(context.Background())
+
+		is.EqualError(err, "server did not become ready: context deadline exceeded")
+		is.Equal([]string{"stopped server", "exited server"}, r.events)
+	})
+
+	t.Run("should give up on components past the deadline", func(t *testing.T) {
+		is := require.New(t)
+
+		manager := lifecycle.NewManager()
+		manager.ShutdownTimeout = 50 * time.Millisecond
+		manager.Add(lifecycle.Worker("stuck", func(context.Context) {
+			select {}
+		}))
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		started := time.Now()
+		is.Nil(manager.Run(ctx))
+		is.True(time.Since(started) < time.Second)
+	})
+}
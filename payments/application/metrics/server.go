@@ -0,0 +1,61 @@
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	log "github.com/sirupsen/logrus"
+)
+
+type Server struct {
+	HTTP      *http.Server
+	Port      int
+	listening chan struct{}
+}
+
+func NewMetricsServer(port int) *Server {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+
+	return &Server{
+		HTTP:      &http.Server{Addr: fmt.Sprintf("0.0.0.0:%d", port), Handler: mux},
+		Port:      port,
+		listening: make(chan struct{}),
+	}
+}
+
+func (s *Server) Serve(ctx context.Context) error {
+	listener, err := net.Listen("tcp", s.HTTP.Addr)
+
+	if err != nil {
+		return err
+	}
+
+	log.Infof("metrics server has been started on port %d", s.Port)
+	close(s.listening)
+
+	err = s.HTTP.Serve(listener)
+
+	if err == http.ErrServerClosed {
+		return nil
+	}
+
+	return err
+}
+
+// Ready blocks until the server is listening.
+func (s *Server) Ready(ctx context.Context) error {
+	select {
+	case <-s.listening:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (s *Server) Stop(ctx context.Context) error {
+	return s.HTTP.Shutdown(ctx)
+}
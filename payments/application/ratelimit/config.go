@@ -0,0 +1,52 @@
+package ratelimit
+
+import (
+	"path"
+	"strings"
+)
+
+type Limit struct {
+	Rate  float64 `mapstructure:"rate"`
+	Burst int     `mapstructure:"burst"`
+}
+
+func (l *Limit) Enabled() bool {
+	return l != nil && l.Rate > 0 && l.Burst > 0
+}
+
+// Config is read from the rate_limit section of the config file. Method
+// limits are keyed by the bare rpc name (e.g. "register") because viper
+// lower-cases keys and splits them on dots.
+type Config struct {
+	Caller  *Limit            `mapstructure:"caller"`
+	Account *Limit            `mapstructure:"account"`
+	Methods map[string]*Limit `mapstructure:"methods"`
+}
+
+func (c *Config) Enabled() bool {
+	if c == nil {
+		return false
+	}
+
+	if c.Caller.Enabled() || c.Account.Enabled() {
+		return true
+	}
+
+	for _, limit := range c.Methods {
+		if limit.Enabled() {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (c *Config) Method(fullMethod string) *Limit {
+	limit := c.Methods[strings.ToLower(path.Base(fullMethod))]
+
+	if !limit.Enabled() {
+		return nil
+	}
+
+	return limit
+}
@@ -0,0 +1,99 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+const (
+	ScopeCaller  = "caller"
+	ScopeMethod  = "method"
+	ScopeAccount = "account"
+)
+
+type Store interface {
+	Take(ctx context.Context, key string, limit *Limit) (allowed bool, retryAfter time.Duration, err error)
+}
+
+type ExceededError struct {
+	Scope      string
+	Key        string
+	RetryAfter time.Duration
+}
+
+func (e *ExceededError) Error() string {
+	return fmt.Sprintf("%s rate limit exceeded, retry after %s", e.Scope, e.RetryAfter)
+}
+
+type Limiter struct {
+	Config *Config
+	Store  Store
+}
+
+func NewLimiter(config *Config, store Store) *Limiter {
+	return &Limiter{
+		Config: config,
+		Store:  store,
+	}
+}
+
+func (l *Limiter) Allow(ctx context.Context, caller, method string) error {
+	if l.Config.Caller.Enabled() {
+		err := l.take(ctx, ScopeCaller, "caller:"+caller, l.Config.Caller)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	if limit := l.Config.Method(method); limit != nil {
+		return l.take(ctx, ScopeMethod, "method:"+method+":"+caller, limit)
+	}
+
+	return nil
+}
+
+// AllowAccounts takes one token for each distinct account a request debits,
+// so a batch counts once per account however many of its items share it.
+func (l *Limiter) AllowAccounts(ctx context.Context, accountIDs ...string) error {
+	if !l.Config.Account.Enabled() {
+		return nil
+	}
+
+	seen := make(map[string]bool, len(accountIDs))
+
+	for _, accountID := range accountIDs {
+		if accountID == "" || seen[accountID] {
+			continue
+		}
+
+		seen[accountID] = true
+
+		err := l.take(ctx, ScopeAccount, "account:"+accountID, l.Config.Account)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (l *Limiter) take(ctx context.Context, scope, key string, limit *Limit) error {
+	allowed, retryAfter, err := l.Store.Take(ctx, key, limit)
+
+	if err != nil {
+		return err
+	}
+
+	if !allowed {
+		return &ExceededError{
+			Scope:      scope,
+			Key:        key,
+			RetryAfter: retryAfter,
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,93 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+	full    time.Time
+}
+
+type MemoryStore struct {
+	SweepInterval time.Duration
+	Now           func() time.Time
+	mu            sync.Mutex
+	buckets       map[string]*bucket
+	swept         time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		SweepInterval: time.Minute,
+		Now:           time.Now,
+		buckets:       make(map[string]*bucket),
+	}
+}
+
+func (s *MemoryStore) Take(ctx context.Context, key string, limit *Limit) (bool, time.Duration, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.Now()
+	s.sweep(now)
+
+	b, ok := s.buckets[key]
+
+	if !ok {
+		b = &bucket{tokens: float64(limit.Burst), updated: now}
+		s.buckets[key] = b
+	}
+
+	elapsed := now.Sub(b.updated).Seconds()
+
+	if elapsed > 0 {
+		b.tokens = math.Min(float64(limit.Burst), b.tokens+elapsed*limit.Rate)
+		b.updated = now
+	}
+
+	allowed := b.tokens >= 1
+
+	if allowed {
+		b.tokens--
+	}
+
+	b.full = now.Add(seconds((float64(limit.Burst) - b.tokens) / limit.Rate))
+
+	if allowed {
+		return true, 0, nil
+	}
+
+	return false, seconds((1 - b.tokens) / limit.Rate), nil
+}
+
+func (s *MemoryStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.buckets)
+}
+
+// sweep drops buckets that have refilled completely, since a fresh bucket
+// behaves exactly the same.
+func (s *MemoryStore) sweep(now time.Time) {
+	if now.Sub(s.swept) < s.SweepInterval {
+		return
+	}
+
+	for key, b := range s.buckets {
+		if !now.Before(b.full) {
+			delete(s.buckets, key)
+		}
+	}
+
+	s.swept = now
+}
+
+func seconds(value float64) time.Duration {
+	return time.Duration(value * float64(time.Second))
+}
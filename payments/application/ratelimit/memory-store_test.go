@@ -0,0 +1,160 @@
+package ratelimit_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/EdlanioJ/kbu/payments/application/ratelimit"
+	"github.com/stretchr/testify/require"
+)
+
+type clock struct {
+	now time.Time
+}
+
+func (c *clock) Now() time.Time {
+	return c.now
+}
+
+func newTestStore() (*ratelimit.MemoryStore, *clock) {
+	c := &clock{now: time.Now()}
+	store := ratelimit.NewMemoryStore()
+	store.Now = c.Now
+
+	return store, c
+}
+
+func TestMemoryStore(t *testing.T) {
+	t.Parallel()
+
+	limit := &ratelimit.Limit{Rate: 2, Burst: 3}
+
+	t.Run("should allow up to burst and then ask to retry", func(t *testing.T) {
+		is := require.New(t)
+		store, _ := newTestStore()
+
+		for i := 0; i < 3; i++ {
+			allowed, _, err := store.Take(context.Background(), "key", limit)
+			is.Nil(err)
+			is.True(allowed)
+		}
+
+		allowed, retryAfter, err := store.Take(context.Background(), "key", limit)
+
+		is.Nil(err)
+		is.False(allowed)
+		is.Equal(500*time.Millisecond, retryAfter)
+	})
+
+	t.Run("should refill tokens over time", func(t *testing.T) {
+		is := require.New(t)
+		store, c := newTestStore()
+
+		for i := 0; i < 3; i++ {
+			store.Take(context.Background(), "key", limit)
+		}
+
+		c.now = c.now.Add(time.Second)
+
+		for i := 0; i < 2; i++ {
+			allowed, _, _ := store.Take(context.Background(), "key", limit)
+			is.True(allowed)
+		}
+
+		allowed, _, _ := store.Take(context.Background(), "key", limit)
+		is.False(allowed)
+	})
+
+	t.Run("should keep keys independent", func(t *testing.T) {
+		is := require.New(t)
+		store, _ := newTestStore()
+
+		for i := 0; i < 3; i++ {
+			store.Take(context.Background(), "a", limit)
+		}
+
+		allowed, _, _ := store.Take(context.Background(), "b", limit)
+		is.True(allowed)
+	})
+
+	t.Run("should drop refilled buckets", func(t *testing.T) {
+		is := require.New(t)
+		store, c := newTestStore()
+
+		store.Take(context.Background(), "a", limit)
+		store.Take(context.Background(), "b", limit)
+		is.Equal(2, store.Len())
+
+		c.now = c.now.Add(2 * time.Minute)
+		store.Take(context.Background(), "c", limit)
+
+		is.Equal(1, store.Len())
+	})
+}
+
+func TestLimiter(t *testing.T) {
+	t.Parallel()
+
+	config := &ratelimit.Config{
+		Caller:  &ratelimit.Limit{Rate: 1, Burst: 2},
+		Account: &ratelimit.Limit{Rate: 1, Burst: 1},
+		Methods: map[string]*ratelimit.Limit{
+			"register": {Rate: 1, Burst: 1},
+		},
+	}
+
+	t.Run("should limit per method and caller", func(t *testing.T) {
+		is := require.New(t)
+		limiter := ratelimit.NewLimiter(config, ratelimit.NewMemoryStore())
+
+		is.Nil(limiter.Allow(context.Background(), "alice", "/payments.PaymentService/Register"))
+		is.Nil(limiter.Allow(context.Background(), "bob", "/payments.PaymentService/Register"))
+
+		err := limiter.Allow(context.Background(), "alice", "/payments.PaymentService/Register")
+		exceeded, ok := err.(*ratelimit.ExceededError)
+
+		is.True(ok)
+		is.Equal(ratelimit.ScopeMethod, exceeded.Scope)
+	})
+
+	t.Run("should limit per caller across methods", func(t *testing.T) {
+		is := require.New(t)
+		limiter := ratelimit.NewLimiter(config, ratelimit.NewMemoryStore())
+
+		is.Nil(limiter.Allow(context.Background(), "alice", "/payments.PaymentService/Get"))
+		is.Nil(limiter.Allow(context.Background(), "alice", "/payments.PaymentService/List"))
+
+		err := limiter.Allow(context.Background(), "alice", "/payments.PaymentService/Get")
+		exceeded, ok := err.(*ratelimit.ExceededError)
+
+		is.True(ok)
+		is.Equal(ratelimit.ScopeCaller, exceeded.Scope)
+	})
+
+	t.Run("should limit per account", func(t *testing.T) {
+		is := require.New(t)
+		limiter := ratelimit.NewLimiter(config, ratelimit.NewMemoryStore())
+
+		is.Nil(limiter.AllowAccounts(context.Background(), "account-1"))
+
+		err := limiter.AllowAccounts(context.Background(), "account-1")
+		exceeded, ok := err.(*ratelimit.ExceededError)
+
+		is.True(ok)
+		is.Equal(ratelimit.ScopeAccount, exceeded.Scope)
+		is.Nil(limiter.AllowAccounts(context.Background(), "account-2"))
+	})
+	t.Run("should take one token per distinct account", func(t *testing.T) {
+		is := require.New(t)
+		limiter := ratelimit.NewLimiter(config, ratelimit.NewMemoryStore())
+
+		is.Nil(limiter.AllowAccounts(context.Background(), "account-1", "account-1", "account-2", "account-1"))
+
+		err := limiter.AllowAccounts(context.Background(), "account-2")
+		exceeded, ok := err.(*ratelimit.ExceededError)
+
+		is.True(ok)
+		is.Equal("account:account-2", exceeded.Key)
+	})
+}
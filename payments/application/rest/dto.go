@@ -0,0 +1,114 @@
+package rest
+
+import (
+	"time"
+
+	"github.com/EdlanioJ/kbu/payments/domain/entity"
+)
+
+type RegisterRequest struct {
+	AccountFrom string  `json:"account_from" description:"account that pays"`
+	AccountTo   string  `json:"account_to" description:"account, service or store that receives"`
+	ExternalID  string  `json:"external_id" description:"caller reference"`
+	Type        string  `json:"type" enum:"to_user,to_service,to_store"`
+	Currency    string  `json:"currency"`
+	Amount      float64 `json:"amount"`
+}
+
+type RegisterBatchRequest struct {
+	Mode  string             `json:"mode" enum:"atomic,best_effort"`
+	Items []*RegisterRequest `json:"items"`
+}
+
+type Transaction struct {
+	ID          string    `json:"id"`
+	Amount      float64   `json:"amount"`
+	Status      string    `json:"status" enum:"pending,completed,canceled"`
+	Currency    string    `json:"currency"`
+	AccountFrom string    `json:"account_from"`
+	AccountTo   string    `json:"account_to"`
+	Type        string    `json:"type" enum:"to_user,to_service,to_store"`
+	ExternalID  string    `json:"external_id"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+type TransactionList struct {
+	Transactions []*Transaction `json:"transactions"`
+	Total        int            `json:"total"`
+}
+
+type ExportItem struct {
+	Transaction *Transaction `json:"transaction"`
+	ResumeToken string       `json:"resume_token"`
+}
+
+type BatchItem struct {
+	Position      int    `json:"position"`
+	Status        string `json:"status"`
+	TransactionID string `json:"transaction_id,omitempty"`
+	Error         string `json:"error,omitempty"`
+}
+
+type Batch struct {
+	ID        string       `json:"id"`
+	Mode      string       `json:"mode"`
+	Status    string       `json:"status"`
+	Total     int          `json:"total"`
+	Succeeded int          `json:"succeeded"`
+	Failed    int          `json:"failed"`
+	CreatedAt time.Time    `json:"created_at"`
+	Items     []*BatchItem `json:"items"`
+}
+
+func toTransaction(transaction *entity.Transaction) *Transaction {
+	return &Transaction{
+		ID:          transaction.ID,
+		Amount:      transaction.Amount,
+		Status:      transaction.Status,
+		Currency:    transaction.Currency,
+		AccountFrom: transaction.AccountFromID,
+		AccountTo:   transaction.AccountToID,
+		Type:        transaction.Type,
+		ExternalID:  transaction.ExternalID,
+		CreatedAt:   transaction.CreatedAt,
+		UpdatedAt:   transaction.UpdatedAt,
+	}
+}
+
+func toTransactionList(transactions []*entity.Transaction, total int) *TransactionList {
+	list := &TransactionList{
+		Transactions: make([]*Transaction, 0, len(transactions)),
+		Total:        total,
+	}
+
+	for _, transaction := range transactions {
+		list.Transactions = append(list.Transactions, toTransaction(transaction))
+	}
+
+	return list
+}
+
+func toBatch(batch *entity.Batch, items []*entity.BatchItem) *Batch {
+	response := &Batch{
+		ID:        batch.ID,
+		Mode:      batch.Mode,
+		Status:    batch.Status,
+		Total:     batch.Total,
+		Succeeded: batch.Succeeded,
+		Failed:    batch.Failed,
+		CreatedAt: batch.CreatedAt,
+		Items:     make([]*BatchItem, 0, len(items)),
+	}
+
+	for _, item := range items {
+		response.Items = append(response.Items, &BatchItem{
+			Position:      item.Position,
+			Status:        item.Status,
+			TransactionID: item.TransactionID,
+			Error:         item.Error,
+		})
+	}
+
+	return response
+}
@@ -0,0 +1,160 @@
+package rest
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/EdlanioJ/kbu/payments/application/ratelimit"
+	"github.com/EdlanioJ/kbu/payments/domain/entity"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	errorRateLimited = "RATE_LIMITED"
+	errorInternal    = "INTERNAL"
+)
+
+var (
+	errRouteNotFound    = &entity.DomainError{Kind: entity.ErrorNotFound, Message: "route not found"}
+	errMethodNotAllowed = &entity.DomainError{Kind: "METHOD_NOT_ALLOWED", Message: "method not allowed"}
+)
+
+var errorStatus = map[string]int{
+	entity.ErrorNotFound:          http.StatusNotFound,
+	entity.ErrorInvalidArgument:   http.StatusBadRequest,
+	entity.ErrorInsufficientFunds: http.StatusUnprocessableEntity,
+	entity.ErrorAccountFrozen:     http.StatusUnprocessableEntity,
+	entity.ErrorConflict:          http.StatusConflict,
+	entity.ErrorUnauthenticated:   http.StatusUnauthorized,
+	entity.ErrorPermissionDenied:  http.StatusForbidden,
+	errMethodNotAllowed.Kind:      http.StatusMethodNotAllowed,
+}
+
+var fallbackReasons = map[int]string{
+	http.StatusBadRequest: entity.ErrorInvalidArgument,
+	http.StatusNotFound:   entity.ErrorNotFound,
+}
+
+type statusError struct {
+	status int
+	err    error
+}
+
+func (e *statusError) Error() string {
+	return e.err.Error()
+}
+
+func (e *statusError) Unwrap() error {
+	return e.err
+}
+
+// withStatus sets the status used when err is not a domain error, the same
+// way each gRPC handler picks a fallback code. The controllers already
+// replace internal errors with safe messages.
+func withStatus(err error, status int) error {
+	if err == nil {
+		return nil
+	}
+
+	if _, ok := entity.AsDomainError(err); ok {
+		return err
+	}
+
+	return &statusError{status: status, err: err}
+}
+
+type ErrorBody struct {
+	Error *ErrorDetail `json:"error"`
+}
+
+type ErrorDetail struct {
+	Status    int               `json:"status"`
+	Reason    string            `json:"reason"`
+	Message   string            `json:"message"`
+	RequestID string            `json:"request_id,omitempty"`
+	Metadata  map[string]string `json:"metadata,omitempty"`
+	Fields    map[string]string `json:"fields,omitempty"`
+}
+
+// errorResponse follows the same kind to code mapping as the gRPC handlers.
+// Errors without a domain kind or a fallback status never leak their message.
+func errorResponse(err error) *ErrorDetail {
+	var exceeded *ratelimit.ExceededError
+
+	if errors.As(err, &exceeded) {
+		return &ErrorDetail{
+			Status:  http.StatusTooManyRequests,
+			Reason:  errorRateLimited,
+			Message: exceeded.Error(),
+		}
+	}
+
+	domainError, ok := entity.AsDomainError(err)
+
+	var fallback *statusError
+
+	if !ok && errors.As(err, &fallback) {
+		reason, ok := fallbackReasons[fallback.status]
+
+		if !ok {
+			reason = errorInternal
+		}
+
+		return &ErrorDetail{
+			Status:  fallback.status,
+			Reason:  reason,
+			Message: fallback.Error(),
+		}
+	}
+
+	if !ok {
+		return &ErrorDetail{
+			Status:  http.StatusInternalServerError,
+			Reason:  errorInternal,
+			Message: "internal server error",
+		}
+	}
+
+	status, ok := errorStatus[domainError.Kind]
+
+	if !ok {
+		status = http.StatusInternalServerError
+	}
+
+	return &ErrorDetail{
+		Status:   status,
+		Reason:   domainError.Kind,
+		Message:  domainError.Message,
+		Metadata: domainError.Metadata,
+		Fields:   domainError.Fields,
+	}
+}
+
+func writeError(w http.ResponseWriter, r *http.Request, err error) {
+	detail := errorResponse(err)
+	detail.RequestID = RequestID(r.Context())
+
+	if detail.Status == http.StatusInternalServerError {
+		logger(r.Context()).WithError(err).Error("request failed")
+	}
+
+	var exceeded *ratelimit.ExceededError
+
+	if errors.As(err, &exceeded) {
+		w.Header().Set("Retry-After", strconv.FormatInt(int64(math.Max(1, math.Ceil(exceeded.RetryAfter.Seconds()))), 10))
+	}
+
+	writeJSON(w, detail.Status, &ErrorBody{Error: detail})
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		log.WithError(err).Warn("could not write response body")
+	}
+}
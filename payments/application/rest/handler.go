@@ -0,0 +1,487 @@
+package rest
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/EdlanioJ/kbu/payments/application/kafka"
+	"github.com/EdlanioJ/kbu/payments/domain/entity"
+	"github.com/EdlanioJ/kbu/payments/presentation/controller"
+)
+
+const (
+	defaultPage  = 1
+	defaultLimit = 20
+	defaultSort  = "created_at desc"
+
+	directionFrom = "from"
+	directionTo   = "to"
+)
+
+var paginationQuery = []*QueryParam{
+	{Name: "page", Type: "integer", Description: "page number, starts at 1"},
+	{Name: "limit", Type: "integer", Description: "page size"},
+	{Name: "sort", Type: "string", Description: "sort expression, e.g. created_at desc"},
+}
+
+var directionQuery = &QueryParam{Name: "direction", Type: "string", Description: "from (default) lists payments sent by the account, to lists payments received"}
+
+var exportQuery = []*QueryParam{
+	{Name: "type", Type: "string"},
+	{Name: "status", Type: "string"},
+	{Name: "account_from", Type: "string"},
+	{Name: "account_to", Type: "string"},
+	{Name: "external_id", Type: "string"},
+	{Name: "created_from", Type: "string", Description: "RFC 3339 timestamp, inclusive"},
+	{Name: "created_to", Type: "string", Description: "RFC 3339 timestamp, exclusive"},
+	{Name: "resume_token", Type: "string", Description: "resume token of the last item received"},
+}
+
+type TransactionHandler struct {
+	TransactionController *controller.Transaction
+	TransactionPublisher  *kafka.TransactionPublisher
+	SagaController        *controller.Saga
+	BatchController       *controller.Batch
+}
+
+func NewTransactionHandler(
+	transaction *controller.Transaction,
+	publisher *kafka.TransactionPublisher,
+	saga *controller.Saga,
+	batch *controller.Batch,
+) *TransactionHandler {
+
+	return &TransactionHandler{
+		TransactionController: transaction,
+		TransactionPublisher:  publisher,
+		SagaController:        saga,
+		BatchController:       batch,
+	}
+}
+
+func (h *TransactionHandler) Routes(router *Router) {
+	router.Add(&Route{
+		Method: http.MethodPost, Path: "/transactions", OperationID: "register", Tag: "transactions",
+		Summary: "Register a payment", Body: &RegisterRequest{}, Response: &Transaction{}, Status: http.StatusCreated,
+		Handler: h.Register,
+	})
+	router.Add(&Route{
+		Method: http.MethodGet, Path: "/transactions", OperationID: "list", Tag: "transactions",
+		Summary: "List payments", Query: paginationQuery, Response: &TransactionList{},
+		Handler: h.List,
+	})
+	router.Add(&Route{
+		Method: http.MethodGet, Path: "/transactions/export", OperationID: "exportTransactions", Tag: "transactions",
+		Summary: "Export payments as newline delimited json", Query: exportQuery, Response: &ExportItem{}, Stream: true,
+		Handler: h.Export,
+	})
+	router.Add(&Route{
+		Method: http.MethodGet, Path: "/transactions/{id}", OperationID: "get", Tag: "transactions",
+		Summary: "Get a payment", Response: &Transaction{},
+		Handler: h.Get,
+	})
+	router.Add(&Route{
+		Method: http.MethodGet, Path: "/types/{type}/transactions", OperationID: "listByType", Tag: "transactions",
+		Summary: "List payments of a type", Query: paginationQuery, Response: &TransactionList{},
+		Handler: h.ListByType,
+	})
+	router.Add(&Route{
+		Method: http.MethodGet, Path: "/types/{type}/transactions/{id}", OperationID: "getByType", Tag: "transactions",
+		Summary: "Get a payment of a type", Response: &Transaction{},
+		Handler: h.GetByType,
+	})
+	router.Add(&Route{
+		Method: http.MethodGet, Path: "/references/{reference}/transactions", OperationID: "listByReference", Tag: "transactions",
+		Summary: "List payments with a caller reference", Query: paginationQuery, Response: &TransactionList{},
+		Handler: h.ListByReference,
+	})
+	router.Add(&Route{
+		Method: http.MethodGet, Path: "/references/{reference}/transactions/{id}", OperationID: "getByReference", Tag: "transactions",
+		Summary: "Get a payment with a caller reference", Response: &Transaction{},
+		Handler: h.GetByReference,
+	})
+	router.Add(&Route{
+		Method: http.MethodGet, Path: "/accounts/{account}/transactions", OperationID: "listByAccount", Tag: "accounts",
+		Summary: "List payments sent or received by an account", Query: append([]*QueryParam{directionQuery}, paginationQuery...), Response: &TransactionList{},
+		Handler: h.ListByAccount,
+	})
+	router.Add(&Route{
+		Method: http.MethodGet, Path: "/accounts/{account}/transactions/{id}", OperationID: "getByAccount", Tag: "accounts",
+		Summary: "Get a payment sent or received by an account", Query: []*QueryParam{directionQuery}, Response: &Transaction{},
+		Handler: h.GetByAccount,
+	})
+	router.Add(&Route{
+		Method: http.MethodPost, Path: "/batches", OperationID: "registerBatch", Tag: "batches",
+		Summary: "Register a batch of payments", Body: &RegisterBatchRequest{}, Response: &Batch{}, Status: http.StatusCreated,
+		Handler: h.RegisterBatch,
+	})
+	router.Add(&Route{
+		Method: http.MethodGet, Path: "/batches/{id}", OperationID: "getBatch", Tag: "batches",
+		Summary: "Get a batch of payments", Response: &Batch{},
+		Handler: h.GetBatch,
+	})
+}
+
+func (h *TransactionHandler) Register(w http.ResponseWriter, r *http.Request) error {
+	in := &RegisterRequest{}
+
+	if err := decode(r, in); err != nil {
+		return err
+	}
+
+	ctx := r.Context()
+
+	if err := allowAccounts(ctx, in.AccountFrom); err != nil {
+		return err
+	}
+
+	if key := r.Header.Get("Idempotency-Key"); key != "" {
+		ctx = controller.WithIdempotencyKey(ctx, key)
+	}
+
+	if in.Type == entity.TransactionToService && h.SagaController != nil {
+		saga, err := h.SagaController.Start(ctx, in.AccountFrom, in.AccountTo, in.ExternalID, in.Currency, in.Amount)
+
+		if err != nil {
+			return withStatus(err, http.StatusInternalServerError)
+		}
+
+		if saga.Status == entity.SagaCompensated {
+			return entity.Conflict("service payment", saga.ID, saga.Error)
+		}
+
+		transaction, err := h.TransactionController.Get(ctx, saga.TransactionID)
+
+		if err != nil {
+			return withStatus(err, http.StatusInternalServerError)
+		}
+
+		writeJSON(w, http.StatusCreated, toTransaction(transaction))
+		return nil
+	}
+
+	transaction, err := h.TransactionController.Register(ctx, in.AccountFrom, in.AccountTo, in.ExternalID, in.Type, in.Currency, in.Amount)
+
+	if err != nil {
+		return withStatus(err, http.StatusInternalServerError)
+	}
+
+	err = h.TransactionPublisher.Publish(ctx, transaction)
+
+	if err != nil {
+		logger(ctx).WithField("transaction_id", transaction.ID).WithError(err).Error("error on publish transaction")
+	}
+
+	writeJSON(w, http.StatusCreated, toTransaction(transaction))
+	return nil
+}
+
+func (h *TransactionHandler) Get(w http.ResponseWriter, r *http.Request) error {
+	transaction, err := h.TransactionController.Get(r.Context(), Param(r, "id"))
+
+	return writeTransaction(w, transaction, err)
+}
+
+func (h *TransactionHandler) List(w http.ResponseWriter, r *http.Request) error {
+	page, limit, sort, err := pagination(r)
+
+	if err != nil {
+		return err
+	}
+
+	transactions, total, err := h.TransactionController.List(r.Context(), page, limit, sort)
+
+	return writeTransactionList(w, transactions, total, err)
+}
+
+func (h *TransactionHandler) GetByType(w http.ResponseWriter, r *http.Request) error {
+	transaction, err := h.TransactionController.GetByType(r.Context(), Param(r, "id"), Param(r, "type"))
+
+	return writeTransaction(w, transaction, err)
+}
+
+func (h *TransactionHandler) ListByType(w http.ResponseWriter, r *http.Request) error {
+	page, limit, sort, err := pagination(r)
+
+	if err != nil {
+		return err
+	}
+
+	transactions, total, err := h.TransactionController.ListByType(r.Context(), Param(r, "type"), page, limit, sort)
+
+	return writeTransactionList(w, transactions, total, err)
+}
+
+func (h *TransactionHandler) GetByReference(w http.ResponseWriter, r *http.Request) error {
+	transaction, err := h.TransactionController.GetByExternalID(r.Context(), Param(r, "id"), Param(r, "reference"))
+
+	return writeTransaction(w, transaction, err)
+}
+
+func (h *TransactionHandler) ListByReference(w http.ResponseWriter, r *http.Request) error {
+	page, limit, sort, err := pagination(r)
+
+	if err != nil {
+		return err
+	}
+
+	transactions, total, err := h.TransactionController.ListByExternalID(r.Context(), Param(r, "reference"), page, limit, sort)
+
+	return writeTransactionList(w, transactions, total, err)
+}
+
+func (h *TransactionHandler) GetByAccount(w http.ResponseWriter, r *http.Request) error {
+	direction, err := accountDirection(r)
+
+	if err != nil {
+		return err
+	}
+
+	var transaction *entity.Transaction
+
+	if direction == directionTo {
+		transaction, err = h.TransactionController.GetByAccoutTo(r.Context(), Param(r, "id"), Param(r, "account"))
+	} else {
+		transaction, err = h.TransactionController.GetByAccountFrom(r.Context(), Param(r, "id"), Param(r, "account"))
+	}
+
+	return writeTransaction(w, transaction, err)
+}
+
+func (h *TransactionHandler) ListByAccount(w http.ResponseWriter, r *http.Request) error {
+	direction, err := accountDirection(r)
+
+	if err != nil {
+		return err
+	}
+
+	page, limit, sort, err := pagination(r)
+
+	if err != nil {
+		return err
+	}
+
+	var transactions []*entity.Transaction
+	var total int
+
+	if direction == directionTo {
+		transactions, total, err = h.TransactionController.ListByAccountTo(r.Context(), Param(r, "account"), page, limit, sort)
+	} else {
+		transactions, total, err = h.TransactionController.ListByAccountFrom(r.Context(), Param(r, "account"), page, limit, sort)
+	}
+
+	return writeTransactionList(w, transactions, total, err)
+}
+
+// Export streams newline delimited json. Once the first item is written the
+// status can no longer change, so a failure halfway is reported as a final
+// error line and the client resumes from the last resume token it got.
+func (h *TransactionHandler) Export(w http.ResponseWriter, r *http.Request) error {
+	query := r.URL.Query()
+
+	filter := &entity.TransactionFilter{
+		Type:          query.Get("type"),
+		Status:        query.Get("status"),
+		AccountFromID: query.Get("account_from"),
+		AccountToID:   query.Get("account_to"),
+		ExternalID:    query.Get("external_id"),
+	}
+
+	var err error
+
+	if value := query.Get("created_from"); value != "" {
+		filter.CreatedFrom, err = time.Parse(time.RFC3339, value)
+
+		if err != nil {
+			return entity.InvalidArgument("created_from must be an RFC 3339 timestamp", map[string]string{"created_from": err.Error()})
+		}
+	}
+
+	if value := query.Get("created_to"); value != "" {
+		filter.CreatedTo, err = time.Parse(time.RFC3339, value)
+
+		if err != nil {
+			return entity.InvalidArgument("created_to must be an RFC 3339 timestamp", map[string]string{"created_to": err.Error()})
+		}
+	}
+
+	flusher, _ := w.(http.Flusher)
+	encoder := json.NewEncoder(w)
+	started := false
+
+	err = h.TransactionController.Export(r.Context(), filter, query.Get("resume_token"), func(transaction *entity.Transaction, resumeToken string) error {
+		if !started {
+			w.Header().Set("Content-Type", "application/x-ndjson")
+			w.WriteHeader(http.StatusOK)
+			started = true
+		}
+
+		err := encoder.Encode(&ExportItem{Transaction: toTransaction(transaction), ResumeToken: resumeToken})
+
+		if err == nil && flusher != nil {
+			flusher.Flush()
+		}
+
+		return err
+	})
+
+	err = withStatus(err, http.StatusInternalServerError)
+
+	if err != nil && started {
+		detail := errorResponse(err)
+		detail.RequestID = RequestID(r.Context())
+
+		return encoder.Encode(&ErrorBody{Error: detail})
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if !started {
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		w.WriteHeader(http.StatusOK)
+	}
+
+	return nil
+}
+
+func (h *TransactionHandler) RegisterBatch(w http.ResponseWriter, r *http.Request) error {
+	in := &RegisterBatchRequest{}
+
+	if err := decode(r, in); err != nil {
+		return err
+	}
+
+	ctx := r.Context()
+	items := make([]*entity.BatchItem, 0, len(in.Items))
+	accounts := make([]string, 0, len(in.Items))
+
+	for position, item := range in.Items {
+		if item == nil {
+			item = &RegisterRequest{}
+		}
+
+		items = append(items, entity.NewBatchItem(position, item.AccountFrom, item.AccountTo, item.ExternalID, item.Type, item.Currency, item.Amount))
+		accounts = append(accounts, item.AccountFrom)
+	}
+
+	if err := allowAccounts(ctx, accounts...); err != nil {
+		return err
+	}
+
+	if key := r.Header.Get("Idempotency-Key"); key != "" {
+		ctx = controller.WithIdempotencyKey(ctx, key)
+	}
+
+	batch, items, err := h.BatchController.Register(ctx, in.Mode, items)
+
+	if err != nil {
+		return withStatus(err, http.StatusInternalServerError)
+	}
+
+	for _, item := range items {
+		if item.Transaction == nil {
+			continue
+		}
+
+		err = h.TransactionPublisher.Publish(ctx, item.Transaction)
+
+		if err != nil {
+			logger(ctx).WithField("transaction_id", item.TransactionID).WithError(err).Error("error on publish transaction")
+		}
+	}
+
+	writeJSON(w, http.StatusCreated, toBatch(batch, items))
+	return nil
+}
+
+func (h *TransactionHandler) GetBatch(w http.ResponseWriter, r *http.Request) error {
+	batch, items, err := h.BatchController.Get(r.Context(), Param(r, "id"))
+
+	if err != nil {
+		return withStatus(err, http.StatusNotFound)
+	}
+
+	writeJSON(w, http.StatusOK, toBatch(batch, items))
+	return nil
+}
+
+func writeTransaction(w http.ResponseWriter, transaction *entity.Transaction, err error) error {
+	if err != nil {
+		return withStatus(err, http.StatusNotFound)
+	}
+
+	writeJSON(w, http.StatusOK, toTransaction(transaction))
+	return nil
+}
+
+func writeTransactionList(w http.ResponseWriter, transactions []*entity.Transaction, total int, err error) error {
+	if err != nil {
+		return withStatus(err, http.StatusInternalServerError)
+	}
+
+	writeJSON(w, http.StatusOK, toTransactionList(transactions, total))
+	return nil
+}
+
+func decode(r *http.Request, in interface{}) error {
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+
+	if err := decoder.Decode(in); err != nil {
+		return entity.InvalidArgument("request body is not valid json", map[string]string{"body": err.Error()})
+	}
+
+	return nil
+}
+
+func pagination(r *http.Request) (int, int, string, error) {
+	query := r.URL.Query()
+	fields := make(map[string]string)
+
+	page, err := intQuery(query.Get("page"), defaultPage)
+
+	if err != nil {
+		fields["page"] = "must be an integer"
+	}
+
+	limit, err := intQuery(query.Get("limit"), defaultLimit)
+
+	if err != nil {
+		fields["limit"] = "must be an integer"
+	}
+
+	if len(fields) > 0 {
+		return 0, 0, "", entity.InvalidArgument("invalid pagination", fields)
+	}
+
+	sort := query.Get("sort")
+
+	if sort == "" {
+		sort = defaultSort
+	}
+
+	return page, limit, sort, nil
+}
+
+func intQuery(value string, fallback int) (int, error) {
+	if value == "" {
+		return fallback, nil
+	}
+
+	return strconv.Atoi(value)
+}
+
+func accountDirection(r *http.Request) (string, error) {
+	switch direction := r.URL.Query().Get("direction"); direction {
+	case "", directionFrom:
+		return directionFrom, nil
+	case directionTo:
+		return directionTo, nil
+	default:
+		return "", entity.InvalidArgument("invalid direction", map[string]string{"direction": "must be one of: from, to"})
+	}
+}
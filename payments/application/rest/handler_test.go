@@ -0,0 +1,208 @@
+package rest_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/EdlanioJ/kbu/payments/application/kafka"
+	"github.com/EdlanioJ/kbu/payments/application/ratelimit"
+	"github.com/EdlanioJ/kbu/payments/application/rest"
+	"github.com/EdlanioJ/kbu/payments/domain/entity"
+	"github.com/EdlanioJ/kbu/payments/presentation/controller"
+	"github.com/EdlanioJ/kbu/payments/presentation/controller/mock"
+	uuid "github.com/satori/go.uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestHandler(useCase *mock.MockTransactionUseCase, limiter *ratelimit.Limiter) http.Handler {
+	handler := rest.NewTransactionHandler(
+		controller.NewTransaction(useCase),
+		kafka.NewTransactionPublisher(kafka.NewMemoryBroker(1)),
+		nil,
+		nil,
+	)
+
+	return rest.NewHandler(handler, nil, limiter)
+}
+
+func serve(handler http.Handler, method, target, body string) *httptest.ResponseRecorder {
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(method, target, strings.NewReader(body)))
+
+	return recorder
+}
+
+func errorBody(t *testing.T, recorder *httptest.ResponseRecorder) *rest.ErrorDetail {
+	body := &rest.ErrorBody{}
+	require.Nil(t, json.Unmarshal(recorder.Body.Bytes(), body))
+	require.NotNil(t, body.Error)
+
+	return body.Error
+}
+
+func TestTransactionHandler(t *testing.T) {
+	t.Parallel()
+
+	t.Run("should register a transaction", func(t *testing.T) {
+		is := require.New(t)
+		useCase := mock.NewMockTransactionUseCase()
+
+		accountFrom := uuid.NewV4().String()
+		accountTo := uuid.NewV4().String()
+		externalID := uuid.NewV4().String()
+
+		transaction := &entity.Transaction{
+			AccountFromID: accountFrom,
+			AccountToID:   accountTo,
+			ExternalID:    externalID,
+			Type:          entity.TransactionToUser,
+			Currency:      "AOA",
+			Amount:        30,
+			Status:        entity.TransactionPending,
+		}
+		transaction.ID = uuid.NewV4().String()
+		transaction.CreatedAt = time.Now()
+
+		useCase.On("Register", accountFrom, accountTo, externalID, entity.TransactionToUser, "AOA", 30.0).Return(transaction, nil)
+
+		body := `{"account_from":"` + accountFrom + `","account_to":"` + accountTo + `","external_id":"` + externalID + `","type":"to_user","currency":"AOA","amount":30}`
+		recorder := serve(newTestHandler(useCase, nil), http.MethodPost, "/transactions", body)
+
+		is.Equal(http.StatusCreated, recorder.Code)
+		is.NotEmpty(recorder.Header().Get(rest.RequestIDHeader))
+
+		response := &rest.Transaction{}
+		is.Nil(json.Unmarshal(recorder.Body.Bytes(), response))
+		is.Equal(transaction.ID, response.ID)
+		is.Equal(accountFrom, response.AccountFrom)
+	})
+
+	t.Run("should return a json error on malformed body", func(t *testing.T) {
+		is := require.New(t)
+
+		recorder := serve(newTestHandler(mock.NewMockTransactionUseCase(), nil), http.MethodPost, "/transactions", `{"amount":`)
+
+		is.Equal(http.StatusBadRequest, recorder.Code)
+		is.Equal("application/json", recorder.Header().Get("Content-Type"))
+
+		detail := errorBody(t, recorder)
+		is.Equal(entity.ErrorInvalidArgument, detail.Reason)
+		is.Equal(recorder.Header().Get(rest.RequestIDHeader), detail.RequestID)
+	})
+
+	t.Run("should map domain errors to http status", func(t *testing.T) {
+		is := require.New(t)
+		useCase := mock.NewMockTransactionUseCase()
+		id := uuid.NewV4().String()
+
+		useCase.On("Find", id).Return(nil, entity.NotFound("payment", id))
+
+		recorder := serve(newTestHandler(useCase, nil), http.MethodGet, "/transactions/"+id, "")
+
+		is.Equal(http.StatusNotFound, recorder.Code)
+
+		detail := errorBody(t, recorder)
+		is.Equal(entity.ErrorNotFound, detail.Reason)
+		is.Equal(id, detail.Metadata["id"])
+	})
+
+	t.Run("should use the controller message for unexpected errors", func(t *testing.T) {
+		is := require.New(t)
+		useCase := mock.NewMockTransactionUseCase()
+		id := uuid.NewV4().String()
+
+		useCase.On("Find", id).Return(nil, errors.New("connection refused"))
+
+		recorder := serve(newTestHandler(useCase, nil), http.MethodGet, "/transactions/"+id, "")
+
+		is.Equal(http.StatusNotFound, recorder.Code)
+		is.Equal("no payment was found", errorBody(t, recorder).Message)
+		is.NotContains(recorder.Body.String(), "connection refused")
+	})
+
+	t.Run("should list transactions of an account", func(t *testing.T) {
+		is := require.New(t)
+		useCase := mock.NewMockTransactionUseCase()
+		accountID := uuid.NewV4().String()
+
+		transaction := &entity.Transaction{AccountToID: accountID}
+		transaction.ID = uuid.NewV4().String()
+
+		useCase.On("FindAllByToAccountID", accountID, 2, 10, "created_at desc").Return([]*entity.Transaction{transaction}, 11, nil)
+
+		recorder := serve(newTestHandler(useCase, nil), http.MethodGet, "/accounts/"+accountID+"/transactions?direction=to&page=2&limit=10", "")
+
+		is.Equal(http.StatusOK, recorder.Code)
+
+		response := &rest.TransactionList{}
+		is.Nil(json.Unmarshal(recorder.Body.Bytes(), response))
+		is.Equal(11, response.Total)
+		is.Len(response.Transactions, 1)
+	})
+
+	t.Run("should reject invalid pagination", func(t *testing.T) {
+		is := require.New(t)
+
+		recorder := serve(newTestHandler(mock.NewMockTransactionUseCase(), nil), http.MethodGet, "/transactions?page=first", "")
+
+		is.Equal(http.StatusBadRequest, recorder.Code)
+		is.Contains(errorBody(t, recorder).Fields, "page")
+	})
+
+	t.Run("should answer unknown routes and methods with json", func(t *testing.T) {
+		is := require.New(t)
+		handler := newTestHandler(mock.NewMockTransactionUseCase(), nil)
+
+		recorder := serve(handler, http.MethodGet, "/unknown", "")
+		is.Equal(http.StatusNotFound, recorder.Code)
+
+		recorder = serve(handler, http.MethodDelete, "/transactions", "")
+		is.Equal(http.StatusMethodNotAllowed, recorder.Code)
+	})
+
+	t.Run("should rate limit registrations", func(t *testing.T) {
+		is := require.New(t)
+		limiter := ratelimit.NewLimiter(&ratelimit.Config{
+			Methods: map[string]*ratelimit.Limit{"register": {Rate: 0.1, Burst: 1}},
+		}, ratelimit.NewMemoryStore())
+		handler := newTestHandler(mock.NewMockTransactionUseCase(), limiter)
+
+		recorder := serve(handler, http.MethodPost, "/transactions", `{}`)
+		is.NotEqual(http.StatusTooManyRequests, recorder.Code)
+
+		recorder = serve(handler, http.MethodPost, "/transactions", `{}`)
+		is.Equal(http.StatusTooManyRequests, recorder.Code)
+		is.Equal("10", recorder.Header().Get("Retry-After"))
+	})
+}
+
+func TestOpenAPI(t *testing.T) {
+	t.Parallel()
+
+	is := require.New(t)
+
+	recorder := serve(newTestHandler(mock.NewMockTransactionUseCase(), nil), http.MethodGet, "/openapi.json", "")
+
+	is.Equal(http.StatusOK, recorder.Code)
+
+	document := struct {
+		OpenAPI    string                                       `json:"openapi"`
+		Paths      map[string]map[string]map[string]interface{} `json:"paths"`
+		Components struct {
+			Schemas map[string]interface{} `json:"schemas"`
+		} `json:"components"`
+	}{}
+
+	is.Nil(json.Unmarshal(recorder.Body.Bytes(), &document))
+	is.Equal("3.0.3", document.OpenAPI)
+	is.Equal("register", document.Paths["/transactions"]["post"]["operationId"])
+	is.Contains(document.Paths, "/accounts/{account}/transactions")
+	is.Contains(document.Components.Schemas, "RegisterRequest")
+	is.Contains(document.Components.Schemas, "ErrorBody")
+	is.NotContains(document.Paths, "/openapi.json")
+}
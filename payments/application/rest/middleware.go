@@ -0,0 +1,257 @@
+package rest
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"runtime/debug"
+	"time"
+
+	grpc_handler "github.com/EdlanioJ/kbu/payments/application/grpc"
+	"github.com/EdlanioJ/kbu/payments/application/ratelimit"
+	"github.com/EdlanioJ/kbu/payments/domain/entity"
+	"github.com/EdlanioJ/kbu/payments/presentation/controller"
+	"github.com/grpc-ecosystem/go-grpc-middleware/logging/logrus/ctxlogrus"
+	uuid "github.com/satori/go.uuid"
+	log "github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc/metadata"
+)
+
+const (
+	RequestIDHeader = "X-Request-Id"
+	maxRequestID    = 128
+)
+
+type limiterKey struct{}
+
+var publicPaths = map[string]bool{
+	"/openapi.json": true,
+}
+
+type Middleware func(http.Handler) http.Handler
+
+func chain(handler http.Handler, middlewares ...Middleware) http.Handler {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		handler = middlewares[i](handler)
+	}
+
+	return handler
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *statusRecorder) Write(body []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+
+	return r.ResponseWriter.Write(body)
+}
+
+func (r *statusRecorder) Flush() {
+	if flusher, ok := r.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func RequestID(ctx context.Context) string {
+	if id, ok := logger(ctx).Data["request_id"].(string); ok {
+		return id
+	}
+
+	return ""
+}
+
+func logger(ctx context.Context) *log.Entry {
+	return ctxlogrus.Extract(ctx)
+}
+
+// requestLogger tags every request with a request id, taken from the
+// X-Request-Id header when the caller sends one, and writes an access log.
+func requestLogger(base *log.Entry, accessLog bool) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			id := r.Header.Get(RequestIDHeader)
+
+			if id == "" || len(id) > maxRequestID {
+				id = uuid.NewV4().String()
+			}
+
+			w.Header().Set(RequestIDHeader, id)
+
+			entry := base.WithField("request_id", id)
+
+			if spanContext := trace.SpanContextFromContext(r.Context()); spanContext.IsValid() {
+				entry = entry.WithField("trace_id", spanContext.TraceID().String())
+			}
+
+			ctx := ctxlogrus.ToContext(entity.WithRequestID(r.Context(), id), entry)
+			recorder := &statusRecorder{ResponseWriter: w}
+			start := time.Now()
+
+			next.ServeHTTP(recorder, r.WithContext(ctx))
+
+			if !accessLog {
+				return
+			}
+
+			logger(ctx).WithFields(log.Fields{
+				"http.method":      r.Method,
+				"http.path":        r.URL.Path,
+				"http.status":      recorder.status,
+				"http.time_ms":     float32(time.Since(start).Nanoseconds()/1000) / 1000,
+				"http.remote_addr": r.RemoteAddr,
+			}).Info("finished http call")
+		})
+	}
+}
+
+func recoverer(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			if p := recover(); p != nil {
+				logger(r.Context()).
+					WithField("panic", p).
+					WithField("stack", string(debug.Stack())).
+					Error("recovered from panic")
+
+				writeJSON(w, http.StatusInternalServerError, &ErrorBody{Error: &ErrorDetail{
+					Status:    http.StatusInternalServerError,
+					Reason:    errorInternal,
+					Message:   "internal server error",
+					RequestID: RequestID(r.Context()),
+				}})
+			}
+		}()
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// authenticate reuses the gRPC authenticator by exposing the credential
+// headers as incoming metadata, so both transports accept the same tokens
+// and api keys.
+func authenticate(authenticator *grpc_handler.Authenticator) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if publicPaths[r.URL.Path] {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			md := metadata.MD{}
+
+			for _, header := range []string{"Authorization", "X-Api-Key"} {
+				if value := r.Header.Get(header); value != "" {
+					md.Set(header, value)
+				}
+			}
+
+			principal, err := authenticator.Authenticate(metadata.NewIncomingContext(r.Context(), md))
+
+			if err != nil {
+				writeError(w, r, err)
+				return
+			}
+
+			ctxlogrus.AddFields(r.Context(), log.Fields{"auth.subject": principal.Subject})
+
+			next.ServeHTTP(w, r.WithContext(controller.WithPrincipal(r.Context(), principal)))
+		})
+	}
+}
+
+// anonymous stands in for authenticate while authentication is off, every
+// request is made by the anonymous principal of the default tenant.
+func anonymous(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r.WithContext(controller.WithPrincipal(r.Context(), entity.AnonymousPrincipal())))
+	})
+}
+
+func rateLimit(limiter *ratelimit.Limiter, routes *Router) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			err := limiter.Allow(r.Context(), callerIdentity(r), operation(routes, r))
+
+			if err = limitError(r.Context(), err); err != nil {
+				writeError(w, r, err)
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), limiterKey{}, limiter)))
+		})
+	}
+}
+
+func allowAccounts(ctx context.Context, accountIDs ...string) error {
+	limiter, ok := ctx.Value(limiterKey{}).(*ratelimit.Limiter)
+
+	if !ok {
+		return nil
+	}
+
+	return limitError(ctx, limiter.AllowAccounts(ctx, accountIDs...))
+}
+
+// limitError lets requests through when the limiter store fails, like the
+// gRPC interceptor does.
+func limitError(ctx context.Context, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	if _, ok := err.(*ratelimit.ExceededError); ok {
+		return err
+	}
+
+	logger(ctx).WithError(err).Warn("rate limit store is unavailable")
+
+	return nil
+}
+
+// operation ids match the gRPC method names they mirror, so method limits in
+// the config file apply to both transports.
+func operation(routes *Router, r *http.Request) string {
+	if route := findRoute(routes, r); route != nil {
+		return "/rest/" + route.OperationID
+	}
+
+	return r.Method + " " + r.URL.Path
+}
+
+func findRoute(routes *Router, r *http.Request) *Route {
+	segments := split(r.URL.Path)
+
+	for _, route := range routes.Routes {
+		if _, ok := match(split(route.Path), segments); ok && route.Method == r.Method {
+			return route
+		}
+	}
+
+	return nil
+}
+
+func callerIdentity(r *http.Request) string {
+	if principal, ok := controller.PrincipalFromContext(r.Context()); ok && principal.Subject != "" {
+		return principal.Subject
+	}
+
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		return host
+	}
+
+	return r.RemoteAddr
+}
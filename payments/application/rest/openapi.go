@@ -0,0 +1,195 @@
+package rest
+
+import (
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const openAPIVersion = "3.0.3"
+
+type Schema map[string]interface{}
+
+// OpenAPI builds an OpenAPI 3 document from the registered routes. Schemas
+// are derived from the request and response types through their json tags,
+// so the document cannot drift from the handlers.
+func OpenAPI(router *Router, title, version string) map[string]interface{} {
+	schemas := map[string]interface{}{}
+	paths := map[string]map[string]interface{}{}
+
+	errorRef := schemaOf(reflect.TypeOf(&ErrorBody{}), schemas)
+
+	for _, route := range router.Routes {
+		operation := map[string]interface{}{
+			"operationId": route.OperationID,
+			"summary":     route.Summary,
+			"tags":        []string{route.Tag},
+		}
+
+		var parameters []interface{}
+
+		for _, name := range pathParams(route.Path) {
+			parameters = append(parameters, map[string]interface{}{
+				"name":     name,
+				"in":       "path",
+				"required": true,
+				"schema":   Schema{"type": "string"},
+			})
+		}
+
+		for _, query := range route.Query {
+			parameter := map[string]interface{}{
+				"name":   query.Name,
+				"in":     "query",
+				"schema": Schema{"type": query.Type},
+			}
+
+			if query.Description != "" {
+				parameter["description"] = query.Description
+			}
+
+			parameters = append(parameters, parameter)
+		}
+
+		if len(parameters) > 0 {
+			operation["parameters"] = parameters
+		}
+
+		if route.Body != nil {
+			operation["requestBody"] = map[string]interface{}{
+				"required": true,
+				"content": map[string]interface{}{
+					"application/json": map[string]interface{}{"schema": schemaOf(reflect.TypeOf(route.Body), schemas)},
+				},
+			}
+		}
+
+		contentType := "application/json"
+
+		if route.Stream {
+			contentType = "application/x-ndjson"
+		}
+
+		responses := map[string]interface{}{
+			"default": map[string]interface{}{
+				"description": "error",
+				"content":     map[string]interface{}{"application/json": map[string]interface{}{"schema": errorRef}},
+			},
+		}
+
+		if route.Response != nil {
+			responses[strconv.Itoa(route.Status)] = map[string]interface{}{
+				"description": http.StatusText(route.Status),
+				"content":     map[string]interface{}{contentType: map[string]interface{}{"schema": schemaOf(reflect.TypeOf(route.Response), schemas)}},
+			}
+		}
+
+		operation["responses"] = responses
+
+		if paths[route.Path] == nil {
+			paths[route.Path] = map[string]interface{}{}
+		}
+
+		paths[route.Path][strings.ToLower(route.Method)] = operation
+	}
+
+	return map[string]interface{}{
+		"openapi": openAPIVersion,
+		"info": map[string]interface{}{
+			"title":   title,
+			"version": version,
+		},
+		"paths": paths,
+		"components": map[string]interface{}{
+			"schemas": schemas,
+			"securitySchemes": map[string]interface{}{
+				"bearer": map[string]interface{}{"type": "http", "scheme": "bearer", "bearerFormat": "JWT"},
+				"apiKey": map[string]interface{}{"type": "apiKey", "in": "header", "name": "X-Api-Key"},
+			},
+		},
+		"security": []interface{}{
+			map[string]interface{}{"bearer": []string{}},
+			map[string]interface{}{"apiKey": []string{}},
+		},
+	}
+}
+
+func schemaOf(t reflect.Type, schemas map[string]interface{}) Schema {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if t == reflect.TypeOf(time.Time{}) {
+		return Schema{"type": "string", "format": "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return Schema{"type": "string"}
+	case reflect.Bool:
+		return Schema{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return Schema{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return Schema{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return Schema{"type": "array", "items": schemaOf(t.Elem(), schemas)}
+	case reflect.Map:
+		return Schema{"type": "object", "additionalProperties": schemaOf(t.Elem(), schemas)}
+	case reflect.Struct:
+		if _, ok := schemas[t.Name()]; !ok {
+			schemas[t.Name()] = Schema{}
+			schemas[t.Name()] = structSchema(t, schemas)
+		}
+
+		return Schema{"$ref": "#/components/schemas/" + t.Name()}
+	}
+
+	return Schema{}
+}
+
+func structSchema(t reflect.Type, schemas map[string]interface{}) Schema {
+	properties := map[string]interface{}{}
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+
+		if name == "-" || field.PkgPath != "" {
+			continue
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+
+		property := schemaOf(field.Type, schemas)
+
+		if enum := field.Tag.Get("enum"); enum != "" {
+			property["enum"] = strings.Split(enum, ",")
+		}
+
+		if description := field.Tag.Get("description"); description != "" {
+			property["description"] = description
+		}
+
+		properties[name] = property
+	}
+
+	return Schema{"type": "object", "properties": properties}
+}
+
+func pathParams(path string) []string {
+	var names []string
+
+	for _, segment := range split(path) {
+		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+			names = append(names, segment[1:len(segment)-1])
+		}
+	}
+
+	return names
+}
@@ -0,0 +1,115 @@
+package rest
+
+import (
+	"context"
+	"net/http"
+	"strings"
+)
+
+type paramsKey struct{}
+
+type HandlerFunc func(w http.ResponseWriter, r *http.Request) error
+
+type Route struct {
+	Method      string
+	Path        string
+	OperationID string
+	Summary     string
+	Tag         string
+	Query       []*QueryParam
+	Body        interface{}
+	Response    interface{}
+	Status      int
+	Stream      bool
+	Handler     HandlerFunc
+}
+
+type QueryParam struct {
+	Name        string
+	Description string
+	Type        string
+}
+
+type Router struct {
+	Routes []*Route
+}
+
+func NewRouter() *Router {
+	return &Router{}
+}
+
+func (rt *Router) Add(route *Route) {
+	if route.Status == 0 {
+		route.Status = http.StatusOK
+	}
+
+	rt.Routes = append(rt.Routes, route)
+}
+
+func (rt *Router) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	segments := split(r.URL.Path)
+	allowed := false
+
+	for _, route := range rt.Routes {
+		params, ok := match(split(route.Path), segments)
+
+		if !ok {
+			continue
+		}
+
+		if route.Method != r.Method {
+			allowed = true
+			continue
+		}
+
+		ctx := context.WithValue(r.Context(), paramsKey{}, params)
+
+		if err := route.Handler(w, r.WithContext(ctx)); err != nil {
+			writeError(w, r, err)
+		}
+
+		return
+	}
+
+	if allowed {
+		writeError(w, r, errMethodNotAllowed)
+		return
+	}
+
+	writeError(w, r, errRouteNotFound)
+}
+
+func Param(r *http.Request, name string) string {
+	params, _ := r.Context().Value(paramsKey{}).(map[string]string)
+
+	return params[name]
+}
+
+func match(pattern, segments []string) (map[string]string, bool) {
+	if len(pattern) != len(segments) {
+		return nil, false
+	}
+
+	params := make(map[string]string)
+
+	for i, segment := range pattern {
+		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+			if segments[i] == "" {
+				return nil, false
+			}
+
+			params[segment[1:len(segment)-1]] = segments[i]
+			continue
+		}
+
+		if segment != segments[i] {
+			return nil, false
+		}
+	}
+
+	return params, true
+}
+
+func split(path string) []string {
+	return strings.Split(strings.Trim(path, "/"), "/")
+}
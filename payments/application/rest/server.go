@@ -0,0 +1,156 @@
+package rest
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/EdlanioJ/kbu/payments/application/factory"
+	grpc_handler "github.com/EdlanioJ/kbu/payments/application/grpc"
+	"github.com/EdlanioJ/kbu/payments/application/kafka"
+	"github.com/EdlanioJ/kbu/payments/application/ratelimit"
+	"github.com/jinzhu/gorm"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	apiTitle   = "KBU Payments"
+	apiVersion = "1.0.0"
+)
+
+type Server struct {
+	HTTP         *http.Server
+	Certificates *grpc_handler.CertificateReloader
+	Port         int
+	listening    chan struct{}
+}
+
+type ServerConfig struct {
+	Port      int
+	TLS       *grpc_handler.TLSOptions
+	Auth      *grpc_handler.AuthOptions
+	RateLimit *ratelimit.Limiter
+}
+
+func NewRestServer(database *gorm.DB, broker kafka.Broker, config *ServerConfig) (*Server, error) {
+	handler := NewTransactionHandler(
+		factory.TransactionControllerFactory(database),
+		kafka.NewTransactionPublisher(broker),
+		factory.SagaControllerFactory(database),
+		factory.BatchControllerFactory(database),
+	)
+
+	var authenticator *grpc_handler.Authenticator
+
+	if config.Auth.Enabled() {
+		var err error
+
+		authenticator, err = grpc_handler.NewAuthenticator(config.Auth)
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	server := &Server{
+		HTTP: &http.Server{
+			Addr:              fmt.Sprintf("0.0.0.0:%d", config.Port),
+			Handler:           NewHandler(handler, authenticator, config.RateLimit),
+			ReadHeaderTimeout: 10 * time.Second,
+		},
+		Port:      config.Port,
+		listening: make(chan struct{}),
+	}
+
+	if config.TLS.Enabled() {
+		reloader, err := grpc_handler.NewCertificateReloader(config.TLS)
+
+		if err != nil {
+			return nil, err
+		}
+
+		server.Certificates = reloader
+		server.HTTP.TLSConfig = reloader.TLSConfig()
+	}
+
+	return server, nil
+}
+
+func NewHandler(handler *TransactionHandler, authenticator *grpc_handler.Authenticator, limiter *ratelimit.Limiter) http.Handler {
+	router := NewRouter()
+	handler.Routes(router)
+
+	document := OpenAPI(router, apiTitle, apiVersion)
+
+	router.Add(&Route{
+		Method: http.MethodGet,
+		Path:   "/openapi.json",
+		Handler: func(w http.ResponseWriter, r *http.Request) error {
+			writeJSON(w, http.StatusOK, document)
+			return nil
+		},
+	})
+
+	logger := log.New()
+	logger.SetFormatter(&log.JSONFormatter{})
+
+	middlewares := []Middleware{
+		traceRequest(router),
+		requestLogger(log.NewEntry(logger), os.Getenv("HTTP_ACCESS_LOG") != "false"),
+		recoverer,
+	}
+
+	if authenticator != nil {
+		middlewares = append(middlewares, authenticate(authenticator))
+	} else {
+		middlewares = append(middlewares, anonymous)
+	}
+
+	if limiter != nil {
+		middlewares = append(middlewares, rateLimit(limiter, router))
+	}
+
+	return chain(router, middlewares...)
+}
+
+func (s *Server) Serve(ctx context.Context) error {
+	listener, err := net.Listen("tcp", s.HTTP.Addr)
+
+	if err != nil {
+		return err
+	}
+
+	if s.Certificates != nil {
+		go s.Certificates.Watch(ctx)
+		listener = tls.NewListener(listener, s.HTTP.TLSConfig)
+	}
+
+	log.WithField("tls", s.Certificates != nil).Infof("http server has been started on port %d", s.Port)
+	close(s.listening)
+
+	err = s.HTTP.Serve(listener)
+
+	if err == http.ErrServerClosed {
+		return nil
+	}
+
+	return err
+}
+
+// Ready blocks until the server is listening.
+func (s *Server) Ready(ctx context.Context) error {
+	select {
+	case <-s.listening:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (s *Server) Stop(ctx context.Context) error {
+	return s.HTTP.Shutdown(ctx)
+}
@@ -0,0 +1,45 @@
+package rest
+
+import (
+	"net/http"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("github.com/EdlanioJ/kbu/payments/application/rest")
+
+// traceRequest starts a server span named after the matched route, continuing
+// the trace of the caller when it sends a traceparent header.
+func traceRequest(routes *Router) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+
+			path := r.URL.Path
+
+			if route := findRoute(routes, r); route != nil {
+				path = route.Path
+			}
+
+			ctx, span := tracer.Start(ctx, r.Method+" "+path,
+				trace.WithSpanKind(trace.SpanKindServer),
+				trace.WithAttributes(semconv.HTTPServerAttributesFromHTTPRequest("", path, r)...),
+			)
+			defer span.End()
+
+			recorder := &statusRecorder{ResponseWriter: w}
+
+			next.ServeHTTP(recorder, r.WithContext(ctx))
+
+			if recorder.status == 0 {
+				recorder.status = http.StatusOK
+			}
+
+			span.SetAttributes(semconv.HTTPAttributesFromHTTPStatusCode(recorder.status)...)
+			span.SetStatus(semconv.SpanStatusFromHTTPStatusCode(recorder.status))
+		})
+	}
+}
@@ -0,0 +1,46 @@
+package saga
+
+import (
+	"context"
+	"time"
+
+	"github.com/EdlanioJ/kbu/payments/presentation/controller"
+	log "github.com/sirupsen/logrus"
+)
+
+type Recoverer struct {
+	SagaController *controller.Saga
+	Interval       time.Duration
+	BatchSize      int
+}
+
+func NewRecoverer(sagaController *controller.Saga) *Recoverer {
+	return &Recoverer{
+		SagaController: sagaController,
+		Interval:       15 * time.Second,
+		BatchSize:      20,
+	}
+}
+
+func (r *Recoverer) Run(ctx context.Context) {
+	log.Info("saga recoverer has been started")
+
+	ctx = controller.WithSystemPrincipal(ctx)
+
+	ticker := time.NewTicker(r.Interval)
+	defer ticker.Stop()
+
+	for {
+		recovered, err := r.SagaController.Recover(ctx, r.BatchSize)
+
+		if err == nil && recovered == r.BatchSize && ctx.Err() == nil {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strconv"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+)
+
+const (
+	ExporterNone = "none"
+	ExporterOTLP = "otlp"
+
+	ServiceName = "payments"
+)
+
+var errUnknownExporter = errors.New("unknown tracing exporter")
+
+type Config struct {
+	Exporter    string
+	SampleRatio float64
+}
+
+func ConfigFromEnv() *Config {
+	config := &Config{
+		Exporter:    os.Getenv("TRACING_EXPORTER"),
+		SampleRatio: 1,
+	}
+
+	if config.Exporter == "" {
+		config.Exporter = ExporterNone
+	}
+
+	if ratio, err := strconv.ParseFloat(os.Getenv("TRACING_SAMPLE_RATIO"), 64); err == nil && ratio >= 0 && ratio <= 1 {
+		config.SampleRatio = ratio
+	}
+
+	return config
+}
+
+type Provider struct {
+	TracerProvider *sdktrace.TracerProvider
+}
+
+// NewProvider installs the global tracer provider and the W3C trace context
+// propagator. With the none exporter spans are still created, so trace ids
+// reach logs and published events, but nothing is exported.
+func NewProvider(ctx context.Context, config *Config) (*Provider, error) {
+	options := []sdktrace.TracerProviderOption{
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(config.SampleRatio))),
+	}
+
+	switch config.Exporter {
+	case ExporterNone:
+	case ExporterOTLP:
+		exporter, err := otlptracehttp.New(ctx)
+
+		if err != nil {
+			return nil, err
+		}
+
+		options = append(options, sdktrace.WithBatcher(exporter))
+	default:
+		return nil, errUnknownExporter
+	}
+
+	res, err := resource.New(ctx,
+		resource.WithAttributes(semconv.ServiceNameKey.String(ServiceName)),
+		resource.WithFromEnv(),
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	options = append(options, sdktrace.WithResource(res))
+
+	provider := &Provider{
+		TracerProvider: sdktrace.NewTracerProvider(options...),
+	}
+
+	otel.SetTracerProvider(provider.TracerProvider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	return provider, nil
+}
+
+func (p *Provider) Stop(ctx context.Context) error {
+	return p.TracerProvider.Shutdown(ctx)
+}
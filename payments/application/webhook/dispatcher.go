@@ -0,0 +1,48 @@
+package webhook
+
+import (
+	"context"
+	"time"
+
+	"github.com/EdlanioJ/kbu/payments/application/factory"
+	"github.com/EdlanioJ/kbu/payments/presentation/controller"
+	"github.com/jinzhu/gorm"
+	log "github.com/sirupsen/logrus"
+)
+
+type Dispatcher struct {
+	WebhookController *controller.Webhook
+	Interval          time.Duration
+	BatchSize         int
+}
+
+func NewDispatcher(database *gorm.DB) *Dispatcher {
+	return &Dispatcher{
+		WebhookController: factory.WebhookControllerFactory(database),
+		Interval:          5 * time.Second,
+		BatchSize:         50,
+	}
+}
+
+func (d *Dispatcher) Run(ctx context.Context) {
+	log.Info("webhook dispatcher has been started")
+
+	ctx = controller.WithSystemPrincipal(ctx)
+
+	ticker := time.NewTicker(d.Interval)
+	defer ticker.Stop()
+
+	for {
+		delivered, err := d.WebhookController.DeliverDue(ctx, d.BatchSize)
+
+		if err == nil && delivered == d.BatchSize && ctx.Err() == nil {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package client
+
+import (
+	"context"
+	"math/rand"
+	"time"
+
+	"github.com/EdlanioJ/kbu/payments/application/grpc/pb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	authorizationHeader  = "authorization"
+	apiKeyHeader         = "x-api-key"
+	idempotencyKeyHeader = "idempotency-key"
+)
+
+type Options struct {
+	// Timeout bounds every attempt unless the caller context ends sooner.
+	Timeout time.Duration
+	// MaxRetries is how many times a call failing with Unavailable is retried.
+	MaxRetries     int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	Token          string
+	APIKey         string
+}
+
+func DefaultOptions() *Options {
+	return &Options{
+		Timeout:        10 * time.Second,
+		MaxRetries:     3,
+		InitialBackoff: 100 * time.Millisecond,
+		MaxBackoff:     2 * time.Second,
+	}
+}
+
+type Client struct {
+	Payments pb.PaymentServiceClient
+	Options  *Options
+	conn     *grpc.ClientConn
+}
+
+func Dial(target string, options *Options, dialOptions ...grpc.DialOption) (*Client, error) {
+	conn, err := grpc.Dial(target, dialOptions...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	c := New(pb.NewPaymentServiceClient(conn), options)
+	c.conn = conn
+
+	return c, nil
+}
+
+func New(payments pb.PaymentServiceClient, options *Options) *Client {
+	if options == nil {
+		options = DefaultOptions()
+	}
+
+	return &Client{
+		Payments: payments,
+		Options:  options,
+	}
+}
+
+func (c *Client) Close() error {
+	if c.conn == nil {
+		return nil
+	}
+
+	return c.conn.Close()
+}
+
+// call runs fn with the configured deadline and credentials, retrying with
+// exponential backoff and jitter while the server is Unavailable.
+func (c *Client) call(ctx context.Context, md metadata.MD, fn func(ctx context.Context) error) error {
+	backoff := c.Options.InitialBackoff
+
+	for attempt := 0; ; attempt++ {
+		err := c.attempt(ctx, md, fn)
+
+		if err == nil {
+			return nil
+		}
+
+		if status.Code(err) != codes.Unavailable || attempt >= c.Options.MaxRetries {
+			return decodeError(err)
+		}
+
+		wait := backoff/2 + time.Duration(rand.Int63n(int64(backoff/2)+1))
+
+		if retryAfter := retryDelay(err); retryAfter > wait {
+			wait = retryAfter
+		}
+
+		select {
+		case <-ctx.Done():
+			return decodeError(status.FromContextError(ctx.Err()).Err())
+		case <-time.After(wait):
+		}
+
+		backoff *= 2
+
+		if backoff > c.Options.MaxBackoff {
+			backoff = c.Options.MaxBackoff
+		}
+	}
+}
+
+func (c *Client) attempt(ctx context.Context, md metadata.MD, fn func(ctx context.Context) error) error {
+	if c.Options.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.Options.Timeout)
+		defer cancel()
+	}
+
+	return fn(c.outgoing(ctx, md))
+}
+
+func (c *Client) outgoing(ctx context.Context, md metadata.MD) context.Context {
+	md = md.Copy()
+
+	if c.Options.Token != "" {
+		md.Set(authorizationHeader, "Bearer "+c.Options.Token)
+	}
+
+	if c.Options.APIKey != "" {
+		md.Set(apiKeyHeader, c.Options.APIKey)
+	}
+
+	if len(md) == 0 {
+		return ctx
+	}
+
+	if outgoing, ok := metadata.FromOutgoingContext(ctx); ok {
+		md = metadata.Join(outgoing, md)
+	}
+
+	return metadata.NewOutgoingContext(ctx, md)
+}
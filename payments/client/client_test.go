@@ -0,0 +1,347 @@
+package client_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	grpc_handler "github.com/EdlanioJ/kbu/payments/application/grpc"
+	"github.com/EdlanioJ/kbu/payments/application/grpc/pb"
+	"github.com/EdlanioJ/kbu/payments/client"
+	"github.com/EdlanioJ/kbu/payments/data/service"
+	"github.com/EdlanioJ/kbu/payments/domain/entity"
+	"github.com/EdlanioJ/kbu/payments/infra/db/gorm/migration"
+	"github.com/EdlanioJ/kbu/payments/infra/db/gorm/repository"
+	"github.com/EdlanioJ/kbu/payments/presentation/controller"
+	"github.com/jinzhu/gorm"
+	_ "github.com/mattn/go-sqlite3"
+	uuid "github.com/satori/go.uuid"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+type fakePaymentServer struct {
+	pb.UnimplementedPaymentServiceServer
+
+	mu           sync.Mutex
+	failures     int
+	keys         []string
+	authorized   []string
+	transactions []*pb.Transaction
+	delay        time.Duration
+}
+
+func (s *fakePaymentServer) Register(ctx context.Context, in *pb.RegisterRequest) (*pb.Response, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	s.keys = append(s.keys, md.Get("idempotency-key")...)
+	s.authorized = append(s.authorized, md.Get("authorization")...)
+
+	if s.failures > 0 {
+		s.failures--
+		return nil, status.Error(codes.Unavailable, "connection reset")
+	}
+
+	return &pb.Response{Transaction: &pb.Transaction{
+		ID:          uuid.NewV4().String(),
+		AccountFrom: in.AccountFrom,
+		AccountTo:   in.AccountTo,
+		Amount:      in.Amount,
+		Type:        in.Type.String(),
+		Status:      "pending",
+		CreatedAt:   time.Date(2021, 5, 1, 10, 0, 0, 0, time.UTC).String(),
+	}}, nil
+}
+
+func (s *fakePaymentServer) Get(ctx context.Context, in *pb.Request) (*pb.Response, error) {
+	if s.delay > 0 {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(s.delay):
+		}
+	}
+
+	st := status.New(codes.NotFound, "no payment was found")
+	st, _ = st.WithDetails(&errdetails.ErrorInfo{
+		Reason:   "NOT_FOUND",
+		Domain:   "payments.kbu",
+		Metadata: map[string]string{"resource": "payment", "id": in.ID},
+	})
+
+	return nil, st.Err()
+}
+
+func (s *fakePaymentServer) List(ctx context.Context, in *pb.PaginationRequest) (*pb.ListResponse, error) {
+	start := int((in.Page - 1) * in.Limit)
+
+	if start >= len(s.transactions) {
+		return nil, status.Error(codes.NotFound, "no payment was found")
+	}
+
+	end := start + int(in.Limit)
+
+	if end > len(s.transactions) {
+		end = len(s.transactions)
+	}
+
+	return &pb.ListResponse{
+		Transactions: s.transactions[start:end],
+		Total:        int32(len(s.transactions)),
+	}, nil
+}
+
+func newTestClient(t *testing.T, server *fakePaymentServer, options *client.Options) *client.Client {
+	grpcServer := grpc.NewServer()
+	pb.RegisterPaymentServiceServer(grpcServer, server)
+
+	return dial(t, grpcServer, options)
+}
+
+func dial(t *testing.T, grpcServer *grpc.Server, options *client.Options) *client.Client {
+	listener := bufconn.Listen(1024 * 1024)
+
+	go grpcServer.Serve(listener)
+	t.Cleanup(grpcServer.Stop)
+
+	c, err := client.Dial("bufnet", options,
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return listener.Dial() }),
+		grpc.WithInsecure(),
+	)
+	require.Nil(t, err)
+	t.Cleanup(func() { c.Close() })
+
+	return c
+}
+
+func testOptions() *client.Options {
+	options := client.DefaultOptions()
+	options.InitialBackoff = time.Millisecond
+	options.MaxBackoff = 5 * time.Millisecond
+
+	return options
+}
+
+func TestRegister(t *testing.T) {
+	t.Parallel()
+
+	t.Run("should retry unavailable with the same idempotency key", func(t *testing.T) {
+		is := require.New(t)
+		server := &fakePaymentServer{failures: 2}
+		options := testOptions()
+		options.Token = "token"
+		c := newTestClient(t, server, options)
+
+		transaction, err := c.Register(context.Background(), &client.RegisterRequest{
+			AccountFrom: uuid.NewV4().String(),
+			AccountTo:   uuid.NewV4().String(),
+			ExternalID:  uuid.NewV4().String(),
+			Type:        client.TypeToUser,
+			Currency:    "AOA",
+			Amount:      30,
+		})
+
+		is.Nil(err)
+		is.Equal(client.TypeToUser, transaction.Type)
+		is.Equal(2021, transaction.CreatedAt.Year())
+		is.Len(server.keys, 3)
+		is.NotEmpty(server.keys[0])
+		is.Equal(server.keys[0], server.keys[1])
+		is.Equal(server.keys[0], server.keys[2])
+		is.Equal("Bearer token", server.authorized[0])
+	})
+
+	t.Run("should give up after max retries", func(t *testing.T) {
+		is := require.New(t)
+		server := &fakePaymentServer{failures: 10}
+		c := newTestClient(t, server, testOptions())
+
+		_, err := c.Register(context.Background(), &client.RegisterRequest{IdempotencyKey: "key-1"})
+
+		is.Equal(codes.Unavailable, status.Code(err))
+		is.Len(server.keys, 4)
+		is.Equal("key-1", server.keys[3])
+	})
+}
+
+type countingProvider struct {
+	mu      sync.Mutex
+	charges int
+}
+
+func (p *countingProvider) Charge(transaction *entity.Transaction) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.charges++
+
+	return "ref-" + transaction.ID, nil
+}
+
+func (p *countingProvider) Refund(transaction *entity.Transaction) error {
+	return nil
+}
+
+// newServiceTestClient serves payments from a real handler over SQLite and
+// loses the response to the first call, as a dropped connection would.
+func newServiceTestClient(t *testing.T, db *gorm.DB, provider *countingProvider) *client.Client {
+	transactionRepo := repository.NewTransactionRepository(db)
+	accountRepo := repository.NewAccountRepository(db)
+	sagaService := service.NewSaga(repository.NewSagaRepository(db), transactionRepo, accountRepo, repository.NewUnitOfWork(db), provider)
+
+	handler := grpc_handler.NewTransactionGrpcHandler(
+		controller.NewTransaction(service.NewTransaction(transactionRepo, accountRepo)),
+		nil,
+		controller.NewSaga(sagaService),
+		nil,
+		nil,
+	)
+
+	var mu sync.Mutex
+	dropped := false
+
+	grpcServer := grpc.NewServer(grpc.UnaryInterceptor(func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, next grpc.UnaryHandler) (interface{}, error) {
+		res, err := next(controller.WithPrincipal(ctx, entity.AnonymousPrincipal()), req)
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		if !dropped {
+			dropped = true
+			return nil, status.Error(codes.Unavailable, "connection reset")
+		}
+
+		return res, err
+	}))
+	pb.RegisterPaymentServiceServer(grpcServer, handler)
+
+	return dial(t, grpcServer, testOptions())
+}
+
+func TestRegisterServicePayment(t *testing.T) {
+	t.Parallel()
+
+	t.Run("should charge a retried service payment once", func(t *testing.T) {
+		is := require.New(t)
+
+		db, err := gorm.Open("sqlite3", ":memory:")
+		is.Nil(err)
+
+		db.LogMode(false)
+		db.DB().SetMaxOpenConns(1)
+		t.Cleanup(func() { db.Close() })
+
+		migrator, err := migration.NewMigrator(db)
+		is.Nil(err)
+
+		_, err = migrator.Up(0)
+		is.Nil(err)
+
+		ctx := context.Background()
+		accountFrom, _ := entity.NewAccount(1000)
+		accountTo, _ := entity.NewAccount(0)
+		is.Nil(repository.NewAccountRepository(db).Save(ctx, accountFrom))
+		is.Nil(repository.NewAccountRepository(db).Save(ctx, accountTo))
+
+		provider := &countingProvider{}
+		c := newServiceTestClient(t, db, provider)
+
+		transaction, err := c.Register(ctx, &client.RegisterRequest{
+			AccountFrom: accountFrom.ID,
+			AccountTo:   accountTo.ID,
+			ExternalID:  uuid.NewV4().String(),
+			Type:        client.TypeToService,
+			Currency:    "AOA",
+			Amount:      300,
+		})
+
+		is.Nil(err)
+		is.Equal(client.StatusCompleted, transaction.Status)
+		is.Equal(1, provider.charges)
+
+		var sagas, transactions int
+		is.Nil(db.Model(&entity.Saga{}).Count(&sagas).Error)
+		is.Nil(db.Model(&entity.Transaction{}).Count(&transactions).Error)
+		is.Equal(1, sagas)
+		is.Equal(1, transactions)
+
+		account, err := repository.NewAccountRepository(db).Find(ctx, accountFrom.ID)
+		is.Nil(err)
+		is.Equal(700.0, account.Balance)
+	})
+}
+
+func TestErrors(t *testing.T) {
+	t.Parallel()
+
+	t.Run("should decode status details into typed errors", func(t *testing.T) {
+		is := require.New(t)
+		c := newTestClient(t, &fakePaymentServer{}, testOptions())
+		id := uuid.NewV4().String()
+
+		_, err := c.Get(context.Background(), id)
+
+		is.True(errors.Is(err, client.ErrNotFound))
+
+		var typed *client.Error
+		is.True(errors.As(err, &typed))
+		is.Equal(codes.NotFound, typed.Code)
+		is.Equal(id, typed.Metadata["id"])
+		is.Equal(codes.NotFound, status.Code(err))
+	})
+
+	t.Run("should apply the call deadline", func(t *testing.T) {
+		is := require.New(t)
+		options := testOptions()
+		options.Timeout = 20 * time.Millisecond
+		c := newTestClient(t, &fakePaymentServer{delay: time.Second}, options)
+
+		_, err := c.Get(context.Background(), uuid.NewV4().String())
+
+		is.Equal(codes.DeadlineExceeded, status.Code(err))
+	})
+}
+
+func TestIterator(t *testing.T) {
+	t.Parallel()
+
+	is := require.New(t)
+	server := &fakePaymentServer{}
+
+	for i := 0; i < 5; i++ {
+		server.transactions = append(server.transactions, &pb.Transaction{ID: fmt.Sprintf("transaction-%d", i)})
+	}
+
+	c := newTestClient(t, server, testOptions())
+	it := c.Transactions(client.PageOptions{Limit: 2})
+
+	var ids []string
+
+	for {
+		transaction, err := it.Next(context.Background())
+
+		if err == client.Done {
+			break
+		}
+
+		is.Nil(err)
+		ids = append(ids, transaction.ID)
+	}
+
+	is.Equal([]string{"transaction-0", "transaction-1", "transaction-2", "transaction-3", "transaction-4"}, ids)
+	is.Equal(5, it.Total())
+
+	_, err := it.Next(context.Background())
+	is.Equal(client.Done, err)
+}
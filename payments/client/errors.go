@@ -0,0 +1,128 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	ReasonNotFound          = "NOT_FOUND"
+	ReasonInsufficientFunds = "INSUFFICIENT_FUNDS"
+	ReasonInvalidArgument   = "INVALID_ARGUMENT"
+	ReasonConflict          = "CONFLICT"
+	ReasonAccountFrozen     = "ACCOUNT_FROZEN"
+	ReasonUnauthenticated   = "UNAUTHENTICATED"
+	ReasonPermissionDenied  = "PERMISSION_DENIED"
+	ReasonRateLimited       = "RATE_LIMITED"
+)
+
+var (
+	ErrNotFound          = &Error{Reason: ReasonNotFound}
+	ErrInsufficientFunds = &Error{Reason: ReasonInsufficientFunds}
+	ErrInvalidArgument   = &Error{Reason: ReasonInvalidArgument}
+	ErrConflict          = &Error{Reason: ReasonConflict}
+	ErrAccountFrozen     = &Error{Reason: ReasonAccountFrozen}
+	ErrUnauthenticated   = &Error{Reason: ReasonUnauthenticated}
+	ErrPermissionDenied  = &Error{Reason: ReasonPermissionDenied}
+	ErrRateLimited       = &Error{Reason: ReasonRateLimited}
+)
+
+type Error struct {
+	Code       codes.Code
+	Reason     string
+	Message    string
+	Metadata   map[string]string
+	Fields     map[string]string
+	RetryAfter time.Duration
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("payments: %s: %s", e.Reason, e.Message)
+}
+
+// Is matches on the reason so callers can write errors.Is(err, client.ErrNotFound).
+func (e *Error) Is(target error) bool {
+	other, ok := target.(*Error)
+
+	return ok && other.Reason == e.Reason
+}
+
+func (e *Error) GRPCStatus() *status.Status {
+	return status.New(e.Code, e.Message)
+}
+
+// decodeError turns a gRPC status into an *Error, reading the ErrorInfo,
+// BadRequest and RetryInfo details the server attaches.
+func decodeError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	var decoded *Error
+
+	if errors.As(err, &decoded) {
+		return err
+	}
+
+	st, ok := status.FromError(err)
+
+	if !ok {
+		return err
+	}
+
+	decoded = &Error{
+		Code:    st.Code(),
+		Reason:  reasonFor(st.Code()),
+		Message: st.Message(),
+	}
+
+	for _, detail := range st.Details() {
+		switch detail := detail.(type) {
+		case *errdetails.ErrorInfo:
+			decoded.Reason = detail.GetReason()
+			decoded.Metadata = detail.GetMetadata()
+		case *errdetails.BadRequest:
+			decoded.Fields = make(map[string]string)
+
+			for _, violation := range detail.GetFieldViolations() {
+				decoded.Fields[violation.GetField()] = violation.GetDescription()
+			}
+		case *errdetails.RetryInfo:
+			decoded.RetryAfter = detail.GetRetryDelay().AsDuration()
+		}
+	}
+
+	return decoded
+}
+
+func reasonFor(code codes.Code) string {
+	switch code {
+	case codes.NotFound:
+		return ReasonNotFound
+	case codes.InvalidArgument:
+		return ReasonInvalidArgument
+	case codes.Unauthenticated:
+		return ReasonUnauthenticated
+	case codes.PermissionDenied:
+		return ReasonPermissionDenied
+	case codes.ResourceExhausted:
+		return ReasonRateLimited
+	}
+
+	return code.String()
+}
+
+func retryDelay(err error) time.Duration {
+	for _, detail := range status.Convert(err).Details() {
+		if retryInfo, ok := detail.(*errdetails.RetryInfo); ok {
+			return retryInfo.GetRetryDelay().AsDuration()
+		}
+	}
+
+	return 0
+}
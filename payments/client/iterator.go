@@ -0,0 +1,167 @@
+package client
+
+import (
+	"context"
+	"errors"
+
+	"github.com/EdlanioJ/kbu/payments/application/grpc/pb"
+)
+
+var Done = errors.New("no more transactions in iterator")
+
+const (
+	defaultPageSize = 50
+	defaultSort     = "created_at desc"
+)
+
+type PageOptions struct {
+	Limit int
+	Sort  string
+}
+
+type Page struct {
+	Transactions []*Transaction
+	Total        int
+}
+
+type pageFunc func(ctx context.Context, pagination *pb.PaginationRequest) (*pb.ListResponse, error)
+
+// Iterator walks a List RPC page by page. Next returns Done after the last
+// transaction.
+type Iterator struct {
+	client  *Client
+	fetch   pageFunc
+	options PageOptions
+	page    int
+	buffer  []*Transaction
+	seen    int
+	total   int
+	done    bool
+}
+
+func (c *Client) List(ctx context.Context, page int, options PageOptions) (*Page, error) {
+	return c.list(ctx, c.listAll, page, options)
+}
+
+func (c *Client) Transactions(options PageOptions) *Iterator {
+	return c.iterator(c.listAll, options)
+}
+
+func (c *Client) TransactionsByType(transactionType string, options PageOptions) *Iterator {
+	return c.iterator(func(ctx context.Context, pagination *pb.PaginationRequest) (*pb.ListResponse, error) {
+		return c.Payments.ListByType(ctx, &pb.ListByTypeRequest{
+			Type:       pb.TransactionType(pb.TransactionType_value[transactionType]),
+			Pagination: pagination,
+		})
+	}, options)
+}
+
+func (c *Client) TransactionsByReference(externalID string, options PageOptions) *Iterator {
+	return c.iterator(func(ctx context.Context, pagination *pb.PaginationRequest) (*pb.ListResponse, error) {
+		return c.Payments.ListByReference(ctx, &pb.ListRequest{ID: externalID, Pagination: pagination})
+	}, options)
+}
+
+func (c *Client) TransactionsByAccountFrom(accountID string, options PageOptions) *Iterator {
+	return c.iterator(func(ctx context.Context, pagination *pb.PaginationRequest) (*pb.ListResponse, error) {
+		return c.Payments.ListByAccountFrom(ctx, &pb.ListRequest{ID: accountID, Pagination: pagination})
+	}, options)
+}
+
+func (c *Client) TransactionsByAccountTo(accountID string, options PageOptions) *Iterator {
+	return c.iterator(func(ctx context.Context, pagination *pb.PaginationRequest) (*pb.ListResponse, error) {
+		return c.Payments.ListByAccountTo(ctx, &pb.ListRequest{ID: accountID, Pagination: pagination})
+	}, options)
+}
+
+func (c *Client) listAll(ctx context.Context, pagination *pb.PaginationRequest) (*pb.ListResponse, error) {
+	return c.Payments.List(ctx, pagination)
+}
+
+func (c *Client) iterator(fetch pageFunc, options PageOptions) *Iterator {
+	return &Iterator{
+		client:  c,
+		fetch:   fetch,
+		options: options,
+	}
+}
+
+// list treats NotFound as an empty page, the server reports an empty
+// result that way.
+func (c *Client) list(ctx context.Context, fetch pageFunc, page int, options PageOptions) (*Page, error) {
+	if options.Limit <= 0 {
+		options.Limit = defaultPageSize
+	}
+
+	if options.Sort == "" {
+		options.Sort = defaultSort
+	}
+
+	var response *pb.ListResponse
+
+	err := c.call(ctx, nil, func(ctx context.Context) error {
+		var err error
+		response, err = fetch(ctx, &pb.PaginationRequest{
+			Page:  int32(page),
+			Limit: int32(options.Limit),
+			Sort:  options.Sort,
+		})
+
+		return err
+	})
+
+	if errors.Is(err, ErrNotFound) {
+		return &Page{}, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Page{Total: int(response.GetTotal())}
+
+	for _, transaction := range response.GetTransactions() {
+		result.Transactions = append(result.Transactions, toTransaction(transaction))
+	}
+
+	return result, nil
+}
+
+func (it *Iterator) Next(ctx context.Context) (*Transaction, error) {
+	if len(it.buffer) == 0 {
+		if it.done {
+			return nil, Done
+		}
+
+		it.page++
+
+		page, err := it.client.list(ctx, it.fetch, it.page, it.options)
+
+		if err != nil {
+			it.page--
+			return nil, err
+		}
+
+		it.buffer = page.Transactions
+		it.total = page.Total
+
+		if len(page.Transactions) == 0 || it.seen+len(page.Transactions) >= page.Total {
+			it.done = true
+		}
+
+		if len(it.buffer) == 0 {
+			return nil, Done
+		}
+	}
+
+	transaction := it.buffer[0]
+	it.buffer = it.buffer[1:]
+	it.seen++
+
+	return transaction, nil
+}
+
+// Total is the number of transactions reported by the last page fetched.
+func (it *Iterator) Total() int {
+	return it.total
+}
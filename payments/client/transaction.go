@@ -0,0 +1,167 @@
+package client
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/EdlanioJ/kbu/payments/application/grpc/pb"
+	uuid "github.com/satori/go.uuid"
+	"google.golang.org/grpc/metadata"
+)
+
+const (
+	TypeToUser    = "to_user"
+	TypeToService = "to_service"
+	TypeToStore   = "to_store"
+
+	StatusPending   = "pending"
+	StatusCompleted = "completed"
+	StatusCanceled  = "canceled"
+)
+
+// serverTimeLayout is the layout of time.Time.String, which the server uses
+// for created and updated timestamps.
+const serverTimeLayout = "2006-01-02 15:04:05.999999999 -0700 MST"
+
+type Transaction struct {
+	ID          string
+	Amount      float64
+	Status      string
+	Currency    string
+	AccountFrom string
+	AccountTo   string
+	Type        string
+	ExternalID  string
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+type RegisterRequest struct {
+	AccountFrom string
+	AccountTo   string
+	ExternalID  string
+	Type        string
+	Currency    string
+	Amount      float64
+	// IdempotencyKey is generated when empty. Reuse it to safely retry a
+	// registration whose outcome is unknown.
+	IdempotencyKey string
+}
+
+// Register sends the same idempotency key on every attempt, so retries
+// after Unavailable never create a second payment.
+func (c *Client) Register(ctx context.Context, request *RegisterRequest) (*Transaction, error) {
+	key := request.IdempotencyKey
+
+	if key == "" {
+		key = uuid.NewV4().String()
+	}
+
+	in := &pb.RegisterRequest{
+		AccountFrom: request.AccountFrom,
+		AccountTo:   request.AccountTo,
+		ExternalID:  request.ExternalID,
+		Type:        pb.TransactionType(pb.TransactionType_value[request.Type]),
+		Currency:    request.Currency,
+		Amount:      float32(request.Amount),
+	}
+
+	var response *pb.Response
+
+	err := c.call(ctx, metadata.Pairs(idempotencyKeyHeader, key), func(ctx context.Context) error {
+		var err error
+		response, err = c.Payments.Register(ctx, in)
+
+		return err
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return toTransaction(response.GetTransaction()), nil
+}
+
+func (c *Client) Get(ctx context.Context, id string) (*Transaction, error) {
+	return c.get(ctx, func(ctx context.Context) (*pb.Response, error) {
+		return c.Payments.Get(ctx, &pb.Request{ID: id})
+	})
+}
+
+func (c *Client) GetByType(ctx context.Context, transactionType, id string) (*Transaction, error) {
+	return c.get(ctx, func(ctx context.Context) (*pb.Response, error) {
+		return c.Payments.GetByType(ctx, &pb.GetByTypeRequest{
+			Type:          pb.TransactionType(pb.TransactionType_value[transactionType]),
+			TransactionID: id,
+		})
+	})
+}
+
+func (c *Client) GetByReference(ctx context.Context, externalID, id string) (*Transaction, error) {
+	return c.get(ctx, func(ctx context.Context) (*pb.Response, error) {
+		return c.Payments.GetByReference(ctx, &pb.GetRequest{Id: externalID, TransactionID: id})
+	})
+}
+
+func (c *Client) GetByAccountFrom(ctx context.Context, accountID, id string) (*Transaction, error) {
+	return c.get(ctx, func(ctx context.Context) (*pb.Response, error) {
+		return c.Payments.GetByAccountFrom(ctx, &pb.GetRequest{Id: accountID, TransactionID: id})
+	})
+}
+
+func (c *Client) GetByAccountTo(ctx context.Context, accountID, id string) (*Transaction, error) {
+	return c.get(ctx, func(ctx context.Context) (*pb.Response, error) {
+		return c.Payments.GetByAccountTo(ctx, &pb.GetRequest{Id: accountID, TransactionID: id})
+	})
+}
+
+func (c *Client) get(ctx context.Context, fn func(ctx context.Context) (*pb.Response, error)) (*Transaction, error) {
+	var response *pb.Response
+
+	err := c.call(ctx, nil, func(ctx context.Context) error {
+		var err error
+		response, err = fn(ctx)
+
+		return err
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return toTransaction(response.GetTransaction()), nil
+}
+
+func toTransaction(transaction *pb.Transaction) *Transaction {
+	if transaction == nil {
+		return nil
+	}
+
+	return &Transaction{
+		ID:          transaction.GetID(),
+		Amount:      float64(transaction.GetAmount()),
+		Status:      transaction.GetStatus(),
+		Currency:    transaction.GetCurrency(),
+		AccountFrom: transaction.GetAccountFrom(),
+		AccountTo:   transaction.GetAccountTo(),
+		Type:        transaction.GetType(),
+		ExternalID:  transaction.GetExternalID(),
+		CreatedAt:   parseTime(transaction.GetCreatedAt()),
+		UpdatedAt:   parseTime(transaction.GetUpdatedAt()),
+	}
+}
+
+func parseTime(value string) time.Time {
+	if i := strings.Index(value, " m="); i >= 0 {
+		value = value[:i]
+	}
+
+	parsed, err := time.Parse(serverTimeLayout, value)
+
+	if err != nil {
+		return time.Time{}
+	}
+
+	return parsed
+}
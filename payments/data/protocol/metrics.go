@@ -0,0 +1,5 @@
+package protocol
+
+type TransactionMetrics interface {
+	RegisterFailed(reason string)
+}
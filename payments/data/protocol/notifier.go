@@ -0,0 +1,7 @@
+package protocol
+
+import "github.com/EdlanioJ/kbu/payments/domain/entity"
+
+type TransactionNotifier interface {
+	Publish(transaction *entity.Transaction)
+}
@@ -0,0 +1,8 @@
+package protocol
+
+import "github.com/EdlanioJ/kbu/payments/domain/entity"
+
+type ServiceProvider interface {
+	Charge(transaction *entity.Transaction) (string, error)
+	Refund(transaction *entity.Transaction) error
+}
@@ -0,0 +1,5 @@
+package protocol
+
+type WebhookSender interface {
+	Send(url string, headers map[string]string, payload []byte) (int, error)
+}
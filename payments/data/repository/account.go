@@ -1,8 +1,12 @@
 package repository
 
-import "github.com/EdlanioJ/kbu/payments/domain/entity"
+import (
+	"context"
+
+	"github.com/EdlanioJ/kbu/payments/domain/entity"
+)
 
 type AccountRepository interface {
-	Find(id string) (*entity.Account, error)
-	Save(account *entity.Account) error
+	Find(ctx context.Context, id string) (*entity.Account, error)
+	Save(ctx context.Context, account *entity.Account) error
 }
@@ -0,0 +1,11 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/EdlanioJ/kbu/payments/domain/entity"
+)
+
+type AuditRepository interface {
+	FindAll(ctx context.Context, filter *entity.AuditFilter, pagination *entity.Pagination) ([]*entity.AuditEntry, int, error)
+}
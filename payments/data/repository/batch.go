@@ -0,0 +1,16 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/EdlanioJ/kbu/payments/domain/entity"
+)
+
+type BatchRepository interface {
+	Register(ctx context.Context, batch *entity.Batch, items []*entity.BatchItem) error
+	Save(ctx context.Context, batch *entity.Batch, items []*entity.BatchItem) error
+	Claim(ctx context.Context, batch *entity.Batch, staleBefore time.Time) error
+	Find(ctx context.Context, id string) (*entity.Batch, []*entity.BatchItem, error)
+	FindByIdempotencyKey(ctx context.Context, key string) (*entity.Batch, []*entity.BatchItem, error)
+}
@@ -0,0 +1,14 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/EdlanioJ/kbu/payments/domain/entity"
+)
+
+type EventRepository interface {
+	FindAllByAggregate(ctx context.Context, aggregateType, aggregateID string) ([]*entity.Event, error)
+	Rebuild(ctx context.Context, aggregateType string, truncate bool) (int, error)
+	Iterate(ctx context.Context, fn func(event *entity.Event) error) error
+	IterateProjection(ctx context.Context, aggregateType string, fn func(aggregateID, state string, latest *entity.Event) error) error
+}
@@ -0,0 +1,17 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/EdlanioJ/kbu/payments/domain/entity"
+)
+
+type SagaRepository interface {
+	Register(ctx context.Context, saga *entity.Saga) error
+	Save(ctx context.Context, saga *entity.Saga, owner string) error
+	Find(ctx context.Context, id string) (*entity.Saga, error)
+	FindByIdempotencyKey(ctx context.Context, key string) (*entity.Saga, error)
+	FindAllInFlight(ctx context.Context, updatedBefore time.Time, limit int) ([]*entity.Saga, error)
+	Claim(ctx context.Context, saga *entity.Saga, owner string, until time.Time) (bool, error)
+}
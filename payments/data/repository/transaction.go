@@ -1,18 +1,26 @@
 package repository
 
-import "github.com/EdlanioJ/kbu/payments/domain/entity"
+import (
+	"context"
+
+	"github.com/EdlanioJ/kbu/payments/domain/entity"
+)
 
 type TransactionRepository interface {
-	Register(transaction *entity.Transaction) error
-	Save(transaction *entity.Transaction) error
-	Find(id string) (*entity.Transaction, error)
-	FindAll(pagination *entity.Pagination) ([]*entity.Transaction, int, error)
-	FindByType(transactionID, transactionType string) (*entity.Transaction, error)
-	FindAllByType(transactionType string, pagination *entity.Pagination) ([]*entity.Transaction, int, error)
-	FindByExternalID(transactionID, ExternalID string) (*entity.Transaction, error)
-	FindAllByExternalID(externalID string, pagination *entity.Pagination) ([]*entity.Transaction, int, error)
-	FindByFromAccountID(transactionID, accountID string) (*entity.Transaction, error)
-	FindAllByFromAccountID(accountID string, pagination *entity.Pagination) ([]*entity.Transaction, int, error)
-	FindByToAccountID(transactionID, accountID string) (*entity.Transaction, error)
-	FindAllByToAccountID(accountID string, pagination *entity.Pagination) ([]*entity.Transaction, int, error)
+	Register(ctx context.Context, transaction *entity.Transaction) error
+	Save(ctx context.Context, transaction *entity.Transaction) error
+	Find(ctx context.Context, id string) (*entity.Transaction, error)
+	FindByIdempotencyKey(ctx context.Context, key string) (*entity.Transaction, error)
+	FindAll(ctx context.Context, pagination *entity.Pagination) ([]*entity.Transaction, int, error)
+	FindByType(ctx context.Context, transactionID, transactionType string) (*entity.Transaction, error)
+	FindAllByType(ctx context.Context, transactionType string, pagination *entity.Pagination) ([]*entity.Transaction, int, error)
+	FindByExternalID(ctx context.Context, transactionID, ExternalID string) (*entity.Transaction, error)
+	FindAllByExternalID(ctx context.Context, externalID string, pagination *entity.Pagination) ([]*entity.Transaction, int, error)
+	FindByFromAccountID(ctx context.Context, transactionID, accountID string) (*entity.Transaction, error)
+	FindAllByFromAccountID(ctx context.Context, accountID string, pagination *entity.Pagination) ([]*entity.Transaction, int, error)
+	FindByToAccountID(ctx context.Context, transactionID, accountID string) (*entity.Transaction, error)
+	FindAllByToAccountID(ctx context.Context, accountID string, pagination *entity.Pagination) ([]*entity.Transaction, int, error)
+	Iterate(ctx context.Context, filter *entity.TransactionFilter, after *entity.ExportCursor, fn func(transaction *entity.Transaction) error) error
+	Totals(ctx context.Context) ([]*entity.TransactionTotals, error)
+	OldestPending(ctx context.Context) (*entity.Transaction, error)
 }
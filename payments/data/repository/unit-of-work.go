@@ -0,0 +1,13 @@
+package repository
+
+import "context"
+
+type UnitOfWork interface {
+	Do(ctx context.Context, fn func(transactions TransactionRepository, accounts AccountRepository) error) error
+}
+
+// ConfirmationUnitOfWork changes the status of a payment and queues its
+// webhook deliveries in one transaction.
+type ConfirmationUnitOfWork interface {
+	Do(ctx context.Context, fn func(transactions TransactionRepository, webhooks WebhookRepository, deliveries WebhookDeliveryRepository) error) error
+}
@@ -0,0 +1,23 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/EdlanioJ/kbu/payments/domain/entity"
+)
+
+type WebhookRepository interface {
+	Register(ctx context.Context, webhook *entity.Webhook) error
+	Find(ctx context.Context, id string) (*entity.Webhook, error)
+	FindAllByAccountID(ctx context.Context, accountID string) ([]*entity.Webhook, error)
+}
+
+type WebhookDeliveryRepository interface {
+	Register(ctx context.Context, delivery *entity.WebhookDelivery) error
+	Save(ctx context.Context, delivery *entity.WebhookDelivery) error
+	Find(ctx context.Context, id string) (*entity.WebhookDelivery, error)
+	FindAllDue(ctx context.Context, now time.Time, limit int) ([]*entity.WebhookDelivery, error)
+	RegisterAttempt(ctx context.Context, attempt *entity.WebhookAttempt) error
+	FindAllAttempts(ctx context.Context, deliveryID string) ([]*entity.WebhookAttempt, error)
+}
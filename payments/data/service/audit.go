@@ -0,0 +1,33 @@
+package service
+
+import (
+	"context"
+
+	"github.com/EdlanioJ/kbu/payments/data/repository"
+	"github.com/EdlanioJ/kbu/payments/domain/entity"
+)
+
+type Audit struct {
+	AuditRepository repository.AuditRepository
+}
+
+func NewAudit(AuditRepository repository.AuditRepository) *Audit {
+	return &Audit{
+		AuditRepository: AuditRepository,
+	}
+}
+
+func (a *Audit) FindAll(ctx context.Context, filter *entity.AuditFilter, page, limit int) ([]*entity.AuditEntry, int, error) {
+	pagination := &entity.Pagination{
+		Page:  page,
+		Limit: limit,
+	}
+
+	entries, total, err := a.AuditRepository.FindAll(ctx, filter, pagination)
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return entries, total, nil
+}
@@ -0,0 +1,46 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/EdlanioJ/kbu/payments/data/service"
+	"github.com/EdlanioJ/kbu/payments/data/service/mock"
+	"github.com/EdlanioJ/kbu/payments/domain/entity"
+	uuid "github.com/satori/go.uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuditFindAll(t *testing.T) {
+	t.Parallel()
+
+	t.Run("should fail on find all", func(t *testing.T) {
+		is := require.New(t)
+		filter := &entity.AuditFilter{Actor: "auditor-1"}
+
+		auditRepo := mock.NewMockAuditRepository()
+		auditRepo.On("FindAll", filter, &entity.Pagination{Page: 1, Limit: 10}).Return(nil, 0, errors.New("db error"))
+
+		entries, total, err := service.NewAudit(auditRepo).FindAll(context.Background(), filter, 1, 10)
+
+		is.NotNil(err)
+		is.Nil(entries)
+		is.Equal(0, total)
+	})
+
+	t.Run("should return a page of entries", func(t *testing.T) {
+		is := require.New(t)
+		filter := &entity.AuditFilter{EntityType: entity.AggregateAccount}
+		entry, _ := entity.NewAuditEntry(context.Background(), entity.EventAccountOpened, entity.AggregateAccount, uuid.NewV4().String(), "", "{}")
+
+		auditRepo := mock.NewMockAuditRepository()
+		auditRepo.On("FindAll", filter, &entity.Pagination{Page: 2, Limit: 5}).Return([]*entity.AuditEntry{entry}, 6, nil)
+
+		entries, total, err := service.NewAudit(auditRepo).FindAll(context.Background(), filter, 2, 5)
+
+		is.Nil(err)
+		is.Equal([]*entity.AuditEntry{entry}, entries)
+		is.Equal(6, total)
+	})
+}
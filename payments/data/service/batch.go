@@ -0,0 +1,282 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/EdlanioJ/kbu/payments/data/protocol"
+	"github.com/EdlanioJ/kbu/payments/data/repository"
+	"github.com/EdlanioJ/kbu/payments/domain/entity"
+)
+
+var errBatchAborted = errors.New("the batch was aborted because another item failed")
+
+type Batch struct {
+	BatchRepository       repository.BatchRepository
+	TransactionRepository repository.TransactionRepository
+	AccountRepository     repository.AccountRepository
+	UnitOfWork            repository.UnitOfWork
+	Notifier              protocol.TransactionNotifier
+	Metrics               protocol.TransactionMetrics
+	Saga                  *Saga
+	StaleAfter            time.Duration
+}
+
+func NewBatch(
+	BatchRepository repository.BatchRepository,
+	TransactionRepository repository.TransactionRepository,
+	AccountRepository repository.AccountRepository,
+	UnitOfWork repository.UnitOfWork,
+) *Batch {
+
+	return &Batch{
+		BatchRepository:       BatchRepository,
+		TransactionRepository: TransactionRepository,
+		AccountRepository:     AccountRepository,
+		UnitOfWork:            UnitOfWork,
+		StaleAfter:            15 * time.Minute,
+	}
+}
+
+func (b *Batch) Register(ctx context.Context, mode string, items []*entity.BatchItem) (*entity.Batch, []*entity.BatchItem, error) {
+	return b.register(ctx, "", mode, items)
+}
+
+// RegisterIdempotent registers a batch at most once per key. A retry with the
+// same key, mode and items gets the batch registered by the first call, a
+// different request is rejected as a conflict.
+func (b *Batch) RegisterIdempotent(ctx context.Context, key, mode string, items []*entity.BatchItem) (*entity.Batch, []*entity.BatchItem, error) {
+	if key == "" {
+		return b.Register(ctx, mode, items)
+	}
+
+	batch, registered, err := b.replay(ctx, key, mode, items)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if batch != nil {
+		return batch, registered, nil
+	}
+
+	batch, registered, err = b.register(ctx, key, mode, items)
+
+	if entity.IsErrorKind(err, entity.ErrorConflict) {
+		replayed, replayedItems, replayErr := b.replay(ctx, key, mode, items)
+
+		if replayErr != nil {
+			return nil, nil, replayErr
+		}
+
+		if replayed != nil {
+			return replayed, replayedItems, nil
+		}
+	}
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return batch, registered, nil
+}
+
+func (b *Batch) replay(ctx context.Context, key, mode string, items []*entity.BatchItem) (*entity.Batch, []*entity.BatchItem, error) {
+	batch, registered, err := b.BatchRepository.FindByIdempotencyKey(ctx, key)
+
+	if entity.IsErrorKind(err, entity.ErrorNotFound) {
+		return nil, nil, nil
+	}
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !batch.SameRequest(mode, registered, items) {
+		return nil, nil, entity.Conflict("payment batch", batch.ID, "idempotency key was already used for a different payment batch")
+	}
+
+	if batch.Status == entity.BatchProcessing {
+		return b.resume(ctx, batch, registered)
+	}
+
+	return batch, registered, nil
+}
+
+// resume finishes a batch whose call died before saving its outcome, once it
+// has been processing for longer than StaleAfter. Its items are paid again
+// with their own idempotency keys, which replays the payments made before.
+func (b *Batch) resume(ctx context.Context, batch *entity.Batch, items []*entity.BatchItem) (*entity.Batch, []*entity.BatchItem, error) {
+	if time.Since(batch.UpdatedAt) < b.StaleAfter {
+		return nil, nil, entity.Conflict("payment batch", batch.ID, "payment batch is being processed")
+	}
+
+	err := b.BatchRepository.Claim(ctx, batch, time.Now().Add(-b.StaleAfter))
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return b.process(ctx, batch, items)
+}
+
+func (b *Batch) register(ctx context.Context, key, mode string, items []*entity.BatchItem) (*entity.Batch, []*entity.BatchItem, error) {
+	batch, err := entity.NewBatch(mode)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	batch.IdempotencyKey = key
+
+	err = b.BatchRepository.Register(ctx, batch, items)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return b.process(ctx, batch, items)
+}
+
+func (b *Batch) process(ctx context.Context, batch *entity.Batch, items []*entity.BatchItem) (*entity.Batch, []*entity.BatchItem, error) {
+	if batch.Mode == entity.BatchAtomic {
+		b.registerAtomic(ctx, batch.IdempotencyKey, items)
+	} else {
+		b.registerBestEffort(ctx, batch.IdempotencyKey, items)
+	}
+
+	batch.Summarize(items)
+
+	err := b.BatchRepository.Save(ctx, batch, items)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return batch, items, nil
+}
+
+func (b *Batch) Find(ctx context.Context, batchID string) (*entity.Batch, []*entity.BatchItem, error) {
+	batch, items, err := b.BatchRepository.Find(ctx, batchID)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return batch, items, nil
+}
+
+func (b *Batch) registerAtomic(ctx context.Context, key string, items []*entity.BatchItem) {
+	for _, item := range items {
+		if item.Status == entity.BatchItemFailed {
+			abort(items)
+			return
+		}
+	}
+
+	err := b.UnitOfWork.Do(ctx, func(transactions repository.TransactionRepository, accounts repository.AccountRepository) error {
+		transactionService := NewTransaction(transactions, accounts)
+		transactionService.Metrics = b.Metrics
+
+		for _, item := range items {
+			err := register(ctx, transactionService, key, item)
+
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		abort(items)
+		return
+	}
+
+	for _, item := range items {
+		b.notify(item.Transaction)
+	}
+}
+
+func (b *Batch) registerBestEffort(ctx context.Context, key string, items []*entity.BatchItem) {
+	transactionService := NewTransaction(b.TransactionRepository, b.AccountRepository)
+	transactionService.Notifier = b.Notifier
+	transactionService.Metrics = b.Metrics
+
+	for _, item := range items {
+		if item.Status == entity.BatchItemFailed {
+			continue
+		}
+
+		if item.Type == entity.TransactionToService && b.Saga != nil {
+			b.registerService(ctx, key, item)
+			continue
+		}
+
+		register(ctx, transactionService, key, item)
+	}
+}
+
+// registerService pays a service item through the saga, like a single service
+// payment. The item keeps the payment of a saga that is still in flight, the
+// recoverer settles it.
+func (b *Batch) registerService(ctx context.Context, key string, item *entity.BatchItem) {
+	saga, err := b.Saga.StartIdempotent(ctx, item.IdempotencyKey(key), item.AccountFromID, item.AccountToID, item.ExternalID, item.Currency, item.Amount)
+
+	if saga == nil {
+		item.Fail(err)
+		return
+	}
+
+	if saga.Status == entity.SagaCompensated {
+		item.Fail(errors.New(saga.Error))
+		return
+	}
+
+	transaction, err := b.TransactionRepository.Find(ctx, saga.TransactionID)
+
+	if err != nil {
+		item.Fail(err)
+		return
+	}
+
+	item.Register(transaction)
+}
+
+func (b *Batch) notify(transaction *entity.Transaction) {
+	if b.Notifier != nil {
+		b.Notifier.Publish(transaction)
+	}
+}
+
+func register(ctx context.Context, transactionService *Transaction, key string, item *entity.BatchItem) error {
+	transaction, err := transactionService.RegisterIdempotent(ctx, item.IdempotencyKey(key), item.AccountFromID, item.AccountToID, item.ExternalID, item.Type, item.Currency, item.Amount)
+
+	if err != nil {
+		item.Fail(err)
+		return err
+	}
+
+	item.Register(transaction)
+
+	return nil
+}
+
+func abort(items []*entity.BatchItem) {
+	for _, item := range items {
+		switch item.Status {
+		case entity.BatchItemRegistered:
+			item.Status = entity.BatchItemRolledBack
+			item.TransactionID = ""
+			item.Transaction = nil
+		case entity.BatchItemPending:
+			item.Status = entity.BatchItemSkipped
+		default:
+			continue
+		}
+
+		item.Error = errBatchAborted.Error()
+	}
+}
@@ -0,0 +1,422 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/EdlanioJ/kbu/payments/data/service"
+	"github.com/EdlanioJ/kbu/payments/data/service/mock"
+	"github.com/EdlanioJ/kbu/payments/domain/entity"
+	uuid "github.com/satori/go.uuid"
+	tMock "github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type batchTestMocks struct {
+	batchRepo       *mock.MockBatchRepository
+	transactionRepo *mock.MockTransactionRepository
+	accountRepo     *mock.MockAccountRepository
+	payer           *entity.Account
+	payees          []*entity.Account
+}
+
+func newBatchTestMocks(balance float64) *batchTestMocks {
+	m := &batchTestMocks{
+		batchRepo:       mock.NewMockBatchRepository(),
+		transactionRepo: mock.NewMockTransactionRepository(),
+		accountRepo:     mock.NewMockAccountRepository(),
+	}
+
+	m.payer, _ = entity.NewAccount(balance)
+	m.accountRepo.On("Find", m.payer.ID).Return(m.payer, nil)
+
+	for i := 0; i < 3; i++ {
+		payee, _ := entity.NewAccount(0)
+		m.payees = append(m.payees, payee)
+		m.accountRepo.On("Find", payee.ID).Return(payee, nil)
+	}
+
+	m.batchRepo.On("Register", tMock.Anything, tMock.Anything).Return(nil)
+	m.batchRepo.On("Save", tMock.Anything, tMock.Anything).Return(nil)
+	m.transactionRepo.On("Register", tMock.Anything).Return(nil)
+	m.accountRepo.On("Save", tMock.Anything).Return(nil)
+
+	return m
+}
+
+func (m *batchTestMocks) items(amounts ...float64) []*entity.BatchItem {
+	var items []*entity.BatchItem
+
+	for i, amount := range amounts {
+		items = append(items, entity.NewBatchItem(i, m.payer.ID, m.payees[i].ID, uuid.NewV4().String(), entity.TransactionToUser, "AOA", amount))
+	}
+
+	return items
+}
+
+func (m *batchTestMocks) service() *service.Batch {
+	return service.NewBatch(m.batchRepo, m.transactionRepo, m.accountRepo, mock.NewMockUnitOfWork(m.transactionRepo, m.accountRepo))
+}
+
+func TestBatchRegister(t *testing.T) {
+	t.Parallel()
+
+	t.Run("should fail on register batch", func(t *testing.T) {
+		is := require.New(t)
+		batchRepo := mock.NewMockBatchRepository()
+		batchRepo.On("Register", tMock.Anything, tMock.Anything).Return(errors.New("db error"))
+
+		batch, items, err := service.NewBatch(batchRepo, nil, nil, nil).Register(context.Background(), entity.BatchAtomic, nil)
+
+		is.NotNil(err)
+		is.Nil(batch)
+		is.Nil(items)
+	})
+
+	t.Run("should register every item of an atomic batch", func(t *testing.T) {
+		is := require.New(t)
+		m := newBatchTestMocks(1000)
+		feed := service.NewTransactionFeed()
+
+		subscription, _ := feed.WatchAccount(m.payer.ID, 0)
+		defer subscription.Close()
+
+		batchService := m.service()
+		batchService.Notifier = feed
+
+		batch, items, err := batchService.Register(context.Background(), entity.BatchAtomic, m.items(100, 200, 300))
+
+		is.Nil(err)
+		is.Equal(entity.BatchCompleted, batch.Status)
+		is.Equal(3, batch.Succeeded)
+		is.Equal(400.0, m.payer.Balance)
+		is.Len(subscription.Updates(), 3)
+
+		for _, item := range items {
+			is.Equal(entity.BatchItemRegistered, item.Status)
+			is.NotEmpty(item.TransactionID)
+		}
+	})
+
+	t.Run("should roll back an atomic batch when an item fails", func(t *testing.T) {
+		is := require.New(t)
+		m := newBatchTestMocks(250)
+		feed := service.NewTransactionFeed()
+
+		subscription, _ := feed.WatchAccount(m.payer.ID, 0)
+		defer subscription.Close()
+
+		batchService := m.service()
+		batchService.Notifier = feed
+
+		batch, items, err := batchService.Register(context.Background(), entity.BatchAtomic, m.items(100, 200, 10))
+
+		is.Nil(err)
+		is.Equal(entity.BatchFailed, batch.Status)
+		is.Equal(0, batch.Succeeded)
+		is.Equal(entity.BatchItemRolledBack, items[0].Status)
+		is.Empty(items[0].TransactionID)
+		is.Equal(entity.BatchItemFailed, items[1].Status)
+		is.Equal("account does not have balance", items[1].Error)
+		is.Equal(entity.BatchItemSkipped, items[2].Status)
+		is.Len(subscription.Updates(), 0)
+	})
+
+	t.Run("should not execute an atomic batch with invalid items", func(t *testing.T) {
+		is := require.New(t)
+		m := newBatchTestMocks(1000)
+
+		items := m.items(100, 200)
+		items[1].Fail(errors.New("amount: must be no less than 0"))
+
+		batch, items, err := m.service().Register(context.Background(), entity.BatchAtomic, items)
+
+		is.Nil(err)
+		is.Equal(entity.BatchFailed, batch.Status)
+		is.Equal(entity.BatchItemSkipped, items[0].Status)
+		m.transactionRepo.AssertNotCalled(t, "Register", tMock.Anything)
+	})
+
+	t.Run("should register what it can in a best effort batch", func(t *testing.T) {
+		is := require.New(t)
+		m := newBatchTestMocks(250)
+
+		batch, items, err := m.service().Register(context.Background(), entity.BatchBestEffort, m.items(100, 200, 10))
+
+		is.Nil(err)
+		is.Equal(entity.BatchPartial, batch.Status)
+		is.Equal(2, batch.Succeeded)
+		is.Equal(1, batch.Failed)
+		is.Equal(entity.BatchItemRegistered, items[0].Status)
+		is.Equal(entity.BatchItemFailed, items[1].Status)
+		is.Equal(entity.BatchItemRegistered, items[2].Status)
+		is.Equal(140.0, m.payer.Balance)
+	})
+}
+
+func TestBatchRegisterObservability(t *testing.T) {
+	t.Parallel()
+
+	t.Run("should count the failed items of a best effort batch", func(t *testing.T) {
+		is := require.New(t)
+		m := newBatchTestMocks(250)
+		metrics := mock.NewMockTransactionMetrics()
+		metrics.On("RegisterFailed", "insufficient_funds").Return()
+
+		batchService := m.service()
+		batchService.Metrics = metrics
+
+		batch, _, err := batchService.Register(context.Background(), entity.BatchBestEffort, m.items(100, 200, 10))
+
+		is.Nil(err)
+		is.Equal(1, batch.Failed)
+		metrics.AssertNumberOfCalls(t, "RegisterFailed", 1)
+	})
+
+	t.Run("should pay service items of a best effort batch through the saga", func(t *testing.T) {
+		is := require.New(t)
+		m := newBatchTestMocks(1000)
+		sagaRepo := mock.NewMockSagaRepository()
+		provider := mock.NewMockServiceProvider()
+		feed := service.NewTransactionFeed()
+
+		subscription, _ := feed.WatchAccount(m.payer.ID, 0)
+		defer subscription.Close()
+
+		var registered *entity.Transaction
+		transactionRepo := mock.NewMockTransactionRepository()
+		transactionRepo.On("Register", tMock.Anything).Return(nil).Run(func(args tMock.Arguments) {
+			registered = args.Get(0).(*entity.Transaction)
+		})
+		transactionRepo.On("Save", tMock.Anything).Return(nil)
+		transactionRepo.On("Find", tMock.Anything).Return(
+			func() *entity.Transaction { return registered },
+			func() error {
+				if registered == nil {
+					return errors.New("record not found")
+				}
+				return nil
+			},
+		)
+		m.transactionRepo = transactionRepo
+
+		sagaRepo.On("Register", tMock.Anything).Return(nil)
+		sagaRepo.On("Save", tMock.Anything, tMock.Anything).Return(nil)
+		provider.On("Charge", tMock.Anything).Return("ref-1", nil)
+
+		sagaService := service.NewSaga(sagaRepo, m.transactionRepo, m.accountRepo, mock.NewMockUnitOfWork(m.transactionRepo, m.accountRepo), provider)
+		sagaService.Notifier = feed
+
+		batchService := m.service()
+		batchService.Notifier = feed
+		batchService.Saga = sagaService
+
+		items := []*entity.BatchItem{
+			entity.NewBatchItem(0, m.payer.ID, m.payees[0].ID, uuid.NewV4().String(), entity.TransactionToService, "AOA", 300),
+		}
+
+		batch, items, err := batchService.Register(context.Background(), entity.BatchBestEffort, items)
+
+		is.Nil(err)
+		is.Equal(entity.BatchCompleted, batch.Status)
+		is.Equal(entity.BatchItemRegistered, items[0].Status)
+		is.Equal(registered.ID, items[0].TransactionID)
+		is.Equal(entity.TransactionCompleted, registered.Status)
+		is.Equal(700.0, m.payer.Balance)
+		is.Len(subscription.Updates(), 2)
+		provider.AssertNumberOfCalls(t, "Charge", 1)
+	})
+
+	t.Run("should fail a service item whose saga was compensated", func(t *testing.T) {
+		is := require.New(t)
+		m := newBatchTestMocks(100)
+		sagaRepo := mock.NewMockSagaRepository()
+		provider := mock.NewMockServiceProvider()
+
+		sagaRepo.On("Register", tMock.Anything).Return(nil)
+		sagaRepo.On("Save", tMock.Anything, tMock.Anything).Return(nil)
+		m.transactionRepo.On("Find", tMock.Anything).Return(nil, errors.New("record not found"))
+
+		batchService := m.service()
+		batchService.Saga = service.NewSaga(sagaRepo, m.transactionRepo, m.accountRepo, mock.NewMockUnitOfWork(m.transactionRepo, m.accountRepo), provider)
+
+		items := []*entity.BatchItem{
+			entity.NewBatchItem(0, m.payer.ID, m.payees[0].ID, uuid.NewV4().String(), entity.TransactionToService, "AOA", 300),
+		}
+
+		batch, items, err := batchService.Register(context.Background(), entity.BatchBestEffort, items)
+
+		is.Nil(err)
+		is.Equal(entity.BatchFailed, batch.Status)
+		is.Equal(entity.BatchItemFailed, items[0].Status)
+		is.Equal("account does not have balance", items[0].Error)
+		provider.AssertNotCalled(t, "Charge", tMock.Anything)
+	})
+}
+
+func TestBatchRegisterIdempotent(t *testing.T) {
+	t.Parallel()
+
+	t.Run("should store the key on a new batch", func(t *testing.T) {
+		is := require.New(t)
+		m := newBatchTestMocks(1000)
+		m.batchRepo.On("FindByIdempotencyKey", "key-1").Return(nil, nil, entity.NotFound("payment batch", "key-1"))
+		m.transactionRepo.On("FindByIdempotencyKey", tMock.Anything).Return(nil, entity.NotFound("payment", ""))
+
+		batch, items, err := m.service().RegisterIdempotent(context.Background(), "key-1", entity.BatchBestEffort, m.items(100, 200))
+
+		is.Nil(err)
+		is.Equal("key-1", batch.IdempotencyKey)
+		is.Equal(entity.BatchCompleted, batch.Status)
+		m.transactionRepo.AssertNumberOfCalls(t, "Register", 2)
+		m.transactionRepo.AssertCalled(t, "FindByIdempotencyKey", items[0].IdempotencyKey("key-1"))
+		m.transactionRepo.AssertCalled(t, "FindByIdempotencyKey", items[1].IdempotencyKey("key-1"))
+		is.NotEqual(items[0].IdempotencyKey("key-1"), items[1].IdempotencyKey("key-1"))
+	})
+
+	t.Run("should start the saga of a service item with a key of its own", func(t *testing.T) {
+		is := require.New(t)
+		m := newBatchTestMocks(100)
+		sagaRepo := mock.NewMockSagaRepository()
+
+		var started *entity.Saga
+		m.batchRepo.On("FindByIdempotencyKey", "key-1").Return(nil, nil, entity.NotFound("payment batch", "key-1"))
+		sagaRepo.On("FindByIdempotencyKey", tMock.Anything).Return(nil, entity.NotFound("service payment", ""))
+		sagaRepo.On("Register", tMock.Anything).Return(nil).Run(func(args tMock.Arguments) {
+			started = args.Get(0).(*entity.Saga)
+		})
+		sagaRepo.On("Save", tMock.Anything, tMock.Anything).Return(nil)
+		m.transactionRepo.On("Find", tMock.Anything).Return(nil, errors.New("record not found"))
+
+		batchService := m.service()
+		batchService.Saga = service.NewSaga(sagaRepo, m.transactionRepo, m.accountRepo, mock.NewMockUnitOfWork(m.transactionRepo, m.accountRepo), mock.NewMockServiceProvider())
+
+		items := []*entity.BatchItem{
+			entity.NewBatchItem(0, m.payer.ID, m.payees[0].ID, uuid.NewV4().String(), entity.TransactionToService, "AOA", 300),
+		}
+
+		_, items, err := batchService.RegisterIdempotent(context.Background(), "key-1", entity.BatchBestEffort, items)
+
+		is.Nil(err)
+		is.Equal(items[0].IdempotencyKey("key-1"), started.IdempotencyKey)
+		sagaRepo.AssertCalled(t, "FindByIdempotencyKey", items[0].IdempotencyKey("key-1"))
+	})
+
+	t.Run("should resume a stale batch without paying its items again", func(t *testing.T) {
+		is := require.New(t)
+		m := newBatchTestMocks(1000)
+		items := m.items(100, 200)
+
+		stale, _ := entity.NewBatch(entity.BatchBestEffort)
+		stale.IdempotencyKey = "key-1"
+		stale.UpdatedAt = time.Now().Add(-time.Hour)
+
+		paid, _ := entity.NewTransaction(m.payer, m.payees[0], items[0].ExternalID, items[0].Type, items[0].Currency, items[0].Amount)
+
+		m.batchRepo.On("FindByIdempotencyKey", "key-1").Return(stale, items, nil)
+		m.batchRepo.On("Claim", stale, tMock.Anything).Return(nil)
+		m.transactionRepo.On("FindByIdempotencyKey", items[0].IdempotencyKey("key-1")).Return(paid, nil)
+		m.transactionRepo.On("FindByIdempotencyKey", items[1].IdempotencyKey("key-1")).Return(nil, entity.NotFound("payment", ""))
+
+		batch, resumed, err := m.service().RegisterIdempotent(context.Background(), "key-1", entity.BatchBestEffort, items)
+
+		is.Nil(err)
+		is.Equal(stale.ID, batch.ID)
+		is.Equal(entity.BatchCompleted, batch.Status)
+		is.Equal(paid.ID, resumed[0].TransactionID)
+		m.transactionRepo.AssertNumberOfCalls(t, "Register", 1)
+		m.batchRepo.AssertCalled(t, "Save", stale, items)
+	})
+
+	t.Run("should not resume a batch that is still being processed", func(t *testing.T) {
+		is := require.New(t)
+		m := newBatchTestMocks(1000)
+
+		processing, _ := entity.NewBatch(entity.BatchBestEffort)
+		processing.UpdatedAt = time.Now()
+		items := m.items(100, 200)
+		m.batchRepo.On("FindByIdempotencyKey", "key-1").Return(processing, items, nil)
+
+		batch, _, err := m.service().RegisterIdempotent(context.Background(), "key-1", entity.BatchBestEffort, items)
+
+		is.Nil(batch)
+		is.True(entity.IsErrorKind(err, entity.ErrorConflict))
+		m.batchRepo.AssertNotCalled(t, "Claim", tMock.Anything, tMock.Anything)
+		m.transactionRepo.AssertNotCalled(t, "Register", tMock.Anything)
+	})
+
+	t.Run("should not resume a stale batch claimed by another call", func(t *testing.T) {
+		is := require.New(t)
+		m := newBatchTestMocks(1000)
+
+		stale, _ := entity.NewBatch(entity.BatchBestEffort)
+		stale.UpdatedAt = time.Now().Add(-time.Hour)
+		items := m.items(100, 200)
+		m.batchRepo.On("FindByIdempotencyKey", "key-1").Return(stale, items, nil)
+		m.batchRepo.On("Claim", stale, tMock.Anything).Return(entity.Conflict("payment batch", stale.ID, "payment batch is being processed"))
+
+		batch, _, err := m.service().RegisterIdempotent(context.Background(), "key-1", entity.BatchBestEffort, items)
+
+		is.Nil(batch)
+		is.True(entity.IsErrorKind(err, entity.ErrorConflict))
+		m.transactionRepo.AssertNotCalled(t, "Register", tMock.Anything)
+	})
+
+	t.Run("should replay a registered batch without paying again", func(t *testing.T) {
+		is := require.New(t)
+		m := newBatchTestMocks(1000)
+		items := m.items(100, 200)
+
+		registered, _ := entity.NewBatch(entity.BatchBestEffort)
+		registered.Status = entity.BatchCompleted
+		m.batchRepo.On("FindByIdempotencyKey", "key-1").Return(registered, items, nil)
+
+		retried := make([]*entity.BatchItem, 0, len(items))
+
+		for _, item := range items {
+			retried = append(retried, entity.NewBatchItem(item.Position, item.AccountFromID, item.AccountToID, item.ExternalID, item.Type, item.Currency, item.Amount))
+		}
+
+		batch, replayed, err := m.service().RegisterIdempotent(context.Background(), "key-1", entity.BatchBestEffort, retried)
+
+		is.Nil(err)
+		is.Equal(registered.ID, batch.ID)
+		is.Equal(items, replayed)
+		m.batchRepo.AssertNotCalled(t, "Register", tMock.Anything, tMock.Anything)
+		m.transactionRepo.AssertNotCalled(t, "Register", tMock.Anything)
+	})
+
+	t.Run("should reject a key reused for different items", func(t *testing.T) {
+		is := require.New(t)
+		m := newBatchTestMocks(1000)
+
+		registered, _ := entity.NewBatch(entity.BatchBestEffort)
+		registered.Status = entity.BatchCompleted
+		m.batchRepo.On("FindByIdempotencyKey", "key-1").Return(registered, m.items(100, 200), nil)
+
+		batch, _, err := m.service().RegisterIdempotent(context.Background(), "key-1", entity.BatchBestEffort, m.items(100, 200))
+
+		is.Nil(batch)
+		is.True(entity.IsErrorKind(err, entity.ErrorConflict))
+		m.transactionRepo.AssertNotCalled(t, "Register", tMock.Anything)
+	})
+}
+
+func TestBatchFind(t *testing.T) {
+	t.Parallel()
+
+	t.Run("should fail on find batch", func(t *testing.T) {
+		is := require.New(t)
+		id := uuid.NewV4().String()
+		batchRepo := mock.NewMockBatchRepository()
+		batchRepo.On("Find", id).Return(nil, nil, errors.New("record not found"))
+
+		batch, items, err := service.NewBatch(batchRepo, nil, nil, nil).Find(context.Background(), id)
+
+		is.NotNil(err)
+		is.Nil(batch)
+		is.Nil(items)
+	})
+}
@@ -0,0 +1,51 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/EdlanioJ/kbu/payments/data/repository"
+	"github.com/EdlanioJ/kbu/payments/domain/entity"
+)
+
+var errHistoryNotFound = errors.New("no history was found")
+
+type Event struct {
+	EventRepository repository.EventRepository
+}
+
+func NewEvent(EventRepository repository.EventRepository) *Event {
+	return &Event{
+		EventRepository: EventRepository,
+	}
+}
+
+func (e *Event) History(ctx context.Context, aggregateType, aggregateID string) ([]*entity.Event, error) {
+	events, err := e.EventRepository.FindAllByAggregate(ctx, aggregateType, aggregateID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(events) == 0 {
+		return nil, errHistoryNotFound
+	}
+
+	return events, nil
+}
+
+func (e *Event) Rebuild(ctx context.Context, truncate bool) (map[string]int, error) {
+	rebuilt := make(map[string]int)
+
+	for _, aggregateType := range []string{entity.AggregateAccount, entity.AggregateTransaction} {
+		total, err := e.EventRepository.Rebuild(ctx, aggregateType, truncate)
+
+		if err != nil {
+			return nil, err
+		}
+
+		rebuilt[aggregateType] = total
+	}
+
+	return rebuilt, nil
+}
@@ -0,0 +1,89 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/EdlanioJ/kbu/payments/data/service"
+	"github.com/EdlanioJ/kbu/payments/data/service/mock"
+	"github.com/EdlanioJ/kbu/payments/domain/entity"
+	uuid "github.com/satori/go.uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventHistory(t *testing.T) {
+	t.Parallel()
+
+	t.Run("should fail on find events", func(t *testing.T) {
+		is := require.New(t)
+		id := uuid.NewV4().String()
+
+		eventRepo := mock.NewMockEventRepository()
+		eventRepo.On("FindAllByAggregate", entity.AggregateTransaction, id).Return(nil, errors.New("db error"))
+
+		events, err := service.NewEvent(eventRepo).History(context.Background(), entity.AggregateTransaction, id)
+
+		is.NotNil(err)
+		is.Nil(events)
+	})
+
+	t.Run("should fail when the aggregate has no events", func(t *testing.T) {
+		is := require.New(t)
+		id := uuid.NewV4().String()
+
+		eventRepo := mock.NewMockEventRepository()
+		eventRepo.On("FindAllByAggregate", entity.AggregateTransaction, id).Return([]*entity.Event{}, nil)
+
+		events, err := service.NewEvent(eventRepo).History(context.Background(), entity.AggregateTransaction, id)
+
+		is.NotNil(err)
+		is.Nil(events)
+	})
+
+	t.Run("should return the events of the aggregate", func(t *testing.T) {
+		is := require.New(t)
+		account, _ := entity.NewAccount(100)
+		event, _ := entity.NewEvent(entity.AggregateAccount, account.ID, 1, entity.EventAccountOpened, account)
+
+		eventRepo := mock.NewMockEventRepository()
+		eventRepo.On("FindAllByAggregate", entity.AggregateAccount, account.ID).Return([]*entity.Event{event}, nil)
+
+		events, err := service.NewEvent(eventRepo).History(context.Background(), entity.AggregateAccount, account.ID)
+
+		is.Nil(err)
+		is.Len(events, 1)
+		eventRepo.AssertExpectations(t)
+	})
+}
+
+func TestEventRebuild(t *testing.T) {
+	t.Parallel()
+
+	t.Run("should fail on rebuild accounts", func(t *testing.T) {
+		is := require.New(t)
+
+		eventRepo := mock.NewMockEventRepository()
+		eventRepo.On("Rebuild", entity.AggregateAccount, true).Return(0, errors.New("db error"))
+
+		result, err := service.NewEvent(eventRepo).Rebuild(context.Background(), true)
+
+		is.NotNil(err)
+		is.Nil(result)
+		eventRepo.AssertNotCalled(t, "Rebuild", entity.AggregateTransaction, true)
+	})
+
+	t.Run("should rebuild every projection", func(t *testing.T) {
+		is := require.New(t)
+
+		eventRepo := mock.NewMockEventRepository()
+		eventRepo.On("Rebuild", entity.AggregateAccount, false).Return(2, nil)
+		eventRepo.On("Rebuild", entity.AggregateTransaction, false).Return(5, nil)
+
+		result, err := service.NewEvent(eventRepo).Rebuild(context.Background(), false)
+
+		is.Nil(err)
+		is.Equal(map[string]int{entity.AggregateAccount: 2, entity.AggregateTransaction: 5}, result)
+		eventRepo.AssertExpectations(t)
+	})
+}
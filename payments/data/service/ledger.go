@@ -0,0 +1,224 @@
+package service
+
+import (
+	"context"
+	"crypto/ed25519"
+	"errors"
+	"fmt"
+
+	"github.com/EdlanioJ/kbu/payments/data/repository"
+	"github.com/EdlanioJ/kbu/payments/domain/entity"
+)
+
+var ledgerAggregates = []string{entity.AggregateAccount, entity.AggregateTransaction}
+
+var (
+	errNoSigningKey = errors.New("no ledger signing key was configured")
+	errLedgerBroken = errors.New("ledger chain is broken")
+	errStopWalk     = errors.New("stop walking the ledger")
+)
+
+type Ledger struct {
+	EventRepository repository.EventRepository
+	SigningKey      ed25519.PrivateKey
+	TrustedKey      ed25519.PublicKey
+}
+
+func NewLedger(EventRepository repository.EventRepository) *Ledger {
+	return &Ledger{
+		EventRepository: EventRepository,
+	}
+}
+
+// Verify walks the hash chain of the ledger of every tenant, in order, and
+// returns its first broken link. Every event of a tenant is chained to the one
+// before it, so removing events, even every event of an aggregate, breaks the
+// chain. Given a checkpoint it also checks the checkpoint signature and that
+// the head it recorded for every tenant is still in place, which catches
+// events removed from the end and rewritten chains that are consistent on
+// their own. The checkpoint must be signed by TrustedKey, never by a key taken
+// from the checkpoint itself, and taken for the tenant ctx is scoped to: a
+// scoped call only walks the chain and rows of that tenant. Last, every
+// account and payment row must match the last event of its aggregate, which
+// catches rows edited or inserted behind the event store. The walk keeps one
+// event per tenant and the projections are compared page by page, so memory
+// does not grow with the history.
+func (l *Ledger) Verify(ctx context.Context, checkpoint *entity.LedgerCheckpoint) (*entity.LedgerBreak, error) {
+	pending := make(map[string]*entity.LedgerHead)
+
+	if checkpoint != nil {
+		err := checkpoint.VerifySignature(l.TrustedKey)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if tenant, _ := entity.Tenant(ctx); checkpoint.Tenant != tenant {
+			return nil, entity.ErrCheckpointTenant
+		}
+
+		for _, head := range checkpoint.Heads {
+			pending[head.Tenant] = head
+		}
+	}
+
+	broken, _, err := l.walk(ctx, func(event *entity.Event) *entity.LedgerBreak {
+		head, ok := pending[event.TenantID]
+
+		if !ok || head.Sequence != event.Sequence {
+			return nil
+		}
+
+		delete(pending, event.TenantID)
+
+		if head.Hash != event.Hash {
+			return eventBreak(event, "hash does not match the checkpoint")
+		}
+
+		return nil
+	})
+
+	if err != nil || broken != nil {
+		return broken, err
+	}
+
+	if checkpoint != nil {
+		for _, head := range checkpoint.Heads {
+			if _, ok := pending[head.Tenant]; ok {
+				return &entity.LedgerBreak{
+					Tenant:   head.Tenant,
+					Sequence: head.Sequence,
+					Reason:   "event recorded by the checkpoint is missing",
+				}, nil
+			}
+		}
+	}
+
+	return l.verifyProjections(ctx)
+}
+
+func (l *Ledger) verifyProjections(ctx context.Context) (*entity.LedgerBreak, error) {
+	for _, aggregateType := range ledgerAggregates {
+		var broken *entity.LedgerBreak
+
+		err := l.EventRepository.IterateProjection(ctx, aggregateType, func(aggregateID, state string, latest *entity.Event) error {
+			switch {
+			case latest == nil:
+				broken = &entity.LedgerBreak{
+					AggregateType: aggregateType,
+					AggregateID:   aggregateID,
+					Reason:        "projection has no events",
+				}
+			case state == "":
+				broken = eventBreak(latest, "projection of the last event is missing")
+			default:
+				expected, err := latest.LedgerState()
+
+				if err != nil {
+					return err
+				}
+
+				if state == expected {
+					return nil
+				}
+
+				broken = eventBreak(latest, "projection does not match the last event")
+			}
+
+			return errStopWalk
+		})
+
+		if errors.Is(err, errStopWalk) {
+			return broken, nil
+		}
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return nil, nil
+}
+
+func (l *Ledger) Checkpoint(ctx context.Context) (*entity.LedgerCheckpoint, error) {
+	if l.SigningKey == nil {
+		return nil, errNoSigningKey
+	}
+
+	broken, heads, err := l.walk(ctx, nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if broken != nil {
+		return nil, fmt.Errorf("%w: %s sequence %d: %s", errLedgerBroken, broken.Tenant, broken.Sequence, broken.Reason)
+	}
+
+	checkpoint := entity.NewLedgerCheckpoint(heads)
+	checkpoint.Tenant, _ = entity.Tenant(ctx)
+	checkpoint.Sign(l.SigningKey)
+
+	return checkpoint, nil
+}
+
+func (l *Ledger) walk(ctx context.Context, check func(event *entity.Event) *entity.LedgerBreak) (*entity.LedgerBreak, []*entity.LedgerHead, error) {
+	var broken *entity.LedgerBreak
+	var heads []*entity.LedgerHead
+	var previous *entity.Event
+
+	err := l.EventRepository.Iterate(ctx, func(event *entity.Event) error {
+		if previous != nil && previous.TenantID != event.TenantID {
+			heads = append(heads, ledgerHead(previous))
+			previous = nil
+		}
+
+		if reason := event.VerifyLink(previous); reason != "" {
+			broken = eventBreak(event, reason)
+			return errStopWalk
+		}
+
+		if check != nil {
+			if broken = check(event); broken != nil {
+				return errStopWalk
+			}
+		}
+
+		previous = event
+
+		return nil
+	})
+
+	if errors.Is(err, errStopWalk) {
+		return broken, nil, nil
+	}
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if previous != nil {
+		heads = append(heads, ledgerHead(previous))
+	}
+
+	return nil, heads, nil
+}
+
+func ledgerHead(event *entity.Event) *entity.LedgerHead {
+	return &entity.LedgerHead{
+		Tenant:   event.TenantID,
+		Sequence: event.Sequence,
+		Hash:     event.Hash,
+	}
+}
+
+func eventBreak(event *entity.Event, reason string) *entity.LedgerBreak {
+	return &entity.LedgerBreak{
+		Tenant:        event.TenantID,
+		Sequence:      event.Sequence,
+		AggregateType: event.AggregateType,
+		AggregateID:   event.AggregateID,
+		Version:       event.Version,
+		Reason:        reason,
+	}
+}
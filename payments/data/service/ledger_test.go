@@ -0,0 +1,388 @@
+package service_test
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"testing"
+
+	"github.com/EdlanioJ/kbu/payments/data/service"
+	"github.com/EdlanioJ/kbu/payments/data/service/mock"
+	"github.com/EdlanioJ/kbu/payments/domain/entity"
+	"github.com/stretchr/testify/require"
+)
+
+func newChain(aggregateType string, length int) []*entity.Event {
+	account, _ := entity.NewAccount(100)
+
+	var chain []*entity.Event
+
+	for version := 1; version <= length; version++ {
+		account.Deposit(10)
+		event, _ := entity.NewEvent(aggregateType, account.ID, version, entity.EventAccountCredited, account)
+
+		chain = append(chain, event)
+	}
+
+	return linkLedger(chain)
+}
+
+// linkLedger chains the events of every aggregate, in order, in the ledger of
+// one tenant.
+func linkLedger(chains ...[]*entity.Event) []*entity.Event {
+	var ledger []*entity.Event
+	var previous *entity.Event
+
+	for _, chain := range chains {
+		for _, event := range chain {
+			event.Link(previous)
+
+			ledger = append(ledger, event)
+			previous = event
+		}
+	}
+
+	return ledger
+}
+
+// projectionOf is the projection an intact store keeps for the aggregates of
+// the type: the state of the last event of every aggregate.
+func projectionOf(aggregateType string, events []*entity.Event) []mock.MockProjection {
+	var rows []mock.MockProjection
+	index := make(map[string]int)
+
+	for _, event := range events {
+		if event.AggregateType != aggregateType {
+			continue
+		}
+
+		state, _ := event.LedgerState()
+		row := mock.MockProjection{AggregateID: event.AggregateID, State: state, Latest: event}
+
+		if i, ok := index[event.AggregateID]; ok {
+			rows[i] = row
+			continue
+		}
+
+		index[event.AggregateID] = len(rows)
+		rows = append(rows, row)
+	}
+
+	return rows
+}
+
+func newLedgerWithProjection(events []*entity.Event, accountRows, transactionRows []mock.MockProjection) *service.Ledger {
+	eventRepo := mock.NewMockEventRepository()
+	eventRepo.On("Iterate").Return(events, nil)
+	eventRepo.On("IterateProjection", entity.AggregateAccount).Return(accountRows, nil)
+	eventRepo.On("IterateProjection", entity.AggregateTransaction).Return(transactionRows, nil)
+
+	return service.NewLedger(eventRepo)
+}
+
+func newLedger(events []*entity.Event) *service.Ledger {
+	return newLedgerWithProjection(events, projectionOf(entity.AggregateAccount, events), projectionOf(entity.AggregateTransaction, events))
+}
+
+func newSigningKey() ed25519.PrivateKey {
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+
+	if err != nil {
+		panic(err)
+	}
+
+	return key
+}
+
+func TestLedgerVerify(t *testing.T) {
+	t.Parallel()
+
+	t.Run("should accept intact chains", func(t *testing.T) {
+		is := require.New(t)
+		events := linkLedger(newChain(entity.AggregateAccount, 3), newChain(entity.AggregateAccount, 2), newChain(entity.AggregateTransaction, 2))
+
+		broken, err := newLedger(events).Verify(context.Background(), nil)
+
+		is.Nil(err)
+		is.Nil(broken)
+	})
+
+	t.Run("should report an edited event", func(t *testing.T) {
+		is := require.New(t)
+		chain := newChain(entity.AggregateAccount, 3)
+		chain[1].Payload = `{"balance":1000000}`
+
+		broken, err := newLedger(chain).Verify(context.Background(), nil)
+
+		is.Nil(err)
+		is.Equal(chain[1].AggregateID, broken.AggregateID)
+		is.Equal(int64(2), broken.Sequence)
+		is.Equal(2, broken.Version)
+		is.Equal("content does not match its hash", broken.Reason)
+	})
+
+	t.Run("should report an event whose hash was recomputed", func(t *testing.T) {
+		is := require.New(t)
+		chain := newChain(entity.AggregateAccount, 3)
+		chain[1].Payload = `{"balance":1000000}`
+		chain[1].Hash = chain[1].ComputeHash()
+
+		broken, err := newLedger(chain).Verify(context.Background(), nil)
+
+		is.Nil(err)
+		is.Equal(3, broken.Version)
+		is.Equal("previous hash does not match the previous event", broken.Reason)
+	})
+
+	t.Run("should report a removed event", func(t *testing.T) {
+		is := require.New(t)
+		chain := newChain(entity.AggregateTransaction, 3)
+
+		broken, err := newLedger([]*entity.Event{chain[0], chain[2]}).Verify(context.Background(), nil)
+
+		is.Nil(err)
+		is.Equal(entity.AggregateTransaction, broken.AggregateType)
+		is.Equal(int64(3), broken.Sequence)
+		is.Equal("expected sequence 2, found 3", broken.Reason)
+	})
+
+	t.Run("should report a payment whose events were all removed", func(t *testing.T) {
+		is := require.New(t)
+		accounts := newChain(entity.AggregateAccount, 2)
+		transactions := newChain(entity.AggregateTransaction, 2)
+		later := newChain(entity.AggregateAccount, 1)
+		linkLedger(accounts, transactions, later)
+
+		broken, err := newLedger(append(accounts, later...)).Verify(context.Background(), nil)
+
+		is.Nil(err)
+		is.Equal(later[0].AggregateID, broken.AggregateID)
+		is.Equal("expected sequence 3, found 5", broken.Reason)
+	})
+
+	t.Run("should keep the ledgers of tenants apart", func(t *testing.T) {
+		is := require.New(t)
+		acme := newChain(entity.AggregateAccount, 2)
+		globex := newChain(entity.AggregateAccount, 2)
+
+		for _, event := range acme {
+			event.TenantID = "acme"
+		}
+
+		for _, event := range globex {
+			event.TenantID = "globex"
+		}
+
+		events := append(linkLedger(acme), linkLedger(globex)...)
+
+		broken, err := newLedger(events).Verify(context.Background(), nil)
+
+		is.Nil(err)
+		is.Nil(broken)
+	})
+
+	t.Run("should report a projection row edited behind the events", func(t *testing.T) {
+		is := require.New(t)
+		chain := newChain(entity.AggregateTransaction, 2)
+		transaction := &entity.Transaction{}
+		is.Nil(chain[1].Decode(transaction))
+		transaction.Amount = 1000000
+
+		rows := []mock.MockProjection{{AggregateID: transaction.ID, State: transaction.LedgerState(), Latest: chain[1]}}
+		broken, err := newLedgerWithProjection(chain, nil, rows).Verify(context.Background(), nil)
+
+		is.Nil(err)
+		is.Equal(entity.AggregateTransaction, broken.AggregateType)
+		is.Equal(chain[1].AggregateID, broken.AggregateID)
+		is.Equal(2, broken.Version)
+		is.Equal("projection does not match the last event", broken.Reason)
+	})
+
+	t.Run("should report a projection row without events", func(t *testing.T) {
+		is := require.New(t)
+		chain := newChain(entity.AggregateAccount, 1)
+		account, _ := entity.NewAccount(1000000)
+
+		rows := append(projectionOf(entity.AggregateAccount, chain), mock.MockProjection{AggregateID: account.ID, State: account.LedgerState()})
+		broken, err := newLedgerWithProjection(chain, rows, nil).Verify(context.Background(), nil)
+
+		is.Nil(err)
+		is.Equal(account.ID, broken.AggregateID)
+		is.Equal("projection has no events", broken.Reason)
+	})
+
+	t.Run("should report a removed projection row", func(t *testing.T) {
+		is := require.New(t)
+		chain := newChain(entity.AggregateAccount, 2)
+
+		rows := []mock.MockProjection{{AggregateID: chain[1].AggregateID, Latest: chain[1]}}
+		broken, err := newLedgerWithProjection(chain, rows, nil).Verify(context.Background(), nil)
+
+		is.Nil(err)
+		is.Equal(chain[1].AggregateID, broken.AggregateID)
+		is.Equal(2, broken.Version)
+		is.Equal("projection of the last event is missing", broken.Reason)
+	})
+
+	t.Run("should fail on iterate", func(t *testing.T) {
+		is := require.New(t)
+
+		eventRepo := mock.NewMockEventRepository()
+		eventRepo.On("Iterate").Return(nil, errors.New("db error"))
+
+		broken, err := service.NewLedger(eventRepo).Verify(context.Background(), nil)
+
+		is.NotNil(err)
+		is.Nil(broken)
+	})
+}
+
+func TestLedgerCheckpoint(t *testing.T) {
+	t.Parallel()
+
+	t.Run("should fail without a signing key", func(t *testing.T) {
+		is := require.New(t)
+
+		checkpoint, err := newLedger(newChain(entity.AggregateAccount, 1)).Checkpoint(context.Background())
+
+		is.NotNil(err)
+		is.Nil(checkpoint)
+	})
+
+	t.Run("should not checkpoint a broken chain", func(t *testing.T) {
+		is := require.New(t)
+		chain := newChain(entity.AggregateAccount, 2)
+		chain[0].Hash = ""
+
+		ledger := newLedger(chain)
+		ledger.SigningKey = newSigningKey()
+
+		checkpoint, err := ledger.Checkpoint(context.Background())
+
+		is.NotNil(err)
+		is.Nil(checkpoint)
+	})
+
+	t.Run("should sign the head of the ledger of every tenant", func(t *testing.T) {
+		is := require.New(t)
+		acme := linkLedger(newChain(entity.AggregateAccount, 3), newChain(entity.AggregateTransaction, 1))
+		globex := newChain(entity.AggregateAccount, 1)
+
+		for _, event := range acme {
+			event.TenantID = "acme"
+		}
+
+		globex[0].TenantID = "globex"
+
+		ledger := newLedger(append(linkLedger(acme), linkLedger(globex)...))
+		ledger.SigningKey = newSigningKey()
+
+		checkpoint, err := ledger.Checkpoint(context.Background())
+
+		is.Nil(err)
+		is.Len(checkpoint.Heads, 2)
+		is.Equal("acme", checkpoint.Heads[0].Tenant)
+		is.Equal(int64(4), checkpoint.Heads[0].Sequence)
+		is.Nil(checkpoint.VerifySignature(ledger.SigningKey.Public().(ed25519.PublicKey)))
+
+		ledger.TrustedKey = ledger.SigningKey.Public().(ed25519.PublicKey)
+		broken, err := ledger.Verify(context.Background(), checkpoint)
+
+		is.Nil(err)
+		is.Nil(broken)
+	})
+
+	t.Run("should report chains rewritten since the checkpoint", func(t *testing.T) {
+		is := require.New(t)
+		key := newSigningKey()
+		accounts := newChain(entity.AggregateAccount, 3)
+
+		ledger := newLedger(accounts)
+		ledger.SigningKey = key
+
+		checkpoint, err := ledger.Checkpoint(context.Background())
+		is.Nil(err)
+
+		rewritten := []*entity.Event{accounts[0], accounts[1]}
+		rewritten[1].Payload = `{"balance":1000000}`
+		rewritten[1].Link(rewritten[0])
+
+		ledger = newLedger(rewritten)
+		ledger.TrustedKey = key.Public().(ed25519.PublicKey)
+
+		broken, err := ledger.Verify(context.Background(), checkpoint)
+
+		is.Nil(err)
+		is.Equal(int64(3), broken.Sequence)
+		is.Equal("event recorded by the checkpoint is missing", broken.Reason)
+	})
+
+	t.Run("should reject checkpoints that were not signed by the trusted key", func(t *testing.T) {
+		is := require.New(t)
+		accounts := newChain(entity.AggregateAccount, 1)
+
+		ledger := newLedger(accounts)
+		ledger.SigningKey = newSigningKey()
+
+		checkpoint, err := ledger.Checkpoint(context.Background())
+		is.Nil(err)
+
+		ledger.TrustedKey = newSigningKey().Public().(ed25519.PublicKey)
+		_, err = ledger.Verify(context.Background(), checkpoint)
+		is.Equal(entity.ErrCheckpointUntrustedKey, err)
+
+		ledger.TrustedKey = ledger.SigningKey.Public().(ed25519.PublicKey)
+		checkpoint.Heads[0].Hash = "0000"
+		_, err = ledger.Verify(context.Background(), checkpoint)
+		is.Equal(entity.ErrCheckpointSignature, err)
+	})
+
+	t.Run("should only verify a checkpoint against the chains of its tenant", func(t *testing.T) {
+		is := require.New(t)
+		acme := entity.WithTenant(context.Background(), "acme")
+
+		ledger := newLedger(newChain(entity.AggregateAccount, 1))
+		ledger.SigningKey = newSigningKey()
+		ledger.TrustedKey = ledger.SigningKey.Public().(ed25519.PublicKey)
+
+		checkpoint, err := ledger.Checkpoint(acme)
+		is.Nil(err)
+		is.Equal("acme", checkpoint.Tenant)
+
+		broken, err := ledger.Verify(acme, checkpoint)
+		is.Nil(err)
+		is.Nil(broken)
+
+		_, err = ledger.Verify(entity.WithTenant(context.Background(), "globex"), checkpoint)
+		is.Equal(entity.ErrCheckpointTenant, err)
+
+		_, err = ledger.Verify(context.Background(), checkpoint)
+		is.Equal(entity.ErrCheckpointTenant, err)
+
+		checkpoint.Tenant = ""
+		_, err = ledger.Verify(context.Background(), checkpoint)
+		is.Equal(entity.ErrCheckpointSignature, err)
+	})
+
+	t.Run("should not trust the signing key to verify checkpoints", func(t *testing.T) {
+		is := require.New(t)
+
+		ledger := newLedger(newChain(entity.AggregateAccount, 1))
+		ledger.SigningKey = newSigningKey()
+
+		checkpoint, err := ledger.Checkpoint(context.Background())
+		is.Nil(err)
+
+		broken, err := ledger.Verify(context.Background(), checkpoint)
+		is.Equal(entity.ErrCheckpointNoTrustedKey, err)
+		is.Nil(broken)
+
+		forged := newSigningKey()
+		checkpoint.Sign(forged)
+
+		ledger.TrustedKey = ledger.SigningKey.Public().(ed25519.PublicKey)
+		_, err = ledger.Verify(context.Background(), checkpoint)
+		is.Equal(entity.ErrCheckpointUntrustedKey, err)
+	})
+}
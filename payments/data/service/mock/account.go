@@ -1,6 +1,7 @@
 package mock
 
 import (
+	"context"
 	"github.com/EdlanioJ/kbu/payments/domain/entity"
 	"github.com/stretchr/testify/mock"
 )
@@ -13,7 +14,7 @@ func NewMockAccountRepository() *MockAccountRepository {
 	return &MockAccountRepository{}
 }
 
-func (mock *MockAccountRepository) Find(id string) (*entity.Account, error) {
+func (mock *MockAccountRepository) Find(ctx context.Context, id string) (*entity.Account, error) {
 	args := mock.Called(id)
 
 	var res0 *entity.Account
@@ -34,7 +35,7 @@ func (mock *MockAccountRepository) Find(id string) (*entity.Account, error) {
 	}
 	return res0, res1
 }
-func (mock *MockAccountRepository) Save(account *entity.Account) error {
+func (mock *MockAccountRepository) Save(ctx context.Context, account *entity.Account) error {
 	args := mock.Called(account)
 
 	var res0 error
@@ -0,0 +1,26 @@
+package mock
+
+import (
+	"context"
+	"github.com/EdlanioJ/kbu/payments/domain/entity"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockAuditRepository struct {
+	mock.Mock
+}
+
+func NewMockAuditRepository() *MockAuditRepository {
+	return &MockAuditRepository{}
+}
+
+func (m *MockAuditRepository) FindAll(ctx context.Context, filter *entity.AuditFilter, pagination *entity.Pagination) ([]*entity.AuditEntry, int, error) {
+	args := m.Called(filter, pagination)
+
+	var res0 []*entity.AuditEntry
+	if args.Get(0) != nil {
+		res0 = args.Get(0).([]*entity.AuditEntry)
+	}
+
+	return res0, args.Int(1), args.Error(2)
+}
@@ -0,0 +1,83 @@
+package mock
+
+import (
+	"context"
+	"github.com/EdlanioJ/kbu/payments/data/repository"
+	"github.com/EdlanioJ/kbu/payments/domain/entity"
+	"github.com/stretchr/testify/mock"
+	"time"
+)
+
+type MockBatchRepository struct {
+	mock.Mock
+}
+
+func NewMockBatchRepository() *MockBatchRepository {
+	return &MockBatchRepository{}
+}
+
+func (m *MockBatchRepository) Register(ctx context.Context, batch *entity.Batch, items []*entity.BatchItem) error {
+	args := m.Called(batch, items)
+
+	return args.Error(0)
+}
+
+func (m *MockBatchRepository) Save(ctx context.Context, batch *entity.Batch, items []*entity.BatchItem) error {
+	args := m.Called(batch, items)
+
+	return args.Error(0)
+}
+
+func (m *MockBatchRepository) Claim(ctx context.Context, batch *entity.Batch, staleBefore time.Time) error {
+	args := m.Called(batch, staleBefore)
+
+	return args.Error(0)
+}
+
+func (m *MockBatchRepository) Find(ctx context.Context, id string) (*entity.Batch, []*entity.BatchItem, error) {
+	args := m.Called(id)
+
+	var res0 *entity.Batch
+	if args.Get(0) != nil {
+		res0 = args.Get(0).(*entity.Batch)
+	}
+
+	var res1 []*entity.BatchItem
+	if args.Get(1) != nil {
+		res1 = args.Get(1).([]*entity.BatchItem)
+	}
+
+	return res0, res1, args.Error(2)
+}
+
+func (m *MockBatchRepository) FindByIdempotencyKey(ctx context.Context, key string) (*entity.Batch, []*entity.BatchItem, error) {
+	args := m.Called(key)
+
+	var res0 *entity.Batch
+	if args.Get(0) != nil {
+		res0 = args.Get(0).(*entity.Batch)
+	}
+
+	var res1 []*entity.BatchItem
+	if args.Get(1) != nil {
+		res1 = args.Get(1).([]*entity.BatchItem)
+	}
+
+	return res0, res1, args.Error(2)
+}
+
+type MockUnitOfWork struct {
+	Transactions repository.TransactionRepository
+	Accounts     repository.AccountRepository
+}
+
+func NewMockUnitOfWork(transactions repository.TransactionRepository, accounts repository.AccountRepository) *MockUnitOfWork {
+	return &MockUnitOfWork{
+		Transactions: transactions,
+		Accounts:     accounts,
+	}
+}
+
+func (m *MockUnitOfWork) Do(ctx context.Context, fn func(transactions repository.TransactionRepository, accounts repository.AccountRepository) error) error {
+	return fn(m.Transactions, m.Accounts)
+}
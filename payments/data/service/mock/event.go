@@ -0,0 +1,69 @@
+package mock
+
+import (
+	"context"
+	"github.com/EdlanioJ/kbu/payments/domain/entity"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockEventRepository struct {
+	mock.Mock
+}
+
+func NewMockEventRepository() *MockEventRepository {
+	return &MockEventRepository{}
+}
+
+func (m *MockEventRepository) FindAllByAggregate(ctx context.Context, aggregateType, aggregateID string) ([]*entity.Event, error) {
+	args := m.Called(aggregateType, aggregateID)
+
+	var res0 []*entity.Event
+	if args.Get(0) != nil {
+		res0 = args.Get(0).([]*entity.Event)
+	}
+
+	return res0, args.Error(1)
+}
+
+func (m *MockEventRepository) Rebuild(ctx context.Context, aggregateType string, truncate bool) (int, error) {
+	args := m.Called(aggregateType, truncate)
+
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockEventRepository) Iterate(ctx context.Context, fn func(event *entity.Event) error) error {
+	args := m.Called()
+
+	if events, ok := args.Get(0).([]*entity.Event); ok {
+		for _, event := range events {
+			if err := fn(event); err != nil {
+				return err
+			}
+		}
+	}
+
+	return args.Error(1)
+}
+
+// MockProjection is a projection row handed to IterateProjection callbacks:
+// State is empty when the aggregate has no row, Latest nil when it has no
+// events.
+type MockProjection struct {
+	AggregateID string
+	State       string
+	Latest      *entity.Event
+}
+
+func (m *MockEventRepository) IterateProjection(ctx context.Context, aggregateType string, fn func(aggregateID, state string, latest *entity.Event) error) error {
+	args := m.Called(aggregateType)
+
+	if rows, ok := args.Get(0).([]MockProjection); ok {
+		for _, row := range rows {
+			if err := fn(row.AggregateID, row.State, row.Latest); err != nil {
+				return err
+			}
+		}
+	}
+
+	return args.Error(1)
+}
@@ -0,0 +1,15 @@
+package mock
+
+import "github.com/stretchr/testify/mock"
+
+type MockTransactionMetrics struct {
+	mock.Mock
+}
+
+func NewMockTransactionMetrics() *MockTransactionMetrics {
+	return &MockTransactionMetrics{}
+}
+
+func (m *MockTransactionMetrics) RegisterFailed(reason string) {
+	m.Called(reason)
+}
@@ -0,0 +1,88 @@
+package mock
+
+import (
+	"context"
+	"time"
+
+	"github.com/EdlanioJ/kbu/payments/domain/entity"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockSagaRepository struct {
+	mock.Mock
+}
+
+func NewMockSagaRepository() *MockSagaRepository {
+	return &MockSagaRepository{}
+}
+
+func (m *MockSagaRepository) Register(ctx context.Context, saga *entity.Saga) error {
+	args := m.Called(saga)
+
+	return args.Error(0)
+}
+
+func (m *MockSagaRepository) Save(ctx context.Context, saga *entity.Saga, owner string) error {
+	args := m.Called(saga, owner)
+
+	return args.Error(0)
+}
+
+func (m *MockSagaRepository) Find(ctx context.Context, id string) (*entity.Saga, error) {
+	args := m.Called(id)
+
+	var res0 *entity.Saga
+	if args.Get(0) != nil {
+		res0 = args.Get(0).(*entity.Saga)
+	}
+
+	return res0, args.Error(1)
+}
+
+func (m *MockSagaRepository) FindByIdempotencyKey(ctx context.Context, key string) (*entity.Saga, error) {
+	args := m.Called(key)
+
+	var res0 *entity.Saga
+	if args.Get(0) != nil {
+		res0 = args.Get(0).(*entity.Saga)
+	}
+
+	return res0, args.Error(1)
+}
+
+func (m *MockSagaRepository) FindAllInFlight(ctx context.Context, updatedBefore time.Time, limit int) ([]*entity.Saga, error) {
+	args := m.Called(updatedBefore, limit)
+
+	var res0 []*entity.Saga
+	if args.Get(0) != nil {
+		res0 = args.Get(0).([]*entity.Saga)
+	}
+
+	return res0, args.Error(1)
+}
+
+func (m *MockSagaRepository) Claim(ctx context.Context, saga *entity.Saga, owner string, until time.Time) (bool, error) {
+	args := m.Called(saga, owner)
+
+	return args.Bool(0), args.Error(1)
+}
+
+type MockServiceProvider struct {
+	mock.Mock
+}
+
+func NewMockServiceProvider() *MockServiceProvider {
+	return &MockServiceProvider{}
+}
+
+func (m *MockServiceProvider) Charge(transaction *entity.Transaction) (string, error) {
+	args := m.Called(transaction)
+
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockServiceProvider) Refund(transaction *entity.Transaction) error {
+	args := m.Called(transaction)
+
+	return args.Error(0)
+}
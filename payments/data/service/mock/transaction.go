@@ -1,6 +1,7 @@
 package mock
 
 import (
+	"context"
 	"github.com/EdlanioJ/kbu/payments/domain/entity"
 	"github.com/stretchr/testify/mock"
 )
@@ -13,7 +14,7 @@ func NewMockTransactionRepository() *MockTransactionRepository {
 	return &MockTransactionRepository{}
 }
 
-func (m *MockTransactionRepository) Register(transaction *entity.Transaction) error {
+func (m *MockTransactionRepository) Register(ctx context.Context, transaction *entity.Transaction) error {
 
 	args := m.Called(transaction)
 
@@ -27,7 +28,7 @@ func (m *MockTransactionRepository) Register(transaction *entity.Transaction) er
 	return res0
 }
 
-func (m *MockTransactionRepository) Save(transaction *entity.Transaction) error {
+func (m *MockTransactionRepository) Save(ctx context.Context, transaction *entity.Transaction) error {
 	args := m.Called(transaction)
 
 	var res0 error
@@ -40,7 +41,7 @@ func (m *MockTransactionRepository) Save(transaction *entity.Transaction) error
 	return res0
 }
 
-func (m *MockTransactionRepository) Find(id string) (*entity.Transaction, error) {
+func (m *MockTransactionRepository) Find(ctx context.Context, id string) (*entity.Transaction, error) {
 	args := m.Called(id)
 
 	var res0 *entity.Transaction
@@ -62,7 +63,29 @@ func (m *MockTransactionRepository) Find(id string) (*entity.Transaction, error)
 	return res0, res1
 }
 
-func (m *MockTransactionRepository) FindAll(pagination *entity.Pagination) ([]*entity.Transaction, int, error) {
+func (m *MockTransactionRepository) FindByIdempotencyKey(ctx context.Context, key string) (*entity.Transaction, error) {
+	args := m.Called(key)
+
+	var res0 *entity.Transaction
+
+	if rf, ok := args.Get(0).(func() *entity.Transaction); ok {
+		res0 = rf()
+	} else {
+		if args.Get(0) != nil {
+			res0 = args.Get(0).(*entity.Transaction)
+		}
+	}
+
+	var res1 error
+	if rf, ok := args.Get(1).(func() error); ok {
+		res1 = rf()
+	} else {
+		res1 = args.Error(1)
+	}
+	return res0, res1
+}
+
+func (m *MockTransactionRepository) FindAll(ctx context.Context, pagination *entity.Pagination) ([]*entity.Transaction, int, error) {
 	args := m.Called(pagination)
 
 	res0 := []*entity.Transaction{}
@@ -93,7 +116,7 @@ func (m *MockTransactionRepository) FindAll(pagination *entity.Pagination) ([]*e
 	return res0, res1, res2
 }
 
-func (m *MockTransactionRepository) FindByType(transactionID, transactionType string) (*entity.Transaction, error) {
+func (m *MockTransactionRepository) FindByType(ctx context.Context, transactionID, transactionType string) (*entity.Transaction, error) {
 	args := m.Called(transactionID, transactionType)
 
 	var res0 *entity.Transaction
@@ -114,7 +137,7 @@ func (m *MockTransactionRepository) FindByType(transactionID, transactionType st
 	return res0, res1
 }
 
-func (m *MockTransactionRepository) FindAllByType(transactionType string, pagination *entity.Pagination) ([]*entity.Transaction, int, error) {
+func (m *MockTransactionRepository) FindAllByType(ctx context.Context, transactionType string, pagination *entity.Pagination) ([]*entity.Transaction, int, error) {
 	args := m.Called(transactionType, pagination)
 
 	res0 := []*entity.Transaction{}
@@ -143,7 +166,7 @@ func (m *MockTransactionRepository) FindAllByType(transactionType string, pagina
 	return res0, res1, res2
 }
 
-func (m *MockTransactionRepository) FindByExternalID(transactionID, externalID string) (*entity.Transaction, error) {
+func (m *MockTransactionRepository) FindByExternalID(ctx context.Context, transactionID, externalID string) (*entity.Transaction, error) {
 	args := m.Called(transactionID, externalID)
 
 	var res0 *entity.Transaction
@@ -164,7 +187,7 @@ func (m *MockTransactionRepository) FindByExternalID(transactionID, externalID s
 	return res0, res1
 }
 
-func (m *MockTransactionRepository) FindAllByExternalID(externalID string, pagination *entity.Pagination) ([]*entity.Transaction, int, error) {
+func (m *MockTransactionRepository) FindAllByExternalID(ctx context.Context, externalID string, pagination *entity.Pagination) ([]*entity.Transaction, int, error) {
 	args := m.Called(externalID, pagination)
 	res0 := []*entity.Transaction{}
 	if rf, ok := args.Get(0).(func() []*entity.Transaction); ok {
@@ -192,7 +215,7 @@ func (m *MockTransactionRepository) FindAllByExternalID(externalID string, pagin
 	return res0, res1, res2
 }
 
-func (m *MockTransactionRepository) FindByFromAccountID(transactionID, accountID string) (*entity.Transaction, error) {
+func (m *MockTransactionRepository) FindByFromAccountID(ctx context.Context, transactionID, accountID string) (*entity.Transaction, error) {
 	args := m.Called(transactionID, accountID)
 
 	var res0 *entity.Transaction
@@ -213,7 +236,7 @@ func (m *MockTransactionRepository) FindByFromAccountID(transactionID, accountID
 	return res0, res1
 }
 
-func (m *MockTransactionRepository) FindAllByFromAccountID(accountID string, pagination *entity.Pagination) ([]*entity.Transaction, int, error) {
+func (m *MockTransactionRepository) FindAllByFromAccountID(ctx context.Context, accountID string, pagination *entity.Pagination) ([]*entity.Transaction, int, error) {
 	args := m.Called(accountID, pagination)
 
 	res0 := []*entity.Transaction{}
@@ -242,7 +265,7 @@ func (m *MockTransactionRepository) FindAllByFromAccountID(accountID string, pag
 	return res0, res1, res2
 }
 
-func (m *MockTransactionRepository) FindByToAccountID(transactionID, accountID string) (*entity.Transaction, error) {
+func (m *MockTransactionRepository) FindByToAccountID(ctx context.Context, transactionID, accountID string) (*entity.Transaction, error) {
 	args := m.Called(transactionID, accountID)
 
 	var res0 *entity.Transaction
@@ -263,7 +286,7 @@ func (m *MockTransactionRepository) FindByToAccountID(transactionID, accountID s
 	return res0, res1
 }
 
-func (m *MockTransactionRepository) FindAllByToAccountID(accountID string, pagination *entity.Pagination) ([]*entity.Transaction, int, error) {
+func (m *MockTransactionRepository) FindAllByToAccountID(ctx context.Context, accountID string, pagination *entity.Pagination) ([]*entity.Transaction, int, error) {
 	args := m.Called(accountID, pagination)
 	res0 := []*entity.Transaction{}
 	if rf, ok := args.Get(0).(func() []*entity.Transaction); ok {
@@ -290,3 +313,39 @@ func (m *MockTransactionRepository) FindAllByToAccountID(accountID string, pagin
 
 	return res0, res1, res2
 }
+
+func (m *MockTransactionRepository) Iterate(ctx context.Context, filter *entity.TransactionFilter, after *entity.ExportCursor, fn func(transaction *entity.Transaction) error) error {
+	args := m.Called(filter, after)
+
+	if transactions, ok := args.Get(0).([]*entity.Transaction); ok {
+		for _, transaction := range transactions {
+			if err := fn(transaction); err != nil {
+				return err
+			}
+		}
+	}
+
+	return args.Error(1)
+}
+
+func (m *MockTransactionRepository) Totals(ctx context.Context) ([]*entity.TransactionTotals, error) {
+	args := m.Called()
+
+	var res0 []*entity.TransactionTotals
+	if rf, ok := args.Get(0).([]*entity.TransactionTotals); ok {
+		res0 = rf
+	}
+
+	return res0, args.Error(1)
+}
+
+func (m *MockTransactionRepository) OldestPending(ctx context.Context) (*entity.Transaction, error) {
+	args := m.Called()
+
+	var res0 *entity.Transaction
+	if rf, ok := args.Get(0).(*entity.Transaction); ok {
+		res0 = rf
+	}
+
+	return res0, args.Error(1)
+}
@@ -0,0 +1,123 @@
+package mock
+
+import (
+	"context"
+	"time"
+
+	"github.com/EdlanioJ/kbu/payments/data/repository"
+	"github.com/EdlanioJ/kbu/payments/domain/entity"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockWebhookRepository struct {
+	mock.Mock
+}
+
+func NewMockWebhookRepository() *MockWebhookRepository {
+	return &MockWebhookRepository{}
+}
+
+func (m *MockWebhookRepository) Register(ctx context.Context, webhook *entity.Webhook) error {
+	args := m.Called(webhook)
+
+	return args.Error(0)
+}
+
+func (m *MockWebhookRepository) Find(ctx context.Context, id string) (*entity.Webhook, error) {
+	args := m.Called(id)
+
+	var res0 *entity.Webhook
+	if args.Get(0) != nil {
+		res0 = args.Get(0).(*entity.Webhook)
+	}
+
+	return res0, args.Error(1)
+}
+
+func (m *MockWebhookRepository) FindAllByAccountID(ctx context.Context, accountID string) ([]*entity.Webhook, error) {
+	args := m.Called(accountID)
+
+	var res0 []*entity.Webhook
+	if args.Get(0) != nil {
+		res0 = args.Get(0).([]*entity.Webhook)
+	}
+
+	return res0, args.Error(1)
+}
+
+type MockWebhookDeliveryRepository struct {
+	mock.Mock
+}
+
+func NewMockWebhookDeliveryRepository() *MockWebhookDeliveryRepository {
+	return &MockWebhookDeliveryRepository{}
+}
+
+func (m *MockWebhookDeliveryRepository) Register(ctx context.Context, delivery *entity.WebhookDelivery) error {
+	args := m.Called(delivery)
+
+	return args.Error(0)
+}
+
+func (m *MockWebhookDeliveryRepository) Save(ctx context.Context, delivery *entity.WebhookDelivery) error {
+	args := m.Called(delivery)
+
+	return args.Error(0)
+}
+
+func (m *MockWebhookDeliveryRepository) Find(ctx context.Context, id string) (*entity.WebhookDelivery, error) {
+	args := m.Called(id)
+
+	var res0 *entity.WebhookDelivery
+	if args.Get(0) != nil {
+		res0 = args.Get(0).(*entity.WebhookDelivery)
+	}
+
+	return res0, args.Error(1)
+}
+
+func (m *MockWebhookDeliveryRepository) FindAllDue(ctx context.Context, now time.Time, limit int) ([]*entity.WebhookDelivery, error) {
+	args := m.Called(now, limit)
+
+	var res0 []*entity.WebhookDelivery
+	if args.Get(0) != nil {
+		res0 = args.Get(0).([]*entity.WebhookDelivery)
+	}
+
+	return res0, args.Error(1)
+}
+
+func (m *MockWebhookDeliveryRepository) RegisterAttempt(ctx context.Context, attempt *entity.WebhookAttempt) error {
+	args := m.Called(attempt)
+
+	return args.Error(0)
+}
+
+func (m *MockWebhookDeliveryRepository) FindAllAttempts(ctx context.Context, deliveryID string) ([]*entity.WebhookAttempt, error) {
+	args := m.Called(deliveryID)
+
+	var res0 []*entity.WebhookAttempt
+	if args.Get(0) != nil {
+		res0 = args.Get(0).([]*entity.WebhookAttempt)
+	}
+
+	return res0, args.Error(1)
+}
+
+type MockConfirmationUnitOfWork struct {
+	Transactions repository.TransactionRepository
+	Webhooks     repository.WebhookRepository
+	Deliveries   repository.WebhookDeliveryRepository
+}
+
+func NewMockConfirmationUnitOfWork(transactions repository.TransactionRepository, webhooks repository.WebhookRepository, deliveries repository.WebhookDeliveryRepository) *MockConfirmationUnitOfWork {
+	return &MockConfirmationUnitOfWork{
+		Transactions: transactions,
+		Webhooks:     webhooks,
+		Deliveries:   deliveries,
+	}
+}
+
+func (m *MockConfirmationUnitOfWork) Do(ctx context.Context, fn func(transactions repository.TransactionRepository, webhooks repository.WebhookRepository, deliveries repository.WebhookDeliveryRepository) error) error {
+	return fn(m.Transactions, m.Webhooks, m.Deliveries)
+}
@@ -0,0 +1,457 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/EdlanioJ/kbu/payments/data/protocol"
+	"github.com/EdlanioJ/kbu/payments/data/repository"
+	"github.com/EdlanioJ/kbu/payments/domain/entity"
+	uuid "github.com/satori/go.uuid"
+)
+
+var (
+	errSagaTimeout        = errors.New("the service provider did not answer in time")
+	errSagaUnknownStep    = errors.New("unknown saga step")
+	errSagaAccountFrom    = errors.New("no account from was found")
+	errSagaAccountTo      = errors.New("no account destination was found")
+	errSagaNoTransaction  = errors.New("no reserved payment was found")
+	errSagaProviderResult = errors.New("the service provider did not return a reference")
+)
+
+type providerResult struct {
+	reference string
+	err       error
+}
+
+type Saga struct {
+	SagaRepository        repository.SagaRepository
+	TransactionRepository repository.TransactionRepository
+	AccountRepository     repository.AccountRepository
+	UnitOfWork            repository.UnitOfWork
+	Provider              protocol.ServiceProvider
+	Notifier              protocol.TransactionNotifier
+	Timeout               time.Duration
+	StaleAfter            time.Duration
+	Owner                 string
+	LeaseFor              time.Duration
+}
+
+func NewSaga(
+	SagaRepository repository.SagaRepository,
+	TransactionRepository repository.TransactionRepository,
+	AccountRepository repository.AccountRepository,
+	UnitOfWork repository.UnitOfWork,
+	Provider protocol.ServiceProvider,
+) *Saga {
+
+	return &Saga{
+		SagaRepository:        SagaRepository,
+		TransactionRepository: TransactionRepository,
+		AccountRepository:     AccountRepository,
+		UnitOfWork:            UnitOfWork,
+		Provider:              Provider,
+		Timeout:               30 * time.Second,
+		StaleAfter:            time.Minute,
+		Owner:                 uuid.NewV4().String(),
+		LeaseFor:              2 * time.Minute,
+	}
+}
+
+func (s *Saga) Start(ctx context.Context, fromAccount, toAccount, externalID, currency string, amount float64) (*entity.Saga, error) {
+	return s.start(ctx, "", fromAccount, toAccount, externalID, currency, amount)
+}
+
+// StartIdempotent starts a service payment at most once per key. A retry with
+// the same key and payload gets the saga started by the first call, a
+// different payload is rejected as a conflict.
+func (s *Saga) StartIdempotent(ctx context.Context, key, fromAccount, toAccount, externalID, currency string, amount float64) (*entity.Saga, error) {
+	if key == "" {
+		return s.Start(ctx, fromAccount, toAccount, externalID, currency, amount)
+	}
+
+	saga, err := s.replay(ctx, key, fromAccount, toAccount, externalID, currency, amount)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if saga != nil {
+		return saga, nil
+	}
+
+	saga, err = s.start(ctx, key, fromAccount, toAccount, externalID, currency, amount)
+
+	if entity.IsErrorKind(err, entity.ErrorConflict) {
+		replayed, replayErr := s.replay(ctx, key, fromAccount, toAccount, externalID, currency, amount)
+
+		if replayErr != nil {
+			return nil, replayErr
+		}
+
+		if replayed != nil {
+			return replayed, nil
+		}
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return saga, nil
+}
+
+func (s *Saga) replay(ctx context.Context, key, fromAccount, toAccount, externalID, currency string, amount float64) (*entity.Saga, error) {
+	saga, err := s.SagaRepository.FindByIdempotencyKey(ctx, key)
+
+	if entity.IsErrorKind(err, entity.ErrorNotFound) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !saga.SameRequest(fromAccount, toAccount, externalID, currency, amount) {
+		return nil, entity.Conflict("service payment", saga.ID, "idempotency key was already used for a different service payment")
+	}
+
+	if saga.InFlight() {
+		return nil, entity.Conflict("service payment", saga.ID, "service payment is being processed")
+	}
+
+	return saga, nil
+}
+
+func (s *Saga) start(ctx context.Context, key, fromAccount, toAccount, externalID, currency string, amount float64) (*entity.Saga, error) {
+	saga, err := entity.NewSaga(fromAccount, toAccount, externalID, currency, amount, s.Timeout)
+
+	if err != nil {
+		return nil, err
+	}
+
+	saga.IdempotencyKey = key
+	saga.Lease(s.Owner, time.Now().Add(s.LeaseFor))
+
+	err = s.SagaRepository.Register(ctx, saga)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return s.run(ctx, saga)
+}
+
+func (s *Saga) Resume(ctx context.Context, sagaID string) (*entity.Saga, error) {
+	saga, err := s.SagaRepository.Find(ctx, sagaID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	claimed, err := s.SagaRepository.Claim(ctx, saga, s.Owner, time.Now().Add(s.LeaseFor))
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !claimed {
+		return nil, entity.Conflict("service payment", sagaID, "service payment is being processed")
+	}
+
+	return s.run(ctx, saga)
+}
+
+func (s *Saga) Recover(ctx context.Context, limit int) (int, error) {
+	sagas, err := s.SagaRepository.FindAllInFlight(ctx, time.Now().Add(-s.StaleAfter), limit)
+
+	if err != nil {
+		return 0, err
+	}
+
+	recovered := 0
+
+	for _, saga := range sagas {
+		claimed, err := s.SagaRepository.Claim(ctx, saga, s.Owner, time.Now().Add(s.LeaseFor))
+
+		if err != nil || !claimed {
+			continue
+		}
+
+		_, err = s.run(ctx, saga)
+
+		if err == nil {
+			recovered++
+		}
+	}
+
+	return recovered, nil
+}
+
+func (s *Saga) Find(ctx context.Context, sagaID string) (*entity.Saga, error) {
+	saga, err := s.SagaRepository.Find(ctx, sagaID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return saga, nil
+}
+
+func (s *Saga) run(ctx context.Context, saga *entity.Saga) (*entity.Saga, error) {
+	for saga.InFlight() {
+		var retryErr error
+
+		if saga.Expired(time.Now()) {
+			saga.Compensate(errSagaTimeout)
+		} else {
+			err := s.execute(ctx, saga)
+
+			if ctx.Err() != nil {
+				// Stopped mid step; the recoverer picks the saga up once
+				// its lease expires.
+				return saga, ctx.Err()
+			}
+
+			switch {
+			case err == nil:
+				saga.Advance()
+			case saga.Status == entity.SagaCompensating:
+				saga.Retry(err)
+				retryErr = err
+			default:
+				saga.Compensate(err)
+			}
+		}
+
+		if saga.InFlight() && retryErr == nil {
+			saga.Lease(s.Owner, time.Now().Add(s.LeaseFor))
+		} else {
+			saga.ReleaseLease()
+		}
+
+		// A conflict means the lease ran out and another instance claimed the
+		// saga, it drives the saga from here on.
+		err := s.SagaRepository.Save(ctx, saga, s.Owner)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if retryErr != nil {
+			return saga, retryErr
+		}
+	}
+
+	return saga, nil
+}
+
+func (s *Saga) execute(ctx context.Context, saga *entity.Saga) error {
+	switch saga.Step {
+	case entity.SagaStepReserve:
+		return s.reserve(ctx, saga)
+	case entity.SagaStepCallProvider:
+		return s.callProvider(ctx, saga)
+	case entity.SagaStepCapture:
+		return s.capture(ctx, saga)
+	case entity.SagaStepRefund:
+		return s.refund(ctx, saga)
+	case entity.SagaStepRelease:
+		return s.release(ctx, saga)
+	}
+
+	return errSagaUnknownStep
+}
+
+func (s *Saga) reserve(ctx context.Context, saga *entity.Saga) error {
+	transaction, err := s.TransactionRepository.Find(ctx, saga.TransactionID)
+
+	if err == nil && transaction != nil {
+		return nil
+	}
+
+	accountFrom, err := s.AccountRepository.Find(ctx, saga.AccountFromID)
+
+	if err != nil {
+		return err
+	}
+
+	if accountFrom == nil {
+		return errSagaAccountFrom
+	}
+
+	accountTo, err := s.AccountRepository.Find(ctx, saga.AccountToID)
+
+	if err != nil {
+		return err
+	}
+
+	if accountTo == nil {
+		return errSagaAccountTo
+	}
+
+	err = accountFrom.Withdow(saga.Amount)
+
+	if err != nil {
+		return err
+	}
+
+	transaction, err = entity.NewTransaction(accountFrom, accountTo, saga.ExternalID, entity.TransactionToService, saga.Currency, saga.Amount)
+
+	if err != nil {
+		return err
+	}
+
+	transaction.ID = saga.TransactionID
+
+	// The payment and the debit commit together, so a reserved payment always
+	// has its funds held.
+	err = s.UnitOfWork.Do(ctx, func(transactions repository.TransactionRepository, accounts repository.AccountRepository) error {
+		err := transactions.Register(ctx, transaction)
+
+		if err != nil {
+			return err
+		}
+
+		return accounts.Save(ctx, accountFrom)
+	})
+
+	if err != nil {
+		return err
+	}
+
+	s.notify(transaction)
+
+	return nil
+}
+
+func (s *Saga) callProvider(ctx context.Context, saga *entity.Saga) error {
+	transaction, err := s.reserved(ctx, saga)
+
+	if err != nil {
+		return err
+	}
+
+	result := make(chan providerResult, 1)
+
+	go func() {
+		reference, err := s.Provider.Charge(transaction)
+		result <- providerResult{reference: reference, err: err}
+	}()
+
+	timer := time.NewTimer(time.Until(saga.ExpiresAt))
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return errSagaTimeout
+	case res := <-result:
+		if res.err != nil {
+			return res.err
+		}
+
+		if res.reference == "" {
+			return errSagaProviderResult
+		}
+
+		saga.ProviderReference = res.reference
+		return nil
+	}
+}
+
+func (s *Saga) capture(ctx context.Context, saga *entity.Saga) error {
+	transaction, err := s.reserved(ctx, saga)
+
+	if err != nil {
+		return err
+	}
+
+	transaction.Status = entity.TransactionCompleted
+
+	err = s.TransactionRepository.Save(ctx, transaction)
+
+	if err != nil {
+		return err
+	}
+
+	s.notify(transaction)
+
+	return nil
+}
+
+func (s *Saga) refund(ctx context.Context, saga *entity.Saga) error {
+	transaction, err := s.reserved(ctx, saga)
+
+	if err != nil {
+		return err
+	}
+
+	return s.Provider.Refund(transaction)
+}
+
+func (s *Saga) release(ctx context.Context, saga *entity.Saga) error {
+	transaction, err := s.reserved(ctx, saga)
+
+	if err != nil {
+		return err
+	}
+
+	if transaction.Status == entity.TransactionCanceled {
+		return nil
+	}
+
+	accountFrom, err := s.AccountRepository.Find(ctx, saga.AccountFromID)
+
+	if err != nil {
+		return err
+	}
+
+	err = accountFrom.Deposit(saga.Amount)
+
+	if err != nil {
+		return err
+	}
+
+	transaction.Status = entity.TransactionCanceled
+
+	err = s.UnitOfWork.Do(ctx, func(transactions repository.TransactionRepository, accounts repository.AccountRepository) error {
+		err := accounts.Save(ctx, accountFrom)
+
+		if err != nil {
+			return err
+		}
+
+		return transactions.Save(ctx, transaction)
+	})
+
+	if err != nil {
+		return err
+	}
+
+	s.notify(transaction)
+
+	return nil
+}
+
+func (s *Saga) reserved(ctx context.Context, saga *entity.Saga) (*entity.Transaction, error) {
+	transaction, err := s.TransactionRepository.Find(ctx, saga.TransactionID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if transaction == nil {
+		return nil, errSagaNoTransaction
+	}
+
+	return transaction, nil
+}
+
+func (s *Saga) notify(transaction *entity.Transaction) {
+	if s.Notifier != nil {
+		s.Notifier.Publish(transaction)
+	}
+}
@@ -0,0 +1,366 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/EdlanioJ/kbu/payments/data/service"
+	"github.com/EdlanioJ/kbu/payments/data/service/mock"
+	"github.com/EdlanioJ/kbu/payments/domain/entity"
+	uuid "github.com/satori/go.uuid"
+	tMock "github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type sagaTestMocks struct {
+	sagaRepo        *mock.MockSagaRepository
+	transactionRepo *mock.MockTransactionRepository
+	accountRepo     *mock.MockAccountRepository
+	provider        *mock.MockServiceProvider
+	accountFrom     *entity.Account
+	accountTo       *entity.Account
+	transaction     *entity.Transaction
+}
+
+func newSagaTestMocks(balance float64) *sagaTestMocks {
+	m := &sagaTestMocks{
+		sagaRepo:        mock.NewMockSagaRepository(),
+		transactionRepo: mock.NewMockTransactionRepository(),
+		accountRepo:     mock.NewMockAccountRepository(),
+		provider:        mock.NewMockServiceProvider(),
+	}
+
+	m.accountFrom, _ = entity.NewAccount(balance)
+	m.accountTo, _ = entity.NewAccount(0)
+
+	m.sagaRepo.On("Register", tMock.Anything).Return(nil)
+	m.sagaRepo.On("Save", tMock.Anything, tMock.Anything).Return(nil)
+	m.accountRepo.On("Find", m.accountFrom.ID).Return(m.accountFrom, nil)
+	m.accountRepo.On("Find", m.accountTo.ID).Return(m.accountTo, nil)
+	m.accountRepo.On("Save", tMock.Anything).Return(nil)
+
+	m.transactionRepo.On("Register", tMock.Anything).Return(nil).Run(func(args tMock.Arguments) {
+		m.transaction = args.Get(0).(*entity.Transaction)
+	})
+	m.transactionRepo.On("Save", tMock.Anything).Return(nil)
+	m.transactionRepo.On("Find", tMock.Anything).Return(
+		func() *entity.Transaction { return m.transaction },
+		func() error {
+			if m.transaction == nil {
+				return errors.New("record not found")
+			}
+			return nil
+		},
+	)
+
+	return m
+}
+
+func (m *sagaTestMocks) service() *service.Saga {
+	return service.NewSaga(m.sagaRepo, m.transactionRepo, m.accountRepo, mock.NewMockUnitOfWork(m.transactionRepo, m.accountRepo), m.provider)
+}
+
+func TestSagaStart(t *testing.T) {
+	t.Parallel()
+
+	t.Run("should reserve, charge and capture the payment", func(t *testing.T) {
+		is := require.New(t)
+		m := newSagaTestMocks(1000)
+		m.provider.On("Charge", tMock.Anything).Return("ref-1", nil)
+
+		saga, err := m.service().Start(context.Background(), m.accountFrom.ID, m.accountTo.ID, uuid.NewV4().String(), "AOA", 300)
+
+		is.Nil(err)
+		is.Equal(entity.SagaCompleted, saga.Status)
+		is.Equal("ref-1", saga.ProviderReference)
+		is.Equal(saga.TransactionID, m.transaction.ID)
+		is.Equal(entity.TransactionCompleted, m.transaction.Status)
+		is.Equal(entity.TransactionToService, m.transaction.Type)
+		is.Equal(700.0, m.accountFrom.Balance)
+		m.provider.AssertNotCalled(t, "Refund", tMock.Anything)
+	})
+
+	t.Run("should refund and release the funds when the provider fails", func(t *testing.T) {
+		is := require.New(t)
+		m := newSagaTestMocks(1000)
+		m.provider.On("Charge", tMock.Anything).Return("", errors.New("provider unavailable"))
+		m.provider.On("Refund", tMock.Anything).Return(nil)
+
+		saga, err := m.service().Start(context.Background(), m.accountFrom.ID, m.accountTo.ID, uuid.NewV4().String(), "AOA", 300)
+
+		is.Nil(err)
+		is.Equal(entity.SagaCompensated, saga.Status)
+		is.Equal("provider unavailable", saga.Error)
+		is.Equal(entity.TransactionCanceled, m.transaction.Status)
+		is.Equal(1000.0, m.accountFrom.Balance)
+		m.provider.AssertNumberOfCalls(t, "Refund", 1)
+	})
+
+	t.Run("should compensate when the provider times out", func(t *testing.T) {
+		is := require.New(t)
+		m := newSagaTestMocks(1000)
+		m.provider.On("Charge", tMock.Anything).Return("ref-1", nil).After(500 * time.Millisecond)
+		m.provider.On("Refund", tMock.Anything).Return(nil)
+
+		sagaService := m.service()
+		sagaService.Timeout = 20 * time.Millisecond
+
+		saga, err := sagaService.Start(context.Background(), m.accountFrom.ID, m.accountTo.ID, uuid.NewV4().String(), "AOA", 300)
+
+		is.Nil(err)
+		is.Equal(entity.SagaCompensated, saga.Status)
+		is.Empty(saga.ProviderReference)
+		is.Equal(1000.0, m.accountFrom.Balance)
+		m.provider.AssertNumberOfCalls(t, "Refund", 1)
+	})
+
+	t.Run("should not call the provider when funds cannot be reserved", func(t *testing.T) {
+		is := require.New(t)
+		m := newSagaTestMocks(100)
+
+		saga, err := m.service().Start(context.Background(), m.accountFrom.ID, m.accountTo.ID, uuid.NewV4().String(), "AOA", 300)
+
+		is.Nil(err)
+		is.Equal(entity.SagaCompensated, saga.Status)
+		is.Equal("account does not have balance", saga.Error)
+		is.Nil(m.transaction)
+		m.provider.AssertNotCalled(t, "Charge", tMock.Anything)
+		m.provider.AssertNotCalled(t, "Refund", tMock.Anything)
+	})
+
+	t.Run("should stay compensating when the refund fails", func(t *testing.T) {
+		is := require.New(t)
+		m := newSagaTestMocks(1000)
+		m.provider.On("Charge", tMock.Anything).Return("", errors.New("provider unavailable"))
+		m.provider.On("Refund", tMock.Anything).Return(errors.New("refund failed"))
+
+		saga, err := m.service().Start(context.Background(), m.accountFrom.ID, m.accountTo.ID, uuid.NewV4().String(), "AOA", 300)
+
+		is.NotNil(err)
+		is.Equal(entity.SagaCompensating, saga.Status)
+		is.Equal(entity.SagaStepRefund, saga.Step)
+		is.Equal(1, saga.Attempts)
+		is.Equal(700.0, m.accountFrom.Balance)
+	})
+
+	t.Run("should not keep the payment when the debit fails", func(t *testing.T) {
+		is := require.New(t)
+		m := newSagaTestMocks(1000)
+		accountRepo := mock.NewMockAccountRepository()
+		accountRepo.On("Find", m.accountFrom.ID).Return(m.accountFrom, nil)
+		accountRepo.On("Find", m.accountTo.ID).Return(m.accountTo, nil)
+		accountRepo.On("Save", tMock.Anything).Return(errors.New("db error"))
+		m.accountRepo = accountRepo
+
+		saga, err := m.service().Start(context.Background(), m.accountFrom.ID, m.accountTo.ID, uuid.NewV4().String(), "AOA", 300)
+
+		is.Nil(err)
+		is.Equal(entity.SagaCompensated, saga.Status)
+		is.Equal("db error", saga.Error)
+		m.transactionRepo.AssertNumberOfCalls(t, "Register", 1)
+		m.transactionRepo.AssertNotCalled(t, "Save", tMock.Anything)
+		m.provider.AssertNotCalled(t, "Charge", tMock.Anything)
+	})
+
+	t.Run("should stop waiting for the provider when the context is canceled", func(t *testing.T) {
+		is := require.New(t)
+		m := newSagaTestMocks(1000)
+		m.provider.On("Charge", tMock.Anything).Return("ref-1", nil).After(500 * time.Millisecond)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+
+		started := time.Now()
+		saga, err := m.service().Start(ctx, m.accountFrom.ID, m.accountTo.ID, uuid.NewV4().String(), "AOA", 300)
+
+		is.ErrorIs(err, context.DeadlineExceeded)
+		is.Less(int64(time.Since(started)), int64(400*time.Millisecond))
+		is.Equal(entity.SagaRunning, saga.Status)
+		is.Equal(entity.SagaStepCallProvider, saga.Step)
+		is.Equal(700.0, m.accountFrom.Balance)
+		m.provider.AssertNotCalled(t, "Refund", tMock.Anything)
+	})
+
+	t.Run("should hold a lease on the saga while it runs", func(t *testing.T) {
+		is := require.New(t)
+		m := newSagaTestMocks(1000)
+		m.provider.On("Charge", tMock.Anything).Return("ref-1", nil)
+
+		sagaService := m.service()
+		saga, err := sagaService.Start(context.Background(), m.accountFrom.ID, m.accountTo.ID, uuid.NewV4().String(), "AOA", 300)
+
+		is.Nil(err)
+		registered := m.sagaRepo.Calls[0].Arguments.Get(0).(*entity.Saga)
+		is.Equal(saga, registered)
+		is.Empty(saga.LeaseOwner)
+		is.Nil(saga.LeaseExpiresAt)
+		m.sagaRepo.AssertNotCalled(t, "Claim", tMock.Anything, tMock.Anything)
+	})
+
+	t.Run("should stop once another instance claimed the saga", func(t *testing.T) {
+		is := require.New(t)
+		m := newSagaTestMocks(1000)
+
+		m.sagaRepo = mock.NewMockSagaRepository()
+		m.sagaRepo.On("Register", tMock.Anything).Return(nil)
+		m.sagaRepo.On("Save", tMock.Anything, tMock.Anything).Return(entity.Conflict("service payment", "", "service payment was claimed by another instance"))
+
+		saga, err := m.service().Start(context.Background(), m.accountFrom.ID, m.accountTo.ID, uuid.NewV4().String(), "AOA", 300)
+
+		is.Nil(saga)
+		is.True(entity.IsErrorKind(err, entity.ErrorConflict))
+		m.sagaRepo.AssertNumberOfCalls(t, "Save", 1)
+		m.provider.AssertNotCalled(t, "Charge", tMock.Anything)
+	})
+
+	t.Run("should fail on register saga", func(t *testing.T) {
+		is := require.New(t)
+		sagaRepo := mock.NewMockSagaRepository()
+		sagaRepo.On("Register", tMock.Anything).Return(errors.New("db error"))
+
+		sagaService := service.NewSaga(sagaRepo, nil, nil, nil, nil)
+		saga, err := sagaService.Start(context.Background(), uuid.NewV4().String(), uuid.NewV4().String(), uuid.NewV4().String(), "AOA", 300)
+
+		is.NotNil(err)
+		is.Nil(saga)
+	})
+}
+
+func TestSagaRecover(t *testing.T) {
+	t.Parallel()
+
+	t.Run("should fail on find in flight sagas", func(t *testing.T) {
+		is := require.New(t)
+		sagaRepo := mock.NewMockSagaRepository()
+		sagaRepo.On("FindAllInFlight", tMock.Anything, 10).Return(nil, errors.New("db error"))
+
+		recovered, err := service.NewSaga(sagaRepo, nil, nil, nil, nil).Recover(context.Background(), 10)
+
+		is.NotNil(err)
+		is.Equal(0, recovered)
+	})
+
+	t.Run("should resume sagas from their persisted step", func(t *testing.T) {
+		is := require.New(t)
+		m := newSagaTestMocks(1000)
+		m.provider.On("Charge", tMock.Anything).Return("", errors.New("provider unavailable"))
+		m.provider.On("Refund", tMock.Anything).Return(nil)
+
+		capturing, _ := entity.NewSaga(m.accountFrom.ID, m.accountTo.ID, uuid.NewV4().String(), "AOA", 300, time.Millisecond)
+		capturing.Step = entity.SagaStepCapture
+		m.transaction, _ = entity.NewTransaction(m.accountFrom, m.accountTo, capturing.ExternalID, entity.TransactionToService, "AOA", 300)
+		m.transaction.ID = capturing.TransactionID
+
+		m.sagaRepo.On("FindAllInFlight", tMock.Anything, 10).Return([]*entity.Saga{capturing}, nil)
+		m.sagaRepo.On("Claim", capturing, tMock.Anything).Return(true, nil)
+
+		time.Sleep(5 * time.Millisecond)
+		recovered, err := m.service().Recover(context.Background(), 10)
+
+		is.Nil(err)
+		is.Equal(1, recovered)
+		is.Equal(entity.SagaCompleted, capturing.Status)
+		is.Equal(entity.TransactionCompleted, m.transaction.Status)
+		m.provider.AssertNotCalled(t, "Charge", tMock.Anything)
+	})
+	t.Run("should skip sagas claimed by another instance", func(t *testing.T) {
+		is := require.New(t)
+		m := newSagaTestMocks(1000)
+
+		running, _ := entity.NewSaga(m.accountFrom.ID, m.accountTo.ID, uuid.NewV4().String(), "AOA", 300, time.Minute)
+		m.sagaRepo.On("FindAllInFlight", tMock.Anything, 10).Return([]*entity.Saga{running}, nil)
+		m.sagaRepo.On("Claim", running, tMock.Anything).Return(false, nil)
+
+		recovered, err := m.service().Recover(context.Background(), 10)
+
+		is.Nil(err)
+		is.Equal(0, recovered)
+		m.sagaRepo.AssertNotCalled(t, "Save", tMock.Anything, tMock.Anything)
+		m.accountRepo.AssertNotCalled(t, "Find", tMock.Anything)
+	})
+}
+
+func TestSagaStartIdempotent(t *testing.T) {
+	t.Parallel()
+
+	t.Run("should store the key on a new saga", func(t *testing.T) {
+		is := require.New(t)
+		m := newSagaTestMocks(1000)
+		m.sagaRepo.On("FindByIdempotencyKey", "key-1").Return(nil, entity.NotFound("service payment", "key-1"))
+		m.provider.On("Charge", tMock.Anything).Return("ref-1", nil)
+
+		saga, err := m.service().StartIdempotent(context.Background(), "key-1", m.accountFrom.ID, m.accountTo.ID, uuid.NewV4().String(), "AOA", 300)
+
+		is.Nil(err)
+		is.Equal(entity.SagaCompleted, saga.Status)
+		is.Equal("key-1", saga.IdempotencyKey)
+		m.provider.AssertNumberOfCalls(t, "Charge", 1)
+	})
+
+	t.Run("should replay a finished saga without charging again", func(t *testing.T) {
+		is := require.New(t)
+		m := newSagaTestMocks(1000)
+		externalID := uuid.NewV4().String()
+
+		finished, _ := entity.NewSaga(m.accountFrom.ID, m.accountTo.ID, externalID, "", 300, time.Minute)
+		finished.Status = entity.SagaCompleted
+		m.sagaRepo.On("FindByIdempotencyKey", "key-1").Return(finished, nil)
+
+		saga, err := m.service().StartIdempotent(context.Background(), "key-1", m.accountFrom.ID, m.accountTo.ID, externalID, "AOA", 300)
+
+		is.Nil(err)
+		is.Equal(finished.ID, saga.ID)
+		m.sagaRepo.AssertNotCalled(t, "Register", tMock.Anything)
+		m.provider.AssertNotCalled(t, "Charge", tMock.Anything)
+	})
+
+	t.Run("should reject a key reused for a different payment", func(t *testing.T) {
+		is := require.New(t)
+		m := newSagaTestMocks(1000)
+
+		finished, _ := entity.NewSaga(m.accountFrom.ID, m.accountTo.ID, uuid.NewV4().String(), "AOA", 300, time.Minute)
+		finished.Status = entity.SagaCompleted
+		m.sagaRepo.On("FindByIdempotencyKey", "key-1").Return(finished, nil)
+
+		saga, err := m.service().StartIdempotent(context.Background(), "key-1", m.accountFrom.ID, m.accountTo.ID, finished.ExternalID, "AOA", 500)
+
+		is.Nil(saga)
+		is.True(entity.IsErrorKind(err, entity.ErrorConflict))
+		m.sagaRepo.AssertNotCalled(t, "Register", tMock.Anything)
+	})
+
+	t.Run("should not replay a saga that is still running", func(t *testing.T) {
+		is := require.New(t)
+		m := newSagaTestMocks(1000)
+
+		running, _ := entity.NewSaga(m.accountFrom.ID, m.accountTo.ID, uuid.NewV4().String(), "AOA", 300, time.Minute)
+		m.sagaRepo.On("FindByIdempotencyKey", "key-1").Return(running, nil)
+
+		saga, err := m.service().StartIdempotent(context.Background(), "key-1", m.accountFrom.ID, m.accountTo.ID, running.ExternalID, "AOA", 300)
+
+		is.Nil(saga)
+		is.True(entity.IsErrorKind(err, entity.ErrorConflict))
+		m.provider.AssertNotCalled(t, "Charge", tMock.Anything)
+	})
+}
+
+func TestSagaResume(t *testing.T) {
+	t.Parallel()
+
+	t.Run("should not resume a saga claimed by another instance", func(t *testing.T) {
+		is := require.New(t)
+		m := newSagaTestMocks(1000)
+
+		running, _ := entity.NewSaga(m.accountFrom.ID, m.accountTo.ID, uuid.NewV4().String(), "AOA", 300, time.Minute)
+		m.sagaRepo.On("Find", running.ID).Return(running, nil)
+		m.sagaRepo.On("Claim", running, tMock.Anything).Return(false, nil)
+
+		saga, err := m.service().Resume(context.Background(), running.ID)
+
+		is.Nil(saga)
+		is.True(entity.IsErrorKind(err, entity.ErrorConflict))
+		m.sagaRepo.AssertNotCalled(t, "Save", tMock.Anything, tMock.Anything)
+	})
+}
@@ -0,0 +1,19 @@
+package service
+
+import (
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var (
+	tracer = otel.Tracer("github.com/EdlanioJ/kbu/payments/data/service")
+
+	replayedAttribute = attribute.Bool("payment.idempotent_replay", true)
+)
+
+func recordError(span trace.Span, err error) {
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
@@ -0,0 +1,156 @@
+package service
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/EdlanioJ/kbu/payments/domain/entity"
+	"github.com/EdlanioJ/kbu/payments/domain/usecase"
+)
+
+var (
+	errVersionUnavailable = errors.New("the requested version is no longer available, watch again without a version")
+	errSubscriberLagged   = errors.New("the subscriber fell behind the feed, resume from the last received version")
+)
+
+type transactionSubscription struct {
+	feed    *TransactionFeed
+	version uint64
+	match   func(transaction *entity.Transaction) bool
+	updates chan *entity.TransactionUpdate
+	err     error
+	closed  bool
+}
+
+func (s *transactionSubscription) Version() uint64 {
+	return s.version
+}
+
+func (s *transactionSubscription) Updates() <-chan *entity.TransactionUpdate {
+	return s.updates
+}
+
+func (s *transactionSubscription) Err() error {
+	s.feed.mu.Lock()
+	defer s.feed.mu.Unlock()
+
+	return s.err
+}
+
+func (s *transactionSubscription) Close() {
+	s.feed.mu.Lock()
+	defer s.feed.mu.Unlock()
+
+	s.feed.remove(s, nil)
+}
+
+type TransactionFeed struct {
+	HistorySize int
+	BufferSize  int
+
+	mu          sync.Mutex
+	version     uint64
+	history     []*entity.TransactionUpdate
+	subscribers map[*transactionSubscription]struct{}
+}
+
+func NewTransactionFeed() *TransactionFeed {
+	return &TransactionFeed{
+		HistorySize: 1024,
+		BufferSize:  64,
+		subscribers: make(map[*transactionSubscription]struct{}),
+	}
+}
+
+func (f *TransactionFeed) Publish(transaction *entity.Transaction) {
+	snapshot := *transaction
+	snapshot.AccountFrom = nil
+	snapshot.AccountTo = nil
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.version++
+	update := &entity.TransactionUpdate{Version: f.version, Transaction: &snapshot}
+
+	f.history = append(f.history, update)
+
+	if len(f.history) > f.HistorySize {
+		f.history = f.history[len(f.history)-f.HistorySize:]
+	}
+
+	for subscription := range f.subscribers {
+		if !subscription.match(update.Transaction) {
+			continue
+		}
+
+		select {
+		case subscription.updates <- update:
+		default:
+			f.remove(subscription, errSubscriberLagged)
+		}
+	}
+}
+
+func (f *TransactionFeed) WatchTransaction(transactionID string, fromVersion uint64) (usecase.TransactionSubscription, error) {
+	return f.subscribe(fromVersion, func(transaction *entity.Transaction) bool {
+		return transaction.ID == transactionID
+	})
+}
+
+func (f *TransactionFeed) WatchAccount(accountID string, fromVersion uint64) (usecase.TransactionSubscription, error) {
+	return f.subscribe(fromVersion, func(transaction *entity.Transaction) bool {
+		return transaction.AccountFromID == accountID || transaction.AccountToID == accountID
+	})
+}
+
+func (f *TransactionFeed) subscribe(fromVersion uint64, match func(transaction *entity.Transaction) bool) (usecase.TransactionSubscription, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var backlog []*entity.TransactionUpdate
+
+	if fromVersion > 0 {
+		oldest := f.version + 1
+
+		if len(f.history) > 0 {
+			oldest = f.history[0].Version
+		}
+
+		if fromVersion > f.version || fromVersion+1 < oldest {
+			return nil, errVersionUnavailable
+		}
+
+		for _, update := range f.history {
+			if update.Version > fromVersion && match(update.Transaction) {
+				backlog = append(backlog, update)
+			}
+		}
+	}
+
+	subscription := &transactionSubscription{
+		feed:    f,
+		version: f.version,
+		match:   match,
+		updates: make(chan *entity.TransactionUpdate, len(backlog)+f.BufferSize),
+	}
+
+	for _, update := range backlog {
+		subscription.updates <- update
+	}
+
+	f.subscribers[subscription] = struct{}{}
+
+	return subscription, nil
+}
+
+func (f *TransactionFeed) remove(subscription *transactionSubscription, err error) {
+	if subscription.closed {
+		return
+	}
+
+	subscription.closed = true
+	subscription.err = err
+	delete(f.subscribers, subscription)
+	close(subscription.updates)
+}
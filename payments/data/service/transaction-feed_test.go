@@ -0,0 +1,155 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/EdlanioJ/kbu/payments/data/service"
+	"github.com/EdlanioJ/kbu/payments/data/service/mock"
+	"github.com/EdlanioJ/kbu/payments/domain/entity"
+	uuid "github.com/satori/go.uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func newFeedTransaction() *entity.Transaction {
+	accountFrom, _ := entity.NewAccount(1000)
+	accountTo, _ := entity.NewAccount(0)
+	transaction, _ := entity.NewTransaction(accountFrom, accountTo, uuid.NewV4().String(), entity.TransactionToStore, "AOA", 100)
+
+	return transaction
+}
+
+func completed(transaction *entity.Transaction) *entity.Transaction {
+	snapshot := *transaction
+	snapshot.Status = entity.TransactionCompleted
+
+	return &snapshot
+}
+
+func TestTransactionFeed(t *testing.T) {
+	t.Parallel()
+
+	t.Run("should push updates of the watched transaction only", func(t *testing.T) {
+		is := require.New(t)
+		feed := service.NewTransactionFeed()
+		watched, other := newFeedTransaction(), newFeedTransaction()
+
+		subscription, err := feed.WatchTransaction(watched.ID, 0)
+		is.Nil(err)
+		defer subscription.Close()
+
+		feed.Publish(other)
+		feed.Publish(completed(watched))
+
+		update := <-subscription.Updates()
+		is.Equal(uint64(2), update.Version)
+		is.Equal(watched.ID, update.Transaction.ID)
+		is.Equal(entity.TransactionCompleted, update.Transaction.Status)
+		is.Nil(update.Transaction.AccountFrom)
+		is.Len(subscription.Updates(), 0)
+	})
+
+	t.Run("should push transactions touching the watched account", func(t *testing.T) {
+		is := require.New(t)
+		feed := service.NewTransactionFeed()
+		outgoing, incoming := newFeedTransaction(), newFeedTransaction()
+		incoming.AccountToID = outgoing.AccountFromID
+
+		subscription, err := feed.WatchAccount(outgoing.AccountFromID, 0)
+		is.Nil(err)
+		defer subscription.Close()
+
+		feed.Publish(outgoing)
+		feed.Publish(newFeedTransaction())
+		feed.Publish(incoming)
+
+		is.Equal(outgoing.ID, (<-subscription.Updates()).Transaction.ID)
+		is.Equal(incoming.ID, (<-subscription.Updates()).Transaction.ID)
+		is.Len(subscription.Updates(), 0)
+	})
+
+	t.Run("should replay missed updates when resuming from a version", func(t *testing.T) {
+		is := require.New(t)
+		feed := service.NewTransactionFeed()
+		transaction := newFeedTransaction()
+
+		feed.Publish(transaction)
+		feed.Publish(newFeedTransaction())
+		feed.Publish(completed(transaction))
+
+		subscription, err := feed.WatchTransaction(transaction.ID, 1)
+		is.Nil(err)
+		defer subscription.Close()
+
+		is.Equal(uint64(3), subscription.Version())
+
+		update := <-subscription.Updates()
+		is.Equal(uint64(3), update.Version)
+		is.Equal(entity.TransactionCompleted, update.Transaction.Status)
+	})
+
+	t.Run("should reject versions outside of the retained history", func(t *testing.T) {
+		is := require.New(t)
+		feed := service.NewTransactionFeed()
+		feed.HistorySize = 2
+		transaction := newFeedTransaction()
+
+		for i := 0; i < 4; i++ {
+			feed.Publish(transaction)
+		}
+
+		_, err := feed.WatchTransaction(transaction.ID, 1)
+		is.NotNil(err)
+
+		_, err = feed.WatchTransaction(transaction.ID, 5)
+		is.NotNil(err)
+
+		subscription, err := feed.WatchTransaction(transaction.ID, 2)
+		is.Nil(err)
+		subscription.Close()
+	})
+
+	t.Run("should drop subscribers that fall behind", func(t *testing.T) {
+		is := require.New(t)
+		feed := service.NewTransactionFeed()
+		feed.BufferSize = 1
+		transaction := newFeedTransaction()
+
+		subscription, err := feed.WatchTransaction(transaction.ID, 0)
+		is.Nil(err)
+
+		feed.Publish(transaction)
+		feed.Publish(transaction)
+
+		_, ok := <-subscription.Updates()
+		is.True(ok)
+		_, ok = <-subscription.Updates()
+		is.False(ok)
+		is.NotNil(subscription.Err())
+
+		subscription.Close()
+	})
+
+	t.Run("should be fed by transaction completion", func(t *testing.T) {
+		is := require.New(t)
+		feed := service.NewTransactionFeed()
+		transaction := newFeedTransaction()
+
+		mockTransactionRepo := mock.NewMockTransactionRepository()
+		mockTransactionRepo.On("Find", transaction.ID).Return(transaction, nil)
+		mockTransactionRepo.On("Save", transaction).Return(nil)
+
+		transactionService := service.NewTransaction(mockTransactionRepo, nil)
+		transactionService.Notifier = feed
+
+		subscription, err := feed.WatchTransaction(transaction.ID, 0)
+		is.Nil(err)
+		defer subscription.Close()
+
+		_, err = transactionService.Complete(context.Background(), transaction.ID)
+		is.Nil(err)
+
+		update := <-subscription.Updates()
+		is.Equal(entity.TransactionCompleted, update.Transaction.Status)
+	})
+}
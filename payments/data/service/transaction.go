@@ -1,8 +1,11 @@
 package service
 
 import (
-	"errors"
+	"context"
+	"fmt"
+	"strings"
 
+	"github.com/EdlanioJ/kbu/payments/data/protocol"
 	"github.com/EdlanioJ/kbu/payments/data/repository"
 	"github.com/EdlanioJ/kbu/payments/domain/entity"
 )
@@ -10,6 +13,13 @@ import (
 type Transaction struct {
 	TransactionRepository repository.TransactionRepository
 	AccountRepository     repository.AccountRepository
+	Notifier              protocol.TransactionNotifier
+	Metrics               protocol.TransactionMetrics
+
+	// Confirmations and Webhooks, when set, queue the webhooks of a confirmed
+	// payment in the same transaction as its status change.
+	Confirmations repository.ConfirmationUnitOfWork
+	Webhooks      *Webhook
 }
 
 func NewTransaction(
@@ -23,25 +33,114 @@ func NewTransaction(
 	}
 }
 
-func (t *Transaction) Register(fromID, toID, externalID, transactionType, currency string, amount float64) (*entity.Transaction, error) {
-	accountFrom, err := t.AccountRepository.Find(fromID)
+func (t *Transaction) Register(ctx context.Context, fromID, toID, externalID, transactionType, currency string, amount float64) (*entity.Transaction, error) {
+	ctx, span := tracer.Start(ctx, "service.Transaction.Register")
+	defer span.End()
+
+	transaction, err := t.register(ctx, fromID, toID, externalID, transactionType, currency, amount, "")
+
+	if err != nil {
+		recordError(span, err)
+		t.registerFailed(err)
+		return nil, err
+	}
+
+	t.notify(transaction)
+
+	return transaction, nil
+}
+
+// RegisterIdempotent registers a payment at most once per key. A retry with
+// the same key and payload gets the payment created by the first call, a
+// different payload is rejected as a conflict.
+func (t *Transaction) RegisterIdempotent(ctx context.Context, key, fromID, toID, externalID, transactionType, currency string, amount float64) (*entity.Transaction, error) {
+	ctx, span := tracer.Start(ctx, "service.Transaction.RegisterIdempotent")
+	defer span.End()
+
+	if key == "" {
+		return t.Register(ctx, fromID, toID, externalID, transactionType, currency, amount)
+	}
+
+	transaction, err := t.replay(ctx, key, fromID, toID, externalID, transactionType, currency, amount)
+
+	if err != nil {
+		recordError(span, err)
+		return nil, err
+	}
+
+	if transaction != nil {
+		span.SetAttributes(replayedAttribute)
+		return transaction, nil
+	}
+
+	transaction, err = t.register(ctx, fromID, toID, externalID, transactionType, currency, amount, key)
+
+	if entity.IsErrorKind(err, entity.ErrorConflict) {
+		replayed, replayErr := t.replay(ctx, key, fromID, toID, externalID, transactionType, currency, amount)
+
+		if replayErr != nil {
+			recordError(span, replayErr)
+			return nil, replayErr
+		}
+
+		if replayed != nil {
+			span.SetAttributes(replayedAttribute)
+			return replayed, nil
+		}
+	}
+
+	if err != nil {
+		recordError(span, err)
+		t.registerFailed(err)
+		return nil, err
+	}
+
+	t.notify(transaction)
+
+	return transaction, nil
+}
+
+func (t *Transaction) replay(ctx context.Context, key, fromID, toID, externalID, transactionType, currency string, amount float64) (*entity.Transaction, error) {
+	transaction, err := t.TransactionRepository.FindByIdempotencyKey(ctx, key)
+
+	if entity.IsErrorKind(err, entity.ErrorNotFound) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !transaction.SameRequest(fromID, toID, externalID, transactionType, currency, amount) {
+		return nil, entity.Conflict("payment", transaction.ID, "idempotency key was already used for a different payment")
+	}
+
+	return transaction, nil
+}
+
+func (t *Transaction) register(ctx context.Context, fromID, toID, externalID, transactionType, currency string, amount float64, idempotencyKey string) (*entity.Transaction, error) {
+	accountFrom, err := t.AccountRepository.Find(ctx, fromID)
 
 	if err != nil {
 		return nil, err
 	}
 
 	if accountFrom == nil {
-		return nil, errors.New("no account from was found")
+		return nil, entity.NotFound("account from", fromID)
 	}
 
-	accountTo, err := t.AccountRepository.Find(toID)
+	accountTo, err := t.AccountRepository.Find(ctx, toID)
 
 	if err != nil {
 		return nil, err
 	}
 
 	if accountTo == nil {
-		return nil, errors.New("no account destination was found")
+		return nil, entity.NotFound("account destination", toID)
+	}
+
+	if accountTo.Frozen {
+		return nil, entity.AccountFrozen(accountTo.ID)
 	}
 
 	err = accountFrom.Withdow(amount)
@@ -56,13 +155,15 @@ func (t *Transaction) Register(fromID, toID, externalID, transactionType, curren
 		return nil, err
 	}
 
-	err = t.TransactionRepository.Register(transaction)
+	transaction.IdempotencyKey = idempotencyKey
+
+	err = t.TransactionRepository.Register(ctx, transaction)
 
 	if err != nil {
 		return nil, err
 	}
 
-	err = t.AccountRepository.Save(accountFrom)
+	err = t.AccountRepository.Save(ctx, accountFrom)
 	if err != nil {
 		return nil, err
 	}
@@ -70,163 +171,294 @@ func (t *Transaction) Register(fromID, toID, externalID, transactionType, curren
 	return transaction, nil
 }
 
-func (t *Transaction) Find(id string) (*entity.Transaction, error) {
-	transaction, err := t.TransactionRepository.Find(id)
+func (t *Transaction) Find(ctx context.Context, id string) (*entity.Transaction, error) {
+	ctx, span := tracer.Start(ctx, "service.Transaction.Find")
+	defer span.End()
+
+	transaction, err := t.TransactionRepository.Find(ctx, id)
 
 	if err != nil {
+		recordError(span, err)
 		return nil, err
 	}
 
 	return transaction, nil
 }
 
-func (t *Transaction) FindAll(page int, limit int, sort string) ([]*entity.Transaction, int, error) {
+func (t *Transaction) FindAll(ctx context.Context, page int, limit int, sort string) ([]*entity.Transaction, int, error) {
+	ctx, span := tracer.Start(ctx, "service.Transaction.FindAll")
+	defer span.End()
+
 	pagination := &entity.Pagination{
 		Page:  page,
 		Limit: limit,
 		Sort:  sort,
 	}
-	transaction, total, err := t.TransactionRepository.FindAll(pagination)
+	transaction, total, err := t.TransactionRepository.FindAll(ctx, pagination)
 
 	if err != nil {
+		recordError(span, err)
 		return nil, 0, err
 	}
 
 	return transaction, total, nil
 }
 
-func (t *Transaction) FindByType(transactionType, transactionID string) (*entity.Transaction, error) {
-	transaction, err := t.TransactionRepository.FindByType(transactionID, transactionType)
+func (t *Transaction) FindByType(ctx context.Context, transactionType, transactionID string) (*entity.Transaction, error) {
+	ctx, span := tracer.Start(ctx, "service.Transaction.FindByType")
+	defer span.End()
+
+	transaction, err := t.TransactionRepository.FindByType(ctx, transactionID, transactionType)
 
 	if err != nil {
+		recordError(span, err)
 		return nil, err
 	}
 	return transaction, nil
 }
 
-func (t *Transaction) FindAllByType(transactionType string, page int, limit int, sort string) ([]*entity.Transaction, int, error) {
+func (t *Transaction) FindAllByType(ctx context.Context, transactionType string, page int, limit int, sort string) ([]*entity.Transaction, int, error) {
+	ctx, span := tracer.Start(ctx, "service.Transaction.FindAllByType")
+	defer span.End()
+
 	pagination := &entity.Pagination{
 		Page:  page,
 		Limit: limit,
 		Sort:  sort,
 	}
 
-	transactions, total, err := t.TransactionRepository.FindAllByType(transactionType, pagination)
+	transactions, total, err := t.TransactionRepository.FindAllByType(ctx, transactionType, pagination)
 
 	if err != nil {
+		recordError(span, err)
 		return nil, 0, err
 	}
 	return transactions, total, nil
 }
 
-func (t *Transaction) FindByExternalID(externalID, transactionID string) (*entity.Transaction, error) {
-	transaction, err := t.TransactionRepository.FindByExternalID(transactionID, externalID)
+func (t *Transaction) FindByExternalID(ctx context.Context, externalID, transactionID string) (*entity.Transaction, error) {
+	ctx, span := tracer.Start(ctx, "service.Transaction.FindByExternalID")
+	defer span.End()
+
+	transaction, err := t.TransactionRepository.FindByExternalID(ctx, transactionID, externalID)
 
 	if err != nil {
+		recordError(span, err)
 		return nil, err
 	}
 
 	return transaction, nil
 }
 
-func (t *Transaction) FindAllByExternalID(externalID string, page int, limit int, sort string) ([]*entity.Transaction, int, error) {
+func (t *Transaction) FindAllByExternalID(ctx context.Context, externalID string, page int, limit int, sort string) ([]*entity.Transaction, int, error) {
+	ctx, span := tracer.Start(ctx, "service.Transaction.FindAllByExternalID")
+	defer span.End()
+
 	pagination := &entity.Pagination{
 		Page:  page,
 		Limit: limit,
 		Sort:  sort,
 	}
 
-	transactions, total, err := t.TransactionRepository.FindAllByExternalID(externalID, pagination)
+	transactions, total, err := t.TransactionRepository.FindAllByExternalID(ctx, externalID, pagination)
 
 	if err != nil {
+		recordError(span, err)
 		return nil, 0, err
 	}
 	return transactions, total, nil
 }
 
-func (t *Transaction) FindAllByFromAccountID(accountID string, page int, limit int, sort string) ([]*entity.Transaction, int, error) {
+func (t *Transaction) FindAllByFromAccountID(ctx context.Context, accountID string, page int, limit int, sort string) ([]*entity.Transaction, int, error) {
+	ctx, span := tracer.Start(ctx, "service.Transaction.FindAllByFromAccountID")
+	defer span.End()
+
 	pagination := &entity.Pagination{
 		Page:  page,
 		Limit: limit,
 		Sort:  sort,
 	}
 
-	transactions, total, err := t.TransactionRepository.FindAllByFromAccountID(accountID, pagination)
+	transactions, total, err := t.TransactionRepository.FindAllByFromAccountID(ctx, accountID, pagination)
 
 	if err != nil {
+		recordError(span, err)
 		return nil, 0, err
 	}
 	return transactions, total, nil
 }
 
-func (t *Transaction) FindByFromAccountID(accountID, transactionID string) (*entity.Transaction, error) {
-	transaction, err := t.TransactionRepository.FindByFromAccountID(transactionID, accountID)
+func (t *Transaction) FindByFromAccountID(ctx context.Context, accountID, transactionID string) (*entity.Transaction, error) {
+	ctx, span := tracer.Start(ctx, "service.Transaction.FindByFromAccountID")
+	defer span.End()
+
+	transaction, err := t.TransactionRepository.FindByFromAccountID(ctx, transactionID, accountID)
 
 	if err != nil {
+		recordError(span, err)
 		return nil, err
 	}
 
 	return transaction, nil
 }
 
-func (t *Transaction) FindAllByToAccountID(accountID string, page int, limit int, sort string) ([]*entity.Transaction, int, error) {
+func (t *Transaction) FindAllByToAccountID(ctx context.Context, accountID string, page int, limit int, sort string) ([]*entity.Transaction, int, error) {
+	ctx, span := tracer.Start(ctx, "service.Transaction.FindAllByToAccountID")
+	defer span.End()
+
 	pagination := &entity.Pagination{
 		Page:  page,
 		Limit: limit,
 		Sort:  sort,
 	}
 
-	transactions, total, err := t.TransactionRepository.FindAllByToAccountID(accountID, pagination)
+	transactions, total, err := t.TransactionRepository.FindAllByToAccountID(ctx, accountID, pagination)
 
 	if err != nil {
+		recordError(span, err)
 		return nil, 0, err
 	}
 
 	return transactions, total, nil
 }
 
-func (t *Transaction) FindByToAccountID(accountID, transactionID string) (*entity.Transaction, error) {
-	transaction, err := t.TransactionRepository.FindByToAccountID(transactionID, accountID)
+func (t *Transaction) FindByToAccountID(ctx context.Context, accountID, transactionID string) (*entity.Transaction, error) {
+	ctx, span := tracer.Start(ctx, "service.Transaction.FindByToAccountID")
+	defer span.End()
+
+	transaction, err := t.TransactionRepository.FindByToAccountID(ctx, transactionID, accountID)
 
 	if err != nil {
+		recordError(span, err)
 		return nil, err
 	}
 
 	return transaction, nil
 }
 
-func (t *Transaction) Complete(transactionId string) (*entity.Transaction, error) {
-	transaction, err := t.TransactionRepository.Find(transactionId)
+func (t *Transaction) Complete(ctx context.Context, transactionId string) (*entity.Transaction, error) {
+	ctx, span := tracer.Start(ctx, "service.Transaction.Complete")
+	defer span.End()
+
+	transaction, err := t.confirm(ctx, transactionId, entity.TransactionCompleted, entity.WebhookPaymentCompleted)
 
 	if err != nil {
+		recordError(span, err)
 		return nil, err
 	}
 
-	transaction.Status = entity.TransactionCompleted
+	t.notify(transaction)
 
-	err = t.TransactionRepository.Save(transaction)
+	return transaction, nil
+}
+
+func (t *Transaction) Error(ctx context.Context, transactionId string) (*entity.Transaction, error) {
+	ctx, span := tracer.Start(ctx, "service.Transaction.Error")
+	defer span.End()
+
+	transaction, err := t.confirm(ctx, transactionId, entity.TransactionCanceled, entity.WebhookPaymentCanceled)
 
 	if err != nil {
-		return nil, errors.New(err.Error())
+		recordError(span, err)
+		return nil, err
 	}
 
+	t.notify(transaction)
+
 	return transaction, nil
 }
 
-func (t *Transaction) Error(transactionId string) (*entity.Transaction, error) {
-	transaction, err := t.TransactionRepository.Find(transactionId)
+func (t *Transaction) Export(ctx context.Context, filter *entity.TransactionFilter, resumeToken string, fn func(transaction *entity.Transaction, resumeToken string) error) error {
+	ctx, span := tracer.Start(ctx, "service.Transaction.Export")
+	defer span.End()
+
+	after, err := entity.ParseExportCursor(resumeToken)
 
 	if err != nil {
-		return nil, errors.New(err.Error())
+		recordError(span, err)
+		return err
 	}
 
-	transaction.Status = entity.TransactionCanceled
+	err = t.TransactionRepository.Iterate(ctx, filter, after, func(transaction *entity.Transaction) error {
+		return fn(transaction, entity.NewExportCursor(transaction).Token())
+	})
 
-	err = t.TransactionRepository.Save(transaction)
+	if err != nil {
+		recordError(span, err)
+	}
+
+	return err
+}
+
+func (t *Transaction) confirm(ctx context.Context, transactionID, status, event string) (*entity.Transaction, error) {
+	if t.Confirmations == nil || t.Webhooks == nil {
+		return changeStatus(ctx, t.TransactionRepository, transactionID, status)
+	}
+
+	var transaction *entity.Transaction
+
+	err := t.Confirmations.Do(ctx, func(transactions repository.TransactionRepository, webhooks repository.WebhookRepository, deliveries repository.WebhookDeliveryRepository) error {
+		var err error
+
+		transaction, err = changeStatus(ctx, transactions, transactionID, status)
+
+		if err != nil {
+			return err
+		}
+
+		_, err = t.Webhooks.queue(ctx, webhooks, deliveries, event, transaction)
+
+		return err
+	})
 
 	if err != nil {
-		return nil, errors.New(err.Error())
+		return nil, err
 	}
 
 	return transaction, nil
 }
+
+// changeStatus moves a pending payment to status. A payment that was already
+// confirmed is a conflict, so a redelivered or late confirmation changes
+// nothing.
+func changeStatus(ctx context.Context, transactions repository.TransactionRepository, transactionID, status string) (*entity.Transaction, error) {
+	transaction, err := transactions.Find(ctx, transactionID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if transaction.Status != entity.TransactionPending {
+		return nil, entity.Conflict("payment", transaction.ID, fmt.Sprintf("payment is already %s", transaction.Status))
+	}
+
+	transaction.Status = status
+
+	err = transactions.Save(ctx, transaction)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return transaction, nil
+}
+
+func (t *Transaction) registerFailed(err error) {
+	if t.Metrics == nil {
+		return
+	}
+
+	reason := "internal"
+
+	if domainError, ok := entity.AsDomainError(err); ok {
+		reason = strings.ToLower(domainError.Kind)
+	}
+
+	t.Metrics.RegisterFailed(reason)
+}
+
+func (t *Transaction) notify(transaction *entity.Transaction) {
+	if t.Notifier != nil {
+		t.Notifier.Publish(transaction)
+	}
+}
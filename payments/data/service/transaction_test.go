@@ -1,6 +1,7 @@
 package service_test
 
 import (
+	"context"
 	"errors"
 	"testing"
 
@@ -24,7 +25,7 @@ func TestRegister(t *testing.T) {
 		mockAccountRepo.On("Find", fromID).Return(nil, errors.New("invalid user"))
 		transactionService := service.NewTransaction(nil, mockAccountRepo)
 
-		result, err := transactionService.Register(fromID, "", "", "", "", 0)
+		result, err := transactionService.Register(context.Background(), fromID, "", "", "", "", 0)
 
 		is.Nil(result)
 		is.NotNil(err)
@@ -39,7 +40,7 @@ func TestRegister(t *testing.T) {
 		mockAccountRepo.On("Find", fromID).Return(nil, nil)
 		transactionService := service.NewTransaction(nil, mockAccountRepo)
 
-		result, err := transactionService.Register(fromID, "", "", "", "", 0)
+		result, err := transactionService.Register(context.Background(), fromID, "", "", "", "", 0)
 
 		is.Nil(result)
 		is.NotNil(err)
@@ -58,7 +59,7 @@ func TestRegister(t *testing.T) {
 		mockAccountRepo.On("Find", toID).Return(nil, errors.New("invalid param"))
 
 		transactionService := service.NewTransaction(nil, mockAccountRepo)
-		result, err := transactionService.Register(accountFrom.ID, toID, "", "", "", 0)
+		result, err := transactionService.Register(context.Background(), accountFrom.ID, toID, "", "", "", 0)
 
 		is.Nil(result)
 		is.NotNil(err)
@@ -76,12 +77,13 @@ func TestRegister(t *testing.T) {
 		mockAccountRepo.On("Find", toID).Return(nil, nil)
 
 		transactionService := service.NewTransaction(nil, mockAccountRepo)
-		result, err := transactionService.Register(accountFrom.ID, toID, "", "", "", 0)
+		result, err := transactionService.Register(context.Background(), accountFrom.ID, toID, "", "", "", 0)
 
 		is.Nil(result)
 		is.NotNil(err)
 		is.Error(err)
 		is.EqualError(err, "no account destination was found")
+		is.True(entity.IsErrorKind(err, entity.ErrorNotFound))
 	})
 
 	t.Run("should fail on withdrow from account", func(t *testing.T) {
@@ -95,12 +97,74 @@ func TestRegister(t *testing.T) {
 		mockAccountRepo.On("Find", accountTo.ID).Return(accountTo, nil)
 
 		transactionService := service.NewTransaction(nil, mockAccountRepo)
-		result, err := transactionService.Register(accountFrom.ID, accountTo.ID, "", "", "", 40)
+		result, err := transactionService.Register(context.Background(), accountFrom.ID, accountTo.ID, "", "", "", 40)
 
 		is.Nil(result)
 		is.NotNil(err)
 		is.Error(err)
 		is.EqualError(err, "account does not have balance")
+		is.True(entity.IsErrorKind(err, entity.ErrorInsufficientFunds))
+	})
+
+	t.Run("should count register failures by reason", func(t *testing.T) {
+		mockAccountRepo := mock.NewMockAccountRepository()
+		mockMetrics := mock.NewMockTransactionMetrics()
+		is := require.New(t)
+
+		accountFrom, _ := entity.NewAccount(30)
+		accountTo, _ := entity.NewAccount(200)
+
+		mockAccountRepo.On("Find", accountFrom.ID).Return(accountFrom, nil)
+		mockAccountRepo.On("Find", accountTo.ID).Return(accountTo, nil)
+		mockMetrics.On("RegisterFailed", "insufficient_funds").Return()
+
+		transactionService := service.NewTransaction(nil, mockAccountRepo)
+		transactionService.Metrics = mockMetrics
+		result, err := transactionService.Register(context.Background(), accountFrom.ID, accountTo.ID, "", "", "", 40)
+
+		mockMetrics.AssertExpectations(t)
+
+		is.Nil(result)
+		is.Error(err)
+	})
+
+	t.Run("should fail if destination account is frozen", func(t *testing.T) {
+		mockAccountRepo := mock.NewMockAccountRepository()
+		is := require.New(t)
+
+		accountFrom, _ := entity.NewAccount(300)
+		accountTo, _ := entity.NewAccount(200)
+		accountTo.Frozen = true
+
+		mockAccountRepo.On("Find", accountFrom.ID).Return(accountFrom, nil)
+		mockAccountRepo.On("Find", accountTo.ID).Return(accountTo, nil)
+
+		transactionService := service.NewTransaction(nil, mockAccountRepo)
+		result, err := transactionService.Register(context.Background(), accountFrom.ID, accountTo.ID, "", "", "", 40)
+
+		is.Nil(result)
+		is.True(entity.IsErrorKind(err, entity.ErrorAccountFrozen))
+		is.Equal(300.0, accountFrom.Balance)
+	})
+
+	t.Run("should fail if the accounts belong to different tenants", func(t *testing.T) {
+		mockAccountRepo := mock.NewMockAccountRepository()
+		mockTransactionRepo := mock.NewMockTransactionRepository()
+		is := require.New(t)
+
+		accountFrom, _ := entity.NewAccount(300)
+		accountTo, _ := entity.NewAccount(200)
+		accountTo.TenantID = "acme"
+
+		mockAccountRepo.On("Find", accountFrom.ID).Return(accountFrom, nil)
+		mockAccountRepo.On("Find", accountTo.ID).Return(accountTo, nil)
+
+		transactionService := service.NewTransaction(mockTransactionRepo, mockAccountRepo)
+		result, err := transactionService.Register(context.Background(), accountFrom.ID, accountTo.ID, uuid.NewV4().String(), entity.TransactionToUser, "AOA", 40)
+
+		is.Nil(result)
+		is.True(entity.IsErrorKind(err, entity.ErrorPermissionDenied))
+		mockTransactionRepo.AssertNotCalled(t, "Register", tMock.Anything)
 	})
 
 	t.Run("should fail on new transaction", func(t *testing.T) {
@@ -114,7 +178,7 @@ func TestRegister(t *testing.T) {
 		mockAccountRepo.On("Find", accountTo.ID).Return(accountTo, nil)
 
 		transactionService := service.NewTransaction(nil, mockAccountRepo)
-		result, err := transactionService.Register(accountFrom.ID, accountTo.ID, "", "", "", 0)
+		result, err := transactionService.Register(context.Background(), accountFrom.ID, accountTo.ID, "", "", "", 0)
 
 		is.Nil(result)
 		is.NotNil(err)
@@ -137,7 +201,7 @@ func TestRegister(t *testing.T) {
 		mockTransactionRepo.On("Register", tMock.Anything).Return(errors.New("register error"))
 
 		transactionService := service.NewTransaction(mockTransactionRepo, mockAccountRepo)
-		result, err := transactionService.Register(accountFrom.ID, accountTo.ID, externalID, transactionType, currency, 40)
+		result, err := transactionService.Register(context.Background(), accountFrom.ID, accountTo.ID, externalID, transactionType, currency, 40)
 
 		is.Nil(result)
 		is.NotNil(err)
@@ -164,7 +228,7 @@ func TestRegister(t *testing.T) {
 		mockAccountRepo.On("Save", accountFrom).Return(errors.New("error on save"))
 
 		transactionService := service.NewTransaction(mockTransactionRepo, mockAccountRepo)
-		result, err := transactionService.Register(accountFrom.ID, accountTo.ID, externalID, transactionType, currency, 40)
+		result, err := transactionService.Register(context.Background(), accountFrom.ID, accountTo.ID, externalID, transactionType, currency, 40)
 
 		is.Nil(result)
 		is.NotNil(err)
@@ -191,7 +255,7 @@ func TestRegister(t *testing.T) {
 		mockAccountRepo.On("Save", accountFrom).Return(nil)
 
 		transactionService := service.NewTransaction(mockTransactionRepo, mockAccountRepo)
-		result, err := transactionService.Register(accountFrom.ID, accountTo.ID, externalID, transactionType, currency, amount)
+		result, err := transactionService.Register(context.Background(), accountFrom.ID, accountTo.ID, externalID, transactionType, currency, amount)
 
 		is.Nil(err)
 		is.Equal(result.AccountFromID, accountFrom.ID)
@@ -203,6 +267,73 @@ func TestRegister(t *testing.T) {
 	})
 }
 
+func TestRegisterIdempotent(t *testing.T) {
+	t.Parallel()
+
+	t.Run("should register with the key on first use", func(t *testing.T) {
+		mockAccountRepo := mock.NewMockAccountRepository()
+		mockTransactionRepo := mock.NewMockTransactionRepository()
+		is := require.New(t)
+
+		accountFrom, _ := entity.NewAccount(3000)
+		accountTo, _ := entity.NewAccount(200)
+		externalID := uuid.NewV4().String()
+		key := uuid.NewV4().String()
+
+		mockTransactionRepo.On("FindByIdempotencyKey", key).Return(nil, entity.NotFound("payment", key))
+		mockAccountRepo.On("Find", accountFrom.ID).Return(accountFrom, nil)
+		mockAccountRepo.On("Find", accountTo.ID).Return(accountTo, nil)
+		mockTransactionRepo.On("Register", tMock.MatchedBy(func(transaction *entity.Transaction) bool {
+			return transaction.IdempotencyKey == key
+		})).Return(nil)
+		mockAccountRepo.On("Save", accountFrom).Return(nil)
+
+		transactionService := service.NewTransaction(mockTransactionRepo, mockAccountRepo)
+		result, err := transactionService.RegisterIdempotent(context.Background(), key, accountFrom.ID, accountTo.ID, externalID, entity.TransactionToUser, "AOA", 30)
+
+		is.Nil(err)
+		is.Equal(key, result.IdempotencyKey)
+		mockTransactionRepo.AssertExpectations(t)
+	})
+
+	t.Run("should return the first payment on retry", func(t *testing.T) {
+		mockTransactionRepo := mock.NewMockTransactionRepository()
+		is := require.New(t)
+
+		accountFrom, _ := entity.NewAccount(3000)
+		accountTo, _ := entity.NewAccount(200)
+		key := uuid.NewV4().String()
+
+		existing, _ := entity.NewTransaction(accountFrom, accountTo, uuid.NewV4().String(), entity.TransactionToUser, "AOA", 30)
+		mockTransactionRepo.On("FindByIdempotencyKey", key).Return(existing, nil)
+
+		transactionService := service.NewTransaction(mockTransactionRepo, nil)
+		result, err := transactionService.RegisterIdempotent(context.Background(), key, accountFrom.ID, accountTo.ID, existing.ExternalID, entity.TransactionToUser, "AOA", 30)
+
+		is.Nil(err)
+		is.Equal(existing.ID, result.ID)
+		mockTransactionRepo.AssertNotCalled(t, "Register", tMock.Anything)
+	})
+
+	t.Run("should reject a key reused for another payment", func(t *testing.T) {
+		mockTransactionRepo := mock.NewMockTransactionRepository()
+		is := require.New(t)
+
+		accountFrom, _ := entity.NewAccount(3000)
+		accountTo, _ := entity.NewAccount(200)
+		key := uuid.NewV4().String()
+
+		existing, _ := entity.NewTransaction(accountFrom, accountTo, uuid.NewV4().String(), entity.TransactionToUser, "AOA", 30)
+		mockTransactionRepo.On("FindByIdempotencyKey", key).Return(existing, nil)
+
+		transactionService := service.NewTransaction(mockTransactionRepo, nil)
+		result, err := transactionService.RegisterIdempotent(context.Background(), key, accountFrom.ID, accountTo.ID, existing.ExternalID, entity.TransactionToUser, "AOA", 31)
+
+		is.Nil(result)
+		is.True(entity.IsErrorKind(err, entity.ErrorConflict))
+	})
+}
+
 func TestFindByType(t *testing.T) {
 	t.Parallel()
 
@@ -216,7 +347,7 @@ func TestFindByType(t *testing.T) {
 		mockTransactionRepo.On("FindByType", transactionID, transactionType).Return(nil, errors.New("error on find"))
 		serviceTransaction := service.NewTransaction(mockTransactionRepo, nil)
 
-		result, err := serviceTransaction.FindByType(transactionType, transactionID)
+		result, err := serviceTransaction.FindByType(context.Background(), transactionType, transactionID)
 		mockTransactionRepo.AssertExpectations(t)
 
 		is.Nil(result)
@@ -239,7 +370,7 @@ func TestFindByType(t *testing.T) {
 		mockTransactionRepo.On("FindByType", transaction.ID, transactionType).Return(transaction, nil)
 		serviceTransaction := service.NewTransaction(mockTransactionRepo, nil)
 
-		result, err := serviceTransaction.FindByType(transactionType, transaction.ID)
+		result, err := serviceTransaction.FindByType(context.Background(), transactionType, transaction.ID)
 		mockTransactionRepo.AssertExpectations(t)
 
 		is.Nil(err)
@@ -265,7 +396,7 @@ func TestFindAllByType(t *testing.T) {
 		mockTransactionRepo.On("FindAllByType", transactionType, pagination).Return(nil, 0, errors.New("error on find"))
 		serviceTransaction := service.NewTransaction(mockTransactionRepo, nil)
 
-		result, total, err := serviceTransaction.FindAllByType(transactionType, page, limit, sort)
+		result, total, err := serviceTransaction.FindAllByType(context.Background(), transactionType, page, limit, sort)
 
 		is.Nil(result)
 		is.Equal(0, total)
@@ -297,7 +428,7 @@ func TestFindAllByType(t *testing.T) {
 		mockTransactionRepo.On("FindAllByType", transactionType, pagination).Return(transactions, totalResult, nil)
 		serviceTransaction := service.NewTransaction(mockTransactionRepo, nil)
 
-		result, total, err := serviceTransaction.FindAllByType(transactionType, page, limit, sort)
+		result, total, err := serviceTransaction.FindAllByType(context.Background(), transactionType, page, limit, sort)
 
 		is.Nil(err)
 		is.Equal(totalResult, total)
@@ -319,7 +450,7 @@ func TestFindByExternalID(t *testing.T) {
 		mockTransactionRepo.On("FindByExternalID", transactionID, externalID).Return(nil, errors.New("error on find"))
 		serviceTransaction := service.NewTransaction(mockTransactionRepo, nil)
 
-		result, err := serviceTransaction.FindByExternalID(externalID, transactionID)
+		result, err := serviceTransaction.FindByExternalID(context.Background(), externalID, transactionID)
 		mockTransactionRepo.AssertExpectations(t)
 
 		is.Nil(result)
@@ -337,7 +468,7 @@ func TestFindByExternalID(t *testing.T) {
 		mockTransactionRepo.On("FindByExternalID", transactionID, externalID).Return(nil, nil)
 		serviceTransaction := service.NewTransaction(mockTransactionRepo, nil)
 
-		result, err := serviceTransaction.FindByExternalID(externalID, transactionID)
+		result, err := serviceTransaction.FindByExternalID(context.Background(), externalID, transactionID)
 		mockTransactionRepo.AssertExpectations(t)
 
 		is.Nil(result)
@@ -363,7 +494,7 @@ func TestFindAllByExternalID(t *testing.T) {
 		mockTransactionRepo.On("FindAllByExternalID", externalID, pagination).Return(nil, 0, errors.New("error on find"))
 		serviceTransaction := service.NewTransaction(mockTransactionRepo, nil)
 
-		result, total, err := serviceTransaction.FindAllByExternalID(externalID, page, limit, sort)
+		result, total, err := serviceTransaction.FindAllByExternalID(context.Background(), externalID, page, limit, sort)
 
 		is.Nil(result)
 		is.Equal(0, total)
@@ -395,7 +526,7 @@ func TestFindAllByExternalID(t *testing.T) {
 		mockTransactionRepo.On("FindAllByExternalID", transaction.ExternalID, pagination).Return(transactions, totalResult, nil)
 		serviceTransaction := service.NewTransaction(mockTransactionRepo, nil)
 
-		result, total, err := serviceTransaction.FindAllByExternalID(transaction.ExternalID, page, limit, sort)
+		result, total, err := serviceTransaction.FindAllByExternalID(context.Background(), transaction.ExternalID, page, limit, sort)
 
 		is.Nil(err)
 		is.Equal(totalResult, total)
@@ -417,7 +548,7 @@ func TestFindByFromAccountID(t *testing.T) {
 		mockTransactionRepo.On("FindByFromAccountID", transactionID, accountID).Return(nil, errors.New("error on find"))
 		serviceTransaction := service.NewTransaction(mockTransactionRepo, nil)
 
-		result, err := serviceTransaction.FindByFromAccountID(accountID, transactionID)
+		result, err := serviceTransaction.FindByFromAccountID(context.Background(), accountID, transactionID)
 		mockTransactionRepo.AssertExpectations(t)
 
 		is.Nil(result)
@@ -435,7 +566,7 @@ func TestFindByFromAccountID(t *testing.T) {
 		mockTransactionRepo.On("FindByFromAccountID", transactionID, accountID).Return(nil, nil)
 		serviceTransaction := service.NewTransaction(mockTransactionRepo, nil)
 
-		result, err := serviceTransaction.FindByFromAccountID(accountID, transactionID)
+		result, err := serviceTransaction.FindByFromAccountID(context.Background(), accountID, transactionID)
 		mockTransactionRepo.AssertExpectations(t)
 
 		is.Nil(result)
@@ -461,7 +592,7 @@ func TestFindAllByFromAccountID(t *testing.T) {
 		mockTransactionRepo.On("FindAllByFromAccountID", accountID, pagination).Return(nil, 0, errors.New("error on find"))
 		serviceTransaction := service.NewTransaction(mockTransactionRepo, nil)
 
-		result, total, err := serviceTransaction.FindAllByFromAccountID(accountID, page, limit, sort)
+		result, total, err := serviceTransaction.FindAllByFromAccountID(context.Background(), accountID, page, limit, sort)
 
 		is.Nil(result)
 		is.Equal(0, total)
@@ -493,7 +624,7 @@ func TestFindAllByFromAccountID(t *testing.T) {
 		mockTransactionRepo.On("FindAllByFromAccountID", transaction.AccountFromID, pagination).Return(transactions, totalResult, nil)
 		serviceTransaction := service.NewTransaction(mockTransactionRepo, nil)
 
-		result, total, err := serviceTransaction.FindAllByFromAccountID(transaction.AccountFromID, page, limit, sort)
+		result, total, err := serviceTransaction.FindAllByFromAccountID(context.Background(), transaction.AccountFromID, page, limit, sort)
 
 		is.Nil(err)
 		is.Equal(totalResult, total)
@@ -515,7 +646,7 @@ func TestFindByToAccountID(t *testing.T) {
 		mockTransactionRepo.On("FindByToAccountID", transactionID, accountID).Return(nil, errors.New("error on find"))
 		serviceTransaction := service.NewTransaction(mockTransactionRepo, nil)
 
-		result, err := serviceTransaction.FindByToAccountID(accountID, transactionID)
+		result, err := serviceTransaction.FindByToAccountID(context.Background(), accountID, transactionID)
 		mockTransactionRepo.AssertExpectations(t)
 
 		is.Nil(result)
@@ -533,7 +664,7 @@ func TestFindByToAccountID(t *testing.T) {
 		mockTransactionRepo.On("FindByToAccountID", transactionID, accountID).Return(nil, nil)
 		serviceTransaction := service.NewTransaction(mockTransactionRepo, nil)
 
-		result, err := serviceTransaction.FindByToAccountID(accountID, transactionID)
+		result, err := serviceTransaction.FindByToAccountID(context.Background(), accountID, transactionID)
 		mockTransactionRepo.AssertExpectations(t)
 
 		is.Nil(result)
@@ -559,7 +690,7 @@ func TestFindAllByToAccountID(t *testing.T) {
 		mockTransactionRepo.On("FindAllByToAccountID", accountID, pagination).Return(nil, 0, errors.New("error on find"))
 		serviceTransaction := service.NewTransaction(mockTransactionRepo, nil)
 
-		result, total, err := serviceTransaction.FindAllByToAccountID(accountID, page, limit, sort)
+		result, total, err := serviceTransaction.FindAllByToAccountID(context.Background(), accountID, page, limit, sort)
 
 		is.Nil(result)
 		is.Equal(0, total)
@@ -591,7 +722,7 @@ func TestFindAllByToAccountID(t *testing.T) {
 		mockTransactionRepo.On("FindAllByToAccountID", transaction.AccountToID, pagination).Return(transactions, totalResult, nil)
 		serviceTransaction := service.NewTransaction(mockTransactionRepo, nil)
 
-		result, total, err := serviceTransaction.FindAllByToAccountID(transaction.AccountToID, page, limit, sort)
+		result, total, err := serviceTransaction.FindAllByToAccountID(context.Background(), transaction.AccountToID, page, limit, sort)
 
 		is.Nil(err)
 		is.Equal(totalResult, total)
@@ -610,7 +741,7 @@ func TestFind(t *testing.T) {
 		mockTransactionRepo.On("Find", id).Return(&entity.Transaction{}, errors.New("transaction not found"))
 		transactionService := service.NewTransaction(mockTransactionRepo, nil)
 
-		result, err := transactionService.Find(id)
+		result, err := transactionService.Find(context.Background(), id)
 
 		mockTransactionRepo.AssertExpectations(t)
 
@@ -633,7 +764,7 @@ func TestFind(t *testing.T) {
 		mockTransactionRepo.On("Find", transaction.ID).Return(transaction, nil)
 		transactionService := service.NewTransaction(mockTransactionRepo, nil)
 
-		result, err := transactionService.Find(transaction.ID)
+		result, err := transactionService.Find(context.Background(), transaction.ID)
 
 		mockTransactionRepo.AssertExpectations(t)
 
@@ -661,7 +792,7 @@ func TestFindAll(t *testing.T) {
 		mockTransactionRepo.On("FindAll", pagination).Return([]*entity.Transaction{}, 0, errors.New("empty list"))
 		transactionService := service.NewTransaction(mockTransactionRepo, nil)
 
-		result, total, err := transactionService.FindAll(page, limit, sort)
+		result, total, err := transactionService.FindAll(context.Background(), page, limit, sort)
 
 		mockTransactionRepo.AssertExpectations(t)
 
@@ -691,7 +822,7 @@ func TestFindAll(t *testing.T) {
 		mockTransactionRepo.On("FindAll", pagination).Return([]*entity.Transaction{transaction}, 1, nil)
 		transactionService := service.NewTransaction(mockTransactionRepo, nil)
 
-		result, total, err := transactionService.FindAll(page, limit, sort)
+		result, total, err := transactionService.FindAll(context.Background(), page, limit, sort)
 
 		mockTransactionRepo.AssertExpectations(t)
 
@@ -711,7 +842,7 @@ func TestComplete(t *testing.T) {
 		mockTransactionRepo.On("Find", id).Return(&entity.Transaction{}, errors.New("transaction not found"))
 		transactionService := service.NewTransaction(mockTransactionRepo, nil)
 
-		result, err := transactionService.Complete(id)
+		result, err := transactionService.Complete(context.Background(), id)
 
 		mockTransactionRepo.AssertExpectations(t)
 
@@ -732,11 +863,10 @@ func TestComplete(t *testing.T) {
 		transaction, _ := entity.NewTransaction(accountFrom, accountTo, externalID, transactionType, "AOA", amount)
 
 		mockTransactionRepo.On("Find", transaction.ID).Return(transaction, nil)
-		transaction.Status = entity.TransactionCompleted
 		mockTransactionRepo.On("Save", transaction).Return(errors.New("failure on save"))
 
 		transactionService := service.NewTransaction(mockTransactionRepo, nil)
-		result, err := transactionService.Complete(transaction.ID)
+		result, err := transactionService.Complete(context.Background(), transaction.ID)
 
 		mockTransactionRepo.AssertExpectations(t)
 
@@ -745,6 +875,58 @@ func TestComplete(t *testing.T) {
 		is.EqualError(err, "failure on save")
 	})
 
+	t.Run("should not complete a payment that is not pending", func(t *testing.T) {
+		mockTransactionRepo := mock.NewMockTransactionRepository()
+		is := require.New(t)
+
+		accountFrom, _ := entity.NewAccount(3000.93)
+		accountTo, _ := entity.NewAccount(200)
+		transaction, _ := entity.NewTransaction(accountFrom, accountTo, uuid.NewV4().String(), entity.TransactionToUser, "AOA", 200)
+		transaction.Status = entity.TransactionCompleted
+
+		mockTransactionRepo.On("Find", transaction.ID).Return(transaction, nil)
+
+		transactionService := service.NewTransaction(mockTransactionRepo, nil)
+		result, err := transactionService.Complete(context.Background(), transaction.ID)
+
+		mockTransactionRepo.AssertExpectations(t)
+		mockTransactionRepo.AssertNotCalled(t, "Save", transaction)
+
+		is.Nil(result)
+		is.True(entity.IsErrorKind(err, entity.ErrorConflict))
+	})
+
+	t.Run("should queue the webhooks of the payment with its status change", func(t *testing.T) {
+		mockTransactionRepo := mock.NewMockTransactionRepository()
+		webhookRepo := mock.NewMockWebhookRepository()
+		deliveryRepo := mock.NewMockWebhookDeliveryRepository()
+		is := require.New(t)
+
+		accountFrom, _ := entity.NewAccount(3000.93)
+		accountTo, _ := entity.NewAccount(200)
+		transaction, _ := entity.NewTransaction(accountFrom, accountTo, uuid.NewV4().String(), entity.TransactionToUser, "AOA", 200)
+		webhook, _ := entity.NewWebhook(accountTo.ID, "https://merchant.kbu.test/hooks")
+		webhook.TenantID = transaction.TenantID
+
+		mockTransactionRepo.On("Find", transaction.ID).Return(transaction, nil)
+		mockTransactionRepo.On("Save", transaction).Return(nil)
+		webhookRepo.On("FindAllByAccountID", accountTo.ID).Return([]*entity.Webhook{webhook}, nil)
+		deliveryRepo.On("Register", tMock.Anything).Return(errors.New("failure on register"))
+
+		transactionService := service.NewTransaction(mockTransactionRepo, nil)
+		transactionService.Webhooks = service.NewWebhook(webhookRepo, deliveryRepo, nil)
+		transactionService.Confirmations = mock.NewMockConfirmationUnitOfWork(mockTransactionRepo, webhookRepo, deliveryRepo)
+
+		result, err := transactionService.Complete(context.Background(), transaction.ID)
+
+		mockTransactionRepo.AssertExpectations(t)
+		webhookRepo.AssertExpectations(t)
+		deliveryRepo.AssertExpectations(t)
+
+		is.Nil(result)
+		is.EqualError(err, "failure on register")
+	})
+
 	t.Run("should succeed on complete", func(t *testing.T) {
 		mockTransactionRepo := mock.NewMockTransactionRepository()
 		is := require.New(t)
@@ -757,17 +939,17 @@ func TestComplete(t *testing.T) {
 		transaction, _ := entity.NewTransaction(accountFrom, accountTo, externalID, transactionType, "AOA", amount)
 
 		mockTransactionRepo.On("Find", transaction.ID).Return(transaction, nil)
-		transaction.Status = entity.TransactionCompleted
 		mockTransactionRepo.On("Save", transaction).Return(nil)
 
 		transactionService := service.NewTransaction(mockTransactionRepo, nil)
-		result, err := transactionService.Complete(transaction.ID)
+		result, err := transactionService.Complete(context.Background(), transaction.ID)
 
 		mockTransactionRepo.AssertExpectations(t)
 
 		is.Nil(err)
 		is.NotNil(result)
 		is.Equal(result, transaction)
+		is.Equal(entity.TransactionCompleted, result.Status)
 	})
 }
 
@@ -782,7 +964,7 @@ func TestError(t *testing.T) {
 		mockTransactionRepo.On("Find", id).Return(&entity.Transaction{}, errors.New("transaction not found"))
 		transactionService := service.NewTransaction(mockTransactionRepo, nil)
 
-		result, err := transactionService.Error(id)
+		result, err := transactionService.Error(context.Background(), id)
 
 		mockTransactionRepo.AssertExpectations(t)
 
@@ -802,11 +984,10 @@ func TestError(t *testing.T) {
 		transaction, _ := entity.NewTransaction(accountFrom, accountTo, externalID, transactionType, "AOA", amount)
 
 		mockTransactionRepo.On("Find", transaction.ID).Return(transaction, nil)
-		transaction.Status = entity.TransactionCanceled
 		mockTransactionRepo.On("Save", transaction).Return(errors.New("failure on save"))
 
 		transactionService := service.NewTransaction(mockTransactionRepo, nil)
-		result, err := transactionService.Error(transaction.ID)
+		result, err := transactionService.Error(context.Background(), transaction.ID)
 
 		mockTransactionRepo.AssertExpectations(t)
 
@@ -815,6 +996,28 @@ func TestError(t *testing.T) {
 		is.EqualError(err, "failure on save")
 	})
 
+	t.Run("should not cancel a completed payment", func(t *testing.T) {
+		mockTransactionRepo := mock.NewMockTransactionRepository()
+		is := require.New(t)
+
+		accountFrom, _ := entity.NewAccount(3000.93)
+		accountTo, _ := entity.NewAccount(200)
+		transaction, _ := entity.NewTransaction(accountFrom, accountTo, uuid.NewV4().String(), entity.TransactionToUser, "AOA", 200)
+		transaction.Status = entity.TransactionCompleted
+
+		mockTransactionRepo.On("Find", transaction.ID).Return(transaction, nil)
+
+		transactionService := service.NewTransaction(mockTransactionRepo, nil)
+		result, err := transactionService.Error(context.Background(), transaction.ID)
+
+		mockTransactionRepo.AssertExpectations(t)
+		mockTransactionRepo.AssertNotCalled(t, "Save", transaction)
+
+		is.Nil(result)
+		is.True(entity.IsErrorKind(err, entity.ErrorConflict))
+		is.Equal(entity.TransactionCompleted, transaction.Status)
+	})
+
 	t.Run("should succeed error", func(t *testing.T) {
 		mockTransactionRepo := mock.NewMockTransactionRepository()
 		is := require.New(t)
@@ -827,15 +1030,68 @@ func TestError(t *testing.T) {
 		transaction, _ := entity.NewTransaction(accountFrom, accountTo, externalID, transactionType, "AOA", amount)
 
 		mockTransactionRepo.On("Find", transaction.ID).Return(transaction, nil)
-		transaction.Status = entity.TransactionCanceled
 		mockTransactionRepo.On("Save", transaction).Return(nil)
 
 		transactionService := service.NewTransaction(mockTransactionRepo, nil)
-		result, err := transactionService.Error(transaction.ID)
+		result, err := transactionService.Error(context.Background(), transaction.ID)
 
 		mockTransactionRepo.AssertExpectations(t)
 
 		is.Nil(err)
 		is.Equal(result, transaction)
+		is.Equal(entity.TransactionCanceled, result.Status)
+	})
+}
+
+func TestExport(t *testing.T) {
+	t.Parallel()
+
+	t.Run("should fail on invalid resume token", func(t *testing.T) {
+		is := require.New(t)
+
+		transactionService := service.NewTransaction(mock.NewMockTransactionRepository(), nil)
+		err := transactionService.Export(context.Background(), &entity.TransactionFilter{}, "%%%", func(*entity.Transaction, string) error { return nil })
+
+		is.NotNil(err)
+	})
+
+	t.Run("should resume after the cursor of the token", func(t *testing.T) {
+		mockTransactionRepo := mock.NewMockTransactionRepository()
+		is := require.New(t)
+
+		accountFrom, _ := entity.NewAccount(3000)
+		accountTo, _ := entity.NewAccount(200)
+		first, _ := entity.NewTransaction(accountFrom, accountTo, uuid.NewV4().String(), entity.TransactionToUser, "AOA", 20)
+		second, _ := entity.NewTransaction(accountFrom, accountTo, uuid.NewV4().String(), entity.TransactionToUser, "AOA", 30)
+		filter := &entity.TransactionFilter{AccountFromID: accountFrom.ID}
+
+		mockTransactionRepo.On("Iterate", filter, (*entity.ExportCursor)(nil)).Return([]*entity.Transaction{first, second}, nil)
+
+		transactionService := service.NewTransaction(mockTransactionRepo, nil)
+
+		var tokens []string
+		err := transactionService.Export(context.Background(), filter, "", func(transaction *entity.Transaction, resumeToken string) error {
+			tokens = append(tokens, resumeToken)
+			return nil
+		})
+
+		is.Nil(err)
+		is.Len(tokens, 2)
+
+		cursor, err := entity.ParseExportCursor(tokens[0])
+		is.Nil(err)
+		is.Equal(first.ID, cursor.ID)
+		is.True(first.CreatedAt.Equal(cursor.CreatedAt))
+
+		mockTransactionRepo.On("Iterate", filter, cursor).Return([]*entity.Transaction{second}, nil)
+
+		var resumed []string
+		err = transactionService.Export(context.Background(), filter, tokens[0], func(transaction *entity.Transaction, resumeToken string) error {
+			resumed = append(resumed, transaction.ID)
+			return nil
+		})
+
+		is.Nil(err)
+		is.Equal([]string{second.ID}, resumed)
 	})
 }
@@ -0,0 +1,267 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/EdlanioJ/kbu/payments/data/protocol"
+	"github.com/EdlanioJ/kbu/payments/data/repository"
+	"github.com/EdlanioJ/kbu/payments/domain/entity"
+)
+
+var errWebhookNotFound = errors.New("no webhook was found")
+
+type webhookPayload struct {
+	ID        string              `json:"id"`
+	Event     string              `json:"event"`
+	CreatedAt time.Time           `json:"created_at"`
+	Data      *entity.Transaction `json:"data"`
+}
+
+type Webhook struct {
+	WebhookRepository  repository.WebhookRepository
+	DeliveryRepository repository.WebhookDeliveryRepository
+	Sender             protocol.WebhookSender
+	MaxAttempts        int
+	InitialBackoff     time.Duration
+	MaxBackoff         time.Duration
+}
+
+func NewWebhook(
+	WebhookRepository repository.WebhookRepository,
+	DeliveryRepository repository.WebhookDeliveryRepository,
+	Sender protocol.WebhookSender,
+) *Webhook {
+
+	return &Webhook{
+		WebhookRepository:  WebhookRepository,
+		DeliveryRepository: DeliveryRepository,
+		Sender:             Sender,
+		MaxAttempts:        8,
+		InitialBackoff:     30 * time.Second,
+		MaxBackoff:         6 * time.Hour,
+	}
+}
+
+func (w *Webhook) Register(ctx context.Context, accountID, url string) (*entity.Webhook, error) {
+	webhook, err := entity.NewWebhook(accountID, url)
+
+	if err != nil {
+		return nil, err
+	}
+
+	err = w.WebhookRepository.Register(ctx, webhook)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return webhook, nil
+}
+
+func (w *Webhook) Notify(ctx context.Context, event string, transaction *entity.Transaction) ([]*entity.WebhookDelivery, error) {
+	return w.queue(ctx, w.WebhookRepository, w.DeliveryRepository, event, transaction)
+}
+
+// queue registers a delivery of event for every active webhook of the
+// receiving account, through the repositories of the caller's transaction.
+func (w *Webhook) queue(
+	ctx context.Context,
+	webhookRepository repository.WebhookRepository,
+	deliveryRepository repository.WebhookDeliveryRepository,
+	event string,
+	transaction *entity.Transaction,
+) ([]*entity.WebhookDelivery, error) {
+	webhooks, err := webhookRepository.FindAllByAccountID(ctx, transaction.AccountToID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var deliveries []*entity.WebhookDelivery
+
+	for _, webhook := range webhooks {
+		if !webhook.Active || webhook.TenantID != transaction.TenantID {
+			continue
+		}
+
+		payload, err := json.Marshal(&webhookPayload{
+			ID:        transaction.ID,
+			Event:     event,
+			CreatedAt: time.Now(),
+			Data:      transaction,
+		})
+
+		if err != nil {
+			return nil, err
+		}
+
+		delivery, err := entity.NewWebhookDelivery(webhook.ID, transaction.ID, event, payload)
+
+		if err != nil {
+			return nil, err
+		}
+
+		delivery.TenantID = webhook.TenantID
+
+		err = deliveryRepository.Register(ctx, delivery)
+
+		if err != nil {
+			return nil, err
+		}
+
+		deliveries = append(deliveries, delivery)
+	}
+
+	return deliveries, nil
+}
+
+func (w *Webhook) Deliver(ctx context.Context, deliveryID string) (*entity.WebhookDelivery, error) {
+	delivery, err := w.DeliveryRepository.Find(ctx, deliveryID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return w.deliver(ctx, delivery)
+}
+
+func (w *Webhook) DeliverDue(ctx context.Context, limit int) (int, error) {
+	deliveries, err := w.DeliveryRepository.FindAllDue(ctx, time.Now(), limit)
+
+	if err != nil {
+		return 0, err
+	}
+
+	var failed int
+	var failure error
+
+	for _, delivery := range deliveries {
+		attempts := delivery.Attempts
+
+		_, err = w.deliver(ctx, delivery)
+
+		if err != nil {
+			delivery.Attempts = attempts
+			failed++
+			failure = err
+
+			if backoffErr := w.recordFailure(ctx, delivery, err); backoffErr != nil {
+				failure = fmt.Errorf("%v; could not back off the delivery: %w", err, backoffErr)
+			}
+		}
+	}
+
+	if failure != nil {
+		return len(deliveries) - failed, fmt.Errorf("%d of %d webhook deliveries failed: %w", failed, len(deliveries), failure)
+	}
+
+	return len(deliveries), nil
+}
+
+// recordFailure backs off a delivery that could not be attempted, so one bad
+// delivery is not picked again on every run ahead of the others.
+func (w *Webhook) recordFailure(ctx context.Context, delivery *entity.WebhookDelivery, cause error) error {
+	delivery.Fail(w.MaxAttempts, w.backoff(delivery.Attempts+1))
+
+	err := w.DeliveryRepository.RegisterAttempt(ctx, entity.NewWebhookAttempt(delivery.ID, 0, cause, 0))
+
+	if err != nil {
+		return err
+	}
+
+	return w.DeliveryRepository.Save(ctx, delivery)
+}
+
+func (w *Webhook) Redeliver(ctx context.Context, deliveryID string) (*entity.WebhookDelivery, error) {
+	delivery, err := w.DeliveryRepository.Find(ctx, deliveryID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	delivery.Reset()
+
+	return w.deliver(ctx, delivery)
+}
+
+func (w *Webhook) FindDelivery(ctx context.Context, deliveryID string) (*entity.WebhookDelivery, []*entity.WebhookAttempt, error) {
+	delivery, err := w.DeliveryRepository.Find(ctx, deliveryID)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	attempts, err := w.DeliveryRepository.FindAllAttempts(ctx, deliveryID)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return delivery, attempts, nil
+}
+
+func (w *Webhook) deliver(ctx context.Context, delivery *entity.WebhookDelivery) (*entity.WebhookDelivery, error) {
+	webhook, err := w.WebhookRepository.Find(ctx, delivery.WebhookID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if webhook == nil {
+		return nil, errWebhookNotFound
+	}
+
+	payload := []byte(delivery.Payload)
+	timestamp := time.Now()
+
+	headers := map[string]string{
+		"Content-Type":    "application/json",
+		"X-Kbu-Event":     delivery.Event,
+		"X-Kbu-Delivery":  delivery.ID,
+		"X-Kbu-Timestamp": strconv.FormatInt(timestamp.Unix(), 10),
+		"X-Kbu-Signature": webhook.Sign(timestamp, payload),
+	}
+
+	statusCode, sendErr := w.Sender.Send(webhook.URL, headers, payload)
+	duration := time.Since(timestamp)
+
+	if sendErr == nil && (statusCode < 200 || statusCode > 299) {
+		sendErr = fmt.Errorf("unexpected status code %d", statusCode)
+	}
+
+	if sendErr != nil {
+		delivery.Fail(w.MaxAttempts, w.backoff(delivery.Attempts+1))
+	} else {
+		delivery.Succeed()
+	}
+
+	err = w.DeliveryRepository.RegisterAttempt(ctx, entity.NewWebhookAttempt(delivery.ID, statusCode, sendErr, duration))
+
+	if err != nil {
+		return nil, err
+	}
+
+	err = w.DeliveryRepository.Save(ctx, delivery)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return delivery, nil
+}
+
+func (w *Webhook) backoff(attempt int) time.Duration {
+	backoff := float64(w.InitialBackoff) * math.Pow(2, float64(attempt-1))
+
+	if backoff > float64(w.MaxBackoff) {
+		return w.MaxBackoff
+	}
+
+	return time.Duration(backoff)
+}
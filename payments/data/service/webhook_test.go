@@ -0,0 +1,376 @@
+package service_test
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/EdlanioJ/kbu/payments/data/service"
+	"github.com/EdlanioJ/kbu/payments/data/service/mock"
+	"github.com/EdlanioJ/kbu/payments/domain/entity"
+	infra "github.com/EdlanioJ/kbu/payments/infra/http"
+	uuid "github.com/satori/go.uuid"
+	tMock "github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type receivedWebhook struct {
+	headers http.Header
+	body    []byte
+}
+
+func newWebhookServer(statusCode int) (*httptest.Server, chan *receivedWebhook) {
+	received := make(chan *receivedWebhook, 10)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		received <- &receivedWebhook{headers: r.Header, body: body}
+		w.WriteHeader(statusCode)
+	}))
+
+	return server, received
+}
+
+func newWebhookDelivery(webhook *entity.Webhook) *entity.WebhookDelivery {
+	delivery, _ := entity.NewWebhookDelivery(webhook.ID, uuid.NewV4().String(), entity.WebhookPaymentCompleted, []byte(`{"id":"1"}`))
+
+	return delivery
+}
+
+func TestWebhookNotify(t *testing.T) {
+	t.Parallel()
+
+	t.Run("should fail on find webhooks", func(t *testing.T) {
+		is := require.New(t)
+		webhookRepo := mock.NewMockWebhookRepository()
+
+		accountFrom, _ := entity.NewAccount(3000)
+		accountTo, _ := entity.NewAccount(200)
+		transaction, _ := entity.NewTransaction(accountFrom, accountTo, uuid.NewV4().String(), entity.TransactionToStore, "AOA", 30)
+
+		webhookRepo.On("FindAllByAccountID", accountTo.ID).Return(nil, errors.New("db error"))
+
+		webhookService := service.NewWebhook(webhookRepo, nil, nil)
+		result, err := webhookService.Notify(context.Background(), entity.WebhookPaymentCompleted, transaction)
+
+		is.Nil(result)
+		is.EqualError(err, "db error")
+	})
+
+	t.Run("should register a delivery per active webhook", func(t *testing.T) {
+		is := require.New(t)
+		webhookRepo := mock.NewMockWebhookRepository()
+		deliveryRepo := mock.NewMockWebhookDeliveryRepository()
+
+		accountFrom, _ := entity.NewAccount(3000)
+		accountTo, _ := entity.NewAccount(200)
+		transaction, _ := entity.NewTransaction(accountFrom, accountTo, uuid.NewV4().String(), entity.TransactionToStore, "AOA", 30)
+
+		active, _ := entity.NewWebhook(accountTo.ID, "https://store.kbu.test/hooks")
+		active.TenantID = entity.DefaultTenant
+		inactive, _ := entity.NewWebhook(accountTo.ID, "https://store.kbu.test/old")
+		inactive.TenantID = entity.DefaultTenant
+		inactive.Active = false
+		foreign, _ := entity.NewWebhook(accountTo.ID, "https://globex.kbu.test/hooks")
+		foreign.TenantID = "globex"
+
+		webhookRepo.On("FindAllByAccountID", accountTo.ID).Return([]*entity.Webhook{active, inactive, foreign}, nil)
+		deliveryRepo.On("Register", tMock.Anything).Return(nil)
+
+		webhookService := service.NewWebhook(webhookRepo, deliveryRepo, nil)
+		result, err := webhookService.Notify(context.Background(), entity.WebhookPaymentCompleted, transaction)
+
+		is.Nil(err)
+		is.Len(result, 1)
+		is.Equal(active.ID, result[0].WebhookID)
+		is.Equal(entity.DefaultTenant, result[0].TenantID)
+		is.Equal(transaction.ID, result[0].TransactionID)
+		is.Equal(entity.WebhookDeliveryPending, result[0].Status)
+		is.Contains(result[0].Payload, entity.WebhookPaymentCompleted)
+		deliveryRepo.AssertNumberOfCalls(t, "Register", 1)
+	})
+}
+
+func TestWebhookDeliver(t *testing.T) {
+	t.Parallel()
+
+	t.Run("should send a signed payload", func(t *testing.T) {
+		is := require.New(t)
+		server, received := newWebhookServer(http.StatusOK)
+		defer server.Close()
+
+		webhookRepo := mock.NewMockWebhookRepository()
+		deliveryRepo := mock.NewMockWebhookDeliveryRepository()
+
+		webhook, _ := entity.NewWebhook(uuid.NewV4().String(), server.URL)
+		delivery := newWebhookDelivery(webhook)
+
+		webhookRepo.On("Find", webhook.ID).Return(webhook, nil)
+		deliveryRepo.On("Find", delivery.ID).Return(delivery, nil)
+		deliveryRepo.On("RegisterAttempt", tMock.Anything).Return(nil)
+		deliveryRepo.On("Save", delivery).Return(nil)
+
+		webhookService := service.NewWebhook(webhookRepo, deliveryRepo, &infra.WebhookSenderHTTP{Client: server.Client()})
+		result, err := webhookService.Deliver(context.Background(), delivery.ID)
+
+		is.Nil(err)
+		is.Equal(entity.WebhookDeliveryDelivered, result.Status)
+		is.Equal(1, result.Attempts)
+
+		request := <-received
+		is.Equal(delivery.Payload, string(request.body))
+		is.Equal(delivery.ID, request.headers.Get("X-Kbu-Delivery"))
+		is.Equal(entity.WebhookPaymentCompleted, request.headers.Get("X-Kbu-Event"))
+
+		timestamp := request.headers.Get("X-Kbu-Timestamp")
+		mac := hmac.New(sha256.New, []byte(webhook.Secret))
+		fmt.Fprintf(mac, "%s.%s", timestamp, request.body)
+		expected := fmt.Sprintf("t=%s,v1=%s", timestamp, hex.EncodeToString(mac.Sum(nil)))
+
+		is.Equal(expected, request.headers.Get("X-Kbu-Signature"))
+
+		attempt := deliveryRepo.Calls[1].Arguments.Get(0).(*entity.WebhookAttempt)
+		is.Equal(http.StatusOK, attempt.StatusCode)
+		is.Empty(attempt.Error)
+	})
+
+	t.Run("should schedule a retry with backoff on failure", func(t *testing.T) {
+		is := require.New(t)
+		server, _ := newWebhookServer(http.StatusInternalServerError)
+		defer server.Close()
+
+		webhookRepo := mock.NewMockWebhookRepository()
+		deliveryRepo := mock.NewMockWebhookDeliveryRepository()
+
+		webhook, _ := entity.NewWebhook(uuid.NewV4().String(), server.URL)
+		delivery := newWebhookDelivery(webhook)
+		delivery.Attempts = 2
+
+		webhookRepo.On("Find", webhook.ID).Return(webhook, nil)
+		deliveryRepo.On("Find", delivery.ID).Return(delivery, nil)
+		deliveryRepo.On("RegisterAttempt", tMock.Anything).Return(nil)
+		deliveryRepo.On("Save", delivery).Return(nil)
+
+		webhookService := service.NewWebhook(webhookRepo, deliveryRepo, &infra.WebhookSenderHTTP{Client: server.Client()})
+		webhookService.InitialBackoff = time.Minute
+
+		before := time.Now()
+		result, err := webhookService.Deliver(context.Background(), delivery.ID)
+
+		is.Nil(err)
+		is.Equal(entity.WebhookDeliveryPending, result.Status)
+		is.Equal(3, result.Attempts)
+		is.True(result.NextAttemptAt.After(before.Add(4 * time.Minute)))
+
+		attempt := deliveryRepo.Calls[1].Arguments.Get(0).(*entity.WebhookAttempt)
+		is.Equal(http.StatusInternalServerError, attempt.StatusCode)
+		is.Equal("unexpected status code 500", attempt.Error)
+	})
+
+	t.Run("should not send to an internal address", func(t *testing.T) {
+		is := require.New(t)
+		server, received := newWebhookServer(http.StatusOK)
+		defer server.Close()
+
+		webhookRepo := mock.NewMockWebhookRepository()
+		deliveryRepo := mock.NewMockWebhookDeliveryRepository()
+
+		webhook, _ := entity.NewWebhook(uuid.NewV4().String(), server.URL)
+		delivery := newWebhookDelivery(webhook)
+
+		webhookRepo.On("Find", webhook.ID).Return(webhook, nil)
+		deliveryRepo.On("Find", delivery.ID).Return(delivery, nil)
+		deliveryRepo.On("RegisterAttempt", tMock.Anything).Return(nil)
+		deliveryRepo.On("Save", delivery).Return(nil)
+
+		webhookService := service.NewWebhook(webhookRepo, deliveryRepo, infra.NewWebhookSender(time.Second))
+		result, err := webhookService.Deliver(context.Background(), delivery.ID)
+
+		is.Nil(err)
+		is.Equal(entity.WebhookDeliveryPending, result.Status)
+		is.Empty(received)
+
+		attempt := deliveryRepo.Calls[1].Arguments.Get(0).(*entity.WebhookAttempt)
+		is.Contains(attempt.Error, "is not public")
+	})
+
+	t.Run("should not follow redirects", func(t *testing.T) {
+		is := require.New(t)
+		redirected := make(chan struct{}, 1)
+
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/internal" {
+				redirected <- struct{}{}
+				return
+			}
+
+			http.Redirect(w, r, "/internal", http.StatusTemporaryRedirect)
+		}))
+		defer server.Close()
+
+		webhookRepo := mock.NewMockWebhookRepository()
+		deliveryRepo := mock.NewMockWebhookDeliveryRepository()
+
+		webhook, _ := entity.NewWebhook(uuid.NewV4().String(), server.URL)
+		delivery := newWebhookDelivery(webhook)
+
+		webhookRepo.On("Find", webhook.ID).Return(webhook, nil)
+		deliveryRepo.On("Find", delivery.ID).Return(delivery, nil)
+		deliveryRepo.On("RegisterAttempt", tMock.Anything).Return(nil)
+		deliveryRepo.On("Save", delivery).Return(nil)
+
+		sender := infra.NewWebhookSender(time.Second)
+		sender.Client.Transport = server.Client().Transport
+
+		webhookService := service.NewWebhook(webhookRepo, deliveryRepo, sender)
+		result, err := webhookService.Deliver(context.Background(), delivery.ID)
+
+		is.Nil(err)
+		is.Equal(entity.WebhookDeliveryPending, result.Status)
+		is.Empty(redirected)
+
+		attempt := deliveryRepo.Calls[1].Arguments.Get(0).(*entity.WebhookAttempt)
+		is.Contains(attempt.Error, "do not follow redirects")
+	})
+
+	t.Run("should give up after max attempts", func(t *testing.T) {
+		is := require.New(t)
+		server, _ := newWebhookServer(http.StatusBadGateway)
+		defer server.Close()
+
+		webhookRepo := mock.NewMockWebhookRepository()
+		deliveryRepo := mock.NewMockWebhookDeliveryRepository()
+
+		webhook, _ := entity.NewWebhook(uuid.NewV4().String(), server.URL)
+		delivery := newWebhookDelivery(webhook)
+		delivery.Attempts = 7
+
+		webhookRepo.On("Find", webhook.ID).Return(webhook, nil)
+		deliveryRepo.On("FindAllDue", tMock.Anything, 10).Return([]*entity.WebhookDelivery{delivery}, nil)
+		deliveryRepo.On("RegisterAttempt", tMock.Anything).Return(nil)
+		deliveryRepo.On("Save", delivery).Return(nil)
+
+		webhookService := service.NewWebhook(webhookRepo, deliveryRepo, &infra.WebhookSenderHTTP{Client: server.Client()})
+		delivered, err := webhookService.DeliverDue(context.Background(), 10)
+
+		is.Nil(err)
+		is.Equal(1, delivered)
+		is.Equal(entity.WebhookDeliveryFailed, delivery.Status)
+		is.Equal(8, delivery.Attempts)
+	})
+
+	t.Run("should keep delivering after a failed delivery", func(t *testing.T) {
+		is := require.New(t)
+		server, received := newWebhookServer(http.StatusOK)
+		defer server.Close()
+
+		webhookRepo := mock.NewMockWebhookRepository()
+		deliveryRepo := mock.NewMockWebhookDeliveryRepository()
+
+		removed, _ := entity.NewWebhook(uuid.NewV4().String(), server.URL)
+		webhook, _ := entity.NewWebhook(uuid.NewV4().String(), server.URL)
+		orphan := newWebhookDelivery(removed)
+		delivery := newWebhookDelivery(webhook)
+
+		webhookRepo.On("Find", removed.ID).Return(nil, nil)
+		webhookRepo.On("Find", webhook.ID).Return(webhook, nil)
+		deliveryRepo.On("FindAllDue", tMock.Anything, 10).Return([]*entity.WebhookDelivery{orphan, delivery}, nil)
+		deliveryRepo.On("RegisterAttempt", tMock.Anything).Return(nil)
+		deliveryRepo.On("Save", tMock.Anything).Return(nil)
+
+		webhookService := service.NewWebhook(webhookRepo, deliveryRepo, &infra.WebhookSenderHTTP{Client: server.Client()})
+		delivered, err := webhookService.DeliverDue(context.Background(), 10)
+
+		is.EqualError(err, "1 of 2 webhook deliveries failed: no webhook was found")
+		is.Equal(1, delivered)
+		is.Equal(entity.WebhookDeliveryDelivered, delivery.Status)
+		is.Equal(entity.WebhookDeliveryPending, orphan.Status)
+		is.Equal(1, orphan.Attempts)
+		is.True(orphan.NextAttemptAt.After(time.Now()))
+		is.NotNil(<-received)
+		deliveryRepo.AssertCalled(t, "Save", orphan)
+	})
+
+	t.Run("should report a failed delivery that could not be backed off", func(t *testing.T) {
+		is := require.New(t)
+
+		webhookRepo := mock.NewMockWebhookRepository()
+		deliveryRepo := mock.NewMockWebhookDeliveryRepository()
+
+		removed, _ := entity.NewWebhook(uuid.NewV4().String(), "https://example.com/hooks")
+		orphan := newWebhookDelivery(removed)
+
+		webhookRepo.On("Find", removed.ID).Return(nil, nil)
+		deliveryRepo.On("FindAllDue", tMock.Anything, 10).Return([]*entity.WebhookDelivery{orphan}, nil)
+		deliveryRepo.On("RegisterAttempt", tMock.Anything).Return(errors.New("db error"))
+
+		webhookService := service.NewWebhook(webhookRepo, deliveryRepo, &infra.WebhookSenderHTTP{})
+		delivered, err := webhookService.DeliverDue(context.Background(), 10)
+
+		is.EqualError(err, "1 of 1 webhook deliveries failed: no webhook was found; could not back off the delivery: db error")
+		is.Equal(0, delivered)
+		deliveryRepo.AssertNotCalled(t, "Save", orphan)
+	})
+
+	t.Run("should redeliver a failed delivery", func(t *testing.T) {
+		is := require.New(t)
+		server, received := newWebhookServer(http.StatusNoContent)
+		defer server.Close()
+
+		webhookRepo := mock.NewMockWebhookRepository()
+		deliveryRepo := mock.NewMockWebhookDeliveryRepository()
+
+		webhook, _ := entity.NewWebhook(uuid.NewV4().String(), server.URL)
+		delivery := newWebhookDelivery(webhook)
+		delivery.Attempts = 8
+		delivery.Status = entity.WebhookDeliveryFailed
+
+		webhookRepo.On("Find", webhook.ID).Return(webhook, nil)
+		deliveryRepo.On("Find", delivery.ID).Return(delivery, nil)
+		deliveryRepo.On("RegisterAttempt", tMock.Anything).Return(nil)
+		deliveryRepo.On("Save", delivery).Return(nil)
+
+		webhookService := service.NewWebhook(webhookRepo, deliveryRepo, &infra.WebhookSenderHTTP{Client: server.Client()})
+		result, err := webhookService.Redeliver(context.Background(), delivery.ID)
+
+		is.Nil(err)
+		is.Equal(entity.WebhookDeliveryDelivered, result.Status)
+		is.Equal(9, result.Attempts)
+		is.NotNil(<-received)
+	})
+
+	t.Run("should fail on unreachable endpoint", func(t *testing.T) {
+		is := require.New(t)
+		server, _ := newWebhookServer(http.StatusOK)
+		server.Close()
+
+		webhookRepo := mock.NewMockWebhookRepository()
+		deliveryRepo := mock.NewMockWebhookDeliveryRepository()
+
+		webhook, _ := entity.NewWebhook(uuid.NewV4().String(), server.URL)
+		delivery := newWebhookDelivery(webhook)
+
+		webhookRepo.On("Find", webhook.ID).Return(webhook, nil)
+		deliveryRepo.On("Find", delivery.ID).Return(delivery, nil)
+		deliveryRepo.On("RegisterAttempt", tMock.Anything).Return(nil)
+		deliveryRepo.On("Save", delivery).Return(nil)
+
+		webhookService := service.NewWebhook(webhookRepo, deliveryRepo, &infra.WebhookSenderHTTP{Client: server.Client()})
+		result, err := webhookService.Deliver(context.Background(), delivery.ID)
+
+		is.Nil(err)
+		is.Equal(entity.WebhookDeliveryPending, result.Status)
+
+		attempt := deliveryRepo.Calls[1].Arguments.Get(0).(*entity.WebhookAttempt)
+		is.Equal(0, attempt.StatusCode)
+		is.NotEmpty(attempt.Error)
+	})
+}
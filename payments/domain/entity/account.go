@@ -1,7 +1,6 @@
 package entity
 
 import (
-	"errors"
 	"time"
 
 	"github.com/asaskevich/govalidator"
@@ -9,8 +8,10 @@ import (
 )
 
 type Account struct {
-	Base    `valid:"required"`
-	Balance float64 `json:"balance" gorm:"type:float" valid:"-"`
+	Base     `valid:"required"`
+	TenantID string  `json:"tenant_id" gorm:"type:varchar(64);not null;default:'default';index" valid:"-"`
+	Balance  float64 `json:"balance" gorm:"type:float" valid:"-"`
+	Frozen   bool    `json:"frozen" gorm:"not null;default:false" valid:"-"`
 }
 
 func (a *Account) isValid() error {
@@ -24,7 +25,8 @@ func (a *Account) isValid() error {
 
 func NewAccount(balance float64) (*Account, error) {
 	account := Account{
-		Balance: balance,
+		TenantID: DefaultTenant,
+		Balance:  balance,
 	}
 
 	account.ID = uuid.NewV4().String()
@@ -50,8 +52,12 @@ func (a *Account) Deposit(amount float64) error {
 }
 
 func (a *Account) Withdow(amount float64) error {
+	if a.Frozen {
+		return AccountFrozen(a.ID)
+	}
+
 	if a.Balance < amount {
-		return errors.New("account does not have balance")
+		return InsufficientFunds(a.ID, a.Balance, amount)
 	}
 
 	a.Balance -= amount
@@ -0,0 +1,114 @@
+package entity
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/asaskevich/govalidator"
+	uuid "github.com/satori/go.uuid"
+)
+
+const AuditActorSystem string = "system"
+
+var ErrAuditImmutable = errors.New("audit entries cannot be changed")
+
+type AuditEntry struct {
+	ID         string    `json:"id" gorm:"column:id;type:uuid;primary key" valid:"uuid"`
+	TenantID   string    `json:"tenant_id" gorm:"type:varchar(64);not null;default:'default';index" valid:"-"`
+	Actor      string    `json:"actor" gorm:"type:varchar(255);index" valid:"notnull"`
+	Action     string    `json:"action" gorm:"type:varchar(50)" valid:"notnull"`
+	EntityType string    `json:"entity_type" gorm:"type:varchar(30);index:idx_audit_entity" valid:"notnull"`
+	EntityID   string    `json:"entity_id" gorm:"type:uuid;index:idx_audit_entity" valid:"notnull,uuid"`
+	Before     string    `json:"before" gorm:"type:text" valid:"-"`
+	After      string    `json:"after" gorm:"type:text" valid:"-"`
+	RequestID  string    `json:"request_id" gorm:"type:varchar(128)" valid:"-"`
+	CreatedAt  time.Time `json:"created_at" gorm:"index" valid:"-"`
+}
+
+type AuditFilter struct {
+	Actor      string    `json:"actor"`
+	Action     string    `json:"action"`
+	EntityType string    `json:"entity_type"`
+	EntityID   string    `json:"entity_id"`
+	From       time.Time `json:"from"`
+	To         time.Time `json:"to"`
+}
+
+func (a *AuditEntry) isValid() error {
+	_, err := govalidator.ValidateStruct(a)
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// The audit log is append-only: gorm runs these hooks before any update or
+// delete of an entry and aborts the statement.
+func (a *AuditEntry) BeforeUpdate() error {
+	return ErrAuditImmutable
+}
+
+func (a *AuditEntry) BeforeDelete() error {
+	return ErrAuditImmutable
+}
+
+func NewAuditEntry(ctx context.Context, action, entityType, entityID, before, after string) (*AuditEntry, error) {
+	tenant, ok := Tenant(ctx)
+
+	if !ok {
+		tenant = DefaultTenant
+	}
+
+	entry := AuditEntry{
+		TenantID:   tenant,
+		Actor:      AuditActor(ctx),
+		Action:     action,
+		EntityType: entityType,
+		EntityID:   entityID,
+		Before:     before,
+		After:      after,
+		RequestID:  RequestID(ctx),
+	}
+
+	entry.ID = uuid.NewV4().String()
+	entry.CreatedAt = time.Now()
+
+	err := entry.isValid()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &entry, nil
+}
+
+type auditActorKey struct{}
+
+type requestIDKey struct{}
+
+func WithAuditActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, auditActorKey{}, actor)
+}
+
+// AuditActor is the authenticated subject behind ctx, or the system actor for
+// work the service starts on its own, such as consuming confirmations.
+func AuditActor(ctx context.Context) string {
+	if actor, ok := ctx.Value(auditActorKey{}).(string); ok && actor != "" {
+		return actor
+	}
+
+	return AuditActorSystem
+}
+
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+
+	return id
+}
@@ -0,0 +1,151 @@
+package entity
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/asaskevich/govalidator"
+	uuid "github.com/satori/go.uuid"
+)
+
+const (
+	BatchAtomic     string = "atomic"
+	BatchBestEffort string = "best_effort"
+
+	BatchProcessing string = "processing"
+	BatchCompleted  string = "completed"
+	BatchPartial    string = "partially_completed"
+	BatchFailed     string = "failed"
+
+	BatchItemPending    string = "pending"
+	BatchItemRegistered string = "registered"
+	BatchItemFailed     string = "failed"
+	BatchItemRolledBack string = "rolled_back"
+	BatchItemSkipped    string = "skipped"
+)
+
+type Batch struct {
+	Base      `valid:"required"`
+	TenantID  string `json:"tenant_id" gorm:"type:varchar(64);not null;default:'default';index" valid:"-"`
+	Mode      string `json:"mode" gorm:"type:varchar(20)" valid:"notnull,in(atomic|best_effort)"`
+	Status    string `json:"status" gorm:"type:varchar(20)" valid:"notnull"`
+	Total     int    `json:"total" valid:"-"`
+	Succeeded int    `json:"succeeded" valid:"-"`
+	Failed    int    `json:"failed" valid:"-"`
+
+	IdempotencyKey string `json:"-" gorm:"type:varchar(128)" valid:"-"`
+}
+
+func (b *Batch) isValid() error {
+	_, err := govalidator.ValidateStruct(b)
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (b *Batch) Summarize(items []*BatchItem) {
+	b.Total = len(items)
+	b.Succeeded = 0
+	b.Failed = 0
+
+	for _, item := range items {
+		if item.Status == BatchItemRegistered {
+			b.Succeeded++
+		} else {
+			b.Failed++
+		}
+	}
+
+	switch {
+	case b.Succeeded == b.Total:
+		b.Status = BatchCompleted
+	case b.Succeeded == 0:
+		b.Status = BatchFailed
+	default:
+		b.Status = BatchPartial
+	}
+}
+
+func NewBatch(mode string) (*Batch, error) {
+	batch := Batch{
+		Mode:   mode,
+		Status: BatchProcessing,
+	}
+
+	batch.ID = uuid.NewV4().String()
+	batch.CreatedAt = time.Now()
+
+	err := batch.isValid()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &batch, nil
+}
+
+type BatchItem struct {
+	Base          `valid:"required"`
+	BatchID       string  `json:"batch_id" gorm:"column:batch_id;type:uuid;not null;index" valid:"-"`
+	Position      int     `json:"position" valid:"-"`
+	AccountFromID string  `json:"account_from" gorm:"column:account_from_id;type:varchar(36)" valid:"-"`
+	AccountToID   string  `json:"account_to" gorm:"column:account_to_id;type:varchar(36)" valid:"-"`
+	ExternalID    string  `json:"external_id" gorm:"column:external_id;type:varchar(36)" valid:"-"`
+	Type          string  `json:"type" gorm:"type:varchar(30)" valid:"-"`
+	Currency      string  `json:"currency" gorm:"type:varchar(5)" valid:"-"`
+	Amount        float64 `json:"amount" gorm:"type:float" valid:"-"`
+	Status        string  `json:"status" gorm:"type:varchar(20)" valid:"notnull"`
+	TransactionID string  `json:"transaction_id" gorm:"column:transaction_id;type:varchar(36)" valid:"-"`
+	Error         string  `json:"error" gorm:"type:text" valid:"-"`
+
+	Transaction *Transaction `json:"-" gorm:"-" valid:"-"`
+}
+
+func (i *BatchItem) Register(transaction *Transaction) {
+	i.Status = BatchItemRegistered
+	i.TransactionID = transaction.ID
+	i.Transaction = transaction
+	i.Error = ""
+}
+
+func (i *BatchItem) Fail(cause error) {
+	i.Status = BatchItemFailed
+	i.Error = cause.Error()
+}
+
+// IdempotencyKey is the key the payment of the item is made with, derived from
+// the key of its batch and its position, so a resumed batch replays the
+// payments of its items instead of paying them again. Items of batches without
+// a key have none.
+func (i *BatchItem) IdempotencyKey(batchKey string) string {
+	if batchKey == "" {
+		return ""
+	}
+
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s:%d", batchKey, i.Position)))
+
+	return "batch:" + hex.EncodeToString(sum[:])
+}
+
+func NewBatchItem(position int, accountFromID, accountToID, externalID, transactionType, currency string, amount float64) *BatchItem {
+	item := BatchItem{
+		Position:      position,
+		AccountFromID: accountFromID,
+		AccountToID:   accountToID,
+		ExternalID:    externalID,
+		Type:          transactionType,
+		Currency:      currency,
+		Amount:        amount,
+		Status:        BatchItemPending,
+	}
+
+	item.ID = uuid.NewV4().String()
+	item.CreatedAt = time.Now()
+
+	return &item
+}
@@ -0,0 +1,108 @@
+package entity
+
+import (
+	"errors"
+	"fmt"
+)
+
+const (
+	ErrorNotFound          string = "NOT_FOUND"
+	ErrorInsufficientFunds string = "INSUFFICIENT_FUNDS"
+	ErrorInvalidArgument   string = "INVALID_ARGUMENT"
+	ErrorConflict          string = "CONFLICT"
+	ErrorAccountFrozen     string = "ACCOUNT_FROZEN"
+	ErrorUnauthenticated   string = "UNAUTHENTICATED"
+	ErrorPermissionDenied  string = "PERMISSION_DENIED"
+)
+
+type DomainError struct {
+	Kind     string
+	Message  string
+	Metadata map[string]string
+	Fields   map[string]string
+}
+
+func (e *DomainError) Error() string {
+	return e.Message
+}
+
+func (e *DomainError) Is(target error) bool {
+	other, ok := target.(*DomainError)
+
+	return ok && other.Kind == e.Kind && (other.Message == "" || other.Message == e.Message)
+}
+
+func AsDomainError(err error) (*DomainError, bool) {
+	var domainError *DomainError
+
+	if errors.As(err, &domainError) {
+		return domainError, true
+	}
+
+	return nil, false
+}
+
+func IsErrorKind(err error, kind string) bool {
+	domainError, ok := AsDomainError(err)
+
+	return ok && domainError.Kind == kind
+}
+
+func NotFound(resource, id string) *DomainError {
+	return &DomainError{
+		Kind:     ErrorNotFound,
+		Message:  fmt.Sprintf("no %s was found", resource),
+		Metadata: map[string]string{"resource": resource, "id": id},
+	}
+}
+
+func InsufficientFunds(accountID string, balance, amount float64) *DomainError {
+	return &DomainError{
+		Kind:    ErrorInsufficientFunds,
+		Message: "account does not have balance",
+		Metadata: map[string]string{
+			"account_id": accountID,
+			"balance":    fmt.Sprintf("%.2f", balance),
+			"amount":     fmt.Sprintf("%.2f", amount),
+		},
+	}
+}
+
+func InvalidArgument(message string, fields map[string]string) *DomainError {
+	return &DomainError{
+		Kind:    ErrorInvalidArgument,
+		Message: message,
+		Fields:  fields,
+	}
+}
+
+func Conflict(resource, id, message string) *DomainError {
+	return &DomainError{
+		Kind:     ErrorConflict,
+		Message:  message,
+		Metadata: map[string]string{"resource": resource, "id": id},
+	}
+}
+
+func AccountFrozen(accountID string) *DomainError {
+	return &DomainError{
+		Kind:     ErrorAccountFrozen,
+		Message:  "account is frozen",
+		Metadata: map[string]string{"account_id": accountID},
+	}
+}
+
+func Unauthenticated(message string) *DomainError {
+	return &DomainError{
+		Kind:    ErrorUnauthenticated,
+		Message: message,
+	}
+}
+
+func PermissionDenied(subject, resource, id string) *DomainError {
+	return &DomainError{
+		Kind:     ErrorPermissionDenied,
+		Message:  fmt.Sprintf("caller is not allowed to act on this %s", resource),
+		Metadata: map[string]string{"subject": subject, "resource": resource, "id": id},
+	}
+}
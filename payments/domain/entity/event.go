@@ -0,0 +1,181 @@
+package entity
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/asaskevich/govalidator"
+	uuid "github.com/satori/go.uuid"
+)
+
+const (
+	AggregateTransaction string = "transaction"
+	AggregateAccount     string = "account"
+
+	EventTransactionRegistered string = "transaction.registered"
+	EventTransactionCompleted  string = "transaction.completed"
+	EventTransactionCanceled   string = "transaction.canceled"
+	EventTransactionUpdated    string = "transaction.updated"
+
+	EventAccountOpened   string = "account.opened"
+	EventAccountDebited  string = "account.debited"
+	EventAccountCredited string = "account.credited"
+	EventAccountUpdated  string = "account.updated"
+)
+
+type Event struct {
+	ID            string    `json:"id" gorm:"column:id;type:uuid;primary key" valid:"uuid"`
+	TenantID      string    `json:"tenant_id" gorm:"type:varchar(64);not null;default:'default';index;unique_index:idx_event_tenant_sequence" valid:"-"`
+	Sequence      int64     `json:"sequence" gorm:"unique_index:idx_event_tenant_sequence" valid:"-"`
+	AggregateType string    `json:"aggregate_type" gorm:"type:varchar(30);unique_index:idx_event_aggregate_version" valid:"notnull"`
+	AggregateID   string    `json:"aggregate_id" gorm:"type:uuid;unique_index:idx_event_aggregate_version" valid:"notnull,uuid"`
+	Version       int       `json:"version" gorm:"unique_index:idx_event_aggregate_version" valid:"-"`
+	Type          string    `json:"type" gorm:"type:varchar(50)" valid:"notnull"`
+	Payload       string    `json:"payload" gorm:"type:text" valid:"notnull"`
+	PreviousHash  string    `json:"previous_hash" gorm:"type:varchar(64)" valid:"-"`
+	Hash          string    `json:"hash" gorm:"type:varchar(64)" valid:"-"`
+	CreatedAt     time.Time `json:"created_at" valid:"-"`
+}
+
+type eventDigest struct {
+	ID            string `json:"id"`
+	TenantID      string `json:"tenant_id"`
+	Sequence      int64  `json:"sequence"`
+	AggregateType string `json:"aggregate_type"`
+	AggregateID   string `json:"aggregate_id"`
+	Version       int    `json:"version"`
+	Type          string `json:"type"`
+	Payload       string `json:"payload"`
+	PreviousHash  string `json:"previous_hash"`
+	CreatedAt     string `json:"created_at"`
+}
+
+func (e *Event) isValid() error {
+	_, err := govalidator.ValidateStruct(e)
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (e *Event) Decode(value interface{}) error {
+	return json.Unmarshal([]byte(e.Payload), value)
+}
+
+// ComputeHash digests the content of the event together with the hash of the
+// event before it in the ledger of its tenant, so editing or removing any
+// event, even every event of an aggregate, breaks every later link.
+func (e *Event) ComputeHash() string {
+	data, _ := json.Marshal(&eventDigest{
+		ID:            e.ID,
+		TenantID:      e.TenantID,
+		Sequence:      e.Sequence,
+		AggregateType: e.AggregateType,
+		AggregateID:   e.AggregateID,
+		Version:       e.Version,
+		Type:          e.Type,
+		Payload:       e.Payload,
+		PreviousHash:  e.PreviousHash,
+		CreatedAt:     e.CreatedAt.UTC().Format(time.RFC3339Nano),
+	})
+
+	sum := sha256.Sum256(data)
+
+	return hex.EncodeToString(sum[:])
+}
+
+// Link chains the event to previous, the last event of the ledger of its
+// tenant.
+func (e *Event) Link(previous *Event) {
+	e.Sequence, e.PreviousHash = 1, ""
+
+	if previous != nil {
+		e.Sequence, e.PreviousHash = previous.Sequence+1, previous.Hash
+	}
+
+	e.Hash = e.ComputeHash()
+}
+
+// VerifyLink returns why the event does not follow previous, the event before
+// it in the ledger of its tenant, or an empty string when it does.
+func (e *Event) VerifyLink(previous *Event) string {
+	var expectedSequence int64 = 1
+	expectedHash := ""
+
+	if previous != nil {
+		expectedSequence, expectedHash = previous.Sequence+1, previous.Hash
+	}
+
+	switch {
+	case e.Sequence != expectedSequence:
+		return fmt.Sprintf("expected sequence %d, found %d", expectedSequence, e.Sequence)
+	case e.Hash == "":
+		return "event has no hash"
+	case e.PreviousHash != expectedHash:
+		return "previous hash does not match the previous event"
+	case e.Hash != e.ComputeHash():
+		return "content does not match its hash"
+	}
+
+	return ""
+}
+
+func NewEvent(aggregateType, aggregateID string, version int, eventType string, payload interface{}) (*Event, error) {
+	data, err := json.Marshal(payload)
+
+	if err != nil {
+		return nil, err
+	}
+
+	event := Event{
+		AggregateType: aggregateType,
+		AggregateID:   aggregateID,
+		Version:       version,
+		Type:          eventType,
+		Payload:       string(data),
+	}
+
+	event.ID = uuid.NewV4().String()
+	// Postgres keeps microseconds, the hash has to survive a round trip.
+	event.CreatedAt = time.Now().UTC().Truncate(time.Microsecond)
+
+	err = event.isValid()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &event, nil
+}
+
+func TransactionEventType(transaction *Transaction) string {
+	switch transaction.Status {
+	case TransactionCompleted:
+		return EventTransactionCompleted
+	case TransactionCanceled:
+		return EventTransactionCanceled
+	}
+
+	return EventTransactionUpdated
+}
+
+func AccountEventType(previous, current *Account) string {
+	if previous == nil {
+		return EventAccountOpened
+	}
+
+	if current.Balance < previous.Balance {
+		return EventAccountDebited
+	}
+
+	if current.Balance > previous.Balance {
+		return EventAccountCredited
+	}
+
+	return EventAccountUpdated
+}
@@ -0,0 +1,68 @@
+package entity
+
+import (
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var errInvalidResumeToken = errors.New("invalid resume token")
+
+type TransactionFilter struct {
+	Type          string    `json:"type"`
+	Status        string    `json:"status"`
+	AccountFromID string    `json:"account_from"`
+	AccountToID   string    `json:"account_to"`
+	ExternalID    string    `json:"external_id"`
+	CreatedFrom   time.Time `json:"created_from"`
+	CreatedTo     time.Time `json:"created_to"`
+}
+
+type ExportCursor struct {
+	CreatedAt time.Time
+	ID        string
+}
+
+func NewExportCursor(transaction *Transaction) *ExportCursor {
+	return &ExportCursor{
+		CreatedAt: transaction.CreatedAt,
+		ID:        transaction.ID,
+	}
+}
+
+func (c *ExportCursor) Token() string {
+	value := strconv.FormatInt(c.CreatedAt.UnixNano(), 10) + ":" + c.ID
+
+	return base64.RawURLEncoding.EncodeToString([]byte(value))
+}
+
+func ParseExportCursor(token string) (*ExportCursor, error) {
+	if token == "" {
+		return nil, nil
+	}
+
+	value, err := base64.RawURLEncoding.DecodeString(token)
+
+	if err != nil {
+		return nil, errInvalidResumeToken
+	}
+
+	parts := strings.SplitN(string(value), ":", 2)
+
+	if len(parts) != 2 || parts[1] == "" {
+		return nil, errInvalidResumeToken
+	}
+
+	nanos, err := strconv.ParseInt(parts[0], 10, 64)
+
+	if err != nil {
+		return nil, errInvalidResumeToken
+	}
+
+	return &ExportCursor{
+		CreatedAt: time.Unix(0, nanos).UTC(),
+		ID:        parts[1],
+	}, nil
+}
@@ -0,0 +1,63 @@
+package entity
+
+import "time"
+
+const MaxIdempotencyKey = 128
+
+type IdempotencyKey struct {
+	Key           string    `json:"key" gorm:"column:key;type:varchar(128);primary key" valid:"notnull"`
+	TransactionID string    `json:"transaction_id" gorm:"column:transaction_id;type:uuid;not null" valid:"uuidv4"`
+	CreatedAt     time.Time `json:"created_at" valid:"-"`
+}
+
+// SameRequest tells whether a retried registration carries the same
+// payload as the one that first used the idempotency key.
+func (t *Transaction) SameRequest(fromID, toID, externalID, transactionType, currency string, amount float64) bool {
+	if currency == "" {
+		currency = "AOA"
+	}
+
+	return t.AccountFromID == fromID &&
+		t.AccountToID == toID &&
+		t.ExternalID == externalID &&
+		t.Type == transactionType &&
+		t.Currency == currency &&
+		t.Amount == amount
+}
+
+// SameRequest tells whether a retried service payment carries the same
+// payload as the one that first used the idempotency key.
+func (s *Saga) SameRequest(fromID, toID, externalID, currency string, amount float64) bool {
+	if currency == "" {
+		currency = "AOA"
+	}
+
+	return s.AccountFromID == fromID &&
+		s.AccountToID == toID &&
+		s.ExternalID == externalID &&
+		s.Currency == currency &&
+		s.Amount == amount
+}
+
+// SameRequest tells whether a retried batch carries the same mode and items,
+// in the same order, as the batch stored with registered.
+func (b *Batch) SameRequest(mode string, registered, items []*BatchItem) bool {
+	if b.Mode != mode || len(registered) != len(items) {
+		return false
+	}
+
+	for i, item := range items {
+		stored := registered[i]
+
+		if stored.AccountFromID != item.AccountFromID ||
+			stored.AccountToID != item.AccountToID ||
+			stored.ExternalID != item.ExternalID ||
+			stored.Type != item.Type ||
+			stored.Currency != item.Currency ||
+			stored.Amount != item.Amount {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,164 @@
+package entity
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+var (
+	ErrUnknownAggregate       = errors.New("unknown aggregate type")
+	ErrCheckpointUnsigned     = errors.New("checkpoint is not signed")
+	ErrCheckpointSignature    = errors.New("checkpoint signature is not valid")
+	ErrCheckpointUntrustedKey = errors.New("checkpoint was signed by another key")
+	ErrCheckpointNoTrustedKey = errors.New("no trusted key to verify the checkpoint against")
+	ErrCheckpointTenant       = errors.New("checkpoint covers the chains of another tenant")
+)
+
+// LedgerHead is the latest event of the ledger of a tenant when a checkpoint
+// was taken.
+type LedgerHead struct {
+	Tenant   string `json:"tenant"`
+	Sequence int64  `json:"sequence"`
+	Hash     string `json:"hash"`
+}
+
+// LedgerBreak is the first link of a ledger that failed verification, or the
+// first projection row that does not match its events.
+type LedgerBreak struct {
+	Tenant        string `json:"tenant"`
+	Sequence      int64  `json:"sequence"`
+	AggregateType string `json:"aggregate_type"`
+	AggregateID   string `json:"aggregate_id"`
+	Version       int    `json:"version"`
+	Reason        string `json:"reason"`
+}
+
+type LedgerCheckpoint struct {
+	CreatedAt time.Time     `json:"created_at"`
+	Tenant    string        `json:"tenant,omitempty"`
+	Heads     []*LedgerHead `json:"heads"`
+	Root      string        `json:"root"`
+	PublicKey string        `json:"public_key,omitempty"`
+	Signature string        `json:"signature,omitempty"`
+}
+
+func NewLedgerCheckpoint(heads []*LedgerHead) *LedgerCheckpoint {
+	sort.Slice(heads, func(i, j int) bool {
+		return heads[i].Tenant < heads[j].Tenant
+	})
+
+	checkpoint := &LedgerCheckpoint{
+		CreatedAt: time.Now().UTC(),
+		Heads:     heads,
+	}
+
+	checkpoint.Root = checkpoint.ComputeRoot()
+
+	return checkpoint
+}
+
+// ComputeRoot digests every head, in order, into a single hash.
+func (c *LedgerCheckpoint) ComputeRoot() string {
+	digest := sha256.New()
+
+	for _, head := range c.Heads {
+		data, _ := json.Marshal(head)
+		digest.Write(data)
+	}
+
+	return hex.EncodeToString(digest.Sum(nil))
+}
+
+// SigningPayload is what the signature covers: the time of the checkpoint, its
+// root, which in turn covers every head, and the tenant it was taken for.
+// Checkpoints of every tenant keep the payload they were signed with.
+func (c *LedgerCheckpoint) SigningPayload() []byte {
+	payload := c.CreatedAt.UTC().Format(time.RFC3339Nano) + "\n" + c.Root
+
+	if c.Tenant != "" {
+		payload += "\n" + c.Tenant
+	}
+
+	return []byte(payload)
+}
+
+func (c *LedgerCheckpoint) Sign(key ed25519.PrivateKey) {
+	c.PublicKey = base64.StdEncoding.EncodeToString(key.Public().(ed25519.PublicKey))
+	c.Signature = base64.StdEncoding.EncodeToString(ed25519.Sign(key, c.SigningPayload()))
+}
+
+// VerifySignature checks the root against the heads and the signature against
+// the root. The checkpoint must be signed by trusted: the key it carries only
+// says who claims to have signed it.
+func (c *LedgerCheckpoint) VerifySignature(trusted ed25519.PublicKey) error {
+	if trusted == nil {
+		return ErrCheckpointNoTrustedKey
+	}
+
+	if c.Signature == "" || c.PublicKey == "" {
+		return ErrCheckpointUnsigned
+	}
+
+	publicKey, err := base64.StdEncoding.DecodeString(c.PublicKey)
+
+	if err != nil || len(publicKey) != ed25519.PublicKeySize {
+		return ErrCheckpointSignature
+	}
+
+	if !ed25519.PublicKey(publicKey).Equal(trusted) {
+		return ErrCheckpointUntrustedKey
+	}
+
+	signature, err := base64.StdEncoding.DecodeString(c.Signature)
+
+	if err != nil || c.Root != c.ComputeRoot() || !ed25519.Verify(publicKey, c.SigningPayload(), signature) {
+		return ErrCheckpointSignature
+	}
+
+	return nil
+}
+
+// LedgerState is the part of an account row the ledger vouches for: what its
+// events record, leaving out the bookkeeping timestamps.
+func (a *Account) LedgerState() string {
+	return fmt.Sprintf("tenant=%s balance=%v frozen=%t", a.TenantID, a.Balance, a.Frozen)
+}
+
+// LedgerState is the part of a payment row the ledger vouches for: what its
+// events record, leaving out the bookkeeping timestamps.
+func (t *Transaction) LedgerState() string {
+	return fmt.Sprintf("tenant=%s amount=%v status=%s currency=%s from=%s to=%s type=%s reference=%s",
+		t.TenantID, t.Amount, t.Status, t.Currency, t.AccountFromID, t.AccountToID, t.Type, t.ExternalID)
+}
+
+// LedgerState is the state the projection row of the aggregate must hold when
+// this is its latest event.
+func (e *Event) LedgerState() (string, error) {
+	switch e.AggregateType {
+	case AggregateAccount:
+		account := &Account{}
+
+		if err := e.Decode(account); err != nil {
+			return "", err
+		}
+
+		return account.LedgerState(), nil
+	case AggregateTransaction:
+		transaction := &Transaction{}
+
+		if err := e.Decode(transaction); err != nil {
+			return "", err
+		}
+
+		return transaction.LedgerState(), nil
+	}
+
+	return "", ErrUnknownAggregate
+}
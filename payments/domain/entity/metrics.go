@@ -0,0 +1,9 @@
+package entity
+
+type TransactionTotals struct {
+	Type     string  `json:"type" valid:"-"`
+	Status   string  `json:"status" valid:"-"`
+	Currency string  `json:"currency" valid:"-"`
+	Count    int64   `json:"count" valid:"-"`
+	Amount   float64 `json:"amount" valid:"-"`
+}
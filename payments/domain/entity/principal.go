@@ -0,0 +1,51 @@
+package entity
+
+const (
+	RoleAdmin    string = "admin"
+	RoleService  string = "service"
+	RoleCustomer string = "customer"
+	RoleAuditor  string = "auditor"
+)
+
+type Principal struct {
+	Subject  string   `json:"subject" valid:"-"`
+	Tenant   string   `json:"tenant" valid:"-"`
+	Roles    []string `json:"roles" valid:"-"`
+	Accounts []string `json:"accounts" valid:"-"`
+}
+
+// SystemPrincipal is the caller of work the service starts on its own, such
+// as consuming confirmations. It is privileged and spans every tenant.
+func SystemPrincipal() *Principal {
+	return &Principal{Subject: AuditActorSystem, Roles: []string{RoleService}}
+}
+
+// AnonymousPrincipal is the caller of every request while authentication is
+// off. It is privileged but confined to the default tenant.
+func AnonymousPrincipal() *Principal {
+	return &Principal{Subject: "anonymous", Tenant: DefaultTenant, Roles: []string{RoleAdmin}}
+}
+
+func (p *Principal) HasRole(role string) bool {
+	for _, r := range p.Roles {
+		if r == role {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (p *Principal) Privileged() bool {
+	return p.HasRole(RoleAdmin) || p.HasRole(RoleService)
+}
+
+func (p *Principal) Owns(accountID string) bool {
+	for _, account := range p.Accounts {
+		if account == accountID {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,148 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/asaskevich/govalidator"
+	uuid "github.com/satori/go.uuid"
+)
+
+const (
+	SagaRunning      string = "running"
+	SagaCompensating string = "compensating"
+	SagaCompleted    string = "completed"
+	SagaCompensated  string = "compensated"
+
+	SagaStepReserve      string = "reserve"
+	SagaStepCallProvider string = "call_provider"
+	SagaStepCapture      string = "capture"
+	SagaStepRefund       string = "refund"
+	SagaStepRelease      string = "release"
+)
+
+var sagaNextStep = map[string]string{
+	SagaStepReserve:      SagaStepCallProvider,
+	SagaStepCallProvider: SagaStepCapture,
+	SagaStepRefund:       SagaStepRelease,
+}
+
+var sagaCompensationStep = map[string]string{
+	SagaStepCallProvider: SagaStepRefund,
+	SagaStepCapture:      SagaStepRefund,
+}
+
+type Saga struct {
+	Base              `valid:"required"`
+	TenantID          string     `json:"tenant_id" gorm:"type:varchar(64);not null;default:'default';index" valid:"-"`
+	TransactionID     string     `json:"transaction_id" gorm:"column:transaction_id;type:uuid;not null;index" valid:"notnull,uuidv4"`
+	AccountFromID     string     `json:"account_from" gorm:"column:account_from_id;type:uuid;not null" valid:"notnull,uuidv4"`
+	AccountToID       string     `json:"account_to" gorm:"column:account_to_id;type:uuid;not null" valid:"notnull,uuidv4"`
+	ExternalID        string     `json:"external_id" gorm:"column:external_id;type:uuid" valid:"notnull,uuidv4"`
+	Currency          string     `json:"currency" gorm:"type:varchar(5)" valid:"notnull"`
+	Amount            float64    `json:"amount" gorm:"type:float" valid:"notnull"`
+	Status            string     `json:"status" gorm:"type:varchar(20);index" valid:"notnull"`
+	Step              string     `json:"step" gorm:"type:varchar(20)" valid:"notnull"`
+	ProviderReference string     `json:"provider_reference" gorm:"type:varchar(255)" valid:"-"`
+	Error             string     `json:"error" gorm:"type:text" valid:"-"`
+	Attempts          int        `json:"attempts" valid:"-"`
+	ExpiresAt         time.Time  `json:"expires_at" valid:"-"`
+	LeaseOwner        string     `json:"-" gorm:"type:varchar(64)" valid:"-"`
+	LeaseExpiresAt    *time.Time `json:"-" valid:"-"`
+	IdempotencyKey    string     `json:"-" gorm:"type:varchar(128)" valid:"-"`
+}
+
+func (s *Saga) isValid() error {
+	_, err := govalidator.ValidateStruct(s)
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *Saga) InFlight() bool {
+	return s.Status == SagaRunning || s.Status == SagaCompensating
+}
+
+func (s *Saga) Expired(now time.Time) bool {
+	return s.Status == SagaRunning && s.Step != SagaStepCapture && now.After(s.ExpiresAt)
+}
+
+func (s *Saga) Advance() {
+	s.Attempts = 0
+
+	next, ok := sagaNextStep[s.Step]
+
+	if !ok {
+		if s.Status == SagaCompensating {
+			s.Status = SagaCompensated
+		} else {
+			s.Status = SagaCompleted
+		}
+		return
+	}
+
+	s.Step = next
+}
+
+func (s *Saga) Compensate(cause error) {
+	s.Attempts = 0
+	s.Error = cause.Error()
+
+	step, ok := sagaCompensationStep[s.Step]
+
+	if !ok {
+		s.Status = SagaCompensated
+		return
+	}
+
+	s.Status = SagaCompensating
+	s.Step = step
+}
+
+// Lease marks the saga as driven by owner until the given time, so no other
+// instance picks it up meanwhile.
+func (s *Saga) Lease(owner string, until time.Time) {
+	s.LeaseOwner = owner
+	s.LeaseExpiresAt = &until
+}
+
+func (s *Saga) ReleaseLease() {
+	s.LeaseOwner = ""
+	s.LeaseExpiresAt = nil
+}
+
+func (s *Saga) Retry(cause error) {
+	s.Attempts++
+	s.Error = cause.Error()
+}
+
+func NewSaga(accountFromID, accountToID, externalID, currency string, amount float64, timeout time.Duration) (*Saga, error) {
+	if currency == "" {
+		currency = "AOA"
+	}
+
+	saga := Saga{
+		TransactionID: uuid.NewV4().String(),
+		AccountFromID: accountFromID,
+		AccountToID:   accountToID,
+		ExternalID:    externalID,
+		Currency:      currency,
+		Amount:        amount,
+		Status:        SagaRunning,
+		Step:          SagaStepReserve,
+	}
+
+	saga.ID = uuid.NewV4().String()
+	saga.CreatedAt = time.Now()
+	saga.ExpiresAt = saga.CreatedAt.Add(timeout)
+
+	err := saga.isValid()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &saga, nil
+}
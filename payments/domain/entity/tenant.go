@@ -0,0 +1,28 @@
+package entity
+
+import "context"
+
+const DefaultTenant string = "default"
+
+type tenantKey struct{}
+
+func WithTenant(ctx context.Context, tenant string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenant)
+}
+
+// Tenant is the tenant every read and write made with ctx is scoped to.
+// Without one the caller is a trusted in-process worker that spans tenants,
+// such as the kafka processor or the webhook dispatcher.
+func Tenant(ctx context.Context) (string, bool) {
+	tenant, ok := ctx.Value(tenantKey{}).(string)
+
+	return tenant, ok && tenant != ""
+}
+
+func CrossTenant(fromTenant, toTenant string) *DomainError {
+	return &DomainError{
+		Kind:     ErrorPermissionDenied,
+		Message:  "payments between tenants are not allowed",
+		Metadata: map[string]string{"tenant_from": fromTenant, "tenant_to": toTenant},
+	}
+}
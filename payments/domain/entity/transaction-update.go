@@ -0,0 +1,6 @@
+package entity
+
+type TransactionUpdate struct {
+	Version     uint64       `json:"version"`
+	Transaction *Transaction `json:"transaction"`
+}
@@ -19,16 +19,18 @@ const (
 )
 
 type Transaction struct {
-	Base          `valid:"required"`
-	Amount        float64  `json:"amount" gorm:"type:float" valid:"notnull"`
-	Status        string   `json:"status" gorm:"type:varchar(20)" valid:"notnull"`
-	Currency      string   `json:"currency" gorm:"type:varchar(5)" valid:"notnull"`
-	AccountFrom   *Account `valid:"-"`
-	AccountFromID string   `json:"account_from" gorm:"column:account_from_id;type:uuid;not null" valid:"notnull,uuidv4"`
-	AccountTo     *Account `valid:"-"`
-	AccountToID   string   `json:"account_to" gorm:"column:account_to_id;type:uuid;default:null" valid:"notnull,uuidv4"`
-	Type          string   `json:"type" gorm:"type:varchar(30)" valid:"notnull"`
-	ExternalID    string   `json:"external_id" gorm:"column:external_id;type:uuid" valid:"notnull,uuidv4"`
+	Base           `valid:"required"`
+	TenantID       string   `json:"tenant_id" gorm:"type:varchar(64);not null;default:'default';index" valid:"-"`
+	Amount         float64  `json:"amount" gorm:"type:float" valid:"notnull"`
+	Status         string   `json:"status" gorm:"type:varchar(20)" valid:"notnull"`
+	Currency       string   `json:"currency" gorm:"type:varchar(5)" valid:"notnull"`
+	AccountFrom    *Account `valid:"-"`
+	AccountFromID  string   `json:"account_from" gorm:"column:account_from_id;type:uuid;not null" valid:"notnull,uuidv4"`
+	AccountTo      *Account `valid:"-"`
+	AccountToID    string   `json:"account_to" gorm:"column:account_to_id;type:uuid;default:null" valid:"notnull,uuidv4"`
+	Type           string   `json:"type" gorm:"type:varchar(30)" valid:"notnull"`
+	ExternalID     string   `json:"external_id" gorm:"column:external_id;type:uuid" valid:"notnull,uuidv4"`
+	IdempotencyKey string   `json:"-" gorm:"-" valid:"-"`
 }
 
 func (t *Transaction) isValid() error {
@@ -57,7 +59,12 @@ func NewTransaction(accountFrom *Account, accountTo *Account, externalID, transa
 		currency = "AOA"
 	}
 
+	if accountFrom.TenantID != accountTo.TenantID {
+		return nil, CrossTenant(accountFrom.TenantID, accountTo.TenantID)
+	}
+
 	transaction := Transaction{
+		TenantID:      accountFrom.TenantID,
 		Amount:        amount,
 		Currency:      currency,
 		AccountFrom:   accountFrom,
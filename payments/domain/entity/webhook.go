@@ -0,0 +1,206 @@
+package entity
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/asaskevich/govalidator"
+	uuid "github.com/satori/go.uuid"
+)
+
+const (
+	WebhookDeliveryPending   string = "pending"
+	WebhookDeliveryDelivered string = "delivered"
+	WebhookDeliveryFailed    string = "failed"
+
+	WebhookPaymentCompleted string = "payment.completed"
+	WebhookPaymentCanceled  string = "payment.canceled"
+)
+
+var internalNetworks = parseNetworks(
+	"0.0.0.0/8",
+	"10.0.0.0/8",
+	"100.64.0.0/10",
+	"127.0.0.0/8",
+	"169.254.0.0/16",
+	"172.16.0.0/12",
+	"192.168.0.0/16",
+	"::/128",
+	"::1/128",
+	"fc00::/7",
+	"fe80::/10",
+)
+
+func parseNetworks(cidrs ...string) []*net.IPNet {
+	networks := make([]*net.IPNet, 0, len(cidrs))
+
+	for _, cidr := range cidrs {
+		_, network, _ := net.ParseCIDR(cidr)
+		networks = append(networks, network)
+	}
+
+	return networks
+}
+
+// InternalAddress reports whether ip is a private, loopback or link-local
+// address, one webhooks must never be delivered to.
+func InternalAddress(ip net.IP) bool {
+	for _, network := range internalNetworks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+type Webhook struct {
+	Base      `valid:"required"`
+	TenantID  string `json:"tenant_id" gorm:"type:varchar(64);not null;default:'default';index" valid:"-"`
+	AccountID string `json:"account_id" gorm:"column:account_id;type:uuid;not null;index" valid:"notnull,uuidv4"`
+	URL       string `json:"url" gorm:"type:varchar(2048)" valid:"notnull,url"`
+	Secret    string `json:"-" gorm:"type:varchar(64)" valid:"notnull"`
+	Active    bool   `json:"active" valid:"-"`
+}
+
+func (w *Webhook) isValid() error {
+	_, err := govalidator.ValidateStruct(w)
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func NewWebhook(accountID, url string) (*Webhook, error) {
+	secret := make([]byte, 32)
+
+	_, err := rand.Read(secret)
+
+	if err != nil {
+		return nil, err
+	}
+
+	webhook := Webhook{
+		AccountID: accountID,
+		URL:       url,
+		Secret:    hex.EncodeToString(secret),
+		Active:    true,
+	}
+
+	webhook.ID = uuid.NewV4().String()
+	webhook.CreatedAt = time.Now()
+
+	err = webhook.isValid()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &webhook, nil
+}
+
+func (w *Webhook) Sign(timestamp time.Time, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(w.Secret))
+	fmt.Fprintf(mac, "%d.", timestamp.Unix())
+	mac.Write(payload)
+
+	return fmt.Sprintf("t=%d,v1=%s", timestamp.Unix(), hex.EncodeToString(mac.Sum(nil)))
+}
+
+type WebhookDelivery struct {
+	Base          `valid:"required"`
+	TenantID      string    `json:"tenant_id" gorm:"type:varchar(64);not null;default:'default';index" valid:"-"`
+	WebhookID     string    `json:"webhook_id" gorm:"column:webhook_id;type:uuid;not null;index" valid:"notnull,uuidv4"`
+	TransactionID string    `json:"transaction_id" gorm:"column:transaction_id;type:uuid;not null" valid:"notnull,uuidv4"`
+	Event         string    `json:"event" gorm:"type:varchar(50)" valid:"notnull"`
+	Payload       string    `json:"payload" gorm:"type:text" valid:"notnull"`
+	Status        string    `json:"status" gorm:"type:varchar(20);index" valid:"notnull"`
+	Attempts      int       `json:"attempts" valid:"-"`
+	NextAttemptAt time.Time `json:"next_attempt_at" gorm:"index" valid:"-"`
+}
+
+func (d *WebhookDelivery) isValid() error {
+	_, err := govalidator.ValidateStruct(d)
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func NewWebhookDelivery(webhookID, transactionID, event string, payload []byte) (*WebhookDelivery, error) {
+	delivery := WebhookDelivery{
+		WebhookID:     webhookID,
+		TransactionID: transactionID,
+		Event:         event,
+		Payload:       string(payload),
+		Status:        WebhookDeliveryPending,
+	}
+
+	delivery.ID = uuid.NewV4().String()
+	delivery.CreatedAt = time.Now()
+	delivery.NextAttemptAt = delivery.CreatedAt
+
+	err := delivery.isValid()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &delivery, nil
+}
+
+func (d *WebhookDelivery) Succeed() {
+	d.Attempts++
+	d.Status = WebhookDeliveryDelivered
+}
+
+func (d *WebhookDelivery) Fail(maxAttempts int, backoff time.Duration) {
+	d.Attempts++
+
+	if d.Attempts >= maxAttempts {
+		d.Status = WebhookDeliveryFailed
+		return
+	}
+
+	d.Status = WebhookDeliveryPending
+	d.NextAttemptAt = time.Now().Add(backoff)
+}
+
+func (d *WebhookDelivery) Reset() {
+	d.Status = WebhookDeliveryPending
+	d.NextAttemptAt = time.Now()
+}
+
+type WebhookAttempt struct {
+	Base       `valid:"required"`
+	DeliveryID string `json:"delivery_id" gorm:"column:delivery_id;type:uuid;not null;index" valid:"notnull,uuidv4"`
+	StatusCode int    `json:"status_code" valid:"-"`
+	Error      string `json:"error" gorm:"type:text" valid:"-"`
+	Duration   int64  `json:"duration_ms" valid:"-"`
+}
+
+func NewWebhookAttempt(deliveryID string, statusCode int, cause error, duration time.Duration) *WebhookAttempt {
+	attempt := WebhookAttempt{
+		DeliveryID: deliveryID,
+		StatusCode: statusCode,
+		Duration:   int64(duration / time.Millisecond),
+	}
+
+	if cause != nil {
+		attempt.Error = cause.Error()
+	}
+
+	attempt.ID = uuid.NewV4().String()
+	attempt.CreatedAt = time.Now()
+
+	return &attempt
+}
@@ -0,0 +1,11 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/EdlanioJ/kbu/payments/domain/entity"
+)
+
+type Audit interface {
+	FindAll(ctx context.Context, filter *entity.AuditFilter, page, limit int) ([]*entity.AuditEntry, int, error)
+}
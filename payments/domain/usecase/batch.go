@@ -0,0 +1,13 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/EdlanioJ/kbu/payments/domain/entity"
+)
+
+type Batch interface {
+	Register(ctx context.Context, mode string, items []*entity.BatchItem) (*entity.Batch, []*entity.BatchItem, error)
+	RegisterIdempotent(ctx context.Context, key, mode string, items []*entity.BatchItem) (*entity.Batch, []*entity.BatchItem, error)
+	Find(ctx context.Context, batchID string) (*entity.Batch, []*entity.BatchItem, error)
+}
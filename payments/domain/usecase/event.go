@@ -0,0 +1,12 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/EdlanioJ/kbu/payments/domain/entity"
+)
+
+type Event interface {
+	History(ctx context.Context, aggregateType, aggregateID string) ([]*entity.Event, error)
+	Rebuild(ctx context.Context, truncate bool) (map[string]int, error)
+}
@@ -0,0 +1,12 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/EdlanioJ/kbu/payments/domain/entity"
+)
+
+type Ledger interface {
+	Verify(ctx context.Context, checkpoint *entity.LedgerCheckpoint) (*entity.LedgerBreak, error)
+	Checkpoint(ctx context.Context) (*entity.LedgerCheckpoint, error)
+}
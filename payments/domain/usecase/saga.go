@@ -0,0 +1,15 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/EdlanioJ/kbu/payments/domain/entity"
+)
+
+type Saga interface {
+	Start(ctx context.Context, fromAccount, toAccount, externalID, currency string, amount float64) (*entity.Saga, error)
+	StartIdempotent(ctx context.Context, key, fromAccount, toAccount, externalID, currency string, amount float64) (*entity.Saga, error)
+	Resume(ctx context.Context, sagaID string) (*entity.Saga, error)
+	Recover(ctx context.Context, limit int) (int, error)
+	Find(ctx context.Context, sagaID string) (*entity.Saga, error)
+}
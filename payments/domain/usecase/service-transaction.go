@@ -1,9 +1,13 @@
 package usecase
 
-import "github.com/EdlanioJ/kbu/payments/domain/entity"
+import (
+	"context"
+
+	"github.com/EdlanioJ/kbu/payments/domain/entity"
+)
 
 type ServiceTransaction interface {
-	RegisterServiceTransaction(fromId string, serviceId string, servicePriceId string, amount float64, currency string) (*entity.Transaction, error)
-	FindAllByServiceId(serviceId string, page int, limit int, sort string) ([]*entity.Transaction, int, error)
-	FindOneByService(serviceId string, transactionId string) (*entity.Transaction, error)
+	RegisterServiceTransaction(ctx context.Context, fromId string, serviceId string, servicePriceId string, amount float64, currency string) (*entity.Transaction, error)
+	FindAllByServiceId(ctx context.Context, serviceId string, page int, limit int, sort string) ([]*entity.Transaction, int, error)
+	FindOneByService(ctx context.Context, serviceId string, transactionId string) (*entity.Transaction, error)
 }
@@ -0,0 +1,16 @@
+package usecase
+
+import "github.com/EdlanioJ/kbu/payments/domain/entity"
+
+type TransactionSubscription interface {
+	Version() uint64
+	Updates() <-chan *entity.TransactionUpdate
+	Err() error
+	Close()
+}
+
+type TransactionFeed interface {
+	Publish(transaction *entity.Transaction)
+	WatchTransaction(transactionID string, fromVersion uint64) (TransactionSubscription, error)
+	WatchAccount(accountID string, fromVersion uint64) (TransactionSubscription, error)
+}
@@ -1,19 +1,25 @@
 package usecase
 
-import "github.com/EdlanioJ/kbu/payments/domain/entity"
+import (
+	"context"
+
+	"github.com/EdlanioJ/kbu/payments/domain/entity"
+)
 
 type Transaction interface {
-	Register(fromAccount, toAccount, externalID, typeTransaction, currency string, amount float64) (*entity.Transaction, error)
-	Find(id string) (*entity.Transaction, error)
-	FindAll(page int, limit int, sort string) ([]*entity.Transaction, int, error)
-	FindByType(typeTransaction, transactionID string) (*entity.Transaction, error)
-	FindAllByType(typeTransaction string, page int, limit int, sort string) ([]*entity.Transaction, int, error)
-	FindByExternalID(externalID, transactionID string) (*entity.Transaction, error)
-	FindAllByExternalID(externalID string, page int, limit int, sort string) ([]*entity.Transaction, int, error)
-	FindAllByFromAccountID(accountID string, page int, limit int, sort string) ([]*entity.Transaction, int, error)
-	FindByFromAccountID(accountID, transactionID string) (*entity.Transaction, error)
-	FindAllByToAccountID(accountID string, page int, limit int, sort string) ([]*entity.Transaction, int, error)
-	FindByToAccountID(accountID, transactionID string) (*entity.Transaction, error)
-	Complete(transactionID string) (*entity.Transaction, error)
-	Error(transactionID string) (*entity.Transaction, error)
+	Register(ctx context.Context, fromAccount, toAccount, externalID, typeTransaction, currency string, amount float64) (*entity.Transaction, error)
+	RegisterIdempotent(ctx context.Context, idempotencyKey, fromAccount, toAccount, externalID, typeTransaction, currency string, amount float64) (*entity.Transaction, error)
+	Find(ctx context.Context, id string) (*entity.Transaction, error)
+	FindAll(ctx context.Context, page int, limit int, sort string) ([]*entity.Transaction, int, error)
+	FindByType(ctx context.Context, typeTransaction, transactionID string) (*entity.Transaction, error)
+	FindAllByType(ctx context.Context, typeTransaction string, page int, limit int, sort string) ([]*entity.Transaction, int, error)
+	FindByExternalID(ctx context.Context, externalID, transactionID string) (*entity.Transaction, error)
+	FindAllByExternalID(ctx context.Context, externalID string, page int, limit int, sort string) ([]*entity.Transaction, int, error)
+	FindAllByFromAccountID(ctx context.Context, accountID string, page int, limit int, sort string) ([]*entity.Transaction, int, error)
+	FindByFromAccountID(ctx context.Context, accountID, transactionID string) (*entity.Transaction, error)
+	FindAllByToAccountID(ctx context.Context, accountID string, page int, limit int, sort string) ([]*entity.Transaction, int, error)
+	FindByToAccountID(ctx context.Context, accountID, transactionID string) (*entity.Transaction, error)
+	Complete(ctx context.Context, transactionID string) (*entity.Transaction, error)
+	Error(ctx context.Context, transactionID string) (*entity.Transaction, error)
+	Export(ctx context.Context, filter *entity.TransactionFilter, resumeToken string, fn func(transaction *entity.Transaction, resumeToken string) error) error
 }
@@ -0,0 +1,16 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/EdlanioJ/kbu/payments/domain/entity"
+)
+
+type Webhook interface {
+	Register(ctx context.Context, accountID, url string) (*entity.Webhook, error)
+	Notify(ctx context.Context, event string, transaction *entity.Transaction) ([]*entity.WebhookDelivery, error)
+	Deliver(ctx context.Context, deliveryID string) (*entity.WebhookDelivery, error)
+	DeliverDue(ctx context.Context, limit int) (int, error)
+	Redeliver(ctx context.Context, deliveryID string) (*entity.WebhookDelivery, error)
+	FindDelivery(ctx context.Context, deliveryID string) (*entity.WebhookDelivery, []*entity.WebhookAttempt, error)
+}
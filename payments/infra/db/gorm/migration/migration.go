@@ -0,0 +1,423 @@
+package migration
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jinzhu/gorm"
+)
+
+// Migrations live in one directory per dialect, with the same versions in
+// every directory, as <version>_<name>.up.sql and <version>_<name>.down.sql.
+//
+//go:embed migrations
+var migrations embed.FS
+
+const createSchemaMigrations = `CREATE TABLE IF NOT EXISTS schema_migrations (
+	version    bigint PRIMARY KEY,
+	name       varchar(255) NOT NULL,
+	applied_at %s NOT NULL
+)`
+
+// timestampTypes also lists the dialects that have migrations.
+var timestampTypes = map[string]string{
+	"postgres": "timestamp with time zone",
+	"sqlite3":  "datetime",
+}
+
+var (
+	ErrUnsupportedDialect = errors.New("no migrations for this database dialect")
+	ErrUnknownVersion     = errors.New("applied migration is not known to this build")
+	ErrInvalidName        = errors.New("migration name must contain letters or digits")
+)
+
+var fileName = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+type SchemaMigration struct {
+	Version   int64     `gorm:"primary_key;auto_increment:false"`
+	Name      string    `gorm:"type:varchar(255)"`
+	AppliedAt time.Time `gorm:"not null"`
+}
+
+func (SchemaMigration) TableName() string {
+	return "schema_migrations"
+}
+
+type Status struct {
+	*Migration
+	Applied   bool
+	AppliedAt time.Time
+	Unknown   bool
+}
+
+// Load reads the migrations of fsys sorted by version. Every migration must
+// have both an up and a down file.
+func Load(fsys fs.FS) ([]*Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int64]*Migration)
+	files := make(map[int64]map[string]bool)
+
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+
+		match := fileName.FindStringSubmatch(entry.Name())
+
+		if match == nil {
+			return nil, fmt.Errorf("invalid migration file name %q", entry.Name())
+		}
+
+		version, err := strconv.ParseInt(match[1], 10, 64)
+
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration version %q: %w", entry.Name(), err)
+		}
+
+		content, err := fs.ReadFile(fsys, entry.Name())
+
+		if err != nil {
+			return nil, err
+		}
+
+		migration, ok := byVersion[version]
+
+		if !ok {
+			migration = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = migration
+			files[version] = make(map[string]bool)
+		}
+
+		if migration.Name != match[2] {
+			return nil, fmt.Errorf("migration %d is named both %q and %q", version, migration.Name, match[2])
+		}
+
+		if files[version][match[3]] {
+			return nil, fmt.Errorf("duplicate %s migration for version %d", match[3], version)
+		}
+
+		files[version][match[3]] = true
+
+		if match[3] == "up" {
+			migration.Up = string(content)
+		} else {
+			migration.Down = string(content)
+		}
+	}
+
+	list := make([]*Migration, 0, len(byVersion))
+
+	for version, migration := range byVersion {
+		if !files[version]["up"] || !files[version]["down"] {
+			return nil, fmt.Errorf("migration %d_%s needs both an up and a down file", version, migration.Name)
+		}
+
+		list = append(list, migration)
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Version < list[j].Version
+	})
+
+	return list, nil
+}
+
+type Migrator struct {
+	DB         *gorm.DB
+	Migrations []*Migration
+}
+
+// NewMigrator loads the migrations embedded in the binary for the dialect of
+// db.
+func NewMigrator(db *gorm.DB) (*Migrator, error) {
+	dialect := db.Dialect().GetName()
+
+	if _, ok := timestampTypes[dialect]; !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedDialect, dialect)
+	}
+
+	fsys, err := fs.Sub(migrations, "migrations/"+dialect)
+
+	if err != nil {
+		return nil, err
+	}
+
+	list, err := Load(fsys)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &Migrator{
+		DB:         db,
+		Migrations: list,
+	}, nil
+}
+
+// Up applies the pending migrations in version order, at most steps of them
+// when steps is positive. Each migration runs in its own transaction together
+// with its schema_migrations row.
+func (m *Migrator) Up(steps int) ([]*Migration, error) {
+	applied, err := m.applied()
+
+	if err != nil {
+		return nil, err
+	}
+
+	var done []*Migration
+
+	for _, migration := range m.Migrations {
+		if steps > 0 && len(done) == steps {
+			break
+		}
+
+		if _, ok := applied[migration.Version]; ok {
+			continue
+		}
+
+		err = m.run(migration.Up, func(tx *gorm.DB) error {
+			return tx.Exec("INSERT INTO schema_migrations (version, name, applied_at) VALUES (?, ?, ?)", migration.Version, migration.Name, time.Now().UTC()).Error
+		})
+
+		if err != nil {
+			return done, fmt.Errorf("migration %d_%s: %w", migration.Version, migration.Name, err)
+		}
+
+		done = append(done, migration)
+	}
+
+	return done, nil
+}
+
+// Down reverts the latest applied migrations, at most steps of them when
+// steps is positive and every one of them otherwise.
+func (m *Migrator) Down(steps int) ([]*Migration, error) {
+	applied, err := m.applied()
+
+	if err != nil {
+		return nil, err
+	}
+
+	versions := make([]int64, 0, len(applied))
+
+	for version := range applied {
+		versions = append(versions, version)
+	}
+
+	sort.Slice(versions, func(i, j int) bool {
+		return versions[i] > versions[j]
+	})
+
+	known := make(map[int64]*Migration, len(m.Migrations))
+
+	for _, migration := range m.Migrations {
+		known[migration.Version] = migration
+	}
+
+	var done []*Migration
+
+	for _, version := range versions {
+		if steps > 0 && len(done) == steps {
+			break
+		}
+
+		migration, ok := known[version]
+
+		if !ok {
+			return done, fmt.Errorf("%w: %d_%s", ErrUnknownVersion, version, applied[version].Name)
+		}
+
+		err = m.run(migration.Down, func(tx *gorm.DB) error {
+			return tx.Exec("DELETE FROM schema_migrations WHERE version = ?", migration.Version).Error
+		})
+
+		if err != nil {
+			return done, fmt.Errorf("migration %d_%s: %w", migration.Version, migration.Name, err)
+		}
+
+		done = append(done, migration)
+	}
+
+	return done, nil
+}
+
+// Status lists every known migration and whether it is applied. Applied
+// versions this build does not know are listed last, flagged as unknown.
+func (m *Migrator) Status() ([]*Status, error) {
+	applied, err := m.applied()
+
+	if err != nil {
+		return nil, err
+	}
+
+	var statuses []*Status
+
+	for _, migration := range m.Migrations {
+		row, ok := applied[migration.Version]
+		status := &Status{Migration: migration, Applied: ok}
+
+		if ok {
+			status.AppliedAt = row.AppliedAt
+			delete(applied, migration.Version)
+		}
+
+		statuses = append(statuses, status)
+	}
+
+	var unknown []*Status
+
+	for _, row := range applied {
+		unknown = append(unknown, &Status{
+			Migration: &Migration{Version: row.Version, Name: row.Name},
+			Applied:   true,
+			AppliedAt: row.AppliedAt,
+			Unknown:   true,
+		})
+	}
+
+	sort.Slice(unknown, func(i, j int) bool {
+		return unknown[i].Version < unknown[j].Version
+	})
+
+	return append(statuses, unknown...), nil
+}
+
+func (m *Migrator) applied() (map[int64]*SchemaMigration, error) {
+	err := m.DB.Exec(fmt.Sprintf(createSchemaMigrations, timestampTypes[m.DB.Dialect().GetName()])).Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []*SchemaMigration
+
+	err = m.DB.Order("version asc").Find(&rows).Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	applied := make(map[int64]*SchemaMigration, len(rows))
+
+	for _, row := range rows {
+		applied[row.Version] = row
+	}
+
+	return applied, nil
+}
+
+// run executes the statements of a migration file as they are, so the file
+// may hold several statements and dialect specific syntax.
+func (m *Migrator) run(statements string, record func(tx *gorm.DB) error) error {
+	tx := m.DB.Begin()
+
+	if tx.Error != nil {
+		return tx.Error
+	}
+
+	if strings.TrimSpace(statements) != "" {
+		_, err := tx.CommonDB().Exec(statements)
+
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	err := record(tx)
+
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit().Error
+}
+
+// Create writes empty up and down files for a new migration in the directory
+// of every dialect under dir, numbered after the latest existing version.
+func Create(dir, name string) ([]string, error) {
+	name = strings.Trim(regexp.MustCompile(`[^a-z0-9]+`).ReplaceAllString(strings.ToLower(name), "_"), "_")
+
+	if name == "" {
+		return nil, ErrInvalidName
+	}
+
+	var latest int64
+
+	for dialect := range timestampTypes {
+		entries, err := os.ReadDir(filepath.Join(dir, dialect))
+
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+
+		for _, entry := range entries {
+			match := fileName.FindStringSubmatch(entry.Name())
+
+			if match == nil {
+				continue
+			}
+
+			version, _ := strconv.ParseInt(match[1], 10, 64)
+
+			if version > latest {
+				latest = version
+			}
+		}
+	}
+
+	dialects := make([]string, 0, len(timestampTypes))
+
+	for dialect := range timestampTypes {
+		dialects = append(dialects, dialect)
+	}
+
+	sort.Strings(dialects)
+
+	var files []string
+
+	for _, dialect := range dialects {
+		err := os.MkdirAll(filepath.Join(dir, dialect), 0755)
+
+		if err != nil {
+			return files, err
+		}
+
+		for _, direction := range []string{"up", "down"} {
+			path := filepath.Join(dir, dialect, fmt.Sprintf("%04d_%s.%s.sql", latest+1, name, direction))
+			content := fmt.Sprintf("-- %s: %s %s\n", name, dialect, direction)
+
+			err = os.WriteFile(path, []byte(content), 0644)
+
+			if err != nil {
+				return files, err
+			}
+
+			files = append(files, path)
+		}
+	}
+
+	return files, nil
+}
@@ -0,0 +1,379 @@
+package migration_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/EdlanioJ/kbu/payments/domain/entity"
+	"github.com/EdlanioJ/kbu/payments/infra/db/gorm/migration"
+	"github.com/EdlanioJ/kbu/payments/infra/db/gorm/repository"
+	"github.com/jinzhu/gorm"
+	_ "github.com/mattn/go-sqlite3"
+	uuid "github.com/satori/go.uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func NewSQLiteTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open("sqlite3", ":memory:")
+	require.Nil(t, err)
+
+	db.LogMode(false)
+	db.DB().SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+
+	return db
+}
+
+func NewPostgresTestMock() (*gorm.DB, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New()
+
+	if err != nil {
+		panic(err)
+	}
+
+	gdb, err := gorm.Open("postgres", db)
+
+	if err != nil {
+		panic(err)
+	}
+
+	gdb.LogMode(false)
+
+	return gdb, mock
+}
+
+func expectSchemaMigrations(mock sqlmock.Sqlmock, versions ...int64) {
+	rows := sqlmock.NewRows([]string{"version", "name", "applied_at"})
+
+	for _, version := range versions {
+		rows.AddRow(version, "migration", time.Now())
+	}
+
+	mock.ExpectExec(regexp.QuoteMeta(`CREATE TABLE IF NOT EXISTS schema_migrations`)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "schema_migrations"   ORDER BY version asc`)).
+		WillReturnRows(rows)
+}
+
+func TestMigratorSQLite(t *testing.T) {
+	t.Parallel()
+
+	t.Run("should create a schema the repositories can use", func(t *testing.T) {
+		db := NewSQLiteTestDB(t)
+		is := require.New(t)
+
+		migrator, err := migration.NewMigrator(db)
+		is.Nil(err)
+
+		applied, err := migrator.Up(0)
+		is.Nil(err)
+		is.Len(applied, len(migrator.Migrations))
+
+		applied, err = migrator.Up(0)
+		is.Nil(err)
+		is.Empty(applied)
+
+		ctx := context.Background()
+		accountFrom, _ := entity.NewAccount(3000)
+		accountTo, _ := entity.NewAccount(200)
+		is.Nil(repository.NewAccountRepository(db).Save(ctx, accountFrom))
+		is.Nil(repository.NewAccountRepository(db).Save(ctx, accountTo))
+
+		transaction, _ := entity.NewTransaction(accountFrom, accountTo, uuid.NewV4().String(), entity.TransactionToUser, "AOA", 30)
+		transaction.IdempotencyKey = "key-1"
+		is.Nil(repository.NewTransactionRepository(db).Register(ctx, transaction))
+
+		found, err := repository.NewTransactionRepository(db).FindByIdempotencyKey(ctx, "key-1")
+		is.Nil(err)
+		is.Equal(transaction.ID, found.ID)
+		is.Equal(entity.DefaultTenant, found.TenantID)
+		is.True(transaction.CreatedAt.Equal(found.CreatedAt))
+
+		entries, total, err := repository.NewAuditRepository(db).FindAll(ctx, &entity.AuditFilter{}, &entity.Pagination{Page: 1, Limit: 10})
+		is.Nil(err)
+		is.Equal(3, total)
+		is.Len(entries, 3)
+
+		saga, _ := entity.NewSaga(accountFrom.ID, accountTo.ID, uuid.NewV4().String(), "AOA", 30, time.Minute)
+		is.Nil(repository.NewSagaRepository(db).Register(ctx, saga))
+
+		claimed, err := repository.NewSagaRepository(db).Claim(ctx, saga, "owner-1", time.Now().Add(time.Minute))
+		is.Nil(err)
+		is.True(claimed)
+
+		claimed, err = repository.NewSagaRepository(db).Claim(ctx, saga, "owner-2", time.Now().Add(time.Minute))
+		is.Nil(err)
+		is.False(claimed)
+
+		keyed, _ := entity.NewSaga(accountFrom.ID, accountTo.ID, uuid.NewV4().String(), "AOA", 30, time.Minute)
+		keyed.IdempotencyKey = "key-2"
+		is.Nil(repository.NewSagaRepository(db).Register(ctx, keyed))
+
+		foundSaga, err := repository.NewSagaRepository(db).FindByIdempotencyKey(ctx, "key-2")
+		is.Nil(err)
+		is.Equal(keyed.ID, foundSaga.ID)
+
+		duplicate, _ := entity.NewSaga(accountFrom.ID, accountTo.ID, uuid.NewV4().String(), "AOA", 30, time.Minute)
+		duplicate.IdempotencyKey = "key-2"
+		is.NotNil(repository.NewSagaRepository(db).Register(ctx, duplicate))
+
+		repository.RegisterTenancy(db)
+		acme := entity.WithTenant(ctx, "acme")
+		tenantSaga, _ := entity.NewSaga(accountFrom.ID, accountTo.ID, uuid.NewV4().String(), "AOA", 30, time.Minute)
+		tenantSaga.IdempotencyKey = "key-2"
+		is.Nil(repository.NewSagaRepository(db).Register(acme, tenantSaga))
+		is.Equal("acme", tenantSaga.TenantID)
+
+		_, err = repository.NewSagaRepository(db).Find(acme, keyed.ID)
+		is.True(entity.IsErrorKind(err, entity.ErrorNotFound))
+
+		batch, _ := entity.NewBatch(entity.BatchBestEffort)
+		batch.IdempotencyKey = "key-3"
+		item := entity.NewBatchItem(0, accountFrom.ID, accountTo.ID, uuid.NewV4().String(), entity.TransactionToUser, "AOA", 10)
+		is.Nil(repository.NewBatchRepository(db).Register(ctx, batch, []*entity.BatchItem{item}))
+
+		foundBatch, foundItems, err := repository.NewBatchRepository(db).FindByIdempotencyKey(ctx, "key-3")
+		is.Nil(err)
+		is.Equal(batch.ID, foundBatch.ID)
+		is.Len(foundItems, 1)
+
+		unkeyed, _ := entity.NewBatch(entity.BatchBestEffort)
+		is.Nil(repository.NewBatchRepository(db).Register(ctx, unkeyed, nil))
+		unkeyed, _ = entity.NewBatch(entity.BatchBestEffort)
+		is.Nil(repository.NewBatchRepository(db).Register(ctx, unkeyed, nil))
+		is.Nil(repository.NewBatchRepository(db).Save(ctx, unkeyed, nil))
+
+		is.NotNil(db.Exec("UPDATE audit_entries SET actor = 'someone-else'").Error)
+		is.NotNil(db.Exec("DELETE FROM audit_entries").Error)
+	})
+
+	t.Run("should revert migrations one at a time or all of them", func(t *testing.T) {
+		db := NewSQLiteTestDB(t)
+		is := require.New(t)
+
+		migrator, err := migration.NewMigrator(db)
+		is.Nil(err)
+
+		_, err = migrator.Up(1)
+		is.Nil(err)
+
+		statuses, err := migrator.Status()
+		is.Nil(err)
+		is.True(statuses[0].Applied)
+		is.False(statuses[1].Applied)
+
+		_, err = migrator.Up(0)
+		is.Nil(err)
+
+		reverted, err := migrator.Down(1)
+		is.Nil(err)
+		is.Len(reverted, 1)
+		is.Equal(migrator.Migrations[len(migrator.Migrations)-1].Version, reverted[0].Version)
+		is.Nil(db.Exec("DELETE FROM audit_entries").Error)
+
+		reverted, err = migrator.Down(0)
+		is.Nil(err)
+		is.Len(reverted, len(migrator.Migrations)-1)
+		is.False(db.HasTable("accounts"))
+		is.False(db.HasTable("audit_entries"))
+
+		statuses, err = migrator.Status()
+		is.Nil(err)
+
+		for _, status := range statuses {
+			is.False(status.Applied)
+		}
+	})
+}
+
+func TestMigratorPostgres(t *testing.T) {
+	t.Parallel()
+
+	t.Run("should apply the pending migrations and record them", func(t *testing.T) {
+		db, mock := NewPostgresTestMock()
+		is := require.New(t)
+
+		migrator, err := migration.NewMigrator(db)
+		is.Nil(err)
+
+		expectSchemaMigrations(mock, 1)
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta(`CREATE OR REPLACE FUNCTION reject_audit_entry_change()`)).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO schema_migrations (version, name, applied_at) VALUES ($1, $2, $3)`)).
+			WithArgs(2, "protect_audit_log", sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta(`ALTER TABLE sagas ADD COLUMN lease_owner varchar(64)`)).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO schema_migrations (version, name, applied_at) VALUES ($1, $2, $3)`)).
+			WithArgs(3, "add_saga_lease", sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta(`ALTER TABLE sagas ADD COLUMN idempotency_key varchar(128)`)).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO schema_migrations (version, name, applied_at) VALUES ($1, $2, $3)`)).
+			WithArgs(4, "add_request_idempotency", sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta(`ALTER TABLE events ADD COLUMN tenant_id varchar(64)`)).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO schema_migrations (version, name, applied_at) VALUES ($1, $2, $3)`)).
+			WithArgs(5, "add_resource_tenant", sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta(`ALTER TABLE events ADD COLUMN sequence bigint`)).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO schema_migrations (version, name, applied_at) VALUES ($1, $2, $3)`)).
+			WithArgs(6, "chain_events_per_tenant", sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		applied, err := migrator.Up(0)
+
+		is.Nil(err)
+		is.Len(applied, 5)
+		is.Equal(int64(2), applied[0].Version)
+		is.Equal(int64(3), applied[1].Version)
+		is.Equal(int64(4), applied[2].Version)
+		is.Equal(int64(5), applied[3].Version)
+		is.Equal(int64(6), applied[4].Version)
+		is.Nil(mock.ExpectationsWereMet())
+	})
+
+	t.Run("should roll back a migration that fails", func(t *testing.T) {
+		db, mock := NewPostgresTestMock()
+		is := require.New(t)
+
+		migrator, err := migration.NewMigrator(db)
+		is.Nil(err)
+
+		expectSchemaMigrations(mock)
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta(`CREATE TABLE IF NOT EXISTS accounts`)).
+			WillReturnError(os.ErrPermission)
+		mock.ExpectRollback()
+
+		applied, err := migrator.Up(0)
+
+		is.ErrorIs(err, os.ErrPermission)
+		is.Empty(applied)
+		is.Nil(mock.ExpectationsWereMet())
+	})
+
+	t.Run("should revert the latest migration", func(t *testing.T) {
+		db, mock := NewPostgresTestMock()
+		is := require.New(t)
+
+		migrator, err := migration.NewMigrator(db)
+		is.Nil(err)
+
+		expectSchemaMigrations(mock, 1, 2)
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta(`DROP TRIGGER IF EXISTS audit_entries_append_only ON audit_entries`)).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM schema_migrations WHERE version = $1`)).
+			WithArgs(2).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		reverted, err := migrator.Down(1)
+
+		is.Nil(err)
+		is.Len(reverted, 1)
+		is.Equal("protect_audit_log", reverted[0].Name)
+		is.Nil(mock.ExpectationsWereMet())
+	})
+
+	t.Run("should not revert a migration unknown to the build", func(t *testing.T) {
+		db, mock := NewPostgresTestMock()
+		is := require.New(t)
+
+		migrator, err := migration.NewMigrator(db)
+		is.Nil(err)
+
+		expectSchemaMigrations(mock, 1, 9999)
+
+		reverted, err := migrator.Down(1)
+
+		is.ErrorIs(err, migration.ErrUnknownVersion)
+		is.Empty(reverted)
+
+		expectSchemaMigrations(mock, 1, 9999)
+
+		statuses, err := migrator.Status()
+
+		is.Nil(err)
+		is.True(statuses[len(statuses)-1].Unknown)
+		is.Nil(mock.ExpectationsWereMet())
+	})
+}
+
+func TestMigrations(t *testing.T) {
+	t.Parallel()
+
+	t.Run("should have the same versions for every dialect", func(t *testing.T) {
+		is := require.New(t)
+
+		postgres, _ := NewPostgresTestMock()
+		sqlite := NewSQLiteTestDB(t)
+
+		postgresMigrator, err := migration.NewMigrator(postgres)
+		is.Nil(err)
+		sqliteMigrator, err := migration.NewMigrator(sqlite)
+		is.Nil(err)
+
+		is.Equal(len(postgresMigrator.Migrations), len(sqliteMigrator.Migrations))
+
+		for i, m := range postgresMigrator.Migrations {
+			is.Equal(m.Version, sqliteMigrator.Migrations[i].Version)
+			is.Equal(m.Name, sqliteMigrator.Migrations[i].Name)
+		}
+	})
+
+	t.Run("should reject malformed migration directories", func(t *testing.T) {
+		is := require.New(t)
+
+		for _, fsys := range []fstest.MapFS{
+			{"0001_init.up.sql": {}},
+			{"init.up.sql": {}, "init.down.sql": {}},
+			{"0001_init.up.sql": {}, "0001_other.down.sql": {}},
+		} {
+			migrations, err := migration.Load(fsys)
+
+			is.NotNil(err)
+			is.Nil(migrations)
+		}
+	})
+
+	t.Run("should create the next migration for every dialect", func(t *testing.T) {
+		is := require.New(t)
+		dir := t.TempDir()
+
+		is.Nil(os.MkdirAll(filepath.Join(dir, "postgres"), 0755))
+		is.Nil(os.WriteFile(filepath.Join(dir, "postgres", "0007_existing.up.sql"), nil, 0644))
+
+		files, err := migration.Create(dir, "Add Ledger Index")
+
+		is.Nil(err)
+		is.Equal([]string{
+			filepath.Join(dir, "postgres", "0008_add_ledger_index.up.sql"),
+			filepath.Join(dir, "postgres", "0008_add_ledger_index.down.sql"),
+			filepath.Join(dir, "sqlite3", "0008_add_ledger_index.up.sql"),
+			filepath.Join(dir, "sqlite3", "0008_add_ledger_index.down.sql"),
+		}, files)
+
+		_, err = migration.Create(dir, "--")
+		is.ErrorIs(err, migration.ErrInvalidName)
+	})
+}
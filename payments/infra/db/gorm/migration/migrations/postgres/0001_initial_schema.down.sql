@@ -0,0 +1,11 @@
+DROP TABLE IF EXISTS audit_entries;
+DROP TABLE IF EXISTS batch_items;
+DROP TABLE IF EXISTS batches;
+DROP TABLE IF EXISTS sagas;
+DROP TABLE IF EXISTS events;
+DROP TABLE IF EXISTS webhook_attempts;
+DROP TABLE IF EXISTS webhook_deliveries;
+DROP TABLE IF EXISTS webhooks;
+DROP TABLE IF EXISTS idempotency_keys;
+DROP TABLE IF EXISTS transactions;
+DROP TABLE IF EXISTS accounts;
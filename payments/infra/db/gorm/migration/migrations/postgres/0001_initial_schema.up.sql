@@ -0,0 +1,154 @@
+-- Tables created with IF NOT EXISTS so databases set up by gorm's
+-- AutoMigrate are adopted as they are. The columns added to accounts and
+-- payments since AutoMigrate was dropped are added to such databases too.
+
+CREATE TABLE IF NOT EXISTS accounts (
+	id         uuid PRIMARY KEY,
+	created_at timestamp with time zone,
+	updated_at timestamp with time zone,
+	tenant_id  varchar(64) NOT NULL DEFAULT 'default',
+	balance    float,
+	frozen     boolean NOT NULL DEFAULT false
+);
+ALTER TABLE accounts ADD COLUMN IF NOT EXISTS tenant_id varchar(64) NOT NULL DEFAULT 'default';
+ALTER TABLE accounts ADD COLUMN IF NOT EXISTS frozen boolean NOT NULL DEFAULT false;
+CREATE INDEX IF NOT EXISTS idx_accounts_tenant_id ON accounts (tenant_id);
+
+CREATE TABLE IF NOT EXISTS transactions (
+	id              uuid PRIMARY KEY,
+	created_at      timestamp with time zone,
+	updated_at      timestamp with time zone,
+	tenant_id       varchar(64) NOT NULL DEFAULT 'default',
+	amount          float,
+	status          varchar(20),
+	currency        varchar(5),
+	account_from_id uuid NOT NULL,
+	account_to_id   uuid DEFAULT NULL,
+	type            varchar(30),
+	external_id     uuid
+);
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS tenant_id varchar(64) NOT NULL DEFAULT 'default';
+CREATE INDEX IF NOT EXISTS idx_transactions_tenant_id ON transactions (tenant_id);
+
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+	key            varchar(128) PRIMARY KEY,
+	transaction_id uuid NOT NULL,
+	created_at     timestamp with time zone
+);
+
+CREATE TABLE IF NOT EXISTS webhooks (
+	id         uuid PRIMARY KEY,
+	created_at timestamp with time zone,
+	updated_at timestamp with time zone,
+	account_id uuid NOT NULL,
+	url        varchar(2048),
+	secret     varchar(64),
+	active     boolean
+);
+CREATE INDEX IF NOT EXISTS idx_webhooks_account_id ON webhooks (account_id);
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+	id              uuid PRIMARY KEY,
+	created_at      timestamp with time zone,
+	updated_at      timestamp with time zone,
+	webhook_id      uuid NOT NULL,
+	transaction_id  uuid NOT NULL,
+	event           varchar(50),
+	payload         text,
+	status          varchar(20),
+	attempts        integer,
+	next_attempt_at timestamp with time zone
+);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_webhook_id ON webhook_deliveries (webhook_id);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_status ON webhook_deliveries (status);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_next_attempt_at ON webhook_deliveries (next_attempt_at);
+
+CREATE TABLE IF NOT EXISTS webhook_attempts (
+	id          uuid PRIMARY KEY,
+	created_at  timestamp with time zone,
+	updated_at  timestamp with time zone,
+	delivery_id uuid NOT NULL,
+	status_code integer,
+	error       text,
+	duration    bigint
+);
+CREATE INDEX IF NOT EXISTS idx_webhook_attempts_delivery_id ON webhook_attempts (delivery_id);
+
+CREATE TABLE IF NOT EXISTS events (
+	id             uuid PRIMARY KEY,
+	aggregate_type varchar(30),
+	aggregate_id   uuid,
+	version        integer,
+	type           varchar(50),
+	payload        text,
+	previous_hash  varchar(64),
+	hash           varchar(64),
+	created_at     timestamp with time zone
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_event_aggregate_version ON events (aggregate_type, aggregate_id, version);
+
+CREATE TABLE IF NOT EXISTS sagas (
+	id                 uuid PRIMARY KEY,
+	created_at         timestamp with time zone,
+	updated_at         timestamp with time zone,
+	transaction_id     uuid NOT NULL,
+	account_from_id    uuid NOT NULL,
+	account_to_id      uuid NOT NULL,
+	external_id        uuid,
+	currency           varchar(5),
+	amount             float,
+	status             varchar(20),
+	step               varchar(20),
+	provider_reference varchar(255),
+	error              text,
+	attempts           integer,
+	expires_at         timestamp with time zone
+);
+CREATE INDEX IF NOT EXISTS idx_sagas_transaction_id ON sagas (transaction_id);
+CREATE INDEX IF NOT EXISTS idx_sagas_status ON sagas (status);
+
+CREATE TABLE IF NOT EXISTS batches (
+	id         uuid PRIMARY KEY,
+	created_at timestamp with time zone,
+	updated_at timestamp with time zone,
+	mode       varchar(20),
+	status     varchar(20),
+	total      integer,
+	succeeded  integer,
+	failed     integer
+);
+
+CREATE TABLE IF NOT EXISTS batch_items (
+	id              uuid PRIMARY KEY,
+	created_at      timestamp with time zone,
+	updated_at      timestamp with time zone,
+	batch_id        uuid NOT NULL,
+	position        integer,
+	account_from_id varchar(36),
+	account_to_id   varchar(36),
+	external_id     varchar(36),
+	type            varchar(30),
+	currency        varchar(5),
+	amount          float,
+	status          varchar(20),
+	transaction_id  varchar(36),
+	error           text
+);
+CREATE INDEX IF NOT EXISTS idx_batch_items_batch_id ON batch_items (batch_id);
+
+CREATE TABLE IF NOT EXISTS audit_entries (
+	id          uuid PRIMARY KEY,
+	tenant_id   varchar(64) NOT NULL DEFAULT 'default',
+	actor       varchar(255),
+	action      varchar(50),
+	entity_type varchar(30),
+	entity_id   uuid,
+	"before"    text,
+	"after"     text,
+	request_id  varchar(128),
+	created_at  timestamp with time zone
+);
+CREATE INDEX IF NOT EXISTS idx_audit_entries_tenant_id ON audit_entries (tenant_id);
+CREATE INDEX IF NOT EXISTS idx_audit_entries_actor ON audit_entries (actor);
+CREATE INDEX IF NOT EXISTS idx_audit_entity ON audit_entries (entity_type, entity_id);
+CREATE INDEX IF NOT EXISTS idx_audit_entries_created_at ON audit_entries (created_at);
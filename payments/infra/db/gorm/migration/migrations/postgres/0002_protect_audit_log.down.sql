@@ -0,0 +1,2 @@
+DROP TRIGGER IF EXISTS audit_entries_append_only ON audit_entries;
+DROP FUNCTION IF EXISTS reject_audit_entry_change();
@@ -0,0 +1,13 @@
+-- The audit log is append-only for every client of the database, not only
+-- for this service.
+CREATE OR REPLACE FUNCTION reject_audit_entry_change() RETURNS trigger AS $$
+BEGIN
+	RAISE EXCEPTION 'audit entries are append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS audit_entries_append_only ON audit_entries;
+
+CREATE TRIGGER audit_entries_append_only
+	BEFORE UPDATE OR DELETE OR TRUNCATE ON audit_entries
+	FOR EACH STATEMENT EXECUTE PROCEDURE reject_audit_entry_change();
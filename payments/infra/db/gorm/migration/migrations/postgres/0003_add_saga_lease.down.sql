@@ -0,0 +1,2 @@
+ALTER TABLE sagas DROP COLUMN IF EXISTS lease_expires_at;
+ALTER TABLE sagas DROP COLUMN IF EXISTS lease_owner;
@@ -0,0 +1,4 @@
+-- Recoverers claim a saga before driving it, so two instances never run the
+-- same saga at once.
+ALTER TABLE sagas ADD COLUMN lease_owner varchar(64);
+ALTER TABLE sagas ADD COLUMN lease_expires_at timestamp with time zone;
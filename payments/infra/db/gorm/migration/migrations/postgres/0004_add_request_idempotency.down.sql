@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_batches_idempotency_key;
+ALTER TABLE batches DROP COLUMN IF EXISTS idempotency_key;
+
+DROP INDEX IF EXISTS idx_sagas_idempotency_key;
+ALTER TABLE sagas DROP COLUMN IF EXISTS idempotency_key;
@@ -0,0 +1,20 @@
+DROP INDEX IF EXISTS idx_batches_idempotency_key;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_batches_idempotency_key ON batches (idempotency_key) WHERE idempotency_key <> '';
+
+DROP INDEX IF EXISTS idx_sagas_idempotency_key;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_sagas_idempotency_key ON sagas (idempotency_key) WHERE idempotency_key <> '';
+
+DROP INDEX IF EXISTS idx_batches_tenant_id;
+ALTER TABLE batches DROP COLUMN IF EXISTS tenant_id;
+
+DROP INDEX IF EXISTS idx_sagas_tenant_id;
+ALTER TABLE sagas DROP COLUMN IF EXISTS tenant_id;
+
+DROP INDEX IF EXISTS idx_webhook_deliveries_tenant_id;
+ALTER TABLE webhook_deliveries DROP COLUMN IF EXISTS tenant_id;
+
+DROP INDEX IF EXISTS idx_webhooks_tenant_id;
+ALTER TABLE webhooks DROP COLUMN IF EXISTS tenant_id;
+
+DROP INDEX IF EXISTS idx_events_tenant_id;
+ALTER TABLE events DROP COLUMN IF EXISTS tenant_id;
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS ledgers;
+
+DROP INDEX IF EXISTS idx_event_tenant_sequence;
+ALTER TABLE events DROP COLUMN IF EXISTS sequence;
@@ -0,0 +1,18 @@
+-- Events are chained in one ledger per tenant instead of one chain per
+-- aggregate, so removing every event of an aggregate still breaks a chain.
+-- The ledgers row of a tenant is locked by every append, which chains the
+-- events of a tenant one after the other.
+-- Existing events are numbered in the order they were written, but keep the
+-- hashes of their aggregate chains: verify the ledger before upgrading, as
+-- verification of a tenant with such events stops at its first event.
+ALTER TABLE events ADD COLUMN sequence bigint NOT NULL DEFAULT 0;
+UPDATE events SET sequence = numbered.sequence
+	FROM (SELECT id, ROW_NUMBER() OVER (PARTITION BY tenant_id ORDER BY created_at, id) AS sequence FROM events) numbered
+	WHERE events.id = numbered.id;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_event_tenant_sequence ON events (tenant_id, sequence);
+
+CREATE TABLE IF NOT EXISTS ledgers (
+	tenant_id varchar(64) PRIMARY KEY,
+	sequence  bigint NOT NULL DEFAULT 0
+);
+INSERT INTO ledgers (tenant_id, sequence) SELECT tenant_id, MAX(sequence) FROM events GROUP BY tenant_id;
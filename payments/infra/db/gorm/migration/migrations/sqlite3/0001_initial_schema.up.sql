@@ -0,0 +1,153 @@
+-- Tables created with IF NOT EXISTS so databases set up by gorm's
+-- AutoMigrate are adopted as they are. SQLite cannot add a column only when
+-- it is missing, so a database adopted here must already have the tenant_id
+-- and frozen columns of accounts and payments: add the missing ones with
+-- ALTER TABLE ... ADD COLUMN, as declared below, before migrating it.
+
+CREATE TABLE IF NOT EXISTS accounts (
+	id         varchar(36) PRIMARY KEY,
+	created_at datetime,
+	updated_at datetime,
+	tenant_id  varchar(64) NOT NULL DEFAULT 'default',
+	balance    float,
+	frozen     boolean NOT NULL DEFAULT false
+);
+CREATE INDEX IF NOT EXISTS idx_accounts_tenant_id ON accounts (tenant_id);
+
+CREATE TABLE IF NOT EXISTS transactions (
+	id              varchar(36) PRIMARY KEY,
+	created_at      datetime,
+	updated_at      datetime,
+	tenant_id       varchar(64) NOT NULL DEFAULT 'default',
+	amount          float,
+	status          varchar(20),
+	currency        varchar(5),
+	account_from_id varchar(36) NOT NULL,
+	account_to_id   varchar(36) DEFAULT NULL,
+	type            varchar(30),
+	external_id     varchar(36)
+);
+CREATE INDEX IF NOT EXISTS idx_transactions_tenant_id ON transactions (tenant_id);
+
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+	key            varchar(128) PRIMARY KEY,
+	transaction_id varchar(36) NOT NULL,
+	created_at     datetime
+);
+
+CREATE TABLE IF NOT EXISTS webhooks (
+	id         varchar(36) PRIMARY KEY,
+	created_at datetime,
+	updated_at datetime,
+	account_id varchar(36) NOT NULL,
+	url        varchar(2048),
+	secret     varchar(64),
+	active     boolean
+);
+CREATE INDEX IF NOT EXISTS idx_webhooks_account_id ON webhooks (account_id);
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+	id              varchar(36) PRIMARY KEY,
+	created_at      datetime,
+	updated_at      datetime,
+	webhook_id      varchar(36) NOT NULL,
+	transaction_id  varchar(36) NOT NULL,
+	event           varchar(50),
+	payload         text,
+	status          varchar(20),
+	attempts        integer,
+	next_attempt_at datetime
+);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_webhook_id ON webhook_deliveries (webhook_id);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_status ON webhook_deliveries (status);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_next_attempt_at ON webhook_deliveries (next_attempt_at);
+
+CREATE TABLE IF NOT EXISTS webhook_attempts (
+	id          varchar(36) PRIMARY KEY,
+	created_at  datetime,
+	updated_at  datetime,
+	delivery_id varchar(36) NOT NULL,
+	status_code integer,
+	error       text,
+	duration    bigint
+);
+CREATE INDEX IF NOT EXISTS idx_webhook_attempts_delivery_id ON webhook_attempts (delivery_id);
+
+CREATE TABLE IF NOT EXISTS events (
+	id             varchar(36) PRIMARY KEY,
+	aggregate_type varchar(30),
+	aggregate_id   varchar(36),
+	version        integer,
+	type           varchar(50),
+	payload        text,
+	previous_hash  varchar(64),
+	hash           varchar(64),
+	created_at     datetime
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_event_aggregate_version ON events (aggregate_type, aggregate_id, version);
+
+CREATE TABLE IF NOT EXISTS sagas (
+	id                 varchar(36) PRIMARY KEY,
+	created_at         datetime,
+	updated_at         datetime,
+	transaction_id     varchar(36) NOT NULL,
+	account_from_id    varchar(36) NOT NULL,
+	account_to_id      varchar(36) NOT NULL,
+	external_id        varchar(36),
+	currency           varchar(5),
+	amount             float,
+	status             varchar(20),
+	step               varchar(20),
+	provider_reference varchar(255),
+	error              text,
+	attempts           integer,
+	expires_at         datetime
+);
+CREATE INDEX IF NOT EXISTS idx_sagas_transaction_id ON sagas (transaction_id);
+CREATE INDEX IF NOT EXISTS idx_sagas_status ON sagas (status);
+
+CREATE TABLE IF NOT EXISTS batches (
+	id         varchar(36) PRIMARY KEY,
+	created_at datetime,
+	updated_at datetime,
+	mode       varchar(20),
+	status     varchar(20),
+	total      integer,
+	succeeded  integer,
+	failed     integer
+);
+
+CREATE TABLE IF NOT EXISTS batch_items (
+	id              varchar(36) PRIMARY KEY,
+	created_at      datetime,
+	updated_at      datetime,
+	batch_id        varchar(36) NOT NULL,
+	position        integer,
+	account_from_id varchar(36),
+	account_to_id   varchar(36),
+	external_id     varchar(36),
+	type            varchar(30),
+	currency        varchar(5),
+	amount          float,
+	status          varchar(20),
+	transaction_id  varchar(36),
+	error           text
+);
+CREATE INDEX IF NOT EXISTS idx_batch_items_batch_id ON batch_items (batch_id);
+
+CREATE TABLE IF NOT EXISTS audit_entries (
+	id          varchar(36) PRIMARY KEY,
+	tenant_id   varchar(64) NOT NULL DEFAULT 'default',
+	actor       varchar(255),
+	action      varchar(50),
+	entity_type varchar(30),
+	entity_id   varchar(36),
+	"before"    text,
+	"after"     text,
+	request_id  varchar(128),
+	created_at  datetime
+);
+CREATE INDEX IF NOT EXISTS idx_audit_entries_tenant_id ON audit_entries (tenant_id);
+CREATE INDEX IF NOT EXISTS idx_audit_entries_actor ON audit_entries (actor);
+CREATE INDEX IF NOT EXISTS idx_audit_entity ON audit_entries (entity_type, entity_id);
+CREATE INDEX IF NOT EXISTS idx_audit_entries_created_at ON audit_entries (created_at);
@@ -0,0 +1,2 @@
+DROP TRIGGER IF EXISTS audit_entries_no_update;
+DROP TRIGGER IF EXISTS audit_entries_no_delete;
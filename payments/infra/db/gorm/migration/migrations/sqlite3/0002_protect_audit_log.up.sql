@@ -0,0 +1,13 @@
+-- The audit log is append-only for every client of the database, not only
+-- for this service.
+CREATE TRIGGER IF NOT EXISTS audit_entries_no_update
+BEFORE UPDATE ON audit_entries
+BEGIN
+	SELECT RAISE(ABORT, 'audit entries are append-only');
+END;
+
+CREATE TRIGGER IF NOT EXISTS audit_entries_no_delete
+BEFORE DELETE ON audit_entries
+BEGIN
+	SELECT RAISE(ABORT, 'audit entries are append-only');
+END;
@@ -0,0 +1,29 @@
+-- SQLite cannot drop columns, so the table is rebuilt without them.
+CREATE TABLE sagas_without_lease (
+	id                 varchar(36) PRIMARY KEY,
+	created_at         datetime,
+	updated_at         datetime,
+	transaction_id     varchar(36) NOT NULL,
+	account_from_id    varchar(36) NOT NULL,
+	account_to_id      varchar(36) NOT NULL,
+	external_id        varchar(36),
+	currency           varchar(5),
+	amount             float,
+	status             varchar(20),
+	step               varchar(20),
+	provider_reference varchar(255),
+	error              text,
+	attempts           integer,
+	expires_at         datetime
+);
+
+INSERT INTO sagas_without_lease
+SELECT id, created_at, updated_at, transaction_id, account_from_id, account_to_id, external_id,
+	currency, amount, status, step, provider_reference, error, attempts, expires_at
+FROM sagas;
+
+DROP TABLE sagas;
+ALTER TABLE sagas_without_lease RENAME TO sagas;
+
+CREATE INDEX IF NOT EXISTS idx_sagas_transaction_id ON sagas (transaction_id);
+CREATE INDEX IF NOT EXISTS idx_sagas_status ON sagas (status);
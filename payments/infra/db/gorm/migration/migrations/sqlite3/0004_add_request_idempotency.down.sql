@@ -0,0 +1,50 @@
+-- SQLite cannot drop columns, so the tables are rebuilt without them.
+CREATE TABLE sagas_without_idempotency (
+	id                 varchar(36) PRIMARY KEY,
+	created_at         datetime,
+	updated_at         datetime,
+	transaction_id     varchar(36) NOT NULL,
+	account_from_id    varchar(36) NOT NULL,
+	account_to_id      varchar(36) NOT NULL,
+	external_id        varchar(36),
+	currency           varchar(5),
+	amount             float,
+	status             varchar(20),
+	step               varchar(20),
+	provider_reference varchar(255),
+	error              text,
+	attempts           integer,
+	expires_at         datetime,
+	lease_owner        varchar(64),
+	lease_expires_at   datetime
+);
+
+INSERT INTO sagas_without_idempotency
+SELECT id, created_at, updated_at, transaction_id, account_from_id, account_to_id, external_id,
+	currency, amount, status, step, provider_reference, error, attempts, expires_at,
+	lease_owner, lease_expires_at
+FROM sagas;
+
+DROP TABLE sagas;
+ALTER TABLE sagas_without_idempotency RENAME TO sagas;
+
+CREATE INDEX IF NOT EXISTS idx_sagas_transaction_id ON sagas (transaction_id);
+CREATE INDEX IF NOT EXISTS idx_sagas_status ON sagas (status);
+
+CREATE TABLE batches_without_idempotency (
+	id         varchar(36) PRIMARY KEY,
+	created_at datetime,
+	updated_at datetime,
+	mode       varchar(20),
+	status     varchar(20),
+	total      integer,
+	succeeded  integer,
+	failed     integer
+);
+
+INSERT INTO batches_without_idempotency
+SELECT id, created_at, updated_at, mode, status, total, succeeded, failed
+FROM batches;
+
+DROP TABLE batches;
+ALTER TABLE batches_without_idempotency RENAME TO batches;
@@ -0,0 +1,7 @@
+-- Service payments and batches keep the idempotency key they were started
+-- with, so a retried request replays them instead of running them again.
+ALTER TABLE sagas ADD COLUMN idempotency_key varchar(128) NOT NULL DEFAULT '';
+CREATE UNIQUE INDEX IF NOT EXISTS idx_sagas_idempotency_key ON sagas (idempotency_key) WHERE idempotency_key <> '';
+
+ALTER TABLE batches ADD COLUMN idempotency_key varchar(128) NOT NULL DEFAULT '';
+CREATE UNIQUE INDEX IF NOT EXISTS idx_batches_idempotency_key ON batches (idempotency_key) WHERE idempotency_key <> '';
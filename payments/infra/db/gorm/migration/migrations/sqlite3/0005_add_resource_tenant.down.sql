@@ -0,0 +1,119 @@
+-- SQLite cannot drop columns, so the tables are rebuilt without them.
+CREATE TABLE events_without_tenant (
+	id             varchar(36) PRIMARY KEY,
+	aggregate_type varchar(30),
+	aggregate_id   varchar(36),
+	version        integer,
+	type           varchar(50),
+	payload        text,
+	previous_hash  varchar(64),
+	hash           varchar(64),
+	created_at     datetime
+);
+
+INSERT INTO events_without_tenant
+SELECT id, aggregate_type, aggregate_id, version, type, payload, previous_hash, hash, created_at
+FROM events;
+
+DROP TABLE events;
+ALTER TABLE events_without_tenant RENAME TO events;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_event_aggregate_version ON events (aggregate_type, aggregate_id, version);
+
+CREATE TABLE webhooks_without_tenant (
+	id         varchar(36) PRIMARY KEY,
+	created_at datetime,
+	updated_at datetime,
+	account_id varchar(36) NOT NULL,
+	url        varchar(2048),
+	secret     varchar(64),
+	active     boolean
+);
+
+INSERT INTO webhooks_without_tenant
+SELECT id, created_at, updated_at, account_id, url, secret, active
+FROM webhooks;
+
+DROP TABLE webhooks;
+ALTER TABLE webhooks_without_tenant RENAME TO webhooks;
+
+CREATE INDEX IF NOT EXISTS idx_webhooks_account_id ON webhooks (account_id);
+
+CREATE TABLE webhook_deliveries_without_tenant (
+	id              varchar(36) PRIMARY KEY,
+	created_at      datetime,
+	updated_at      datetime,
+	webhook_id      varchar(36) NOT NULL,
+	transaction_id  varchar(36) NOT NULL,
+	event           varchar(50),
+	payload         text,
+	status          varchar(20),
+	attempts        integer,
+	next_attempt_at datetime
+);
+
+INSERT INTO webhook_deliveries_without_tenant
+SELECT id, created_at, updated_at, webhook_id, transaction_id, event, payload, status, attempts, next_attempt_at
+FROM webhook_deliveries;
+
+DROP TABLE webhook_deliveries;
+ALTER TABLE webhook_deliveries_without_tenant RENAME TO webhook_deliveries;
+
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_webhook_id ON webhook_deliveries (webhook_id);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_status ON webhook_deliveries (status);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_next_attempt_at ON webhook_deliveries (next_attempt_at);
+
+CREATE TABLE sagas_without_tenant (
+	id                 varchar(36) PRIMARY KEY,
+	created_at         datetime,
+	updated_at         datetime,
+	transaction_id     varchar(36) NOT NULL,
+	account_from_id    varchar(36) NOT NULL,
+	account_to_id      varchar(36) NOT NULL,
+	external_id        varchar(36),
+	currency           varchar(5),
+	amount             float,
+	status             varchar(20),
+	step               varchar(20),
+	provider_reference varchar(255),
+	error              text,
+	attempts           integer,
+	expires_at         datetime,
+	lease_owner        varchar(64),
+	lease_expires_at   datetime,
+	idempotency_key    varchar(128) NOT NULL DEFAULT ''
+);
+
+INSERT INTO sagas_without_tenant
+SELECT id, created_at, updated_at, transaction_id, account_from_id, account_to_id, external_id,
+	currency, amount, status, step, provider_reference, error, attempts, expires_at,
+	lease_owner, lease_expires_at, idempotency_key
+FROM sagas;
+
+DROP TABLE sagas;
+ALTER TABLE sagas_without_tenant RENAME TO sagas;
+
+CREATE INDEX IF NOT EXISTS idx_sagas_transaction_id ON sagas (transaction_id);
+CREATE INDEX IF NOT EXISTS idx_sagas_status ON sagas (status);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_sagas_idempotency_key ON sagas (idempotency_key) WHERE idempotency_key <> '';
+
+CREATE TABLE batches_without_tenant (
+	id              varchar(36) PRIMARY KEY,
+	created_at      datetime,
+	updated_at      datetime,
+	mode            varchar(20),
+	status          varchar(20),
+	total           integer,
+	succeeded       integer,
+	failed          integer,
+	idempotency_key varchar(128) NOT NULL DEFAULT ''
+);
+
+INSERT INTO batches_without_tenant
+SELECT id, created_at, updated_at, mode, status, total, succeeded, failed, idempotency_key
+FROM batches;
+
+DROP TABLE batches;
+ALTER TABLE batches_without_tenant RENAME TO batches;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_batches_idempotency_key ON batches (idempotency_key) WHERE idempotency_key <> '';
@@ -0,0 +1,41 @@
+-- Events, webhooks, deliveries, service payments and batches belong to the
+-- tenant of the accounts they were made for, so the tenant scope covers them.
+-- Existing rows take the tenant of their account.
+ALTER TABLE events ADD COLUMN tenant_id varchar(64) NOT NULL DEFAULT 'default';
+UPDATE events SET tenant_id = COALESCE(
+	(SELECT accounts.tenant_id FROM accounts WHERE events.aggregate_type = 'account' AND accounts.id = events.aggregate_id),
+	(SELECT transactions.tenant_id FROM transactions WHERE events.aggregate_type = 'transaction' AND transactions.id = events.aggregate_id),
+	'default');
+CREATE INDEX IF NOT EXISTS idx_events_tenant_id ON events (tenant_id);
+
+ALTER TABLE webhooks ADD COLUMN tenant_id varchar(64) NOT NULL DEFAULT 'default';
+UPDATE webhooks SET tenant_id = COALESCE(
+	(SELECT accounts.tenant_id FROM accounts WHERE accounts.id = webhooks.account_id),
+	'default');
+CREATE INDEX IF NOT EXISTS idx_webhooks_tenant_id ON webhooks (tenant_id);
+
+ALTER TABLE webhook_deliveries ADD COLUMN tenant_id varchar(64) NOT NULL DEFAULT 'default';
+UPDATE webhook_deliveries SET tenant_id = COALESCE(
+	(SELECT webhooks.tenant_id FROM webhooks WHERE webhooks.id = webhook_deliveries.webhook_id),
+	'default');
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_tenant_id ON webhook_deliveries (tenant_id);
+
+ALTER TABLE sagas ADD COLUMN tenant_id varchar(64) NOT NULL DEFAULT 'default';
+UPDATE sagas SET tenant_id = COALESCE(
+	(SELECT accounts.tenant_id FROM accounts WHERE accounts.id = sagas.account_from_id),
+	'default');
+CREATE INDEX IF NOT EXISTS idx_sagas_tenant_id ON sagas (tenant_id);
+
+ALTER TABLE batches ADD COLUMN tenant_id varchar(64) NOT NULL DEFAULT 'default';
+UPDATE batches SET tenant_id = COALESCE(
+	(SELECT accounts.tenant_id FROM batch_items JOIN accounts ON accounts.id = batch_items.account_from_id
+		WHERE batch_items.batch_id = batches.id LIMIT 1),
+	'default');
+CREATE INDEX IF NOT EXISTS idx_batches_tenant_id ON batches (tenant_id);
+
+-- Idempotency keys are chosen by the clients of a tenant.
+DROP INDEX IF EXISTS idx_sagas_idempotency_key;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_sagas_idempotency_key ON sagas (tenant_id, idempotency_key) WHERE idempotency_key <> '';
+
+DROP INDEX IF EXISTS idx_batches_idempotency_key;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_batches_idempotency_key ON batches (tenant_id, idempotency_key) WHERE idempotency_key <> '';
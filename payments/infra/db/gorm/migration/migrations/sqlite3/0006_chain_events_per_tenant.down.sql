@@ -0,0 +1,25 @@
+DROP TABLE IF EXISTS ledgers;
+
+-- SQLite cannot drop columns, so the table is rebuilt without them.
+CREATE TABLE events_without_sequence (
+	id             varchar(36) PRIMARY KEY,
+	aggregate_type varchar(30),
+	aggregate_id   varchar(36),
+	version        integer,
+	type           varchar(50),
+	payload        text,
+	previous_hash  varchar(64),
+	hash           varchar(64),
+	created_at     datetime,
+	tenant_id      varchar(64) NOT NULL DEFAULT 'default'
+);
+
+INSERT INTO events_without_sequence
+SELECT id, aggregate_type, aggregate_id, version, type, payload, previous_hash, hash, created_at, tenant_id
+FROM events;
+
+DROP TABLE events;
+ALTER TABLE events_without_sequence RENAME TO events;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_event_aggregate_version ON events (aggregate_type, aggregate_id, version);
+CREATE INDEX IF NOT EXISTS idx_events_tenant_id ON events (tenant_id);
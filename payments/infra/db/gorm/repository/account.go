@@ -1,6 +1,8 @@
 package repository
 
 import (
+	"context"
+
 	"github.com/EdlanioJ/kbu/payments/domain/entity"
 	"github.com/jinzhu/gorm"
 )
@@ -15,22 +17,46 @@ func NewAccountRepository(db *gorm.DB) *AccountRepositoryGORM {
 	}
 }
 
-func (a *AccountRepositoryGORM) Find(id string) (*entity.Account, error) {
+func (a *AccountRepositoryGORM) Find(ctx context.Context, id string) (*entity.Account, error) {
 	account := &entity.Account{}
 
-	err := a.DB.First(account, "id = ?", id).Error
+	err := withContext(ctx, a.DB, "repository.Account.Find", true, func(tx *gorm.DB) error {
+		return tx.First(account, "id = ?", id).Error
+	})
 
 	if err != nil {
-		return nil, err
+		return nil, translate(err, "account", id)
 	}
 
 	return account, nil
 }
-func (a *AccountRepositoryGORM) Save(account *entity.Account) error {
-	err := a.DB.Save(account).Error
+func (a *AccountRepositoryGORM) Save(ctx context.Context, account *entity.Account) error {
+	err := withContext(ctx, a.DB, "repository.Account.Save", false, func(tx *gorm.DB) error {
+		err := save(ctx, tx, account)
+
+		if err != nil {
+			return err
+		}
+
+		return appendEvent(ctx, tx, account.TenantID, entity.AggregateAccount, account.ID, account, func(previous *entity.Event) (string, error) {
+			if previous == nil {
+				return entity.AccountEventType(nil, account), nil
+			}
+
+			snapshot := &entity.Account{}
+
+			err := previous.Decode(snapshot)
+
+			if err != nil {
+				return "", err
+			}
+
+			return entity.AccountEventType(snapshot, account), nil
+		})
+	})
 
 	if err != nil {
-		return err
+		return translate(err, "account", account.ID)
 	}
 
 	return nil
@@ -1,6 +1,8 @@
 package repository_test
 
 import (
+	"context"
+	"errors"
 	"regexp"
 	"testing"
 
@@ -49,7 +51,7 @@ func TestAccountRepository(t *testing.T) {
 			WithArgs(account.ID).
 			WillReturnRows(row)
 
-		result, err := repo.Find(account.ID)
+		result, err := repo.Find(context.Background(), account.ID)
 
 		is.Nil(err)
 		is.Equal(result.ID, account.ID)
@@ -57,7 +59,7 @@ func TestAccountRepository(t *testing.T) {
 		is.Equal(result.CreatedAt, account.CreatedAt)
 
 		id := uuid.NewV4().String()
-		result, err = repo.Find(id)
+		result, err = repo.Find(context.Background(), id)
 
 		is.NotNil(err)
 		is.Nil(result)
@@ -71,26 +73,74 @@ func TestAccountRepository(t *testing.T) {
 			AddRow(account.ID, account.Balance, account.CreatedAt)
 
 		const sqlSelect = `SELECT * FROM "accounts" WHERE "accounts"."id" = $1 ORDER BY "accounts"."id" ASC LIMIT 1`
-		const sqlUpdate = `UPDATE "accounts" SET "created_at" = $1, "updated_at" = $2, "balance" = $3 WHERE "accounts"."id" = $4`
+		const sqlUpdate = `UPDATE "accounts" SET "created_at" = $1, "updated_at" = $2, "tenant_id" = $3, "balance" = $4, "frozen" = $5 WHERE "accounts"."id" = $6`
 
 		mock.ExpectBegin()
 
 		mock.ExpectExec(regexp.QuoteMeta(sqlUpdate)).
-			WithArgs(account.CreatedAt, sqlmock.AnyArg(), account.Balance, account.ID).
+			WithArgs(account.CreatedAt, sqlmock.AnyArg(), account.TenantID, account.Balance, account.Frozen, account.ID).
 			WillReturnResult(sqlmock.NewResult(0, 0))
-		mock.ExpectCommit()
 
 		mock.ExpectQuery(regexp.QuoteMeta(sqlSelect)).
 			WithArgs(account.ID).
 			WillReturnRows(rows)
 
-		err := repo.Save(account)
+		opened, _ := entity.NewEvent(entity.AggregateAccount, account.ID, 1, entity.EventAccountOpened, &entity.Account{Base: account.Base, Balance: account.Balance + 100})
+		previous := sqlmock.NewRows(eventColumns).
+			AddRow(opened.ID, opened.AggregateType, opened.AggregateID, opened.Version, opened.Type, opened.Payload, opened.CreatedAt)
+		expectAppendEvent(mock, entity.AggregateAccount, account.ID, previous, 2, entity.EventAccountDebited)
+		mock.ExpectCommit()
+
+		err := repo.Save(context.Background(), account)
 
 		is.Nil(err)
 
-		err = repo.Save(&entity.Account{})
+		err = repo.Save(context.Background(), &entity.Account{})
 
 		is.NotNil(err)
 		is.Error(err)
 	})
+
+	t.Run("should run the query on a transaction bound to the context", func(t *testing.T) {
+		repo, mock, account := NewAccountTestMock()
+		is := require.New(t)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		row := sqlmock.NewRows([]string{"id", "balance", "created_at"}).
+			AddRow(account.ID, account.Balance, account.CreatedAt)
+
+		const sql = `SELECT * FROM "accounts" WHERE (id = $1) ORDER BY "accounts"."id" ASC LIMIT 1`
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta(sql)).
+			WithArgs(account.ID).
+			WillReturnRows(row)
+		mock.ExpectCommit()
+
+		result, err := repo.Find(ctx, account.ID)
+
+		is.Nil(err)
+		is.Equal(account.ID, result.ID)
+		is.Nil(mock.ExpectationsWereMet())
+	})
+
+	t.Run("should not query once the context is cancelled", func(t *testing.T) {
+		repo, mock, account := NewAccountTestMock()
+		is := require.New(t)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		result, err := repo.Find(ctx, account.ID)
+
+		is.True(errors.Is(err, context.Canceled))
+		is.Nil(result)
+
+		err = repo.Save(ctx, account)
+
+		is.True(errors.Is(err, context.Canceled))
+		is.Nil(mock.ExpectationsWereMet())
+	})
 }
@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/EdlanioJ/kbu/payments/domain/entity"
+	"github.com/jinzhu/gorm"
+)
+
+type AuditRepositoryGORM struct {
+	DB *gorm.DB
+}
+
+func NewAuditRepository(db *gorm.DB) *AuditRepositoryGORM {
+	return &AuditRepositoryGORM{
+		DB: db,
+	}
+}
+
+func (a *AuditRepositoryGORM) FindAll(ctx context.Context, filter *entity.AuditFilter, pagination *entity.Pagination) ([]*entity.AuditEntry, int, error) {
+	var entries []*entity.AuditEntry
+	var total int
+
+	err := withContext(ctx, a.DB, "repository.Audit.FindAll", true, func(tx *gorm.DB) error {
+		query := filterAudit(tx.Model(&entity.AuditEntry{}), filter)
+
+		err := query.Count(&total).Error
+
+		if err != nil {
+			return err
+		}
+
+		return query.
+			Order("created_at desc").
+			Offset((pagination.Page - 1) * pagination.Limit).
+			Limit(pagination.Limit).
+			Find(&entries).
+			Error
+	})
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return entries, total, nil
+}
+
+func filterAudit(query *gorm.DB, filter *entity.AuditFilter) *gorm.DB {
+	if filter.Actor != "" {
+		query = query.Where("actor = ?", filter.Actor)
+	}
+
+	if filter.Action != "" {
+		query = query.Where("action = ?", filter.Action)
+	}
+
+	if filter.EntityType != "" {
+		query = query.Where("entity_type = ?", filter.EntityType)
+	}
+
+	if filter.EntityID != "" {
+		query = query.Where("entity_id = ?", filter.EntityID)
+	}
+
+	if !filter.From.IsZero() {
+		query = query.Where("created_at >= ?", filter.From)
+	}
+
+	if !filter.To.IsZero() {
+		query = query.Where("created_at < ?", filter.To)
+	}
+
+	return query
+}
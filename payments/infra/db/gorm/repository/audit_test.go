@@ -0,0 +1,130 @@
+package repository_test
+
+import (
+	"context"
+	"database/sql/driver"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/EdlanioJ/kbu/payments/domain/entity"
+	"github.com/EdlanioJ/kbu/payments/infra/db/gorm/repository"
+	uuid "github.com/satori/go.uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func expectAudit(mock sqlmock.Sqlmock, actor driver.Value, action, entityType, entityID string) {
+	const insertAudit = `INSERT INTO "audit_entries" ("id","tenant_id","actor","action","entity_type","entity_id","before","after","request_id","created_at") VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10) RETURNING "audit_entries"."id"`
+
+	mock.ExpectQuery(regexp.QuoteMeta(insertAudit)).
+		WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), actor, action, entityType, entityID, sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(uuid.NewV4().String()))
+}
+
+func TestAuditRepository(t *testing.T) {
+	t.Parallel()
+
+	t.Run("should record the actor, request and snapshots of a change", func(t *testing.T) {
+		gdb, mock := NewGormTestMock()
+		repo := repository.NewAccountRepository(gdb)
+		is := require.New(t)
+
+		account, _ := entity.NewAccount(100)
+		opened, _ := entity.NewEvent(entity.AggregateAccount, account.ID, 1, entity.EventAccountOpened, account)
+		account.Withdow(40)
+
+		const insertAudit = `INSERT INTO "audit_entries" ("id","tenant_id","actor","action","entity_type","entity_id","before","after","request_id","created_at") VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10) RETURNING "audit_entries"."id"`
+
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta(`UPDATE "accounts"`)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		expectLockLedger(mock, entity.DefaultTenant, nil)
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "events"`)).
+			WillReturnRows(sqlmock.NewRows(eventColumns).
+				AddRow(opened.ID, opened.AggregateType, opened.AggregateID, opened.Version, opened.Type, opened.Payload, opened.CreatedAt))
+		mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "events"`)).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(uuid.NewV4().String()))
+		mock.ExpectQuery(regexp.QuoteMeta(insertAudit)).
+			WithArgs(sqlmock.AnyArg(), entity.DefaultTenant, "auditor-1", entity.EventAccountDebited, entity.AggregateAccount, account.ID, opened.Payload, sqlmock.AnyArg(), "req-1", sqlmock.AnyArg()).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(uuid.NewV4().String()))
+		mock.ExpectCommit()
+
+		ctx := entity.WithRequestID(entity.WithAuditActor(context.Background(), "auditor-1"), "req-1")
+		err := repo.Save(ctx, account)
+
+		is.Nil(err)
+		is.Nil(mock.ExpectationsWereMet())
+	})
+
+	t.Run("should roll back the change when the audit entry fails", func(t *testing.T) {
+		gdb, mock := NewGormTestMock()
+		repo := repository.NewAccountRepository(gdb)
+		is := require.New(t)
+
+		account, _ := entity.NewAccount(100)
+
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta(`UPDATE "accounts"`)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		expectLockLedger(mock, entity.DefaultTenant, nil)
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "events"`)).
+			WillReturnRows(sqlmock.NewRows(eventColumns))
+		mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "events"`)).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(uuid.NewV4().String()))
+		mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "audit_entries"`)).
+			WillReturnError(driver.ErrBadConn)
+		mock.ExpectRollback()
+
+		err := repo.Save(context.Background(), account)
+
+		is.NotNil(err)
+		is.Nil(mock.ExpectationsWereMet())
+	})
+
+	t.Run("should refuse to change recorded entries", func(t *testing.T) {
+		gdb, mock := NewGormTestMock()
+		is := require.New(t)
+
+		entry, err := entity.NewAuditEntry(context.Background(), entity.EventAccountOpened, entity.AggregateAccount, uuid.NewV4().String(), "", "{}")
+		is.Nil(err)
+		is.Equal(entity.AuditActorSystem, entry.Actor)
+
+		mock.ExpectBegin()
+		mock.ExpectRollback()
+		is.Equal(entity.ErrAuditImmutable, gdb.Save(entry).Error)
+
+		mock.ExpectBegin()
+		mock.ExpectRollback()
+		is.Equal(entity.ErrAuditImmutable, gdb.Delete(entry).Error)
+
+		is.Nil(mock.ExpectationsWereMet())
+	})
+
+	t.Run("should test find all", func(t *testing.T) {
+		gdb, mock := NewGormTestMock()
+		repo := repository.NewAuditRepository(gdb)
+		is := require.New(t)
+
+		accountID := uuid.NewV4().String()
+		from := time.Now().Add(-time.Hour)
+		columns := []string{"id", "actor", "action", "entity_type", "entity_id", "before", "after", "request_id", "created_at"}
+
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT count(*) FROM "audit_entries"  WHERE (actor = $1) AND (entity_type = $2) AND (entity_id = $3) AND (created_at >= $4)`)).
+			WithArgs("auditor-1", entity.AggregateAccount, accountID, from).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "audit_entries"  WHERE (actor = $1) AND (entity_type = $2) AND (entity_id = $3) AND (created_at >= $4) ORDER BY created_at desc LIMIT 10 OFFSET 10`)).
+			WithArgs("auditor-1", entity.AggregateAccount, accountID, from).
+			WillReturnRows(sqlmock.NewRows(columns).
+				AddRow(uuid.NewV4().String(), "auditor-1", entity.EventAccountDebited, entity.AggregateAccount, accountID, "{}", "{}", "req-1", time.Now()))
+
+		filter := &entity.AuditFilter{Actor: "auditor-1", EntityType: entity.AggregateAccount, EntityID: accountID, From: from}
+		entries, total, err := repo.FindAll(context.Background(), filter, &entity.Pagination{Page: 2, Limit: 10})
+
+		is.Nil(err)
+		is.Equal(1, total)
+		is.Len(entries, 1)
+		is.Equal("req-1", entries[0].RequestID)
+		is.Nil(mock.ExpectationsWereMet())
+	})
+}
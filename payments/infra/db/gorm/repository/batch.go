@@ -0,0 +1,129 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/EdlanioJ/kbu/payments/domain/entity"
+	"github.com/jinzhu/gorm"
+)
+
+type BatchRepositoryGORM struct {
+	DB *gorm.DB
+}
+
+func NewBatchRepository(db *gorm.DB) *BatchRepositoryGORM {
+	return &BatchRepositoryGORM{
+		DB: db,
+	}
+}
+
+func (b *BatchRepositoryGORM) Register(ctx context.Context, batch *entity.Batch, items []*entity.BatchItem) error {
+	err := withContext(ctx, b.DB, "repository.Batch.Register", false, func(tx *gorm.DB) error {
+		err := tx.Create(batch).Error
+
+		if err != nil {
+			return err
+		}
+
+		for _, item := range items {
+			item.BatchID = batch.ID
+
+			err = tx.Create(item).Error
+
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return translate(err, "payment batch", batch.ID)
+	}
+
+	return nil
+}
+
+func (b *BatchRepositoryGORM) Save(ctx context.Context, batch *entity.Batch, items []*entity.BatchItem) error {
+	return withContext(ctx, b.DB, "repository.Batch.Save", false, func(tx *gorm.DB) error {
+		err := save(ctx, tx, batch)
+
+		if err != nil {
+			return err
+		}
+
+		for _, item := range items {
+			err = save(ctx, tx, item)
+
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// Claim takes over a batch still processing since before staleBefore, whose
+// call never saved its outcome. Only one caller claims it, the others get a
+// conflict as if it was still being processed.
+func (b *BatchRepositoryGORM) Claim(ctx context.Context, batch *entity.Batch, staleBefore time.Time) error {
+	var claimed bool
+
+	now := time.Now()
+
+	err := withContext(ctx, b.DB, "repository.Batch.Claim", false, func(tx *gorm.DB) error {
+		result := tx.
+			Model(&entity.Batch{}).
+			Where("id = ? AND status = ? AND updated_at < ?", batch.ID, entity.BatchProcessing, staleBefore).
+			UpdateColumn("updated_at", now)
+
+		claimed = result.RowsAffected == 1
+
+		return result.Error
+	})
+
+	if err != nil {
+		return translate(err, "payment batch", batch.ID)
+	}
+
+	if !claimed {
+		return entity.Conflict("payment batch", batch.ID, "payment batch is being processed")
+	}
+
+	batch.UpdatedAt = now
+
+	return nil
+}
+
+func (b *BatchRepositoryGORM) Find(ctx context.Context, id string) (*entity.Batch, []*entity.BatchItem, error) {
+	return b.find(ctx, "repository.Batch.Find", id, "id = ?", id)
+}
+
+func (b *BatchRepositoryGORM) FindByIdempotencyKey(ctx context.Context, key string) (*entity.Batch, []*entity.BatchItem, error) {
+	return b.find(ctx, "repository.Batch.FindByIdempotencyKey", key, "idempotency_key = ?", key)
+}
+
+func (b *BatchRepositoryGORM) find(ctx context.Context, operation, id string, where ...interface{}) (*entity.Batch, []*entity.BatchItem, error) {
+	batch := &entity.Batch{}
+
+	var items []*entity.BatchItem
+
+	err := withContext(ctx, b.DB, operation, true, func(tx *gorm.DB) error {
+		err := tx.First(batch, where...).Error
+
+		if err != nil {
+			return translate(err, "payment batch", id)
+		}
+
+		return tx.Where("batch_id = ?", batch.ID).Order("position asc").Find(&items).Error
+	})
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return batch, items, nil
+}
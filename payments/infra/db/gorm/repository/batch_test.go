@@ -0,0 +1,148 @@
+package repository_test
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	dataRepository "github.com/EdlanioJ/kbu/payments/data/repository"
+	"github.com/EdlanioJ/kbu/payments/domain/entity"
+	"github.com/EdlanioJ/kbu/payments/infra/db/gorm/repository"
+	"github.com/jinzhu/gorm"
+	uuid "github.com/satori/go.uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func NewGormTestMock() (*gorm.DB, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New()
+
+	if err != nil {
+		panic(err)
+	}
+
+	gdb, err := gorm.Open("postgres", db)
+
+	gdb.LogMode(false)
+	if err != nil {
+		panic(err)
+	}
+
+	return gdb, mock
+}
+
+func TestBatchRepository(t *testing.T) {
+	t.Parallel()
+
+	t.Run("should test register", func(t *testing.T) {
+		gdb, mock := NewGormTestMock()
+		repo := repository.NewBatchRepository(gdb)
+		is := require.New(t)
+
+		batch, _ := entity.NewBatch(entity.BatchAtomic)
+		items := []*entity.BatchItem{
+			entity.NewBatchItem(0, uuid.NewV4().String(), uuid.NewV4().String(), uuid.NewV4().String(), entity.TransactionToUser, "AOA", 10),
+			entity.NewBatchItem(1, uuid.NewV4().String(), uuid.NewV4().String(), uuid.NewV4().String(), entity.TransactionToUser, "AOA", 20),
+		}
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "batches"`)).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(batch.ID))
+		mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "batch_items"`)).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(items[0].ID))
+		mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "batch_items"`)).
+			WillReturnError(errors.New("db error"))
+		mock.ExpectRollback()
+
+		err := repo.Register(context.Background(), batch, items)
+
+		is.NotNil(err)
+		is.Equal(batch.ID, items[1].BatchID)
+		is.Nil(mock.ExpectationsWereMet())
+	})
+
+	t.Run("should test find", func(t *testing.T) {
+		gdb, mock := NewGormTestMock()
+		repo := repository.NewBatchRepository(gdb)
+		is := require.New(t)
+
+		batch, _ := entity.NewBatch(entity.BatchBestEffort)
+		itemID := uuid.NewV4().String()
+
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "batches" WHERE (id = $1) ORDER BY "batches"."id" ASC LIMIT 1`)).
+			WithArgs(batch.ID).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "mode", "status"}).AddRow(batch.ID, batch.Mode, entity.BatchCompleted))
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "batch_items"  WHERE (batch_id = $1) ORDER BY position asc`)).
+			WithArgs(batch.ID).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "batch_id", "position", "status"}).AddRow(itemID, batch.ID, 0, entity.BatchItemRegistered))
+
+		result, items, err := repo.Find(context.Background(), batch.ID)
+
+		is.Nil(err)
+		is.Equal(entity.BatchCompleted, result.Status)
+		is.Len(items, 1)
+		is.Equal(itemID, items[0].ID)
+	})
+
+	t.Run("should claim a stale processing batch only once", func(t *testing.T) {
+		gdb, mock := NewGormTestMock()
+		repo := repository.NewBatchRepository(gdb)
+		is := require.New(t)
+
+		batch, _ := entity.NewBatch(entity.BatchBestEffort)
+		staleBefore := time.Now().Add(-time.Minute)
+		update := regexp.QuoteMeta(`UPDATE "batches" SET "updated_at" = $1 WHERE (id = $2 AND status = $3 AND updated_at < $4)`)
+
+		mock.ExpectBegin()
+		mock.ExpectExec(update).
+			WithArgs(sqlmock.AnyArg(), batch.ID, entity.BatchProcessing, staleBefore).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+		mock.ExpectBegin()
+		mock.ExpectExec(update).
+			WithArgs(sqlmock.AnyArg(), batch.ID, entity.BatchProcessing, staleBefore).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectCommit()
+
+		is.Nil(repo.Claim(context.Background(), batch, staleBefore))
+		is.True(batch.UpdatedAt.After(staleBefore))
+
+		err := repo.Claim(context.Background(), batch, staleBefore)
+
+		is.True(entity.IsErrorKind(err, entity.ErrorConflict))
+		is.Nil(mock.ExpectationsWereMet())
+	})
+}
+
+func TestUnitOfWork(t *testing.T) {
+	t.Parallel()
+
+	t.Run("should roll back every write when the work fails", func(t *testing.T) {
+		gdb, mock := NewGormTestMock()
+		unitOfWork := repository.NewUnitOfWork(gdb)
+		is := require.New(t)
+
+		account, _ := entity.NewAccount(100)
+
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta(`UPDATE "accounts"`)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		expectAppendEvent(mock, entity.AggregateAccount, account.ID, nil, 1, entity.EventAccountOpened)
+		mock.ExpectRollback()
+
+		err := unitOfWork.Do(context.Background(), func(transactions dataRepository.TransactionRepository, accounts dataRepository.AccountRepository) error {
+			err := accounts.Save(context.Background(), account)
+
+			if err != nil {
+				return err
+			}
+
+			return errors.New("insufficient balance")
+		})
+
+		is.EqualError(err, "insufficient balance")
+		is.Nil(mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/jinzhu/gorm"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// withContext runs fn on a database transaction bound to ctx, so its queries
+// are cancelled when ctx is. gorm v1 only takes a context when a transaction
+// begins. Reads skip the transaction when ctx can never be cancelled, and calls
+// that already run inside a transaction (a unit of work) reuse it. Calls made
+// within a trace get a span named after operation, with a child span for each
+// statement.
+func withContext(ctx context.Context, db *gorm.DB, operation string, readOnly bool, fn func(tx *gorm.DB) error) error {
+	span := trace.SpanFromContext(ctx)
+
+	if span.SpanContext().IsValid() {
+		ctx, span = tracer.Start(ctx, operation)
+		defer span.End()
+	}
+
+	err := run(ctx, db, readOnly, fn)
+
+	if err != nil {
+		recordError(span, err)
+	}
+
+	return err
+}
+
+func run(ctx context.Context, db *gorm.DB, readOnly bool, fn func(tx *gorm.DB) error) error {
+	if _, ok := db.CommonDB().(*sql.Tx); ok {
+		return fn(db.Set(contextKey, ctx))
+	}
+
+	if readOnly && ctx.Done() == nil {
+		return fn(db.Set(contextKey, ctx))
+	}
+
+	tx := db.BeginTx(ctx, &sql.TxOptions{ReadOnly: readOnly})
+
+	if tx.Error != nil {
+		return tx.Error
+	}
+
+	committed := false
+
+	defer func() {
+		if !committed {
+			tx.Rollback()
+		}
+	}()
+
+	err := fn(tx.Set(contextKey, ctx))
+
+	if err != nil {
+		return err
+	}
+
+	committed = true
+
+	return tx.Commit().Error
+}
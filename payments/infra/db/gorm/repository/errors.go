@@ -0,0 +1,23 @@
+package repository
+
+import (
+	"fmt"
+
+	"github.com/EdlanioJ/kbu/payments/domain/entity"
+	"github.com/jinzhu/gorm"
+	"github.com/lib/pq"
+)
+
+const uniqueViolation = "23505"
+
+func translate(err error, resource, id string) error {
+	if gorm.IsRecordNotFoundError(err) {
+		return entity.NotFound(resource, id)
+	}
+
+	if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == uniqueViolation {
+		return entity.Conflict(resource, id, fmt.Sprintf("the %s was changed or already exists", resource))
+	}
+
+	return err
+}
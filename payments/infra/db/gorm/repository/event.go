@@ -0,0 +1,467 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/EdlanioJ/kbu/payments/domain/entity"
+	"github.com/jinzhu/gorm"
+)
+
+const rebuildBatchSize = 500
+
+const latestVersion = "version = (SELECT MAX(latest.version) FROM events latest WHERE latest.aggregate_type = events.aggregate_type AND latest.aggregate_id = events.aggregate_id)"
+
+type projection struct {
+	model   interface{}
+	project func(ctx context.Context, tx *gorm.DB, event *entity.Event) error
+	state   func(tx *gorm.DB, rows *sql.Rows) (string, string, error)
+}
+
+var projections = map[string]projection{
+	entity.AggregateTransaction: {
+		model: &entity.Transaction{},
+		project: func(ctx context.Context, tx *gorm.DB, event *entity.Event) error {
+			transaction := &entity.Transaction{}
+
+			err := event.Decode(transaction)
+
+			if err != nil {
+				return err
+			}
+
+			return save(ctx, tx.Omit("AccountFrom", "Service", "Store", "AccountTo"), transaction)
+		},
+		state: func(tx *gorm.DB, rows *sql.Rows) (string, string, error) {
+			transaction := &entity.Transaction{}
+
+			err := tx.ScanRows(rows, transaction)
+
+			if err != nil {
+				return "", "", err
+			}
+
+			return transaction.ID, transaction.LedgerState(), nil
+		},
+	},
+	entity.AggregateAccount: {
+		model: &entity.Account{},
+		project: func(ctx context.Context, tx *gorm.DB, event *entity.Event) error {
+			account := &entity.Account{}
+
+			err := event.Decode(account)
+
+			if err != nil {
+				return err
+			}
+
+			return save(ctx, tx, account)
+		},
+		state: func(tx *gorm.DB, rows *sql.Rows) (string, string, error) {
+			account := &entity.Account{}
+
+			err := tx.ScanRows(rows, account)
+
+			if err != nil {
+				return "", "", err
+			}
+
+			return account.ID, account.LedgerState(), nil
+		},
+	},
+}
+
+type EventRepositoryGORM struct {
+	DB *gorm.DB
+}
+
+func NewEventRepository(db *gorm.DB) *EventRepositoryGORM {
+	return &EventRepositoryGORM{
+		DB: db,
+	}
+}
+
+func (e *EventRepositoryGORM) FindAllByAggregate(ctx context.Context, aggregateType, aggregateID string) ([]*entity.Event, error) {
+	var events []*entity.Event
+
+	err := withContext(ctx, e.DB, "repository.Event.FindAllByAggregate", true, func(tx *gorm.DB) error {
+		return tx.
+			Where("aggregate_type = ? AND aggregate_id = ?", aggregateType, aggregateID).
+			Order("version asc").
+			Find(&events).
+			Error
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return events, nil
+}
+
+// Iterate hands fn every event in the order of the ledger of its tenant.
+func (e *EventRepositoryGORM) Iterate(ctx context.Context, fn func(event *entity.Event) error) error {
+	return withContext(ctx, e.DB, "repository.Event.Iterate", true, func(tx *gorm.DB) error {
+		rows, err := tx.
+			Model(&entity.Event{}).
+			Order("tenant_id asc, sequence asc").
+			Rows()
+
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			event := &entity.Event{}
+
+			err = tx.ScanRows(rows, event)
+
+			if err != nil {
+				return err
+			}
+
+			err = fn(event)
+
+			if err != nil {
+				return err
+			}
+		}
+
+		return rows.Err()
+	})
+}
+
+// IterateProjection hands fn the ledger state of every projection row of the
+// aggregate type, ordered by id, with the last event of its aggregate, nil
+// when it has none, and then the last event of every aggregate without a row,
+// with an empty state. Both are read a page at a time.
+func (e *EventRepositoryGORM) IterateProjection(ctx context.Context, aggregateType string, fn func(aggregateID, state string, latest *entity.Event) error) error {
+	projection, ok := projections[aggregateType]
+
+	if !ok {
+		return entity.ErrUnknownAggregate
+	}
+
+	return withContext(ctx, e.DB, "repository.Event.IterateProjection", true, func(tx *gorm.DB) error {
+		lastID := ""
+
+		for {
+			ids, states, err := projectionPage(tx, projection, lastID)
+
+			if err != nil {
+				return err
+			}
+
+			latest := make(map[string]*entity.Event)
+
+			if len(ids) > 0 {
+				var events []*entity.Event
+
+				err = tx.
+					Where("aggregate_type = ? AND aggregate_id IN (?)", aggregateType, ids).
+					Where(latestVersion).
+					Find(&events).
+					Error
+
+				if err != nil {
+					return err
+				}
+
+				for _, event := range events {
+					latest[event.AggregateID] = event
+				}
+			}
+
+			for i, id := range ids {
+				err = fn(id, states[i], latest[id])
+
+				if err != nil {
+					return err
+				}
+			}
+
+			if len(ids) < rebuildBatchSize {
+				break
+			}
+
+			lastID = ids[len(ids)-1]
+		}
+
+		lastID = ""
+
+		for {
+			events, err := latestEvents(tx, aggregateType, lastID)
+
+			if err != nil {
+				return err
+			}
+
+			if len(events) > 0 {
+				ids := make([]string, len(events))
+
+				for i, event := range events {
+					ids[i] = event.AggregateID
+				}
+
+				var found []string
+
+				err = tx.Model(projection.model).Where("id IN (?)", ids).Pluck("id", &found).Error
+
+				if err != nil {
+					return err
+				}
+
+				projected := make(map[string]bool, len(found))
+
+				for _, id := range found {
+					projected[id] = true
+				}
+
+				for _, event := range events {
+					if projected[event.AggregateID] {
+						continue
+					}
+
+					err = fn(event.AggregateID, "", event)
+
+					if err != nil {
+						return err
+					}
+				}
+			}
+
+			if len(events) < rebuildBatchSize {
+				return nil
+			}
+
+			lastID = events[len(events)-1].AggregateID
+		}
+	})
+}
+
+// projectionPage reads the ids and ledger states of the page of projection
+// rows after lastID.
+func projectionPage(tx *gorm.DB, projection projection, lastID string) ([]string, []string, error) {
+	query := tx.Model(projection.model)
+
+	if lastID != "" {
+		query = query.Where("id > ?", lastID)
+	}
+
+	rows, err := query.
+		Order("id asc").
+		Limit(rebuildBatchSize).
+		Rows()
+
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	var ids, states []string
+
+	for rows.Next() {
+		id, state, err := projection.state(tx, rows)
+
+		if err != nil {
+			return nil, nil, err
+		}
+
+		ids = append(ids, id)
+		states = append(states, state)
+	}
+
+	return ids, states, rows.Err()
+}
+
+// latestEvents reads the last event of the page of aggregates after lastID.
+func latestEvents(tx *gorm.DB, aggregateType, lastID string) ([]*entity.Event, error) {
+	var events []*entity.Event
+
+	query := tx.Where("aggregate_type = ?", aggregateType)
+
+	if lastID != "" {
+		query = query.Where("aggregate_id > ?", lastID)
+	}
+
+	err := query.
+		Where(latestVersion).
+		Order("aggregate_id asc").
+		Limit(rebuildBatchSize).
+		Find(&events).
+		Error
+
+	return events, err
+}
+
+func (e *EventRepositoryGORM) Rebuild(ctx context.Context, aggregateType string, truncate bool) (int, error) {
+	projection, ok := projections[aggregateType]
+
+	if !ok {
+		return 0, entity.ErrUnknownAggregate
+	}
+
+	var total int
+
+	err := withContext(ctx, e.DB, "repository.Event.Rebuild", false, func(tx *gorm.DB) error {
+		if truncate {
+			err := tx.Delete(projection.model).Error
+
+			if err != nil {
+				return err
+			}
+		}
+
+		lastID := ""
+
+		for {
+			events, err := latestEvents(tx, aggregateType, lastID)
+
+			if err != nil {
+				return err
+			}
+
+			for _, event := range events {
+				// Every row is written in the scope of the tenant of its
+				// event, so it never lands on a row of another tenant.
+				scoped := entity.WithTenant(ctx, event.TenantID)
+
+				err = projection.project(scoped, tx.Set(contextKey, scoped), event)
+
+				if err != nil {
+					return err
+				}
+			}
+
+			total += len(events)
+
+			if len(events) < rebuildBatchSize {
+				return nil
+			}
+
+			lastID = events[len(events)-1].AggregateID
+		}
+	})
+
+	if err != nil {
+		return 0, err
+	}
+
+	return total, nil
+}
+
+// appendEvent records the next version of an aggregate and, on the same
+// transaction, the audit entry of the change with the previous version as the
+// before snapshot, both owned by the tenant of the aggregate. The event is
+// chained to the hash of the last event of the ledger of the tenant, whose row
+// in ledgers stays locked until the transaction ends, so the appends of a
+// tenant are chained one after the other.
+func appendEvent(ctx context.Context, tx *gorm.DB, tenant, aggregateType, aggregateID string, payload interface{}, eventType func(previous *entity.Event) (string, error)) error {
+	if tenant == "" {
+		tenant, _ = entity.Tenant(ctx)
+	}
+
+	if tenant == "" {
+		tenant = entity.DefaultTenant
+	}
+
+	locked := tx.Exec("UPDATE ledgers SET sequence = sequence + 1 WHERE tenant_id = ?", tenant)
+
+	if locked.Error != nil {
+		return locked.Error
+	}
+
+	if locked.RowsAffected == 0 {
+		err := tx.Exec("INSERT INTO ledgers (tenant_id, sequence) VALUES (?, 1)", tenant).Error
+
+		if err != nil {
+			return err
+		}
+	}
+
+	var head []*entity.Event
+
+	err := tx.
+		Where("tenant_id = ?", tenant).
+		Order("sequence desc").
+		Limit(1).
+		Find(&head).
+		Error
+
+	if err != nil {
+		return err
+	}
+
+	var chained *entity.Event
+
+	if len(head) > 0 {
+		chained = head[0]
+	}
+
+	var latest []*entity.Event
+
+	err = tx.
+		Where("aggregate_type = ? AND aggregate_id = ?", aggregateType, aggregateID).
+		Order("version desc").
+		Limit(1).
+		Find(&latest).
+		Error
+
+	if err != nil {
+		return err
+	}
+
+	var previous *entity.Event
+	version := 1
+
+	if len(latest) > 0 {
+		previous = latest[0]
+		version = previous.Version + 1
+	}
+
+	name, err := eventType(previous)
+
+	if err != nil {
+		return err
+	}
+
+	event, err := entity.NewEvent(aggregateType, aggregateID, version, name, payload)
+
+	if err != nil {
+		return err
+	}
+
+	event.TenantID = tenant
+	event.Link(chained)
+
+	err = tx.Create(event).Error
+
+	if err != nil {
+		return err
+	}
+
+	var before string
+
+	if previous != nil {
+		before = previous.Payload
+	}
+
+	entry, err := entity.NewAuditEntry(ctx, event.Type, aggregateType, aggregateID, before, event.Payload)
+
+	if err != nil {
+		return err
+	}
+
+	entry.TenantID = tenant
+
+	return tx.Create(entry).Error
+}
+
+func transactionSnapshot(transaction *entity.Transaction) *entity.Transaction {
+	snapshot := *transaction
+	snapshot.AccountFrom = nil
+	snapshot.AccountTo = nil
+
+	return &snapshot
+}
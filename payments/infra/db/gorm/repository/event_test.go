@@ -0,0 +1,335 @@
+package repository_test
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/EdlanioJ/kbu/payments/domain/entity"
+	"github.com/EdlanioJ/kbu/payments/infra/db/gorm/repository"
+	"github.com/jinzhu/gorm"
+	uuid "github.com/satori/go.uuid"
+	"github.com/stretchr/testify/require"
+)
+
+var eventColumns = []string{"id", "aggregate_type", "aggregate_id", "version", "type", "payload", "created_at"}
+
+func NewEventTestMock() (*repository.EventRepositoryGORM, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New()
+
+	if err != nil {
+		panic(err)
+	}
+
+	gdb, err := gorm.Open("postgres", db)
+
+	gdb.LogMode(false)
+	if err != nil {
+		panic(err)
+	}
+
+	repo := repository.NewEventRepository(gdb)
+
+	return repo, mock
+}
+
+func expectAppendEvent(mock sqlmock.Sqlmock, aggregateType, aggregateID string, previous *sqlmock.Rows, version int, eventType string) {
+	const selectLatest = `SELECT * FROM "events"  WHERE (aggregate_type = $1 AND aggregate_id = $2) ORDER BY version desc LIMIT 1`
+
+	if previous == nil {
+		previous = sqlmock.NewRows(eventColumns)
+	}
+
+	expectLockLedger(mock, entity.DefaultTenant, nil)
+	mock.ExpectQuery(regexp.QuoteMeta(selectLatest)).
+		WithArgs(aggregateType, aggregateID).
+		WillReturnRows(previous)
+	expectInsertEvent(mock, entity.DefaultTenant, aggregateType, aggregateID, version, eventType)
+}
+
+// expectScopedAppendEvent is expectAppendEvent for a call scoped to tenant.
+func expectScopedAppendEvent(mock sqlmock.Sqlmock, tenant, aggregateType, aggregateID string, version int, eventType string) {
+	const selectLatest = `SELECT * FROM "events"  WHERE (aggregate_type = $1 AND aggregate_id = $2) AND ("events".tenant_id = $3) ORDER BY version desc LIMIT 1`
+
+	expectLockLedger(mock, tenant, nil)
+	mock.ExpectQuery(regexp.QuoteMeta(selectLatest)).
+		WithArgs(aggregateType, aggregateID, tenant).
+		WillReturnRows(sqlmock.NewRows(eventColumns))
+	expectInsertEvent(mock, tenant, aggregateType, aggregateID, version, eventType)
+}
+
+// expectLockLedger expects the lock on the ledger of tenant and the read of
+// its last event, head, or none when it is nil.
+func expectLockLedger(mock sqlmock.Sqlmock, tenant string, head *sqlmock.Rows) {
+	if head == nil {
+		head = sqlmock.NewRows(eventColumns)
+	}
+
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE ledgers SET sequence = sequence + 1 WHERE tenant_id = $1`)).
+		WithArgs(tenant).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "events"  WHERE (tenant_id = $1)`)).
+		WillReturnRows(head)
+}
+
+func expectInsertEvent(mock sqlmock.Sqlmock, tenant, aggregateType, aggregateID string, version int, eventType string) {
+	const insertEvent = `INSERT INTO "events" ("id","tenant_id","sequence","aggregate_type","aggregate_id","version","type","payload","previous_hash","hash","created_at") VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11) RETURNING "events"."id"`
+
+	mock.ExpectQuery(regexp.QuoteMeta(insertEvent)).
+		WithArgs(sqlmock.AnyArg(), tenant, sqlmock.AnyArg(), aggregateType, aggregateID, version, eventType, sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(uuid.NewV4().String()))
+	expectAudit(mock, sqlmock.AnyArg(), eventType, aggregateType, aggregateID)
+}
+
+func TestEventRepository(t *testing.T) {
+	t.Parallel()
+
+	t.Run("should test find all by aggregate", func(t *testing.T) {
+		repo, mock := NewEventTestMock()
+		is := require.New(t)
+
+		account, _ := entity.NewAccount(100)
+		opened, _ := entity.NewEvent(entity.AggregateAccount, account.ID, 1, entity.EventAccountOpened, account)
+		account.Withdow(40)
+		debited, _ := entity.NewEvent(entity.AggregateAccount, account.ID, 2, entity.EventAccountDebited, account)
+
+		rows := sqlmock.NewRows(eventColumns).
+			AddRow(opened.ID, opened.AggregateType, opened.AggregateID, opened.Version, opened.Type, opened.Payload, opened.CreatedAt).
+			AddRow(debited.ID, debited.AggregateType, debited.AggregateID, debited.Version, debited.Type, debited.Payload, debited.CreatedAt)
+
+		const sql = `SELECT * FROM "events"  WHERE (aggregate_type = $1 AND aggregate_id = $2) ORDER BY version asc`
+
+		mock.ExpectQuery(regexp.QuoteMeta(sql)).
+			WithArgs(entity.AggregateAccount, account.ID).
+			WillReturnRows(rows)
+
+		events, err := repo.FindAllByAggregate(context.Background(), entity.AggregateAccount, account.ID)
+
+		is.Nil(err)
+		is.Len(events, 2)
+		is.Equal(entity.EventAccountOpened, events[0].Type)
+		is.Equal(entity.EventAccountDebited, events[1].Type)
+
+		snapshot := &entity.Account{}
+		is.Nil(events[1].Decode(snapshot))
+		is.Equal(60.0, snapshot.Balance)
+
+		events, err = repo.FindAllByAggregate(context.Background(), entity.AggregateAccount, account.ID)
+
+		is.NotNil(err)
+		is.Nil(events)
+	})
+
+	t.Run("should iterate the ledger of every tenant in order", func(t *testing.T) {
+		repo, mock := NewEventTestMock()
+		is := require.New(t)
+
+		account, _ := entity.NewAccount(100)
+		opened, _ := entity.NewEvent(entity.AggregateAccount, account.ID, 1, entity.EventAccountOpened, account)
+		opened.Link(nil)
+		account.Withdow(40)
+		debited, _ := entity.NewEvent(entity.AggregateAccount, account.ID, 2, entity.EventAccountDebited, account)
+		debited.Link(opened)
+
+		columns := append(eventColumns, "sequence", "previous_hash", "hash")
+		rows := sqlmock.NewRows(columns).
+			AddRow(opened.ID, opened.AggregateType, opened.AggregateID, opened.Version, opened.Type, opened.Payload, opened.CreatedAt, opened.Sequence, opened.PreviousHash, opened.Hash).
+			AddRow(debited.ID, debited.AggregateType, debited.AggregateID, debited.Version, debited.Type, debited.Payload, debited.CreatedAt, debited.Sequence, debited.PreviousHash, debited.Hash)
+
+		mock.ExpectQuery(`SELECT \* FROM "events" +ORDER BY tenant_id asc, sequence asc`).
+			WillReturnRows(rows)
+
+		var events []*entity.Event
+		err := repo.Iterate(context.Background(), func(event *entity.Event) error {
+			events = append(events, event)
+			return nil
+		})
+
+		is.Nil(err)
+		is.Len(events, 2)
+		is.Equal("", events[1].VerifyLink(events[0]))
+		is.Nil(mock.ExpectationsWereMet())
+	})
+
+	t.Run("should test iterate projection", func(t *testing.T) {
+		repo, mock := NewEventTestMock()
+		is := require.New(t)
+
+		account, _ := entity.NewAccount(100)
+		event, _ := entity.NewEvent(entity.AggregateAccount, account.ID, 1, entity.EventAccountOpened, account)
+		unprojected, _ := entity.NewAccount(50)
+		orphan, _ := entity.NewEvent(entity.AggregateAccount, unprojected.ID, 1, entity.EventAccountOpened, unprojected)
+
+		rows := sqlmock.NewRows([]string{"id", "tenant_id", "balance", "frozen", "created_at", "updated_at"}).
+			AddRow(account.ID, account.TenantID, account.Balance, account.Frozen, account.CreatedAt, account.UpdatedAt)
+
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "accounts"   ORDER BY id asc LIMIT 500`)).
+			WillReturnRows(rows)
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "events"  WHERE (aggregate_type = $1 AND aggregate_id IN ($2)) AND (version = (SELECT MAX(latest.version)`)).
+			WithArgs(entity.AggregateAccount, account.ID).
+			WillReturnRows(sqlmock.NewRows(eventColumns).
+				AddRow(event.ID, event.AggregateType, event.AggregateID, event.Version, event.Type, event.Payload, event.CreatedAt))
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "events"  WHERE (aggregate_type = $1) AND (version = (SELECT MAX(latest.version)`)).
+			WithArgs(entity.AggregateAccount).
+			WillReturnRows(sqlmock.NewRows(eventColumns).
+				AddRow(event.ID, event.AggregateType, event.AggregateID, event.Version, event.Type, event.Payload, event.CreatedAt).
+				AddRow(orphan.ID, orphan.AggregateType, orphan.AggregateID, orphan.Version, orphan.Type, orphan.Payload, orphan.CreatedAt))
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT id FROM "accounts"  WHERE (id IN ($1,$2))`)).
+			WithArgs(account.ID, unprojected.ID).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(account.ID))
+
+		states := make(map[string]string)
+		latest := make(map[string]string)
+		err := repo.IterateProjection(context.Background(), entity.AggregateAccount, func(aggregateID, state string, event *entity.Event) error {
+			states[aggregateID] = state
+			latest[aggregateID] = event.ID
+			return nil
+		})
+
+		is.Nil(err)
+		is.Equal(map[string]string{account.ID: account.LedgerState(), unprojected.ID: ""}, states)
+		is.Equal(map[string]string{account.ID: event.ID, unprojected.ID: orphan.ID}, latest)
+		is.Nil(mock.ExpectationsWereMet())
+	})
+
+	t.Run("should fail iterate projection of an unknown aggregate", func(t *testing.T) {
+		repo, _ := NewEventTestMock()
+		is := require.New(t)
+
+		err := repo.IterateProjection(context.Background(), "store", func(aggregateID, state string, latest *entity.Event) error {
+			return nil
+		})
+
+		is.NotNil(err)
+	})
+
+	t.Run("should chain each event to the hash of the last event of the tenant", func(t *testing.T) {
+		gdb, mock := NewGormTestMock()
+		repo := repository.NewAccountRepository(gdb)
+		is := require.New(t)
+
+		account, _ := entity.NewAccount(100)
+		opened, _ := entity.NewEvent(entity.AggregateAccount, account.ID, 1, entity.EventAccountOpened, account)
+		opened.TenantID = entity.DefaultTenant
+		opened.Link(nil)
+		account.Withdow(40)
+
+		columns := append(eventColumns, "tenant_id", "sequence", "previous_hash", "hash")
+		row := func() *sqlmock.Rows {
+			return sqlmock.NewRows(columns).
+				AddRow(opened.ID, opened.AggregateType, opened.AggregateID, opened.Version, opened.Type, opened.Payload, opened.CreatedAt, opened.TenantID, opened.Sequence, opened.PreviousHash, opened.Hash)
+		}
+
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta(`UPDATE "accounts"`)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		expectLockLedger(mock, entity.DefaultTenant, row())
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "events"`)).
+			WillReturnRows(row())
+		mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "events"`)).
+			WithArgs(sqlmock.AnyArg(), entity.DefaultTenant, 2, entity.AggregateAccount, account.ID, 2, entity.EventAccountDebited, sqlmock.AnyArg(), opened.Hash, sqlmock.AnyArg(), sqlmock.AnyArg()).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(uuid.NewV4().String()))
+		expectAudit(mock, sqlmock.AnyArg(), entity.EventAccountDebited, entity.AggregateAccount, account.ID)
+		mock.ExpectCommit()
+
+		err := repo.Save(context.Background(), account)
+
+		is.Nil(err)
+		is.Nil(mock.ExpectationsWereMet())
+	})
+
+	t.Run("should start the ledger of a tenant with its first event", func(t *testing.T) {
+		gdb, mock := NewGormTestMock()
+		repo := repository.NewAccountRepository(gdb)
+		is := require.New(t)
+
+		account, _ := entity.NewAccount(100)
+
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta(`UPDATE "accounts"`)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(regexp.QuoteMeta(`UPDATE ledgers SET sequence = sequence + 1 WHERE tenant_id = $1`)).
+			WithArgs(entity.DefaultTenant).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO ledgers (tenant_id, sequence) VALUES ($1, 1)`)).
+			WithArgs(entity.DefaultTenant).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "events"  WHERE (tenant_id = $1)`)).
+			WillReturnRows(sqlmock.NewRows(eventColumns))
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "events"`)).
+			WillReturnRows(sqlmock.NewRows(eventColumns))
+		mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "events"`)).
+			WithArgs(sqlmock.AnyArg(), entity.DefaultTenant, 1, entity.AggregateAccount, account.ID, 1, entity.EventAccountOpened, sqlmock.AnyArg(), "", sqlmock.AnyArg(), sqlmock.AnyArg()).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(uuid.NewV4().String()))
+		expectAudit(mock, sqlmock.AnyArg(), entity.EventAccountOpened, entity.AggregateAccount, account.ID)
+		mock.ExpectCommit()
+
+		err := repo.Save(context.Background(), account)
+
+		is.Nil(err)
+		is.Nil(mock.ExpectationsWereMet())
+	})
+
+	t.Run("should rebuild projections from the latest event of each aggregate", func(t *testing.T) {
+		repo, mock := NewEventTestMock()
+		is := require.New(t)
+
+		account, _ := entity.NewAccount(250)
+		event, _ := entity.NewEvent(entity.AggregateAccount, account.ID, 3, entity.EventAccountCredited, account)
+
+		rows := sqlmock.NewRows(append(eventColumns, "tenant_id")).
+			AddRow(event.ID, event.AggregateType, event.AggregateID, event.Version, event.Type, event.Payload, event.CreatedAt, account.TenantID)
+
+		const deleteSql = `DELETE FROM "accounts"`
+		const selectLatest = `SELECT * FROM "events"  WHERE (aggregate_type = $1) AND (version = (SELECT MAX(latest.version) FROM events latest WHERE latest.aggregate_type = events.aggregate_type AND latest.aggregate_id = events.aggregate_id)) ORDER BY aggregate_id asc LIMIT 500`
+		const updateSql = `UPDATE "accounts" SET "created_at" = $1, "updated_at" = $2, "tenant_id" = $3, "balance" = $4, "frozen" = $5 WHERE "accounts"."id" = $6`
+
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta(deleteSql)).
+			WillReturnResult(sqlmock.NewResult(0, 4))
+		mock.ExpectQuery(regexp.QuoteMeta(selectLatest)).
+			WithArgs(entity.AggregateAccount).
+			WillReturnRows(rows)
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT count(*) FROM "accounts"  WHERE "accounts"."id" = $1`)).
+			WithArgs(account.ID).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+		mock.ExpectExec(regexp.QuoteMeta(updateSql)).
+			WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), account.TenantID, account.Balance, false, account.ID).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		total, err := repo.Rebuild(context.Background(), entity.AggregateAccount, true)
+
+		is.Nil(err)
+		is.Equal(1, total)
+		is.Nil(mock.ExpectationsWereMet())
+	})
+
+	t.Run("should roll back the rebuild when a projection fails", func(t *testing.T) {
+		repo, mock := NewEventTestMock()
+		is := require.New(t)
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT \* FROM "events"`).
+			WillReturnRows(sqlmock.NewRows(eventColumns).
+				AddRow(uuid.NewV4().String(), entity.AggregateTransaction, uuid.NewV4().String(), 1, entity.EventTransactionRegistered, "{", nil))
+		mock.ExpectRollback()
+
+		total, err := repo.Rebuild(context.Background(), entity.AggregateTransaction, false)
+
+		is.NotNil(err)
+		is.Equal(0, total)
+		is.Nil(mock.ExpectationsWereMet())
+	})
+
+	t.Run("should reject unknown aggregate types", func(t *testing.T) {
+		repo, _ := NewEventTestMock()
+		is := require.New(t)
+
+		total, err := repo.Rebuild(context.Background(), "wallet", false)
+
+		is.NotNil(err)
+		is.Equal(0, total)
+	})
+}
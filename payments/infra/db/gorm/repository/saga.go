@@ -0,0 +1,148 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/EdlanioJ/kbu/payments/domain/entity"
+	"github.com/jinzhu/gorm"
+)
+
+type SagaRepositoryGORM struct {
+	DB *gorm.DB
+}
+
+func NewSagaRepository(db *gorm.DB) *SagaRepositoryGORM {
+	return &SagaRepositoryGORM{
+		DB: db,
+	}
+}
+
+func (s *SagaRepositoryGORM) Register(ctx context.Context, saga *entity.Saga) error {
+	err := withContext(ctx, s.DB, "repository.Saga.Register", false, func(tx *gorm.DB) error {
+		return tx.Create(saga).Error
+	})
+
+	if err != nil {
+		return translate(err, "service payment", saga.ID)
+	}
+
+	return nil
+}
+
+// Save writes the progress of saga while owner still holds an unexpired
+// lease on it. The check and the update are a single statement, so a saga
+// another instance claimed in the meantime is never overwritten.
+func (s *SagaRepositoryGORM) Save(ctx context.Context, saga *entity.Saga, owner string) error {
+	var saved bool
+
+	now := time.Now()
+
+	err := withContext(ctx, s.DB, "repository.Saga.Save", false, func(tx *gorm.DB) error {
+		result := tx.
+			Model(&entity.Saga{}).
+			Where("id = ? AND lease_owner = ? AND lease_expires_at >= ?", saga.ID, owner, now).
+			UpdateColumns(map[string]interface{}{
+				"status":             saga.Status,
+				"step":               saga.Step,
+				"provider_reference": saga.ProviderReference,
+				"error":              saga.Error,
+				"attempts":           saga.Attempts,
+				"expires_at":         saga.ExpiresAt,
+				"lease_owner":        saga.LeaseOwner,
+				"lease_expires_at":   saga.LeaseExpiresAt,
+				"updated_at":         now,
+			})
+
+		saved = result.RowsAffected == 1
+
+		return result.Error
+	})
+
+	if err != nil {
+		return translate(err, "service payment", saga.ID)
+	}
+
+	if !saved {
+		return entity.Conflict("service payment", saga.ID, "service payment was claimed by another instance")
+	}
+
+	saga.UpdatedAt = now
+
+	return nil
+}
+
+func (s *SagaRepositoryGORM) Find(ctx context.Context, id string) (*entity.Saga, error) {
+	saga := &entity.Saga{}
+
+	err := withContext(ctx, s.DB, "repository.Saga.Find", true, func(tx *gorm.DB) error {
+		return tx.First(saga, "id = ?", id).Error
+	})
+
+	if err != nil {
+		return nil, translate(err, "service payment", id)
+	}
+
+	return saga, nil
+}
+
+func (s *SagaRepositoryGORM) FindByIdempotencyKey(ctx context.Context, key string) (*entity.Saga, error) {
+	saga := &entity.Saga{}
+
+	err := withContext(ctx, s.DB, "repository.Saga.FindByIdempotencyKey", true, func(tx *gorm.DB) error {
+		return tx.First(saga, "idempotency_key = ?", key).Error
+	})
+
+	if err != nil {
+		return nil, translate(err, "service payment", key)
+	}
+
+	return saga, nil
+}
+
+func (s *SagaRepositoryGORM) FindAllInFlight(ctx context.Context, updatedBefore time.Time, limit int) ([]*entity.Saga, error) {
+	var sagas []*entity.Saga
+
+	err := withContext(ctx, s.DB, "repository.Saga.FindAllInFlight", true, func(tx *gorm.DB) error {
+		return tx.
+			Where("status IN (?) AND updated_at <= ?", []string{entity.SagaRunning, entity.SagaCompensating}, updatedBefore).
+			Order("updated_at asc").
+			Limit(limit).
+			Find(&sagas).
+			Error
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return sagas, nil
+}
+
+// Claim takes the lease of saga for owner unless another owner holds an
+// unexpired one. The check and the update are a single statement, so two
+// instances cannot both claim the same saga.
+func (s *SagaRepositoryGORM) Claim(ctx context.Context, saga *entity.Saga, owner string, until time.Time) (bool, error) {
+	var claimed bool
+
+	err := withContext(ctx, s.DB, "repository.Saga.Claim", false, func(tx *gorm.DB) error {
+		result := tx.
+			Model(&entity.Saga{}).
+			Where("id = ? AND (lease_owner = ? OR lease_expires_at IS NULL OR lease_expires_at < ?)", saga.ID, owner, time.Now()).
+			UpdateColumns(map[string]interface{}{"lease_owner": owner, "lease_expires_at": until})
+
+		claimed = result.RowsAffected == 1
+
+		return result.Error
+	})
+
+	if err != nil {
+		return false, err
+	}
+
+	if claimed {
+		saga.Lease(owner, until)
+	}
+
+	return claimed, nil
+}
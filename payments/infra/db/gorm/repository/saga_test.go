@@ -0,0 +1,148 @@
+package repository_test
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/EdlanioJ/kbu/payments/domain/entity"
+	"github.com/EdlanioJ/kbu/payments/infra/db/gorm/repository"
+	"github.com/jinzhu/gorm"
+	uuid "github.com/satori/go.uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func NewSagaTestMock() (*repository.SagaRepositoryGORM, sqlmock.Sqlmock, *entity.Saga) {
+	saga, _ := entity.NewSaga(uuid.NewV4().String(), uuid.NewV4().String(), uuid.NewV4().String(), "AOA", 120, time.Minute)
+
+	db, mock, err := sqlmock.New()
+
+	if err != nil {
+		panic(err)
+	}
+
+	gdb, err := gorm.Open("postgres", db)
+
+	gdb.LogMode(false)
+	if err != nil {
+		panic(err)
+	}
+
+	repo := repository.NewSagaRepository(gdb)
+
+	return repo, mock, saga
+}
+
+func TestSagaRepository(t *testing.T) {
+	t.Parallel()
+
+	t.Run("should test register", func(t *testing.T) {
+		repo, mock, saga := NewSagaTestMock()
+		is := require.New(t)
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "sagas"`)).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(saga.ID))
+		mock.ExpectCommit()
+
+		err := repo.Register(context.Background(), saga)
+
+		is.Nil(err)
+		is.Nil(mock.ExpectationsWereMet())
+
+		err = repo.Register(context.Background(), saga)
+
+		is.NotNil(err)
+	})
+
+	t.Run("should test find all in flight", func(t *testing.T) {
+		repo, mock, saga := NewSagaTestMock()
+		is := require.New(t)
+
+		before := time.Now()
+		rows := sqlmock.NewRows([]string{"id", "transaction_id", "status", "step"}).
+			AddRow(saga.ID, saga.TransactionID, saga.Status, saga.Step)
+
+		const sql = `SELECT * FROM "sagas"  WHERE (status IN ($1,$2) AND updated_at <= $3) ORDER BY updated_at asc LIMIT 10`
+
+		mock.ExpectQuery(regexp.QuoteMeta(sql)).
+			WithArgs(entity.SagaRunning, entity.SagaCompensating, before).
+			WillReturnRows(rows)
+
+		sagas, err := repo.FindAllInFlight(context.Background(), before, 10)
+
+		is.Nil(err)
+		is.Len(sagas, 1)
+		is.Equal(saga.TransactionID, sagas[0].TransactionID)
+		is.Equal(entity.SagaStepReserve, sagas[0].Step)
+
+		sagas, err = repo.FindAllInFlight(context.Background(), before, 10)
+
+		is.NotNil(err)
+		is.Nil(sagas)
+	})
+	t.Run("should save a saga only while the owner holds its lease", func(t *testing.T) {
+		repo, mock, saga := NewSagaTestMock()
+		is := require.New(t)
+
+		const sql = `UPDATE "sagas" SET "attempts" = $1, "error" = $2, "expires_at" = $3, "lease_expires_at" = $4, "lease_owner" = $5, "provider_reference" = $6, "status" = $7, "step" = $8, "updated_at" = $9 WHERE (id = $10 AND lease_owner = $11 AND lease_expires_at >= $12)`
+
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta(sql)).
+			WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), saga.Status, saga.Step, sqlmock.AnyArg(), saga.ID, "owner-1", sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		err := repo.Save(context.Background(), saga, "owner-1")
+
+		is.Nil(err)
+
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta(sql)).
+			WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), saga.Status, saga.Step, sqlmock.AnyArg(), saga.ID, "owner-2", sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectCommit()
+
+		err = repo.Save(context.Background(), saga, "owner-2")
+
+		is.True(entity.IsErrorKind(err, entity.ErrorConflict))
+		is.Nil(mock.ExpectationsWereMet())
+	})
+	t.Run("should claim a saga only when no other owner holds its lease", func(t *testing.T) {
+		repo, mock, saga := NewSagaTestMock()
+		is := require.New(t)
+
+		until := time.Now().Add(time.Minute)
+		const sql = `UPDATE "sagas" SET "lease_expires_at" = $1, "lease_owner" = $2 WHERE (id = $3 AND (lease_owner = $4 OR lease_expires_at IS NULL OR lease_expires_at < $5))`
+
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta(sql)).
+			WithArgs(until, "owner-1", saga.ID, "owner-1", sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		claimed, err := repo.Claim(context.Background(), saga, "owner-1", until)
+
+		is.Nil(err)
+		is.True(claimed)
+		is.Equal("owner-1", saga.LeaseOwner)
+		is.Equal(until, *saga.LeaseExpiresAt)
+
+		other, _ := entity.NewSaga(saga.AccountFromID, saga.AccountToID, saga.ExternalID, "AOA", 120, time.Minute)
+
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta(sql)).
+			WithArgs(until, "owner-2", other.ID, "owner-2", sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectCommit()
+
+		claimed, err = repo.Claim(context.Background(), other, "owner-2", until)
+
+		is.Nil(err)
+		is.False(claimed)
+		is.Empty(other.LeaseOwner)
+		is.Nil(mock.ExpectationsWereMet())
+	})
+}
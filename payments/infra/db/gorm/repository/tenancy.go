@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/EdlanioJ/kbu/payments/domain/entity"
+	"github.com/jinzhu/gorm"
+)
+
+const tenantColumn = "tenant_id"
+
+// RegisterTenancy scopes every statement on a model with a tenant column to
+// the tenant of the repository call that runs it: reads, updates and deletes
+// only match rows of that tenant and inserts are stamped with it. Calls whose
+// context has no tenant, made by trusted in-process workers, are not scoped.
+func RegisterTenancy(db *gorm.DB) {
+	callbacks := db.Callback()
+
+	callbacks.Create().Before("gorm:create").Register("tenancy:create", assignTenant)
+	callbacks.Query().Before("gorm:query").Register("tenancy:query", scopeTenant)
+	callbacks.RowQuery().Before("gorm:row_query").Register("tenancy:row_query", scopeTenant)
+	callbacks.Update().Before("gorm:update").Register("tenancy:update", scopeTenant)
+	callbacks.Delete().Before("gorm:delete").Register("tenancy:delete", scopeTenant)
+}
+
+func scopeTenant(scope *gorm.Scope) {
+	tenant, ok := statementTenant(scope)
+
+	if !ok {
+		return
+	}
+
+	scope.Search.Where(scope.QuotedTableName()+"."+tenantColumn+" = ?", tenant)
+}
+
+func assignTenant(scope *gorm.Scope) {
+	tenant, ok := statementTenant(scope)
+
+	if !ok {
+		return
+	}
+
+	field, ok := scope.FieldByName(tenantColumn)
+
+	if !ok {
+		return
+	}
+
+	if field.IsBlank {
+		scope.Err(field.Set(tenant))
+		return
+	}
+
+	if owner := field.Field.String(); owner != tenant {
+		scope.Err(entity.CrossTenant(tenant, owner))
+	}
+}
+
+func statementTenant(scope *gorm.Scope) (string, bool) {
+	value, ok := scope.Get(contextKey)
+
+	if !ok {
+		return "", false
+	}
+
+	for _, field := range scope.GetModelStruct().StructFields {
+		if field.DBName == tenantColumn {
+			return entity.Tenant(value.(context.Context))
+		}
+	}
+
+	return "", false
+}
+
+// save updates value, or creates it when it has no row yet. When gorm's Save
+// updates no row it falls back to FirstOrCreate on a new handle, which drops
+// the context of the call and with it the tenant scope: it would load, and then
+// write events for, a row of another tenant with the same id. Scoped calls look
+// the row up themselves instead.
+func save(ctx context.Context, tx *gorm.DB, value interface{}) error {
+	if _, ok := entity.Tenant(ctx); ok {
+		var count int
+
+		err := tx.Model(value).Count(&count).Error
+
+		if err != nil {
+			return err
+		}
+
+		if count == 0 {
+			return tx.Create(value).Error
+		}
+	}
+
+	return tx.Save(value).Error
+}
@@ -0,0 +1,194 @@
+package repository_test
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/EdlanioJ/kbu/payments/domain/entity"
+	"github.com/EdlanioJ/kbu/payments/infra/db/gorm/repository"
+	"github.com/jinzhu/gorm"
+	uuid "github.com/satori/go.uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func NewTenantGormTestMock() (*gorm.DB, sqlmock.Sqlmock) {
+	gdb, mock := NewGormTestMock()
+	repository.RegisterTenancy(gdb)
+
+	return gdb, mock
+}
+
+func TestRepositoryTenancy(t *testing.T) {
+	t.Parallel()
+
+	t.Run("should not find a payment of another tenant", func(t *testing.T) {
+		gdb, mock := NewTenantGormTestMock()
+		repo := repository.NewTransactionRepository(gdb)
+		is := require.New(t)
+
+		id := uuid.NewV4().String()
+		const sql = `SELECT * FROM "transactions"  WHERE (id = $1) AND ("transactions".tenant_id = $2) ORDER BY "transactions"."id" ASC LIMIT 1`
+
+		mock.ExpectQuery(regexp.QuoteMeta(sql)).
+			WithArgs(id, "acme").
+			WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+		result, err := repo.Find(entity.WithTenant(context.Background(), "acme"), id)
+
+		is.Nil(result)
+		is.True(entity.IsErrorKind(err, entity.ErrorNotFound))
+		is.Nil(mock.ExpectationsWereMet())
+	})
+
+	t.Run("should list and count only the payments of the tenant", func(t *testing.T) {
+		gdb, mock := NewTenantGormTestMock()
+		repo := repository.NewTransactionRepository(gdb)
+		is := require.New(t)
+
+		accountFrom, _ := entity.NewAccount(3000)
+		accountTo, _ := entity.NewAccount(200)
+		transaction, _ := entity.NewTransaction(accountFrom, accountTo, uuid.NewV4().String(), entity.TransactionToUser, "AOA", 30)
+
+		const selectSql = `SELECT * FROM "transactions"  WHERE (type = $1) AND ("transactions".tenant_id = $2) ORDER BY created_at LIMIT 10 OFFSET 0`
+		const countSql = `SELECT count(*) FROM "transactions"  WHERE (type = $1) AND ("transactions".tenant_id = $2)`
+
+		mock.ExpectQuery(regexp.QuoteMeta(selectSql)).
+			WithArgs(entity.TransactionToUser, entity.DefaultTenant).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "tenant_id"}).AddRow(transaction.ID, transaction.TenantID))
+		mock.ExpectQuery(regexp.QuoteMeta(countSql)).
+			WithArgs(entity.TransactionToUser, entity.DefaultTenant).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+
+		ctx := entity.WithTenant(context.Background(), entity.DefaultTenant)
+		transactions, total, err := repo.FindAllByType(ctx, entity.TransactionToUser, &entity.Pagination{Page: 1, Limit: 10, Sort: "created_at"})
+
+		is.Nil(err)
+		is.Equal(1, total)
+		is.Len(transactions, 1)
+		is.Equal(entity.DefaultTenant, transactions[0].TenantID)
+		is.Nil(mock.ExpectationsWereMet())
+	})
+
+	t.Run("should only update the accounts of the tenant", func(t *testing.T) {
+		gdb, mock := NewTenantGormTestMock()
+		repo := repository.NewAccountRepository(gdb)
+		is := require.New(t)
+
+		account, _ := entity.NewAccount(100)
+		account.TenantID = "acme"
+
+		const countSql = `SELECT count(*) FROM "accounts"  WHERE "accounts"."id" = $1 AND (("accounts".tenant_id = $2))`
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta(countSql)).
+			WithArgs(account.ID, "globex").
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+		mock.ExpectRollback()
+
+		err := repo.Save(entity.WithTenant(context.Background(), "globex"), account)
+
+		is.True(entity.IsErrorKind(err, entity.ErrorPermissionDenied))
+		is.Nil(mock.ExpectationsWereMet())
+	})
+
+	t.Run("should update an account of the tenant in its scope", func(t *testing.T) {
+		gdb, mock := NewTenantGormTestMock()
+		repo := repository.NewAccountRepository(gdb)
+		is := require.New(t)
+
+		account, _ := entity.NewAccount(100)
+		account.TenantID = "acme"
+
+		const countSql = `SELECT count(*) FROM "accounts"  WHERE "accounts"."id" = $1 AND (("accounts".tenant_id = $2))`
+		const updateSql = `UPDATE "accounts" SET "created_at" = $1, "updated_at" = $2, "tenant_id" = $3, "balance" = $4, "frozen" = $5 WHERE "accounts"."id" = $6 AND (("accounts".tenant_id = $7))`
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta(countSql)).
+			WithArgs(account.ID, "acme").
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+		mock.ExpectExec(regexp.QuoteMeta(updateSql)).
+			WithArgs(account.CreatedAt, sqlmock.AnyArg(), "acme", account.Balance, account.Frozen, account.ID, "acme").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		expectScopedAppendEvent(mock, "acme", entity.AggregateAccount, account.ID, 1, entity.EventAccountOpened)
+		mock.ExpectCommit()
+
+		err := repo.Save(entity.WithTenant(context.Background(), "acme"), account)
+
+		is.Nil(err)
+		is.Nil(mock.ExpectationsWereMet())
+	})
+
+	t.Run("should rebuild a projection row in the scope of the tenant of its event", func(t *testing.T) {
+		gdb, mock := NewTenantGormTestMock()
+		repo := repository.NewEventRepository(gdb)
+		is := require.New(t)
+
+		account, _ := entity.NewAccount(100)
+		account.TenantID = "acme"
+		event, _ := entity.NewEvent(entity.AggregateAccount, account.ID, 1, entity.EventAccountOpened, account)
+
+		const countSql = `SELECT count(*) FROM "accounts"  WHERE "accounts"."id" = $1 AND (("accounts".tenant_id = $2))`
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "events"`)).
+			WillReturnRows(sqlmock.NewRows(append(eventColumns, "tenant_id")).
+				AddRow(event.ID, event.AggregateType, event.AggregateID, event.Version, event.Type, event.Payload, event.CreatedAt, "acme"))
+		mock.ExpectQuery(regexp.QuoteMeta(countSql)).
+			WithArgs(account.ID, "acme").
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+		mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "accounts"`)).
+			WithArgs(account.ID, sqlmock.AnyArg(), sqlmock.AnyArg(), "acme", account.Balance).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(account.ID))
+		mock.ExpectCommit()
+
+		total, err := repo.Rebuild(context.Background(), entity.AggregateAccount, false)
+
+		is.Nil(err)
+		is.Equal(1, total)
+		is.Nil(mock.ExpectationsWereMet())
+	})
+
+	t.Run("should stamp new payments with the tenant of the caller", func(t *testing.T) {
+		gdb, mock := NewTenantGormTestMock()
+		repo := repository.NewTransactionRepository(gdb)
+		is := require.New(t)
+
+		accountFrom, _ := entity.NewAccount(3000)
+		accountTo, _ := entity.NewAccount(200)
+		transaction, _ := entity.NewTransaction(accountFrom, accountTo, uuid.NewV4().String(), entity.TransactionToUser, "AOA", 30)
+		transaction.TenantID = ""
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "transactions"`)).
+			WithArgs(transaction.ID, sqlmock.AnyArg(), sqlmock.AnyArg(), "acme", sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(transaction.ID))
+		expectScopedAppendEvent(mock, "acme", entity.AggregateTransaction, transaction.ID, 1, entity.EventTransactionRegistered)
+		mock.ExpectCommit()
+
+		err := repo.Register(entity.WithTenant(context.Background(), "acme"), transaction)
+
+		is.Nil(err)
+		is.Equal("acme", transaction.TenantID)
+		is.Nil(mock.ExpectationsWereMet())
+	})
+
+	t.Run("should not insert a payment owned by another tenant", func(t *testing.T) {
+		gdb, mock := NewTenantGormTestMock()
+		repo := repository.NewTransactionRepository(gdb)
+		is := require.New(t)
+
+		accountFrom, _ := entity.NewAccount(3000)
+		accountTo, _ := entity.NewAccount(200)
+		transaction, _ := entity.NewTransaction(accountFrom, accountTo, uuid.NewV4().String(), entity.TransactionToUser, "AOA", 30)
+
+		mock.ExpectBegin()
+		mock.ExpectRollback()
+
+		err := repo.Register(entity.WithTenant(context.Background(), "acme"), transaction)
+
+		is.True(entity.IsErrorKind(err, entity.ErrorPermissionDenied))
+		is.Nil(mock.ExpectationsWereMet())
+	})
+}
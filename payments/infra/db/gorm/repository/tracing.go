@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/jinzhu/gorm"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	contextKey = "repository:context"
+	spanKey    = "tracing:span"
+)
+
+var tracer = otel.Tracer("github.com/EdlanioJ/kbu/payments/infra/db/gorm/repository")
+
+// RegisterTracing adds a span for every statement run through db. Statements
+// are only traced when a repository call within a trace runs them, which is
+// where the request context is attached to the query.
+func RegisterTracing(db *gorm.DB) {
+	callbacks := db.Callback()
+
+	callbacks.Create().Before("gorm:create").Register("tracing:before_create", startStatement("INSERT"))
+	callbacks.Create().After("gorm:create").Register("tracing:after_create", endStatement)
+	callbacks.Query().Before("gorm:query").Register("tracing:before_query", startStatement("SELECT"))
+	callbacks.Query().After("gorm:query").Register("tracing:after_query", endStatement)
+	callbacks.RowQuery().Before("gorm:row_query").Register("tracing:before_row_query", startStatement("SELECT"))
+	callbacks.RowQuery().After("gorm:row_query").Register("tracing:after_row_query", endStatement)
+	callbacks.Update().Before("gorm:update").Register("tracing:before_update", startStatement("UPDATE"))
+	callbacks.Update().After("gorm:update").Register("tracing:after_update", endStatement)
+	callbacks.Delete().Before("gorm:delete").Register("tracing:before_delete", startStatement("DELETE"))
+	callbacks.Delete().After("gorm:delete").Register("tracing:after_delete", endStatement)
+}
+
+func startStatement(operation string) func(scope *gorm.Scope) {
+	return func(scope *gorm.Scope) {
+		value, ok := scope.Get(contextKey)
+
+		if !ok {
+			return
+		}
+
+		ctx := value.(context.Context)
+
+		if !trace.SpanContextFromContext(ctx).IsValid() {
+			return
+		}
+
+		table := scope.TableName()
+
+		_, span := tracer.Start(ctx, operation+" "+table,
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(
+				semconv.DBSystemPostgreSQL,
+				semconv.DBOperationKey.String(operation),
+				semconv.DBSQLTableKey.String(table),
+			),
+		)
+
+		scope.Set(spanKey, span)
+	}
+}
+
+func endStatement(scope *gorm.Scope) {
+	value, ok := scope.Get(spanKey)
+
+	if !ok {
+		return
+	}
+
+	span := value.(trace.Span)
+	span.SetAttributes(semconv.DBStatementKey.String(scope.SQL))
+
+	if err := scope.DB().Error; err != nil {
+		recordError(span, err)
+	}
+
+	span.End()
+}
+
+func recordError(span trace.Span, err error) {
+	if gorm.IsRecordNotFoundError(err) {
+		return
+	}
+
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
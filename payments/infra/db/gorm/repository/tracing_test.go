@@ -0,0 +1,142 @@
+package repository_test
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"sync"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/EdlanioJ/kbu/payments/domain/entity"
+	"github.com/EdlanioJ/kbu/payments/infra/db/gorm/repository"
+	"github.com/jinzhu/gorm"
+	uuid "github.com/satori/go.uuid"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var (
+	spanExporter     *tracetest.InMemoryExporter
+	spanExporterOnce sync.Once
+)
+
+func startTestTrace(t *testing.T) (context.Context, trace.Span) {
+	spanExporterOnce.Do(func() {
+		spanExporter = tracetest.NewInMemoryExporter()
+		otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(spanExporter)))
+	})
+
+	return otel.Tracer("test").Start(context.Background(), t.Name())
+}
+
+func endedSpans(traceID trace.TraceID) map[string]tracetest.SpanStub {
+	spans := make(map[string]tracetest.SpanStub)
+
+	for _, span := range spanExporter.GetSpans() {
+		if span.SpanContext.TraceID() == traceID {
+			spans[span.Name] = span
+		}
+	}
+
+	return spans
+}
+
+func NewTracedTransactionTestMock() (*repository.TransactionRepositoryGORM, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New()
+
+	if err != nil {
+		panic(err)
+	}
+
+	gdb, err := gorm.Open("postgres", db)
+
+	if err != nil {
+		panic(err)
+	}
+
+	gdb.LogMode(false)
+	repository.RegisterTracing(gdb)
+
+	return repository.NewTransactionRepository(gdb), mock
+}
+
+func TestRepositoryTracing(t *testing.T) {
+	t.Parallel()
+
+	t.Run("should trace each statement under the repository call", func(t *testing.T) {
+		repo, mock := NewTracedTransactionTestMock()
+		is := require.New(t)
+
+		accountFrom, _ := entity.NewAccount(3000)
+		accountTo, _ := entity.NewAccount(200)
+		transaction, _ := entity.NewTransaction(accountFrom, accountTo, uuid.NewV4().String(), entity.TransactionToService, "AOA", 30)
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "transactions"`)).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(transaction.ID))
+		expectAppendEvent(mock, entity.AggregateTransaction, transaction.ID, nil, 1, entity.EventTransactionRegistered)
+		mock.ExpectCommit()
+
+		ctx, parent := startTestTrace(t)
+		err := repo.Register(ctx, transaction)
+		parent.End()
+
+		is.Nil(err)
+		is.Nil(mock.ExpectationsWereMet())
+
+		spans := endedSpans(parent.SpanContext().TraceID())
+		register := spans["repository.Transaction.Register"]
+		is.Equal(parent.SpanContext().SpanID(), register.Parent.SpanID())
+
+		for _, name := range []string{"INSERT transactions", "SELECT events", "INSERT events"} {
+			statement, ok := spans[name]
+			is.True(ok, name)
+			is.Equal(register.SpanContext.SpanID(), statement.Parent.SpanID())
+			is.Equal(trace.SpanKindClient, statement.SpanKind)
+		}
+
+		var statement string
+		for _, attribute := range spans["INSERT transactions"].Attributes {
+			if attribute.Key == "db.statement" {
+				statement = attribute.Value.AsString()
+			}
+		}
+		is.Contains(statement, `INSERT INTO "transactions"`)
+	})
+
+	t.Run("should record failed statements on their spans", func(t *testing.T) {
+		repo, mock := NewTracedTransactionTestMock()
+		is := require.New(t)
+
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "transactions"`)).
+			WillReturnError(errors.New("db error"))
+
+		ctx, parent := startTestTrace(t)
+		_, err := repo.Find(ctx, uuid.NewV4().String())
+		parent.End()
+
+		is.NotNil(err)
+
+		spans := endedSpans(parent.SpanContext().TraceID())
+		is.Equal(codes.Error, spans["SELECT transactions"].Status.Code)
+		is.Equal(codes.Error, spans["repository.Transaction.Find"].Status.Code)
+	})
+
+	t.Run("should not trace calls made outside a trace", func(t *testing.T) {
+		repo, mock := NewTracedTransactionTestMock()
+		is := require.New(t)
+
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "transactions"`)).
+			WillReturnError(gorm.ErrRecordNotFound)
+
+		_, err := repo.Find(context.Background(), uuid.NewV4().String())
+
+		is.NotNil(err)
+		is.Nil(mock.ExpectationsWereMet())
+	})
+}
@@ -1,6 +1,8 @@
 package repository
 
 import (
+	"context"
+
 	"github.com/EdlanioJ/kbu/payments/domain/entity"
 	"github.com/jinzhu/gorm"
 )
@@ -15,37 +17,88 @@ func NewTransactionRepository(db *gorm.DB) *TransactionRepositoryGORM {
 	}
 }
 
-func (t *TransactionRepositoryGORM) Register(transaction *entity.Transaction) error {
-	err := t.DB.Omit("AccountFrom", "Service", "Store", "AccountTo").Create(transaction).Error
+func (t *TransactionRepositoryGORM) Register(ctx context.Context, transaction *entity.Transaction) error {
+	err := withContext(ctx, t.DB, "repository.Transaction.Register", false, func(tx *gorm.DB) error {
+		err := tx.Omit("AccountFrom", "Service", "Store", "AccountTo").Create(transaction).Error
+
+		if err != nil {
+			return err
+		}
+
+		if transaction.IdempotencyKey != "" {
+			err = tx.Create(&entity.IdempotencyKey{
+				Key:           transaction.IdempotencyKey,
+				TransactionID: transaction.ID,
+				CreatedAt:     transaction.CreatedAt,
+			}).Error
+
+			if err != nil {
+				return err
+			}
+		}
+
+		return appendEvent(ctx, tx, transaction.TenantID, entity.AggregateTransaction, transaction.ID, transactionSnapshot(transaction), func(previous *entity.Event) (string, error) {
+			return entity.EventTransactionRegistered, nil
+		})
+	})
 
 	if err != nil {
-		return err
+		return translate(err, "payment", transaction.ID)
 	}
 
 	return nil
 }
 
-func (t *TransactionRepositoryGORM) Save(transaction *entity.Transaction) error {
-	err := t.DB.Omit("AccountFrom", "Service", "Store", "AccountTo").Save(transaction).Error
+func (t *TransactionRepositoryGORM) Save(ctx context.Context, transaction *entity.Transaction) error {
+	err := withContext(ctx, t.DB, "repository.Transaction.Save", false, func(tx *gorm.DB) error {
+		err := save(ctx, tx.Omit("AccountFrom", "Service", "Store", "AccountTo"), transaction)
+
+		if err != nil {
+			return err
+		}
+
+		return appendEvent(ctx, tx, transaction.TenantID, entity.AggregateTransaction, transaction.ID, transactionSnapshot(transaction), func(previous *entity.Event) (string, error) {
+			return entity.TransactionEventType(transaction), nil
+		})
+	})
 
 	if err != nil {
-		return err
+		return translate(err, "payment", transaction.ID)
 	}
 	return nil
 }
 
-func (t *TransactionRepositoryGORM) Find(id string) (*entity.Transaction, error) {
+func (t *TransactionRepositoryGORM) Find(ctx context.Context, id string) (*entity.Transaction, error) {
 	transaction := &entity.Transaction{}
-	err := t.DB.First(transaction, "id = ?", id).Error
+	err := withContext(ctx, t.DB, "repository.Transaction.Find", true, func(tx *gorm.DB) error {
+		return tx.First(transaction, "id = ?", id).Error
+	})
 
 	if err != nil {
-		return nil, err
+		return nil, translate(err, "payment", id)
 	}
 
 	return transaction, nil
 }
 
-func (t *TransactionRepositoryGORM) FindAll(pagination *entity.Pagination) ([]*entity.Transaction, int, error) {
+func (t *TransactionRepositoryGORM) FindByIdempotencyKey(ctx context.Context, key string) (*entity.Transaction, error) {
+	transaction := &entity.Transaction{}
+	err := withContext(ctx, t.DB, "repository.Transaction.FindByIdempotencyKey", true, func(tx *gorm.DB) error {
+		return tx.
+			Joins("JOIN idempotency_keys ON idempotency_keys.transaction_id = transactions.id").
+			First(transaction, "idempotency_keys.key = ?", key).Error
+	})
+
+	if err != nil {
+		return nil, translate(err, "payment", key)
+	}
+
+	transaction.IdempotencyKey = key
+
+	return transaction, nil
+}
+
+func (t *TransactionRepositoryGORM) FindAll(ctx context.Context, pagination *entity.Pagination) ([]*entity.Transaction, int, error) {
 	var transactions []*entity.Transaction
 
 	limit := pagination.Limit
@@ -53,13 +106,15 @@ func (t *TransactionRepositoryGORM) FindAll(pagination *entity.Pagination) ([]*e
 	page := pagination.Page
 
 	var totalTransaction int
-	err := t.DB.
-		Offset((page - 1) * limit).
-		Limit(limit).
-		Order(sort).
-		Find(&transactions).
-		Count(&totalTransaction).
-		Error
+	err := withContext(ctx, t.DB, "repository.Transaction.FindAll", true, func(tx *gorm.DB) error {
+		return tx.
+			Offset((page - 1) * limit).
+			Limit(limit).
+			Order(sort).
+			Find(&transactions).
+			Count(&totalTransaction).
+			Error
+	})
 
 	if err != nil {
 		return nil, 0, err
@@ -67,19 +122,21 @@ func (t *TransactionRepositoryGORM) FindAll(pagination *entity.Pagination) ([]*e
 	return transactions, totalTransaction, nil
 }
 
-func (t *TransactionRepositoryGORM) FindByType(transactionID, transactionType string) (*entity.Transaction, error) {
+func (t *TransactionRepositoryGORM) FindByType(ctx context.Context, transactionID, transactionType string) (*entity.Transaction, error) {
 	transaction := &entity.Transaction{}
 
-	err := t.DB.First(transaction, "id = ? AND type = ?", transactionID, transactionType).Error
+	err := withContext(ctx, t.DB, "repository.Transaction.FindByType", true, func(tx *gorm.DB) error {
+		return tx.First(transaction, "id = ? AND type = ?", transactionID, transactionType).Error
+	})
 
 	if err != nil {
-		return nil, err
+		return nil, translate(err, "payment", transactionID)
 	}
 
 	return transaction, nil
 }
 
-func (t *TransactionRepositoryGORM) FindAllByType(transactionType string, pagination *entity.Pagination) ([]*entity.Transaction, int, error) {
+func (t *TransactionRepositoryGORM) FindAllByType(ctx context.Context, transactionType string, pagination *entity.Pagination) ([]*entity.Transaction, int, error) {
 	var transactions []*entity.Transaction
 
 	limit := pagination.Limit
@@ -87,14 +144,16 @@ func (t *TransactionRepositoryGORM) FindAllByType(transactionType string, pagina
 	page := pagination.Page
 
 	var totalTransaction int
-	err := t.DB.
-		Where("type = ?", transactionType).
-		Offset((page - 1) * limit).
-		Limit(limit).
-		Order(sort).
-		Find(&transactions).
-		Count(&totalTransaction).
-		Error
+	err := withContext(ctx, t.DB, "repository.Transaction.FindAllByType", true, func(tx *gorm.DB) error {
+		return tx.
+			Where("type = ?", transactionType).
+			Offset((page - 1) * limit).
+			Limit(limit).
+			Order(sort).
+			Find(&transactions).
+			Count(&totalTransaction).
+			Error
+	})
 
 	if err != nil {
 		return nil, 0, err
@@ -102,19 +161,21 @@ func (t *TransactionRepositoryGORM) FindAllByType(transactionType string, pagina
 	return transactions, totalTransaction, nil
 }
 
-func (t *TransactionRepositoryGORM) FindByExternalID(transactionID, externalID string) (*entity.Transaction, error) {
+func (t *TransactionRepositoryGORM) FindByExternalID(ctx context.Context, transactionID, externalID string) (*entity.Transaction, error) {
 	transaction := &entity.Transaction{}
 
-	err := t.DB.First(transaction, "id = ? AND external_id = ?", transactionID, externalID).Error
+	err := withContext(ctx, t.DB, "repository.Transaction.FindByExternalID", true, func(tx *gorm.DB) error {
+		return tx.First(transaction, "id = ? AND external_id = ?", transactionID, externalID).Error
+	})
 
 	if err != nil {
-		return nil, err
+		return nil, translate(err, "payment", transactionID)
 	}
 
 	return transaction, nil
 }
 
-func (t *TransactionRepositoryGORM) FindAllByExternalID(externalID string, pagination *entity.Pagination) ([]*entity.Transaction, int, error) {
+func (t *TransactionRepositoryGORM) FindAllByExternalID(ctx context.Context, externalID string, pagination *entity.Pagination) ([]*entity.Transaction, int, error) {
 	var transactions []*entity.Transaction
 
 	limit := pagination.Limit
@@ -123,14 +184,16 @@ func (t *TransactionRepositoryGORM) FindAllByExternalID(externalID string, pagin
 
 	var totalTransaction int
 
-	err := t.DB.
-		Where("external_id = ?", externalID).
-		Offset((page - 1) * limit).
-		Limit(limit).
-		Order(sort).
-		Find(&transactions).
-		Count(&totalTransaction).
-		Error
+	err := withContext(ctx, t.DB, "repository.Transaction.FindAllByExternalID", true, func(tx *gorm.DB) error {
+		return tx.
+			Where("external_id = ?", externalID).
+			Offset((page - 1) * limit).
+			Limit(limit).
+			Order(sort).
+			Find(&transactions).
+			Count(&totalTransaction).
+			Error
+	})
 
 	if err != nil {
 		return nil, 0, err
@@ -138,19 +201,21 @@ func (t *TransactionRepositoryGORM) FindAllByExternalID(externalID string, pagin
 	return transactions, totalTransaction, nil
 }
 
-func (t *TransactionRepositoryGORM) FindByFromAccountID(transactionID, accountID string) (*entity.Transaction, error) {
+func (t *TransactionRepositoryGORM) FindByFromAccountID(ctx context.Context, transactionID, accountID string) (*entity.Transaction, error) {
 	transaction := &entity.Transaction{}
 
-	err := t.DB.First(transaction, "id = ? AND account_from_id = ?", transactionID, accountID).Error
+	err := withContext(ctx, t.DB, "repository.Transaction.FindByFromAccountID", true, func(tx *gorm.DB) error {
+		return tx.First(transaction, "id = ? AND account_from_id = ?", transactionID, accountID).Error
+	})
 
 	if err != nil {
-		return nil, err
+		return nil, translate(err, "payment", transactionID)
 	}
 
 	return transaction, nil
 }
 
-func (t *TransactionRepositoryGORM) FindAllByFromAccountID(accountID string, pagination *entity.Pagination) ([]*entity.Transaction, int, error) {
+func (t *TransactionRepositoryGORM) FindAllByFromAccountID(ctx context.Context, accountID string, pagination *entity.Pagination) ([]*entity.Transaction, int, error) {
 	var transactions []*entity.Transaction
 
 	limit := pagination.Limit
@@ -159,14 +224,16 @@ func (t *TransactionRepositoryGORM) FindAllByFromAccountID(accountID string, pag
 
 	var totalTransaction int
 
-	err := t.DB.
-		Where("account_from_id = ?", accountID).
-		Offset((page - 1) * limit).
-		Limit(limit).
-		Order(sort).
-		Find(&transactions).
-		Count(&totalTransaction).
-		Error
+	err := withContext(ctx, t.DB, "repository.Transaction.FindAllByFromAccountID", true, func(tx *gorm.DB) error {
+		return tx.
+			Where("account_from_id = ?", accountID).
+			Offset((page - 1) * limit).
+			Limit(limit).
+			Order(sort).
+			Find(&transactions).
+			Count(&totalTransaction).
+			Error
+	})
 
 	if err != nil {
 		return nil, 0, err
@@ -174,18 +241,20 @@ func (t *TransactionRepositoryGORM) FindAllByFromAccountID(accountID string, pag
 	return transactions, totalTransaction, nil
 }
 
-func (t *TransactionRepositoryGORM) FindByToAccountID(transactionID, accountID string) (*entity.Transaction, error) {
+func (t *TransactionRepositoryGORM) FindByToAccountID(ctx context.Context, transactionID, accountID string) (*entity.Transaction, error) {
 	transaction := &entity.Transaction{}
-	err := t.DB.First(transaction, "id = ? AND account_to_id = ?", transactionID, accountID).Error
+	err := withContext(ctx, t.DB, "repository.Transaction.FindByToAccountID", true, func(tx *gorm.DB) error {
+		return tx.First(transaction, "id = ? AND account_to_id = ?", transactionID, accountID).Error
+	})
 
 	if err != nil {
-		return nil, err
+		return nil, translate(err, "payment", transactionID)
 	}
 
 	return transaction, nil
 }
 
-func (t *TransactionRepositoryGORM) FindAllByToAccountID(accountID string, pagination *entity.Pagination) ([]*entity.Transaction, int, error) {
+func (t *TransactionRepositoryGORM) FindAllByToAccountID(ctx context.Context, accountID string, pagination *entity.Pagination) ([]*entity.Transaction, int, error) {
 	var transactions []*entity.Transaction
 
 	limit := pagination.Limit
@@ -194,17 +263,120 @@ func (t *TransactionRepositoryGORM) FindAllByToAccountID(accountID string, pagin
 
 	var totalTransaction int
 
-	err := t.DB.
-		Where("account_to_id = ?", accountID).
-		Offset((page - 1) * limit).
-		Limit(limit).
-		Order(sort).
-		Find(&transactions).
-		Count(&totalTransaction).
-		Error
+	err := withContext(ctx, t.DB, "repository.Transaction.FindAllByToAccountID", true, func(tx *gorm.DB) error {
+		return tx.
+			Where("account_to_id = ?", accountID).
+			Offset((page - 1) * limit).
+			Limit(limit).
+			Order(sort).
+			Find(&transactions).
+			Count(&totalTransaction).
+			Error
+	})
 
 	if err != nil {
 		return nil, 0, err
 	}
 	return transactions, totalTransaction, nil
 }
+
+func (t *TransactionRepositoryGORM) Iterate(ctx context.Context, filter *entity.TransactionFilter, after *entity.ExportCursor, fn func(transaction *entity.Transaction) error) error {
+	return withContext(ctx, t.DB, "repository.Transaction.Iterate", true, func(tx *gorm.DB) error {
+		return iterate(tx, filter, after, fn)
+	})
+}
+
+func iterate(db *gorm.DB, filter *entity.TransactionFilter, after *entity.ExportCursor, fn func(transaction *entity.Transaction) error) error {
+	query := db.Model(&entity.Transaction{})
+
+	if filter.Type != "" {
+		query = query.Where("type = ?", filter.Type)
+	}
+
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+
+	if filter.AccountFromID != "" {
+		query = query.Where("account_from_id = ?", filter.AccountFromID)
+	}
+
+	if filter.AccountToID != "" {
+		query = query.Where("account_to_id = ?", filter.AccountToID)
+	}
+
+	if filter.ExternalID != "" {
+		query = query.Where("external_id = ?", filter.ExternalID)
+	}
+
+	if !filter.CreatedFrom.IsZero() {
+		query = query.Where("created_at >= ?", filter.CreatedFrom)
+	}
+
+	if !filter.CreatedTo.IsZero() {
+		query = query.Where("created_at < ?", filter.CreatedTo)
+	}
+
+	if after != nil {
+		query = query.Where("created_at > ? OR (created_at = ? AND id > ?)", after.CreatedAt, after.CreatedAt, after.ID)
+	}
+
+	rows, err := query.Order("created_at asc, id asc").Rows()
+
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		transaction := &entity.Transaction{}
+
+		err = db.ScanRows(rows, transaction)
+
+		if err != nil {
+			return err
+		}
+
+		err = fn(transaction)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+func (t *TransactionRepositoryGORM) Totals(ctx context.Context) ([]*entity.TransactionTotals, error) {
+	var totals []*entity.TransactionTotals
+
+	err := withContext(ctx, t.DB, "repository.Transaction.Totals", true, func(tx *gorm.DB) error {
+		return tx.Model(&entity.Transaction{}).
+			Select("type, status, currency, count(*) as count, coalesce(sum(amount), 0) as amount").
+			Group("type, status, currency").
+			Scan(&totals).Error
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return totals, nil
+}
+
+func (t *TransactionRepositoryGORM) OldestPending(ctx context.Context) (*entity.Transaction, error) {
+	transaction := &entity.Transaction{}
+	err := withContext(ctx, t.DB, "repository.Transaction.OldestPending", true, func(tx *gorm.DB) error {
+		return tx.Where("status = ?", entity.TransactionPending).Order("created_at asc").First(transaction).Error
+	})
+
+	if gorm.IsRecordNotFoundError(err) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return transaction, nil
+}
@@ -1,9 +1,11 @@
 package repository_test
 
 import (
+	"context"
 	"fmt"
 	"regexp"
 	"testing"
+	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/EdlanioJ/kbu/payments/domain/entity"
@@ -47,18 +49,19 @@ func TestTransactionRepository(t *testing.T) {
 		repo, mock, transaction := NewTransactionTestMock()
 		is := require.New(t)
 
-		const insertSql = `INSERT INTO "transactions" ("id","created_at","updated_at","amount","status","currency","account_from_id","account_to_id","type","external_id") VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10) RETURNING "transactions"."id"`
+		const insertSql = `INSERT INTO "transactions" ("id","created_at","updated_at","tenant_id","amount","status","currency","account_from_id","account_to_id","type","external_id") VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11) RETURNING "transactions"."id"`
 		mock.ExpectBegin()
 		mock.ExpectQuery(regexp.QuoteMeta(insertSql)).
 			WithArgs(
-				transaction.ID, transaction.CreatedAt, sqlmock.AnyArg(), transaction.Amount, transaction.Status, transaction.Currency, transaction.AccountFromID, transaction.AccountToID, transaction.Type, transaction.ExternalID).
+				transaction.ID, transaction.CreatedAt, sqlmock.AnyArg(), transaction.TenantID, transaction.Amount, transaction.Status, transaction.Currency, transaction.AccountFromID, transaction.AccountToID, transaction.Type, transaction.ExternalID).
 			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(transaction.ID))
+		expectAppendEvent(mock, entity.AggregateTransaction, transaction.ID, nil, 1, entity.EventTransactionRegistered)
 		mock.ExpectCommit()
 
-		err := repo.Register(transaction)
+		err := repo.Register(context.Background(), transaction)
 		is.Nil(err)
 
-		err = repo.Register(&entity.Transaction{})
+		err = repo.Register(context.Background(), &entity.Transaction{})
 		is.NotNil(err)
 	})
 
@@ -69,24 +72,29 @@ func TestTransactionRepository(t *testing.T) {
 		row := sqlmock.NewRows([]string{"id", "account_from_id", "amount", "status", "currency", "account_to_id", "created_at", "updated_at"}).
 			AddRow(transaction.ID, transaction.AccountFromID, transaction.Amount, transaction.Status, transaction.Currency, transaction.AccountToID, transaction.CreatedAt, transaction.UpdatedAt)
 
-		const updateSql = `UPDATE "transactions" SET "created_at" = $1, "updated_at" = $2, "amount" = $3, "status" = $4, "currency" = $5, "account_from_id" = $6, "account_to_id" = $7, "type" = $8, "external_id" = $9 WHERE "transactions"."id" = $10`
+		const updateSql = `UPDATE "transactions" SET "created_at" = $1, "updated_at" = $2, "tenant_id" = $3, "amount" = $4, "status" = $5, "currency" = $6, "account_from_id" = $7, "account_to_id" = $8, "type" = $9, "external_id" = $10 WHERE "transactions"."id" = $11`
 		const selectTransaction = `SELECT * FROM "transactions"  WHERE "transactions"."id" = $1 ORDER BY "transactions"."id" ASC LIMIT 1`
 
 		mock.ExpectBegin()
 		mock.ExpectExec(regexp.QuoteMeta(updateSql)).
-			WithArgs(transaction.CreatedAt, sqlmock.AnyArg(), transaction.Amount, transaction.Status, transaction.Currency, transaction.AccountFromID, transaction.AccountToID, transaction.Type, transaction.ExternalID, transaction.ID).
+			WithArgs(transaction.CreatedAt, sqlmock.AnyArg(), transaction.TenantID, transaction.Amount, transaction.Status, transaction.Currency, transaction.AccountFromID, transaction.AccountToID, transaction.Type, transaction.ExternalID, transaction.ID).
 			WillReturnResult(sqlmock.NewResult(0, 0))
-		mock.ExpectCommit()
 
 		mock.ExpectQuery(regexp.QuoteMeta(selectTransaction)).
 			WithArgs(transaction.ID).
 			WillReturnRows(row)
 
-		err := repo.Save(transaction)
+		registered, _ := entity.NewEvent(entity.AggregateTransaction, transaction.ID, 1, entity.EventTransactionRegistered, transaction)
+		previous := sqlmock.NewRows(eventColumns).
+			AddRow(registered.ID, registered.AggregateType, registered.AggregateID, registered.Version, registered.Type, registered.Payload, registered.CreatedAt)
+		expectAppendEvent(mock, entity.AggregateTransaction, transaction.ID, previous, 2, entity.EventTransactionUpdated)
+		mock.ExpectCommit()
+
+		err := repo.Save(context.Background(), transaction)
 
 		is.Nil(err)
 
-		err = repo.Save(&entity.Transaction{})
+		err = repo.Save(context.Background(), &entity.Transaction{})
 
 		is.NotNil(err)
 	})
@@ -104,19 +112,36 @@ func TestTransactionRepository(t *testing.T) {
 			WithArgs(transaction.ID).
 			WillReturnRows(row)
 
-		result, err := repo.Find(transaction.ID)
+		result, err := repo.Find(context.Background(), transaction.ID)
 
 		is.Nil(err)
 		is.Equal(result.ID, transaction.ID)
 		is.Equal(result.AccountFromID, transaction.AccountFromID)
 		is.Equal(result.Amount, transaction.Amount)
 
-		result, err = repo.Find(uuid.NewV4().String())
+		result, err = repo.Find(context.Background(), uuid.NewV4().String())
 
 		is.NotNil(err)
 		is.Nil(result)
 	})
 
+	t.Run("should translate missing rows into not found", func(t *testing.T) {
+		repo, mock, transaction := NewTransactionTestMock()
+		is := require.New(t)
+
+		const selectTransaction = `SELECT * FROM "transactions" WHERE (id = $1) ORDER BY "transactions"."id" ASC LIMIT 1`
+
+		mock.ExpectQuery(regexp.QuoteMeta(selectTransaction)).
+			WithArgs(transaction.ID).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+		result, err := repo.Find(context.Background(), transaction.ID)
+
+		is.Nil(result)
+		is.True(entity.IsErrorKind(err, entity.ErrorNotFound))
+		is.EqualError(err, "no payment was found")
+	})
+
 	t.Run("should test find all", func(t *testing.T) {
 		repo, mock, transaction := NewTransactionTestMock()
 		is := require.New(t)
@@ -142,7 +167,7 @@ func TestTransactionRepository(t *testing.T) {
 		mock.ExpectQuery(regexp.QuoteMeta(selectTransaction)).WillReturnRows(row)
 		mock.ExpectQuery(regexp.QuoteMeta(countSelect)).WillReturnRows(countRow)
 
-		result, total, err := repo.FindAll(pagination)
+		result, total, err := repo.FindAll(context.Background(), pagination)
 
 		is.Nil(err)
 		is.Equal(total, 1)
@@ -150,7 +175,7 @@ func TestTransactionRepository(t *testing.T) {
 		is.Equal(result[0].AccountFromID, transaction.AccountFromID)
 		is.Equal(result[0].Amount, transaction.Amount)
 
-		result, total, err = repo.FindAll(&entity.Pagination{
+		result, total, err = repo.FindAll(context.Background(), &entity.Pagination{
 			Page:  1,
 			Limit: 20,
 			Sort:  "created_at ASC",
@@ -174,14 +199,14 @@ func TestTransactionRepository(t *testing.T) {
 			WithArgs(transaction.ID, transaction.Type).
 			WillReturnRows(row)
 
-		result, err := repo.FindByType(transaction.ID, transaction.Type)
+		result, err := repo.FindByType(context.Background(), transaction.ID, transaction.Type)
 
 		is.Nil(err)
 		is.NotNil(result)
 		is.Equal(result.ID, transaction.ID)
 		is.Equal(result.Type, transaction.Type)
 
-		result, err = repo.FindByType(transaction.AccountFromID, transaction.Type)
+		result, err = repo.FindByType(context.Background(), transaction.AccountFromID, transaction.Type)
 		is.Nil(result)
 		is.NotNil(err)
 		is.Error(err)
@@ -212,7 +237,7 @@ func TestTransactionRepository(t *testing.T) {
 		mock.ExpectQuery(regexp.QuoteMeta(selectTransaction)).WithArgs(transaction.Type).WillReturnRows(row)
 		mock.ExpectQuery(regexp.QuoteMeta(countSelect)).WithArgs(transaction.Type).WillReturnRows(countRow)
 
-		result, total, err := repo.FindAllByType(transaction.Type, pagination)
+		result, total, err := repo.FindAllByType(context.Background(), transaction.Type, pagination)
 
 		fmt.Println(err)
 		is.Nil(err)
@@ -222,7 +247,7 @@ func TestTransactionRepository(t *testing.T) {
 		is.Equal(result[0].Amount, transaction.Amount)
 
 		transactionType := entity.TransactionToService
-		result, total, err = repo.FindAllByType(transactionType, &entity.Pagination{
+		result, total, err = repo.FindAllByType(context.Background(), transactionType, &entity.Pagination{
 			Page:  2,
 			Limit: limit,
 			Sort:  "id DESC",
@@ -246,14 +271,14 @@ func TestTransactionRepository(t *testing.T) {
 			WithArgs(transaction.ID, transaction.ExternalID).
 			WillReturnRows(row)
 
-		result, err := repo.FindByExternalID(transaction.ID, transaction.ExternalID)
+		result, err := repo.FindByExternalID(context.Background(), transaction.ID, transaction.ExternalID)
 
 		is.Nil(err)
 		is.NotNil(result)
 		is.Equal(result.ID, transaction.ID)
 		is.Equal(result.ExternalID, transaction.ExternalID)
 
-		result, err = repo.FindByExternalID(transaction.AccountFromID, transaction.ExternalID)
+		result, err = repo.FindByExternalID(context.Background(), transaction.AccountFromID, transaction.ExternalID)
 		is.Nil(result)
 		is.NotNil(err)
 		is.Error(err)
@@ -284,7 +309,7 @@ func TestTransactionRepository(t *testing.T) {
 		mock.ExpectQuery(regexp.QuoteMeta(selectTransaction)).WithArgs(transaction.ExternalID).WillReturnRows(row)
 		mock.ExpectQuery(regexp.QuoteMeta(countSelect)).WithArgs(transaction.ExternalID).WillReturnRows(countRow)
 
-		result, total, err := repo.FindAllByExternalID(transaction.ExternalID, pagination)
+		result, total, err := repo.FindAllByExternalID(context.Background(), transaction.ExternalID, pagination)
 
 		fmt.Println(err)
 		is.Nil(err)
@@ -293,7 +318,7 @@ func TestTransactionRepository(t *testing.T) {
 		is.Equal(result[0].AccountFromID, transaction.AccountFromID)
 		is.Equal(result[0].Amount, transaction.Amount)
 
-		result, total, err = repo.FindAllByExternalID(transaction.AccountFromID, &entity.Pagination{
+		result, total, err = repo.FindAllByExternalID(context.Background(), transaction.AccountFromID, &entity.Pagination{
 			Page:  2,
 			Limit: limit,
 			Sort:  "id DESC",
@@ -317,14 +342,14 @@ func TestTransactionRepository(t *testing.T) {
 			WithArgs(transaction.ID, transaction.AccountFromID).
 			WillReturnRows(row)
 
-		result, err := repo.FindByFromAccountID(transaction.ID, transaction.AccountFromID)
+		result, err := repo.FindByFromAccountID(context.Background(), transaction.ID, transaction.AccountFromID)
 
 		is.Nil(err)
 		is.NotNil(result)
 		is.Equal(result.ID, transaction.ID)
 		is.Equal(result.AccountFromID, transaction.AccountFromID)
 
-		result, err = repo.FindByFromAccountID(transaction.AccountFromID, transaction.AccountToID)
+		result, err = repo.FindByFromAccountID(context.Background(), transaction.AccountFromID, transaction.AccountToID)
 		is.Nil(result)
 		is.NotNil(err)
 		is.Error(err)
@@ -355,7 +380,7 @@ func TestTransactionRepository(t *testing.T) {
 		mock.ExpectQuery(regexp.QuoteMeta(selectTransaction)).WithArgs(transaction.AccountFromID).WillReturnRows(row)
 		mock.ExpectQuery(regexp.QuoteMeta(countSelect)).WithArgs(transaction.AccountFromID).WillReturnRows(countRow)
 
-		result, total, err := repo.FindAllByFromAccountID(transaction.AccountFromID, pagination)
+		result, total, err := repo.FindAllByFromAccountID(context.Background(), transaction.AccountFromID, pagination)
 
 		fmt.Println(err)
 		is.Nil(err)
@@ -364,7 +389,7 @@ func TestTransactionRepository(t *testing.T) {
 		is.Equal(result[0].AccountFromID, transaction.AccountFromID)
 		is.Equal(result[0].Amount, transaction.Amount)
 
-		result, total, err = repo.FindAllByFromAccountID(transaction.ExternalID, &entity.Pagination{
+		result, total, err = repo.FindAllByFromAccountID(context.Background(), transaction.ExternalID, &entity.Pagination{
 			Page:  2,
 			Limit: limit,
 			Sort:  "id DESC",
@@ -388,14 +413,14 @@ func TestTransactionRepository(t *testing.T) {
 			WithArgs(transaction.ID, transaction.AccountToID).
 			WillReturnRows(row)
 
-		result, err := repo.FindByToAccountID(transaction.ID, transaction.AccountToID)
+		result, err := repo.FindByToAccountID(context.Background(), transaction.ID, transaction.AccountToID)
 
 		is.Nil(err)
 		is.NotNil(result)
 		is.Equal(result.ID, transaction.ID)
 		is.Equal(result.AccountFromID, transaction.AccountFromID)
 
-		result, err = repo.FindByToAccountID(transaction.AccountFromID, transaction.AccountToID)
+		result, err = repo.FindByToAccountID(context.Background(), transaction.AccountFromID, transaction.AccountToID)
 		is.Nil(result)
 		is.NotNil(err)
 		is.Error(err)
@@ -426,7 +451,7 @@ func TestTransactionRepository(t *testing.T) {
 		mock.ExpectQuery(regexp.QuoteMeta(selectTransaction)).WithArgs(transaction.AccountToID).WillReturnRows(row)
 		mock.ExpectQuery(regexp.QuoteMeta(countSelect)).WithArgs(transaction.AccountToID).WillReturnRows(countRow)
 
-		result, total, err := repo.FindAllByToAccountID(transaction.AccountToID, pagination)
+		result, total, err := repo.FindAllByToAccountID(context.Background(), transaction.AccountToID, pagination)
 
 		fmt.Println(err)
 		is.Nil(err)
@@ -435,7 +460,7 @@ func TestTransactionRepository(t *testing.T) {
 		is.Equal(result[0].AccountToID, transaction.AccountToID)
 		is.Equal(result[0].Amount, transaction.Amount)
 
-		result, total, err = repo.FindAllByToAccountID(transaction.ExternalID, &entity.Pagination{
+		result, total, err = repo.FindAllByToAccountID(context.Background(), transaction.ExternalID, &entity.Pagination{
 			Page:  2,
 			Limit: limit,
 			Sort:  "id DESC",
@@ -446,3 +471,117 @@ func TestTransactionRepository(t *testing.T) {
 		is.NotNil(err)
 	})
 }
+
+func TestTransactionRepositoryIterate(t *testing.T) {
+	t.Parallel()
+
+	t.Run("should stream filtered rows after the cursor", func(t *testing.T) {
+		repo, mock, transaction := NewTransactionTestMock()
+		is := require.New(t)
+
+		second, _ := entity.NewTransaction(transaction.AccountFrom, transaction.AccountTo, transaction.ExternalID, transaction.Type, "AOA", 10)
+		after := &entity.ExportCursor{CreatedAt: transaction.CreatedAt.Add(-time.Minute), ID: uuid.NewV4().String()}
+
+		rows := sqlmock.NewRows([]string{"id", "account_from_id", "amount", "status", "currency", "account_to_id", "type", "external_id", "created_at", "updated_at"}).
+			AddRow(transaction.ID, transaction.AccountFromID, transaction.Amount, transaction.Status, transaction.Currency, transaction.AccountToID, transaction.Type, transaction.ExternalID, transaction.CreatedAt, transaction.UpdatedAt).
+			AddRow(second.ID, second.AccountFromID, second.Amount, second.Status, second.Currency, second.AccountToID, second.Type, second.ExternalID, second.CreatedAt, second.UpdatedAt)
+
+		const sql = `SELECT * FROM "transactions"  WHERE (type = $1) AND (account_from_id = $2) AND (created_at > $3 OR (created_at = $4 AND id > $5)) ORDER BY created_at asc, id asc`
+
+		mock.ExpectQuery(regexp.QuoteMeta(sql)).
+			WithArgs(entity.TransactionToService, transaction.AccountFromID, after.CreatedAt, after.CreatedAt, after.ID).
+			WillReturnRows(rows)
+
+		var streamed []*entity.Transaction
+		filter := &entity.TransactionFilter{Type: entity.TransactionToService, AccountFromID: transaction.AccountFromID}
+
+		err := repo.Iterate(context.Background(), filter, after, func(transaction *entity.Transaction) error {
+			streamed = append(streamed, transaction)
+			return nil
+		})
+
+		is.Nil(err)
+		is.Len(streamed, 2)
+		is.Equal(transaction.ID, streamed[0].ID)
+		is.Equal(second.ID, streamed[1].ID)
+		is.Equal(second.Amount, streamed[1].Amount)
+	})
+
+	t.Run("should stop when the callback fails", func(t *testing.T) {
+		repo, mock, transaction := NewTransactionTestMock()
+		is := require.New(t)
+
+		rows := sqlmock.NewRows([]string{"id", "amount"}).
+			AddRow(transaction.ID, transaction.Amount).
+			AddRow(uuid.NewV4().String(), transaction.Amount)
+
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "transactions"  ORDER BY created_at asc, id asc`)).
+			WillReturnRows(rows).
+			RowsWillBeClosed()
+
+		calls := 0
+		err := repo.Iterate(context.Background(), &entity.TransactionFilter{}, nil, func(transaction *entity.Transaction) error {
+			calls++
+			return fmt.Errorf("client went away")
+		})
+
+		is.EqualError(err, "client went away")
+		is.Equal(1, calls)
+		is.Nil(mock.ExpectationsWereMet())
+	})
+}
+
+func TestTransactionRepositoryMetrics(t *testing.T) {
+	t.Parallel()
+
+	t.Run("should sum transactions by type, status and currency", func(t *testing.T) {
+		repo, mock, _ := NewTransactionTestMock()
+		is := require.New(t)
+
+		rows := sqlmock.NewRows([]string{"type", "status", "currency", "count", "amount"}).
+			AddRow(entity.TransactionToUser, entity.TransactionPending, "AOA", 3, 120.5).
+			AddRow(entity.TransactionToStore, entity.TransactionCompleted, "AOA", 1, 30.0)
+
+		mock.ExpectQuery(regexp.QuoteMeta(`FROM "transactions" GROUP BY type, status, currency`)).
+			WillReturnRows(rows)
+
+		totals, err := repo.Totals(context.Background())
+
+		is.Nil(err)
+		is.Len(totals, 2)
+		is.Equal(int64(3), totals[0].Count)
+		is.Equal(120.5, totals[0].Amount)
+		is.Equal(entity.TransactionCompleted, totals[1].Status)
+	})
+
+	t.Run("should find the oldest pending transaction", func(t *testing.T) {
+		repo, mock, transaction := NewTransactionTestMock()
+		is := require.New(t)
+
+		row := sqlmock.NewRows([]string{"id", "status", "created_at"}).
+			AddRow(transaction.ID, transaction.Status, transaction.CreatedAt)
+
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "transactions" WHERE (status = $1) ORDER BY created_at asc`)).
+			WithArgs(entity.TransactionPending).
+			WillReturnRows(row)
+
+		result, err := repo.OldestPending(context.Background())
+
+		is.Nil(err)
+		is.Equal(transaction.ID, result.ID)
+	})
+
+	t.Run("should return nil when nothing is pending", func(t *testing.T) {
+		repo, mock, _ := NewTransactionTestMock()
+		is := require.New(t)
+
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "transactions" WHERE (status = $1)`)).
+			WithArgs(entity.TransactionPending).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+		result, err := repo.OldestPending(context.Background())
+
+		is.Nil(err)
+		is.Nil(result)
+	})
+}
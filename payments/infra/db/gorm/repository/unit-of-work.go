@@ -0,0 +1,40 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/EdlanioJ/kbu/payments/data/repository"
+	"github.com/jinzhu/gorm"
+)
+
+type UnitOfWorkGORM struct {
+	DB *gorm.DB
+}
+
+func NewUnitOfWork(db *gorm.DB) *UnitOfWorkGORM {
+	return &UnitOfWorkGORM{
+		DB: db,
+	}
+}
+
+func (u *UnitOfWorkGORM) Do(ctx context.Context, fn func(transactions repository.TransactionRepository, accounts repository.AccountRepository) error) error {
+	return withContext(ctx, u.DB, "repository.UnitOfWork.Do", false, func(tx *gorm.DB) error {
+		return fn(NewTransactionRepository(tx), NewAccountRepository(tx))
+	})
+}
+
+type ConfirmationUnitOfWorkGORM struct {
+	DB *gorm.DB
+}
+
+func NewConfirmationUnitOfWork(db *gorm.DB) *ConfirmationUnitOfWorkGORM {
+	return &ConfirmationUnitOfWorkGORM{
+		DB: db,
+	}
+}
+
+func (u *ConfirmationUnitOfWorkGORM) Do(ctx context.Context, fn func(transactions repository.TransactionRepository, webhooks repository.WebhookRepository, deliveries repository.WebhookDeliveryRepository) error) error {
+	return withContext(ctx, u.DB, "repository.ConfirmationUnitOfWork.Do", false, func(tx *gorm.DB) error {
+		return fn(NewTransactionRepository(tx), NewWebhookRepository(tx), NewWebhookDeliveryRepository(tx))
+	})
+}
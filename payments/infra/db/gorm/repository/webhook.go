@@ -0,0 +1,159 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/EdlanioJ/kbu/payments/domain/entity"
+	"github.com/jinzhu/gorm"
+)
+
+type WebhookRepositoryGORM struct {
+	DB *gorm.DB
+}
+
+func NewWebhookRepository(db *gorm.DB) *WebhookRepositoryGORM {
+	return &WebhookRepositoryGORM{
+		DB: db,
+	}
+}
+
+func (w *WebhookRepositoryGORM) Register(ctx context.Context, webhook *entity.Webhook) error {
+	err := withContext(ctx, w.DB, "repository.Webhook.Register", false, func(tx *gorm.DB) error {
+		return tx.Create(webhook).Error
+	})
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (w *WebhookRepositoryGORM) Find(ctx context.Context, id string) (*entity.Webhook, error) {
+	webhook := &entity.Webhook{}
+
+	err := withContext(ctx, w.DB, "repository.Webhook.Find", true, func(tx *gorm.DB) error {
+		return tx.First(webhook, "id = ?", id).Error
+	})
+
+	if err != nil {
+		return nil, translate(err, "webhook", id)
+	}
+
+	return webhook, nil
+}
+
+func (w *WebhookRepositoryGORM) FindAllByAccountID(ctx context.Context, accountID string) ([]*entity.Webhook, error) {
+	var webhooks []*entity.Webhook
+
+	err := withContext(ctx, w.DB, "repository.Webhook.FindAllByAccountID", true, func(tx *gorm.DB) error {
+		return tx.
+			Where("account_id = ? AND active = ?", accountID, true).
+			Find(&webhooks).
+			Error
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return webhooks, nil
+}
+
+type WebhookDeliveryRepositoryGORM struct {
+	DB *gorm.DB
+}
+
+func NewWebhookDeliveryRepository(db *gorm.DB) *WebhookDeliveryRepositoryGORM {
+	return &WebhookDeliveryRepositoryGORM{
+		DB: db,
+	}
+}
+
+func (w *WebhookDeliveryRepositoryGORM) Register(ctx context.Context, delivery *entity.WebhookDelivery) error {
+	err := withContext(ctx, w.DB, "repository.WebhookDelivery.Register", false, func(tx *gorm.DB) error {
+		return tx.Create(delivery).Error
+	})
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (w *WebhookDeliveryRepositoryGORM) Save(ctx context.Context, delivery *entity.WebhookDelivery) error {
+	err := withContext(ctx, w.DB, "repository.WebhookDelivery.Save", false, func(tx *gorm.DB) error {
+		return save(ctx, tx, delivery)
+	})
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (w *WebhookDeliveryRepositoryGORM) Find(ctx context.Context, id string) (*entity.WebhookDelivery, error) {
+	delivery := &entity.WebhookDelivery{}
+
+	err := withContext(ctx, w.DB, "repository.WebhookDelivery.Find", true, func(tx *gorm.DB) error {
+		return tx.First(delivery, "id = ?", id).Error
+	})
+
+	if err != nil {
+		return nil, translate(err, "webhook delivery", id)
+	}
+
+	return delivery, nil
+}
+
+func (w *WebhookDeliveryRepositoryGORM) FindAllDue(ctx context.Context, now time.Time, limit int) ([]*entity.WebhookDelivery, error) {
+	var deliveries []*entity.WebhookDelivery
+
+	err := withContext(ctx, w.DB, "repository.WebhookDelivery.FindAllDue", true, func(tx *gorm.DB) error {
+		return tx.
+			Where("status = ? AND next_attempt_at <= ?", entity.WebhookDeliveryPending, now).
+			Order("next_attempt_at").
+			Limit(limit).
+			Find(&deliveries).
+			Error
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return deliveries, nil
+}
+
+func (w *WebhookDeliveryRepositoryGORM) RegisterAttempt(ctx context.Context, attempt *entity.WebhookAttempt) error {
+	err := withContext(ctx, w.DB, "repository.WebhookDelivery.RegisterAttempt", false, func(tx *gorm.DB) error {
+		return tx.Create(attempt).Error
+	})
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (w *WebhookDeliveryRepositoryGORM) FindAllAttempts(ctx context.Context, deliveryID string) ([]*entity.WebhookAttempt, error) {
+	var attempts []*entity.WebhookAttempt
+
+	err := withContext(ctx, w.DB, "repository.WebhookDelivery.FindAllAttempts", true, func(tx *gorm.DB) error {
+		return tx.
+			Where("delivery_id = ?", deliveryID).
+			Order("created_at").
+			Find(&attempts).
+			Error
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return attempts, nil
+}
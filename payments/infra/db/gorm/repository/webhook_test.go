@@ -0,0 +1,84 @@
+package repository_test
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/EdlanioJ/kbu/payments/domain/entity"
+	"github.com/EdlanioJ/kbu/payments/infra/db/gorm/repository"
+	"github.com/jinzhu/gorm"
+	uuid "github.com/satori/go.uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func NewWebhookTestMock() (*gorm.DB, sqlmock.Sqlmock, *entity.Webhook) {
+	webhook, _ := entity.NewWebhook(uuid.NewV4().String(), "https://store.kbu.test/hooks")
+
+	db, mock, err := sqlmock.New()
+
+	if err != nil {
+		panic(err)
+	}
+
+	gdb, err := gorm.Open("postgres", db)
+
+	gdb.LogMode(false)
+	if err != nil {
+		panic(err)
+	}
+
+	return gdb, mock, webhook
+}
+
+func TestWebhookRepository(t *testing.T) {
+	t.Parallel()
+
+	t.Run("should test find all by account id", func(t *testing.T) {
+		gdb, mock, webhook := NewWebhookTestMock()
+		repo := repository.NewWebhookRepository(gdb)
+		is := require.New(t)
+
+		rows := sqlmock.NewRows([]string{"id", "account_id", "url", "secret", "active", "created_at"}).
+			AddRow(webhook.ID, webhook.AccountID, webhook.URL, webhook.Secret, webhook.Active, webhook.CreatedAt)
+
+		const sql = `SELECT * FROM "webhooks" WHERE (account_id = $1 AND active = $2)`
+
+		mock.ExpectQuery(regexp.QuoteMeta(sql)).
+			WithArgs(webhook.AccountID, true).
+			WillReturnRows(rows)
+
+		result, err := repo.FindAllByAccountID(context.Background(), webhook.AccountID)
+
+		is.Nil(err)
+		is.Len(result, 1)
+		is.Equal(webhook.URL, result[0].URL)
+		is.Equal(webhook.Secret, result[0].Secret)
+	})
+
+	t.Run("should test find all due deliveries", func(t *testing.T) {
+		gdb, mock, webhook := NewWebhookTestMock()
+		repo := repository.NewWebhookDeliveryRepository(gdb)
+		is := require.New(t)
+
+		delivery, _ := entity.NewWebhookDelivery(webhook.ID, uuid.NewV4().String(), entity.WebhookPaymentCompleted, []byte("{}"))
+		now := time.Now()
+
+		rows := sqlmock.NewRows([]string{"id", "webhook_id", "transaction_id", "event", "payload", "status", "attempts", "next_attempt_at"}).
+			AddRow(delivery.ID, delivery.WebhookID, delivery.TransactionID, delivery.Event, delivery.Payload, delivery.Status, delivery.Attempts, delivery.NextAttemptAt)
+
+		const sql = `SELECT * FROM "webhook_deliveries" WHERE (status = $1 AND next_attempt_at <= $2) ORDER BY next_attempt_at LIMIT 10`
+
+		mock.ExpectQuery(regexp.QuoteMeta(sql)).
+			WithArgs(entity.WebhookDeliveryPending, now).
+			WillReturnRows(rows)
+
+		result, err := repo.FindAllDue(context.Background(), now, 10)
+
+		is.Nil(err)
+		is.Len(result, 1)
+		is.Equal(delivery.ID, result[0].ID)
+	})
+}
@@ -0,0 +1,99 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/EdlanioJ/kbu/payments/domain/entity"
+)
+
+type chargeRequest struct {
+	TransactionID string  `json:"transaction_id"`
+	ServiceID     string  `json:"service_id"`
+	AccountID     string  `json:"account_id"`
+	Amount        float64 `json:"amount"`
+	Currency      string  `json:"currency"`
+}
+
+type chargeResponse struct {
+	Reference string `json:"reference"`
+}
+
+type ServiceProviderHTTP struct {
+	BaseURL string
+	Client  *http.Client
+}
+
+func NewServiceProvider(baseURL string, timeout time.Duration) *ServiceProviderHTTP {
+	return &ServiceProviderHTTP{
+		BaseURL: strings.TrimRight(baseURL, "/"),
+		Client:  &http.Client{Timeout: timeout},
+	}
+}
+
+func (s *ServiceProviderHTTP) Charge(transaction *entity.Transaction) (string, error) {
+	payload, err := json.Marshal(&chargeRequest{
+		TransactionID: transaction.ID,
+		ServiceID:     transaction.ExternalID,
+		AccountID:     transaction.AccountToID,
+		Amount:        transaction.Amount,
+		Currency:      transaction.Currency,
+	})
+
+	if err != nil {
+		return "", err
+	}
+
+	body, err := s.post(s.BaseURL+"/charges", transaction.ID, payload)
+
+	if err != nil {
+		return "", err
+	}
+
+	response := &chargeResponse{}
+
+	err = json.Unmarshal(body, response)
+
+	if err != nil {
+		return "", err
+	}
+
+	return response.Reference, nil
+}
+
+func (s *ServiceProviderHTTP) Refund(transaction *entity.Transaction) error {
+	_, err := s.post(fmt.Sprintf("%s/charges/%s/refund", s.BaseURL, transaction.ID), "refund-"+transaction.ID, nil)
+
+	return err
+}
+
+func (s *ServiceProviderHTTP) post(url, idempotencyKey string, payload []byte) ([]byte, error) {
+	request, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(payload))
+
+	if err != nil {
+		return nil, err
+	}
+
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("Idempotency-Key", idempotencyKey)
+
+	response, err := s.Client.Do(request)
+
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		io.Copy(ioutil.Discard, response.Body)
+		return nil, fmt.Errorf("service provider responded with status %d", response.StatusCode)
+	}
+
+	return ioutil.ReadAll(response.Body)
+}
@@ -0,0 +1,85 @@
+package http
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+
+	"github.com/EdlanioJ/kbu/payments/domain/entity"
+)
+
+var errWebhookRedirect = errors.New("webhooks do not follow redirects")
+
+type WebhookSenderHTTP struct {
+	Client *http.Client
+}
+
+// NewWebhookSender only dials public addresses and does not follow redirects,
+// a webhook host that resolves or redirects to an internal service is refused.
+func NewWebhookSender(timeout time.Duration) *WebhookSenderHTTP {
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: dialPublic,
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+
+	return &WebhookSenderHTTP{
+		Client: &http.Client{
+			Timeout:   timeout,
+			Transport: transport,
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return errWebhookRedirect
+			},
+		},
+	}
+}
+
+// dialPublic runs after the host was resolved, so it sees the address that is
+// actually dialed.
+func dialPublic(network, address string, conn syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+
+	if err != nil {
+		return err
+	}
+
+	ip := net.ParseIP(host)
+
+	if ip == nil || entity.InternalAddress(ip) {
+		return fmt.Errorf("webhook address %s is not public", host)
+	}
+
+	return nil
+}
+
+func (w *WebhookSenderHTTP) Send(url string, headers map[string]string, payload []byte) (int, error) {
+	request, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(payload))
+
+	if err != nil {
+		return 0, err
+	}
+
+	for key, value := range headers {
+		request.Header.Set(key, value)
+	}
+
+	response, err := w.Client.Do(request)
+
+	if err != nil {
+		return 0, err
+	}
+	defer response.Body.Close()
+
+	io.Copy(ioutil.Discard, response.Body)
+
+	return response.StatusCode, nil
+}
@@ -0,0 +1,50 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const startedAtKey = "metrics:started_at"
+
+func InstrumentDB(db *gorm.DB, registerer prometheus.Registerer) {
+	duration := prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "db_query_duration_seconds",
+		Help:      "Latency of database statements issued by the repositories.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"operation", "table"})
+
+	registerer.MustRegister(duration)
+
+	before := func(scope *gorm.Scope) {
+		scope.Set(startedAtKey, time.Now())
+	}
+
+	after := func(operation string) func(scope *gorm.Scope) {
+		return func(scope *gorm.Scope) {
+			value, ok := scope.Get(startedAtKey)
+
+			if !ok {
+				return
+			}
+
+			duration.WithLabelValues(operation, scope.TableName()).Observe(time.Since(value.(time.Time)).Seconds())
+		}
+	}
+
+	callback := db.Callback()
+
+	callback.Create().Before("gorm:create").Register("metrics:before_create", before)
+	callback.Create().After("gorm:create").Register("metrics:after_create", after("create"))
+	callback.Update().Before("gorm:update").Register("metrics:before_update", before)
+	callback.Update().After("gorm:update").Register("metrics:after_update", after("update"))
+	callback.Delete().Before("gorm:delete").Register("metrics:before_delete", before)
+	callback.Delete().After("gorm:delete").Register("metrics:after_delete", after("delete"))
+	callback.Query().Before("gorm:query").Register("metrics:before_query", before)
+	callback.Query().After("gorm:query").Register("metrics:after_query", after("query"))
+	callback.RowQuery().Before("gorm:row_query").Register("metrics:before_row_query", before)
+	callback.RowQuery().After("gorm:row_query").Register("metrics:after_row_query", after("row_query"))
+}
@@ -0,0 +1,27 @@
+package metrics
+
+import "github.com/prometheus/client_golang/prometheus"
+
+const namespace = "payments"
+
+type Prometheus struct {
+	registerFailures *prometheus.CounterVec
+}
+
+func NewPrometheus(registerer prometheus.Registerer) *Prometheus {
+	registerFailures := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "register_failures_total",
+		Help:      "Payments that could not be registered, by failure reason.",
+	}, []string{"reason"})
+
+	registerer.MustRegister(registerFailures)
+
+	return &Prometheus{
+		registerFailures: registerFailures,
+	}
+}
+
+func (p *Prometheus) RegisterFailed(reason string) {
+	p.registerFailures.WithLabelValues(reason).Inc()
+}
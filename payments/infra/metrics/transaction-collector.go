@@ -0,0 +1,151 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/EdlanioJ/kbu/payments/data/repository"
+	"github.com/EdlanioJ/kbu/payments/domain/entity"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	transactionsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "transactions"),
+		"Payments stored, by type, status and currency.",
+		[]string{"type", "status", "currency"}, nil,
+	)
+	transactionAmountDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "transaction_amount"),
+		"Sum of payment amounts, by type, status and currency.",
+		[]string{"type", "status", "currency"}, nil,
+	)
+	oldestPendingDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "oldest_pending_transaction_age_seconds"),
+		"Age of the oldest payment still pending.",
+		nil, nil,
+	)
+)
+
+var errCollectTimeout = errors.New("payment metrics are still being computed")
+
+// TransactionCollector serves the payment gauges from a snapshot of the
+// database that is refreshed at most once every CacheFor. A scrape waits up to
+// Timeout for a refresh and otherwise serves the previous snapshot, so a slow
+// database can neither stall scrapes nor pile up queries behind them: only one
+// refresh runs at a time.
+type TransactionCollector struct {
+	TransactionRepository repository.TransactionRepository
+	CacheFor              time.Duration
+	Timeout               time.Duration
+	now                   func() time.Time
+
+	mu         sync.Mutex
+	snapshot   *transactionSnapshot
+	refreshing chan struct{}
+}
+
+type transactionSnapshot struct {
+	totals    []*entity.TransactionTotals
+	totalsErr error
+	oldest    *entity.Transaction
+	oldestErr error
+	takenAt   time.Time
+}
+
+func NewTransactionCollector(transactionRepository repository.TransactionRepository) *TransactionCollector {
+	return &TransactionCollector{
+		TransactionRepository: transactionRepository,
+		CacheFor:              15 * time.Second,
+		Timeout:               5 * time.Second,
+		now:                   time.Now,
+	}
+}
+
+func (c *TransactionCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- transactionsDesc
+	ch <- transactionAmountDesc
+	ch <- oldestPendingDesc
+}
+
+func (c *TransactionCollector) Collect(ch chan<- prometheus.Metric) {
+	snapshot := c.current()
+
+	if snapshot == nil {
+		ch <- prometheus.NewInvalidMetric(transactionsDesc, errCollectTimeout)
+		ch <- prometheus.NewInvalidMetric(oldestPendingDesc, errCollectTimeout)
+		return
+	}
+
+	if snapshot.totalsErr != nil {
+		ch <- prometheus.NewInvalidMetric(transactionsDesc, snapshot.totalsErr)
+	}
+
+	for _, total := range snapshot.totals {
+		ch <- prometheus.MustNewConstMetric(transactionsDesc, prometheus.GaugeValue, float64(total.Count), total.Type, total.Status, total.Currency)
+		ch <- prometheus.MustNewConstMetric(transactionAmountDesc, prometheus.GaugeValue, total.Amount, total.Type, total.Status, total.Currency)
+	}
+
+	if snapshot.oldestErr != nil {
+		ch <- prometheus.NewInvalidMetric(oldestPendingDesc, snapshot.oldestErr)
+		return
+	}
+
+	age := 0.0
+
+	if snapshot.oldest != nil {
+		age = c.now().Sub(snapshot.oldest.CreatedAt).Seconds()
+	}
+
+	ch <- prometheus.MustNewConstMetric(oldestPendingDesc, prometheus.GaugeValue, age)
+}
+
+// current returns the latest snapshot, refreshing it first when it is older
+// than CacheFor. It returns nil when no refresh has finished yet.
+func (c *TransactionCollector) current() *transactionSnapshot {
+	c.mu.Lock()
+
+	if c.snapshot != nil && c.now().Sub(c.snapshot.takenAt) < c.CacheFor {
+		snapshot := c.snapshot
+		c.mu.Unlock()
+		return snapshot
+	}
+
+	if c.refreshing == nil {
+		c.refreshing = make(chan struct{})
+		go c.refresh(c.refreshing)
+	}
+
+	refreshing := c.refreshing
+	c.mu.Unlock()
+
+	timer := time.NewTimer(c.Timeout)
+	defer timer.Stop()
+
+	select {
+	case <-refreshing:
+	case <-timer.C:
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.snapshot
+}
+
+func (c *TransactionCollector) refresh(done chan struct{}) {
+	ctx := context.Background()
+	snapshot := &transactionSnapshot{takenAt: c.now()}
+
+	snapshot.totals, snapshot.totalsErr = c.TransactionRepository.Totals(ctx)
+	snapshot.oldest, snapshot.oldestErr = c.TransactionRepository.OldestPending(ctx)
+
+	c.mu.Lock()
+	c.snapshot = snapshot
+	c.refreshing = nil
+	c.mu.Unlock()
+
+	close(done)
+}
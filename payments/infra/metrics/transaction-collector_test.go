@@ -0,0 +1,130 @@
+package metrics
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/EdlanioJ/kbu/payments/infra/db/gorm/repository"
+	"github.com/jinzhu/gorm"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	totalsSQL        = `SELECT type, status, currency, count(*) as count, coalesce(sum(amount), 0) as amount FROM "transactions"`
+	oldestPendingSQL = `SELECT * FROM "transactions" WHERE (status = $1)`
+)
+
+func newCollectorMock(t *testing.T) (*TransactionCollector, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+
+	gdb, err := gorm.Open("postgres", db)
+	require.NoError(t, err)
+	gdb.LogMode(false)
+
+	t.Cleanup(func() { gdb.Close() })
+
+	return NewTransactionCollector(repository.NewTransactionRepository(gdb)), mock
+}
+
+func TestTransactionCollector(t *testing.T) {
+	t.Parallel()
+
+	t.Run("should collect payment totals and the oldest pending age", func(t *testing.T) {
+		is := require.New(t)
+		collector, mock := newCollectorMock(t)
+		now := time.Date(2021, 5, 1, 12, 0, 0, 0, time.UTC)
+		collector.now = func() time.Time { return now }
+
+		mock.ExpectQuery(regexp.QuoteMeta(totalsSQL)).
+			WillReturnRows(sqlmock.NewRows([]string{"type", "status", "currency", "count", "amount"}).
+				AddRow("transfer", "pending", "AOA", 2, 150.5).
+				AddRow("transfer", "completed", "AOA", 1, 40))
+
+		mock.ExpectQuery(regexp.QuoteMeta(oldestPendingSQL)).
+			WithArgs("pending").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "status", "created_at"}).
+				AddRow("5ad4f3d2-5d1b-4bd8-92a1-4d3bbd0d6f09", "pending", now.Add(-90*time.Second)))
+
+		expected := `
+# HELP payments_oldest_pending_transaction_age_seconds Age of the oldest payment still pending.
+# TYPE payments_oldest_pending_transaction_age_seconds gauge
+payments_oldest_pending_transaction_age_seconds 90
+# HELP payments_transaction_amount Sum of payment amounts, by type, status and currency.
+# TYPE payments_transaction_amount gauge
+payments_transaction_amount{currency="AOA",status="completed",type="transfer"} 40
+payments_transaction_amount{currency="AOA",status="pending",type="transfer"} 150.5
+# HELP payments_transactions Payments stored, by type, status and currency.
+# TYPE payments_transactions gauge
+payments_transactions{currency="AOA",status="completed",type="transfer"} 1
+payments_transactions{currency="AOA",status="pending",type="transfer"} 2
+`
+
+		is.NoError(testutil.CollectAndCompare(collector, strings.NewReader(expected)))
+		is.NoError(mock.ExpectationsWereMet())
+
+		now = now.Add(10 * time.Second)
+		expected = strings.Replace(expected, "seconds 90", "seconds 100", 1)
+
+		is.NoError(testutil.CollectAndCompare(collector, strings.NewReader(expected)), "should serve the cached totals without querying again")
+	})
+
+	t.Run("should report no pending age when nothing is pending", func(t *testing.T) {
+		is := require.New(t)
+		collector, mock := newCollectorMock(t)
+
+		mock.ExpectQuery(regexp.QuoteMeta(totalsSQL)).
+			WillReturnRows(sqlmock.NewRows([]string{"type", "status", "currency", "count", "amount"}))
+
+		mock.ExpectQuery(regexp.QuoteMeta(oldestPendingSQL)).
+			WithArgs("pending").
+			WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+		expected := `
+# HELP payments_oldest_pending_transaction_age_seconds Age of the oldest payment still pending.
+# TYPE payments_oldest_pending_transaction_age_seconds gauge
+payments_oldest_pending_transaction_age_seconds 0
+`
+
+		is.NoError(testutil.CollectAndCompare(collector, strings.NewReader(expected)))
+		is.NoError(mock.ExpectationsWereMet())
+	})
+
+	t.Run("should give up on a scrape when the database is slow", func(t *testing.T) {
+		is := require.New(t)
+		collector, mock := newCollectorMock(t)
+		collector.Timeout = 20 * time.Millisecond
+
+		mock.ExpectQuery(regexp.QuoteMeta(totalsSQL)).
+			WillDelayFor(200 * time.Millisecond).
+			WillReturnRows(sqlmock.NewRows([]string{"type", "status", "currency", "count", "amount"}))
+		mock.ExpectQuery(regexp.QuoteMeta(oldestPendingSQL)).
+			WithArgs("pending").
+			WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+		start := time.Now()
+		err := testutil.CollectAndCompare(collector, strings.NewReader(""))
+
+		is.Error(err)
+		is.Contains(err.Error(), errCollectTimeout.Error())
+		is.Less(int64(time.Since(start)), int64(150*time.Millisecond))
+
+		err = testutil.CollectAndCompare(collector, strings.NewReader(""))
+		is.Error(err, "should not start a second refresh while one is running")
+
+		expected := `
+# HELP payments_oldest_pending_transaction_age_seconds Age of the oldest payment still pending.
+# TYPE payments_oldest_pending_transaction_age_seconds gauge
+payments_oldest_pending_transaction_age_seconds 0
+`
+
+		is.Eventually(func() bool {
+			return testutil.CollectAndCompare(collector, strings.NewReader(expected)) == nil
+		}, time.Second, 10*time.Millisecond)
+		is.NoError(mock.ExpectationsWereMet())
+	})
+}
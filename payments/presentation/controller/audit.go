@@ -0,0 +1,59 @@
+package controller
+
+import (
+	"context"
+	"errors"
+
+	"github.com/EdlanioJ/kbu/payments/domain/entity"
+	"github.com/EdlanioJ/kbu/payments/domain/usecase"
+	"github.com/EdlanioJ/kbu/payments/presentation/validator"
+	log "github.com/sirupsen/logrus"
+)
+
+var errOnListAudit = errors.New("an error on list audit entries")
+
+type Audit struct {
+	Audit  usecase.Audit
+	logger *log.Logger
+}
+
+func NewAudit(audit usecase.Audit) *Audit {
+	logger := newLogger()
+
+	return &Audit{
+		Audit:  audit,
+		logger: logger,
+	}
+}
+
+func (c *Audit) List(ctx context.Context, filter *entity.AuditFilter, page, limit int) ([]*entity.AuditEntry, int, error) {
+	err := validator.AuditParams(filter, page, limit)
+
+	if err != nil {
+		c.logger.WithContext(ctx).Error(err)
+		return nil, 0, err
+	}
+
+	err = authorizeAudit(ctx)
+
+	if err != nil {
+		c.logger.WithContext(ctx).WithError(err).Warn("caller is not authorized")
+		return nil, 0, err
+	}
+
+	entries, total, err := c.Audit.FindAll(ctx, filter, page, limit)
+
+	if err != nil {
+		c.logger.
+			WithFields(log.Fields{
+				"actor":       filter.Actor,
+				"entity_type": filter.EntityType,
+				"entity_id":   filter.EntityID,
+			}).WithContext(ctx).
+			WithError(err).
+			Error(errOnListAudit)
+		return nil, 0, domainOr(err, errOnListAudit)
+	}
+
+	return entries, total, nil
+}
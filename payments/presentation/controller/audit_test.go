@@ -0,0 +1,79 @@
+package controller_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/EdlanioJ/kbu/payments/domain/entity"
+	"github.com/EdlanioJ/kbu/payments/presentation/controller"
+	"github.com/EdlanioJ/kbu/payments/presentation/controller/mock"
+	uuid "github.com/satori/go.uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListAudit(t *testing.T) {
+	t.Parallel()
+
+	t.Run("should fail on validation", func(t *testing.T) {
+		is := require.New(t)
+
+		c := controller.NewAudit(nil)
+
+		from := time.Now()
+		entries, _, err := c.List(systemContext(), &entity.AuditFilter{EntityType: "batch", From: from, To: from.Add(-time.Hour)}, 0, 1000)
+
+		is.Nil(entries)
+		is.True(entity.IsErrorKind(err, entity.ErrorInvalidArgument))
+	})
+
+	t.Run("should not list for callers that are not auditors", func(t *testing.T) {
+		is := require.New(t)
+		auditUseCase := mock.NewMockAuditUseCase()
+
+		c := controller.NewAudit(auditUseCase)
+		entries, _, err := c.List(customerContext(uuid.NewV4().String()), &entity.AuditFilter{}, 1, 10)
+
+		auditUseCase.AssertNotCalled(t, "FindAll")
+
+		is.Nil(entries)
+		is.True(entity.IsErrorKind(err, entity.ErrorPermissionDenied))
+	})
+
+	t.Run("should fail on find all", func(t *testing.T) {
+		is := require.New(t)
+		auditUseCase := mock.NewMockAuditUseCase()
+		filter := &entity.AuditFilter{}
+
+		auditUseCase.On("FindAll", filter, 1, 10).Return(nil, 0, errors.New("db error"))
+		c := controller.NewAudit(auditUseCase)
+
+		ctx := controller.WithPrincipal(context.TODO(), &entity.Principal{Subject: "admin", Roles: []string{entity.RoleAdmin}})
+		entries, _, err := c.List(ctx, filter, 1, 10)
+
+		is.Nil(entries)
+		is.EqualError(err, "an error on list audit entries")
+	})
+
+	t.Run("should list for auditors", func(t *testing.T) {
+		is := require.New(t)
+		auditUseCase := mock.NewMockAuditUseCase()
+		filter := &entity.AuditFilter{EntityType: entity.AggregateTransaction, EntityID: uuid.NewV4().String()}
+		entry, _ := entity.NewAuditEntry(systemContext(), entity.EventTransactionRegistered, filter.EntityType, filter.EntityID, "", "{}")
+
+		auditUseCase.On("FindAll", filter, 1, 10).Return([]*entity.AuditEntry{entry}, 1, nil)
+		c := controller.NewAudit(auditUseCase)
+
+		ctx := controller.WithPrincipal(context.TODO(), &entity.Principal{
+			Subject: "auditor-1",
+			Roles:   []string{entity.RoleAuditor},
+		})
+		entries, total, err := c.List(ctx, filter, 1, 10)
+
+		is.Nil(err)
+		is.Equal(1, total)
+		is.Equal([]*entity.AuditEntry{entry}, entries)
+		is.Equal("auditor-1", entity.AuditActor(ctx))
+	})
+}
@@ -0,0 +1,133 @@
+package controller
+
+import (
+	"context"
+
+	"github.com/EdlanioJ/kbu/payments/domain/entity"
+)
+
+type principalKey struct{}
+
+var errMissingPrincipal = entity.Unauthenticated("missing credentials")
+
+// WithPrincipal also scopes ctx to the tenant of the principal, principals
+// issued without one belong to the default tenant.
+func WithPrincipal(ctx context.Context, principal *entity.Principal) context.Context {
+	tenant := principal.Tenant
+
+	if tenant == "" {
+		tenant = entity.DefaultTenant
+	}
+
+	ctx = entity.WithTenant(ctx, tenant)
+	ctx = entity.WithAuditActor(ctx, principal.Subject)
+
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// WithSystemPrincipal marks ctx as work of the service itself. Unlike
+// WithPrincipal it leaves ctx unscoped, the system works for every tenant.
+func WithSystemPrincipal(ctx context.Context) context.Context {
+	principal := entity.SystemPrincipal()
+
+	ctx = entity.WithAuditActor(ctx, principal.Subject)
+
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+func PrincipalFromContext(ctx context.Context) (*entity.Principal, bool) {
+	principal, ok := ctx.Value(principalKey{}).(*entity.Principal)
+
+	return principal, ok && principal != nil
+}
+
+// Every call must carry a principal: the transports attach the caller of each
+// request and in-process callers use WithSystemPrincipal. A call without one
+// is refused.
+func authorizeAccounts(ctx context.Context, accountIDs ...string) error {
+	principal, ok := PrincipalFromContext(ctx)
+
+	if !ok {
+		return errMissingPrincipal
+	}
+
+	if principal.Privileged() {
+		return nil
+	}
+
+	for _, accountID := range accountIDs {
+		if !principal.Owns(accountID) {
+			return entity.PermissionDenied(principal.Subject, "account", accountID)
+		}
+	}
+
+	return nil
+}
+
+func authorizeTransaction(ctx context.Context, transaction *entity.Transaction) error {
+	principal, ok := PrincipalFromContext(ctx)
+
+	if !ok {
+		return errMissingPrincipal
+	}
+
+	if principal.Privileged() {
+		return nil
+	}
+
+	if principal.Owns(transaction.AccountFromID) || principal.Owns(transaction.AccountToID) {
+		return nil
+	}
+
+	return entity.PermissionDenied(principal.Subject, "payment", transaction.ID)
+}
+
+func authorizePrivileged(ctx context.Context, resource string) error {
+	principal, ok := PrincipalFromContext(ctx)
+
+	if !ok {
+		return errMissingPrincipal
+	}
+
+	if principal.Privileged() {
+		return nil
+	}
+
+	return entity.PermissionDenied(principal.Subject, resource, "")
+}
+
+func authorizeAudit(ctx context.Context) error {
+	principal, ok := PrincipalFromContext(ctx)
+
+	if !ok {
+		return errMissingPrincipal
+	}
+
+	if principal.HasRole(entity.RoleAdmin) || principal.HasRole(entity.RoleAuditor) {
+		return nil
+	}
+
+	return entity.PermissionDenied(principal.Subject, "audit log", "")
+}
+
+func authorizeFilter(ctx context.Context, filter *entity.TransactionFilter) error {
+	principal, ok := PrincipalFromContext(ctx)
+
+	if !ok {
+		return errMissingPrincipal
+	}
+
+	if principal.Privileged() {
+		return nil
+	}
+
+	if filter.AccountFromID != "" && principal.Owns(filter.AccountFromID) {
+		return nil
+	}
+
+	if filter.AccountToID != "" && principal.Owns(filter.AccountToID) {
+		return nil
+	}
+
+	return entity.PermissionDenied(principal.Subject, "payment export", "")
+}
@@ -0,0 +1,123 @@
+package controller_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/EdlanioJ/kbu/payments/domain/entity"
+	"github.com/EdlanioJ/kbu/payments/presentation/controller"
+	"github.com/EdlanioJ/kbu/payments/presentation/controller/mock"
+	uuid "github.com/satori/go.uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func customerContext(accounts ...string) context.Context {
+	return controller.WithPrincipal(context.TODO(), &entity.Principal{
+		Subject:  "customer",
+		Roles:    []string{entity.RoleCustomer},
+		Accounts: accounts,
+	})
+}
+
+// systemContext is the context of in-process callers, privileged and not
+// scoped to a tenant.
+func systemContext() context.Context {
+	return controller.WithSystemPrincipal(context.TODO())
+}
+
+func TestAuthorization(t *testing.T) {
+	t.Parallel()
+
+	t.Run("should refuse calls without a principal", func(t *testing.T) {
+		is := require.New(t)
+		transactionUseCase := mock.NewMockTransactionUseCase()
+
+		c := controller.NewTransaction(transactionUseCase)
+
+		result, err := c.Complete(context.TODO(), uuid.NewV4().String())
+		is.Nil(result)
+		is.True(entity.IsErrorKind(err, entity.ErrorUnauthenticated))
+
+		_, _, err = c.ListByAccountFrom(context.TODO(), uuid.NewV4().String(), 1, 10, "created_at")
+		is.True(entity.IsErrorKind(err, entity.ErrorUnauthenticated))
+
+		transactionUseCase.AssertNotCalled(t, "Complete")
+		transactionUseCase.AssertNotCalled(t, "FindAllByFromAccountID")
+	})
+
+	t.Run("should not register from an account the caller does not own", func(t *testing.T) {
+		is := require.New(t)
+		transactionUseCase := mock.NewMockTransactionUseCase()
+
+		accountFrom := uuid.NewV4().String()
+		accountTo := uuid.NewV4().String()
+		externalID := uuid.NewV4().String()
+
+		c := controller.NewTransaction(transactionUseCase)
+		result, err := c.Register(customerContext(accountTo), accountFrom, accountTo, externalID, entity.TransactionToUser, "AOA", 30)
+
+		transactionUseCase.AssertNotCalled(t, "Register")
+
+		is.Nil(result)
+		is.True(entity.IsErrorKind(err, entity.ErrorPermissionDenied))
+	})
+
+	t.Run("should register from an owned account", func(t *testing.T) {
+		is := require.New(t)
+		transactionUseCase := mock.NewMockTransactionUseCase()
+
+		accountFrom, _ := entity.NewAccount(3000)
+		accountTo, _ := entity.NewAccount(200)
+		externalID := uuid.NewV4().String()
+		transaction, _ := entity.NewTransaction(accountFrom, accountTo, externalID, entity.TransactionToUser, "AOA", 30)
+
+		transactionUseCase.On("Register", accountFrom.ID, accountTo.ID, externalID, entity.TransactionToUser, "AOA", 30.0).Return(transaction, nil)
+
+		c := controller.NewTransaction(transactionUseCase)
+		result, err := c.Register(customerContext(accountFrom.ID), accountFrom.ID, accountTo.ID, externalID, entity.TransactionToUser, "AOA", 30)
+
+		is.Nil(err)
+		is.Equal(transaction, result)
+	})
+
+	t.Run("should not show payments between other accounts", func(t *testing.T) {
+		is := require.New(t)
+		transactionUseCase := mock.NewMockTransactionUseCase()
+
+		accountFrom, _ := entity.NewAccount(3000)
+		accountTo, _ := entity.NewAccount(200)
+		transaction, _ := entity.NewTransaction(accountFrom, accountTo, uuid.NewV4().String(), entity.TransactionToUser, "AOA", 30)
+
+		transactionUseCase.On("Find", transaction.ID).Return(transaction, nil)
+
+		c := controller.NewTransaction(transactionUseCase)
+
+		result, err := c.Get(customerContext(uuid.NewV4().String()), transaction.ID)
+		is.Nil(result)
+		is.True(entity.IsErrorKind(err, entity.ErrorPermissionDenied))
+
+		result, err = c.Get(customerContext(accountTo.ID), transaction.ID)
+		is.Nil(err)
+		is.Equal(transaction, result)
+	})
+
+	t.Run("should let privileged roles bypass ownership", func(t *testing.T) {
+		is := require.New(t)
+		transactionUseCase := mock.NewMockTransactionUseCase()
+
+		accountID := uuid.NewV4().String()
+		transactionUseCase.On("FindAllByFromAccountID", accountID, 1, 10, "created_at").Return(nil, 0, nil)
+
+		c := controller.NewTransaction(transactionUseCase)
+
+		_, _, err := c.ListByAccountFrom(customerContext(), accountID, 1, 10, "created_at")
+		is.True(entity.IsErrorKind(err, entity.ErrorPermissionDenied))
+
+		for _, role := range []string{entity.RoleAdmin, entity.RoleService} {
+			ctx := controller.WithPrincipal(context.TODO(), &entity.Principal{Subject: role, Roles: []string{role}})
+
+			_, _, err = c.ListByAccountFrom(ctx, accountID, 1, 10, "created_at")
+			is.Nil(err)
+		}
+	})
+}
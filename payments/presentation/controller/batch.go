@@ -0,0 +1,111 @@
+package controller
+
+import (
+	"context"
+	"errors"
+
+	"github.com/EdlanioJ/kbu/payments/domain/entity"
+	"github.com/EdlanioJ/kbu/payments/domain/usecase"
+	"github.com/EdlanioJ/kbu/payments/presentation/validator"
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	errOnRegisterBatch = errors.New("an error on register payment batch")
+	errOnNotFoundBatch = errors.New("no payment batch was found")
+)
+
+type Batch struct {
+	Batch  usecase.Batch
+	logger *log.Logger
+}
+
+func NewBatch(batch usecase.Batch) *Batch {
+	logger := newLogger()
+
+	return &Batch{
+		Batch:  batch,
+		logger: logger,
+	}
+}
+
+func (c *Batch) Register(ctx context.Context, mode string, items []*entity.BatchItem) (*entity.Batch, []*entity.BatchItem, error) {
+	err := validator.RegisterBatchParams(mode, len(items))
+
+	if err == nil {
+		err = validator.IdempotencyKeyParams(IdempotencyKeyFromContext(ctx))
+	}
+
+	if err != nil {
+		c.logger.WithContext(ctx).Error(err)
+		return nil, nil, err
+	}
+
+	for _, item := range items {
+		err = validator.BatchItemParams(mode, item)
+
+		if err == nil {
+			err = authorizeAccounts(ctx, item.AccountFromID)
+		}
+
+		if err != nil {
+			item.Fail(err)
+		}
+	}
+
+	var batch *entity.Batch
+
+	if key := IdempotencyKeyFromContext(ctx); key != "" {
+		batch, items, err = c.Batch.RegisterIdempotent(ctx, key, mode, items)
+	} else {
+		batch, items, err = c.Batch.Register(ctx, mode, items)
+	}
+
+	if err != nil {
+		c.logger.
+			WithFields(log.Fields{
+				"mode":  mode,
+				"items": len(items),
+			}).WithContext(ctx).
+			WithError(err).
+			Error(errOnRegisterBatch)
+		return nil, nil, domainOr(err, errOnRegisterBatch)
+	}
+
+	return batch, items, nil
+}
+
+func (c *Batch) Get(ctx context.Context, batchID string) (*entity.Batch, []*entity.BatchItem, error) {
+	err := validator.GetParams(batchID)
+
+	if err != nil {
+		c.logger.WithContext(ctx).Error(err)
+		return nil, nil, err
+	}
+
+	batch, items, err := c.Batch.Find(ctx, batchID)
+
+	if err != nil {
+		c.logger.
+			WithField("batch_id", batchID).
+			WithContext(ctx).
+			WithError(err).
+			Error(errOnNotFoundBatch)
+		return nil, nil, domainOr(err, errOnNotFoundBatch)
+	}
+
+	accounts := make([]string, 0, len(items))
+
+	for _, item := range items {
+		accounts = append(accounts, item.AccountFromID)
+	}
+
+	err = authorizeAccounts(ctx, accounts...)
+
+	if err != nil {
+		c.logger.WithContext(ctx).WithError(err).Warn("caller is not authorized")
+		return nil, nil, err
+	}
+
+	return batch, items, nil
+}
@@ -0,0 +1,122 @@
+package controller_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/EdlanioJ/kbu/payments/domain/entity"
+	"github.com/EdlanioJ/kbu/payments/presentation/controller"
+	"github.com/EdlanioJ/kbu/payments/presentation/controller/mock"
+	uuid "github.com/satori/go.uuid"
+	tMock "github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func newBatchItem(position int, transactionType string, amount float64) *entity.BatchItem {
+	return entity.NewBatchItem(position, uuid.NewV4().String(), uuid.NewV4().String(), uuid.NewV4().String(), transactionType, "AOA", amount)
+}
+
+func TestRegisterBatch(t *testing.T) {
+	t.Parallel()
+
+	t.Run("should fail on validation", func(t *testing.T) {
+		is := require.New(t)
+		c := controller.NewBatch(nil)
+
+		batch, items, err := c.Register(systemContext(), "eventually", []*entity.BatchItem{newBatchItem(0, entity.TransactionToUser, 10)})
+		is.Nil(batch)
+		is.Nil(items)
+		is.Error(err)
+
+		_, _, err = c.Register(systemContext(), entity.BatchAtomic, nil)
+		is.Error(err)
+	})
+
+	t.Run("should mark invalid items as failed", func(t *testing.T) {
+		is := require.New(t)
+		batchUseCase := mock.NewMockBatchUseCase()
+		batch, _ := entity.NewBatch(entity.BatchBestEffort)
+
+		items := []*entity.BatchItem{
+			newBatchItem(0, entity.TransactionToUser, 10),
+			newBatchItem(1, entity.TransactionToService, 10),
+			newBatchItem(2, entity.TransactionToStore, 0),
+		}
+
+		batchUseCase.On("Register", entity.BatchBestEffort, items).Return(batch, items, nil)
+		c := controller.NewBatch(batchUseCase)
+
+		_, result, err := c.Register(systemContext(), entity.BatchBestEffort, items)
+
+		is.Nil(err)
+		is.Equal(entity.BatchItemPending, result[0].Status)
+		is.Equal(entity.BatchItemPending, result[1].Status)
+		is.Equal(entity.BatchItemFailed, result[2].Status)
+	})
+
+	t.Run("should reject service payments in an atomic batch", func(t *testing.T) {
+		is := require.New(t)
+		batchUseCase := mock.NewMockBatchUseCase()
+		batch, _ := entity.NewBatch(entity.BatchAtomic)
+
+		items := []*entity.BatchItem{
+			newBatchItem(0, entity.TransactionToUser, 10),
+			newBatchItem(1, entity.TransactionToService, 10),
+		}
+
+		batchUseCase.On("Register", entity.BatchAtomic, items).Return(batch, items, nil)
+		c := controller.NewBatch(batchUseCase)
+
+		_, result, err := c.Register(systemContext(), entity.BatchAtomic, items)
+
+		is.Nil(err)
+		is.Equal(entity.BatchItemPending, result[0].Status)
+		is.Equal(entity.BatchItemFailed, result[1].Status)
+		is.Contains(result[1].Error, "service payments cannot be registered in an atomic batch")
+	})
+
+	t.Run("should fail on register", func(t *testing.T) {
+		is := require.New(t)
+		batchUseCase := mock.NewMockBatchUseCase()
+
+		batchUseCase.On("Register", entity.BatchAtomic, tMock.Anything).Return(nil, nil, errors.New("db error"))
+		c := controller.NewBatch(batchUseCase)
+
+		_, _, err := c.Register(systemContext(), entity.BatchAtomic, []*entity.BatchItem{newBatchItem(0, entity.TransactionToUser, 10)})
+
+		is.EqualError(err, "an error on register payment batch")
+	})
+
+	t.Run("should register once per idempotency key", func(t *testing.T) {
+		is := require.New(t)
+		batchUseCase := mock.NewMockBatchUseCase()
+		batch, _ := entity.NewBatch(entity.BatchAtomic)
+		items := []*entity.BatchItem{newBatchItem(0, entity.TransactionToUser, 10)}
+
+		batchUseCase.On("RegisterIdempotent", "key-1", entity.BatchAtomic, items).Return(batch, items, nil)
+		c := controller.NewBatch(batchUseCase)
+
+		result, _, err := c.Register(controller.WithIdempotencyKey(systemContext(), "key-1"), entity.BatchAtomic, items)
+
+		is.Nil(err)
+		is.Equal(batch, result)
+		batchUseCase.AssertNotCalled(t, "Register", entity.BatchAtomic, items)
+	})
+}
+
+func TestGetBatch(t *testing.T) {
+	t.Parallel()
+
+	t.Run("should fail when the batch does not exist", func(t *testing.T) {
+		is := require.New(t)
+		batchUseCase := mock.NewMockBatchUseCase()
+		id := uuid.NewV4().String()
+
+		batchUseCase.On("Find", id).Return(nil, nil, errors.New("record not found"))
+		c := controller.NewBatch(batchUseCase)
+
+		_, _, err := c.Get(systemContext(), id)
+
+		is.EqualError(err, "no payment batch was found")
+	})
+}
@@ -0,0 +1,11 @@
+package controller
+
+import "github.com/EdlanioJ/kbu/payments/domain/entity"
+
+func domainOr(err, fallback error) error {
+	if domainError, ok := entity.AsDomainError(err); ok {
+		return domainError
+	}
+
+	return fallback
+}
@@ -0,0 +1,51 @@
+package controller
+
+import (
+	"github.com/grpc-ecosystem/go-grpc-middleware/logging/logrus/ctxlogrus"
+	log "github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type requestFieldsHook struct{}
+
+func (requestFieldsHook) Levels() []log.Level {
+	return log.AllLevels
+}
+
+func (requestFieldsHook) Fire(entry *log.Entry) error {
+	if entry.Context == nil {
+		return nil
+	}
+
+	for key, value := range ctxlogrus.Extract(entry.Context).Data {
+		if _, ok := entry.Data[key]; !ok {
+			entry.Data[key] = value
+		}
+	}
+
+	span := trace.SpanFromContext(entry.Context)
+
+	if spanContext := span.SpanContext(); spanContext.IsValid() {
+		entry.Data["trace_id"] = spanContext.TraceID().String()
+		entry.Data["span_id"] = spanContext.SpanID().String()
+	}
+
+	if entry.Level <= log.ErrorLevel {
+		if err, ok := entry.Data[log.ErrorKey].(error); ok {
+			span.RecordError(err)
+		}
+
+		span.SetStatus(codes.Error, entry.Message)
+	}
+
+	return nil
+}
+
+func newLogger() *log.Logger {
+	logger := log.New()
+	logger.SetFormatter(&log.JSONFormatter{})
+	logger.AddHook(requestFieldsHook{})
+
+	return logger
+}
@@ -0,0 +1,26 @@
+package mock
+
+import (
+	"context"
+	"github.com/EdlanioJ/kbu/payments/domain/entity"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockAuditUseCase struct {
+	mock.Mock
+}
+
+func NewMockAuditUseCase() *MockAuditUseCase {
+	return &MockAuditUseCase{}
+}
+
+func (m *MockAuditUseCase) FindAll(ctx context.Context, filter *entity.AuditFilter, page, limit int) ([]*entity.AuditEntry, int, error) {
+	args := m.Called(filter, page, limit)
+
+	var r0 []*entity.AuditEntry
+	if args.Get(0) != nil {
+		r0 = args.Get(0).([]*entity.AuditEntry)
+	}
+
+	return r0, args.Int(1), args.Error(2)
+}
@@ -0,0 +1,63 @@
+package mock
+
+import (
+	"context"
+	"github.com/EdlanioJ/kbu/payments/domain/entity"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockBatchUseCase struct {
+	mock.Mock
+}
+
+func NewMockBatchUseCase() *MockBatchUseCase {
+	return &MockBatchUseCase{}
+}
+
+func (m *MockBatchUseCase) Register(ctx context.Context, mode string, items []*entity.BatchItem) (*entity.Batch, []*entity.BatchItem, error) {
+	args := m.Called(mode, items)
+
+	var r0 *entity.Batch
+	if args.Get(0) != nil {
+		r0 = args.Get(0).(*entity.Batch)
+	}
+
+	var r1 []*entity.BatchItem
+	if args.Get(1) != nil {
+		r1 = args.Get(1).([]*entity.BatchItem)
+	}
+
+	return r0, r1, args.Error(2)
+}
+
+func (m *MockBatchUseCase) RegisterIdempotent(ctx context.Context, key, mode string, items []*entity.BatchItem) (*entity.Batch, []*entity.BatchItem, error) {
+	args := m.Called(key, mode, items)
+
+	var r0 *entity.Batch
+	if args.Get(0) != nil {
+		r0 = args.Get(0).(*entity.Batch)
+	}
+
+	var r1 []*entity.BatchItem
+	if args.Get(1) != nil {
+		r1 = args.Get(1).([]*entity.BatchItem)
+	}
+
+	return r0, r1, args.Error(2)
+}
+
+func (m *MockBatchUseCase) Find(ctx context.Context, batchID string) (*entity.Batch, []*entity.BatchItem, error) {
+	args := m.Called(batchID)
+
+	var r0 *entity.Batch
+	if args.Get(0) != nil {
+		r0 = args.Get(0).(*entity.Batch)
+	}
+
+	var r1 []*entity.BatchItem
+	if args.Get(1) != nil {
+		r1 = args.Get(1).([]*entity.BatchItem)
+	}
+
+	return r0, r1, args.Error(2)
+}
@@ -0,0 +1,65 @@
+package mock
+
+import (
+	"context"
+	"github.com/EdlanioJ/kbu/payments/domain/entity"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockSagaUseCase struct {
+	mock.Mock
+}
+
+func NewMockSagaUseCase() *MockSagaUseCase {
+	return &MockSagaUseCase{}
+}
+
+func (m *MockSagaUseCase) Start(ctx context.Context, fromAccount, toAccount, externalID, currency string, amount float64) (*entity.Saga, error) {
+	args := m.Called(fromAccount, toAccount, externalID, currency, amount)
+
+	var r0 *entity.Saga
+	if args.Get(0) != nil {
+		r0 = args.Get(0).(*entity.Saga)
+	}
+
+	return r0, args.Error(1)
+}
+
+func (m *MockSagaUseCase) StartIdempotent(ctx context.Context, key, fromAccount, toAccount, externalID, currency string, amount float64) (*entity.Saga, error) {
+	args := m.Called(key, fromAccount, toAccount, externalID, currency, amount)
+
+	var r0 *entity.Saga
+	if args.Get(0) != nil {
+		r0 = args.Get(0).(*entity.Saga)
+	}
+
+	return r0, args.Error(1)
+}
+
+func (m *MockSagaUseCase) Resume(ctx context.Context, sagaID string) (*entity.Saga, error) {
+	args := m.Called(sagaID)
+
+	var r0 *entity.Saga
+	if args.Get(0) != nil {
+		r0 = args.Get(0).(*entity.Saga)
+	}
+
+	return r0, args.Error(1)
+}
+
+func (m *MockSagaUseCase) Recover(ctx context.Context, limit int) (int, error) {
+	args := m.Called(limit)
+
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockSagaUseCase) Find(ctx context.Context, sagaID string) (*entity.Saga, error) {
+	args := m.Called(sagaID)
+
+	var r0 *entity.Saga
+	if args.Get(0) != nil {
+		r0 = args.Get(0).(*entity.Saga)
+	}
+
+	return r0, args.Error(1)
+}
@@ -1,6 +1,7 @@
 package mock
 
 import (
+	"context"
 	"github.com/EdlanioJ/kbu/payments/domain/entity"
 	"github.com/stretchr/testify/mock"
 )
@@ -12,7 +13,7 @@ type MockTransactionUseCase struct {
 func NewMockTransactionUseCase() *MockTransactionUseCase {
 	return &MockTransactionUseCase{}
 }
-func (m *MockTransactionUseCase) Register(fromAccount, toAccount, externalID, typeTransaction, currency string, amount float64) (*entity.Transaction, error) {
+func (m *MockTransactionUseCase) Register(ctx context.Context, fromAccount, toAccount, externalID, typeTransaction, currency string, amount float64) (*entity.Transaction, error) {
 	args := m.Called(fromAccount, toAccount, externalID, typeTransaction, currency, amount)
 
 	var r0 *entity.Transaction
@@ -34,7 +35,29 @@ func (m *MockTransactionUseCase) Register(fromAccount, toAccount, externalID, ty
 	return r0, r1
 }
 
-func (m *MockTransactionUseCase) Find(id string) (*entity.Transaction, error) {
+func (m *MockTransactionUseCase) RegisterIdempotent(ctx context.Context, idempotencyKey, fromAccount, toAccount, externalID, typeTransaction, currency string, amount float64) (*entity.Transaction, error) {
+	args := m.Called(idempotencyKey, fromAccount, toAccount, externalID, typeTransaction, currency, amount)
+
+	var r0 *entity.Transaction
+	if rf, ok := args.Get(0).(func() *entity.Transaction); ok {
+		r0 = rf()
+	} else {
+		if args.Get(0) != nil {
+			r0 = args.Get(0).(*entity.Transaction)
+		}
+	}
+
+	var r1 error
+	if rf, ok := args.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = args.Error(1)
+	}
+
+	return r0, r1
+}
+
+func (m *MockTransactionUseCase) Find(ctx context.Context, id string) (*entity.Transaction, error) {
 	args := m.Called(id)
 
 	var r0 *entity.Transaction
@@ -56,7 +79,7 @@ func (m *MockTransactionUseCase) Find(id string) (*entity.Transaction, error) {
 	return r0, r1
 }
 
-func (m *MockTransactionUseCase) FindAll(page int, limit int, sort string) ([]*entity.Transaction, int, error) {
+func (m *MockTransactionUseCase) FindAll(ctx context.Context, page int, limit int, sort string) ([]*entity.Transaction, int, error) {
 	args := m.Called(page, limit, sort)
 
 	res0 := []*entity.Transaction{}
@@ -86,7 +109,7 @@ func (m *MockTransactionUseCase) FindAll(page int, limit int, sort string) ([]*e
 	return res0, res1, res2
 }
 
-func (m *MockTransactionUseCase) FindByType(typeTransaction, transactionID string) (*entity.Transaction, error) {
+func (m *MockTransactionUseCase) FindByType(ctx context.Context, typeTransaction, transactionID string) (*entity.Transaction, error) {
 	args := m.Called(typeTransaction, transactionID)
 	var r0 *entity.Transaction
 
@@ -108,7 +131,7 @@ func (m *MockTransactionUseCase) FindByType(typeTransaction, transactionID strin
 	return r0, r1
 }
 
-func (m *MockTransactionUseCase) FindAllByType(typeTransaction string, page int, limit int, sort string) ([]*entity.Transaction, int, error) {
+func (m *MockTransactionUseCase) FindAllByType(ctx context.Context, typeTransaction string, page int, limit int, sort string) ([]*entity.Transaction, int, error) {
 	args := m.Called(typeTransaction, page, limit, sort)
 
 	res0 := []*entity.Transaction{}
@@ -136,7 +159,7 @@ func (m *MockTransactionUseCase) FindAllByType(typeTransaction string, page int,
 
 	return res0, res1, res2
 }
-func (m *MockTransactionUseCase) FindByExternalID(externalID, transactionID string) (*entity.Transaction, error) {
+func (m *MockTransactionUseCase) FindByExternalID(ctx context.Context, externalID, transactionID string) (*entity.Transaction, error) {
 	args := m.Called(externalID, transactionID)
 	var r0 *entity.Transaction
 
@@ -157,7 +180,7 @@ func (m *MockTransactionUseCase) FindByExternalID(externalID, transactionID stri
 
 	return r0, r1
 }
-func (m *MockTransactionUseCase) FindAllByExternalID(externalID string, page int, limit int, sort string) ([]*entity.Transaction, int, error) {
+func (m *MockTransactionUseCase) FindAllByExternalID(ctx context.Context, externalID string, page int, limit int, sort string) ([]*entity.Transaction, int, error) {
 	args := m.Called(externalID, page, limit, sort)
 
 	res0 := []*entity.Transaction{}
@@ -185,7 +208,7 @@ func (m *MockTransactionUseCase) FindAllByExternalID(externalID string, page int
 
 	return res0, res1, res2
 }
-func (m *MockTransactionUseCase) FindAllByFromAccountID(accountID string, page int, limit int, sort string) ([]*entity.Transaction, int, error) {
+func (m *MockTransactionUseCase) FindAllByFromAccountID(ctx context.Context, accountID string, page int, limit int, sort string) ([]*entity.Transaction, int, error) {
 	args := m.Called(accountID, page, limit, sort)
 
 	res0 := []*entity.Transaction{}
@@ -214,7 +237,7 @@ func (m *MockTransactionUseCase) FindAllByFromAccountID(accountID string, page i
 	return res0, res1, res2
 }
 
-func (m *MockTransactionUseCase) FindByFromAccountID(accountID, transactionID string) (*entity.Transaction, error) {
+func (m *MockTransactionUseCase) FindByFromAccountID(ctx context.Context, accountID, transactionID string) (*entity.Transaction, error) {
 	args := m.Called(accountID, transactionID)
 	var r0 *entity.Transaction
 
@@ -235,7 +258,7 @@ func (m *MockTransactionUseCase) FindByFromAccountID(accountID, transactionID st
 
 	return r0, r1
 }
-func (m *MockTransactionUseCase) FindAllByToAccountID(accountID string, page int, limit int, sort string) ([]*entity.Transaction, int, error) {
+func (m *MockTransactionUseCase) FindAllByToAccountID(ctx context.Context, accountID string, page int, limit int, sort string) ([]*entity.Transaction, int, error) {
 	args := m.Called(accountID, page, limit, sort)
 
 	res0 := []*entity.Transaction{}
@@ -263,7 +286,7 @@ func (m *MockTransactionUseCase) FindAllByToAccountID(accountID string, page int
 
 	return res0, res1, res2
 }
-func (m *MockTransactionUseCase) FindByToAccountID(accountID, transactionID string) (*entity.Transaction, error) {
+func (m *MockTransactionUseCase) FindByToAccountID(ctx context.Context, accountID, transactionID string) (*entity.Transaction, error) {
 	args := m.Called(accountID, transactionID)
 	var r0 *entity.Transaction
 
@@ -285,7 +308,7 @@ func (m *MockTransactionUseCase) FindByToAccountID(accountID, transactionID stri
 	return r0, r1
 }
 
-func (m *MockTransactionUseCase) Complete(transactionId string) (*entity.Transaction, error) {
+func (m *MockTransactionUseCase) Complete(ctx context.Context, transactionId string) (*entity.Transaction, error) {
 	args := m.Called(transactionId)
 
 	var res0 *entity.Transaction
@@ -307,7 +330,7 @@ func (m *MockTransactionUseCase) Complete(transactionId string) (*entity.Transac
 	return res0, res1
 }
 
-func (m *MockTransactionUseCase) Error(transactionId string) (*entity.Transaction, error) {
+func (m *MockTransactionUseCase) Error(ctx context.Context, transactionId string) (*entity.Transaction, error) {
 	args := m.Called(transactionId)
 
 	var res0 *entity.Transaction
@@ -328,3 +351,17 @@ func (m *MockTransactionUseCase) Error(transactionId string) (*entity.Transactio
 
 	return res0, res1
 }
+
+func (m *MockTransactionUseCase) Export(ctx context.Context, filter *entity.TransactionFilter, resumeToken string, fn func(transaction *entity.Transaction, resumeToken string) error) error {
+	args := m.Called(filter, resumeToken)
+
+	if transactions, ok := args.Get(0).([]*entity.Transaction); ok {
+		for _, transaction := range transactions {
+			if err := fn(transaction, transaction.ID); err != nil {
+				return err
+			}
+		}
+	}
+
+	return args.Error(1)
+}
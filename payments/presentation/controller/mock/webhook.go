@@ -0,0 +1,81 @@
+package mock
+
+import (
+	"context"
+	"github.com/EdlanioJ/kbu/payments/domain/entity"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockWebhookUseCase struct {
+	mock.Mock
+}
+
+func NewMockWebhookUseCase() *MockWebhookUseCase {
+	return &MockWebhookUseCase{}
+}
+
+func (m *MockWebhookUseCase) Register(ctx context.Context, accountID, url string) (*entity.Webhook, error) {
+	args := m.Called(accountID, url)
+
+	var r0 *entity.Webhook
+	if args.Get(0) != nil {
+		r0 = args.Get(0).(*entity.Webhook)
+	}
+
+	return r0, args.Error(1)
+}
+
+func (m *MockWebhookUseCase) Notify(ctx context.Context, event string, transaction *entity.Transaction) ([]*entity.WebhookDelivery, error) {
+	args := m.Called(event, transaction)
+
+	var r0 []*entity.WebhookDelivery
+	if args.Get(0) != nil {
+		r0 = args.Get(0).([]*entity.WebhookDelivery)
+	}
+
+	return r0, args.Error(1)
+}
+
+func (m *MockWebhookUseCase) Deliver(ctx context.Context, deliveryID string) (*entity.WebhookDelivery, error) {
+	args := m.Called(deliveryID)
+
+	var r0 *entity.WebhookDelivery
+	if args.Get(0) != nil {
+		r0 = args.Get(0).(*entity.WebhookDelivery)
+	}
+
+	return r0, args.Error(1)
+}
+
+func (m *MockWebhookUseCase) DeliverDue(ctx context.Context, limit int) (int, error) {
+	args := m.Called(limit)
+
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockWebhookUseCase) Redeliver(ctx context.Context, deliveryID string) (*entity.WebhookDelivery, error) {
+	args := m.Called(deliveryID)
+
+	var r0 *entity.WebhookDelivery
+	if args.Get(0) != nil {
+		r0 = args.Get(0).(*entity.WebhookDelivery)
+	}
+
+	return r0, args.Error(1)
+}
+
+func (m *MockWebhookUseCase) FindDelivery(ctx context.Context, deliveryID string) (*entity.WebhookDelivery, []*entity.WebhookAttempt, error) {
+	args := m.Called(deliveryID)
+
+	var r0 *entity.WebhookDelivery
+	if args.Get(0) != nil {
+		r0 = args.Get(0).(*entity.WebhookDelivery)
+	}
+
+	var r1 []*entity.WebhookAttempt
+	if args.Get(1) != nil {
+		r1 = args.Get(1).([]*entity.WebhookAttempt)
+	}
+
+	return r0, r1, args.Error(2)
+}
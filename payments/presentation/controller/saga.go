@@ -0,0 +1,137 @@
+package controller
+
+import (
+	"context"
+	"errors"
+
+	"github.com/EdlanioJ/kbu/payments/domain/entity"
+	"github.com/EdlanioJ/kbu/payments/domain/usecase"
+	"github.com/EdlanioJ/kbu/payments/presentation/validator"
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	errOnStartSaga    = errors.New("an error on process service payment")
+	errOnNotFoundSaga = errors.New("no service payment was found")
+	errOnRecoverSagas = errors.New("an error on recover service payments")
+)
+
+type Saga struct {
+	Saga   usecase.Saga
+	logger *log.Logger
+}
+
+func NewSaga(saga usecase.Saga) *Saga {
+	logger := newLogger()
+
+	return &Saga{
+		Saga:   saga,
+		logger: logger,
+	}
+}
+
+func (c *Saga) Start(ctx context.Context, accountFrom, accountTo, externalID, currency string, amount float64) (*entity.Saga, error) {
+	err := validator.RegisterParams(accountFrom, accountTo, externalID, entity.TransactionToService, currency, amount)
+
+	if err == nil {
+		err = validator.IdempotencyKeyParams(IdempotencyKeyFromContext(ctx))
+	}
+
+	if err != nil {
+		c.logger.WithContext(ctx).Error(err)
+		return nil, err
+	}
+
+	err = authorizeAccounts(ctx, accountFrom)
+
+	if err != nil {
+		c.logger.WithContext(ctx).WithError(err).Warn("caller is not authorized")
+		return nil, err
+	}
+
+	var saga *entity.Saga
+
+	if key := IdempotencyKeyFromContext(ctx); key != "" {
+		saga, err = c.Saga.StartIdempotent(ctx, key, accountFrom, accountTo, externalID, currency, amount)
+	} else {
+		saga, err = c.Saga.Start(ctx, accountFrom, accountTo, externalID, currency, amount)
+	}
+
+	if err != nil {
+		c.logger.
+			WithFields(log.Fields{
+				"from_account_id": accountFrom,
+				"to_account_id":   accountTo,
+				"reference_id":    externalID,
+				"currency":        currency,
+				"amount":          amount,
+			}).WithContext(ctx).
+			WithError(err).
+			Error(errOnStartSaga)
+
+		return nil, domainOr(err, errOnStartSaga)
+	}
+
+	if saga.Status == entity.SagaCompensated {
+		c.logger.
+			WithFields(log.Fields{
+				"saga_id":        saga.ID,
+				"transaction_id": saga.TransactionID,
+				"step":           saga.Step,
+			}).WithContext(ctx).
+			Warn(saga.Error)
+	}
+
+	return saga, nil
+}
+
+func (c *Saga) Get(ctx context.Context, id string) (*entity.Saga, error) {
+	err := validator.GetParams(id)
+
+	if err != nil {
+		c.logger.WithContext(ctx).Error(err)
+		return nil, err
+	}
+
+	saga, err := c.Saga.Find(ctx, id)
+
+	if err != nil {
+		c.logger.
+			WithField("saga_id", id).
+			WithContext(ctx).
+			WithError(err).
+			Error(errOnNotFoundSaga)
+		return nil, domainOr(err, errOnNotFoundSaga)
+	}
+
+	err = authorizeAccounts(ctx, saga.AccountFromID)
+
+	if err != nil {
+		c.logger.WithContext(ctx).WithError(err).Warn("caller is not authorized")
+		return nil, err
+	}
+
+	return saga, nil
+}
+
+func (c *Saga) Recover(ctx context.Context, limit int) (int, error) {
+	err := authorizePrivileged(ctx, "service payment")
+
+	if err != nil {
+		c.logger.WithContext(ctx).WithError(err).Warn("caller is not authorized")
+		return 0, err
+	}
+
+	recovered, err := c.Saga.Recover(ctx, limit)
+
+	if err != nil {
+		c.logger.
+			WithField("limit", limit).
+			WithContext(ctx).
+			WithError(err).
+			Error(errOnRecoverSagas)
+		return 0, domainOr(err, errOnRecoverSagas)
+	}
+
+	return recovered, nil
+}
@@ -0,0 +1,107 @@
+package controller_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/EdlanioJ/kbu/payments/domain/entity"
+	"github.com/EdlanioJ/kbu/payments/presentation/controller"
+	"github.com/EdlanioJ/kbu/payments/presentation/controller/mock"
+	uuid "github.com/satori/go.uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStartSaga(t *testing.T) {
+	t.Parallel()
+
+	t.Run("should fail on validation", func(t *testing.T) {
+		is := require.New(t)
+
+		c := controller.NewSaga(nil)
+
+		result, err := c.Start(systemContext(), "invalid", uuid.NewV4().String(), uuid.NewV4().String(), "AOA", 100)
+
+		is.Nil(result)
+		is.Error(err)
+	})
+
+	t.Run("should fail on start", func(t *testing.T) {
+		is := require.New(t)
+		sagaUseCase := mock.NewMockSagaUseCase()
+
+		from, to, externalID := uuid.NewV4().String(), uuid.NewV4().String(), uuid.NewV4().String()
+
+		sagaUseCase.On("Start", from, to, externalID, "AOA", 100.0).Return(nil, errors.New("db error"))
+		c := controller.NewSaga(sagaUseCase)
+
+		result, err := c.Start(systemContext(), from, to, externalID, "AOA", 100)
+
+		is.Nil(result)
+		is.EqualError(err, "an error on process service payment")
+	})
+
+	t.Run("should succeed", func(t *testing.T) {
+		is := require.New(t)
+		sagaUseCase := mock.NewMockSagaUseCase()
+
+		from, to, externalID := uuid.NewV4().String(), uuid.NewV4().String(), uuid.NewV4().String()
+		saga, _ := entity.NewSaga(from, to, externalID, "AOA", 100, time.Minute)
+		saga.Status = entity.SagaCompleted
+
+		sagaUseCase.On("Start", from, to, externalID, "AOA", 100.0).Return(saga, nil)
+		c := controller.NewSaga(sagaUseCase)
+
+		result, err := c.Start(systemContext(), from, to, externalID, "AOA", 100)
+
+		is.Nil(err)
+		is.Equal(saga, result)
+	})
+
+	t.Run("should start once per idempotency key", func(t *testing.T) {
+		is := require.New(t)
+		sagaUseCase := mock.NewMockSagaUseCase()
+
+		from, to, externalID := uuid.NewV4().String(), uuid.NewV4().String(), uuid.NewV4().String()
+		saga, _ := entity.NewSaga(from, to, externalID, "AOA", 100, time.Minute)
+
+		sagaUseCase.On("StartIdempotent", "key-1", from, to, externalID, "AOA", 100.0).Return(saga, nil)
+		c := controller.NewSaga(sagaUseCase)
+
+		result, err := c.Start(controller.WithIdempotencyKey(systemContext(), "key-1"), from, to, externalID, "AOA", 100)
+
+		is.Nil(err)
+		is.Equal(saga, result)
+		sagaUseCase.AssertNotCalled(t, "Start", from, to, externalID, "AOA", 100.0)
+	})
+}
+
+func TestRecoverSagas(t *testing.T) {
+	t.Parallel()
+
+	t.Run("should fail on recover", func(t *testing.T) {
+		is := require.New(t)
+		sagaUseCase := mock.NewMockSagaUseCase()
+
+		sagaUseCase.On("Recover", 20).Return(0, errors.New("db error"))
+		c := controller.NewSaga(sagaUseCase)
+
+		recovered, err := c.Recover(systemContext(), 20)
+
+		is.Equal(0, recovered)
+		is.EqualError(err, "an error on recover service payments")
+	})
+
+	t.Run("should succeed", func(t *testing.T) {
+		is := require.New(t)
+		sagaUseCase := mock.NewMockSagaUseCase()
+
+		sagaUseCase.On("Recover", 20).Return(3, nil)
+		c := controller.NewSaga(sagaUseCase)
+
+		recovered, err := c.Recover(systemContext(), 20)
+
+		is.Nil(err)
+		is.Equal(3, recovered)
+	})
+}
@@ -0,0 +1,5 @@
+package controller
+
+import "go.opentelemetry.io/otel"
+
+var tracer = otel.Tracer("github.com/EdlanioJ/kbu/payments/presentation/controller")
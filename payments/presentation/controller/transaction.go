@@ -20,6 +20,7 @@ var (
 	errOnListByAccountTo     = errors.New("an error on list payments by account destination")
 	errOnCompeteTransaction  = errors.New("error on complete payment")
 	errOnCancelTransaction   = errors.New("error on cancel payment")
+	errOnExportTransactions  = errors.New("an error on export payments")
 )
 
 type Transaction struct {
@@ -28,8 +29,7 @@ type Transaction struct {
 }
 
 func NewTransaction(transaction usecase.Transaction) *Transaction {
-	logger := log.New()
-	logger.SetFormatter(&log.JSONFormatter{})
+	logger := newLogger()
 
 	return &Transaction{
 		Transaction: transaction,
@@ -38,13 +38,34 @@ func NewTransaction(transaction usecase.Transaction) *Transaction {
 }
 
 func (c *Transaction) Register(ctx context.Context, accountFrom, accountTo, externalID, transactionType, currency string, amount float64) (*entity.Transaction, error) {
+	ctx, span := tracer.Start(ctx, "controller.Transaction.Register")
+	defer span.End()
+
 	err := validator.RegisterParams(accountFrom, accountTo, externalID, transactionType, currency, amount)
 
+	if err == nil {
+		err = validator.IdempotencyKeyParams(IdempotencyKeyFromContext(ctx))
+	}
+
 	if err != nil {
 		c.logger.WithContext(ctx).Error(err)
 		return nil, err
 	}
-	transaction, err := c.Transaction.Register(accountFrom, accountTo, externalID, transactionType, currency, amount)
+
+	err = authorizeAccounts(ctx, accountFrom)
+
+	if err != nil {
+		c.logger.WithContext(ctx).WithError(err).Warn("caller is not authorized")
+		return nil, err
+	}
+
+	var transaction *entity.Transaction
+
+	if key := IdempotencyKeyFromContext(ctx); key != "" {
+		transaction, err = c.Transaction.RegisterIdempotent(ctx, key, accountFrom, accountTo, externalID, transactionType, currency, amount)
+	} else {
+		transaction, err = c.Transaction.Register(ctx, accountFrom, accountTo, externalID, transactionType, currency, amount)
+	}
 
 	if err != nil {
 		c.logger.
@@ -59,13 +80,16 @@ func (c *Transaction) Register(ctx context.Context, accountFrom, accountTo, exte
 			WithError(err).
 			Error(errOnRegister)
 
-		return nil, errOnRegister
+		return nil, domainOr(err, errOnRegister)
 	}
 
 	return transaction, nil
 }
 
 func (c *Transaction) Get(ctx context.Context, id string) (*entity.Transaction, error) {
+	ctx, span := tracer.Start(ctx, "controller.Transaction.Get")
+	defer span.End()
+
 	err := validator.GetParams(id)
 
 	if err != nil {
@@ -73,7 +97,7 @@ func (c *Transaction) Get(ctx context.Context, id string) (*entity.Transaction,
 		return nil, err
 	}
 
-	transaction, err := c.Transaction.Find(id)
+	transaction, err := c.Transaction.Find(ctx, id)
 
 	if err != nil {
 		c.logger.
@@ -81,20 +105,38 @@ func (c *Transaction) Get(ctx context.Context, id string) (*entity.Transaction,
 			WithContext(ctx).
 			WithError(err).
 			Error(errOnNotFoundTransaction)
-		return nil, errOnNotFoundTransaction
+		return nil, domainOr(err, errOnNotFoundTransaction)
+	}
+
+	err = authorizeTransaction(ctx, transaction)
+
+	if err != nil {
+		c.logger.WithContext(ctx).WithError(err).Warn("caller is not authorized")
+		return nil, err
 	}
 
 	return transaction, nil
 }
 
 func (c *Transaction) List(ctx context.Context, page int, limit int, sort string) ([]*entity.Transaction, int, error) {
+	ctx, span := tracer.Start(ctx, "controller.Transaction.List")
+	defer span.End()
+
 	err := validator.GetAllParams(page, limit, sort)
 
 	if err != nil {
 		c.logger.WithContext(ctx).Error(err)
 		return nil, 0, err
 	}
-	transactions, total, err := c.Transaction.FindAll(page, limit, sort)
+
+	err = authorizePrivileged(ctx, "payment list")
+
+	if err != nil {
+		c.logger.WithContext(ctx).WithError(err).Warn("caller is not authorized")
+		return nil, 0, err
+	}
+
+	transactions, total, err := c.Transaction.FindAll(ctx, page, limit, sort)
 
 	if err != nil {
 		c.logger.
@@ -107,7 +149,7 @@ func (c *Transaction) List(ctx context.Context, page int, limit int, sort string
 			).WithContext(ctx).
 			WithError(err).
 			Error(errOnFindAllTransaction)
-		return nil, 0, errOnFindAllTransaction
+		return nil, 0, domainOr(err, errOnFindAllTransaction)
 	}
 
 	if len(transactions) == 0 {
@@ -125,6 +167,9 @@ func (c *Transaction) List(ctx context.Context, page int, limit int, sort string
 }
 
 func (c *Transaction) GetByType(ctx context.Context, transactionID, transactionType string) (*entity.Transaction, error) {
+	ctx, span := tracer.Start(ctx, "controller.Transaction.GetByType")
+	defer span.End()
+
 	err := validator.GetByTypeParams(transactionID, transactionType)
 
 	if err != nil {
@@ -132,7 +177,7 @@ func (c *Transaction) GetByType(ctx context.Context, transactionID, transactionT
 		return nil, err
 	}
 
-	transaction, err := c.Transaction.FindByType(transactionType, transactionID)
+	transaction, err := c.Transaction.FindByType(ctx, transactionType, transactionID)
 
 	if err != nil {
 		c.logger.
@@ -144,13 +189,23 @@ func (c *Transaction) GetByType(ctx context.Context, transactionID, transactionT
 			).WithContext(ctx).
 			WithError(err).
 			Error(errOnNotFoundTransaction)
-		return nil, errOnNotFoundTransaction
+		return nil, domainOr(err, errOnNotFoundTransaction)
+	}
+
+	err = authorizeTransaction(ctx, transaction)
+
+	if err != nil {
+		c.logger.WithContext(ctx).WithError(err).Warn("caller is not authorized")
+		return nil, err
 	}
 
 	return transaction, nil
 }
 
 func (c *Transaction) ListByType(ctx context.Context, transactionType string, page, limit int, sort string) ([]*entity.Transaction, int, error) {
+	ctx, span := tracer.Start(ctx, "controller.Transaction.ListByType")
+	defer span.End()
+
 	err := validator.ListByTypeParams(transactionType, page, limit, sort)
 
 	if err != nil {
@@ -158,7 +213,14 @@ func (c *Transaction) ListByType(ctx context.Context, transactionType string, pa
 		return nil, 0, err
 	}
 
-	transactions, total, err := c.Transaction.FindAllByType(transactionType, page, limit, sort)
+	err = authorizePrivileged(ctx, "payment list")
+
+	if err != nil {
+		c.logger.WithContext(ctx).WithError(err).Warn("caller is not authorized")
+		return nil, 0, err
+	}
+
+	transactions, total, err := c.Transaction.FindAllByType(ctx, transactionType, page, limit, sort)
 
 	if err != nil {
 		c.logger.
@@ -172,7 +234,7 @@ func (c *Transaction) ListByType(ctx context.Context, transactionType string, pa
 			).WithContext(ctx).
 			WithError(err).
 			Error(errOnListByType)
-		return nil, 0, errOnListByType
+		return nil, 0, domainOr(err, errOnListByType)
 	}
 
 	if len(transactions) == 0 {
@@ -191,6 +253,9 @@ func (c *Transaction) ListByType(ctx context.Context, transactionType string, pa
 }
 
 func (c *Transaction) GetByExternalID(ctx context.Context, transactionID, externalID string) (*entity.Transaction, error) {
+	ctx, span := tracer.Start(ctx, "controller.Transaction.GetByExternalID")
+	defer span.End()
+
 	err := validator.GetByExternalIDParams(transactionID, externalID)
 
 	if err != nil {
@@ -198,7 +263,7 @@ func (c *Transaction) GetByExternalID(ctx context.Context, transactionID, extern
 		return nil, err
 	}
 
-	transaction, err := c.Transaction.FindByExternalID(externalID, transactionID)
+	transaction, err := c.Transaction.FindByExternalID(ctx, externalID, transactionID)
 
 	if err != nil {
 		c.logger.
@@ -210,20 +275,37 @@ func (c *Transaction) GetByExternalID(ctx context.Context, transactionID, extern
 			).WithContext(ctx).
 			WithError(err).
 			Error(errOnNotFoundTransaction)
-		return nil, errOnNotFoundTransaction
+		return nil, domainOr(err, errOnNotFoundTransaction)
+	}
+
+	err = authorizeTransaction(ctx, transaction)
+
+	if err != nil {
+		c.logger.WithContext(ctx).WithError(err).Warn("caller is not authorized")
+		return nil, err
 	}
 
 	return transaction, nil
 }
 
 func (c *Transaction) ListByExternalID(ctx context.Context, externalID string, page, limit int, sort string) ([]*entity.Transaction, int, error) {
+	ctx, span := tracer.Start(ctx, "controller.Transaction.ListByExternalID")
+	defer span.End()
+
 	err := validator.ListByExternalIDParams(externalID, page, limit, sort)
 	if err != nil {
 		c.logger.WithContext(ctx).Error(err)
 		return nil, 0, err
 	}
 
-	transactions, total, err := c.Transaction.FindAllByExternalID(externalID, page, limit, sort)
+	err = authorizePrivileged(ctx, "payment list")
+
+	if err != nil {
+		c.logger.WithContext(ctx).WithError(err).Warn("caller is not authorized")
+		return nil, 0, err
+	}
+
+	transactions, total, err := c.Transaction.FindAllByExternalID(ctx, externalID, page, limit, sort)
 
 	if err != nil {
 		c.logger.
@@ -237,7 +319,7 @@ func (c *Transaction) ListByExternalID(ctx context.Context, externalID string, p
 			).WithContext(ctx).
 			WithError(err).
 			Error(errOnListByExternalID)
-		return nil, 0, errOnListByExternalID
+		return nil, 0, domainOr(err, errOnListByExternalID)
 	}
 
 	if len(transactions) == 0 {
@@ -258,6 +340,9 @@ func (c *Transaction) ListByExternalID(ctx context.Context, externalID string, p
 }
 
 func (c *Transaction) GetByAccountFrom(ctx context.Context, transactionID, accountID string) (*entity.Transaction, error) {
+	ctx, span := tracer.Start(ctx, "controller.Transaction.GetByAccountFrom")
+	defer span.End()
+
 	err := validator.GetByAccountFromParams(transactionID, accountID)
 
 	if err != nil {
@@ -265,7 +350,14 @@ func (c *Transaction) GetByAccountFrom(ctx context.Context, transactionID, accou
 		return nil, err
 	}
 
-	transaction, err := c.Transaction.FindByFromAccountID(accountID, transactionID)
+	err = authorizeAccounts(ctx, accountID)
+
+	if err != nil {
+		c.logger.WithContext(ctx).WithError(err).Warn("caller is not authorized")
+		return nil, err
+	}
+
+	transaction, err := c.Transaction.FindByFromAccountID(ctx, accountID, transactionID)
 
 	if err != nil {
 		c.logger.
@@ -277,13 +369,16 @@ func (c *Transaction) GetByAccountFrom(ctx context.Context, transactionID, accou
 			).WithContext(ctx).
 			WithError(err).
 			Error(errOnNotFoundTransaction)
-		return nil, errOnNotFoundTransaction
+		return nil, domainOr(err, errOnNotFoundTransaction)
 	}
 
 	return transaction, nil
 }
 
 func (c *Transaction) ListByAccountFrom(ctx context.Context, accountID string, page int, limit int, sort string) ([]*entity.Transaction, int, error) {
+	ctx, span := tracer.Start(ctx, "controller.Transaction.ListByAccountFrom")
+	defer span.End()
+
 	err := validator.ListByAccountFromParams(accountID, page, limit, sort)
 
 	if err != nil {
@@ -291,7 +386,14 @@ func (c *Transaction) ListByAccountFrom(ctx context.Context, accountID string, p
 		return nil, 0, err
 	}
 
-	transactions, total, err := c.Transaction.FindAllByFromAccountID(accountID, page, limit, sort)
+	err = authorizeAccounts(ctx, accountID)
+
+	if err != nil {
+		c.logger.WithContext(ctx).WithError(err).Warn("caller is not authorized")
+		return nil, 0, err
+	}
+
+	transactions, total, err := c.Transaction.FindAllByFromAccountID(ctx, accountID, page, limit, sort)
 
 	if err != nil {
 		c.logger.
@@ -305,7 +407,7 @@ func (c *Transaction) ListByAccountFrom(ctx context.Context, accountID string, p
 			).WithContext(ctx).
 			WithError(err).
 			Error(errOnListByAccountFrom)
-		return nil, 0, errOnListByAccountFrom
+		return nil, 0, domainOr(err, errOnListByAccountFrom)
 	}
 
 	if len(transactions) == 0 {
@@ -326,6 +428,9 @@ func (c *Transaction) ListByAccountFrom(ctx context.Context, accountID string, p
 }
 
 func (c *Transaction) GetByAccoutTo(ctx context.Context, transactionID, accountID string) (*entity.Transaction, error) {
+	ctx, span := tracer.Start(ctx, "controller.Transaction.GetByAccoutTo")
+	defer span.End()
+
 	err := validator.GetByAccoutToParams(transactionID, accountID)
 
 	if err != nil {
@@ -333,7 +438,14 @@ func (c *Transaction) GetByAccoutTo(ctx context.Context, transactionID, accountI
 		return nil, err
 	}
 
-	transaction, err := c.Transaction.FindByToAccountID(accountID, transactionID)
+	err = authorizeAccounts(ctx, accountID)
+
+	if err != nil {
+		c.logger.WithContext(ctx).WithError(err).Warn("caller is not authorized")
+		return nil, err
+	}
+
+	transaction, err := c.Transaction.FindByToAccountID(ctx, accountID, transactionID)
 
 	if err != nil {
 		c.logger.
@@ -345,20 +457,30 @@ func (c *Transaction) GetByAccoutTo(ctx context.Context, transactionID, accountI
 			).WithContext(ctx).
 			WithError(err).
 			Error(errOnNotFoundTransaction)
-		return nil, errOnNotFoundTransaction
+		return nil, domainOr(err, errOnNotFoundTransaction)
 	}
 
 	return transaction, nil
 }
 
 func (c *Transaction) ListByAccountTo(ctx context.Context, accountID string, page int, limit int, sort string) ([]*entity.Transaction, int, error) {
+	ctx, span := tracer.Start(ctx, "controller.Transaction.ListByAccountTo")
+	defer span.End()
+
 	err := validator.ListByAccoutToParams(accountID, page, limit, sort)
 	if err != nil {
 		c.logger.WithContext(ctx).Error(err)
 		return nil, 0, err
 	}
 
-	transactions, total, err := c.Transaction.FindAllByToAccountID(accountID, page, limit, sort)
+	err = authorizeAccounts(ctx, accountID)
+
+	if err != nil {
+		c.logger.WithContext(ctx).WithError(err).Warn("caller is not authorized")
+		return nil, 0, err
+	}
+
+	transactions, total, err := c.Transaction.FindAllByToAccountID(ctx, accountID, page, limit, sort)
 
 	if err != nil {
 		c.logger.
@@ -372,7 +494,7 @@ func (c *Transaction) ListByAccountTo(ctx context.Context, accountID string, pag
 			).WithContext(ctx).
 			WithError(err).
 			Error(errOnListByAccountTo)
-		return nil, 0, errOnListByAccountTo
+		return nil, 0, domainOr(err, errOnListByAccountTo)
 	}
 
 	if len(transactions) == 0 {
@@ -393,6 +515,9 @@ func (c *Transaction) ListByAccountTo(ctx context.Context, accountID string, pag
 }
 
 func (c *Transaction) Complete(ctx context.Context, transactionId string) (*entity.Transaction, error) {
+	ctx, span := tracer.Start(ctx, "controller.Transaction.Complete")
+	defer span.End()
+
 	err := validator.CompleteParams(transactionId)
 
 	if err != nil {
@@ -400,20 +525,30 @@ func (c *Transaction) Complete(ctx context.Context, transactionId string) (*enti
 		return nil, err
 	}
 
-	transaction, err := c.Transaction.Complete(transactionId)
+	err = authorizePrivileged(ctx, "payment")
+
+	if err != nil {
+		c.logger.WithContext(ctx).WithError(err).Warn("caller is not authorized")
+		return nil, err
+	}
+
+	transaction, err := c.Transaction.Complete(ctx, transactionId)
 
 	if err != nil {
 		c.logger.
 			WithField("transaction_id", transactionId).
 			WithContext(ctx).WithError(err).
 			Error(errOnCompeteTransaction)
-		return nil, errOnCompeteTransaction
+		return nil, domainOr(err, errOnCompeteTransaction)
 	}
 
 	return transaction, nil
 }
 
 func (c *Transaction) Error(ctx context.Context, transactionId string) (*entity.Transaction, error) {
+	ctx, span := tracer.Start(ctx, "controller.Transaction.Error")
+	defer span.End()
+
 	err := validator.ErrorParams(transactionId)
 
 	if err != nil {
@@ -421,7 +556,14 @@ func (c *Transaction) Error(ctx context.Context, transactionId string) (*entity.
 		return nil, err
 	}
 
-	transaction, err := c.Transaction.Error(transactionId)
+	err = authorizePrivileged(ctx, "payment")
+
+	if err != nil {
+		c.logger.WithContext(ctx).WithError(err).Warn("caller is not authorized")
+		return nil, err
+	}
+
+	transaction, err := c.Transaction.Error(ctx, transactionId)
 
 	if err != nil {
 		c.logger.
@@ -429,8 +571,48 @@ func (c *Transaction) Error(ctx context.Context, transactionId string) (*entity.
 			WithField("transaction_id", transactionId).
 			WithError(err).
 			Error(errOnCancelTransaction)
-		return nil, errOnCancelTransaction
+		return nil, domainOr(err, errOnCancelTransaction)
 	}
 
 	return transaction, nil
 }
+
+func (c *Transaction) Export(ctx context.Context, filter *entity.TransactionFilter, resumeToken string, fn func(transaction *entity.Transaction, resumeToken string) error) error {
+	ctx, span := tracer.Start(ctx, "controller.Transaction.Export")
+	defer span.End()
+
+	err := validator.ExportParams(filter, resumeToken)
+
+	if err != nil {
+		c.logger.WithContext(ctx).Error(err)
+		return err
+	}
+
+	err = authorizeFilter(ctx, filter)
+
+	if err != nil {
+		c.logger.WithContext(ctx).WithError(err).Warn("caller is not authorized")
+		return err
+	}
+
+	err = c.Transaction.Export(ctx, filter, resumeToken, func(transaction *entity.Transaction, resumeToken string) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		return fn(transaction, resumeToken)
+	})
+
+	if err != nil {
+		c.logger.
+			WithFields(log.Fields{
+				"filter":       filter,
+				"resume_token": resumeToken,
+			}).WithContext(ctx).
+			WithError(err).
+			Error(errOnExportTransactions)
+		return domainOr(err, errOnExportTransactions)
+	}
+
+	return nil
+}
@@ -26,11 +26,17 @@ func TestRegister(t *testing.T) {
 
 		c := controller.NewTransaction(nil)
 
-		result, err := c.Register(context.TODO(), accountFrom, accountTo, externalID, transactionType, currency, amount)
+		result, err := c.Register(systemContext(), accountFrom, accountTo, externalID, transactionType, currency, amount)
 
 		is.Nil(result)
 		is.NotNil(err)
 		is.Error(err)
+
+		domainError, ok := entity.AsDomainError(err)
+		is.True(ok)
+		is.Equal(entity.ErrorInvalidArgument, domainError.Kind)
+		is.Contains(domainError.Fields, "account_from")
+		is.Contains(domainError.Fields, "currency")
 	})
 
 	t.Run("should fail on register", func(t *testing.T) {
@@ -47,7 +53,7 @@ func TestRegister(t *testing.T) {
 		transactionUseCase.On("Register", accountFrom, accountTo, externalID, transactionType, currency, amount).Return(nil, errors.New("register error"))
 		c := controller.NewTransaction(transactionUseCase)
 
-		result, err := c.Register(context.TODO(), accountFrom, accountTo, externalID, transactionType, currency, amount)
+		result, err := c.Register(systemContext(), accountFrom, accountTo, externalID, transactionType, currency, amount)
 
 		is.Nil(result)
 		is.NotNil(err)
@@ -55,6 +61,27 @@ func TestRegister(t *testing.T) {
 		is.EqualError(err, "an error on register payment")
 	})
 
+	t.Run("should return domain error on register", func(t *testing.T) {
+		is := require.New(t)
+		transactionUseCase := mock.NewMockTransactionUseCase()
+
+		accountFrom := uuid.NewV4().String()
+		accountTo := uuid.NewV4().String()
+		transactionType := entity.TransactionToUser
+		externalID := uuid.NewV4().String()
+		currency := "AOA"
+		amount := 30.00
+
+		transactionUseCase.On("Register", accountFrom, accountTo, externalID, transactionType, currency, amount).Return(nil, entity.InsufficientFunds(accountFrom, 10, amount))
+		c := controller.NewTransaction(transactionUseCase)
+
+		result, err := c.Register(systemContext(), accountFrom, accountTo, externalID, transactionType, currency, amount)
+
+		is.Nil(result)
+		is.True(entity.IsErrorKind(err, entity.ErrorInsufficientFunds))
+		is.EqualError(err, "account does not have balance")
+	})
+
 	t.Run("should succeed", func(t *testing.T) {
 		is := require.New(t)
 		transactionUseCase := mock.NewMockTransactionUseCase()
@@ -70,7 +97,7 @@ func TestRegister(t *testing.T) {
 		transactionUseCase.On("Register", accountFrom.ID, accountTo.ID, externalID, transactionType, currency, amount).Return(transaction, nil)
 		c := controller.NewTransaction(transactionUseCase)
 
-		result, err := c.Register(context.TODO(), accountFrom.ID, accountTo.ID, externalID, transactionType, currency, amount)
+		result, err := c.Register(systemContext(), accountFrom.ID, accountTo.ID, externalID, transactionType, currency, amount)
 
 		is.NotNil(result)
 		is.Equal(result, transaction)
@@ -88,7 +115,7 @@ func TestGet(t *testing.T) {
 
 		c := controller.NewTransaction(nil)
 
-		result, err := c.Get(context.TODO(), id)
+		result, err := c.Get(systemContext(), id)
 
 		is.Nil(result)
 		is.NotNil(err)
@@ -104,7 +131,7 @@ func TestGet(t *testing.T) {
 
 		c := controller.NewTransaction(transactionUseCase)
 
-		result, err := c.Get(context.TODO(), id)
+		result, err := c.Get(systemContext(), id)
 
 		transactionUseCase.AssertExpectations(t)
 
@@ -128,7 +155,7 @@ func TestGet(t *testing.T) {
 
 		c := controller.NewTransaction(transactionUseCase)
 
-		result, err := c.Get(context.TODO(), transaction.ID)
+		result, err := c.Get(systemContext(), transaction.ID)
 
 		is.Nil(err)
 		is.Equal(result, transaction)
@@ -147,7 +174,7 @@ func TestList(t *testing.T) {
 
 		c := controller.NewTransaction(nil)
 
-		result, total, err := c.List(context.TODO(), page, limit, sort)
+		result, total, err := c.List(systemContext(), page, limit, sort)
 
 		is.Nil(result)
 		is.Equal(0, total)
@@ -166,7 +193,7 @@ func TestList(t *testing.T) {
 
 		c := controller.NewTransaction(transactionUseCase)
 
-		result, total, err := c.List(context.TODO(), page, limit, sort)
+		result, total, err := c.List(systemContext(), page, limit, sort)
 
 		is.Nil(result)
 		is.Equal(0, total)
@@ -185,7 +212,7 @@ func TestList(t *testing.T) {
 
 		c := controller.NewTransaction(transactionUseCase)
 
-		result, total, err := c.List(context.TODO(), page, limit, sort)
+		result, total, err := c.List(systemContext(), page, limit, sort)
 
 		is.Nil(result)
 		is.Equal(0, total)
@@ -212,7 +239,7 @@ func TestList(t *testing.T) {
 
 		c := controller.NewTransaction(transactionUseCase)
 
-		result, total, err := c.List(context.TODO(), page, limit, sort)
+		result, total, err := c.List(systemContext(), page, limit, sort)
 
 		transactionUseCase.AssertExpectations(t)
 
@@ -233,7 +260,7 @@ func TestGetByType(t *testing.T) {
 
 		c := controller.NewTransaction(nil)
 
-		result, err := c.GetByType(context.TODO(), transactionID, transactionType)
+		result, err := c.GetByType(systemContext(), transactionID, transactionType)
 
 		is.Nil(result)
 		is.NotNil(err)
@@ -250,7 +277,7 @@ func TestGetByType(t *testing.T) {
 		transactionUseCase.On("FindByType", transactionType, transactionID).Return(nil, errors.New("error on get"))
 		c := controller.NewTransaction(transactionUseCase)
 
-		result, err := c.GetByType(context.TODO(), transactionID, transactionType)
+		result, err := c.GetByType(systemContext(), transactionID, transactionType)
 
 		is.Nil(result)
 		is.NotNil(err)
@@ -273,7 +300,7 @@ func TestGetByType(t *testing.T) {
 		transactionUseCase.On("FindByType", transactionType, transaction.ID).Return(transaction, nil)
 		c := controller.NewTransaction(transactionUseCase)
 
-		result, err := c.GetByType(context.TODO(), transaction.ID, transactionType)
+		result, err := c.GetByType(systemContext(), transaction.ID, transactionType)
 
 		is.Nil(err)
 		is.NotNil(result)
@@ -293,7 +320,7 @@ func TestListByType(t *testing.T) {
 		sort := "id Desc"
 		c := controller.NewTransaction(nil)
 
-		result, total, err := c.ListByType(context.TODO(), transactionType, page, limit, sort)
+		result, total, err := c.ListByType(systemContext(), transactionType, page, limit, sort)
 
 		is.Nil(result)
 		is.Equal(total, 0)
@@ -313,7 +340,7 @@ func TestListByType(t *testing.T) {
 		transactionUseCase.On("FindAllByType", transactionType, page, limit, sort).Return(nil, 0, errors.New("internal error"))
 		c := controller.NewTransaction(transactionUseCase)
 
-		result, total, err := c.ListByType(context.TODO(), transactionType, page, limit, sort)
+		result, total, err := c.ListByType(systemContext(), transactionType, page, limit, sort)
 
 		is.Nil(result)
 		is.Equal(0, total)
@@ -334,7 +361,7 @@ func TestListByType(t *testing.T) {
 		transactionUseCase.On("FindAllByType", transactionType, page, limit, sort).Return(nil, 0, nil)
 		c := controller.NewTransaction(transactionUseCase)
 
-		result, total, err := c.ListByType(context.TODO(), transactionType, page, limit, sort)
+		result, total, err := c.ListByType(systemContext(), transactionType, page, limit, sort)
 
 		is.Nil(result)
 		is.Equal(total, 0)
@@ -361,7 +388,7 @@ func TestListByType(t *testing.T) {
 		transactionUseCase.On("FindAllByType", transactionType, page, limit, sort).Return(transactions, len(transactions), nil)
 		c := controller.NewTransaction(transactionUseCase)
 
-		result, total, err := c.ListByType(context.TODO(), transactionType, page, limit, sort)
+		result, total, err := c.ListByType(systemContext(), transactionType, page, limit, sort)
 
 		is.Equal(len(transactions), total)
 		is.Nil(err)
@@ -381,7 +408,7 @@ func TestGetByExternalID(t *testing.T) {
 
 		c := controller.NewTransaction(nil)
 
-		result, err := c.GetByExternalID(context.TODO(), transactionID, externalID)
+		result, err := c.GetByExternalID(systemContext(), transactionID, externalID)
 
 		is.Nil(result)
 		is.NotNil(err)
@@ -398,7 +425,7 @@ func TestGetByExternalID(t *testing.T) {
 
 		c := controller.NewTransaction(transactionUseCase)
 
-		_, err := c.GetByExternalID(context.TODO(), transactionID, externalID)
+		_, err := c.GetByExternalID(systemContext(), transactionID, externalID)
 
 		is.NotNil(err)
 		is.Error(err)
@@ -421,7 +448,7 @@ func TestGetByExternalID(t *testing.T) {
 
 		c := controller.NewTransaction(transactionUseCase)
 
-		result, err := c.GetByExternalID(context.TODO(), transaction.ID, externalID)
+		result, err := c.GetByExternalID(systemContext(), transaction.ID, externalID)
 
 		is.Nil(err)
 		is.NotNil(result)
@@ -443,7 +470,7 @@ func TestListByExternalID(t *testing.T) {
 
 		c := controller.NewTransaction(nil)
 
-		result, total, err := c.ListByExternalID(context.TODO(), externalID, page, limit, sort)
+		result, total, err := c.ListByExternalID(systemContext(), externalID, page, limit, sort)
 
 		is.Equal(total, 0)
 		is.Nil(result)
@@ -464,7 +491,7 @@ func TestListByExternalID(t *testing.T) {
 
 		c := controller.NewTransaction(transactionUseCase)
 
-		result, _, err := c.ListByExternalID(context.TODO(), externalID, page, limit, sort)
+		result, _, err := c.ListByExternalID(systemContext(), externalID, page, limit, sort)
 
 		is.Nil(result)
 		is.NotNil(err)
@@ -484,7 +511,7 @@ func TestListByExternalID(t *testing.T) {
 
 		c := controller.NewTransaction(transactionUseCase)
 
-		result, _, err := c.ListByExternalID(context.TODO(), externalID, page, limit, sort)
+		result, _, err := c.ListByExternalID(systemContext(), externalID, page, limit, sort)
 
 		is.Nil(result)
 		is.Nil(err)
@@ -510,7 +537,7 @@ func TestListByExternalID(t *testing.T) {
 
 		c := controller.NewTransaction(transactionUseCase)
 
-		result, total, err := c.ListByExternalID(context.TODO(), externalID, page, limit, sort)
+		result, total, err := c.ListByExternalID(systemContext(), externalID, page, limit, sort)
 
 		is.Nil(err)
 		is.Equal(len(transactions), total)
@@ -529,7 +556,7 @@ func TestGetByAccountFrom(t *testing.T) {
 
 		c := controller.NewTransaction(nil)
 
-		result, err := c.GetByAccountFrom(context.TODO(), transactionID, accountID)
+		result, err := c.GetByAccountFrom(systemContext(), transactionID, accountID)
 
 		is.Nil(result)
 		is.NotNil(err)
@@ -546,7 +573,7 @@ func TestGetByAccountFrom(t *testing.T) {
 
 		c := controller.NewTransaction(transactionUseCase)
 
-		_, err := c.GetByAccountFrom(context.TODO(), transactionID, accountID)
+		_, err := c.GetByAccountFrom(systemContext(), transactionID, accountID)
 
 		is.NotNil(err)
 		is.Error(err)
@@ -569,7 +596,7 @@ func TestGetByAccountFrom(t *testing.T) {
 
 		c := controller.NewTransaction(transactionUseCase)
 
-		result, err := c.GetByAccountFrom(context.TODO(), transaction.ID, accountFrom.ID)
+		result, err := c.GetByAccountFrom(systemContext(), transaction.ID, accountFrom.ID)
 
 		is.Nil(err)
 		is.NotNil(result)
@@ -591,7 +618,7 @@ func TestListByAccountFrom(t *testing.T) {
 
 		c := controller.NewTransaction(nil)
 
-		result, total, err := c.ListByAccountFrom(context.TODO(), accountID, page, limit, sort)
+		result, total, err := c.ListByAccountFrom(systemContext(), accountID, page, limit, sort)
 
 		is.Equal(total, 0)
 		is.Nil(result)
@@ -612,7 +639,7 @@ func TestListByAccountFrom(t *testing.T) {
 
 		c := controller.NewTransaction(transactionUseCase)
 
-		result, _, err := c.ListByAccountFrom(context.TODO(), accountID, page, limit, sort)
+		result, _, err := c.ListByAccountFrom(systemContext(), accountID, page, limit, sort)
 
 		is.Nil(result)
 		is.NotNil(err)
@@ -632,7 +659,7 @@ func TestListByAccountFrom(t *testing.T) {
 
 		c := controller.NewTransaction(transactionUseCase)
 
-		result, _, err := c.ListByAccountFrom(context.TODO(), accountID, page, limit, sort)
+		result, _, err := c.ListByAccountFrom(systemContext(), accountID, page, limit, sort)
 
 		is.Nil(result)
 		is.Nil(err)
@@ -658,7 +685,7 @@ func TestListByAccountFrom(t *testing.T) {
 
 		c := controller.NewTransaction(transactionUseCase)
 
-		result, total, err := c.ListByAccountFrom(context.TODO(), accountFrom.ID, page, limit, sort)
+		result, total, err := c.ListByAccountFrom(systemContext(), accountFrom.ID, page, limit, sort)
 
 		is.Nil(err)
 		is.Equal(len(transactions), total)
@@ -677,7 +704,7 @@ func TestGetByAccoutTo(t *testing.T) {
 
 		c := controller.NewTransaction(nil)
 
-		result, err := c.GetByAccoutTo(context.TODO(), transactionID, accountID)
+		result, err := c.GetByAccoutTo(systemContext(), transactionID, accountID)
 
 		is.Nil(result)
 		is.NotNil(err)
@@ -694,7 +721,7 @@ func TestGetByAccoutTo(t *testing.T) {
 
 		c := controller.NewTransaction(transactionUseCase)
 
-		_, err := c.GetByAccoutTo(context.TODO(), transactionID, accountID)
+		_, err := c.GetByAccoutTo(systemContext(), transactionID, accountID)
 
 		is.NotNil(err)
 		is.Error(err)
@@ -717,7 +744,7 @@ func TestGetByAccoutTo(t *testing.T) {
 
 		c := controller.NewTransaction(transactionUseCase)
 
-		result, err := c.GetByAccoutTo(context.TODO(), transaction.ID, accountTo.ID)
+		result, err := c.GetByAccoutTo(systemContext(), transaction.ID, accountTo.ID)
 
 		is.Nil(err)
 		is.NotNil(result)
@@ -739,7 +766,7 @@ func TestListByAccountTo(t *testing.T) {
 
 		c := controller.NewTransaction(nil)
 
-		result, total, err := c.ListByAccountTo(context.TODO(), accountID, page, limit, sort)
+		result, total, err := c.ListByAccountTo(systemContext(), accountID, page, limit, sort)
 
 		is.Equal(total, 0)
 		is.Nil(result)
@@ -760,7 +787,7 @@ func TestListByAccountTo(t *testing.T) {
 
 		c := controller.NewTransaction(transactionUseCase)
 
-		result, _, err := c.ListByAccountTo(context.TODO(), accountID, page, limit, sort)
+		result, _, err := c.ListByAccountTo(systemContext(), accountID, page, limit, sort)
 
 		is.Nil(result)
 		is.NotNil(err)
@@ -780,7 +807,7 @@ func TestListByAccountTo(t *testing.T) {
 
 		c := controller.NewTransaction(transactionUseCase)
 
-		result, _, err := c.ListByAccountTo(context.TODO(), accountID, page, limit, sort)
+		result, _, err := c.ListByAccountTo(systemContext(), accountID, page, limit, sort)
 
 		is.Nil(result)
 		is.Nil(err)
@@ -806,7 +833,7 @@ func TestListByAccountTo(t *testing.T) {
 
 		c := controller.NewTransaction(transactionUseCase)
 
-		result, total, err := c.ListByAccountTo(context.TODO(), accountTo.ID, page, limit, sort)
+		result, total, err := c.ListByAccountTo(systemContext(), accountTo.ID, page, limit, sort)
 
 		is.Nil(err)
 		is.Equal(len(transactions), total)
@@ -825,7 +852,7 @@ func TestComplete(t *testing.T) {
 
 		c := controller.NewTransaction(nil)
 
-		result, err := c.Complete(context.TODO(), id)
+		result, err := c.Complete(systemContext(), id)
 
 		is.Nil(result)
 		is.NotNil(err)
@@ -841,7 +868,7 @@ func TestComplete(t *testing.T) {
 
 		c := controller.NewTransaction(transactionUseCase)
 
-		result, err := c.Complete(context.TODO(), id)
+		result, err := c.Complete(systemContext(), id)
 
 		transactionUseCase.AssertExpectations(t)
 
@@ -867,7 +894,7 @@ func TestComplete(t *testing.T) {
 
 		c := controller.NewTransaction(transactionUseCase)
 
-		result, err := c.Complete(context.TODO(), transaction.ID)
+		result, err := c.Complete(systemContext(), transaction.ID)
 		transactionUseCase.AssertExpectations(t)
 
 		is.Nil(err)
@@ -884,7 +911,7 @@ func TestError(t *testing.T) {
 
 		c := controller.NewTransaction(nil)
 
-		result, err := c.Error(context.TODO(), id)
+		result, err := c.Error(systemContext(), id)
 
 		is.Nil(result)
 		is.NotNil(err)
@@ -900,7 +927,7 @@ func TestError(t *testing.T) {
 
 		c := controller.NewTransaction(transactionUseCase)
 
-		result, err := c.Error(context.TODO(), id)
+		result, err := c.Error(systemContext(), id)
 
 		transactionUseCase.AssertExpectations(t)
 
@@ -926,10 +953,60 @@ func TestError(t *testing.T) {
 
 		c := controller.NewTransaction(transactionUseCase)
 
-		result, err := c.Error(context.TODO(), transaction.ID)
+		result, err := c.Error(systemContext(), transaction.ID)
 		transactionUseCase.AssertExpectations(t)
 
 		is.Nil(err)
 		is.Equal(result, transaction)
 	})
 }
+
+func TestExport(t *testing.T) {
+	t.Parallel()
+
+	t.Run("should fail on validation", func(t *testing.T) {
+		is := require.New(t)
+		c := controller.NewTransaction(nil)
+
+		err := c.Export(systemContext(), &entity.TransactionFilter{Status: "lost"}, "", nil)
+		is.Error(err)
+
+		err = c.Export(systemContext(), &entity.TransactionFilter{}, "not a token", nil)
+		is.Error(err)
+	})
+
+	t.Run("should fail on export", func(t *testing.T) {
+		is := require.New(t)
+		transactionUseCase := mock.NewMockTransactionUseCase()
+		filter := &entity.TransactionFilter{Type: entity.TransactionToStore}
+
+		transactionUseCase.On("Export", filter, "").Return(nil, errors.New("db error"))
+		c := controller.NewTransaction(transactionUseCase)
+
+		err := c.Export(systemContext(), filter, "", nil)
+
+		is.EqualError(err, "an error on export payments")
+	})
+
+	t.Run("should stop when the context is canceled", func(t *testing.T) {
+		is := require.New(t)
+		transactionUseCase := mock.NewMockTransactionUseCase()
+		filter := &entity.TransactionFilter{}
+		transaction := &entity.Transaction{}
+
+		transactionUseCase.On("Export", filter, "").Return([]*entity.Transaction{transaction, transaction}, nil)
+		c := controller.NewTransaction(transactionUseCase)
+
+		ctx, cancel := context.WithCancel(systemContext())
+		calls := 0
+
+		err := c.Export(ctx, filter, "", func(*entity.Transaction, string) error {
+			calls++
+			cancel()
+			return nil
+		})
+
+		is.Error(err)
+		is.Equal(1, calls)
+	})
+}
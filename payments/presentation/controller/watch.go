@@ -0,0 +1,143 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/EdlanioJ/kbu/payments/domain/entity"
+	"github.com/EdlanioJ/kbu/payments/domain/usecase"
+	"github.com/EdlanioJ/kbu/payments/presentation/validator"
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	errOnWatchTransaction = errors.New("an error on watch payment")
+	errOnWatchAccount     = errors.New("an error on watch account payments")
+)
+
+type Watch struct {
+	Feed   usecase.TransactionFeed
+	logger *log.Logger
+}
+
+func NewWatch(feed usecase.TransactionFeed) *Watch {
+	logger := newLogger()
+
+	return &Watch{
+		Feed:   feed,
+		logger: logger,
+	}
+}
+
+func (c *Watch) WatchTransaction(ctx context.Context, transactionID string, fromVersion uint64) (usecase.TransactionSubscription, error) {
+	err := validator.GetParams(transactionID)
+
+	if err != nil {
+		c.logger.WithContext(ctx).Error(err)
+		return nil, err
+	}
+
+	subscription, err := c.Feed.WatchTransaction(transactionID, fromVersion)
+
+	if err != nil {
+		c.logger.
+			WithFields(log.Fields{
+				"transaction_id": transactionID,
+				"from_version":   fromVersion,
+			}).WithContext(ctx).
+			WithError(err).
+			Error(errOnWatchTransaction)
+		return nil, err
+	}
+
+	return scopeSubscription(ctx, subscription), nil
+}
+
+func (c *Watch) WatchAccount(ctx context.Context, accountID string, fromVersion uint64) (usecase.TransactionSubscription, error) {
+	err := validator.GetParams(accountID)
+
+	if err != nil {
+		c.logger.WithContext(ctx).Error(err)
+		return nil, err
+	}
+
+	err = authorizeAccounts(ctx, accountID)
+
+	if err != nil {
+		c.logger.WithContext(ctx).WithError(err).Warn("caller is not authorized")
+		return nil, err
+	}
+
+	subscription, err := c.Feed.WatchAccount(accountID, fromVersion)
+
+	if err != nil {
+		c.logger.
+			WithFields(log.Fields{
+				"account_id":   accountID,
+				"from_version": fromVersion,
+			}).WithContext(ctx).
+			WithError(err).
+			Error(errOnWatchAccount)
+		return nil, err
+	}
+
+	return scopeSubscription(ctx, subscription), nil
+}
+
+// tenantSubscription drops the updates of payments owned by other tenants,
+// the feed itself is shared by every tenant of the process.
+type tenantSubscription struct {
+	usecase.TransactionSubscription
+	tenant  string
+	updates chan *entity.TransactionUpdate
+	done    chan struct{}
+	once    sync.Once
+}
+
+func scopeSubscription(ctx context.Context, subscription usecase.TransactionSubscription) usecase.TransactionSubscription {
+	tenant, ok := entity.Tenant(ctx)
+
+	if !ok {
+		return subscription
+	}
+
+	scoped := &tenantSubscription{
+		TransactionSubscription: subscription,
+		tenant:                  tenant,
+		updates:                 make(chan *entity.TransactionUpdate),
+		done:                    make(chan struct{}),
+	}
+
+	go scoped.forward()
+
+	return scoped
+}
+
+func (s *tenantSubscription) forward() {
+	defer close(s.updates)
+
+	for update := range s.TransactionSubscription.Updates() {
+		if update.Transaction == nil || update.Transaction.TenantID != s.tenant {
+			continue
+		}
+
+		select {
+		case s.updates <- update:
+		case <-s.done:
+			return
+		}
+	}
+}
+
+func (s *tenantSubscription) Updates() <-chan *entity.TransactionUpdate {
+	return s.updates
+}
+
+func (s *tenantSubscription) Close() {
+	s.once.Do(func() {
+		close(s.done)
+	})
+
+	s.TransactionSubscription.Close()
+}
@@ -0,0 +1,54 @@
+package controller_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/EdlanioJ/kbu/payments/data/service"
+	"github.com/EdlanioJ/kbu/payments/domain/entity"
+	"github.com/EdlanioJ/kbu/payments/presentation/controller"
+	uuid "github.com/satori/go.uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func tenantContext(tenant string) context.Context {
+	return controller.WithPrincipal(context.TODO(), &entity.Principal{
+		Subject: "admin",
+		Roles:   []string{entity.RoleAdmin},
+		Tenant:  tenant,
+	})
+}
+
+func TestWatch(t *testing.T) {
+	t.Parallel()
+
+	t.Run("should only stream the payments of the tenant of the caller", func(t *testing.T) {
+		is := require.New(t)
+		feed := service.NewTransactionFeed()
+
+		accountFrom, _ := entity.NewAccount(3000)
+		accountTo, _ := entity.NewAccount(200)
+		accountFrom.TenantID = "acme"
+		accountTo.TenantID = "acme"
+		transaction, _ := entity.NewTransaction(accountFrom, accountTo, uuid.NewV4().String(), entity.TransactionToUser, "AOA", 30)
+
+		c := controller.NewWatch(feed)
+
+		owner, err := c.WatchAccount(tenantContext("acme"), accountFrom.ID, 0)
+		is.Nil(err)
+		defer owner.Close()
+
+		other, err := c.WatchAccount(tenantContext("globex"), accountFrom.ID, 0)
+		is.Nil(err)
+
+		feed.Publish(transaction)
+
+		update := <-owner.Updates()
+		is.Equal(transaction.ID, update.Transaction.ID)
+
+		other.Close()
+
+		_, ok := <-other.Updates()
+		is.False(ok)
+	})
+}
@@ -0,0 +1,154 @@
+package controller
+
+import (
+	"context"
+	"errors"
+
+	"github.com/EdlanioJ/kbu/payments/domain/entity"
+	"github.com/EdlanioJ/kbu/payments/domain/usecase"
+	"github.com/EdlanioJ/kbu/payments/presentation/validator"
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	errOnRegisterWebhook  = errors.New("an error on register webhook")
+	errOnNotifyWebhook    = errors.New("an error on notify webhooks")
+	errOnNotFoundDelivery = errors.New("no webhook delivery was found")
+	errOnRedeliver        = errors.New("an error on redeliver webhook")
+	errOnDeliverWebhooks  = errors.New("an error on deliver webhooks")
+)
+
+type Webhook struct {
+	Webhook usecase.Webhook
+	logger  *log.Logger
+}
+
+func NewWebhook(webhook usecase.Webhook) *Webhook {
+	logger := newLogger()
+
+	return &Webhook{
+		Webhook: webhook,
+		logger:  logger,
+	}
+}
+
+func (c *Webhook) Register(ctx context.Context, accountID, url string) (*entity.Webhook, error) {
+	err := validator.RegisterWebhookParams(accountID, url)
+
+	if err != nil {
+		c.logger.WithContext(ctx).Error(err)
+		return nil, err
+	}
+
+	err = authorizeAccounts(ctx, accountID)
+
+	if err != nil {
+		c.logger.WithContext(ctx).WithError(err).Warn("caller is not authorized")
+		return nil, err
+	}
+
+	webhook, err := c.Webhook.Register(ctx, accountID, url)
+
+	if err != nil {
+		c.logger.
+			WithFields(log.Fields{
+				"account_id": accountID,
+				"url":        url,
+			}).WithContext(ctx).
+			WithError(err).
+			Error(errOnRegisterWebhook)
+		return nil, domainOr(err, errOnRegisterWebhook)
+	}
+
+	return webhook, nil
+}
+
+func (c *Webhook) Notify(ctx context.Context, event string, transaction *entity.Transaction) ([]*entity.WebhookDelivery, error) {
+	deliveries, err := c.Webhook.Notify(ctx, event, transaction)
+
+	if err != nil {
+		c.logger.
+			WithFields(log.Fields{
+				"event":          event,
+				"transaction_id": transaction.ID,
+			}).WithContext(ctx).
+			WithError(err).
+			Error(errOnNotifyWebhook)
+		return nil, domainOr(err, errOnNotifyWebhook)
+	}
+
+	return deliveries, nil
+}
+
+func (c *Webhook) GetDelivery(ctx context.Context, deliveryID string) (*entity.WebhookDelivery, []*entity.WebhookAttempt, error) {
+	err := validator.DeliveryParams(deliveryID)
+
+	if err != nil {
+		c.logger.WithContext(ctx).Error(err)
+		return nil, nil, err
+	}
+
+	err = authorizePrivileged(ctx, "webhook delivery")
+
+	if err != nil {
+		c.logger.WithContext(ctx).WithError(err).Warn("caller is not authorized")
+		return nil, nil, err
+	}
+
+	delivery, attempts, err := c.Webhook.FindDelivery(ctx, deliveryID)
+
+	if err != nil {
+		c.logger.
+			WithField("delivery_id", deliveryID).
+			WithContext(ctx).
+			WithError(err).
+			Error(errOnNotFoundDelivery)
+		return nil, nil, domainOr(err, errOnNotFoundDelivery)
+	}
+
+	return delivery, attempts, nil
+}
+
+func (c *Webhook) Redeliver(ctx context.Context, deliveryID string) (*entity.WebhookDelivery, []*entity.WebhookAttempt, error) {
+	err := validator.DeliveryParams(deliveryID)
+
+	if err != nil {
+		c.logger.WithContext(ctx).Error(err)
+		return nil, nil, err
+	}
+
+	err = authorizePrivileged(ctx, "webhook delivery")
+
+	if err != nil {
+		c.logger.WithContext(ctx).WithError(err).Warn("caller is not authorized")
+		return nil, nil, err
+	}
+
+	_, err = c.Webhook.Redeliver(ctx, deliveryID)
+
+	if err != nil {
+		c.logger.
+			WithField("delivery_id", deliveryID).
+			WithContext(ctx).
+			WithError(err).
+			Error(errOnRedeliver)
+		return nil, nil, domainOr(err, errOnRedeliver)
+	}
+
+	return c.GetDelivery(ctx, deliveryID)
+}
+
+func (c *Webhook) DeliverDue(ctx context.Context, limit int) (int, error) {
+	delivered, err := c.Webhook.DeliverDue(ctx, limit)
+
+	if err != nil {
+		c.logger.
+			WithField("limit", limit).
+			WithContext(ctx).
+			WithError(err).
+			Error(errOnDeliverWebhooks)
+		return delivered, domainOr(err, errOnDeliverWebhooks)
+	}
+
+	return delivered, nil
+}
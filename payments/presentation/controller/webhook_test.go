@@ -0,0 +1,134 @@
+package controller_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/EdlanioJ/kbu/payments/domain/entity"
+	"github.com/EdlanioJ/kbu/payments/presentation/controller"
+	"github.com/EdlanioJ/kbu/payments/presentation/controller/mock"
+	uuid "github.com/satori/go.uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegisterWebhook(t *testing.T) {
+	t.Parallel()
+
+	t.Run("should fail on validation", func(t *testing.T) {
+		is := require.New(t)
+
+		c := controller.NewWebhook(nil)
+
+		result, err := c.Register(systemContext(), uuid.NewV4().String(), "not a url")
+
+		is.Nil(result)
+		is.Error(err)
+	})
+
+	t.Run("should reject urls that are not public https endpoints", func(t *testing.T) {
+		is := require.New(t)
+
+		c := controller.NewWebhook(nil)
+
+		for _, url := range []string{
+			"http://store.kbu.test/hooks",
+			"https://localhost/hooks",
+			"https://metadata.google.internal/hooks",
+			"https://127.0.0.1/hooks",
+			"https://10.1.2.3/hooks",
+			"https://192.168.0.10:8443/hooks",
+			"https://169.254.169.254/latest",
+			"https://[::1]/hooks",
+			"https://[fd00::1]/hooks",
+		} {
+			result, err := c.Register(systemContext(), uuid.NewV4().String(), url)
+
+			is.Nil(result, url)
+			is.True(entity.IsErrorKind(err, entity.ErrorInvalidArgument), url)
+		}
+	})
+
+	t.Run("should fail on register", func(t *testing.T) {
+		is := require.New(t)
+		webhookUseCase := mock.NewMockWebhookUseCase()
+
+		accountID := uuid.NewV4().String()
+		url := "https://store.kbu.test/hooks"
+
+		webhookUseCase.On("Register", accountID, url).Return(nil, errors.New("db error"))
+		c := controller.NewWebhook(webhookUseCase)
+
+		result, err := c.Register(systemContext(), accountID, url)
+
+		is.Nil(result)
+		is.EqualError(err, "an error on register webhook")
+	})
+
+	t.Run("should succeed", func(t *testing.T) {
+		is := require.New(t)
+		webhookUseCase := mock.NewMockWebhookUseCase()
+
+		accountID := uuid.NewV4().String()
+		url := "https://store.kbu.test/hooks"
+		webhook, _ := entity.NewWebhook(accountID, url)
+
+		webhookUseCase.On("Register", accountID, url).Return(webhook, nil)
+		c := controller.NewWebhook(webhookUseCase)
+
+		result, err := c.Register(systemContext(), accountID, url)
+
+		is.Nil(err)
+		is.Equal(webhook, result)
+	})
+}
+
+func TestRedeliverWebhook(t *testing.T) {
+	t.Parallel()
+
+	t.Run("should fail on validation", func(t *testing.T) {
+		is := require.New(t)
+
+		c := controller.NewWebhook(nil)
+
+		delivery, attempts, err := c.Redeliver(systemContext(), "invalid")
+
+		is.Nil(delivery)
+		is.Nil(attempts)
+		is.Error(err)
+	})
+
+	t.Run("should fail on redeliver", func(t *testing.T) {
+		is := require.New(t)
+		webhookUseCase := mock.NewMockWebhookUseCase()
+
+		deliveryID := uuid.NewV4().String()
+		webhookUseCase.On("Redeliver", deliveryID).Return(nil, errors.New("db error"))
+
+		c := controller.NewWebhook(webhookUseCase)
+
+		delivery, _, err := c.Redeliver(systemContext(), deliveryID)
+
+		is.Nil(delivery)
+		is.EqualError(err, "an error on redeliver webhook")
+	})
+
+	t.Run("should return the delivery with its attempts", func(t *testing.T) {
+		is := require.New(t)
+		webhookUseCase := mock.NewMockWebhookUseCase()
+
+		webhook, _ := entity.NewWebhook(uuid.NewV4().String(), "https://store.kbu.test/hooks")
+		delivery, _ := entity.NewWebhookDelivery(webhook.ID, uuid.NewV4().String(), entity.WebhookPaymentCompleted, []byte("{}"))
+		attempts := []*entity.WebhookAttempt{entity.NewWebhookAttempt(delivery.ID, 200, nil, 0)}
+
+		webhookUseCase.On("Redeliver", delivery.ID).Return(delivery, nil)
+		webhookUseCase.On("FindDelivery", delivery.ID).Return(delivery, attempts, nil)
+
+		c := controller.NewWebhook(webhookUseCase)
+
+		resultDelivery, resultAttempts, err := c.Redeliver(systemContext(), delivery.ID)
+
+		is.Nil(err)
+		is.Equal(delivery, resultDelivery)
+		is.Equal(attempts, resultAttempts)
+	})
+}
@@ -0,0 +1,31 @@
+package validator
+
+import (
+	"errors"
+
+	"github.com/EdlanioJ/kbu/payments/domain/entity"
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/go-ozzo/ozzo-validation/v4/is"
+)
+
+const maxAuditLimit = 100
+
+func AuditParams(filter *entity.AuditFilter, page, limit int) error {
+	err := validation.Errors{
+		"entity_type": validation.Validate(filter.EntityType, validation.In(
+			entity.AggregateTransaction,
+			entity.AggregateAccount,
+		)),
+		"entity_id": validation.Validate(filter.EntityID, is.UUIDv4),
+		"to": validation.Validate(filter.To, validation.By(func(value interface{}) error {
+			if !filter.From.IsZero() && !filter.To.IsZero() && !filter.To.After(filter.From) {
+				return errors.New("must be after from")
+			}
+			return nil
+		})),
+		"page":  validation.Validate(page, validation.Required, validation.Min(1)),
+		"limit": validation.Validate(limit, validation.Required, validation.Max(maxAuditLimit)),
+	}.Filter()
+
+	return invalid(err)
+}
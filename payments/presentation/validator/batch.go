@@ -0,0 +1,36 @@
+package validator
+
+import (
+	"github.com/EdlanioJ/kbu/payments/domain/entity"
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+)
+
+const MaxBatchItems = 1000
+
+func RegisterBatchParams(mode string, items int) error {
+	err := validation.Errors{
+		"mode":  validation.Validate(mode, validation.Required, validation.In(entity.BatchAtomic, entity.BatchBestEffort)),
+		"items": validation.Validate(items, validation.Required, validation.Max(MaxBatchItems)),
+	}.Filter()
+
+	return invalid(err)
+}
+
+func BatchItemParams(mode string, item *entity.BatchItem) error {
+	err := RegisterParams(item.AccountFromID, item.AccountToID, item.ExternalID, item.Type, item.Currency, item.Amount)
+
+	if err != nil {
+		return err
+	}
+
+	if mode != entity.BatchAtomic {
+		return nil
+	}
+
+	err = validation.Errors{
+		"type": validation.Validate(item.Type, validation.In(entity.TransactionToUser, entity.TransactionToStore).
+			Error("service payments cannot be registered in an atomic batch")),
+	}.Filter()
+
+	return invalid(err)
+}
@@ -0,0 +1,26 @@
+package validator
+
+import (
+	"github.com/EdlanioJ/kbu/payments/domain/entity"
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+)
+
+func invalid(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	errs, ok := err.(validation.Errors)
+
+	if !ok {
+		return entity.InvalidArgument(err.Error(), nil)
+	}
+
+	fields := make(map[string]string, len(errs))
+
+	for field, fieldErr := range errs {
+		fields[field] = fieldErr.Error()
+	}
+
+	return entity.InvalidArgument(err.Error(), fields)
+}
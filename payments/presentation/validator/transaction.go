@@ -1,6 +1,8 @@
 package validator
 
 import (
+	"errors"
+
 	"github.com/EdlanioJ/kbu/payments/domain/entity"
 	validation "github.com/go-ozzo/ozzo-validation/v4"
 	"github.com/go-ozzo/ozzo-validation/v4/is"
@@ -21,7 +23,7 @@ func RegisterParams(accountFrom, accountTo, externalID, transactionType, currenc
 		"amount":   validation.Validate(amount, validation.Required, validation.Min(float64(0))),
 	}.Filter()
 
-	return err
+	return invalid(err)
 }
 
 func GetParams(id string) error {
@@ -30,7 +32,7 @@ func GetParams(id string) error {
 		"id": validation.Validate(id, validation.Required, is.UUIDv4),
 	}.Filter()
 
-	return err
+	return invalid(err)
 }
 
 func GetAllParams(page int, limit int, sort string) error {
@@ -40,7 +42,7 @@ func GetAllParams(page int, limit int, sort string) error {
 		"sort":  validation.Validate(sort, validation.Required),
 	}.Filter()
 
-	return err
+	return invalid(err)
 }
 
 func GetByTypeParams(transactionID, transactionType string) error {
@@ -53,7 +55,7 @@ func GetByTypeParams(transactionID, transactionType string) error {
 		)),
 	}.Filter()
 
-	return err
+	return invalid(err)
 }
 
 func ListByTypeParams(transactionType string, page, limit int, sort string) error {
@@ -68,7 +70,7 @@ func ListByTypeParams(transactionType string, page, limit int, sort string) erro
 		"sort":  validation.Validate(sort, validation.Required),
 	}.Filter()
 
-	return err
+	return invalid(err)
 }
 
 func GetByExternalIDParams(transactionID, externalID string) error {
@@ -77,7 +79,7 @@ func GetByExternalIDParams(transactionID, externalID string) error {
 		"reference_id":   validation.Validate(externalID, validation.Required, is.UUIDv4),
 	}.Filter()
 
-	return err
+	return invalid(err)
 }
 
 func ListByExternalIDParams(externalID string, page, limit int, sort string) error {
@@ -88,7 +90,7 @@ func ListByExternalIDParams(externalID string, page, limit int, sort string) err
 		"sort":         validation.Validate(sort, validation.Required),
 	}.Filter()
 
-	return err
+	return invalid(err)
 }
 
 func GetByAccountFromParams(transactionID, accountID string) error {
@@ -97,7 +99,7 @@ func GetByAccountFromParams(transactionID, accountID string) error {
 		"account_id":     validation.Validate(accountID, validation.Required, is.UUIDv4),
 	}.Filter()
 
-	return err
+	return invalid(err)
 }
 
 func ListByAccountFromParams(accountID string, page, limit int, sort string) error {
@@ -108,7 +110,7 @@ func ListByAccountFromParams(accountID string, page, limit int, sort string) err
 		"sort":       validation.Validate(sort, validation.Required),
 	}.Filter()
 
-	return err
+	return invalid(err)
 }
 
 func GetByAccoutToParams(transactionID, accountID string) error {
@@ -117,7 +119,7 @@ func GetByAccoutToParams(transactionID, accountID string) error {
 		"account_id":     validation.Validate(accountID, validation.Required, is.UUIDv4),
 	}.Filter()
 
-	return err
+	return invalid(err)
 }
 
 func ListByAccoutToParams(accountID string, page, limit int, sort string) error {
@@ -128,7 +130,7 @@ func ListByAccoutToParams(accountID string, page, limit int, sort string) error
 		"sort":       validation.Validate(sort, validation.Required),
 	}.Filter()
 
-	return err
+	return invalid(err)
 }
 
 func CompleteParams(id string) error {
@@ -136,7 +138,7 @@ func CompleteParams(id string) error {
 		"transaction": validation.Validate(id, validation.Required, is.UUIDv4),
 	}.Filter()
 
-	return err
+	return invalid(err)
 }
 
 func ErrorParams(id string) error {
@@ -144,5 +146,43 @@ func ErrorParams(id string) error {
 		"transaction": validation.Validate(id, validation.Required, is.UUIDv4),
 	}.Filter()
 
-	return err
+	return invalid(err)
+}
+
+func ExportParams(filter *entity.TransactionFilter, resumeToken string) error {
+	err := validation.Errors{
+		"type": validation.Validate(filter.Type, validation.In(
+			entity.TransactionToService,
+			entity.TransactionToStore,
+			entity.TransactionToUser,
+		)),
+		"status": validation.Validate(filter.Status, validation.In(
+			entity.TransactionPending,
+			entity.TransactionCompleted,
+			entity.TransactionCanceled,
+		)),
+		"account_from": validation.Validate(filter.AccountFromID, is.UUIDv4),
+		"account_to":   validation.Validate(filter.AccountToID, is.UUIDv4),
+		"reference_id": validation.Validate(filter.ExternalID, is.UUIDv4),
+		"created_to": validation.Validate(filter.CreatedTo, validation.By(func(value interface{}) error {
+			if !filter.CreatedFrom.IsZero() && !filter.CreatedTo.IsZero() && !filter.CreatedTo.After(filter.CreatedFrom) {
+				return errors.New("must be after created_from")
+			}
+			return nil
+		})),
+		"resume_token": validation.Validate(resumeToken, validation.By(func(value interface{}) error {
+			_, err := entity.ParseExportCursor(resumeToken)
+			return err
+		})),
+	}.Filter()
+
+	return invalid(err)
+}
+
+func IdempotencyKeyParams(key string) error {
+	err := validation.Errors{
+		"idempotency_key": validation.Validate(key, validation.Length(0, entity.MaxIdempotencyKey), is.PrintableASCII),
+	}.Filter()
+
+	return invalid(err)
 }
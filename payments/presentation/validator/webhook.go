@@ -0,0 +1,71 @@
+package validator
+
+import (
+	"errors"
+	"net"
+	"net/url"
+	"strings"
+
+	"github.com/EdlanioJ/kbu/payments/domain/entity"
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/go-ozzo/ozzo-validation/v4/is"
+)
+
+var internalSuffixes = []string{"localhost", ".local", ".internal", ".localdomain"}
+
+// publicHTTPS only accepts https URLs whose host is neither an internal name
+// nor a private, loopback or link-local address, so webhooks cannot be used to
+// reach services inside the network. Names are only resolved when a webhook is
+// sent, the sender checks the address it dials.
+func publicHTTPS(value interface{}) error {
+	raw, _ := value.(string)
+
+	if raw == "" {
+		return nil
+	}
+
+	u, err := url.Parse(raw)
+
+	if err != nil {
+		return errors.New("must be a valid URL")
+	}
+
+	if u.Scheme != "https" {
+		return errors.New("must use https")
+	}
+
+	host := strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+
+	if host == "" || (!strings.Contains(host, ".") && net.ParseIP(host) == nil) {
+		return errors.New("must have a public host")
+	}
+
+	for _, suffix := range internalSuffixes {
+		if host == strings.TrimPrefix(suffix, ".") || strings.HasSuffix(host, suffix) {
+			return errors.New("must not point to an internal host")
+		}
+	}
+
+	if ip := net.ParseIP(host); ip != nil && entity.InternalAddress(ip) {
+		return errors.New("must not point to a private address")
+	}
+
+	return nil
+}
+
+func RegisterWebhookParams(accountID, url string) error {
+	err := validation.Errors{
+		"account_id": validation.Validate(accountID, validation.Required, is.UUIDv4),
+		"url":        validation.Validate(url, validation.Required, is.URL, validation.By(publicHTTPS)),
+	}.Filter()
+
+	return invalid(err)
+}
+
+func DeliveryParams(deliveryID string) error {
+	err := validation.Errors{
+		"delivery_id": validation.Validate(deliveryID, validation.Required, is.UUIDv4),
+	}.Filter()
+
+	return invalid(err)
+}